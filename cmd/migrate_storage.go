@@ -35,7 +35,7 @@ var CmdMigrateStorage = cli.Command{
 		cli.StringFlag{
 			Name:  "storage, s",
 			Value: "",
-			Usage: "New storage type: local (default) or minio",
+			Usage: "New storage type: local (default), minio, azureblob or gcs",
 		},
 		cli.StringFlag{
 			Name:  "path, p",
@@ -76,6 +76,66 @@ var CmdMigrateStorage = cli.Command{
 			Name:  "minio-use-ssl",
 			Usage: "Enable SSL for minio",
 		},
+		cli.StringFlag{
+			Name:  "minio-server-side-encryption",
+			Value: "",
+			Usage: "Minio server side encryption: sse-s3 or sse-c",
+		},
+		cli.StringFlag{
+			Name:  "minio-server-side-encryption-key",
+			Value: "",
+			Usage: "Minio base64 encoded 256 bit key for sse-c",
+		},
+		cli.StringFlag{
+			Name:  "azure-blob-endpoint",
+			Value: "",
+			Usage: "Azure blob storage endpoint",
+		},
+		cli.StringFlag{
+			Name:  "azure-blob-account-name",
+			Value: "",
+			Usage: "Azure blob storage account name",
+		},
+		cli.StringFlag{
+			Name:  "azure-blob-account-key",
+			Value: "",
+			Usage: "Azure blob storage account key",
+		},
+		cli.StringFlag{
+			Name:  "azure-blob-container",
+			Value: "",
+			Usage: "Azure blob storage container",
+		},
+		cli.StringFlag{
+			Name:  "azure-blob-base-path",
+			Value: "",
+			Usage: "Azure blob storage basepath on the container",
+		},
+		cli.StringFlag{
+			Name:  "gcs-endpoint",
+			Value: "",
+			Usage: "Google cloud storage endpoint (leave blank for the public endpoint)",
+		},
+		cli.StringFlag{
+			Name:  "gcs-bucket",
+			Value: "",
+			Usage: "Google cloud storage bucket",
+		},
+		cli.StringFlag{
+			Name:  "gcs-project-id",
+			Value: "",
+			Usage: "Google cloud storage project to create the bucket in",
+		},
+		cli.StringFlag{
+			Name:  "gcs-credentials-file",
+			Value: "",
+			Usage: "Google cloud storage service account credentials file",
+		},
+		cli.StringFlag{
+			Name:  "gcs-base-path",
+			Value: "",
+			Usage: "Google cloud storage basepath on the bucket",
+		},
 	},
 }
 
@@ -153,13 +213,35 @@ func runMigrateStorage(ctx *cli.Context) error {
 		dstStorage, err = storage.NewMinioStorage(
 			goCtx,
 			storage.MinioStorageConfig{
-				Endpoint:        ctx.String("minio-endpoint"),
-				AccessKeyID:     ctx.String("minio-access-key-id"),
-				SecretAccessKey: ctx.String("minio-secret-access-key"),
-				Bucket:          ctx.String("minio-bucket"),
-				Location:        ctx.String("minio-location"),
-				BasePath:        ctx.String("minio-base-path"),
-				UseSSL:          ctx.Bool("minio-use-ssl"),
+				Endpoint:                ctx.String("minio-endpoint"),
+				AccessKeyID:             ctx.String("minio-access-key-id"),
+				SecretAccessKey:         ctx.String("minio-secret-access-key"),
+				Bucket:                  ctx.String("minio-bucket"),
+				Location:                ctx.String("minio-location"),
+				BasePath:                ctx.String("minio-base-path"),
+				UseSSL:                  ctx.Bool("minio-use-ssl"),
+				ServerSideEncryption:    ctx.String("minio-server-side-encryption"),
+				ServerSideEncryptionKey: ctx.String("minio-server-side-encryption-key"),
+			})
+	case string(storage.AzureBlobStorageType):
+		dstStorage, err = storage.NewAzureBlobStorage(
+			goCtx,
+			storage.AzureBlobStorageConfig{
+				Endpoint:    ctx.String("azure-blob-endpoint"),
+				AccountName: ctx.String("azure-blob-account-name"),
+				AccountKey:  ctx.String("azure-blob-account-key"),
+				Container:   ctx.String("azure-blob-container"),
+				BasePath:    ctx.String("azure-blob-base-path"),
+			})
+	case string(storage.GCSStorageType):
+		dstStorage, err = storage.NewGCSStorage(
+			goCtx,
+			storage.GCSStorageConfig{
+				Endpoint:        ctx.String("gcs-endpoint"),
+				Bucket:          ctx.String("gcs-bucket"),
+				ProjectID:       ctx.String("gcs-project-id"),
+				CredentialsFile: ctx.String("gcs-credentials-file"),
+				BasePath:        ctx.String("gcs-base-path"),
 			})
 	default:
 		return fmt.Errorf("Unsupported storage type: %s", ctx.String("storage"))
@@ -0,0 +1,22 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"errors"
+)
+
+var (
+	// ErrRunnerNotExist indicates a runner not exist error
+	ErrRunnerNotExist = errors.New("Runner does not exist")
+	// ErrRunnerTokenNotExist indicates a runner registration token not exist error
+	ErrRunnerTokenNotExist = errors.New("Runner registration token does not exist")
+	// ErrRunNotExist indicates a run not exist error
+	ErrRunNotExist = errors.New("Run does not exist")
+	// ErrRunJobNotExist indicates a run job not exist error
+	ErrRunJobNotExist = errors.New("Run job does not exist")
+	// ErrRunJobStateConflict indicates that the job was modified concurrently
+	ErrRunJobStateConflict = errors.New("Run job state has changed")
+)
@@ -0,0 +1,136 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+func init() {
+	db.RegisterModel(new(ActionRun))
+}
+
+// ActionRun represents a single execution of a workflow file
+type ActionRun struct {
+	ID            int64  `xorm:"pk autoincr"`
+	RepoID        int64  `xorm:"INDEX NOT NULL"`
+	OwnerID       int64  `xorm:"INDEX NOT NULL"`
+	WorkflowID    string `xorm:"NOT NULL"` // the name of the workflow file
+	Name          string // the name of the workflow
+	Title         string
+	TriggerUserID int64  `xorm:"NOT NULL DEFAULT 0"`
+	Ref           string `xorm:"NOT NULL"`
+	CommitSHA     string `xorm:"VARCHAR(40) INDEX NOT NULL"`
+	Event         string `xorm:"NOT NULL"`
+	Status        Status `xorm:"INDEX NOT NULL DEFAULT 0"`
+
+	StartedUnix timeutil.TimeStamp
+	StoppedUnix timeutil.TimeStamp
+	CreatedUnix timeutil.TimeStamp `xorm:"created INDEX"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// InsertRun inserts a run and its jobs
+func InsertRun(ctx context.Context, run *ActionRun, jobs []*ActionRunJob) error {
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return err
+	}
+	defer committer.Close()
+
+	e := db.GetEngine(ctx)
+
+	run.Status = StatusWaiting
+	if _, err := e.Insert(run); err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		job.RunID = run.ID
+		job.RepoID = run.RepoID
+		job.OwnerID = run.OwnerID
+		job.CommitSHA = run.CommitSHA
+		if len(job.Needs) > 0 {
+			job.Status = StatusBlocked
+		} else {
+			job.Status = StatusWaiting
+		}
+	}
+	if _, err := e.Insert(jobs); err != nil {
+		return err
+	}
+
+	return committer.Commit()
+}
+
+// GetRunByID gets a run by id
+func GetRunByID(ctx context.Context, id int64) (*ActionRun, error) {
+	run := &ActionRun{}
+	has, err := db.GetEngine(ctx).ID(id).Get(run)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrRunNotExist
+	}
+	return run, nil
+}
+
+// GetRunByRepoAndID gets a run of the repository by id
+func GetRunByRepoAndID(ctx context.Context, repoID, id int64) (*ActionRun, error) {
+	run, err := GetRunByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if run.RepoID != repoID {
+		return nil, ErrRunNotExist
+	}
+	return run, nil
+}
+
+// UpdateRunStatus updates the status of the run and its start and stop times
+func UpdateRunStatus(ctx context.Context, run *ActionRun, status Status) error {
+	if run.Status == status {
+		return nil
+	}
+
+	now := timeutil.TimeStampNow()
+	if run.StartedUnix == 0 && status.HasRun() {
+		run.StartedUnix = now
+	}
+	if status.IsDone() {
+		run.StoppedUnix = now
+	}
+	run.Status = status
+
+	_, err := db.GetEngine(ctx).ID(run.ID).Cols("status", "started_unix", "stopped_unix").Update(run)
+	return err
+}
+
+// FindRunOptions are options for FindRuns
+type FindRunOptions struct {
+	db.ListOptions
+	RepoID int64
+	Status Status
+}
+
+// FindRuns gets the runs of a repository, newest first
+func FindRuns(ctx context.Context, opts *FindRunOptions) ([]*ActionRun, int64, error) {
+	sess := db.GetEngine(ctx).Where("repo_id = ?", opts.RepoID)
+	if opts.Status != StatusUnknown {
+		sess = sess.And("status = ?", opts.Status)
+	}
+	sess = sess.Desc("id")
+	if opts.Page > 0 {
+		sess = db.SetSessionPagination(sess, opts)
+	}
+
+	runs := make([]*ActionRun, 0, 10)
+	count, err := sess.FindAndCount(&runs)
+	return runs, count, err
+}
@@ -0,0 +1,239 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/login"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	gouuid "github.com/google/uuid"
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(ActionRunJob))
+}
+
+// ActionRunJob represents a job of a run which is executed by a runner
+type ActionRunJob struct {
+	ID              int64    `xorm:"pk autoincr"`
+	RunID           int64    `xorm:"INDEX NOT NULL"`
+	RepoID          int64    `xorm:"INDEX NOT NULL"`
+	OwnerID         int64    `xorm:"INDEX NOT NULL"`
+	CommitSHA       string   `xorm:"VARCHAR(40) NOT NULL"`
+	JobID           string   `xorm:"VARCHAR(255) NOT NULL"` // the key of the job in the workflow file
+	Name            string   `xorm:"VARCHAR(255)"`
+	Needs           []string `xorm:"TEXT JSON"`
+	RunsOn          []string `xorm:"TEXT JSON"`
+	WorkflowPayload string   `xorm:"LONGTEXT"` // the workflow file reduced to this job
+	Status          Status   `xorm:"INDEX NOT NULL DEFAULT 0"`
+	RunnerID        int64    `xorm:"INDEX NOT NULL DEFAULT 0"`
+	LogLength       int64    `xorm:"NOT NULL DEFAULT 0"`
+	LogChunks       int64    `xorm:"NOT NULL DEFAULT 0"`
+
+	// the token lets the runner clone the repository while the job is running
+	Token            string `xorm:"-"`
+	TokenHash        string // sha256 of token
+	TokenSalt        string
+	TokenLastEight   string `xorm:"INDEX token_last_eight"`
+	TokenExpiresUnix timeutil.TimeStamp
+
+	StartedUnix timeutil.TimeStamp
+	StoppedUnix timeutil.TimeStamp
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated INDEX"`
+}
+
+// LogChunkPath returns the relative storage path of a log chunk
+func (job *ActionRunJob) LogChunkPath(chunk int64) string {
+	return fmt.Sprintf("%d/%d/%d.log", job.RunID, job.ID, chunk)
+}
+
+// GetRunJobByID gets a job by id
+func GetRunJobByID(ctx context.Context, id int64) (*ActionRunJob, error) {
+	job := &ActionRunJob{}
+	has, err := db.GetEngine(ctx).ID(id).Get(job)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrRunJobNotExist
+	}
+	return job, nil
+}
+
+// GetRunJobsByRunID gets all jobs of a run
+func GetRunJobsByRunID(ctx context.Context, runID int64) ([]*ActionRunJob, error) {
+	jobs := make([]*ActionRunJob, 0, 5)
+	return jobs, db.GetEngine(ctx).
+		Where("run_id = ?", runID).
+		Asc("id").
+		Find(&jobs)
+}
+
+// FindWaitingJobsForRunner gets the oldest waiting jobs in the scope of the runner.
+// The runs-on labels of the jobs are not checked.
+func FindWaitingJobsForRunner(ctx context.Context, runner *ActionRunner, limit int) ([]*ActionRunJob, error) {
+	cond := builder.Eq{"status": StatusWaiting}
+	if runner.RepoID != 0 {
+		cond["repo_id"] = runner.RepoID
+	}
+	if runner.OwnerID != 0 {
+		cond["owner_id"] = runner.OwnerID
+	}
+
+	jobs := make([]*ActionRunJob, 0, limit)
+	return jobs, db.GetEngine(ctx).
+		Where(cond).
+		Asc("id").
+		Limit(limit).
+		Find(&jobs)
+}
+
+// FindStaleRunningJobs gets all running jobs which were not updated since the given time
+func FindStaleRunningJobs(ctx context.Context, olderThan timeutil.TimeStamp) ([]*ActionRunJob, error) {
+	jobs := make([]*ActionRunJob, 0, 10)
+	return jobs, db.GetEngine(ctx).
+		Where("status = ? AND updated_unix < ?", StatusRunning, olderThan).
+		Find(&jobs)
+}
+
+// UpdateRunJobStatus changes the status of the job if it still has the expected old status.
+// ErrRunJobStateConflict is returned if the job was modified in the meantime.
+func UpdateRunJobStatus(ctx context.Context, job *ActionRunJob, status Status) error {
+	now := timeutil.TimeStampNow()
+
+	update := &ActionRunJob{
+		Status:      status,
+		RunnerID:    job.RunnerID,
+		StartedUnix: job.StartedUnix,
+		StoppedUnix: job.StoppedUnix,
+	}
+	if status == StatusRunning && update.StartedUnix == 0 {
+		update.StartedUnix = now
+	}
+	cols := []string{"status", "runner_id", "started_unix", "stopped_unix"}
+	if status.IsDone() {
+		update.StoppedUnix = now
+		// the token of the job is revoked as soon as it is done
+		cols = append(cols, "token_hash", "token_salt", "token_last_eight", "token_expires_unix")
+	}
+
+	n, err := db.GetEngine(ctx).
+		Where("id = ? AND status = ?", job.ID, job.Status).
+		Cols(cols...).
+		Update(update)
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return ErrRunJobStateConflict
+	}
+
+	job.Status = update.Status
+	job.StartedUnix = update.StartedUnix
+	job.StoppedUnix = update.StoppedUnix
+	if status.IsDone() {
+		job.Token, job.TokenHash, job.TokenSalt, job.TokenLastEight = "", "", "", ""
+		job.TokenExpiresUnix = 0
+	}
+	return nil
+}
+
+// GenerateRunJobToken generates the token of a running job which expires after lifetime
+func GenerateRunJobToken(ctx context.Context, job *ActionRunJob, lifetime time.Duration) error {
+	salt, err := util.RandomString(10)
+	if err != nil {
+		return err
+	}
+	token := base.EncodeSha1(gouuid.New().String())
+	update := &ActionRunJob{
+		TokenHash:        login.HashToken(token, salt),
+		TokenSalt:        salt,
+		TokenLastEight:   token[len(token)-8:],
+		TokenExpiresUnix: timeutil.TimeStamp(time.Now().Add(lifetime).Unix()),
+	}
+
+	n, err := db.GetEngine(ctx).
+		Where("id = ? AND status = ?", job.ID, StatusRunning).
+		Cols("token_hash", "token_salt", "token_last_eight", "token_expires_unix").
+		Update(update)
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return ErrRunJobStateConflict
+	}
+
+	job.Token = token
+	job.TokenHash = update.TokenHash
+	job.TokenSalt = update.TokenSalt
+	job.TokenLastEight = update.TokenLastEight
+	job.TokenExpiresUnix = update.TokenExpiresUnix
+	return nil
+}
+
+// GetRunJobByToken gets the running job of an unexpired token
+func GetRunJobByToken(ctx context.Context, token string) (*ActionRunJob, error) {
+	if len(token) < 8 {
+		return nil, ErrRunJobNotExist
+	}
+
+	jobs := make([]*ActionRunJob, 0, 1)
+	if err := db.GetEngine(ctx).
+		Where("token_last_eight = ? AND status = ? AND token_expires_unix > ?", token[len(token)-8:], StatusRunning, timeutil.TimeStampNow()).
+		Find(&jobs); err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if subtle.ConstantTimeCompare([]byte(job.TokenHash), []byte(login.HashToken(token, job.TokenSalt))) == 1 {
+			return job, nil
+		}
+	}
+	return nil, ErrRunJobNotExist
+}
+
+// ClaimRunJob assigns a waiting job to the runner
+func ClaimRunJob(ctx context.Context, job *ActionRunJob, runner *ActionRunner) error {
+	if job.Status != StatusWaiting {
+		return ErrRunJobStateConflict
+	}
+
+	oldRunnerID := job.RunnerID
+	job.RunnerID = runner.ID
+	if err := UpdateRunJobStatus(ctx, job, StatusRunning); err != nil {
+		job.RunnerID = oldRunnerID
+		return err
+	}
+	return nil
+}
+
+// AddRunJobLogChunk registers an additional log chunk of the given size.
+// ErrRunJobStateConflict is returned if another chunk was added in the meantime.
+func AddRunJobLogChunk(ctx context.Context, job *ActionRunJob, size int64) error {
+	n, err := db.GetEngine(ctx).
+		Where("id = ? AND log_chunks = ?", job.ID, job.LogChunks).
+		Incr("log_chunks").
+		Incr("log_length", size).
+		Update(&ActionRunJob{})
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return ErrRunJobStateConflict
+	}
+
+	job.LogChunks++
+	job.LogLength += size
+	return nil
+}
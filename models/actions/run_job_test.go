@@ -0,0 +1,136 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunnerRegistration(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	ctx := db.DefaultContext
+
+	_, err := GetLatestRunnerToken(ctx, 0, 1)
+	assert.ErrorIs(t, err, ErrRunnerTokenNotExist)
+
+	t1, err := GetOrCreateRunnerToken(ctx, 0, 1)
+	assert.NoError(t, err)
+	t2, err := GetOrCreateRunnerToken(ctx, 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, t1.Token, t2.Token)
+
+	t3, err := NewRunnerToken(ctx, 0, 1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, t1.Token, t3.Token)
+
+	_, err = GetRunnerToken(ctx, t1.Token)
+	assert.ErrorIs(t, err, ErrRunnerTokenNotExist)
+	_, err = GetRunnerToken(ctx, t3.Token)
+	assert.NoError(t, err)
+
+	r := &ActionRunner{Name: "runner", RepoID: 1, Labels: []string{"linux"}}
+	assert.NoError(t, CreateRunner(ctx, r))
+	assert.NotEmpty(t, r.UUID)
+	assert.NotEmpty(t, r.Token)
+
+	r2, err := GetRunnerByCredentials(ctx, r.UUID, r.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, r.ID, r2.ID)
+
+	_, err = GetRunnerByCredentials(ctx, r.UUID, "0000000000")
+	assert.ErrorIs(t, err, ErrRunnerNotExist)
+	_, err = GetRunnerByCredentials(ctx, "", r.Token)
+	assert.ErrorIs(t, err, ErrRunnerNotExist)
+}
+
+func TestRunJobs(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	ctx := db.DefaultContext
+
+	run := &ActionRun{RepoID: 1, OwnerID: 2, WorkflowID: "ci.yml", Ref: "refs/heads/master", CommitSHA: "65f1bf27bc3bf70f64657658635e66094edbcb4d", Event: "push"}
+	jobs := []*ActionRunJob{
+		{JobID: "test", Name: "test", RunsOn: []string{"linux"}},
+		{JobID: "build", Name: "build", Needs: []string{"test"}},
+	}
+	assert.NoError(t, InsertRun(ctx, run, jobs))
+	assert.Equal(t, StatusWaiting, run.Status)
+	assert.Equal(t, StatusWaiting, jobs[0].Status)
+	assert.Equal(t, StatusBlocked, jobs[1].Status)
+
+	loaded, err := GetRunJobsByRunID(ctx, run.ID)
+	assert.NoError(t, err)
+	assert.Len(t, loaded, 2)
+
+	_, err = GetRunByRepoAndID(ctx, 2, run.ID)
+	assert.ErrorIs(t, err, ErrRunNotExist)
+
+	other := &ActionRunner{ID: 10, RepoID: 3, Labels: []string{"linux"}}
+	candidates, err := FindWaitingJobsForRunner(ctx, other, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, candidates)
+
+	runner := &ActionRunner{ID: 11, Labels: []string{"Linux"}}
+	candidates, err = FindWaitingJobsForRunner(ctx, runner, 10)
+	assert.NoError(t, err)
+	assert.Len(t, candidates, 1)
+	assert.True(t, runner.CanRunJob(candidates[0]))
+	assert.False(t, (&ActionRunner{ID: 12}).CanRunJob(candidates[0]))
+
+	job := candidates[0]
+	stale := *job
+	assert.NoError(t, ClaimRunJob(ctx, job, runner))
+	assert.Equal(t, StatusRunning, job.Status)
+	assert.EqualValues(t, 11, job.RunnerID)
+	assert.NotZero(t, job.StartedUnix)
+
+	// a second runner with an outdated view must not get the job
+	assert.ErrorIs(t, ClaimRunJob(ctx, &stale, &ActionRunner{ID: 12}), ErrRunJobStateConflict)
+
+	assert.NoError(t, AddRunJobLogChunk(ctx, job, 10))
+	assert.NoError(t, AddRunJobLogChunk(ctx, job, 5))
+	assert.EqualValues(t, 2, job.LogChunks)
+	assert.EqualValues(t, 15, job.LogLength)
+	assert.ErrorIs(t, AddRunJobLogChunk(ctx, &stale, 1), ErrRunJobStateConflict)
+
+	assert.NoError(t, GenerateRunJobToken(ctx, job, -time.Minute))
+	_, err = GetRunJobByToken(ctx, job.Token)
+	assert.ErrorIs(t, err, ErrRunJobNotExist)
+
+	assert.NoError(t, GenerateRunJobToken(ctx, job, time.Hour))
+	assert.NotEmpty(t, job.Token)
+	byToken, err := GetRunJobByToken(ctx, job.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, job.ID, byToken.ID)
+	_, err = GetRunJobByToken(ctx, "0000000000")
+	assert.ErrorIs(t, err, ErrRunJobNotExist)
+	token := job.Token
+
+	assert.NoError(t, UpdateRunJobStatus(ctx, job, StatusSuccess))
+	assert.NotZero(t, job.StoppedUnix)
+
+	// the token is revoked once the job is done
+	_, err = GetRunJobByToken(ctx, token)
+	assert.ErrorIs(t, err, ErrRunJobNotExist)
+	assert.ErrorIs(t, GenerateRunJobToken(ctx, job, time.Hour), ErrRunJobStateConflict)
+
+	job, err = GetRunJobByID(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusSuccess, job.Status)
+	assert.EqualValues(t, 15, job.LogLength)
+	assert.Equal(t, "1/1/1.log", job.LogChunkPath(1))
+
+	runs, count, err := FindRuns(ctx, &FindRunOptions{RepoID: 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	assert.Len(t, runs, 1)
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/login"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	gouuid "github.com/google/uuid"
+)
+
+func init() {
+	db.RegisterModel(new(ActionRunner))
+}
+
+// ActionRunner represents an external runner which executes jobs
+type ActionRunner struct {
+	ID             int64    `xorm:"pk autoincr"`
+	UUID           string   `xorm:"CHAR(36) UNIQUE NOT NULL"`
+	Name           string   `xorm:"VARCHAR(255)"`
+	OwnerID        int64    `xorm:"INDEX NOT NULL DEFAULT 0"` // if zero and RepoID is zero, the runner is available for all repositories
+	RepoID         int64    `xorm:"INDEX NOT NULL DEFAULT 0"`
+	Labels         []string `xorm:"TEXT JSON"`
+	Token          string   `xorm:"-"`
+	TokenHash      string   `xorm:"UNIQUE"` // sha256 of token
+	TokenSalt      string
+	TokenLastEight string `xorm:"token_last_eight"`
+
+	LastOnlineUnix timeutil.TimeStamp `xorm:"INDEX"`
+	CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix    timeutil.TimeStamp `xorm:"updated"`
+}
+
+// CanRunJob returns true if the runner is allowed to and capable of running the job
+func (r *ActionRunner) CanRunJob(job *ActionRunJob) bool {
+	if r.RepoID != 0 && r.RepoID != job.RepoID {
+		return false
+	}
+	if r.OwnerID != 0 && r.OwnerID != job.OwnerID {
+		return false
+	}
+	for _, label := range job.RunsOn {
+		if !util.IsStringInSlice(label, r.Labels, true) {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateRunner inserts a runner and generates its secret token
+func CreateRunner(ctx context.Context, r *ActionRunner) error {
+	salt, err := util.RandomString(10)
+	if err != nil {
+		return err
+	}
+	r.UUID = gouuid.New().String()
+	r.TokenSalt = salt
+	r.Token = base.EncodeSha1(gouuid.New().String())
+	r.TokenHash = login.HashToken(r.Token, r.TokenSalt)
+	r.TokenLastEight = r.Token[len(r.Token)-8:]
+	r.LastOnlineUnix = timeutil.TimeStampNow()
+	_, err = db.GetEngine(ctx).Insert(r)
+	return err
+}
+
+// GetRunnerByUUID gets a runner by its uuid
+func GetRunnerByUUID(ctx context.Context, uuid string) (*ActionRunner, error) {
+	r := &ActionRunner{}
+	has, err := db.GetEngine(ctx).Where("uuid = ?", uuid).Get(r)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrRunnerNotExist
+	}
+	return r, nil
+}
+
+// GetRunnerByCredentials gets a runner by its uuid and verifies the token
+func GetRunnerByCredentials(ctx context.Context, uuid, token string) (*ActionRunner, error) {
+	if uuid == "" || len(token) < 8 {
+		return nil, ErrRunnerNotExist
+	}
+
+	r, err := GetRunnerByUUID(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+	if r.TokenLastEight != token[len(token)-8:] {
+		return nil, ErrRunnerNotExist
+	}
+	if subtle.ConstantTimeCompare([]byte(r.TokenHash), []byte(login.HashToken(token, r.TokenSalt))) != 1 {
+		return nil, ErrRunnerNotExist
+	}
+	return r, nil
+}
+
+// UpdateRunnerLastOnline marks the runner as online
+func UpdateRunnerLastOnline(ctx context.Context, r *ActionRunner) error {
+	r.LastOnlineUnix = timeutil.TimeStampNow()
+	_, err := db.GetEngine(ctx).ID(r.ID).Cols("last_online_unix").NoAutoTime().Update(r)
+	return err
+}
+
+// DeleteRunnerByID deletes a runner
+func DeleteRunnerByID(ctx context.Context, id int64) error {
+	_, err := db.GetEngine(ctx).ID(id).Delete(&ActionRunner{})
+	return err
+}
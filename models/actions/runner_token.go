@@ -0,0 +1,100 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	gouuid "github.com/google/uuid"
+)
+
+func init() {
+	db.RegisterModel(new(ActionRunnerToken))
+}
+
+// ActionRunnerToken is used to register new runners.
+// The scope of the registered runner is inherited from the token.
+type ActionRunnerToken struct {
+	ID       int64  `xorm:"pk autoincr"`
+	Token    string `xorm:"UNIQUE"`
+	OwnerID  int64  `xorm:"INDEX NOT NULL DEFAULT 0"`
+	RepoID   int64  `xorm:"INDEX NOT NULL DEFAULT 0"`
+	IsActive bool   `xorm:"NOT NULL DEFAULT false"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// GetRunnerToken gets an active registration token by its value
+func GetRunnerToken(ctx context.Context, token string) (*ActionRunnerToken, error) {
+	t := &ActionRunnerToken{}
+	has, err := db.GetEngine(ctx).Where("token = ? AND is_active = ?", token, true).Get(t)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrRunnerTokenNotExist
+	}
+	return t, nil
+}
+
+// GetLatestRunnerToken gets the active registration token of the scope
+func GetLatestRunnerToken(ctx context.Context, ownerID, repoID int64) (*ActionRunnerToken, error) {
+	t := &ActionRunnerToken{}
+	has, err := db.GetEngine(ctx).
+		Where("owner_id = ? AND repo_id = ? AND is_active = ?", ownerID, repoID, true).
+		Desc("id").
+		Get(t)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrRunnerTokenNotExist
+	}
+	return t, nil
+}
+
+// NewRunnerToken creates a new registration token for the scope and deactivates all older ones
+func NewRunnerToken(ctx context.Context, ownerID, repoID int64) (*ActionRunnerToken, error) {
+	txCtx, committer, err := db.TxContext()
+	if err != nil {
+		return nil, err
+	}
+	defer committer.Close()
+
+	e := db.GetEngine(txCtx)
+
+	if _, err := e.
+		Where("owner_id = ? AND repo_id = ?", ownerID, repoID).
+		Cols("is_active").
+		Update(&ActionRunnerToken{IsActive: false}); err != nil {
+		return nil, err
+	}
+
+	t := &ActionRunnerToken{
+		Token:    base.EncodeSha1(gouuid.New().String()),
+		OwnerID:  ownerID,
+		RepoID:   repoID,
+		IsActive: true,
+	}
+	if _, err := e.Insert(t); err != nil {
+		return nil, err
+	}
+
+	return t, committer.Commit()
+}
+
+// GetOrCreateRunnerToken gets the active registration token of the scope or creates a new one
+func GetOrCreateRunnerToken(ctx context.Context, ownerID, repoID int64) (*ActionRunnerToken, error) {
+	t, err := GetLatestRunnerToken(ctx, ownerID, repoID)
+	if err == ErrRunnerTokenNotExist {
+		return NewRunnerToken(ctx, ownerID, repoID)
+	}
+	return t, err
+}
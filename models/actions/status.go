@@ -0,0 +1,120 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// Status represents the state of a run or a job
+type Status int
+
+// List of all run and job states
+const (
+	StatusUnknown   Status = iota // 0, consistent with runner protocol defaults
+	StatusWaiting                 // 1, the job waits for a runner
+	StatusRunning                 // 2, the job was picked up by a runner
+	StatusSuccess                 // 3
+	StatusFailure                 // 4
+	StatusCancelled               // 5
+	StatusSkipped                 // 6, the job was skipped because one of its needs did not succeed
+	StatusBlocked                 // 7, the job waits for its needs to finish
+)
+
+var statusNames = map[Status]string{
+	StatusUnknown:   "unknown",
+	StatusWaiting:   "waiting",
+	StatusRunning:   "running",
+	StatusSuccess:   "success",
+	StatusFailure:   "failure",
+	StatusCancelled: "cancelled",
+	StatusSkipped:   "skipped",
+	StatusBlocked:   "blocked",
+}
+
+// String returns the name of the status
+func (s Status) String() string {
+	return statusNames[s]
+}
+
+// StatusFromString returns the status with the given name
+func StatusFromString(name string) Status {
+	for s, n := range statusNames {
+		if n == name {
+			return s
+		}
+	}
+	return StatusUnknown
+}
+
+// IsDone returns true if the status is a final state
+func (s Status) IsDone() bool {
+	switch s {
+	case StatusSuccess, StatusFailure, StatusCancelled, StatusSkipped:
+		return true
+	}
+	return false
+}
+
+// HasRun returns true if a job with this status was executed by a runner
+func (s Status) HasRun() bool {
+	switch s {
+	case StatusRunning, StatusSuccess, StatusFailure:
+		return true
+	}
+	return false
+}
+
+// CommitStatusState maps the status to a commit status state
+func (s Status) CommitStatusState() api.CommitStatusState {
+	switch s {
+	case StatusSuccess:
+		return api.CommitStatusSuccess
+	case StatusFailure:
+		return api.CommitStatusFailure
+	case StatusCancelled:
+		return api.CommitStatusError
+	case StatusSkipped:
+		return api.CommitStatusWarning
+	}
+	return api.CommitStatusPending
+}
+
+// AggregateStatus calculates the status of a run from the states of its jobs
+func AggregateStatus(states []Status) Status {
+	if len(states) == 0 {
+		return StatusUnknown
+	}
+
+	allDone := true
+	hasRunning := false
+	hasFailure := false
+	hasCancelled := false
+	for _, s := range states {
+		if !s.IsDone() {
+			allDone = false
+		}
+		switch s {
+		case StatusRunning:
+			hasRunning = true
+		case StatusFailure:
+			hasFailure = true
+		case StatusCancelled:
+			hasCancelled = true
+		}
+	}
+
+	switch {
+	case hasRunning:
+		return StatusRunning
+	case !allDone:
+		return StatusWaiting
+	case hasCancelled:
+		return StatusCancelled
+	case hasFailure:
+		return StatusFailure
+	}
+	return StatusSuccess
+}
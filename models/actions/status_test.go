@@ -0,0 +1,35 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateStatus(t *testing.T) {
+	cases := []struct {
+		States   []Status
+		Expected Status
+	}{
+		{nil, StatusUnknown},
+		{[]Status{StatusWaiting, StatusBlocked}, StatusWaiting},
+		{[]Status{StatusSuccess, StatusRunning, StatusBlocked}, StatusRunning},
+		{[]Status{StatusSuccess, StatusWaiting}, StatusWaiting},
+		{[]Status{StatusSuccess, StatusSkipped}, StatusSuccess},
+		{[]Status{StatusSuccess, StatusFailure, StatusSkipped}, StatusFailure},
+		{[]Status{StatusCancelled, StatusFailure}, StatusCancelled},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.Expected, AggregateStatus(c.States), "%v", c.States)
+	}
+
+	for s, name := range statusNames {
+		assert.Equal(t, s, StatusFromString(name))
+	}
+	assert.Equal(t, StatusUnknown, StatusFromString("invalid"))
+}
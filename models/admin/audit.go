@@ -0,0 +1,146 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// AuditAction describes what has been done in an audit event
+type AuditAction string
+
+// The audited actions
+const (
+	AuditUserSignIn           AuditAction = "user_sign_in"
+	AuditUserSignInFailed     AuditAction = "user_sign_in_failed"
+	AuditUserSignOut          AuditAction = "user_sign_out"
+	AuditUserCreate           AuditAction = "user_create"
+	AuditUserUpdate           AuditAction = "user_update"
+	AuditUserDelete           AuditAction = "user_delete"
+	AuditUserPasswordChange   AuditAction = "user_password_change"
+	AuditUserTwoFactorEnable  AuditAction = "user_two_factor_enable"
+	AuditUserTwoFactorDisable AuditAction = "user_two_factor_disable"
+
+	AuditAccessTokenCreate AuditAction = "access_token_create"
+	AuditAccessTokenDelete AuditAction = "access_token_delete"
+
+	AuditOrgCreate            AuditAction = "org_create"
+	AuditOrgUpdate            AuditAction = "org_update"
+	AuditOrgDelete            AuditAction = "org_delete"
+	AuditOrgMemberRemove      AuditAction = "org_member_remove"
+	AuditTeamCreate           AuditAction = "team_create"
+	AuditTeamUpdate           AuditAction = "team_update"
+	AuditTeamDelete           AuditAction = "team_delete"
+	AuditTeamMemberAdd        AuditAction = "team_member_add"
+	AuditTeamMemberRemove     AuditAction = "team_member_remove"
+	AuditTeamRepositoryAdd    AuditAction = "team_repository_add"
+	AuditTeamRepositoryRemove AuditAction = "team_repository_remove"
+
+	AuditRepoCreate          AuditAction = "repo_create"
+	AuditRepoMigrate         AuditAction = "repo_migrate"
+	AuditRepoDelete          AuditAction = "repo_delete"
+	AuditRepoRename          AuditAction = "repo_rename"
+	AuditRepoTransfer        AuditAction = "repo_transfer"
+	AuditRepoTransferPending AuditAction = "repo_transfer_pending"
+	AuditRepoBranchDelete    AuditAction = "repo_branch_delete"
+
+	AuditBranchProtectionCreate AuditAction = "branch_protection_create"
+	AuditBranchProtectionUpdate AuditAction = "branch_protection_update"
+	AuditBranchProtectionDelete AuditAction = "branch_protection_delete"
+
+	AuditDeployKeyAdd    AuditAction = "deploy_key_add"
+	AuditDeployKeyRemove AuditAction = "deploy_key_remove"
+)
+
+// AuditEvent represents an administrative or security relevant event.
+// Audit events are never updated or deleted once they are written.
+type AuditEvent struct {
+	ID         int64       `xorm:"pk autoincr"`
+	Action     AuditAction `xorm:"VARCHAR(50) INDEX NOT NULL"`
+	ActorID    int64       `xorm:"INDEX"`
+	ActorName  string
+	IPAddress  string `xorm:"VARCHAR(64)"`
+	TargetType string `xorm:"VARCHAR(30) INDEX"`
+	TargetID   int64  `xorm:"INDEX"`
+	TargetName string
+	// Before and After hold JSON encoded snapshots of the changed target
+	Before      string             `xorm:"TEXT"`
+	After       string             `xorm:"TEXT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+func init() {
+	db.RegisterModel(new(AuditEvent))
+}
+
+// InsertAuditEvent writes a new audit event
+func InsertAuditEvent(ctx context.Context, event *AuditEvent) error {
+	return db.Insert(ctx, event)
+}
+
+// FindAuditEventsOptions represents the filters of audit events
+type FindAuditEventsOptions struct {
+	db.ListOptions
+	Action     AuditAction
+	ActorName  string
+	TargetType string
+	TargetID   int64
+	IPAddress  string
+	Since      timeutil.TimeStamp
+	Before     timeutil.TimeStamp
+}
+
+func (opts *FindAuditEventsOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.Action != "" {
+		cond = cond.And(builder.Eq{"action": opts.Action})
+	}
+	if opts.ActorName != "" {
+		cond = cond.And(builder.Eq{"actor_name": opts.ActorName})
+	}
+	if opts.TargetType != "" {
+		cond = cond.And(builder.Eq{"target_type": opts.TargetType})
+	}
+	if opts.TargetID > 0 {
+		cond = cond.And(builder.Eq{"target_id": opts.TargetID})
+	}
+	if opts.IPAddress != "" {
+		cond = cond.And(builder.Eq{"ip_address": opts.IPAddress})
+	}
+	if opts.Since > 0 {
+		cond = cond.And(builder.Gte{"created_unix": opts.Since})
+	}
+	if opts.Before > 0 {
+		cond = cond.And(builder.Lt{"created_unix": opts.Before})
+	}
+	return cond
+}
+
+// FindAuditEvents returns a page of the audit events matching the options, newest first
+func FindAuditEvents(opts *FindAuditEventsOptions) ([]*AuditEvent, int64, error) {
+	sess := db.SetSessionPagination(db.GetEngine(db.DefaultContext).Where(opts.toConds()), opts).
+		Desc("id")
+
+	events := make([]*AuditEvent, 0, opts.PageSize)
+	count, err := sess.FindAndCount(&events)
+	return events, count, err
+}
+
+// IterateAuditEvents calls fn for every audit event matching the options, oldest first.
+// Pagination options are ignored.
+func IterateAuditEvents(ctx context.Context, opts *FindAuditEventsOptions, fn func(event *AuditEvent) error) error {
+	return db.GetEngine(ctx).Where(opts.toConds()).
+		Asc("id").
+		BufferSize(setting.Database.IterateBufferSize).
+		Iterate(new(AuditEvent), func(idx int, bean interface{}) error {
+			return fn(bean.(*AuditEvent))
+		})
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindAuditEvents(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	events, total, err := FindAuditEvents(&FindAuditEventsOptions{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+	if assert.Len(t, events, 3) {
+		assert.EqualValues(t, 3, events[0].ID)
+		assert.EqualValues(t, 1, events[2].ID)
+	}
+
+	events, total, err = FindAuditEvents(&FindAuditEventsOptions{ActorName: "user1"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	assert.Len(t, events, 2)
+
+	events, _, err = FindAuditEvents(&FindAuditEventsOptions{Action: AuditUserSignInFailed, IPAddress: "10.0.0.2"})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.EqualValues(t, 3, events[0].ID)
+	}
+
+	events, _, err = FindAuditEvents(&FindAuditEventsOptions{TargetType: "team", TargetID: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, `{"member":"user2"}`, events[0].After)
+	}
+
+	events, total, err = FindAuditEvents(&FindAuditEventsOptions{Since: 1640000100, Before: 1640000200})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	if assert.Len(t, events, 1) {
+		assert.EqualValues(t, 2, events[0].ID)
+	}
+
+	events, total, err = FindAuditEvents(&FindAuditEventsOptions{ListOptions: db.ListOptions{Page: 2, PageSize: 2}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+	if assert.Len(t, events, 1) {
+		assert.EqualValues(t, 1, events[0].ID)
+	}
+}
+
+func TestInsertAndIterateAuditEvents(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	event := &AuditEvent{
+		Action:     AuditDeployKeyAdd,
+		ActorID:    2,
+		ActorName:  "user2",
+		TargetType: "deploy_key",
+		TargetID:   1,
+		TargetName: "key",
+	}
+	assert.NoError(t, InsertAuditEvent(db.DefaultContext, event))
+	unittest.AssertExistsAndLoadBean(t, &AuditEvent{ID: event.ID, Action: AuditDeployKeyAdd})
+
+	var ids []int64
+	assert.NoError(t, IterateAuditEvents(db.DefaultContext, &FindAuditEventsOptions{ActorName: "user2"}, func(event *AuditEvent) error {
+		ids = append(ids, event.ID)
+		return nil
+	}))
+	assert.Equal(t, []int64{3, event.ID}, ids)
+}
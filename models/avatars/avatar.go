@@ -53,7 +53,7 @@ func HashEmail(email string) string {
 
 // GetEmailForHash converts a provided md5sum to the email
 func GetEmailForHash(md5Sum string) (string, error) {
-	return cache.GetString("Avatar:"+md5Sum, func() (string, error) {
+	return cache.GetString(db.DefaultContext, "Avatar:"+md5Sum, func() (string, error) {
 		emailHash := EmailHash{
 			Hash: strings.ToLower(strings.TrimSpace(md5Sum)),
 		}
@@ -84,7 +84,7 @@ func LibravatarURL(email string) (*url.URL, error) {
 func saveEmailHash(email string) string {
 	lowerEmail := strings.ToLower(strings.TrimSpace(email))
 	emailHash := HashEmail(lowerEmail)
-	_, _ = cache.GetString("Avatar:"+emailHash, func() (string, error) {
+	_, _ = cache.GetString(db.DefaultContext, "Avatar:"+emailHash, func() (string, error) {
 		emailHash := &EmailHash{
 			Email: lowerEmail,
 			Hash:  emailHash,
@@ -7,6 +7,7 @@ package models
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -21,10 +22,12 @@ import (
 )
 
 // ProtectedBranch struct
+// BranchName is the name of the rule, either the name of a branch or a glob pattern matching several branches.
 type ProtectedBranch struct {
 	ID                            int64  `xorm:"pk autoincr"`
 	RepoID                        int64  `xorm:"UNIQUE(s)"`
 	BranchName                    string `xorm:"UNIQUE(s)"`
+	Priority                      int64  `xorm:"NOT NULL DEFAULT 0"`
 	CanPush                       bool   `xorm:"NOT NULL DEFAULT false"`
 	EnableWhitelist               bool
 	WhitelistUserIDs              []int64  `xorm:"JSON TEXT"`
@@ -41,7 +44,9 @@ type ProtectedBranch struct {
 	RequiredApprovals             int64    `xorm:"NOT NULL DEFAULT 0"`
 	BlockOnRejectedReviews        bool     `xorm:"NOT NULL DEFAULT false"`
 	BlockOnOfficialReviewRequests bool     `xorm:"NOT NULL DEFAULT false"`
+	RequireCodeOwnerReviews       bool     `xorm:"NOT NULL DEFAULT false"`
 	BlockOnOutdatedBranch         bool     `xorm:"NOT NULL DEFAULT false"`
+	EnableMergeQueue              bool     `xorm:"NOT NULL DEFAULT false"`
 	DismissStaleApprovals         bool     `xorm:"NOT NULL DEFAULT false"`
 	RequireSignedCommits          bool     `xorm:"NOT NULL DEFAULT false"`
 	ProtectedFilePatterns         string   `xorm:"TEXT"`
@@ -49,6 +54,8 @@ type ProtectedBranch struct {
 
 	CreatedUnix timeutil.TimeStamp `xorm:"created"`
 	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+
+	globRule glob.Glob `xorm:"-"`
 }
 
 func init() {
@@ -57,6 +64,42 @@ func init() {
 	db.RegisterModel(new(RenamedBranch))
 }
 
+// IsRuleNamePattern returns true if the rule name is a glob pattern instead of the name of a single branch
+func (protectBranch *ProtectedBranch) IsRuleNamePattern() bool {
+	return IsProtectedBranchRuleNamePattern(protectBranch.BranchName)
+}
+
+// IsProtectedBranchRuleNamePattern returns true if the rule name is a glob pattern
+func IsProtectedBranchRuleNamePattern(ruleName string) bool {
+	return glob.QuoteMeta(ruleName) != ruleName
+}
+
+// Match returns true if the rule applies to the branch
+func (protectBranch *ProtectedBranch) Match(branchName string) bool {
+	if !protectBranch.IsRuleNamePattern() {
+		return protectBranch.BranchName == branchName
+	}
+
+	if protectBranch.globRule == nil {
+		var err error
+		protectBranch.globRule, err = glob.Compile(protectBranch.BranchName, '/')
+		if err != nil {
+			log.Warn("Invalid glob rule for ProtectedBranch[%d]: %s %v", protectBranch.ID, protectBranch.BranchName, err)
+			protectBranch.globRule = glob.MustCompile(glob.QuoteMeta(protectBranch.BranchName), '/')
+		}
+	}
+	return protectBranch.globRule.Match(branchName)
+}
+
+// IsValidProtectedBranchRuleName returns true if the rule name is a valid branch name or glob pattern
+func IsValidProtectedBranchRuleName(ruleName string) bool {
+	if strings.TrimSpace(ruleName) == "" {
+		return false
+	}
+	_, err := glob.Compile(ruleName, '/')
+	return err == nil
+}
+
 // IsProtected returns if the branch is protected
 func (protectBranch *ProtectedBranch) IsProtected() bool {
 	return protectBranch.ID > 0
@@ -217,8 +260,9 @@ func (protectBranch *ProtectedBranch) MergeBlockedByOfficialReviewRequests(pr *P
 }
 
 // MergeBlockedByOutdatedBranch returns true if merge is blocked by an outdated head branch
+// The merge queue tests every pull request on top of the latest base branch so it is never blocked.
 func (protectBranch *ProtectedBranch) MergeBlockedByOutdatedBranch(pr *PullRequest) bool {
-	return protectBranch.BlockOnOutdatedBranch && pr.CommitsBehind > 0
+	return protectBranch.BlockOnOutdatedBranch && !protectBranch.EnableMergeQueue && pr.CommitsBehind > 0
 }
 
 // GetProtectedFilePatterns parses a semicolon separated list of protected file patterns and returns a glob.Glob slice
@@ -300,7 +344,7 @@ func (protectBranch *ProtectedBranch) IsUnprotectedFile(patterns []glob.Glob, pa
 	return r
 }
 
-// GetProtectedBranchBy getting protected branch by ID/Name
+// GetProtectedBranchBy getting the protected branch rule by its name
 func GetProtectedBranchBy(repoID int64, branchName string) (*ProtectedBranch, error) {
 	return getProtectedBranchBy(db.GetEngine(db.DefaultContext), repoID, branchName)
 }
@@ -317,6 +361,65 @@ func getProtectedBranchBy(e db.Engine, repoID int64, branchName string) (*Protec
 	return rel, nil
 }
 
+// ProtectedBranchRules is a list of branch protection rules in the order they are applied.
+// Rules naming a single branch take precedence over glob rules, which are applied by ascending priority.
+type ProtectedBranchRules []*ProtectedBranch
+
+func (rules ProtectedBranchRules) sort() {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if isPattern := rules[i].IsRuleNamePattern(); isPattern != rules[j].IsRuleNamePattern() {
+			return !isPattern
+		}
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority < rules[j].Priority
+		}
+		return rules[i].ID < rules[j].ID
+	})
+}
+
+// GetFirstMatched returns the rule applying to the branch, nil if no rule matches
+func (rules ProtectedBranchRules) GetFirstMatched(branchName string) *ProtectedBranch {
+	for _, rule := range rules {
+		if rule.Match(branchName) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Match returns all rules matching the branch, the first one is the rule applying to it
+func (rules ProtectedBranchRules) Match(branchName string) ProtectedBranchRules {
+	matched := make(ProtectedBranchRules, 0, 1)
+	for _, rule := range rules {
+		if rule.Match(branchName) {
+			matched = append(matched, rule)
+		}
+	}
+	return matched
+}
+
+func getProtectedBranchRules(e db.Engine, repoID int64) (ProtectedBranchRules, error) {
+	rules := make(ProtectedBranchRules, 0)
+	if err := e.Where("repo_id = ?", repoID).Find(&rules); err != nil {
+		return nil, err
+	}
+	rules.sort()
+	return rules, nil
+}
+
+// GetFirstMatchProtectedBranchRule returns the rule protecting the branch, nil if the branch is not protected
+func GetFirstMatchProtectedBranchRule(repoID int64, branchName string) (*ProtectedBranch, error) {
+	return getFirstMatchProtectedBranchRule(db.GetEngine(db.DefaultContext), repoID, branchName)
+}
+
+func getFirstMatchProtectedBranchRule(e db.Engine, repoID int64, branchName string) (*ProtectedBranch, error) {
+	rules, err := getProtectedBranchRules(e, repoID)
+	if err != nil {
+		return nil, err
+	}
+	return rules.GetFirstMatched(branchName), nil
+}
+
 // WhitelistOptions represent all sorts of whitelists used for protected branches
 type WhitelistOptions struct {
 	UserIDs []int64
@@ -390,29 +493,23 @@ func UpdateProtectBranch(repo *Repository, protectBranch *ProtectedBranch, opts
 	return nil
 }
 
-// GetProtectedBranches get all protected branches
-func (repo *Repository) GetProtectedBranches() ([]*ProtectedBranch, error) {
-	protectedBranches := make([]*ProtectedBranch, 0)
-	return protectedBranches, db.GetEngine(db.DefaultContext).Find(&protectedBranches, &ProtectedBranch{RepoID: repo.ID})
+// GetProtectedBranches get all protected branch rules in the order they are applied
+func (repo *Repository) GetProtectedBranches() (ProtectedBranchRules, error) {
+	return getProtectedBranchRules(db.GetEngine(db.DefaultContext), repo.ID)
 }
 
-// GetBranchProtection get the branch protection of a branch
+// GetBranchProtection get the branch protection rule applying to a branch
 func (repo *Repository) GetBranchProtection(branchName string) (*ProtectedBranch, error) {
-	return GetProtectedBranchBy(repo.ID, branchName)
+	return GetFirstMatchProtectedBranchRule(repo.ID, branchName)
 }
 
 // IsProtectedBranch checks if branch is protected
 func (repo *Repository) IsProtectedBranch(branchName string) (bool, error) {
-	protectedBranch := &ProtectedBranch{
-		RepoID:     repo.ID,
-		BranchName: branchName,
-	}
-
-	has, err := db.GetEngine(db.DefaultContext).Exist(protectedBranch)
+	protectedBranch, err := GetFirstMatchProtectedBranchRule(repo.ID, branchName)
 	if err != nil {
 		return true, err
 	}
-	return has, nil
+	return protectedBranch != nil, nil
 }
 
 // updateApprovalWhitelist checks whether the user whitelist changed and returns a whitelist with
@@ -631,13 +728,13 @@ func (repo *Repository) RenameBranch(from, to string, gitAction func(isDefault b
 		}
 	}
 
-	// 2. Update protected branch if needed
+	// 2. Update protected branch if needed, glob rules are kept as they are
 	protectedBranch, err := getProtectedBranchBy(sess, repo.ID, from)
 	if err != nil {
 		return err
 	}
 
-	if protectedBranch != nil {
+	if protectedBranch != nil && !protectedBranch.IsRuleNamePattern() {
 		protectedBranch.BranchName = to
 		_, err = sess.ID(protectedBranch.ID).Cols("branch_name").Update(protectedBranch)
 		if err != nil {
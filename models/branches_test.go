@@ -154,3 +154,74 @@ func TestOnlyGetDeletedBranchOnCorrectRepo(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, deletedBranch)
 }
+
+func TestProtectedBranchRuleMatch(t *testing.T) {
+	kases := []struct {
+		Rule          string
+		BranchName    string
+		ExpectedMatch bool
+	}{
+		{"main", "main", true},
+		{"main", "main2", false},
+		{"release/*", "release/v1.0", true},
+		{"release/*", "release/v1.0/hotfix", false},
+		{"release/**", "release/v1.0/hotfix", true},
+		{"*", "main", true},
+		{"*", "feature/a", false},
+		{"**", "feature/a", true},
+		{"v1.?", "v1.1", true},
+		{"{main,develop}", "develop", true},
+		{`feat\{x\}`, "feat{x}", true},
+		{`feat\{x\}`, "featx", false},
+	}
+
+	for _, kase := range kases {
+		rule := ProtectedBranch{BranchName: kase.Rule}
+		assert.EqualValues(t, kase.ExpectedMatch, rule.Match(kase.BranchName), "%s - %s", kase.Rule, kase.BranchName)
+	}
+}
+
+func TestProtectedBranchRulesOrder(t *testing.T) {
+	rules := ProtectedBranchRules{
+		{ID: 1, BranchName: "**", Priority: 2},
+		{ID: 2, BranchName: "release/*", Priority: 1},
+		{ID: 3, BranchName: "release/v1.0"},
+		{ID: 4, BranchName: "release/v*", Priority: 1},
+	}
+	rules.sort()
+
+	assert.EqualValues(t, 3, rules.GetFirstMatched("release/v1.0").ID)
+	assert.EqualValues(t, 2, rules.GetFirstMatched("release/v1.1").ID)
+	assert.EqualValues(t, 1, rules.GetFirstMatched("feature/a").ID)
+
+	matched := rules.Match("release/v1.1")
+	if assert.Len(t, matched, 3) {
+		assert.EqualValues(t, 2, matched[0].ID)
+		assert.EqualValues(t, 4, matched[1].ID)
+		assert.EqualValues(t, 1, matched[2].ID)
+	}
+}
+
+func TestGetFirstMatchProtectedBranchRule(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	repo := unittest.AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+
+	assert.NoError(t, UpdateProtectBranch(repo, &ProtectedBranch{RepoID: repo.ID, BranchName: "release/*"}, WhitelistOptions{}))
+	assert.NoError(t, UpdateProtectBranch(repo, &ProtectedBranch{RepoID: repo.ID, BranchName: "release/v1.0", RequiredApprovals: 2}, WhitelistOptions{}))
+
+	rule, err := GetFirstMatchProtectedBranchRule(repo.ID, "release/v1.0")
+	assert.NoError(t, err)
+	if assert.NotNil(t, rule) {
+		assert.EqualValues(t, "release/v1.0", rule.BranchName)
+	}
+
+	rule, err = GetFirstMatchProtectedBranchRule(repo.ID, "release/v2.0")
+	assert.NoError(t, err)
+	if assert.NotNil(t, rule) {
+		assert.EqualValues(t, "release/*", rule.BranchName)
+	}
+
+	isProtected, err := repo.IsProtectedBranch("master")
+	assert.NoError(t, err)
+	assert.False(t, isProtected)
+}
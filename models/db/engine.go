@@ -141,6 +141,9 @@ func InitEngine(ctx context.Context) (err error) {
 	x.SetMaxOpenConns(setting.Database.MaxOpenConns)
 	x.SetMaxIdleConns(setting.Database.MaxIdleConns)
 	x.SetConnMaxLifetime(setting.Database.ConnMaxLifetime)
+	if setting.Tracing.Enabled {
+		x.AddHook(&TracingHook{})
+	}
 
 	DefaultContext = &Context{
 		Context: ctx,
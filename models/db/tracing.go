@@ -0,0 +1,51 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"context"
+	"strings"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/tracing"
+
+	"xorm.io/xorm/contexts"
+)
+
+type tracingSpanKey struct{}
+
+// TracingHook is a xorm hook starting a span for each SQL statement run with a traced context
+type TracingHook struct{}
+
+var _ contexts.Hook = &TracingHook{}
+
+// BeforeProcess starts the span of the statement
+func (TracingHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	ctx, span := tracing.StartChild(c.Ctx, "db "+sqlOperation(c.SQL), tracing.SpanKindClient)
+	if span == nil {
+		return c.Ctx, nil
+	}
+	span.SetAttribute("db.system", setting.Database.Type)
+	span.SetAttribute("db.statement", c.SQL)
+	return context.WithValue(ctx, tracingSpanKey{}, span), nil
+}
+
+// AfterProcess ends the span of the statement
+func (TracingHook) AfterProcess(c *contexts.ContextHook) error {
+	if span, ok := c.Ctx.Value(tracingSpanKey{}).(*tracing.Span); ok {
+		span.RecordError(c.Err)
+		span.End()
+	}
+	return nil
+}
+
+// sqlOperation returns the first keyword of a statement, e.g. SELECT
+func sqlOperation(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if i := strings.IndexAny(sql, " \t\n"); i > 0 {
+		sql = sql[:i]
+	}
+	return strings.ToUpper(sql)
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"code.gitea.io/gitea/modules/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm/contexts"
+)
+
+func runTracingHook(t *testing.T, ctx context.Context, sql string, err error) {
+	hook := TracingHook{}
+	c := contexts.NewContextHook(ctx, sql, nil)
+	ctx, hookErr := hook.BeforeProcess(c)
+	assert.NoError(t, hookErr)
+	c.End(ctx, nil, err)
+	assert.NoError(t, hook.AfterProcess(c))
+}
+
+func TestTracingHook(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	tracing.SetExporter(exporter, 1)
+	defer tracing.SetExporter(nil, 1)
+
+	// the statements without a traced context are not traced
+	runTracingHook(t, context.Background(), "SELECT 1", nil)
+	assert.Empty(t, exporter.Spans())
+
+	ctx, root := tracing.Start(context.Background(), "request", tracing.SpanKindServer)
+	runTracingHook(t, ctx, "select * from `user` where id=?", nil)
+	runTracingHook(t, ctx, "\nUPDATE `user` SET name=?", errors.New("locked"))
+	root.End()
+
+	spans := exporter.Spans()
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, "db SELECT", spans[0].Name)
+		assert.Equal(t, "select * from `user` where id=?", spans[0].Attributes["db.statement"])
+		assert.Equal(t, root.Context().SpanID, spans[0].ParentSpanID)
+		assert.Empty(t, spans[0].Error)
+		assert.Equal(t, "db UPDATE", spans[1].Name)
+		assert.Equal(t, "locked", spans[1].Error)
+		assert.Equal(t, "request", spans[2].Name)
+	}
+}
@@ -170,6 +170,21 @@ func (err ErrUserOwnRepos) Error() string {
 	return fmt.Sprintf("user still has ownership of repositories [uid: %d]", err.UID)
 }
 
+// ErrUserOwnPackages notifies that the user (still) owns the packages.
+type ErrUserOwnPackages struct {
+	UID int64
+}
+
+// IsErrUserOwnPackages checks if an error is an ErrUserOwnPackages.
+func IsErrUserOwnPackages(err error) bool {
+	_, ok := err.(ErrUserOwnPackages)
+	return ok
+}
+
+func (err ErrUserOwnPackages) Error() string {
+	return fmt.Sprintf("user still has ownership of packages [uid: %d]", err.UID)
+}
+
 // ErrUserHasOrgs represents a "UserHasOrgs" kind of error.
 type ErrUserHasOrgs struct {
 	UID int64
@@ -1489,6 +1504,70 @@ func (err ErrPullRequestHasMerged) Error() string {
 		err.ID, err.IssueID, err.HeadRepoID, err.BaseRepoID, err.HeadBranch, err.BaseBranch)
 }
 
+// ErrMergeQueueEntryNotExist represents a "MergeQueueEntryNotExist"-error
+type ErrMergeQueueEntryNotExist struct {
+	PullID int64
+}
+
+// IsErrMergeQueueEntryNotExist checks if an error is a ErrMergeQueueEntryNotExist.
+func IsErrMergeQueueEntryNotExist(err error) bool {
+	_, ok := err.(ErrMergeQueueEntryNotExist)
+	return ok
+}
+
+// Error does pretty-printing :D
+func (err ErrMergeQueueEntryNotExist) Error() string {
+	return fmt.Sprintf("pull request is not in the merge queue [pull_id: %d]", err.PullID)
+}
+
+// ErrMergeQueueEntryAlreadyExist represents a "MergeQueueEntryAlreadyExist"-error
+type ErrMergeQueueEntryAlreadyExist struct {
+	PullID int64
+}
+
+// IsErrMergeQueueEntryAlreadyExist checks if an error is a ErrMergeQueueEntryAlreadyExist.
+func IsErrMergeQueueEntryAlreadyExist(err error) bool {
+	_, ok := err.(ErrMergeQueueEntryAlreadyExist)
+	return ok
+}
+
+// Error does pretty-printing :D
+func (err ErrMergeQueueEntryAlreadyExist) Error() string {
+	return fmt.Sprintf("pull request is already in the merge queue [pull_id: %d]", err.PullID)
+}
+
+// ErrPullAutoMergeNotExist represents a "PullAutoMergeNotExist"-error
+type ErrPullAutoMergeNotExist struct {
+	PullID int64
+}
+
+// IsErrPullAutoMergeNotExist checks if an error is a ErrPullAutoMergeNotExist.
+func IsErrPullAutoMergeNotExist(err error) bool {
+	_, ok := err.(ErrPullAutoMergeNotExist)
+	return ok
+}
+
+// Error does pretty-printing :D
+func (err ErrPullAutoMergeNotExist) Error() string {
+	return fmt.Sprintf("pull request is not scheduled to be merged automatically [pull_id: %d]", err.PullID)
+}
+
+// ErrPullAutoMergeAlreadyScheduled represents a "PullAutoMergeAlreadyScheduled"-error
+type ErrPullAutoMergeAlreadyScheduled struct {
+	PullID int64
+}
+
+// IsErrPullAutoMergeAlreadyScheduled checks if an error is a ErrPullAutoMergeAlreadyScheduled.
+func IsErrPullAutoMergeAlreadyScheduled(err error) bool {
+	_, ok := err.(ErrPullAutoMergeAlreadyScheduled)
+	return ok
+}
+
+// Error does pretty-printing :D
+func (err ErrPullAutoMergeAlreadyScheduled) Error() string {
+	return fmt.Sprintf("pull request is already scheduled to be merged automatically [pull_id: %d]", err.PullID)
+}
+
 // _________                                       __
 // \_   ___ \  ____   _____   _____   ____   _____/  |_
 // /    \  \/ /  _ \ /     \ /     \_/ __ \ /    \   __\
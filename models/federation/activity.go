@@ -0,0 +1,71 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+func init() {
+	db.RegisterModel(new(Activity))
+}
+
+// Activity is an activity sent by a local user or repository or received in one of their inboxes.
+// Sent activities make up the outbox, received activities are only stored to detect duplicates.
+type Activity struct {
+	ID         int64  `xorm:"pk autoincr"`
+	IRI        string `xorm:"VARCHAR(255) UNIQUE NOT NULL"`
+	Type       string `xorm:"NOT NULL"`
+	UserID     int64  `xorm:"INDEX NOT NULL DEFAULT 0"`
+	RepoID     int64  `xorm:"INDEX NOT NULL DEFAULT 0"`
+	IsOutbound bool   `xorm:"INDEX NOT NULL DEFAULT false"`
+	Content    string `xorm:"LONGTEXT"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created INDEX"`
+}
+
+// TableName sets the table name of the activities
+func (a *Activity) TableName() string {
+	return "federation_activity"
+}
+
+// HasActivity returns if an activity with the IRI was stored before
+func HasActivity(ctx context.Context, iri string) (bool, error) {
+	return db.GetEngine(ctx).Where("iri = ?", iri).Exist(&Activity{})
+}
+
+// InsertActivity stores the activity.
+// ErrActivityAlreadyExist is returned if an activity with the same IRI was stored before.
+func InsertActivity(ctx context.Context, a *Activity) error {
+	has, err := HasActivity(ctx, a.IRI)
+	if err != nil {
+		return err
+	}
+	if has {
+		return ErrActivityAlreadyExist
+	}
+	_, err = db.GetEngine(ctx).Insert(a)
+	return err
+}
+
+// FindOutboxActivities returns the newest activities sent by the user or repository
+func FindOutboxActivities(ctx context.Context, userID, repoID int64, opts db.ListOptions) ([]*Activity, int64, error) {
+	cond := "user_id = ? AND repo_id = ? AND is_outbound = ?"
+
+	count, err := db.GetEngine(ctx).Where(cond, userID, repoID, true).Count(&Activity{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	activities := make([]*Activity, 0, opts.PageSize)
+	sess := db.GetEngine(ctx).Where(cond, userID, repoID, true).Desc("id")
+	if opts.Page > 0 {
+		sess = db.SetSessionPagination(sess, &opts)
+	}
+	return activities, count, sess.Find(&activities)
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+func init() {
+	db.RegisterModel(new(Actor))
+}
+
+// Actor is a cached copy of the document of an actor on another instance
+type Actor struct {
+	ID                int64  `xorm:"pk autoincr"`
+	IRI               string `xorm:"VARCHAR(255) UNIQUE NOT NULL"`
+	Type              string `xorm:"NOT NULL"`
+	PreferredUsername string
+	Name              string
+	Host              string `xorm:"INDEX NOT NULL"`
+	URL               string `xorm:"TEXT"`
+	Inbox             string `xorm:"TEXT NOT NULL"`
+	PublicKeyID       string `xorm:"TEXT"`
+	PublicKeyPem      string `xorm:"TEXT"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// TableName sets the table name of the remote actors
+func (a *Actor) TableName() string {
+	return "federation_actor"
+}
+
+// Handle returns the handle of the actor like user@example.com
+func (a *Actor) Handle() string {
+	if a.PreferredUsername == "" {
+		return a.IRI
+	}
+	return a.PreferredUsername + "@" + a.Host
+}
+
+// GetActorByID gets a remote actor by its id
+func GetActorByID(ctx context.Context, id int64) (*Actor, error) {
+	a := &Actor{}
+	has, err := db.GetEngine(ctx).ID(id).Get(a)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrActorNotExist
+	}
+	return a, nil
+}
+
+// GetActorByIRI gets a remote actor by its IRI
+func GetActorByIRI(ctx context.Context, iri string) (*Actor, error) {
+	a := &Actor{}
+	has, err := db.GetEngine(ctx).Where("iri = ?", iri).Get(a)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrActorNotExist
+	}
+	return a, nil
+}
+
+// SaveActor inserts the actor or updates the stored copy with the same IRI
+func SaveActor(ctx context.Context, a *Actor) error {
+	existing, err := GetActorByIRI(ctx, a.IRI)
+	if err == ErrActorNotExist {
+		_, err = db.GetEngine(ctx).Insert(a)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	a.ID = existing.ID
+	_, err = db.GetEngine(ctx).ID(a.ID).AllCols().Omit("created_unix").Update(a)
+	return err
+}
@@ -0,0 +1,16 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"errors"
+)
+
+var (
+	// ErrActorNotExist indicates a remote actor not exist error
+	ErrActorNotExist = errors.New("Remote actor does not exist")
+	// ErrActivityAlreadyExist indicates that an activity was already stored
+	ErrActivityAlreadyExist = errors.New("Activity already exists")
+)
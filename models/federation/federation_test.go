@@ -0,0 +1,116 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveActor(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	actor := &Actor{IRI: "https://example.com/users/alice", Type: "Person", PreferredUsername: "alice", Host: "example.com", Inbox: "https://example.com/users/alice/inbox"}
+	assert.NoError(t, SaveActor(db.DefaultContext, actor))
+	assert.NotZero(t, actor.ID)
+	assert.Equal(t, "alice@example.com", actor.Handle())
+
+	updated := &Actor{IRI: actor.IRI, Type: "Person", PreferredUsername: "alice", Name: "Alice", Host: "example.com", Inbox: actor.Inbox}
+	assert.NoError(t, SaveActor(db.DefaultContext, updated))
+	assert.Equal(t, actor.ID, updated.ID)
+
+	stored, err := GetActorByIRI(db.DefaultContext, actor.IRI)
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", stored.Name)
+
+	_, err = GetActorByIRI(db.DefaultContext, "https://example.com/users/bob")
+	assert.ErrorIs(t, err, ErrActorNotExist)
+}
+
+func TestFollowers(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	actor := &Actor{IRI: "https://example.com/users/alice", Type: "Person", Host: "example.com", Inbox: "https://example.com/users/alice/inbox"}
+	assert.NoError(t, SaveActor(db.DefaultContext, actor))
+
+	assert.NoError(t, AddFollower(db.DefaultContext, actor.ID, 0, 1))
+	assert.NoError(t, AddFollower(db.DefaultContext, actor.ID, 0, 1))
+
+	count, err := CountFollowers(db.DefaultContext, 0, 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	count, err = CountFollowers(db.DefaultContext, 2, 0)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+
+	actors, err := GetFollowerActors(db.DefaultContext, 0, 1)
+	assert.NoError(t, err)
+	assert.Len(t, actors, 1)
+	assert.Equal(t, actor.IRI, actors[0].IRI)
+
+	assert.NoError(t, RemoveFollower(db.DefaultContext, actor.ID, 0, 1))
+	count, err = CountFollowers(db.DefaultContext, 0, 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+}
+
+func TestAcceptFollowing(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	assert.NoError(t, AddFollowing(db.DefaultContext, 2, 1, "https://try.gitea.io/follow-1"))
+
+	accepted, err := AcceptFollowing(db.DefaultContext, 3, "https://try.gitea.io/follow-1")
+	assert.NoError(t, err)
+	assert.False(t, accepted)
+
+	accepted, err = AcceptFollowing(db.DefaultContext, 1, "https://try.gitea.io/follow-1")
+	assert.NoError(t, err)
+	assert.True(t, accepted)
+
+	f, err := GetFollowing(db.DefaultContext, 2, 1)
+	assert.NoError(t, err)
+	assert.True(t, f.IsAccepted)
+
+	count, err := CountFollowing(db.DefaultContext, 2)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestInsertActivity(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	a := &Activity{IRI: "https://example.com/activities/1", Type: "Follow", RepoID: 1}
+	assert.NoError(t, InsertActivity(db.DefaultContext, a))
+	assert.ErrorIs(t, InsertActivity(db.DefaultContext, &Activity{IRI: a.IRI, Type: "Follow"}), ErrActivityAlreadyExist)
+
+	assert.NoError(t, InsertActivity(db.DefaultContext, &Activity{IRI: "https://try.gitea.io/activities/2", Type: "Accept", RepoID: 1, IsOutbound: true, Content: "{}"}))
+	activities, total, err := FindOutboxActivities(db.DefaultContext, 0, 1, db.ListOptions{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	assert.Len(t, activities, 1)
+	assert.Equal(t, "Accept", activities[0].Type)
+}
+
+func TestGetOrCreateKeyPair(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	k1, err := GetOrCreateKeyPair(db.DefaultContext, 2, 0)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, k1.PrivateKey)
+	assert.NotEmpty(t, k1.PublicKey)
+
+	k2, err := GetOrCreateKeyPair(db.DefaultContext, 2, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, k1.ID, k2.ID)
+	assert.Equal(t, k1.PublicKey, k2.PublicKey)
+
+	k3, err := GetOrCreateKeyPair(db.DefaultContext, 0, 1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, k1.PublicKey, k3.PublicKey)
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+func init() {
+	db.RegisterModel(new(Follower))
+}
+
+// Follower represents a remote actor which follows a local user or repository.
+// Followers of a user have a RepoID of 0, followers of a repository have a UserID of 0.
+type Follower struct {
+	ID          int64              `xorm:"pk autoincr"`
+	ActorID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	UserID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+	RepoID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// TableName sets the table name of the remote followers
+func (f *Follower) TableName() string {
+	return "federation_follower"
+}
+
+// AddFollower adds the remote actor as follower of the user or repository
+func AddFollower(ctx context.Context, actorID, userID, repoID int64) error {
+	f := &Follower{ActorID: actorID, UserID: userID, RepoID: repoID}
+	has, err := db.GetEngine(ctx).Get(&Follower{ActorID: actorID, UserID: userID, RepoID: repoID})
+	if err != nil || has {
+		return err
+	}
+	_, err = db.GetEngine(ctx).Insert(f)
+	return err
+}
+
+// RemoveFollower removes the remote actor from the followers of the user or repository
+func RemoveFollower(ctx context.Context, actorID, userID, repoID int64) error {
+	_, err := db.GetEngine(ctx).
+		Where("actor_id = ? AND user_id = ? AND repo_id = ?", actorID, userID, repoID).
+		Delete(&Follower{})
+	return err
+}
+
+// GetFollowerActors returns the remote actors which follow the user or repository
+func GetFollowerActors(ctx context.Context, userID, repoID int64) ([]*Actor, error) {
+	actors := make([]*Actor, 0, 10)
+	return actors, db.GetEngine(ctx).
+		Join("INNER", "federation_follower", "federation_follower.actor_id = federation_actor.id").
+		Where("federation_follower.user_id = ? AND federation_follower.repo_id = ?", userID, repoID).
+		Asc("federation_follower.id").
+		Find(&actors)
+}
+
+// CountFollowers counts the remote actors which follow the user or repository
+func CountFollowers(ctx context.Context, userID, repoID int64) (int64, error) {
+	return db.GetEngine(ctx).Where("user_id = ? AND repo_id = ?", userID, repoID).Count(&Follower{})
+}
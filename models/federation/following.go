@@ -0,0 +1,74 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+func init() {
+	db.RegisterModel(new(Following))
+}
+
+// Following represents a local user which follows a remote actor.
+// The follow is accepted once the remote actor answered the Follow activity.
+type Following struct {
+	ID          int64              `xorm:"pk autoincr"`
+	UserID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	ActorID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	ActivityIRI string             `xorm:"VARCHAR(255) INDEX NOT NULL"`
+	IsAccepted  bool               `xorm:"NOT NULL DEFAULT false"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// TableName sets the table name of the followed remote actors
+func (f *Following) TableName() string {
+	return "federation_following"
+}
+
+// AddFollowing stores that the user requested to follow the remote actor
+func AddFollowing(ctx context.Context, userID, actorID int64, activityIRI string) error {
+	f := &Following{}
+	has, err := db.GetEngine(ctx).Where("user_id = ? AND actor_id = ?", userID, actorID).Get(f)
+	if err != nil {
+		return err
+	}
+	if has {
+		_, err = db.GetEngine(ctx).ID(f.ID).Cols("activity_iri").Update(&Following{ActivityIRI: activityIRI})
+		return err
+	}
+	_, err = db.GetEngine(ctx).Insert(&Following{UserID: userID, ActorID: actorID, ActivityIRI: activityIRI})
+	return err
+}
+
+// AcceptFollowing marks the follow request of the activity as accepted by the remote actor
+func AcceptFollowing(ctx context.Context, actorID int64, activityIRI string) (bool, error) {
+	n, err := db.GetEngine(ctx).
+		Where("actor_id = ? AND activity_iri = ?", actorID, activityIRI).
+		Cols("is_accepted").
+		Update(&Following{IsAccepted: true})
+	return n > 0, err
+}
+
+// GetFollowing returns the follow of the remote actor by the user
+func GetFollowing(ctx context.Context, userID, actorID int64) (*Following, error) {
+	f := &Following{}
+	has, err := db.GetEngine(ctx).Where("user_id = ? AND actor_id = ?", userID, actorID).Get(f)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrActorNotExist
+	}
+	return f, nil
+}
+
+// CountFollowing counts the remote actors the user follows
+func CountFollowing(ctx context.Context, userID int64) (int64, error) {
+	return db.GetEngine(ctx).Where("user_id = ? AND is_accepted = ?", userID, true).Count(&Following{})
+}
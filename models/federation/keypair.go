@@ -0,0 +1,59 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/activitypub"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+func init() {
+	db.RegisterModel(new(KeyPair))
+}
+
+// KeyPair is the key pair a local user or repository signs its activities with
+type KeyPair struct {
+	ID          int64              `xorm:"pk autoincr"`
+	UserID      int64              `xorm:"UNIQUE(s) NOT NULL DEFAULT 0"`
+	RepoID      int64              `xorm:"UNIQUE(s) NOT NULL DEFAULT 0"`
+	PrivateKey  string             `xorm:"TEXT NOT NULL"`
+	PublicKey   string             `xorm:"TEXT NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// TableName sets the table name of the key pairs
+func (k *KeyPair) TableName() string {
+	return "federation_key_pair"
+}
+
+// GetOrCreateKeyPair returns the key pair of the user or repository and generates it on first use
+func GetOrCreateKeyPair(ctx context.Context, userID, repoID int64) (*KeyPair, error) {
+	k := &KeyPair{}
+	has, err := db.GetEngine(ctx).Where("user_id = ? AND repo_id = ?", userID, repoID).Get(k)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return k, nil
+	}
+
+	priv, pub, err := activitypub.GenerateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	k = &KeyPair{UserID: userID, RepoID: repoID, PrivateKey: priv, PublicKey: pub}
+	if _, err := db.GetEngine(ctx).Insert(k); err != nil {
+		// the key pair may have been created concurrently
+		existing := &KeyPair{}
+		if has, err2 := db.GetEngine(ctx).Where("user_id = ? AND repo_id = ?", userID, repoID).Get(existing); err2 == nil && has {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return k, nil
+}
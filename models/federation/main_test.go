@@ -0,0 +1,23 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/gitea/models/unittest"
+)
+
+func TestMain(m *testing.M) {
+	unittest.MainTest(m, filepath.Join("..", ".."),
+		"federation_actor.yml",
+		"federation_follower.yml",
+		"federation_following.yml",
+		"federation_star.yml",
+		"federation_activity.yml",
+		"federation_key_pair.yml",
+	)
+}
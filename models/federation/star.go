@@ -0,0 +1,50 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+func init() {
+	db.RegisterModel(new(Star))
+}
+
+// Star represents a star of a local repository by a remote actor
+type Star struct {
+	ID          int64              `xorm:"pk autoincr"`
+	ActorID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	RepoID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// TableName sets the table name of the remote stars
+func (s *Star) TableName() string {
+	return "federation_star"
+}
+
+// AddStar stars the repository by the remote actor
+func AddStar(ctx context.Context, actorID, repoID int64) error {
+	has, err := db.GetEngine(ctx).Get(&Star{ActorID: actorID, RepoID: repoID})
+	if err != nil || has {
+		return err
+	}
+	_, err = db.GetEngine(ctx).Insert(&Star{ActorID: actorID, RepoID: repoID})
+	return err
+}
+
+// RemoveStar removes the star of the repository by the remote actor
+func RemoveStar(ctx context.Context, actorID, repoID int64) error {
+	_, err := db.GetEngine(ctx).Where("actor_id = ? AND repo_id = ?", actorID, repoID).Delete(&Star{})
+	return err
+}
+
+// CountStars counts the remote actors which starred the repository
+func CountStars(ctx context.Context, repoID int64) (int64, error) {
+	return db.GetEngine(ctx).Where("repo_id = ?", repoID).Count(&Star{})
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package foreignreference
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models/db"
+)
+
+// Type* are the kinds of objects which are referenced
+const (
+	TypeIssue       = "issue"
+	TypePullRequest = "pull_request"
+	TypeComment     = "comment"
+	TypeReview      = "review"
+)
+
+// ForeignReference maps an object of a migrated repository to the id it has on the source of the migration.
+// LocalIndex is the index of issues and pull requests and the id of all other objects.
+type ForeignReference struct {
+	ID           int64  `xorm:"pk autoincr"`
+	RepoID       int64  `xorm:"UNIQUE(repo_foreign_type) INDEX(repo_local)"`
+	LocalIndex   int64  `xorm:"INDEX(repo_local)"`
+	ForeignIndex string `xorm:"UNIQUE(repo_foreign_type)"`
+	Type         string `xorm:"VARCHAR(16) UNIQUE(repo_foreign_type) INDEX(repo_local)"`
+}
+
+func init() {
+	db.RegisterModel(new(ForeignReference))
+}
+
+// ErrForeignReferenceNotExist represents a "ForeignReferenceNotExist" kind of error.
+type ErrForeignReferenceNotExist struct {
+	RepoID       int64
+	ForeignIndex string
+	Type         string
+}
+
+// IsErrForeignReferenceNotExist checks if an error is a ErrForeignReferenceNotExist.
+func IsErrForeignReferenceNotExist(err error) bool {
+	_, ok := err.(ErrForeignReferenceNotExist)
+	return ok
+}
+
+func (err ErrForeignReferenceNotExist) Error() string {
+	return fmt.Sprintf("foreign reference does not exist [repo_id: %d, foreign_index: %s, type: %s]", err.RepoID, err.ForeignIndex, err.Type)
+}
+
+// New returns a reference from a local index to the index of the object on the source of the migration
+func New(repoID, localIndex int64, foreignIndex, tp string) *ForeignReference {
+	return &ForeignReference{
+		RepoID:       repoID,
+		LocalIndex:   localIndex,
+		ForeignIndex: foreignIndex,
+		Type:         tp,
+	}
+}
+
+// InsertForeignReferences inserts the references of migrated objects
+func InsertForeignReferences(refs ...*ForeignReference) error {
+	if len(refs) == 0 {
+		return nil
+	}
+	return db.Insert(db.DefaultContext, refs)
+}
+
+// GetLocalIndex returns the local index of an object of a repository by the index it has on the source of the migration
+func GetLocalIndex(repoID int64, foreignIndex, tp string) (int64, error) {
+	ref := &ForeignReference{
+		RepoID:       repoID,
+		ForeignIndex: foreignIndex,
+		Type:         tp,
+	}
+	has, err := db.GetEngine(db.DefaultContext).Get(ref)
+	if err != nil {
+		return 0, err
+	} else if !has {
+		return 0, ErrForeignReferenceNotExist{RepoID: repoID, ForeignIndex: foreignIndex, Type: tp}
+	}
+	return ref.LocalIndex, nil
+}
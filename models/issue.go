@@ -1087,6 +1087,11 @@ func NewIssue(repo *Repository, issue *Issue, labelIDs []int64, uuids []string)
 
 // GetIssueByIndex returns raw issue without loading attributes by index in a repository.
 func GetIssueByIndex(repoID, index int64) (*Issue, error) {
+	return GetIssueByIndexCtx(db.DefaultContext, repoID, index)
+}
+
+// GetIssueByIndexCtx returns raw issue without loading attributes by index in a repository.
+func GetIssueByIndexCtx(ctx context.Context, repoID, index int64) (*Issue, error) {
 	if index < 1 {
 		return nil, ErrIssueNotExist{}
 	}
@@ -1094,7 +1099,7 @@ func GetIssueByIndex(repoID, index int64) (*Issue, error) {
 		RepoID: repoID,
 		Index:  index,
 	}
-	has, err := db.GetEngine(db.DefaultContext).Get(issue)
+	has, err := db.GetEngine(ctx).Get(issue)
 	if err != nil {
 		return nil, err
 	} else if !has {
@@ -1162,6 +1167,7 @@ func GetIssuesByIDs(issueIDs []int64) ([]*Issue, error) {
 type IssuesOptions struct {
 	db.ListOptions
 	RepoIDs            []int64 // include all repos if empty
+	RepoCond           builder.Cond
 	AssigneeID         int64
 	PosterID           int64
 	MentionedID        int64
@@ -1173,12 +1179,15 @@ type IssuesOptions struct {
 	IsPull             util.OptionalBool
 	LabelIDs           []int64
 	IncludedLabelNames []string
+	RequiredLabelNames []string // issues must have all these labels
 	ExcludedLabelNames []string
 	IncludeMilestones  []string
 	SortType           string
 	IssueIDs           []int64
 	UpdatedAfterUnix   int64
 	UpdatedBeforeUnix  int64
+	CreatedAfterUnix   int64
+	CreatedBeforeUnix  int64
 	// prioritize issues from this repo
 	PriorityRepoID int64
 	IsArchived     util.OptionalBool
@@ -1240,6 +1249,10 @@ func (opts *IssuesOptions) setupSession(sess *xorm.Session) {
 		applyReposCondition(sess, opts.RepoIDs)
 	}
 
+	if opts.RepoCond != nil {
+		sess.And(opts.RepoCond)
+	}
+
 	switch opts.IsClosed {
 	case util.OptionalBoolTrue:
 		sess.And("issue.is_closed=?", true)
@@ -1273,6 +1286,12 @@ func (opts *IssuesOptions) setupSession(sess *xorm.Session) {
 	if opts.UpdatedBeforeUnix != 0 {
 		sess.And(builder.Lte{"issue.updated_unix": opts.UpdatedBeforeUnix})
 	}
+	if opts.CreatedAfterUnix != 0 {
+		sess.And(builder.Gte{"issue.created_unix": opts.CreatedAfterUnix})
+	}
+	if opts.CreatedBeforeUnix != 0 {
+		sess.And(builder.Lte{"issue.created_unix": opts.CreatedBeforeUnix})
+	}
 
 	if opts.ProjectID > 0 {
 		sess.Join("INNER", "project_issue", "issue.id = project_issue.issue_id").
@@ -1313,6 +1332,10 @@ func (opts *IssuesOptions) setupSession(sess *xorm.Session) {
 		sess.In("issue.id", BuildLabelNamesIssueIDsCondition(opts.IncludedLabelNames))
 	}
 
+	for _, labelName := range opts.RequiredLabelNames {
+		sess.In("issue.id", BuildLabelNamesIssueIDsCondition([]string{labelName}))
+	}
+
 	if len(opts.ExcludedLabelNames) > 0 {
 		sess.And(builder.NotIn("issue.id", BuildLabelNamesIssueIDsCondition(opts.ExcludedLabelNames)))
 	}
@@ -1441,6 +1464,25 @@ func CountIssues(opts *IssuesOptions) (int64, error) {
 	return countsSlice[0].Count, nil
 }
 
+// CountIssueStats returns the numbers of open and closed issues matching the options,
+// whatever their IsClosed option and pagination are.
+func CountIssueStats(opts *IssuesOptions) (*IssueStats, error) {
+	countOpts := *opts
+	countOpts.ListOptions = db.ListOptions{}
+
+	var err error
+	stats := &IssueStats{}
+	countOpts.IsClosed = util.OptionalBoolFalse
+	if stats.OpenCount, err = CountIssues(&countOpts); err != nil {
+		return nil, err
+	}
+	countOpts.IsClosed = util.OptionalBoolTrue
+	if stats.ClosedCount, err = CountIssues(&countOpts); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
 // GetParticipantsIDsByIssueID returns the IDs of all users who participated in comments of an issue,
 // but skips joining with `user` for performance reasons.
 // User permissions must be verified elsewhere if required.
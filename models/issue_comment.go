@@ -107,6 +107,14 @@ const (
 	CommentTypeDismissReview
 	// 33 Change issue ref
 	CommentTypeChangeIssueRef
+	// 34 Pull request added to the merge queue
+	CommentTypeMergeQueueAdd
+	// 35 Pull request removed from the merge queue
+	CommentTypeMergeQueueRemove
+	// 36 Pull request scheduled to be merged when all checks succeed
+	CommentTypePRScheduledToAutoMerge
+	// 37 Scheduled automatic merge of the pull request canceled
+	CommentTypePRUnScheduledToAutoMerge
 )
 
 // RoleDescriptor defines comment tag type
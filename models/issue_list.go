@@ -68,6 +68,32 @@ func (issues IssueList) LoadRepositories() ([]*Repository, error) {
 	return issues.loadRepositories(db.GetEngine(db.DefaultContext))
 }
 
+// FilterReadableByUser returns the issues of the list which the user, nil for anonymous, can
+// read in their repositories
+func (issues IssueList) FilterReadableByUser(user *User) (IssueList, error) {
+	e := db.GetEngine(db.DefaultContext)
+	if _, err := issues.loadRepositories(e); err != nil {
+		return nil, err
+	}
+
+	perms := make(map[int64]Permission)
+	readable := make(IssueList, 0, len(issues))
+	for _, issue := range issues {
+		perm, ok := perms[issue.RepoID]
+		if !ok {
+			var err error
+			if perm, err = getUserRepoPermission(e, issue.Repo, user); err != nil {
+				return nil, err
+			}
+			perms[issue.RepoID] = perm
+		}
+		if perm.CanReadIssuesOrPulls(issue.IsPull) {
+			readable = append(readable, issue)
+		}
+	}
+	return readable, nil
+}
+
 func (issues IssueList) getPosterIDs() []int64 {
 	posterIDs := make(map[int64]struct{}, len(issues))
 	for _, issue := range issues {
@@ -70,3 +70,30 @@ func TestIssueList_LoadAttributes(t *testing.T) {
 		}
 	}
 }
+
+func TestIssueList_FilterReadableByUser(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	issueList := IssueList{
+		unittest.AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue),
+		unittest.AssertExistsAndLoadBean(t, &Issue{ID: 4}).(*Issue),
+		unittest.AssertExistsAndLoadBean(t, &Issue{ID: 6}).(*Issue),
+	}
+
+	for _, kase := range []struct {
+		userID   int64
+		issueIDs []int64
+	}{
+		{0, []int64{1}},
+		{2, []int64{1, 4, 6}},
+		{5, []int64{1}},
+	} {
+		var user *User
+		if kase.userID > 0 {
+			user = unittest.AssertExistsAndLoadBean(t, &User{ID: kase.userID}).(*User)
+		}
+		readable, err := issueList.FilterReadableByUser(user)
+		assert.NoError(t, err)
+		assert.Equal(t, kase.issueIDs, readable.getIssueIDs())
+	}
+}
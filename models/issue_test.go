@@ -13,6 +13,7 @@ import (
 
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/util"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -180,6 +181,19 @@ func TestIssues(t *testing.T) {
 			},
 			[]int64{}, // issues with **both** label 1 and 2, none of these issues matches, TODO: add more tests
 		},
+		{
+			IssuesOptions{
+				RequiredLabelNames: []string{"label1", "orglabel4"},
+			},
+			[]int64{2},
+		},
+		{
+			IssuesOptions{
+				RequiredLabelNames: []string{"label1"},
+				ExcludedLabelNames: []string{"orglabel4"},
+			},
+			[]int64{1},
+		},
 	} {
 		issues, err := Issues(&test.Opts)
 		assert.NoError(t, err)
@@ -191,6 +205,18 @@ func TestIssues(t *testing.T) {
 	}
 }
 
+func TestCountIssueStats(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	stats, err := CountIssueStats(&IssuesOptions{
+		ListOptions: db.ListOptions{Page: 1, PageSize: 1},
+		RepoIDs:     []int64{1},
+		IsClosed:    util.OptionalBoolTrue,
+		IsPull:      util.OptionalBoolFalse,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, &IssueStats{OpenCount: 1, ClosedCount: 1}, stats)
+}
+
 func TestGetUserIssueStats(t *testing.T) {
 	assert.NoError(t, unittest.PrepareTestDatabase())
 	for _, test := range []struct {
@@ -222,6 +222,169 @@ func InsertReleases(rels ...*Release) error {
 	return committer.Commit()
 }
 
+// UpdateMigratedIssues updates issues of a repository synced from the original service
+// and replaces their labels and reactions. Unlike the usual updates no comments are created.
+func UpdateMigratedIssues(issues ...*Issue) error {
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return err
+	}
+	defer committer.Close()
+	sess := db.GetEngine(ctx)
+
+	counters := newMigratedIssueCounters()
+	for _, issue := range issues {
+		if err := updateMigratedIssue(sess, issue, counters); err != nil {
+			return err
+		}
+	}
+	if err := counters.update(sess); err != nil {
+		return err
+	}
+	return committer.Commit()
+}
+
+// UpdateMigratedPullRequests updates pull requests of a repository synced from the original service
+func UpdateMigratedPullRequests(prs ...*PullRequest) error {
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return err
+	}
+	defer committer.Close()
+	sess := db.GetEngine(ctx)
+
+	counters := newMigratedIssueCounters()
+	for _, pr := range prs {
+		if err := updateMigratedIssue(sess, pr.Issue, counters); err != nil {
+			return err
+		}
+		pr.IssueID = pr.Issue.ID
+		if _, err := sess.ID(pr.ID).NoAutoTime().
+			Cols("head_branch", "base_branch", "merge_base", "has_merged", "merged_unix", "merged_commit_id", "merger_id").
+			Update(pr); err != nil {
+			return err
+		}
+	}
+	if err := counters.update(sess); err != nil {
+		return err
+	}
+	return committer.Commit()
+}
+
+// migratedIssueCounters collects the labels, milestones and repositories
+// whose counters have to be recalculated after updating migrated issues
+type migratedIssueCounters struct {
+	labelIDs     map[int64]struct{}
+	milestoneIDs map[int64]struct{}
+	issues       map[bool]*Issue
+}
+
+func newMigratedIssueCounters() *migratedIssueCounters {
+	return &migratedIssueCounters{
+		labelIDs:     make(map[int64]struct{}),
+		milestoneIDs: make(map[int64]struct{}),
+		issues:       make(map[bool]*Issue),
+	}
+}
+
+func (c *migratedIssueCounters) update(e db.Engine) error {
+	for labelID := range c.labelIDs {
+		if err := updateLabelCols(e, &Label{ID: labelID}, "num_issues", "num_closed_issues"); err != nil {
+			return err
+		}
+	}
+	for milestoneID := range c.milestoneIDs {
+		if err := updateMilestoneCounters(e, milestoneID); err != nil {
+			return err
+		}
+	}
+	for _, issue := range c.issues {
+		if err := issue.updateClosedNum(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func updateMigratedIssue(e db.Engine, issue *Issue, counters *migratedIssueCounters) error {
+	old, err := getIssueByID(e, issue.ID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := e.ID(issue.ID).NoAutoTime().
+		Cols("poster_id", "original_author", "original_author_id", "name", "content", "milestone_id", "is_closed", "is_locked", "ref", "updated_unix", "closed_unix").
+		Update(issue); err != nil {
+		return err
+	}
+
+	var oldLabelIDs []int64
+	if err := e.Table("issue_label").Where("issue_id = ?", issue.ID).Cols("label_id").Find(&oldLabelIDs); err != nil {
+		return err
+	}
+	if _, err := e.Delete(&IssueLabel{IssueID: issue.ID}); err != nil {
+		return err
+	}
+	issueLabels := make([]IssueLabel, 0, len(issue.Labels))
+	for _, label := range issue.Labels {
+		issueLabels = append(issueLabels, IssueLabel{
+			IssueID: issue.ID,
+			LabelID: label.ID,
+		})
+		counters.labelIDs[label.ID] = struct{}{}
+	}
+	if len(issueLabels) > 0 {
+		if _, err := e.Insert(issueLabels); err != nil {
+			return err
+		}
+	}
+	for _, labelID := range oldLabelIDs {
+		counters.labelIDs[labelID] = struct{}{}
+	}
+
+	if _, err := e.Where("comment_id = 0").Delete(&Reaction{IssueID: issue.ID}); err != nil {
+		return err
+	}
+	for _, reaction := range issue.Reactions {
+		reaction.IssueID = issue.ID
+	}
+	if len(issue.Reactions) > 0 {
+		if _, err := e.Insert(issue.Reactions); err != nil {
+			return err
+		}
+	}
+
+	if old.MilestoneID > 0 {
+		counters.milestoneIDs[old.MilestoneID] = struct{}{}
+	}
+	if issue.MilestoneID > 0 {
+		counters.milestoneIDs[issue.MilestoneID] = struct{}{}
+	}
+	if old.IsClosed != issue.IsClosed {
+		counters.issues[issue.IsPull] = issue
+	}
+	return nil
+}
+
+// UpdateMigratedComments updates the content of comments synced from the original service
+func UpdateMigratedComments(comments ...*Comment) error {
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return err
+	}
+	defer committer.Close()
+	sess := db.GetEngine(ctx)
+
+	for _, comment := range comments {
+		if _, err := sess.ID(comment.ID).NoAutoTime().
+			Cols("content", "updated_unix").
+			Update(comment); err != nil {
+			return err
+		}
+	}
+	return committer.Commit()
+}
+
 func migratedIssueCond(tp structs.GitServiceType) builder.Cond {
 	return builder.In("issue_id",
 		builder.Select("issue.id").
@@ -359,6 +359,34 @@ var migrations = []Migration{
 	NewMigration("Drop table remote_version (if exists)", dropTableRemoteVersion),
 	// v202 -> v203
 	NewMigration("Create key/value table for user settings", createUserSettingsTable),
+	// v203 -> v204
+	NewMigration("Add package tables", addPackageTables),
+	// v204 -> v205
+	NewMigration("Add actions tables", addActionsTables),
+	// v205 -> v206
+	NewMigration("Add federation tables", addFederationTables),
+	// v206 -> v207
+	NewMigration("Add merge queue", addMergeQueue),
+	// v207 -> v208
+	NewMigration("Add table for scheduled automatic merges of pull requests", addPullAutoMergeTable),
+	// v208 -> v209
+	NewMigration("Add require code owner reviews to protected branch", addRequireCodeOwnerReviewsToProtectedBranch),
+	// v209 -> v210
+	NewMigration("Add glob rules with priority to protected branch", addGlobRulesToProtectedBranch),
+	// v210 -> v211
+	NewMigration("Add audit event table", addAuditEventTable),
+	// v211 -> v212
+	NewMigration("Add foreign reference table and metadata sync to mirror", addForeignReferenceTableAndMirrorMetadataSync),
+	// v212 -> v213
+	NewMigration("Add sync on push, ref filters and attempts to push mirror", addPushMirrorSyncOnPushAndAttempts),
+	// v213 -> v214
+	NewMigration("Add repo symbol table", addRepoSymbolTable),
+	// v214 -> v215
+	NewMigration("Add delivery retries to webhooks", addWebhookDeliveryRetries),
+	// v215 -> v216
+	NewMigration("Add owner to projects", addOwnerToProject),
+	// v216 -> v217
+	NewMigration("Add project automation rule table", addProjectAutomationRuleTable),
 }
 
 // GetCurrentDBVersion returns the current db version
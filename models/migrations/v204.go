@@ -0,0 +1,90 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addActionsTables(x *xorm.Engine) error {
+	type ActionRunner struct {
+		ID             int64    `xorm:"pk autoincr"`
+		UUID           string   `xorm:"CHAR(36) UNIQUE NOT NULL"`
+		Name           string   `xorm:"VARCHAR(255)"`
+		OwnerID        int64    `xorm:"INDEX NOT NULL DEFAULT 0"`
+		RepoID         int64    `xorm:"INDEX NOT NULL DEFAULT 0"`
+		Labels         []string `xorm:"TEXT JSON"`
+		TokenHash      string   `xorm:"UNIQUE"`
+		TokenSalt      string
+		TokenLastEight string             `xorm:"token_last_eight"`
+		LastOnlineUnix timeutil.TimeStamp `xorm:"INDEX"`
+		CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix    timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionRunnerToken struct {
+		ID          int64              `xorm:"pk autoincr"`
+		Token       string             `xorm:"UNIQUE"`
+		OwnerID     int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+		RepoID      int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+		IsActive    bool               `xorm:"NOT NULL DEFAULT false"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionRun struct {
+		ID            int64  `xorm:"pk autoincr"`
+		RepoID        int64  `xorm:"INDEX NOT NULL"`
+		OwnerID       int64  `xorm:"INDEX NOT NULL"`
+		WorkflowID    string `xorm:"NOT NULL"`
+		Name          string
+		Title         string
+		TriggerUserID int64  `xorm:"NOT NULL DEFAULT 0"`
+		Ref           string `xorm:"NOT NULL"`
+		CommitSHA     string `xorm:"VARCHAR(40) INDEX NOT NULL"`
+		Event         string `xorm:"NOT NULL"`
+		Status        int    `xorm:"INDEX NOT NULL DEFAULT 0"`
+		StartedUnix   timeutil.TimeStamp
+		StoppedUnix   timeutil.TimeStamp
+		CreatedUnix   timeutil.TimeStamp `xorm:"created INDEX"`
+		UpdatedUnix   timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionRunJob struct {
+		ID              int64    `xorm:"pk autoincr"`
+		RunID           int64    `xorm:"INDEX NOT NULL"`
+		RepoID          int64    `xorm:"INDEX NOT NULL"`
+		OwnerID         int64    `xorm:"INDEX NOT NULL"`
+		CommitSHA       string   `xorm:"VARCHAR(40) NOT NULL"`
+		JobID           string   `xorm:"VARCHAR(255) NOT NULL"`
+		Name            string   `xorm:"VARCHAR(255)"`
+		Needs           []string `xorm:"TEXT JSON"`
+		RunsOn          []string `xorm:"TEXT JSON"`
+		WorkflowPayload string   `xorm:"LONGTEXT"`
+		Status          int      `xorm:"INDEX NOT NULL DEFAULT 0"`
+		RunnerID        int64    `xorm:"INDEX NOT NULL DEFAULT 0"`
+		LogLength       int64    `xorm:"NOT NULL DEFAULT 0"`
+		LogChunks       int64    `xorm:"NOT NULL DEFAULT 0"`
+
+		TokenHash        string
+		TokenSalt        string
+		TokenLastEight   string `xorm:"INDEX token_last_eight"`
+		TokenExpiresUnix timeutil.TimeStamp
+
+		StartedUnix timeutil.TimeStamp
+		StoppedUnix timeutil.TimeStamp
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"updated INDEX"`
+	}
+
+	if err := x.Sync2(new(ActionRunner), new(ActionRunnerToken), new(ActionRun), new(ActionRunJob)); err != nil {
+		return fmt.Errorf("sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addFederationTables(x *xorm.Engine) error {
+	type FederationActor struct {
+		ID                int64  `xorm:"pk autoincr"`
+		IRI               string `xorm:"VARCHAR(255) UNIQUE NOT NULL"`
+		Type              string `xorm:"NOT NULL"`
+		PreferredUsername string
+		Name              string
+		Host              string             `xorm:"INDEX NOT NULL"`
+		URL               string             `xorm:"TEXT"`
+		Inbox             string             `xorm:"TEXT NOT NULL"`
+		PublicKeyID       string             `xorm:"TEXT"`
+		PublicKeyPem      string             `xorm:"TEXT"`
+		CreatedUnix       timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix       timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type FederationFollower struct {
+		ID          int64              `xorm:"pk autoincr"`
+		ActorID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		UserID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+		RepoID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	}
+
+	type FederationFollowing struct {
+		ID          int64              `xorm:"pk autoincr"`
+		UserID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		ActorID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		ActivityIRI string             `xorm:"VARCHAR(255) INDEX NOT NULL"`
+		IsAccepted  bool               `xorm:"NOT NULL DEFAULT false"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	}
+
+	type FederationStar struct {
+		ID          int64              `xorm:"pk autoincr"`
+		ActorID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		RepoID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	}
+
+	type FederationActivity struct {
+		ID          int64              `xorm:"pk autoincr"`
+		IRI         string             `xorm:"VARCHAR(255) UNIQUE NOT NULL"`
+		Type        string             `xorm:"NOT NULL"`
+		UserID      int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+		RepoID      int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+		IsOutbound  bool               `xorm:"INDEX NOT NULL DEFAULT false"`
+		Content     string             `xorm:"LONGTEXT"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created INDEX"`
+	}
+
+	type FederationKeyPair struct {
+		ID          int64              `xorm:"pk autoincr"`
+		UserID      int64              `xorm:"UNIQUE(s) NOT NULL DEFAULT 0"`
+		RepoID      int64              `xorm:"UNIQUE(s) NOT NULL DEFAULT 0"`
+		PrivateKey  string             `xorm:"TEXT NOT NULL"`
+		PublicKey   string             `xorm:"TEXT NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	}
+
+	if err := x.Sync2(
+		new(FederationActor),
+		new(FederationFollower),
+		new(FederationFollowing),
+		new(FederationStar),
+		new(FederationActivity),
+		new(FederationKeyPair),
+	); err != nil {
+		return fmt.Errorf("sync2: %v", err)
+	}
+	return nil
+}
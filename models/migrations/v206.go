@@ -0,0 +1,42 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addMergeQueue(x *xorm.Engine) error {
+	type ProtectedBranch struct {
+		EnableMergeQueue bool `xorm:"NOT NULL DEFAULT false"`
+	}
+
+	type MergeQueueEntry struct {
+		ID           int64              `xorm:"pk autoincr"`
+		RepoID       int64              `xorm:"INDEX(branch) NOT NULL"`
+		BaseBranch   string             `xorm:"INDEX(branch) NOT NULL"`
+		PullID       int64              `xorm:"UNIQUE NOT NULL"`
+		DoerID       int64              `xorm:"NOT NULL"`
+		MergeStyle   string             `xorm:"VARCHAR(30)"`
+		Message      string             `xorm:"TEXT"`
+		HeadCommitID string             `xorm:"VARCHAR(40)"`
+		BaseCommitID string             `xorm:"VARCHAR(40)"`
+		CommitID     string             `xorm:"VARCHAR(40) INDEX"`
+		CreatedUnix  timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix  timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	if err := x.Sync2(new(ProtectedBranch)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	if err := x.Sync2(new(MergeQueueEntry)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
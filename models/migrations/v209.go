@@ -0,0 +1,42 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/gobwas/glob"
+	"xorm.io/xorm"
+)
+
+func addGlobRulesToProtectedBranch(x *xorm.Engine) error {
+	type ProtectedBranch struct {
+		ID         int64  `xorm:"pk autoincr"`
+		RepoID     int64  `xorm:"UNIQUE(s)"`
+		BranchName string `xorm:"UNIQUE(s)"`
+		Priority   int64  `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	if err := x.Sync2(new(ProtectedBranch)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	// Branch names are interpreted as glob patterns now, existing rules have to keep protecting exactly their branch
+	rules := make([]*ProtectedBranch, 0, 10)
+	if err := x.Find(&rules); err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		quoted := glob.QuoteMeta(rule.BranchName)
+		if quoted == rule.BranchName {
+			continue
+		}
+		rule.BranchName = quoted
+		if _, err := x.ID(rule.ID).Cols("branch_name").Update(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addAuditEventTable(x *xorm.Engine) error {
+	type AuditEvent struct {
+		ID          int64  `xorm:"pk autoincr"`
+		Action      string `xorm:"VARCHAR(50) INDEX NOT NULL"`
+		ActorID     int64  `xorm:"INDEX"`
+		ActorName   string
+		IPAddress   string `xorm:"VARCHAR(64)"`
+		TargetType  string `xorm:"VARCHAR(30) INDEX"`
+		TargetID    int64  `xorm:"INDEX"`
+		TargetName  string
+		Before      string             `xorm:"TEXT"`
+		After       string             `xorm:"TEXT"`
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	}
+
+	if err := x.Sync2(new(AuditEvent)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
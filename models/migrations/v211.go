@@ -0,0 +1,37 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addForeignReferenceTableAndMirrorMetadataSync(x *xorm.Engine) error {
+	type ForeignReference struct {
+		ID           int64  `xorm:"pk autoincr"`
+		RepoID       int64  `xorm:"UNIQUE(repo_foreign_type) INDEX(repo_local)"`
+		LocalIndex   int64  `xorm:"INDEX(repo_local)"`
+		ForeignIndex string `xorm:"UNIQUE(repo_foreign_type)"`
+		Type         string `xorm:"VARCHAR(16) UNIQUE(repo_foreign_type) INDEX(repo_local)"`
+	}
+
+	if err := x.Sync2(new(ForeignReference)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	type Mirror struct {
+		MetadataConfig     string `xorm:"TEXT"`
+		MetadataSyncedUnix timeutil.TimeStamp
+	}
+
+	if err := x.Sync2(new(Mirror)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
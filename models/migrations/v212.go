@@ -0,0 +1,39 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addPushMirrorSyncOnPushAndAttempts(x *xorm.Engine) error {
+	type PushMirror struct {
+		SyncOnPush  bool   `xorm:"NOT NULL DEFAULT false"`
+		IncludeRefs string `xorm:"TEXT"`
+		ExcludeRefs string `xorm:"TEXT"`
+	}
+
+	if err := x.Sync2(new(PushMirror)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	type PushMirrorAttempt struct {
+		ID           int64  `xorm:"pk autoincr"`
+		PushMirrorID int64  `xorm:"INDEX"`
+		RepoID       int64  `xorm:"INDEX"`
+		Error        string `xorm:"TEXT"`
+		StartedUnix  timeutil.TimeStamp
+		EndedUnix    timeutil.TimeStamp
+	}
+
+	if err := x.Sync2(new(PushMirrorAttempt)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
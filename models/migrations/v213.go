@@ -0,0 +1,29 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+func addRepoSymbolTable(x *xorm.Engine) error {
+	type RepoSymbol struct {
+		ID       int64  `xorm:"pk autoincr"`
+		RepoID   int64  `xorm:"INDEX(s) NOT NULL"`
+		Name     string `xorm:"INDEX(s) VARCHAR(255) NOT NULL"`
+		Kind     string `xorm:"VARCHAR(20)"`
+		Language string `xorm:"VARCHAR(50)"`
+		Path     string `xorm:"TEXT NOT NULL"`
+		Line     int
+		CommitID string `xorm:"VARCHAR(40)"`
+	}
+
+	if err := x.Sync2(new(RepoSymbol)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
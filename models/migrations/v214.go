@@ -0,0 +1,34 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addWebhookDeliveryRetries(x *xorm.Engine) error {
+	type HookTask struct {
+		Attempts        int
+		NextAttemptUnix timeutil.TimeStamp `xorm:"INDEX"`
+		AttemptsContent string             `xorm:"TEXT"`
+	}
+
+	type Webhook struct {
+		MaxAttempts         int
+		ConsecutiveFailures int `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	if err := x.Sync2(new(HookTask)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	if err := x.Sync2(new(Webhook)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
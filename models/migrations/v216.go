@@ -0,0 +1,30 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addProjectAutomationRuleTable(x *xorm.Engine) error {
+	type ProjectAutomationRule struct {
+		ID          int64 `xorm:"pk autoincr"`
+		ProjectID   int64 `xorm:"INDEX NOT NULL"`
+		Event       int   `xorm:"NOT NULL"`
+		BoardID     int64
+		Labels      []string `xorm:"TEXT JSON"`
+		CreatorID   int64
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	}
+
+	if err := x.Sync2(new(ProjectAutomationRule)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"strings"
 
+	actions_model "code.gitea.io/gitea/models/actions"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/unit"
 	user_model "code.gitea.io/gitea/models/user"
@@ -336,10 +337,16 @@ func DeleteOrganization(ctx context.Context, org *Organization) error {
 		&OrgUser{OrgID: org.ID},
 		&TeamUser{OrgID: org.ID},
 		&TeamUnit{OrgID: org.ID},
+		&actions_model.ActionRunner{OwnerID: org.ID},
+		&actions_model.ActionRunnerToken{OwnerID: org.ID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err := deleteProjectsByOwnerID(e, org.ID); err != nil {
+		return fmt.Errorf("deleteProjectsByOwnerID: %v", err)
+	}
+
 	if _, err := e.ID(org.ID).Delete(new(User)); err != nil {
 		return fmt.Errorf("Delete: %v", err)
 	}
@@ -160,6 +160,18 @@ func (t *Team) IsMember(userID int64) bool {
 	return isMember
 }
 
+// FindTeamsByCond returns the teams of all organizations matching cond ordered by id skipping the first offset teams,
+// and the number of all matching teams. Caller is responsible to check permissions.
+func FindTeamsByCond(cond builder.Cond, offset, limit int) ([]*Team, int64, error) {
+	teams := make([]*Team, 0, limit)
+	count, err := db.GetEngine(db.DefaultContext).
+		Where(cond).
+		OrderBy("id").
+		Limit(limit, offset).
+		FindAndCount(&teams)
+	return teams, count, err
+}
+
 func (t *Team) getRepositories(e db.Engine) error {
 	if t.Repos != nil {
 		return nil
@@ -0,0 +1,44 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unit"
+)
+
+// GetPackageAccessMode returns the access mode of the user to the packages of the owner
+func GetPackageAccessMode(owner, user *User) (AccessMode, error) {
+	return getPackageAccessMode(db.GetEngine(db.DefaultContext), owner, user)
+}
+
+func getPackageAccessMode(e db.Engine, owner, user *User) (AccessMode, error) {
+	if user != nil && (user.IsAdmin || user.ID == owner.ID) {
+		return AccessModeOwner, nil
+	}
+
+	if !hasOrgOrUserVisible(e, owner, user) {
+		return AccessModeNone, nil
+	}
+
+	mode := AccessModeRead
+	if user == nil || !owner.IsOrganization() {
+		return mode, nil
+	}
+
+	teams, err := getUserOrgTeams(e, owner.ID, user.ID)
+	if err != nil {
+		return AccessModeNone, err
+	}
+	for _, team := range teams {
+		if team.Authorize >= AccessModeOwner {
+			return AccessModeOwner, nil
+		}
+		if team.unitEnabled(e, unit.TypePackages) && team.Authorize > mode {
+			mode = team.Authorize
+		}
+	}
+	return mode, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrDuplicatePackageVersion indicates a duplicated package version error
+	ErrDuplicatePackageVersion = errors.New("Package version does exist already")
+	// ErrDuplicatePackageFile indicates a duplicated package file error
+	ErrDuplicatePackageFile = errors.New("Package file does exist already")
+	// ErrPackageFileNotExist indicates a package file not exist error
+	ErrPackageFileNotExist = errors.New("Package file does not exist")
+	// ErrPackageBlobNotExist indicates a package blob not exist error
+	ErrPackageBlobNotExist = errors.New("Package blob does not exist")
+	// ErrPackageBlobUploadNotExist indicates a package blob upload not exist error
+	ErrPackageBlobUploadNotExist = errors.New("Package blob upload does not exist")
+)
+
+// ErrPackageNotExist represents a "PackageNotExist" kind of error.
+type ErrPackageNotExist struct {
+	ID      int64
+	OwnerID int64
+	Type    Type
+	Name    string
+}
+
+// IsErrPackageNotExist checks if an error is a ErrPackageNotExist.
+func IsErrPackageNotExist(err error) bool {
+	_, ok := err.(ErrPackageNotExist)
+	return ok
+}
+
+func (err ErrPackageNotExist) Error() string {
+	return fmt.Sprintf("package does not exist [id: %d, owner_id: %d, type: %s, name: %s]", err.ID, err.OwnerID, err.Type, err.Name)
+}
+
+// ErrPackageVersionNotExist represents a "PackageVersionNotExist" kind of error.
+type ErrPackageVersionNotExist struct {
+	ID      int64
+	Name    string
+	Version string
+}
+
+// IsErrPackageVersionNotExist checks if an error is a ErrPackageVersionNotExist.
+func IsErrPackageVersionNotExist(err error) bool {
+	_, ok := err.(ErrPackageVersionNotExist)
+	return ok
+}
+
+func (err ErrPackageVersionNotExist) Error() string {
+	return fmt.Sprintf("package version does not exist [id: %d, name: %s, version: %s]", err.ID, err.Name, err.Version)
+}
@@ -0,0 +1,194 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(Package))
+}
+
+// Type of a package
+type Type string
+
+// List of supported packages
+const (
+	TypeContainer Type = "container"
+	TypeMaven     Type = "maven"
+	TypeNpm       Type = "npm"
+)
+
+// TypeList contains all supported package types
+var TypeList = []Type{
+	TypeContainer,
+	TypeMaven,
+	TypeNpm,
+}
+
+// Name gets the name of the package type
+func (pt Type) Name() string {
+	switch pt {
+	case TypeContainer:
+		return "Container"
+	case TypeMaven:
+		return "Maven"
+	case TypeNpm:
+		return "npm"
+	}
+	panic(fmt.Sprintf("unknown package type: %s", string(pt)))
+}
+
+// SVGName gets the name of the package type svg image
+func (pt Type) SVGName() string {
+	switch pt {
+	case TypeContainer:
+		return "octicon-container"
+	case TypeMaven, TypeNpm:
+		return "octicon-package"
+	}
+	panic(fmt.Sprintf("unknown package type: %s", string(pt)))
+}
+
+// IsValid checks if the type is a supported package type
+func (pt Type) IsValid() bool {
+	for _, t := range TypeList {
+		if t == pt {
+			return true
+		}
+	}
+	return false
+}
+
+// Package represents a package owned by a user or an organization
+type Package struct {
+	ID          int64              `xorm:"pk autoincr"`
+	OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Type        Type               `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Name        string             `xorm:"NOT NULL"`
+	LowerName   string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created INDEX NOT NULL"`
+}
+
+// TryInsertPackage inserts a package. If a package with the same owner, type and name exists already, it gets returned.
+func TryInsertPackage(ctx context.Context, p *Package) (*Package, error) {
+	e := db.GetEngine(ctx)
+
+	p.LowerName = strings.ToLower(p.Name)
+
+	key := &Package{
+		OwnerID:   p.OwnerID,
+		Type:      p.Type,
+		LowerName: p.LowerName,
+	}
+
+	has, err := e.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return key, nil
+	}
+	if _, err = e.Insert(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetPackageByID gets a package by id
+func GetPackageByID(ctx context.Context, packageID int64) (*Package, error) {
+	p := &Package{}
+
+	has, err := db.GetEngine(ctx).ID(packageID).Get(p)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrPackageNotExist{ID: packageID}
+	}
+	return p, nil
+}
+
+// GetPackageByName gets a package by name
+func GetPackageByName(ctx context.Context, ownerID int64, packageType Type, name string) (*Package, error) {
+	p := &Package{}
+
+	has, err := db.GetEngine(ctx).
+		Where("owner_id = ? AND type = ? AND lower_name = ?", ownerID, packageType, strings.ToLower(name)).
+		Get(p)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrPackageNotExist{OwnerID: ownerID, Type: packageType, Name: name}
+	}
+	return p, nil
+}
+
+// PackageSearchOptions are options for SearchPackages
+type PackageSearchOptions struct {
+	db.ListOptions
+	OwnerID int64
+	Type    Type
+	Query   string
+}
+
+func (opts *PackageSearchOptions) toConds() builder.Cond {
+	// packages which only contain internal versions (e.g. pending container uploads) are hidden
+	cond := builder.In("id", builder.Select("package_id").From("package_version").Where(builder.Eq{"is_internal": false}))
+	if opts.OwnerID != 0 {
+		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
+	}
+	if opts.Type != "" {
+		cond = cond.And(builder.Eq{"type": opts.Type})
+	}
+	if opts.Query != "" {
+		cond = cond.And(builder.Like{"lower_name", strings.ToLower(opts.Query)})
+	}
+	return cond
+}
+
+// SearchPackages searches for packages by the given options
+func SearchPackages(ctx context.Context, opts *PackageSearchOptions) ([]*Package, int64, error) {
+	sess := db.GetEngine(ctx).
+		Where(opts.toConds()).
+		OrderBy("lower_name ASC")
+	if opts.Page > 0 {
+		sess = db.SetSessionPagination(sess, &opts.ListOptions)
+	}
+
+	ps := make([]*Package, 0, 10)
+	count, err := sess.FindAndCount(&ps)
+	return ps, count, err
+}
+
+// HasOwnerPackages tests if a user or an organization has packages
+func HasOwnerPackages(ctx context.Context, ownerID int64) (bool, error) {
+	return db.GetEngine(ctx).Where("owner_id = ?", ownerID).Exist(&Package{})
+}
+
+// DeletePackageByIDIfUnreferenced deletes a package if there are no associated versions
+func DeletePackageByIDIfUnreferenced(ctx context.Context, packageID int64) error {
+	e := db.GetEngine(ctx)
+
+	has, err := e.Where("package_id = ?", packageID).Exist(&PackageVersion{})
+	if err != nil {
+		return err
+	}
+	if has {
+		return nil
+	}
+
+	_, err = e.ID(packageID).Delete(&Package{})
+	return err
+}
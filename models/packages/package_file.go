@@ -0,0 +1,126 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"context"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+func init() {
+	db.RegisterModel(new(PackageFile))
+}
+
+// PackageFile represents a file of a package version
+type PackageFile struct {
+	ID          int64              `xorm:"pk autoincr"`
+	VersionID   int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	BlobID      int64              `xorm:"INDEX NOT NULL"`
+	Name        string             `xorm:"NOT NULL"`
+	LowerName   string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	IsLead      bool               `xorm:"NOT NULL DEFAULT false"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created INDEX NOT NULL"`
+}
+
+// TryInsertFile inserts a file. If the file exists already ErrDuplicatePackageFile is returned
+func TryInsertFile(ctx context.Context, pf *PackageFile) (*PackageFile, error) {
+	e := db.GetEngine(ctx)
+
+	pf.LowerName = strings.ToLower(pf.Name)
+
+	key := &PackageFile{
+		VersionID: pf.VersionID,
+		LowerName: pf.LowerName,
+	}
+
+	has, err := e.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return pf, ErrDuplicatePackageFile
+	}
+	if _, err = e.Insert(pf); err != nil {
+		return nil, err
+	}
+	return pf, nil
+}
+
+// GetFilesByVersionID gets all files of a version
+func GetFilesByVersionID(ctx context.Context, versionID int64) ([]*PackageFile, error) {
+	pfs := make([]*PackageFile, 0, 10)
+	return pfs, db.GetEngine(ctx).Where("version_id = ?", versionID).Find(&pfs)
+}
+
+// GetFileForVersionByName gets a file of a version by name
+func GetFileForVersionByName(ctx context.Context, versionID int64, name string) (*PackageFile, error) {
+	if name == "" {
+		return nil, ErrPackageFileNotExist
+	}
+
+	pf := &PackageFile{
+		VersionID: versionID,
+		LowerName: strings.ToLower(name),
+	}
+
+	has, err := db.GetEngine(ctx).Get(pf)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrPackageFileNotExist
+	}
+	return pf, nil
+}
+
+// GetFileForPackageByName gets a file of any version of a package by name.
+// It is used by protocols which address content independent of the version (e.g. container blobs by digest).
+func GetFileForPackageByName(ctx context.Context, packageID int64, name string) (*PackageFile, error) {
+	pf := &PackageFile{}
+
+	has, err := db.GetEngine(ctx).
+		Join("INNER", "package_version", "package_version.id = package_file.version_id").
+		Where("package_version.package_id = ? AND package_file.lower_name = ?", packageID, strings.ToLower(name)).
+		Get(pf)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrPackageFileNotExist
+	}
+	return pf, nil
+}
+
+// GetLeadFileForPackageByName gets the lead file of any version of a package by name
+func GetLeadFileForPackageByName(ctx context.Context, packageID int64, name string) (*PackageFile, error) {
+	pf := &PackageFile{}
+
+	has, err := db.GetEngine(ctx).
+		Join("INNER", "package_version", "package_version.id = package_file.version_id").
+		Where("package_version.package_id = ? AND package_file.lower_name = ? AND package_file.is_lead = ?", packageID, strings.ToLower(name), true).
+		Get(pf)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrPackageFileNotExist
+	}
+	return pf, nil
+}
+
+// DeleteFileByID deletes a file
+func DeleteFileByID(ctx context.Context, fileID int64) error {
+	_, err := db.GetEngine(ctx).ID(fileID).Delete(&PackageFile{})
+	return err
+}
+
+// DeleteFilesByVersionID deletes all files of a version
+func DeleteFilesByVersionID(ctx context.Context, versionID int64) error {
+	_, err := db.GetEngine(ctx).Where("version_id = ?", versionID).Delete(&PackageFile{})
+	return err
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackages(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	ctx := db.DefaultContext
+
+	p, err := TryInsertPackage(ctx, &Package{OwnerID: 2, Type: TypeNpm, Name: "Test-Package"})
+	assert.NoError(t, err)
+	assert.Equal(t, "test-package", p.LowerName)
+
+	p2, err := TryInsertPackage(ctx, &Package{OwnerID: 2, Type: TypeNpm, Name: "test-package"})
+	assert.NoError(t, err)
+	assert.Equal(t, p.ID, p2.ID)
+
+	// packages without public versions are hidden
+	ps, count, err := SearchPackages(ctx, &PackageSearchOptions{OwnerID: 2})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+	assert.Empty(t, ps)
+
+	internal, err := GetOrInsertVersion(ctx, &PackageVersion{PackageID: p.ID, Version: "_upload", IsInternal: true})
+	assert.NoError(t, err)
+
+	ps, count, err = SearchPackages(ctx, &PackageSearchOptions{OwnerID: 2})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+	assert.Empty(t, ps)
+
+	pv, err := GetOrInsertVersion(ctx, &PackageVersion{PackageID: p.ID, CreatorID: 2, Version: "1.0.0"})
+	assert.NoError(t, err)
+
+	existing, err := GetOrInsertVersion(ctx, &PackageVersion{PackageID: p.ID, Version: "1.0.0"})
+	assert.ErrorIs(t, err, ErrDuplicatePackageVersion)
+	assert.Equal(t, pv.ID, existing.ID)
+
+	ps, count, err = SearchPackages(ctx, &PackageSearchOptions{OwnerID: 2, Type: TypeNpm, Query: "package"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	assert.Len(t, ps, 1)
+
+	pv2, err := GetVersionByNameAndVersion(ctx, 2, TypeNpm, "TEST-PACKAGE", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, pv.ID, pv2.ID)
+
+	_, err = GetVersionByNameAndVersion(ctx, 2, TypeNpm, "test-package", "_upload")
+	assert.True(t, IsErrPackageVersionNotExist(err))
+
+	pv2, err = GetInternalVersionByNameAndVersion(ctx, 2, TypeNpm, "test-package", "_upload")
+	assert.NoError(t, err)
+	assert.Equal(t, internal.ID, pv2.ID)
+
+	has, err := HasOwnerPackages(ctx, 2)
+	assert.NoError(t, err)
+	assert.True(t, has)
+
+	// the package is only deleted if all versions are gone
+	assert.NoError(t, DeleteVersionByID(ctx, pv.ID))
+	assert.NoError(t, DeletePackageByIDIfUnreferenced(ctx, p.ID))
+	_, err = GetPackageByID(ctx, p.ID)
+	assert.NoError(t, err)
+
+	assert.NoError(t, DeleteVersionByID(ctx, internal.ID))
+	assert.NoError(t, DeletePackageByIDIfUnreferenced(ctx, p.ID))
+	_, err = GetPackageByID(ctx, p.ID)
+	assert.True(t, IsErrPackageNotExist(err))
+}
+
+func TestPackageFilesAndBlobs(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	ctx := db.DefaultContext
+
+	p, err := TryInsertPackage(ctx, &Package{OwnerID: 2, Type: TypeMaven, Name: "com.gitea:test"})
+	assert.NoError(t, err)
+	pv, err := GetOrInsertVersion(ctx, &PackageVersion{PackageID: p.ID, Version: "1.0"})
+	assert.NoError(t, err)
+
+	blob := &PackageBlob{
+		Size:       4,
+		HashMD5:    "md5",
+		HashSHA1:   "sha1",
+		HashSHA256: "sha256",
+		HashSHA512: "sha512",
+	}
+	pb, exists, err := GetOrInsertBlob(ctx, blob)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	pb2, exists, err := GetOrInsertBlob(ctx, &PackageBlob{HashSHA256: "sha256"})
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, pb.ID, pb2.ID)
+
+	pf, err := TryInsertFile(ctx, &PackageFile{VersionID: pv.ID, BlobID: pb.ID, Name: "Test-1.0.jar", IsLead: true})
+	assert.NoError(t, err)
+
+	_, err = TryInsertFile(ctx, &PackageFile{VersionID: pv.ID, BlobID: pb.ID, Name: "test-1.0.jar"})
+	assert.ErrorIs(t, err, ErrDuplicatePackageFile)
+
+	pf2, err := GetFileForVersionByName(ctx, pv.ID, "TEST-1.0.JAR")
+	assert.NoError(t, err)
+	assert.Equal(t, pf.ID, pf2.ID)
+
+	pf2, err = GetLeadFileForPackageByName(ctx, p.ID, "test-1.0.jar")
+	assert.NoError(t, err)
+	assert.Equal(t, pf.ID, pf2.ID)
+
+	unreferenced, err := FindUnreferencedBlobs(ctx, timeutil.TimeStampNow()+1)
+	assert.NoError(t, err)
+	assert.Empty(t, unreferenced)
+
+	assert.NoError(t, DeleteFilesByVersionID(ctx, pv.ID))
+
+	unreferenced, err = FindUnreferencedBlobs(ctx, timeutil.TimeStampNow()+1)
+	assert.NoError(t, err)
+	assert.Len(t, unreferenced, 1)
+	assert.Equal(t, pb.ID, unreferenced[0].ID)
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
@@ -44,11 +45,15 @@ type Project struct {
 	Title       string `xorm:"INDEX NOT NULL"`
 	Description string `xorm:"TEXT"`
 	RepoID      int64  `xorm:"INDEX"`
+	OwnerID     int64  `xorm:"INDEX"` // the user or organization owning an individual or organization project
 	CreatorID   int64  `xorm:"NOT NULL"`
 	IsClosed    bool   `xorm:"INDEX"`
 	BoardType   ProjectBoardType
 	Type        ProjectType
 
+	Repo  *Repository `xorm:"-"`
+	Owner *User       `xorm:"-"`
+
 	RenderedContent string `xorm:"-"`
 
 	CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
@@ -72,17 +77,80 @@ func GetProjectsConfig() []ProjectsConfig {
 // IsProjectTypeValid checks if a project type is valid
 func IsProjectTypeValid(p ProjectType) bool {
 	switch p {
-	case ProjectTypeRepository:
+	case ProjectTypeIndividual, ProjectTypeRepository, ProjectTypeOrganization:
 		return true
 	default:
 		return false
 	}
 }
 
+// LoadRepo loads the repository of a repository project
+func (p *Project) LoadRepo() (err error) {
+	return p.loadRepo(db.GetEngine(db.DefaultContext))
+}
+
+func (p *Project) loadRepo(e db.Engine) (err error) {
+	if p.Repo == nil && p.RepoID > 0 {
+		p.Repo, err = getRepositoryByID(e, p.RepoID)
+	}
+	return err
+}
+
+// LoadOwner loads the user or organization owning an individual or organization project
+func (p *Project) LoadOwner() (err error) {
+	return p.loadOwner(db.GetEngine(db.DefaultContext))
+}
+
+func (p *Project) loadOwner(e db.Engine) (err error) {
+	if p.Owner == nil && p.OwnerID > 0 {
+		p.Owner, err = getUserByID(e, p.OwnerID)
+	}
+	return err
+}
+
+// Link returns the link to the project
+func (p *Project) Link() string {
+	if p.Type == ProjectTypeRepository {
+		if err := p.LoadRepo(); err != nil {
+			log.Error("LoadRepo: %v", err)
+			return ""
+		}
+		return fmt.Sprintf("%s/projects/%d", p.Repo.Link(), p.ID)
+	}
+	if err := p.LoadOwner(); err != nil {
+		log.Error("LoadOwner: %v", err)
+		return ""
+	}
+	return fmt.Sprintf("%s/-/projects/%d", p.Owner.HomeLink(), p.ID)
+}
+
+// HTMLURL returns the absolute URL to the project
+func (p *Project) HTMLURL() string {
+	if p.Type == ProjectTypeRepository {
+		if err := p.LoadRepo(); err != nil {
+			log.Error("LoadRepo: %v", err)
+			return ""
+		}
+		return fmt.Sprintf("%s/projects/%d", p.Repo.HTMLURL(), p.ID)
+	}
+	if err := p.LoadOwner(); err != nil {
+		log.Error("LoadOwner: %v", err)
+		return ""
+	}
+	return fmt.Sprintf("%s/-/projects/%d", p.Owner.HTMLURL(), p.ID)
+}
+
+// APIURL returns the absolute API URL to the project
+func (p *Project) APIURL() string {
+	return fmt.Sprintf("%sapi/v1/projects/%d", setting.AppURL, p.ID)
+}
+
 // ProjectSearchOptions are options for GetProjects
 type ProjectSearchOptions struct {
 	RepoID   int64
+	OwnerID  int64
 	Page     int
+	PageSize int // setting.UI.IssuePagingNum if not set
 	IsClosed util.OptionalBool
 	SortType string
 	Type     ProjectType
@@ -94,20 +162,13 @@ func GetProjects(opts ProjectSearchOptions) ([]*Project, int64, error) {
 }
 
 func getProjects(e db.Engine, opts ProjectSearchOptions) ([]*Project, int64, error) {
-	projects := make([]*Project, 0, setting.UI.IssuePagingNum)
-
-	var cond builder.Cond = builder.Eq{"repo_id": opts.RepoID}
-	switch opts.IsClosed {
-	case util.OptionalBoolTrue:
-		cond = cond.And(builder.Eq{"is_closed": true})
-	case util.OptionalBoolFalse:
-		cond = cond.And(builder.Eq{"is_closed": false})
-	}
-
-	if opts.Type > 0 {
-		cond = cond.And(builder.Eq{"type": opts.Type})
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = setting.UI.IssuePagingNum
 	}
+	projects := make([]*Project, 0, pageSize)
 
+	cond := opts.toCond()
 	count, err := e.Where(cond).Count(new(Project))
 	if err != nil {
 		return nil, 0, fmt.Errorf("Count: %v", err)
@@ -116,7 +177,7 @@ func getProjects(e db.Engine, opts ProjectSearchOptions) ([]*Project, int64, err
 	e = e.Where(cond)
 
 	if opts.Page > 0 {
-		e = e.Limit(setting.UI.IssuePagingNum, (opts.Page-1)*setting.UI.IssuePagingNum)
+		e = e.Limit(pageSize, (opts.Page-1)*pageSize)
 	}
 
 	switch opts.SortType {
@@ -133,6 +194,31 @@ func getProjects(e db.Engine, opts ProjectSearchOptions) ([]*Project, int64, err
 	return projects, count, e.Find(&projects)
 }
 
+// toCond returns the condition matching the projects, the projects of a repository if RepoID
+// is set and the projects of a user or an organization otherwise
+func (opts *ProjectSearchOptions) toCond() builder.Cond {
+	var cond builder.Cond = builder.Eq{"repo_id": opts.RepoID}
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
+	}
+	switch opts.IsClosed {
+	case util.OptionalBoolTrue:
+		cond = cond.And(builder.Eq{"is_closed": true})
+	case util.OptionalBoolFalse:
+		cond = cond.And(builder.Eq{"is_closed": false})
+	}
+
+	if opts.Type > 0 {
+		cond = cond.And(builder.Eq{"type": opts.Type})
+	}
+	return cond
+}
+
+// CountProjects returns the number of projects matching the options
+func CountProjects(opts ProjectSearchOptions) (int64, error) {
+	return db.GetEngine(db.DefaultContext).Where(opts.toCond()).Count(new(Project))
+}
+
 // NewProject creates a new Project
 func NewProject(p *Project) error {
 	if !IsProjectBoardTypeValid(p.BoardType) {
@@ -142,6 +228,13 @@ func NewProject(p *Project) error {
 	if !IsProjectTypeValid(p.Type) {
 		return errors.New("project type is not valid")
 	}
+	if p.Type == ProjectTypeRepository {
+		if p.RepoID == 0 || p.OwnerID > 0 {
+			return errors.New("repository project must only belong to a repository")
+		}
+	} else if p.OwnerID == 0 || p.RepoID > 0 {
+		return errors.New("individual or organization project must only belong to a user or an organization")
+	}
 
 	ctx, committer, err := db.TxContext()
 	if err != nil {
@@ -153,8 +246,10 @@ func NewProject(p *Project) error {
 		return err
 	}
 
-	if _, err := db.Exec(ctx, "UPDATE `repository` SET num_projects = num_projects + 1 WHERE id = ?", p.RepoID); err != nil {
-		return err
+	if p.RepoID > 0 {
+		if _, err := db.Exec(ctx, "UPDATE `repository` SET num_projects = num_projects + 1 WHERE id = ?", p.RepoID); err != nil {
+			return err
+		}
 	}
 
 	if err := createBoardsForProjectsType(db.GetEngine(ctx), p); err != nil {
@@ -196,6 +291,10 @@ func updateProject(e db.Engine, p *Project) error {
 }
 
 func updateRepositoryProjectCount(e db.Engine, repoID int64) error {
+	if repoID == 0 {
+		return nil
+	}
+
 	if _, err := e.Exec(builder.Update(
 		builder.Eq{
 			"`num_projects`": builder.Select("count(*)").From("`project`").
@@ -303,9 +402,28 @@ func deleteProjectByID(e db.Engine, id int64) error {
 		return err
 	}
 
+	if err := deleteProjectAutomationRulesByProjectID(e, id); err != nil {
+		return err
+	}
+
 	if _, err = e.ID(p.ID).Delete(new(Project)); err != nil {
 		return err
 	}
 
 	return updateRepositoryProjectCount(e, p.RepoID)
 }
+
+func deleteProjectsByOwnerID(e db.Engine, ownerID int64) error {
+	projects, _, err := getProjects(e, ProjectSearchOptions{
+		OwnerID: ownerID,
+	})
+	if err != nil {
+		return fmt.Errorf("get projects: %v", err)
+	}
+	for i := range projects {
+		if err := deleteProjectByID(e, projects[i].ID); err != nil {
+			return fmt.Errorf("delete project [%d]: %v", projects[i].ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/references"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// ProjectAutomationEvent is the event triggering a project automation rule
+type ProjectAutomationEvent int
+
+const (
+	// ProjectAutomationIssueOpened adds an issue, opened or labeled, to the project if it has one
+	// of the labels of the rule
+	ProjectAutomationIssueOpened ProjectAutomationEvent = iota + 1
+
+	// ProjectAutomationPullOpened moves the cards of the issues referenced by a pull request when
+	// it is opened
+	ProjectAutomationPullOpened
+
+	// ProjectAutomationIssueClosed moves the card of an issue when it is closed
+	ProjectAutomationIssueClosed
+
+	// ProjectAutomationPullMerged moves the cards of a pull request and of the issues it
+	// references when it is merged
+	ProjectAutomationPullMerged
+)
+
+// ProjectAutomationEvents are the events of the project automation rules, in the order they are
+// shown
+var ProjectAutomationEvents = []ProjectAutomationEvent{
+	ProjectAutomationIssueOpened,
+	ProjectAutomationPullOpened,
+	ProjectAutomationIssueClosed,
+	ProjectAutomationPullMerged,
+}
+
+// IsValid returns true if the event is known
+func (e ProjectAutomationEvent) IsValid() bool {
+	return e >= ProjectAutomationIssueOpened && e <= ProjectAutomationPullMerged
+}
+
+// Name returns the name of the event
+func (e ProjectAutomationEvent) Name() string {
+	switch e {
+	case ProjectAutomationIssueOpened:
+		return "issue_opened"
+	case ProjectAutomationPullOpened:
+		return "pull_opened"
+	case ProjectAutomationIssueClosed:
+		return "issue_closed"
+	case ProjectAutomationPullMerged:
+		return "pull_merged"
+	}
+	return ""
+}
+
+// ProjectAutomationRule is a rule adding the issues to a project or moving its cards to a board
+// when an event occurs
+type ProjectAutomationRule struct {
+	ID        int64                  `xorm:"pk autoincr"`
+	ProjectID int64                  `xorm:"INDEX NOT NULL"`
+	Event     ProjectAutomationEvent `xorm:"NOT NULL"`
+	// the board the cards are moved to or added on, 0 for none
+	BoardID int64
+	// the names of the labels of which an issue must have one to be added, any issue if empty
+	Labels    []string `xorm:"TEXT JSON"`
+	CreatorID int64
+
+	Board *ProjectBoard `xorm:"-"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+func init() {
+	db.RegisterModel(new(ProjectAutomationRule))
+}
+
+// MatchLabels returns true if one of the labels is in the label filter of the rule, or if the
+// rule has no label filter
+func (rule *ProjectAutomationRule) MatchLabels(labels []*Label) bool {
+	if len(rule.Labels) == 0 {
+		return true
+	}
+	for _, label := range labels {
+		for _, name := range rule.Labels {
+			if strings.EqualFold(label.Name, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetProjectAutomationRules returns the automation rules of a project with their boards
+func GetProjectAutomationRules(projectID int64) ([]*ProjectAutomationRule, error) {
+	e := db.GetEngine(db.DefaultContext)
+	rules := make([]*ProjectAutomationRule, 0, 5)
+	if err := e.Where("project_id=?", projectID).OrderBy("event, id").Find(&rules); err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if rule.BoardID == 0 {
+			continue
+		}
+		board, err := getProjectBoard(e, rule.BoardID)
+		if err != nil {
+			return nil, err
+		}
+		rule.Board = board
+	}
+	return rules, nil
+}
+
+// NewProjectAutomationRule creates an automation rule for a project
+func NewProjectAutomationRule(rule *ProjectAutomationRule) error {
+	if !rule.Event.IsValid() {
+		return errors.New("project automation event is not valid")
+	}
+	if rule.Event != ProjectAutomationIssueOpened {
+		rule.Labels = nil
+	}
+
+	e := db.GetEngine(db.DefaultContext)
+	if rule.BoardID > 0 {
+		board, err := getProjectBoard(e, rule.BoardID)
+		if err != nil {
+			return err
+		}
+		if board.ProjectID != rule.ProjectID {
+			return ErrProjectBoardNotExist{BoardID: rule.BoardID}
+		}
+	}
+
+	_, err := e.Insert(rule)
+	return err
+}
+
+// DeleteProjectAutomationRule deletes an automation rule of a project
+func DeleteProjectAutomationRule(projectID, ruleID int64) error {
+	_, err := db.GetEngine(db.DefaultContext).Where("project_id=?", projectID).ID(ruleID).Delete(new(ProjectAutomationRule))
+	return err
+}
+
+func deleteProjectAutomationRulesByProjectID(e db.Engine, projectID int64) error {
+	_, err := e.Where("project_id=?", projectID).Delete(new(ProjectAutomationRule))
+	return err
+}
+
+func deleteProjectAutomationRulesByBoardID(e db.Engine, boardID int64) error {
+	_, err := e.Where("board_id=?", boardID).Delete(new(ProjectAutomationRule))
+	return err
+}
+
+// AutoAddIssueToProject adds an issue which is not in a project to the first open project, of its
+// repository or of the owner of its repository, having an automation rule matching its labels
+func AutoAddIssueToProject(issue *Issue, doer *User) error {
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return err
+	}
+	defer committer.Close()
+	e := db.GetEngine(ctx)
+
+	if issue.IsPull || issue.projectID(e) > 0 {
+		return nil
+	}
+	if err := issue.loadRepo(e); err != nil {
+		return err
+	}
+	// the labels of the issue may have just changed
+	labels, err := getLabelsByIssueID(e, issue.ID)
+	if err != nil {
+		return err
+	}
+
+	rules := make([]*ProjectAutomationRule, 0, 5)
+	if err := e.Table("project_automation_rule").
+		Join("INNER", "project", "project.id = project_automation_rule.project_id").
+		Where(builder.Eq{
+			"project_automation_rule.event": ProjectAutomationIssueOpened,
+			"project.is_closed":             false,
+		}.And(builder.Or(
+			builder.Eq{"project.repo_id": issue.RepoID, "project.type": ProjectTypeRepository},
+			builder.Eq{"project.owner_id": issue.Repo.OwnerID}.And(builder.Neq{"project.type": ProjectTypeRepository}),
+		))).
+		OrderBy("project_automation_rule.id").
+		Find(&rules); err != nil {
+		return fmt.Errorf("find rules: %v", err)
+	}
+
+	for _, rule := range rules {
+		if !rule.MatchLabels(labels) {
+			continue
+		}
+		if err := addUpdateIssueProject(ctx, issue, doer, rule.ProjectID); err != nil {
+			return err
+		}
+		if _, err := e.Where("issue_id=?", issue.ID).Cols("project_board_id").
+			Update(&ProjectIssue{ProjectBoardID: rule.BoardID}); err != nil {
+			return err
+		}
+		break
+	}
+
+	return committer.Commit()
+}
+
+// MoveProjectCardsOnEvent moves the cards of the issues to the board of the first automation rule
+// of their project for the event
+func MoveProjectCardsOnEvent(issues []*Issue, event ProjectAutomationEvent) error {
+	e := db.GetEngine(db.DefaultContext)
+	for _, issue := range issues {
+		projectID := issue.projectID(e)
+		if projectID == 0 {
+			continue
+		}
+
+		rule := new(ProjectAutomationRule)
+		has, err := e.Where("project_id=? AND event=?", projectID, event).OrderBy("id").Get(rule)
+		if err != nil {
+			return err
+		} else if !has {
+			continue
+		}
+
+		if _, err := e.Where("issue_id=? AND project_id=?", issue.ID, projectID).Cols("project_board_id").
+			Update(&ProjectIssue{ProjectBoardID: rule.BoardID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetIssuesReferencedByPull returns the issues referenced by a pull request
+func GetIssuesReferencedByPull(pr *PullRequest) (IssueList, error) {
+	e := db.GetEngine(db.DefaultContext)
+	issueIDs := make([]int64, 0, 5)
+	if err := e.Table("comment").
+		Where(builder.Eq{"ref_issue_id": pr.IssueID, "ref_is_pull": true}).
+		And(builder.Neq{"ref_action": references.XRefActionNeutered}).
+		In("type", CommentTypeIssueRef, CommentTypePullRef, CommentTypeCommentRef).
+		Distinct("issue_id").
+		Find(&issueIDs); err != nil {
+		return nil, err
+	}
+	if len(issueIDs) == 0 {
+		return IssueList{}, nil
+	}
+
+	issues := make(IssueList, 0, len(issueIDs))
+	return issues, e.In("id", issueIDs).And("is_pull=?", false).Find(&issues)
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/references"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectAutomationRule_MatchLabels(t *testing.T) {
+	labels := []*Label{{Name: "bug"}, {Name: "UI"}}
+
+	assert.True(t, (&ProjectAutomationRule{}).MatchLabels(labels))
+	assert.True(t, (&ProjectAutomationRule{}).MatchLabels(nil))
+	assert.True(t, (&ProjectAutomationRule{Labels: []string{"feature", "ui"}}).MatchLabels(labels))
+	assert.False(t, (&ProjectAutomationRule{Labels: []string{"feature"}}).MatchLabels(labels))
+	assert.False(t, (&ProjectAutomationRule{Labels: []string{"bug"}}).MatchLabels(nil))
+}
+
+func TestGetProjectAutomationRules(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	rules, err := GetProjectAutomationRules(1)
+	assert.NoError(t, err)
+	if assert.Len(t, rules, 4) {
+		assert.Equal(t, ProjectAutomationIssueOpened, rules[0].Event)
+		assert.Equal(t, []string{"label1"}, rules[0].Labels)
+		if assert.NotNil(t, rules[0].Board) {
+			assert.Equal(t, "To Do", rules[0].Board.Title)
+		}
+	}
+
+	rules, err = GetProjectAutomationRules(2)
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+}
+
+func TestNewProjectAutomationRule(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	assert.Error(t, NewProjectAutomationRule(&ProjectAutomationRule{ProjectID: 4, Event: 0}))
+
+	// the board belongs to another project
+	err := NewProjectAutomationRule(&ProjectAutomationRule{ProjectID: 4, Event: ProjectAutomationIssueClosed, BoardID: 1})
+	assert.True(t, IsErrProjectBoardNotExist(err))
+
+	rule := &ProjectAutomationRule{
+		ProjectID: 4,
+		Event:     ProjectAutomationIssueClosed,
+		BoardID:   4,
+		Labels:    []string{"bug"},
+		CreatorID: 2,
+	}
+	assert.NoError(t, NewProjectAutomationRule(rule))
+	rule = unittest.AssertExistsAndLoadBean(t, &ProjectAutomationRule{ID: rule.ID}).(*ProjectAutomationRule)
+	// only the rules adding issues filter them by labels
+	assert.Empty(t, rule.Labels)
+
+	assert.NoError(t, DeleteProjectAutomationRule(1, rule.ID))
+	unittest.AssertExistsAndLoadBean(t, &ProjectAutomationRule{ID: rule.ID})
+	assert.NoError(t, DeleteProjectAutomationRule(4, rule.ID))
+	unittest.AssertNotExistsBean(t, &ProjectAutomationRule{ID: rule.ID})
+}
+
+func TestDeleteProjectAutomationRules(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	assert.NoError(t, DeleteProjectBoardByID(3))
+	unittest.AssertNotExistsBean(t, &ProjectAutomationRule{ID: 3})
+	unittest.AssertNotExistsBean(t, &ProjectAutomationRule{ID: 4})
+	unittest.AssertExistsAndLoadBean(t, &ProjectAutomationRule{ID: 1})
+
+	assert.NoError(t, DeleteProjectByID(1))
+	unittest.AssertNotExistsBean(t, &ProjectAutomationRule{ProjectID: 1})
+}
+
+func TestAutoAddIssueToProject(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	doer := unittest.AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	// issue 1 has label1 and is added to the board of the rule
+	issue := unittest.AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	assert.NoError(t, ChangeProjectAssign(issue, doer, 0))
+	assert.NoError(t, AutoAddIssueToProject(issue, doer))
+	unittest.AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectID: 1, ProjectBoardID: 1})
+
+	// issue 5 doesn't have label1
+	issue = unittest.AssertExistsAndLoadBean(t, &Issue{ID: 5}).(*Issue)
+	assert.NoError(t, ChangeProjectAssign(issue, doer, 0))
+	assert.NoError(t, AutoAddIssueToProject(issue, doer))
+	assert.EqualValues(t, 0, issue.ProjectID())
+
+	// issue 7 is in a repository of user2 and is added to a project of its owner
+	assert.NoError(t, NewProjectAutomationRule(&ProjectAutomationRule{
+		ProjectID: 5,
+		Event:     ProjectAutomationIssueOpened,
+		CreatorID: 2,
+	}))
+	issue = unittest.AssertExistsAndLoadBean(t, &Issue{ID: 7}).(*Issue)
+	assert.NoError(t, AutoAddIssueToProject(issue, doer))
+	unittest.AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 7, ProjectID: 5, ProjectBoardID: 0})
+
+	// issues already in a project are not moved to another one
+	issue = unittest.AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	assert.NoError(t, AutoAddIssueToProject(issue, doer))
+	unittest.AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 2, ProjectID: 1})
+}
+
+func TestMoveProjectCardsOnEvent(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	issues := []*Issue{
+		unittest.AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue),
+		// issue 6 is in a project without rules
+		unittest.AssertExistsAndLoadBean(t, &Issue{ID: 6}).(*Issue),
+		// issue 7 is not in a project
+		unittest.AssertExistsAndLoadBean(t, &Issue{ID: 7}).(*Issue),
+	}
+	assert.NoError(t, ChangeProjectAssign(issues[1], unittest.AssertExistsAndLoadBean(t, &User{ID: 3}).(*User), 2))
+
+	assert.NoError(t, MoveProjectCardsOnEvent(issues, ProjectAutomationIssueClosed))
+	unittest.AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectID: 1, ProjectBoardID: 3})
+	unittest.AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 6, ProjectID: 2, ProjectBoardID: 0})
+	assert.EqualValues(t, 0, issues[2].ProjectID())
+}
+
+func TestGetIssuesReferencedByPull(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	pr := unittest.AssertExistsAndLoadBean(t, &PullRequest{ID: 1}).(*PullRequest)
+
+	issues, err := GetIssuesReferencedByPull(pr)
+	assert.NoError(t, err)
+	assert.Empty(t, issues)
+
+	for _, comment := range []*Comment{
+		{Type: CommentTypePullRef, IssueID: 1, RefIssueID: pr.IssueID, RefIsPull: true, RefAction: references.XRefActionCloses},
+		{Type: CommentTypePullRef, IssueID: 1, RefIssueID: pr.IssueID, RefIsPull: true},
+		{Type: CommentTypePullRef, IssueID: 5, RefIssueID: pr.IssueID, RefIsPull: true, RefAction: references.XRefActionNeutered},
+		// pull request 3 references pull request 2
+		{Type: CommentTypePullRef, IssueID: 3, RefIssueID: pr.IssueID, RefIsPull: true},
+	} {
+		_, err := db.GetEngine(db.DefaultContext).Insert(comment)
+		assert.NoError(t, err)
+	}
+
+	issues, err = GetIssuesReferencedByPull(pr)
+	assert.NoError(t, err)
+	if assert.Len(t, issues, 1) {
+		assert.EqualValues(t, 1, issues[0].ID)
+	}
+}
@@ -143,6 +143,10 @@ func deleteProjectBoardByID(e db.Engine, boardID int64) error {
 		return err
 	}
 
+	if err = deleteProjectAutomationRulesByBoardID(e, board.ID); err != nil {
+		return err
+	}
+
 	if _, err := e.ID(board.ID).Delete(board); err != nil {
 		return err
 	}
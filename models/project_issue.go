@@ -9,6 +9,8 @@ import (
 	"fmt"
 
 	"code.gitea.io/gitea/models/db"
+
+	"xorm.io/builder"
 )
 
 // ProjectIssue saves relation from issue to a project
@@ -30,6 +32,43 @@ func deleteProjectIssuesByProjectID(e db.Engine, projectID int64) error {
 	return err
 }
 
+// ReadableProjectIssuesCond returns the condition of the issues of the project which the user,
+// nil for anonymous, can read in their repositories. The projects of users and organizations
+// may hold issues from any repository.
+func ReadableProjectIssuesCond(projectID int64, user *User) (builder.Cond, error) {
+	e := db.GetEngine(db.DefaultContext)
+	repoIDs := make([]int64, 0, 10)
+	if err := e.Table("issue").
+		Join("INNER", "project_issue", "issue.id = project_issue.issue_id").
+		Where("project_issue.project_id=?", projectID).
+		Distinct("issue.repo_id").
+		Find(&repoIDs); err != nil {
+		return nil, err
+	}
+	repos := make(map[int64]*Repository, len(repoIDs))
+	if err := e.In("id", repoIDs).Find(&repos); err != nil {
+		return nil, err
+	}
+
+	var issueRepoIDs, pullRepoIDs []int64
+	for id, repo := range repos {
+		perm, err := getUserRepoPermission(e, repo, user)
+		if err != nil {
+			return nil, err
+		}
+		if perm.CanReadIssuesOrPulls(false) {
+			issueRepoIDs = append(issueRepoIDs, id)
+		}
+		if perm.CanReadIssuesOrPulls(true) {
+			pullRepoIDs = append(pullRepoIDs, id)
+		}
+	}
+	return builder.Or(
+		builder.In("issue.repo_id", issueRepoIDs).And(builder.Eq{"issue.is_pull": false}),
+		builder.In("issue.repo_id", pullRepoIDs).And(builder.Eq{"issue.is_pull": true}),
+	), nil
+}
+
 //  ___
 // |_ _|___ ___ _   _  ___
 //  | |/ __/ __| | | |/ _ \
@@ -0,0 +1,109 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unit"
+)
+
+// GetProjectOwnerAccessMode returns the access mode of the user to the projects of the owner
+func GetProjectOwnerAccessMode(owner, user *User) (AccessMode, error) {
+	return getProjectOwnerAccessMode(db.GetEngine(db.DefaultContext), owner, user)
+}
+
+func getProjectOwnerAccessMode(e db.Engine, owner, user *User) (AccessMode, error) {
+	if user != nil && (user.IsAdmin || user.ID == owner.ID) {
+		return AccessModeOwner, nil
+	}
+
+	if !hasOrgOrUserVisible(e, owner, user) {
+		return AccessModeNone, nil
+	}
+
+	mode := AccessModeRead
+	if user == nil || !owner.IsOrganization() {
+		return mode, nil
+	}
+
+	teams, err := getUserOrgTeams(e, owner.ID, user.ID)
+	if err != nil {
+		return AccessModeNone, err
+	}
+	for _, team := range teams {
+		if team.Authorize >= AccessModeOwner {
+			return AccessModeOwner, nil
+		}
+		if team.unitEnabled(e, unit.TypeProjects) && team.Authorize > mode {
+			mode = team.Authorize
+		}
+	}
+	return mode, nil
+}
+
+// GetUserProjectAccessMode returns the access mode of the user to the project: the access to
+// the projects of its repository or of its owner
+func GetUserProjectAccessMode(p *Project, user *User) (AccessMode, error) {
+	return getUserProjectAccessMode(db.GetEngine(db.DefaultContext), p, user)
+}
+
+func getUserProjectAccessMode(e db.Engine, p *Project, user *User) (AccessMode, error) {
+	if p.Type == ProjectTypeRepository {
+		if err := p.loadRepo(e); err != nil {
+			return AccessModeNone, err
+		}
+		perm, err := getUserRepoPermission(e, p.Repo, user)
+		if err != nil {
+			return AccessModeNone, err
+		}
+		return perm.UnitAccessMode(unit.TypeProjects), nil
+	}
+
+	if err := p.loadOwner(e); err != nil {
+		return AccessModeNone, err
+	}
+	return getProjectOwnerAccessMode(e, p.Owner, user)
+}
+
+// CanUserAddIssueToProject returns true if the user, who can write the project, can add the
+// issue to it. The issue of a repository project has to be in its repository, the issue of
+// another project has to be readable by the user. The project the issue is currently in, if
+// any, has to be writable by the user too.
+func CanUserAddIssueToProject(p *Project, issue *Issue, user *User) (bool, error) {
+	e := db.GetEngine(db.DefaultContext)
+	if p.Type == ProjectTypeRepository {
+		if issue.RepoID != p.RepoID {
+			return false, nil
+		}
+	} else {
+		if err := issue.loadRepo(e); err != nil {
+			return false, err
+		}
+		perm, err := getUserRepoPermission(e, issue.Repo, user)
+		if err != nil {
+			return false, err
+		}
+		if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+			return false, nil
+		}
+	}
+
+	currentID := issue.projectID(e)
+	if currentID == 0 || currentID == p.ID {
+		return true, nil
+	}
+	current, err := getProjectByID(e, currentID)
+	if err != nil {
+		if IsErrProjectNotExist(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	mode, err := getUserProjectAccessMode(e, current, user)
+	if err != nil {
+		return false, err
+	}
+	return mode >= AccessModeWrite, nil
+}
@@ -7,7 +7,9 @@ package models
 import (
 	"testing"
 
+	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
 
 	"github.com/stretchr/testify/assert"
@@ -20,9 +22,9 @@ func TestIsProjectTypeValid(t *testing.T) {
 		typ   ProjectType
 		valid bool
 	}{
-		{ProjectTypeIndividual, false},
+		{ProjectTypeIndividual, true},
 		{ProjectTypeRepository, true},
-		{ProjectTypeOrganization, false},
+		{ProjectTypeOrganization, true},
 		{UnknownType, false},
 	}
 
@@ -81,3 +83,138 @@ func TestProject(t *testing.T) {
 
 	assert.True(t, projectFromDB.IsClosed)
 }
+
+func TestNewOwnerProject(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	project := &Project{
+		Type:      ProjectTypeOrganization,
+		BoardType: ProjectBoardTypeBasicKanban,
+		Title:     "New organization project",
+		OwnerID:   3,
+		CreatorID: 2,
+	}
+	assert.NoError(t, NewProject(project))
+
+	projects, count, err := GetProjects(ProjectSearchOptions{OwnerID: 3, Type: ProjectTypeOrganization})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	if assert.Len(t, projects, 2) {
+		assert.EqualValues(t, 4, projects[0].ID)
+		assert.EqualValues(t, project.ID, projects[1].ID)
+	}
+
+	// the projects of users and organizations are not listed with the projects of repositories
+	projects, _, err = GetProjects(ProjectSearchOptions{RepoID: 1})
+	assert.NoError(t, err)
+	assert.Len(t, projects, 1)
+
+	assert.Error(t, NewProject(&Project{Type: ProjectTypeOrganization, Title: "Without owner", CreatorID: 2}))
+	assert.Error(t, NewProject(&Project{Type: ProjectTypeIndividual, Title: "With repository", OwnerID: 2, RepoID: 1, CreatorID: 2}))
+	assert.Error(t, NewProject(&Project{Type: ProjectTypeRepository, Title: "With owner", OwnerID: 2, RepoID: 1, CreatorID: 2}))
+}
+
+func TestProject_Link(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	repoProject := unittest.AssertExistsAndLoadBean(t, &Project{ID: 1}).(*Project)
+	assert.Equal(t, setting.AppSubURL+"/user2/repo1/projects/1", repoProject.Link())
+
+	orgProject := unittest.AssertExistsAndLoadBean(t, &Project{ID: 4}).(*Project)
+	assert.Equal(t, setting.AppSubURL+"/user3/-/projects/4", orgProject.Link())
+}
+
+func TestGetProjectOwnerAccessMode(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	org := unittest.AssertExistsAndLoadBean(t, &User{ID: 3}).(*User)
+	individual := unittest.AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	for _, kase := range []struct {
+		owner  *User
+		userID int64
+		mode   AccessMode
+	}{
+		{org, 0, AccessModeRead},
+		{org, 1, AccessModeOwner}, // site admin
+		{org, 2, AccessModeOwner}, // member of the owner team
+		{org, 4, AccessModeRead},  // member of a team without the projects unit
+		{org, 5, AccessModeRead},
+		{individual, 0, AccessModeRead},
+		{individual, 2, AccessModeOwner},
+		{individual, 4, AccessModeRead},
+	} {
+		var user *User
+		if kase.userID > 0 {
+			user = unittest.AssertExistsAndLoadBean(t, &User{ID: kase.userID}).(*User)
+		}
+		mode, err := GetProjectOwnerAccessMode(kase.owner, user)
+		assert.NoError(t, err)
+		assert.Equal(t, kase.mode, mode, "owner %d, user %d", kase.owner.ID, kase.userID)
+	}
+}
+
+func TestCanUserAddIssueToProject(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	repoProject := unittest.AssertExistsAndLoadBean(t, &Project{ID: 1}).(*Project)
+	individualProject := unittest.AssertExistsAndLoadBean(t, &Project{ID: 5}).(*Project)
+
+	for _, kase := range []struct {
+		project *Project
+		issueID int64
+		userID  int64
+		canAdd  bool
+	}{
+		{individualProject, 4, 2, true},
+		{individualProject, 4, 5, false}, // issue of a private repository
+		{individualProject, 1, 2, true},
+		{individualProject, 1, 4, false}, // issue in a repository project the user cannot write
+		{repoProject, 1, 2, true},
+		{repoProject, 6, 2, false}, // issue of another repository
+	} {
+		issue := unittest.AssertExistsAndLoadBean(t, &Issue{ID: kase.issueID}).(*Issue)
+		user := unittest.AssertExistsAndLoadBean(t, &User{ID: kase.userID}).(*User)
+		canAdd, err := CanUserAddIssueToProject(kase.project, issue, user)
+		assert.NoError(t, err)
+		assert.Equal(t, kase.canAdd, canAdd, "project %d, issue %d, user %d", kase.project.ID, kase.issueID, kase.userID)
+	}
+}
+
+func TestReadableProjectIssuesCond(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	for _, issueID := range []int64{1, 4, 6} {
+		assert.NoError(t, db.Insert(db.DefaultContext, &ProjectIssue{IssueID: issueID, ProjectID: 5}))
+	}
+
+	for _, kase := range []struct {
+		userID   int64
+		issueIDs []int64
+	}{
+		{0, []int64{1}},
+		{2, []int64{1, 4, 6}},
+		{5, []int64{1}},
+	} {
+		var user *User
+		if kase.userID > 0 {
+			user = unittest.AssertExistsAndLoadBean(t, &User{ID: kase.userID}).(*User)
+		}
+		cond, err := ReadableProjectIssuesCond(5, user)
+		assert.NoError(t, err)
+
+		// the unreadable issues are left out before paginating
+		var issueIDs []int64
+		for page := 1; page <= 3; page++ {
+			issues, err := Issues(&IssuesOptions{
+				ListOptions: db.ListOptions{Page: page, PageSize: 1},
+				ProjectID:   5,
+				RepoCond:    cond,
+				SortType:    "oldest",
+			})
+			assert.NoError(t, err)
+			issueIDs = append(issueIDs, IssueList(issues).getIssueIDs()...)
+		}
+		assert.Equal(t, kase.issueIDs, issueIDs, "user %d", kase.userID)
+	}
+}
@@ -212,7 +212,7 @@ func (pr *PullRequest) loadProtectedBranch(e db.Engine) (err error) {
 				return
 			}
 		}
-		pr.ProtectedBranch, err = getProtectedBranchBy(e, pr.BaseRepo.ID, pr.BaseBranch)
+		pr.ProtectedBranch, err = getFirstMatchProtectedBranchRule(e, pr.BaseRepo.ID, pr.BaseBranch)
 	}
 	return
 }
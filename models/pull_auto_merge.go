@@ -0,0 +1,74 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// PullAutoMerge represents a pull request scheduled to be merged as soon as all checks succeed
+type PullAutoMerge struct {
+	ID         int64      `xorm:"pk autoincr"`
+	RepoID     int64      `xorm:"INDEX NOT NULL"`
+	PullID     int64      `xorm:"UNIQUE NOT NULL"`
+	DoerID     int64      `xorm:"NOT NULL"`
+	Doer       *User      `xorm:"-"`
+	MergeStyle MergeStyle `xorm:"VARCHAR(30)"`
+	Message    string     `xorm:"TEXT"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+func init() {
+	db.RegisterModel(new(PullAutoMerge))
+}
+
+// LoadDoer loads the user who scheduled the merge
+func (m *PullAutoMerge) LoadDoer() (err error) {
+	if m.Doer == nil {
+		m.Doer, err = GetUserByID(m.DoerID)
+	}
+	return err
+}
+
+// InsertPullAutoMerge schedules the automatic merge of a pull request
+func InsertPullAutoMerge(m *PullAutoMerge) error {
+	sess := db.GetEngine(db.DefaultContext)
+	exist, err := sess.Exist(&PullAutoMerge{PullID: m.PullID})
+	if err != nil {
+		return err
+	} else if exist {
+		return ErrPullAutoMergeAlreadyScheduled{PullID: m.PullID}
+	}
+	_, err = sess.Insert(m)
+	return err
+}
+
+// GetPullAutoMergeByPullID returns the scheduled automatic merge of the pull request
+func GetPullAutoMergeByPullID(pullID int64) (*PullAutoMerge, error) {
+	m := new(PullAutoMerge)
+	has, err := db.GetEngine(db.DefaultContext).Where("pull_id = ?", pullID).Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPullAutoMergeNotExist{PullID: pullID}
+	}
+	return m, nil
+}
+
+// GetPullAutoMergesByRepoID returns the scheduled automatic merges of the pull requests of the repository
+func GetPullAutoMergesByRepoID(repoID int64) ([]*PullAutoMerge, error) {
+	merges := make([]*PullAutoMerge, 0, 5)
+	return merges, db.GetEngine(db.DefaultContext).
+		Where("repo_id = ?", repoID).
+		Find(&merges)
+}
+
+// DeletePullAutoMerge removes the scheduled automatic merge
+func DeletePullAutoMerge(m *PullAutoMerge) error {
+	_, err := db.GetEngine(db.DefaultContext).ID(m.ID).Delete(new(PullAutoMerge))
+	return err
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullAutoMerge(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	m := &PullAutoMerge{RepoID: 1, PullID: 2, DoerID: 2, MergeStyle: MergeStyleSquash, Message: "message"}
+	assert.NoError(t, InsertPullAutoMerge(m))
+	assert.True(t, IsErrPullAutoMergeAlreadyScheduled(InsertPullAutoMerge(&PullAutoMerge{RepoID: 1, PullID: 2, DoerID: 1})))
+
+	scheduled, err := GetPullAutoMergeByPullID(2)
+	assert.NoError(t, err)
+	assert.Equal(t, m.ID, scheduled.ID)
+	assert.Equal(t, MergeStyleSquash, scheduled.MergeStyle)
+	assert.NoError(t, scheduled.LoadDoer())
+	assert.EqualValues(t, 2, scheduled.Doer.ID)
+
+	merges, err := GetPullAutoMergesByRepoID(1)
+	assert.NoError(t, err)
+	assert.Len(t, merges, 1)
+
+	assert.NoError(t, DeletePullAutoMerge(m))
+	_, err = GetPullAutoMergeByPullID(2)
+	assert.True(t, IsErrPullAutoMergeNotExist(err))
+}
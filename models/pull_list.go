@@ -69,6 +69,17 @@ func GetUnmergedPullRequestsByBaseInfo(repoID int64, branch string) ([]*PullRequ
 		Find(&prs)
 }
 
+// GetUnmergedPullRequestBaseBranches returns the base branches of all open pull requests which have not been merged
+func GetUnmergedPullRequestBaseBranches(repoID int64) ([]string, error) {
+	branches := make([]string, 0, 10)
+	return branches, db.GetEngine(db.DefaultContext).
+		Table("pull_request").
+		Join("INNER", "issue", "issue.id=pull_request.issue_id").
+		Where("base_repo_id=? AND has_merged=? AND issue.is_closed=?", repoID, false, false).
+		Distinct("base_branch").
+		Find(&branches)
+}
+
 // GetPullRequestIDsByCheckStatus returns all pull requests according the special checking status.
 func GetPullRequestIDsByCheckStatus(status PullRequestStatus) ([]int64, error) {
 	prs := make([]int64, 0, 10)
@@ -0,0 +1,128 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// MergeQueueBranchPrefix is the prefix of the branches which hold the speculative merge commits of the merge queue
+const MergeQueueBranchPrefix = "merge-queue/"
+
+// MergeQueueEntry represents a pull request waiting in the merge queue of its base branch.
+// The entries of a branch are merged in the order of their IDs.
+type MergeQueueEntry struct {
+	ID         int64        `xorm:"pk autoincr"`
+	RepoID     int64        `xorm:"INDEX(branch) NOT NULL"`
+	BaseBranch string       `xorm:"INDEX(branch) NOT NULL"`
+	PullID     int64        `xorm:"UNIQUE NOT NULL"`
+	Pull       *PullRequest `xorm:"-"`
+	DoerID     int64        `xorm:"NOT NULL"`
+	Doer       *User        `xorm:"-"`
+	MergeStyle MergeStyle   `xorm:"VARCHAR(30)"`
+	Message    string       `xorm:"TEXT"`
+
+	// HeadCommitID is the head of the pull request when it was queued
+	HeadCommitID string `xorm:"VARCHAR(40)"`
+	// BaseCommitID is the commit the speculative merge commit was built on
+	BaseCommitID string `xorm:"VARCHAR(40)"`
+	// CommitID is the speculative merge commit, it is empty if the commit has to be (re)built
+	CommitID string `xorm:"VARCHAR(40) INDEX"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+func init() {
+	db.RegisterModel(new(MergeQueueEntry))
+}
+
+// BranchName returns the name of the branch the speculative merge commit is pushed to
+func (e *MergeQueueEntry) BranchName() string {
+	if err := e.LoadPull(); err != nil {
+		return fmt.Sprintf("%s%s/pr-%d", MergeQueueBranchPrefix, e.BaseBranch, e.PullID)
+	}
+	return fmt.Sprintf("%s%s/pr-%d", MergeQueueBranchPrefix, e.BaseBranch, e.Pull.Index)
+}
+
+// LoadPull loads the pull request of the entry
+func (e *MergeQueueEntry) LoadPull() (err error) {
+	if e.Pull == nil {
+		e.Pull, err = GetPullRequestByID(e.PullID)
+	}
+	return err
+}
+
+// LoadDoer loads the user who added the pull request to the merge queue
+func (e *MergeQueueEntry) LoadDoer() (err error) {
+	if e.Doer == nil {
+		e.Doer, err = GetUserByID(e.DoerID)
+	}
+	return err
+}
+
+// Position returns the 1-based position of the entry in the merge queue of its branch
+func (e *MergeQueueEntry) Position() (int64, error) {
+	return db.GetEngine(db.DefaultContext).
+		Where("repo_id = ? AND base_branch = ? AND id <= ?", e.RepoID, e.BaseBranch, e.ID).
+		Count(new(MergeQueueEntry))
+}
+
+// InsertMergeQueueEntry appends the pull request to the end of the merge queue
+func InsertMergeQueueEntry(e *MergeQueueEntry) error {
+	sess := db.GetEngine(db.DefaultContext)
+	exist, err := sess.Exist(&MergeQueueEntry{PullID: e.PullID})
+	if err != nil {
+		return err
+	} else if exist {
+		return ErrMergeQueueEntryAlreadyExist{PullID: e.PullID}
+	}
+	_, err = sess.Insert(e)
+	return err
+}
+
+// GetMergeQueueEntryByPullID returns the merge queue entry of the pull request
+func GetMergeQueueEntryByPullID(pullID int64) (*MergeQueueEntry, error) {
+	e := new(MergeQueueEntry)
+	has, err := db.GetEngine(db.DefaultContext).Where("pull_id = ?", pullID).Get(e)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrMergeQueueEntryNotExist{PullID: pullID}
+	}
+	return e, nil
+}
+
+// GetMergeQueueEntries returns the entries of the merge queue of the branch in the order they are merged
+func GetMergeQueueEntries(repoID int64, branch string) ([]*MergeQueueEntry, error) {
+	entries := make([]*MergeQueueEntry, 0, 5)
+	return entries, db.GetEngine(db.DefaultContext).
+		Where("repo_id = ? AND base_branch = ?", repoID, branch).
+		Asc("id").
+		Find(&entries)
+}
+
+// GetMergeQueueEntriesByCommitID returns the entries whose speculative merge commit is the commit
+func GetMergeQueueEntriesByCommitID(repoID int64, commitID string) ([]*MergeQueueEntry, error) {
+	entries := make([]*MergeQueueEntry, 0, 1)
+	return entries, db.GetEngine(db.DefaultContext).
+		Where("repo_id = ? AND commit_id = ?", repoID, commitID).
+		Find(&entries)
+}
+
+// UpdateMergeQueueEntryCols updates the given columns of the entry
+func UpdateMergeQueueEntryCols(e *MergeQueueEntry, cols ...string) error {
+	_, err := db.GetEngine(db.DefaultContext).ID(e.ID).Cols(cols...).Update(e)
+	return err
+}
+
+// DeleteMergeQueueEntry removes the entry from the merge queue
+func DeleteMergeQueueEntry(e *MergeQueueEntry) error {
+	_, err := db.GetEngine(db.DefaultContext).ID(e.ID).Delete(new(MergeQueueEntry))
+	return err
+}
@@ -0,0 +1,52 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeQueueEntry(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	first := &MergeQueueEntry{RepoID: 1, BaseBranch: "master", PullID: 1, DoerID: 2, MergeStyle: MergeStyleMerge}
+	second := &MergeQueueEntry{RepoID: 1, BaseBranch: "master", PullID: 2, DoerID: 2, MergeStyle: MergeStyleSquash}
+	assert.NoError(t, InsertMergeQueueEntry(first))
+	assert.NoError(t, InsertMergeQueueEntry(second))
+	assert.True(t, IsErrMergeQueueEntryAlreadyExist(InsertMergeQueueEntry(&MergeQueueEntry{RepoID: 1, BaseBranch: "master", PullID: 1})))
+
+	assert.Equal(t, "merge-queue/master/pr-3", second.BranchName())
+
+	entries, err := GetMergeQueueEntries(1, "master")
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, first.ID, entries[0].ID)
+		assert.Equal(t, second.ID, entries[1].ID)
+	}
+
+	position, err := second.Position()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, position)
+
+	second.CommitID = "65f1bf27bc3bf70f64657658635e66094edbcb4d"
+	assert.NoError(t, UpdateMergeQueueEntryCols(second, "commit_id"))
+	entries, err = GetMergeQueueEntriesByCommitID(1, second.CommitID)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, second.PullID, entries[0].PullID)
+	}
+
+	assert.NoError(t, DeleteMergeQueueEntry(first))
+	_, err = GetMergeQueueEntryByPullID(first.PullID)
+	assert.True(t, IsErrMergeQueueEntryNotExist(err))
+
+	position, err = second.Position()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, position)
+}
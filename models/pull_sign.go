@@ -53,7 +53,7 @@ Loop:
 				return false, "", nil, &ErrWontSign{twofa}
 			}
 		case approved:
-			protectedBranch, err := GetProtectedBranchBy(repo.ID, pr.BaseBranch)
+			protectedBranch, err := GetFirstMatchProtectedBranchRule(repo.ID, pr.BaseBranch)
 			if err != nil {
 				return false, "", nil, err
 			}
@@ -0,0 +1,48 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignMergeApprovedGlobRule(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	oldSigning := setting.Repository.Signing
+	defer func() {
+		setting.Repository.Signing = oldSigning
+	}()
+	setting.Repository.Signing.SigningKey = "DEADBEEF"
+	setting.Repository.Signing.Merges = []string{"approved"}
+
+	pr := unittest.AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+	assert.NoError(t, pr.LoadBaseRepo())
+	assert.NoError(t, pr.LoadIssue())
+	reviewer := unittest.AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	_, err := CreateReview(CreateReviewOptions{
+		Type:     ReviewTypeApprove,
+		Issue:    pr.Issue,
+		Reviewer: reviewer,
+		Official: true,
+	})
+	assert.NoError(t, err)
+
+	// without a rule protecting the base branch the approvals are not counted
+	sign, _, _, err := pr.SignMerge(reviewer, "", "", "")
+	assert.False(t, sign)
+	assert.True(t, IsErrWontSign(err))
+
+	assert.NoError(t, UpdateProtectBranch(pr.BaseRepo, &ProtectedBranch{RepoID: pr.BaseRepoID, BranchName: "ma*"}, WhitelistOptions{}))
+	sign, key, _, err := pr.SignMerge(reviewer, "", "", "")
+	assert.NoError(t, err)
+	assert.True(t, sign)
+	assert.EqualValues(t, "DEADBEEF", key)
+}
@@ -23,8 +23,11 @@ import (
 
 	_ "image/jpeg" // Needed for jpeg support
 
+	actions_model "code.gitea.io/gitea/models/actions"
 	admin_model "code.gitea.io/gitea/models/admin"
 	"code.gitea.io/gitea/models/db"
+	federation_model "code.gitea.io/gitea/models/federation"
+	"code.gitea.io/gitea/models/foreignreference"
 	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/models/unit"
 	"code.gitea.io/gitea/models/webhook"
@@ -1505,6 +1508,28 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		releaseAttachments = append(releaseAttachments, attachments[i].RelativePath())
 	}
 
+	actionJobs := make([]*actions_model.ActionRunJob, 0, 10)
+	if err = sess.Where("repo_id = ? AND log_chunks > 0", repoID).Find(&actionJobs); err != nil {
+		return err
+	}
+	actionLogPaths := make([]string, 0, len(actionJobs))
+	for _, job := range actionJobs {
+		for chunk := int64(0); chunk < job.LogChunks; chunk++ {
+			actionLogPaths = append(actionLogPaths, job.LogChunkPath(chunk))
+		}
+	}
+
+	archiveTasks := make([]*Task, 0, 5)
+	if err = sess.Where("repo_id = ?", repoID).
+		In("type", api.TaskTypeExportRepo, api.TaskTypeImportRepo).
+		Find(&archiveTasks); err != nil {
+		return err
+	}
+	taskArchivePaths := make([]string, 0, len(archiveTasks))
+	for _, task := range archiveTasks {
+		taskArchivePaths = append(taskArchivePaths, task.ArchiveRelativePath())
+	}
+
 	if _, err := sess.Exec("UPDATE `user` SET num_stars=num_stars-1 WHERE id IN (SELECT `uid` FROM `star` WHERE repo_id = ?)", repo.ID); err != nil {
 		return err
 	}
@@ -1512,23 +1537,36 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 	if err := deleteBeans(sess,
 		&Access{RepoID: repo.ID},
 		&Action{RepoID: repo.ID},
+		&actions_model.ActionRun{RepoID: repoID},
+		&actions_model.ActionRunJob{RepoID: repoID},
+		&actions_model.ActionRunner{RepoID: repoID},
+		&actions_model.ActionRunnerToken{RepoID: repoID},
 		&Collaboration{RepoID: repoID},
 		&Comment{RefRepoID: repoID},
 		&CommitStatus{RepoID: repoID},
 		&DeletedBranch{RepoID: repoID},
+		&federation_model.Activity{RepoID: repoID},
+		&federation_model.Follower{RepoID: repoID},
+		&federation_model.KeyPair{RepoID: repoID},
+		&federation_model.Star{RepoID: repoID},
+		&foreignreference.ForeignReference{RepoID: repoID},
 		&webhook.HookTask{RepoID: repoID},
 		&LFSLock{RepoID: repoID},
 		&LanguageStat{RepoID: repoID},
+		&MergeQueueEntry{RepoID: repoID},
 		&Milestone{RepoID: repoID},
 		&Mirror{RepoID: repoID},
 		&Notification{RepoID: repoID},
 		&ProtectedBranch{RepoID: repoID},
 		&ProtectedTag{RepoID: repoID},
+		&PullAutoMerge{RepoID: repoID},
 		&PullRequest{BaseRepoID: repoID},
 		&PushMirror{RepoID: repoID},
+		&PushMirrorAttempt{RepoID: repoID},
 		&Release{RepoID: repoID},
 		&RepoIndexerStatus{RepoID: repoID},
 		&RepoRedirect{RedirectRepoID: repoID},
+		&RepoSymbol{RepoID: repoID},
 		&RepoUnit{RepoID: repoID},
 		&Star{RepoID: repoID},
 		&Task{RepoID: repoID},
@@ -1670,6 +1708,11 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		admin_model.RemoveStorageWithNotice(db.DefaultContext, storage.RepoArchives, "Delete repo archive file", archivePaths[i])
 	}
 
+	// Remove export and import archives
+	for i := range taskArchivePaths {
+		admin_model.RemoveStorageWithNotice(db.DefaultContext, storage.RepoExports, "Delete repo export archive", taskArchivePaths[i])
+	}
+
 	// Remove lfs objects
 	for i := range lfsPaths {
 		admin_model.RemoveStorageWithNotice(db.DefaultContext, storage.LFS, "Delete orphaned LFS file", lfsPaths[i])
@@ -1690,6 +1733,13 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		admin_model.RemoveStorageWithNotice(db.DefaultContext, storage.Attachments, "Delete issue attachment", attachmentPaths[i])
 	}
 
+	// Remove actions job logs
+	if storage.ActionsLog != nil {
+		for i := range actionLogPaths {
+			admin_model.RemoveStorageWithNotice(db.DefaultContext, storage.ActionsLog, "Delete actions job log", actionLogPaths[i])
+		}
+	}
+
 	if len(repo.Avatar) > 0 {
 		if err := storage.RepoAvatars.Delete(repo.CustomAvatarRelativePath()); err != nil {
 			return fmt.Errorf("Failed to remove %s: %v", repo.Avatar, err)
@@ -1721,11 +1771,16 @@ func getRepositoryByOwnerAndName(e db.Engine, ownerName, repoName string) (*Repo
 
 // GetRepositoryByName returns the repository by given name under user if exists.
 func GetRepositoryByName(ownerID int64, name string) (*Repository, error) {
+	return GetRepositoryByNameCtx(db.DefaultContext, ownerID, name)
+}
+
+// GetRepositoryByNameCtx returns the repository by given name under user if exists.
+func GetRepositoryByNameCtx(ctx context.Context, ownerID int64, name string) (*Repository, error) {
 	repo := &Repository{
 		OwnerID:   ownerID,
 		LowerName: strings.ToLower(name),
 	}
-	has, err := db.GetEngine(db.DefaultContext).Get(repo)
+	has, err := db.GetEngine(ctx).Get(repo)
 	if err != nil {
 		return nil, err
 	} else if !has {
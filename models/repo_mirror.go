@@ -9,8 +9,13 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migration"
+	"code.gitea.io/gitea/modules/secret"
+	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
 
 	"xorm.io/xorm"
 )
@@ -35,6 +40,11 @@ type Mirror struct {
 	LFS         bool   `xorm:"lfs_enabled NOT NULL DEFAULT false"`
 	LFSEndpoint string `xorm:"lfs_endpoint TEXT"`
 
+	// MetadataConfig holds the migrate options with encrypted credentials if issues,
+	// pull requests, releases etc. of the mirror are synced from the original service.
+	MetadataConfig     string `xorm:"TEXT"`
+	MetadataSyncedUnix timeutil.TimeStamp
+
 	Address string `xorm:"-"`
 }
 
@@ -82,6 +92,63 @@ func (m *Mirror) ScheduleNextUpdate() {
 	}
 }
 
+// SyncsMetadata returns true if the issues, pull requests, releases etc. of the mirror are synced
+func (m *Mirror) SyncsMetadata() bool {
+	return m.MetadataConfig != ""
+}
+
+// MigrateConfig returns the migrate options used to sync the metadata of the mirror
+func (m *Mirror) MigrateConfig() (*migration.MigrateOptions, error) {
+	var opts migration.MigrateOptions
+	err := json.Unmarshal([]byte(m.MetadataConfig), &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// decrypt credentials
+	if opts.CloneAddrEncrypted != "" {
+		if opts.CloneAddr, err = secret.DecryptSecret(setting.SecretKey, opts.CloneAddrEncrypted); err != nil {
+			return nil, err
+		}
+	}
+	if opts.AuthPasswordEncrypted != "" {
+		if opts.AuthPassword, err = secret.DecryptSecret(setting.SecretKey, opts.AuthPasswordEncrypted); err != nil {
+			return nil, err
+		}
+	}
+	if opts.AuthTokenEncrypted != "" {
+		if opts.AuthToken, err = secret.DecryptSecret(setting.SecretKey, opts.AuthTokenEncrypted); err != nil {
+			return nil, err
+		}
+	}
+
+	return &opts, nil
+}
+
+// SetMigrateConfig stores the migrate options used to sync the metadata of the mirror
+func (m *Mirror) SetMigrateConfig(opts migration.MigrateOptions) error {
+	var err error
+	if opts.CloneAddrEncrypted, err = secret.EncryptSecret(setting.SecretKey, opts.CloneAddr); err != nil {
+		return err
+	}
+	opts.CloneAddr = util.NewStringURLSanitizer(opts.CloneAddr, true).Replace(opts.CloneAddr)
+	if opts.AuthPasswordEncrypted, err = secret.EncryptSecret(setting.SecretKey, opts.AuthPassword); err != nil {
+		return err
+	}
+	opts.AuthPassword = ""
+	if opts.AuthTokenEncrypted, err = secret.EncryptSecret(setting.SecretKey, opts.AuthToken); err != nil {
+		return err
+	}
+	opts.AuthToken = ""
+
+	bs, err := json.Marshal(&opts)
+	if err != nil {
+		return err
+	}
+	m.MetadataConfig = string(bs)
+	return nil
+}
+
 func getMirrorByRepoID(e db.Engine, repoID int64) (*Mirror, error) {
 	m := &Mirror{RepoID: repoID}
 	has, err := e.Get(m)
@@ -6,12 +6,15 @@ package models
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/timeutil"
 
+	"github.com/gobwas/glob"
 	"xorm.io/xorm"
 )
 
@@ -27,14 +30,29 @@ type PushMirror struct {
 	Repo       *Repository `xorm:"-"`
 	RemoteName string
 
-	Interval       time.Duration
+	Interval   time.Duration
+	SyncOnPush bool `xorm:"NOT NULL DEFAULT false"`
+	// whitespace separated glob patterns of the refs to push, all refs are pushed if both are empty
+	IncludeRefs    string             `xorm:"TEXT"`
+	ExcludeRefs    string             `xorm:"TEXT"`
 	CreatedUnix    timeutil.TimeStamp `xorm:"created"`
 	LastUpdateUnix timeutil.TimeStamp `xorm:"INDEX last_update"`
 	LastError      string             `xorm:"text"`
 }
 
+// PushMirrorAttempt represents an attempt to sync a push mirror
+type PushMirrorAttempt struct {
+	ID           int64  `xorm:"pk autoincr"`
+	PushMirrorID int64  `xorm:"INDEX"`
+	RepoID       int64  `xorm:"INDEX"`
+	Error        string `xorm:"TEXT"`
+	StartedUnix  timeutil.TimeStamp
+	EndedUnix    timeutil.TimeStamp
+}
+
 func init() {
 	db.RegisterModel(new(PushMirror))
+	db.RegisterModel(new(PushMirrorAttempt))
 }
 
 // AfterLoad is invoked from XORM after setting the values of all fields of this object.
@@ -60,6 +78,66 @@ func (m *PushMirror) GetRemoteName() string {
 	return m.RemoteName
 }
 
+// HasRefFilter returns whether only some refs are pushed to the mirror
+func (m *PushMirror) HasRefFilter() bool {
+	return len(strings.Fields(m.IncludeRefs)) > 0 || len(strings.Fields(m.ExcludeRefs)) > 0
+}
+
+// RefMatcher returns a function reporting whether a ref is pushed to the mirror.
+// Patterns starting with "refs/" are matched against the full ref name, others against
+// the name of the branch or tag.
+func (m *PushMirror) RefMatcher() func(refName string) bool {
+	include := compileRefPatterns(m.IncludeRefs)
+	exclude := compileRefPatterns(m.ExcludeRefs)
+	return func(refName string) bool {
+		return (len(include) == 0 || matchRefPatterns(include, refName)) && !matchRefPatterns(exclude, refName)
+	}
+}
+
+type refPattern struct {
+	full bool
+	glob glob.Glob
+}
+
+func compileRefPatterns(patterns string) []refPattern {
+	globs := make([]refPattern, 0, 5)
+	for _, expr := range strings.Fields(patterns) {
+		g, err := glob.Compile(expr, '/')
+		if err != nil {
+			log.Info("Invalid glob expression '%s' (skipped): %v", expr, err)
+			continue
+		}
+		globs = append(globs, refPattern{full: strings.HasPrefix(expr, "refs/"), glob: g})
+	}
+	return globs
+}
+
+func matchRefPatterns(patterns []refPattern, refName string) bool {
+	var shortName string
+	if strings.HasPrefix(refName, git.BranchPrefix) {
+		shortName = strings.TrimPrefix(refName, git.BranchPrefix)
+	} else if strings.HasPrefix(refName, git.TagPrefix) {
+		shortName = strings.TrimPrefix(refName, git.TagPrefix)
+	}
+	for _, pattern := range patterns {
+		if pattern.full && pattern.glob.Match(refName) ||
+			!pattern.full && shortName != "" && pattern.glob.Match(shortName) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRefPatterns checks whether the whitespace separated glob patterns of refs are valid
+func ValidateRefPatterns(patterns string) error {
+	for _, expr := range strings.Fields(patterns) {
+		if _, err := glob.Compile(expr, '/'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // InsertPushMirror inserts a push-mirror to database
 func InsertPushMirror(m *PushMirror) error {
 	_, err := db.GetEngine(db.DefaultContext).Insert(m)
@@ -74,14 +152,38 @@ func UpdatePushMirror(m *PushMirror) error {
 
 // DeletePushMirrorByID deletes a push-mirrors by ID
 func DeletePushMirrorByID(ID int64) error {
-	_, err := db.GetEngine(db.DefaultContext).ID(ID).Delete(&PushMirror{})
-	return err
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return err
+	}
+	defer committer.Close()
+	sess := db.GetEngine(ctx)
+
+	if _, err := sess.ID(ID).Delete(&PushMirror{}); err != nil {
+		return err
+	}
+	if _, err := sess.Delete(&PushMirrorAttempt{PushMirrorID: ID}); err != nil {
+		return err
+	}
+	return committer.Commit()
 }
 
 // DeletePushMirrorsByRepoID deletes all push-mirrors by repoID
 func DeletePushMirrorsByRepoID(repoID int64) error {
-	_, err := db.GetEngine(db.DefaultContext).Delete(&PushMirror{RepoID: repoID})
-	return err
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return err
+	}
+	defer committer.Close()
+	sess := db.GetEngine(ctx)
+
+	if _, err := sess.Delete(&PushMirror{RepoID: repoID}); err != nil {
+		return err
+	}
+	if _, err := sess.Delete(&PushMirrorAttempt{RepoID: repoID}); err != nil {
+		return err
+	}
+	return committer.Commit()
 }
 
 // GetPushMirrorByID returns push-mirror information.
@@ -102,6 +204,14 @@ func GetPushMirrorsByRepoID(repoID int64) ([]*PushMirror, error) {
 	return mirrors, db.GetEngine(db.DefaultContext).Where("repo_id=?", repoID).Find(&mirrors)
 }
 
+// GetPushMirrorsSyncedOnPush returns the push-mirrors of a repository which are synced on push.
+func GetPushMirrorsSyncedOnPush(repoID int64) ([]*PushMirror, error) {
+	mirrors := make([]*PushMirror, 0, 10)
+	return mirrors, db.GetEngine(db.DefaultContext).
+		Where("repo_id = ? AND sync_on_push = ?", repoID, true).
+		Find(&mirrors)
+}
+
 // PushMirrorsIterate iterates all push-mirror repositories.
 func PushMirrorsIterate(f func(idx int, bean interface{}) error) error {
 	return db.GetEngine(db.DefaultContext).
@@ -110,3 +220,44 @@ func PushMirrorsIterate(f func(idx int, bean interface{}) error) error {
 		OrderBy("last_update ASC").
 		Iterate(new(PushMirror), f)
 }
+
+// InsertPushMirrorAttempt records an attempt to sync a push-mirror and removes the attempts
+// exceeding the number of attempts to keep.
+func InsertPushMirrorAttempt(attempt *PushMirrorAttempt, keep int) error {
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return err
+	}
+	defer committer.Close()
+	sess := db.GetEngine(ctx)
+
+	if _, err := sess.Insert(attempt); err != nil {
+		return err
+	}
+
+	var ids []int64
+	if err := sess.Table("push_mirror_attempt").
+		Where("push_mirror_id = ?", attempt.PushMirrorID).
+		Desc("id").
+		Limit(1, keep).
+		Cols("id").
+		Find(&ids); err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		if _, err := sess.Where("push_mirror_id = ? AND id <= ?", attempt.PushMirrorID, ids[0]).
+			Delete(&PushMirrorAttempt{}); err != nil {
+			return err
+		}
+	}
+	return committer.Commit()
+}
+
+// GetPushMirrorAttempts returns the recorded attempts to sync a push-mirror, latest first.
+func GetPushMirrorAttempts(mirrorID int64) ([]*PushMirrorAttempt, error) {
+	attempts := make([]*PushMirrorAttempt, 0, 10)
+	return attempts, db.GetEngine(db.DefaultContext).
+		Where("push_mirror_id = ?", mirrorID).
+		Desc("id").
+		Find(&attempts)
+}
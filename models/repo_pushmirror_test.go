@@ -5,6 +5,7 @@
 package models
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -48,3 +49,48 @@ func TestPushMirrorsIterate(t *testing.T) {
 		return nil
 	})
 }
+
+func TestPushMirrorRefMatcher(t *testing.T) {
+	match := (&PushMirror{}).RefMatcher()
+	assert.True(t, match("refs/heads/main"))
+	assert.True(t, match("refs/tags/v1.0"))
+
+	match = (&PushMirror{IncludeRefs: "main v*", ExcludeRefs: "v*-rc*"}).RefMatcher()
+	assert.True(t, match("refs/heads/main"))
+	assert.True(t, match("refs/tags/v1.0"))
+	assert.False(t, match("refs/tags/v1.0-rc1"))
+	assert.False(t, match("refs/heads/dev"))
+	assert.False(t, match("refs/pull/1/head"))
+
+	match = (&PushMirror{IncludeRefs: "refs/heads/release/*"}).RefMatcher()
+	assert.True(t, match("refs/heads/release/1.16"))
+	assert.False(t, match("refs/tags/release/1.16"))
+	assert.False(t, match("refs/heads/release/1.16/fix"))
+
+	assert.NoError(t, ValidateRefPatterns("main  v*\nrefs/heads/*"))
+	assert.Error(t, ValidateRefPatterns("main [v"))
+}
+
+func TestInsertPushMirrorAttempt(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, InsertPushMirrorAttempt(&PushMirrorAttempt{
+			PushMirrorID: 1,
+			RepoID:       1,
+			Error:        fmt.Sprintf("error %d", i),
+		}, 3))
+	}
+	assert.NoError(t, InsertPushMirrorAttempt(&PushMirrorAttempt{PushMirrorID: 2, RepoID: 1}, 3))
+
+	attempts, err := GetPushMirrorAttempts(1)
+	assert.NoError(t, err)
+	if assert.Len(t, attempts, 3) {
+		assert.Equal(t, "error 4", attempts[0].Error)
+		assert.Equal(t, "error 2", attempts[2].Error)
+	}
+
+	attempts, err = GetPushMirrorAttempts(2)
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 1)
+}
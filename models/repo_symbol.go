@@ -0,0 +1,111 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/models/db"
+
+	"xorm.io/builder"
+)
+
+// RepoSymbol represents the definition of a symbol in a file of the default branch of a
+// repository, as extracted by the code indexer
+type RepoSymbol struct {
+	ID       int64  `xorm:"pk autoincr"`
+	RepoID   int64  `xorm:"INDEX(s) NOT NULL"`
+	Name     string `xorm:"INDEX(s) VARCHAR(255) NOT NULL"`
+	Kind     string `xorm:"VARCHAR(20)"`
+	Language string `xorm:"VARCHAR(50)"`
+	Path     string `xorm:"TEXT NOT NULL"`
+	Line     int
+	CommitID string `xorm:"VARCHAR(40)"`
+}
+
+func init() {
+	db.RegisterModel(new(RepoSymbol))
+}
+
+// UpdateRepoSymbols replaces the symbols of the files of a repository. The symbols of the
+// removed files and of the files in symbols are deleted, then the given symbols are inserted.
+func UpdateRepoSymbols(repoID int64, removedPaths []string, symbols map[string][]*RepoSymbol) error {
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return err
+	}
+	defer committer.Close()
+	sess := db.GetEngine(ctx)
+
+	paths := make([]string, 0, len(removedPaths)+len(symbols))
+	paths = append(paths, removedPaths...)
+	for path := range symbols {
+		paths = append(paths, path)
+	}
+	for len(paths) > 0 {
+		n := len(paths)
+		if n > 50 {
+			n = 50
+		}
+		if _, err := sess.Where("repo_id = ?", repoID).In("path", paths[:n]).Delete(new(RepoSymbol)); err != nil {
+			return err
+		}
+		paths = paths[n:]
+	}
+
+	beans := make([]*RepoSymbol, 0, 100)
+	for path, pathSymbols := range symbols {
+		for _, symbol := range pathSymbols {
+			symbol.RepoID = repoID
+			symbol.Path = path
+			beans = append(beans, symbol)
+			if len(beans) == cap(beans) {
+				if _, err := sess.Insert(beans); err != nil {
+					return err
+				}
+				beans = beans[:0]
+			}
+		}
+	}
+	if len(beans) > 0 {
+		if _, err := sess.Insert(beans); err != nil {
+			return err
+		}
+	}
+	return committer.Commit()
+}
+
+// DeleteRepoSymbols deletes all the symbols of a repository
+func DeleteRepoSymbols(repoID int64) error {
+	_, err := db.GetEngine(db.DefaultContext).Delete(&RepoSymbol{RepoID: repoID})
+	return err
+}
+
+// FindRepoSymbolsOptions represents the filters of repository symbols
+type FindRepoSymbolsOptions struct {
+	db.ListOptions
+	RepoIDs []int64
+	Name    string
+}
+
+func (opts *FindRepoSymbolsOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if len(opts.RepoIDs) > 0 {
+		cond = cond.And(builder.In("repo_id", opts.RepoIDs))
+	}
+	if opts.Name != "" {
+		cond = cond.And(builder.Eq{"name": opts.Name})
+	}
+	return cond
+}
+
+// FindRepoSymbols returns the symbols matching the options ordered by repository and location
+func FindRepoSymbols(opts *FindRepoSymbolsOptions) ([]*RepoSymbol, error) {
+	sess := db.GetEngine(db.DefaultContext).Where(opts.toConds()).
+		Asc("repo_id", "path", "line")
+	if opts.PageSize > 0 {
+		sess = db.SetSessionPagination(sess, opts)
+	}
+	symbols := make([]*RepoSymbol, 0, 10)
+	return symbols, sess.Find(&symbols)
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateRepoSymbols(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	assert.NoError(t, UpdateRepoSymbols(1, nil, map[string][]*RepoSymbol{
+		"main.go": {
+			{Name: "main", Kind: "function", Language: "Go", Line: 5, CommitID: "a"},
+			{Name: "Version", Kind: "constant", Language: "Go", Line: 3, CommitID: "a"},
+		},
+		"util/util.go": {
+			{Name: "Version", Kind: "variable", Language: "Go", Line: 10, CommitID: "a"},
+		},
+	}))
+	assert.NoError(t, UpdateRepoSymbols(2, nil, map[string][]*RepoSymbol{
+		"main.go": {{Name: "Version", Kind: "constant", Language: "Go", Line: 1, CommitID: "b"}},
+	}))
+
+	symbols, err := FindRepoSymbols(&FindRepoSymbolsOptions{RepoIDs: []int64{1}, Name: "Version"})
+	assert.NoError(t, err)
+	if assert.Len(t, symbols, 2) {
+		assert.Equal(t, "main.go", symbols[0].Path)
+		assert.Equal(t, 3, symbols[0].Line)
+		assert.Equal(t, "util/util.go", symbols[1].Path)
+	}
+
+	symbols, err = FindRepoSymbols(&FindRepoSymbolsOptions{Name: "Version"})
+	assert.NoError(t, err)
+	assert.Len(t, symbols, 3)
+
+	// the symbols of updated and removed files are replaced
+	assert.NoError(t, UpdateRepoSymbols(1, []string{"util/util.go"}, map[string][]*RepoSymbol{
+		"main.go": {{Name: "main", Kind: "function", Language: "Go", Line: 7, CommitID: "c"}},
+	}))
+	symbols, err = FindRepoSymbols(&FindRepoSymbolsOptions{RepoIDs: []int64{1}})
+	assert.NoError(t, err)
+	if assert.Len(t, symbols, 1) {
+		assert.Equal(t, "main", symbols[0].Name)
+		assert.Equal(t, 7, symbols[0].Line)
+		assert.Equal(t, "c", symbols[0].CommitID)
+	}
+
+	assert.NoError(t, DeleteRepoSymbols(1))
+	unittest.AssertNotExistsBean(t, &RepoSymbol{RepoID: 1})
+	unittest.AssertExistsAndLoadBean(t, &RepoSymbol{RepoID: 2})
+}
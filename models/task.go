@@ -115,9 +115,9 @@ func (task *Task) UpdateCols(cols ...string) error {
 	return err
 }
 
-// MigrateConfig returns task config when migrate repository
+// MigrateConfig returns task config when migrate or import repository
 func (task *Task) MigrateConfig() (*migration.MigrateOptions, error) {
-	if task.Type == structs.TaskTypeMigrateRepo {
+	if task.Type == structs.TaskTypeMigrateRepo || task.Type == structs.TaskTypeImportRepo {
 		var opts migration.MigrateOptions
 		err := json.Unmarshal([]byte(task.PayloadContent), &opts)
 		if err != nil {
@@ -146,6 +146,12 @@ func (task *Task) MigrateConfig() (*migration.MigrateOptions, error) {
 	return nil, fmt.Errorf("Task type is %s, not Migrate Repo", task.Type.Name())
 }
 
+// ArchiveRelativePath returns the relative path of the archive produced by an export task
+// or uploaded for an import task
+func (task *Task) ArchiveRelativePath() string {
+	return fmt.Sprintf("%d/%d.zip", task.OwnerID, task.ID)
+}
+
 // ErrTaskDoesNotExist represents a "TaskDoesNotExist" kind of error.
 type ErrTaskDoesNotExist struct {
 	ID     int64
@@ -164,33 +170,35 @@ func (err ErrTaskDoesNotExist) Error() string {
 		err.ID, err.RepoID, err.Type)
 }
 
-// GetMigratingTask returns the migrating task by repo's id
+// migratingTaskTypes are the types of the tasks which create a repository
+var migratingTaskTypes = []structs.TaskType{structs.TaskTypeMigrateRepo, structs.TaskTypeImportRepo}
+
+// GetMigratingTask returns the migrating or importing task by repo's id
 func GetMigratingTask(repoID int64) (*Task, error) {
-	task := Task{
-		RepoID: repoID,
-		Type:   structs.TaskTypeMigrateRepo,
-	}
-	has, err := db.GetEngine(db.DefaultContext).Get(&task)
+	var task Task
+	has, err := db.GetEngine(db.DefaultContext).
+		Where("repo_id = ?", repoID).
+		In("type", migratingTaskTypes).
+		Get(&task)
 	if err != nil {
 		return nil, err
 	} else if !has {
-		return nil, ErrTaskDoesNotExist{0, repoID, task.Type}
+		return nil, ErrTaskDoesNotExist{0, repoID, structs.TaskTypeMigrateRepo}
 	}
 	return &task, nil
 }
 
-// GetMigratingTaskByID returns the migrating task by repo's id
+// GetMigratingTaskByID returns the migrating or importing task by its id
 func GetMigratingTaskByID(id, doerID int64) (*Task, *migration.MigrateOptions, error) {
-	task := Task{
-		ID:     id,
-		DoerID: doerID,
-		Type:   structs.TaskTypeMigrateRepo,
-	}
-	has, err := db.GetEngine(db.DefaultContext).Get(&task)
+	var task Task
+	has, err := db.GetEngine(db.DefaultContext).
+		Where("id = ? AND doer_id = ?", id, doerID).
+		In("type", migratingTaskTypes).
+		Get(&task)
 	if err != nil {
 		return nil, nil, err
 	} else if !has {
-		return nil, nil, ErrTaskDoesNotExist{id, 0, task.Type}
+		return nil, nil, ErrTaskDoesNotExist{id, 0, structs.TaskTypeMigrateRepo}
 	}
 
 	var opts migration.MigrateOptions
@@ -200,9 +208,40 @@ func GetMigratingTaskByID(id, doerID int64) (*Task, *migration.MigrateOptions, e
 	return &task, &opts, nil
 }
 
+// GetRepoTask returns the task of a repository by its type and id
+func GetRepoTask(repoID, id int64, tp structs.TaskType) (*Task, error) {
+	var task Task
+	has, err := db.GetEngine(db.DefaultContext).
+		Where("id = ? AND repo_id = ? AND type = ?", id, repoID, tp).
+		Get(&task)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrTaskDoesNotExist{id, repoID, tp}
+	}
+	return &task, nil
+}
+
+// GetLatestRepoTask returns the latest task of a repository by its type
+func GetLatestRepoTask(repoID int64, tp structs.TaskType) (*Task, error) {
+	var task Task
+	has, err := db.GetEngine(db.DefaultContext).
+		Where("repo_id = ? AND type = ?", repoID, tp).
+		Desc("id").
+		Get(&task)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrTaskDoesNotExist{0, repoID, tp}
+	}
+	return &task, nil
+}
+
 // FindTaskOptions find all tasks
 type FindTaskOptions struct {
 	Status int
+	Type   int
+	RepoID int64
 }
 
 // ToConds generates conditions for database operation.
@@ -211,6 +250,12 @@ func (opts FindTaskOptions) ToConds() builder.Cond {
 	if opts.Status >= 0 {
 		cond = cond.And(builder.Eq{"status": opts.Status})
 	}
+	if opts.Type >= 0 {
+		cond = cond.And(builder.Eq{"type": opts.Type})
+	}
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
 	return cond
 }
 
@@ -221,6 +266,12 @@ func FindTasks(opts FindTaskOptions) ([]*Task, error) {
 	return tasks, err
 }
 
+// DeleteTask deletes a task
+func DeleteTask(task *Task) error {
+	_, err := db.GetEngine(db.DefaultContext).ID(task.ID).Delete(new(Task))
+	return err
+}
+
 // CreateTask creates a task on database
 func CreateTask(task *Task) error {
 	return createTask(db.GetEngine(db.DefaultContext), task)
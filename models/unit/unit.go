@@ -25,6 +25,7 @@ const (
 	TypeExternalWiki                    // 6 ExternalWiki
 	TypeExternalTracker                 // 7 ExternalTracker
 	TypeProjects                        // 8 Kanban board
+	TypePackages                        // 9 Packages
 )
 
 // Value returns integer value for unit type
@@ -50,6 +51,8 @@ func (u Type) String() string {
 		return "TypeExternalTracker"
 	case TypeProjects:
 		return "TypeProjects"
+	case TypePackages:
+		return "TypePackages"
 	}
 	return fmt.Sprintf("Unknown Type %d", u)
 }
@@ -72,6 +75,7 @@ var (
 		TypeExternalWiki,
 		TypeExternalTracker,
 		TypeProjects,
+		TypePackages,
 	}
 
 	// DefaultRepoUnits contains the default unit types
@@ -88,6 +92,7 @@ var (
 	NotAllowedDefaultRepoUnits = []Type{
 		TypeExternalWiki,
 		TypeExternalTracker,
+		TypePackages,
 	}
 
 	// MustRepoUnits contains the units could not be disabled currently
@@ -256,6 +261,16 @@ var (
 		5,
 	}
 
+	// UnitPackages is not bound to a repository but to the owner of the
+	// packages, it is only used to grant team access to the packages of an organization
+	UnitPackages = Unit{
+		TypePackages,
+		"repo.packages",
+		"/packages",
+		"repo.packages.desc",
+		6,
+	}
+
 	// Units contains all the units
 	Units = map[Type]Unit{
 		TypeCode:            UnitCode,
@@ -266,6 +281,7 @@ var (
 		TypeWiki:            UnitWiki,
 		TypeExternalWiki:    UnitExternalWiki,
 		TypeProjects:        UnitProjects,
+		TypePackages:        UnitPackages,
 	}
 )
 
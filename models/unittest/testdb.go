@@ -90,6 +90,12 @@ func MainTest(m *testing.M, pathToGiteaRoot string, fixtureFiles ...string) {
 
 	setting.RepoArchive.Storage.Path = filepath.Join(setting.AppDataPath, "repo-archive")
 
+	setting.RepoExport.Storage.Path = filepath.Join(setting.AppDataPath, "repo-export")
+
+	setting.Packages.Storage.Path = filepath.Join(setting.AppDataPath, "packages")
+
+	setting.Actions.Storage.Path = filepath.Join(setting.AppDataPath, "actions_log")
+
 	if err = storage.Init(); err != nil {
 		fatalTestError("storage.Init: %v\n", err)
 	}
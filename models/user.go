@@ -22,7 +22,9 @@ import (
 
 	_ "image/jpeg" // Needed for jpeg support
 
+	actions_model "code.gitea.io/gitea/models/actions"
 	"code.gitea.io/gitea/models/db"
+	federation_model "code.gitea.io/gitea/models/federation"
 	"code.gitea.io/gitea/models/login"
 	"code.gitea.io/gitea/models/unit"
 	user_model "code.gitea.io/gitea/models/user"
@@ -754,6 +756,7 @@ var (
 		"stars",
 		"template",
 		"user",
+		"v2",
 	}
 
 	reservedUserPatterns = []string{"*.keys", "*.gpg", "*.rss", "*.atom"}
@@ -1119,10 +1122,20 @@ func DeleteUser(ctx context.Context, u *User) (err error) {
 		&Collaboration{UserID: u.ID},
 		&Stopwatch{UserID: u.ID},
 		&user_model.Setting{UserID: u.ID},
+		&actions_model.ActionRunner{OwnerID: u.ID},
+		&actions_model.ActionRunnerToken{OwnerID: u.ID},
+		&federation_model.Activity{UserID: u.ID},
+		&federation_model.Follower{UserID: u.ID},
+		&federation_model.Following{UserID: u.ID},
+		&federation_model.KeyPair{UserID: u.ID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err = deleteProjectsByOwnerID(e, u.ID); err != nil {
+		return fmt.Errorf("deleteProjectsByOwnerID: %v", err)
+	}
+
 	if setting.Service.UserDeleteWithCommentsMaxTime != 0 &&
 		u.CreatedUnix.AsTime().Add(setting.Service.UserDeleteWithCommentsMaxTime).After(time.Now()) {
 
@@ -1232,7 +1245,12 @@ func GetUserByIDCtx(ctx context.Context, id int64) (*User, error) {
 
 // GetUserByName returns user by given name.
 func GetUserByName(name string) (*User, error) {
-	return getUserByName(db.GetEngine(db.DefaultContext), name)
+	return GetUserByNameCtx(db.DefaultContext, name)
+}
+
+// GetUserByNameCtx returns user by given name.
+func GetUserByNameCtx(ctx context.Context, name string) (*User, error) {
+	return getUserByName(db.GetEngine(ctx), name)
 }
 
 func getUserByName(e db.Engine, name string) (*User, error) {
@@ -1316,6 +1334,15 @@ func GetUsersByIDs(ids []int64) (UserList, error) {
 	return ous, err
 }
 
+// GetActiveAdminUsers returns the active site administrators.
+func GetActiveAdminUsers() ([]*User, error) {
+	users := make([]*User, 0, 5)
+	return users, db.GetEngine(db.DefaultContext).
+		Where("type = ? AND is_admin = ? AND is_active = ?", UserTypeIndividual, true, true).
+		Asc("name").
+		Find(&users)
+}
+
 // GetUserIDsByNames returns a slice of ids corresponds to names.
 func GetUserIDsByNames(names []string, ignoreNonExistent bool) ([]int64, error) {
 	ids := make([]int64, 0, len(names))
@@ -1563,6 +1590,18 @@ func SearchUsers(opts *SearchUserOptions) (users []*User, _ int64, _ error) {
 	return users, count, sessQuery.Find(&users)
 }
 
+// FindIndividualUsersByCond returns the individual users matching cond ordered by id skipping the first offset users,
+// and the number of all matching users
+func FindIndividualUsersByCond(cond builder.Cond, offset, limit int) ([]*User, int64, error) {
+	users := make([]*User, 0, limit)
+	count, err := db.GetEngine(db.DefaultContext).
+		Where(builder.And(builder.Eq{"type": UserTypeIndividual}, cond)).
+		OrderBy("id").
+		Limit(limit, offset).
+		FindAndCount(&users)
+	return users, count, err
+}
+
 // GetStarredRepos returns the repos starred by a particular user
 func GetStarredRepos(userID int64, private bool, listOptions db.ListOptions) ([]*Repository, error) {
 	sess := db.GetEngine(db.DefaultContext).Where("star.uid=?", userID).
@@ -95,6 +95,65 @@ func MakeEmailPrimary(email *user_model.EmailAddress) error {
 	return committer.Commit()
 }
 
+// ReplacePrimaryEmailAddress makes email the primary email address of a user. The address is
+// added to the email addresses of the user, or activated, if needed. It must not be used by
+// another user.
+func ReplacePrimaryEmailAddress(u *User, email string) error {
+	email = strings.TrimSpace(email)
+	if strings.EqualFold(u.Email, email) {
+		return nil
+	}
+	if err := user_model.ValidateEmail(email); err != nil {
+		return err
+	}
+
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return err
+	}
+	defer committer.Close()
+	sess := db.GetEngine(ctx)
+
+	addr := &user_model.EmailAddress{LowerEmail: strings.ToLower(email)}
+	has, err := sess.Get(addr)
+	if err != nil {
+		return err
+	} else if has && addr.UID != u.ID {
+		return user_model.ErrEmailAlreadyUsed{Email: email}
+	}
+	if err = checkDupEmail(sess, &User{ID: u.ID, Type: u.Type, Email: email}); err != nil {
+		return err
+	}
+
+	if !has {
+		addr = &user_model.EmailAddress{UID: u.ID, Email: email, IsActivated: true}
+		if err = db.Insert(ctx, addr); err != nil {
+			return err
+		}
+	} else if !addr.IsActivated {
+		addr.IsActivated = true
+		if _, err = sess.ID(addr.ID).Cols("is_activated").Update(addr); err != nil {
+			return err
+		}
+	}
+
+	if _, err = sess.Where("uid=? AND is_primary=?", u.ID, true).Cols("is_primary").Update(&user_model.EmailAddress{
+		IsPrimary: false,
+	}); err != nil {
+		return err
+	}
+	addr.IsPrimary = true
+	if _, err = sess.ID(addr.ID).Cols("is_primary").Update(addr); err != nil {
+		return err
+	}
+
+	u.Email = email
+	if err = updateUserCols(sess, u, "email"); err != nil {
+		return err
+	}
+	return committer.Commit()
+}
+
 // VerifyActiveEmailCode verifies active email code when active account
 func VerifyActiveEmailCode(code, email string) *user_model.EmailAddress {
 	minutes := setting.Service.ActiveCodeLives
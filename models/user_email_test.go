@@ -49,6 +49,28 @@ func TestMakeEmailPrimary(t *testing.T) {
 	assert.Equal(t, "user101@example.com", user.Email)
 }
 
+func TestReplacePrimaryEmailAddress(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	user := unittest.AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	err := ReplacePrimaryEmailAddress(user, "user1@example.com")
+	assert.True(t, user_model.IsErrEmailAlreadyUsed(err))
+	err = ReplacePrimaryEmailAddress(user, "invalid")
+	assert.True(t, user_model.IsErrEmailInvalid(err))
+	assert.Equal(t, "user2@example.com", user.Email)
+
+	assert.NoError(t, ReplacePrimaryEmailAddress(user, "user2-new@example.com"))
+	assert.Equal(t, "user2-new@example.com", user.Email)
+	unittest.AssertExistsAndLoadBean(t, &User{ID: 2, Email: "user2-new@example.com"})
+	unittest.AssertExistsAndLoadBean(t, &user_model.EmailAddress{UID: 2, Email: "user2-new@example.com"}, unittest.Cond("is_activated=? AND is_primary=?", true, true))
+	unittest.AssertExistsAndLoadBean(t, &user_model.EmailAddress{UID: 2, Email: "user2@example.com"}, unittest.Cond("is_primary=?", false))
+
+	// an existing address of the user is made primary again
+	assert.NoError(t, ReplacePrimaryEmailAddress(user, "user2@example.com"))
+	unittest.AssertExistsAndLoadBean(t, &user_model.EmailAddress{UID: 2, Email: "user2@example.com", IsPrimary: true})
+	unittest.AssertExistsAndLoadBean(t, &User{ID: 2, Email: "user2@example.com"})
+}
+
 func TestActivate(t *testing.T) {
 	assert.NoError(t, unittest.PrepareTestDatabase())
 
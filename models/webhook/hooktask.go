@@ -13,6 +13,9 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
 
 	gouuid "github.com/google/uuid"
 )
@@ -97,6 +100,31 @@ type HookResponse struct {
 	Body    string            `json:"body"`
 }
 
+// HookAttempt represents an attempt to deliver a hook task.
+type HookAttempt struct {
+	Delivered int64  `json:"delivered"`
+	Status    int    `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// DeliveredString returns the formatted time of the attempt
+func (a *HookAttempt) DeliveredString() string {
+	return time.Unix(0, a.Delivered).Format("2006-01-02 15:04:05 MST")
+}
+
+// maxHookAttempts is the maximum number of attempts kept in the history of a hook task
+const maxHookAttempts = 50
+
+// HookTaskStatus represents the delivery status of a hook task.
+type HookTaskStatus string
+
+// Delivery statuses of hook tasks
+const (
+	HookTaskStatusPending   HookTaskStatus = "pending"
+	HookTaskStatusSucceeded HookTaskStatus = "succeeded"
+	HookTaskStatusFailed    HookTaskStatus = "failed"
+)
+
 // HookTask represents a hook task.
 type HookTask struct {
 	ID              int64 `xorm:"pk autoincr"`
@@ -110,6 +138,12 @@ type HookTask struct {
 	Delivered       int64
 	DeliveredString string `xorm:"-"`
 
+	// Retry info, a failed attempt is retried at NextAttemptUnix while IsDelivered is false.
+	Attempts        int
+	NextAttemptUnix timeutil.TimeStamp `xorm:"INDEX"`
+	AttemptsContent string             `xorm:"TEXT"`
+	AttemptInfos    []*HookAttempt     `xorm:"-"`
+
 	// History info.
 	IsSucceed       bool
 	RequestContent  string        `xorm:"TEXT"`
@@ -131,12 +165,21 @@ func (t *HookTask) BeforeUpdate() {
 	if t.ResponseInfo != nil {
 		t.ResponseContent = t.simpleMarshalJSON(t.ResponseInfo)
 	}
+	if t.AttemptInfos != nil {
+		t.AttemptsContent = t.simpleMarshalJSON(t.AttemptInfos)
+	}
 }
 
 // AfterLoad updates the webhook object upon setting a column
 func (t *HookTask) AfterLoad() {
 	t.DeliveredString = time.Unix(0, t.Delivered).Format("2006-01-02 15:04:05 MST")
 
+	if len(t.AttemptsContent) > 0 {
+		if err := json.Unmarshal([]byte(t.AttemptsContent), &t.AttemptInfos); err != nil {
+			log.Error("Unmarshal AttemptsContent[%d]: %v", t.ID, err)
+		}
+	}
+
 	if len(t.RequestContent) == 0 {
 		return
 	}
@@ -154,6 +197,25 @@ func (t *HookTask) AfterLoad() {
 	}
 }
 
+// AddAttempt records an attempt to deliver the hook task
+func (t *HookTask) AddAttempt(attempt *HookAttempt) {
+	t.Attempts++
+	t.AttemptInfos = append(t.AttemptInfos, attempt)
+	if len(t.AttemptInfos) > maxHookAttempts {
+		t.AttemptInfos = t.AttemptInfos[len(t.AttemptInfos)-maxHookAttempts:]
+	}
+}
+
+// Status returns the delivery status of the hook task
+func (t *HookTask) Status() HookTaskStatus {
+	if !t.IsDelivered {
+		return HookTaskStatusPending
+	} else if t.IsSucceed {
+		return HookTaskStatusSucceeded
+	}
+	return HookTaskStatusFailed
+}
+
 func (t *HookTask) simpleMarshalJSON(v interface{}) string {
 	p, err := json.Marshal(v)
 	if err != nil {
@@ -195,24 +257,93 @@ func UpdateHookTask(t *HookTask) error {
 	return err
 }
 
-// FindUndeliveredHookTasks represents find the undelivered hook tasks
+// FindUndeliveredHookTasks represents find the undelivered hook tasks whose next attempt is due
 func FindUndeliveredHookTasks() ([]*HookTask, error) {
 	tasks := make([]*HookTask, 0, 10)
-	if err := db.GetEngine(db.DefaultContext).Where("is_delivered=?", false).Find(&tasks); err != nil {
+	if err := db.GetEngine(db.DefaultContext).
+		Where("is_delivered=? AND next_attempt_unix<=?", false, timeutil.TimeStampNow()).
+		Find(&tasks); err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
 
-// FindRepoUndeliveredHookTasks represents find the undelivered hook tasks of one repository
+// FindRepoUndeliveredHookTasks represents find the undelivered hook tasks of one repository whose
+// next attempt is due
 func FindRepoUndeliveredHookTasks(repoID int64) ([]*HookTask, error) {
 	tasks := make([]*HookTask, 0, 5)
-	if err := db.GetEngine(db.DefaultContext).Where("repo_id=? AND is_delivered=?", repoID, false).Find(&tasks); err != nil {
+	if err := db.GetEngine(db.DefaultContext).
+		Where("repo_id=? AND is_delivered=? AND next_attempt_unix<=?", repoID, false, timeutil.TimeStampNow()).
+		Find(&tasks); err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
 
+// FindHookTasksOptions represents the filters of hook tasks
+type FindHookTasksOptions struct {
+	db.ListOptions
+	HookID int64
+	Status HookTaskStatus
+	IDs    []int64
+}
+
+func (opts *FindHookTasksOptions) toCond() builder.Cond {
+	cond := builder.NewCond()
+	if opts.HookID > 0 {
+		cond = cond.And(builder.Eq{"hook_id": opts.HookID})
+	}
+	switch opts.Status {
+	case HookTaskStatusPending:
+		cond = cond.And(builder.Eq{"is_delivered": false})
+	case HookTaskStatusSucceeded:
+		cond = cond.And(builder.Eq{"is_delivered": true, "is_succeed": true})
+	case HookTaskStatusFailed:
+		cond = cond.And(builder.Eq{"is_delivered": true, "is_succeed": false})
+	}
+	if len(opts.IDs) > 0 {
+		cond = cond.And(builder.In("id", opts.IDs))
+	}
+	return cond
+}
+
+// FindHookTasks returns the hook tasks matching the options, the most recent first, and their count
+func FindHookTasks(opts *FindHookTasksOptions) ([]*HookTask, int64, error) {
+	sess := db.GetEngine(db.DefaultContext).Where(opts.toCond()).Desc("id")
+	if opts.PageSize > 0 {
+		sess = db.SetSessionPagination(sess, opts)
+	}
+	tasks := make([]*HookTask, 0, 10)
+	count, err := sess.FindAndCount(&tasks)
+	return tasks, count, err
+}
+
+// RedeliverHookTasks resets the failed hook tasks of a webhook, only those with the given IDs
+// if any, so they are delivered again. It returns the reset hook tasks.
+func RedeliverHookTasks(hookID int64, ids []int64) ([]*HookTask, error) {
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return nil, err
+	}
+	defer committer.Close()
+	sess := db.GetEngine(ctx)
+
+	tasks := make([]*HookTask, 0, 10)
+	cond := (&FindHookTasksOptions{HookID: hookID, Status: HookTaskStatusFailed, IDs: ids}).toCond()
+	if err := sess.Where(cond).Find(&tasks); err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		t.IsDelivered = false
+		t.Attempts = 0
+		t.NextAttemptUnix = 0
+		if _, err := sess.ID(t.ID).Cols("is_delivered", "attempts", "next_attempt_unix").Update(t); err != nil {
+			return nil, err
+		}
+	}
+	return tasks, committer.Commit()
+}
+
 // CleanupHookTaskTable deletes rows from hook_task as needed.
 func CleanupHookTaskTable(ctx context.Context, cleanupType HookTaskCleanupType, olderThan time.Duration, numberToKeep int) error {
 	log.Trace("Doing: CleanupHookTaskTable")
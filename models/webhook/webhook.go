@@ -174,6 +174,11 @@ type Webhook struct {
 	Meta            string     `xorm:"TEXT"` // store hook-specific attributes
 	LastStatus      HookStatus // Last delivery status
 
+	// MaxAttempts is the maximum number of attempts to deliver a hook task, 0 for the default one
+	MaxAttempts int
+	// ConsecutiveFailures is the number of hook tasks which failed in a row after all their attempts
+	ConsecutiveFailures int `xorm:"NOT NULL DEFAULT 0"`
+
 	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
 }
@@ -498,12 +503,31 @@ func UpdateWebhook(w *Webhook) error {
 	return err
 }
 
-// UpdateWebhookLastStatus updates last status of webhook.
-func UpdateWebhookLastStatus(w *Webhook) error {
-	_, err := db.GetEngine(db.DefaultContext).ID(w.ID).Cols("last_status").Update(w)
+// UpdateWebhookLastStatus updates last status of webhook. A successful delivery resets its
+// consecutive failures, which are incremented if countFailure is true, i.e. when a hook task
+// failed after all its attempts. Both are done in the database as deliveries run concurrently.
+func UpdateWebhookLastStatus(id int64, status HookStatus, countFailure bool) error {
+	sess := db.GetEngine(db.DefaultContext).ID(id).Cols("last_status")
+	if status == HookStatusSucceed {
+		sess.SetExpr("consecutive_failures", 0)
+	} else if countFailure {
+		sess.Incr("consecutive_failures")
+	}
+	_, err := sess.Update(&Webhook{LastStatus: status})
 	return err
 }
 
+// DisableWebhookAfterFailures deactivates an active webhook if at least threshold hook tasks
+// failed in a row, and resets its consecutive failures. It returns true if the webhook has been
+// deactivated by this call, so that only one of the concurrent deliveries reports it.
+func DisableWebhookAfterFailures(id int64, threshold int) (bool, error) {
+	affected, err := db.GetEngine(db.DefaultContext).
+		Where("id = ? AND is_active = ? AND consecutive_failures >= ?", id, true, threshold).
+		Cols("is_active", "consecutive_failures").
+		Update(&Webhook{IsActive: false, ConsecutiveFailures: 0})
+	return affected > 0, err
+}
+
 // deleteWebhook uses argument bean as query condition,
 // ID must be specified and do not assign unnecessary fields.
 func deleteWebhook(bean *Webhook) (err error) {
@@ -13,6 +13,7 @@ import (
 	"code.gitea.io/gitea/models/unittest"
 	"code.gitea.io/gitea/modules/json"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
 
 	"github.com/stretchr/testify/assert"
@@ -169,6 +170,47 @@ func TestUpdateWebhook(t *testing.T) {
 	unittest.AssertExistsAndLoadBean(t, hook)
 }
 
+func TestUpdateWebhookLastStatus(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	assert.NoError(t, UpdateWebhookLastStatus(1, HookStatusFail, false))
+	unittest.AssertExistsAndLoadBean(t, &Webhook{ID: 1, LastStatus: HookStatusFail, ConsecutiveFailures: 0})
+
+	assert.NoError(t, UpdateWebhookLastStatus(1, HookStatusFail, true))
+	assert.NoError(t, UpdateWebhookLastStatus(1, HookStatusFail, true))
+	unittest.AssertExistsAndLoadBean(t, &Webhook{ID: 1, LastStatus: HookStatusFail, ConsecutiveFailures: 2})
+
+	assert.NoError(t, UpdateWebhookLastStatus(1, HookStatusSucceed, false))
+	hook := unittest.AssertExistsAndLoadBean(t, &Webhook{ID: 1, LastStatus: HookStatusSucceed}).(*Webhook)
+	assert.Zero(t, hook.ConsecutiveFailures)
+	assert.True(t, hook.IsActive)
+}
+
+func TestDisableWebhookAfterFailures(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	assert.NoError(t, UpdateWebhookLastStatus(1, HookStatusFail, true))
+	assert.NoError(t, UpdateWebhookLastStatus(1, HookStatusFail, true))
+
+	disabled, err := DisableWebhookAfterFailures(1, 3)
+	assert.NoError(t, err)
+	assert.False(t, disabled)
+	unittest.AssertExistsAndLoadBean(t, &Webhook{ID: 1, IsActive: true})
+
+	assert.NoError(t, UpdateWebhookLastStatus(1, HookStatusFail, true))
+	disabled, err = DisableWebhookAfterFailures(1, 3)
+	assert.NoError(t, err)
+	assert.True(t, disabled)
+	hook := unittest.AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	assert.False(t, hook.IsActive)
+	assert.Zero(t, hook.ConsecutiveFailures)
+
+	// an inactive webhook is not disabled again
+	disabled, err = DisableWebhookAfterFailures(1, 0)
+	assert.NoError(t, err)
+	assert.False(t, disabled)
+}
+
 func TestDeleteWebhookByRepoID(t *testing.T) {
 	assert.NoError(t, unittest.PrepareTestDatabase())
 	unittest.AssertExistsAndLoadBean(t, &Webhook{ID: 2, RepoID: 1})
@@ -228,6 +270,88 @@ func TestUpdateHookTask(t *testing.T) {
 	unittest.AssertExistsAndLoadBean(t, hook)
 }
 
+func TestFindUndeliveredHookTasks(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	hookTask := &HookTask{
+		RepoID:    3,
+		HookID:    3,
+		Payloader: &api.PushPayload{},
+	}
+	assert.NoError(t, CreateHookTask(hookTask))
+
+	tasks, err := FindRepoUndeliveredHookTasks(3)
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+
+	hookTask.NextAttemptUnix = timeutil.TimeStampNow().Add(60)
+	_, err = db.GetEngine(db.DefaultContext).ID(hookTask.ID).Cols("next_attempt_unix").Update(hookTask)
+	assert.NoError(t, err)
+	tasks, err = FindRepoUndeliveredHookTasks(3)
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 0)
+}
+
+func TestFindHookTasks(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	hookTask := &HookTask{
+		RepoID:    1,
+		HookID:    1,
+		Payloader: &api.PushPayload{},
+	}
+	assert.NoError(t, CreateHookTask(hookTask))
+
+	tasks, count, err := FindHookTasks(&FindHookTasksOptions{HookID: 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	if assert.Len(t, tasks, 2) {
+		assert.Equal(t, hookTask.ID, tasks[0].ID)
+		assert.Equal(t, HookTaskStatusPending, tasks[0].Status())
+		assert.Equal(t, HookTaskStatusFailed, tasks[1].Status())
+	}
+
+	tasks, count, err = FindHookTasks(&FindHookTasksOptions{HookID: 1, Status: HookTaskStatusFailed})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	if assert.Len(t, tasks, 1) {
+		assert.EqualValues(t, 1, tasks[0].ID)
+	}
+
+	_, count, err = FindHookTasks(&FindHookTasksOptions{HookID: 1, Status: HookTaskStatusSucceeded})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+}
+
+func TestRedeliverHookTasks(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	tasks, err := RedeliverHookTasks(1, []int64{unittest.NonexistentID})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 0)
+
+	tasks, err = RedeliverHookTasks(1, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, tasks, 1) {
+		assert.EqualValues(t, 1, tasks[0].ID)
+	}
+	hookTask := unittest.AssertExistsAndLoadBean(t, &HookTask{ID: 1}).(*HookTask)
+	assert.Equal(t, HookTaskStatusPending, hookTask.Status())
+	assert.Zero(t, hookTask.Attempts)
+
+	tasks, err = RedeliverHookTasks(1, nil)
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 0)
+}
+
+func TestHookTask_AddAttempt(t *testing.T) {
+	hookTask := &HookTask{}
+	for i := 0; i < maxHookAttempts+2; i++ {
+		hookTask.AddAttempt(&HookAttempt{Delivered: int64(i)})
+	}
+	assert.Equal(t, maxHookAttempts+2, hookTask.Attempts)
+	assert.Len(t, hookTask.AttemptInfos, maxHookAttempts)
+	assert.EqualValues(t, 2, hookTask.AttemptInfos[0].Delivered)
+}
+
 func TestCleanupHookTaskTable_PerWebhook_DeletesDelivered(t *testing.T) {
 	assert.NoError(t, unittest.PrepareTestDatabase())
 	hookTask := &HookTask{
@@ -0,0 +1,364 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/gobwas/glob"
+	"gopkg.in/yaml.v2"
+)
+
+// List of supported workflow trigger events
+const (
+	EventPush        = "push"
+	EventPullRequest = "pull_request"
+)
+
+// WorkflowDirs are the repository directories which are searched for workflow files.
+// The first directory containing workflow files wins.
+var WorkflowDirs = []string{".gitea/workflows", ".github/workflows"}
+
+const maxWorkflowSize = 1 << 20
+
+var (
+	// ErrInvalidWorkflow indicates an invalid workflow file
+	ErrInvalidWorkflow = errors.New("Workflow file is invalid")
+)
+
+// Workflow represents a parsed workflow file
+type Workflow struct {
+	Name   string
+	Events map[string]*EventFilter
+	Jobs   []*Job
+
+	rawOn interface{}
+}
+
+// EventFilter restricts the refs an event triggers the workflow for
+type EventFilter struct {
+	Branches       []string `yaml:"branches"`
+	BranchesIgnore []string `yaml:"branches-ignore"`
+	Tags           []string `yaml:"tags"`
+	TagsIgnore     []string `yaml:"tags-ignore"`
+}
+
+// Job represents a single job of a workflow
+type Job struct {
+	ID     string
+	Name   string
+	RunsOn []string
+	Needs  []string
+
+	raw interface{}
+}
+
+// stringList accepts a single string or a list of strings
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single string
+	if err := unmarshal(&single); err == nil {
+		if single == "" {
+			*l = nil
+		} else {
+			*l = []string{single}
+		}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+type jobSpec struct {
+	Name   string     `yaml:"name"`
+	RunsOn stringList `yaml:"runs-on"`
+	Needs  stringList `yaml:"needs"`
+}
+
+type eventFilterSpec struct {
+	Branches       stringList `yaml:"branches"`
+	BranchesIgnore stringList `yaml:"branches-ignore"`
+	Tags           stringList `yaml:"tags"`
+	TagsIgnore     stringList `yaml:"tags-ignore"`
+}
+
+// ParseWorkflow parses the content of a workflow file
+func ParseWorkflow(content []byte) (*Workflow, error) {
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+	}
+
+	w := &Workflow{}
+	var rawJobs yaml.MapSlice
+	for _, item := range doc {
+		// YAML 1.1 resolves an unquoted "on" key to a boolean
+		if b, ok := item.Key.(bool); ok && b {
+			item.Key = "on"
+		}
+		key, _ := item.Key.(string)
+		switch key {
+		case "name":
+			w.Name, _ = item.Value.(string)
+		case "on":
+			w.rawOn = item.Value
+		case "jobs":
+			if err := remarshal(item.Value, &rawJobs); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	events, err := parseEvents(w.rawOn)
+	if err != nil {
+		return nil, err
+	}
+	w.Events = events
+
+	for _, item := range rawJobs {
+		id, ok := item.Key.(string)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("%w: invalid job id", ErrInvalidWorkflow)
+		}
+
+		var spec jobSpec
+		if err := remarshal(item.Value, &spec); err != nil {
+			return nil, err
+		}
+
+		job := &Job{
+			ID:     id,
+			Name:   spec.Name,
+			RunsOn: spec.RunsOn,
+			Needs:  spec.Needs,
+			raw:    item.Value,
+		}
+		if job.Name == "" {
+			job.Name = id
+		}
+		w.Jobs = append(w.Jobs, job)
+	}
+	if len(w.Jobs) == 0 {
+		return nil, fmt.Errorf("%w: no jobs defined", ErrInvalidWorkflow)
+	}
+
+	if err := checkJobNeeds(w.Jobs); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func remarshal(in, out interface{}) error {
+	data, err := yaml.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+	}
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+	}
+	return nil
+}
+
+func parseEvents(rawOn interface{}) (map[string]*EventFilter, error) {
+	events := make(map[string]*EventFilter)
+	switch v := rawOn.(type) {
+	case nil:
+		return nil, fmt.Errorf("%w: no trigger events defined", ErrInvalidWorkflow)
+	case string:
+		events[v] = &EventFilter{}
+	case []interface{}:
+		for _, e := range v {
+			name, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: invalid event %v", ErrInvalidWorkflow, e)
+			}
+			events[name] = &EventFilter{}
+		}
+	default:
+		var specs map[string]*eventFilterSpec
+		if err := remarshal(v, &specs); err != nil {
+			return nil, err
+		}
+		for name, spec := range specs {
+			filter := &EventFilter{}
+			if spec != nil {
+				filter.Branches = spec.Branches
+				filter.BranchesIgnore = spec.BranchesIgnore
+				filter.Tags = spec.Tags
+				filter.TagsIgnore = spec.TagsIgnore
+			}
+			for _, patterns := range [][]string{filter.Branches, filter.BranchesIgnore, filter.Tags, filter.TagsIgnore} {
+				for _, pattern := range patterns {
+					if _, err := glob.Compile(pattern, '/'); err != nil {
+						return nil, fmt.Errorf("%w: invalid pattern %q: %v", ErrInvalidWorkflow, pattern, err)
+					}
+				}
+			}
+			events[name] = filter
+		}
+	}
+	return events, nil
+}
+
+func checkJobNeeds(jobs []*Job) error {
+	byID := make(map[string]*Job, len(jobs))
+	for _, job := range jobs {
+		byID[job.ID] = job
+	}
+	for _, job := range jobs {
+		for _, need := range job.Needs {
+			if _, ok := byID[need]; !ok {
+				return fmt.Errorf("%w: job %q needs unknown job %q", ErrInvalidWorkflow, job.ID, need)
+			}
+		}
+	}
+
+	// detect cycles, jobs of a cycle would be blocked forever
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(jobs))
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case visiting:
+			return fmt.Errorf("%w: cyclic needs of job %q", ErrInvalidWorkflow, id)
+		case visited:
+			return nil
+		}
+		state[id] = visiting
+		for _, need := range byID[id].Needs {
+			if err := visit(need); err != nil {
+				return err
+			}
+		}
+		state[id] = visited
+		return nil
+	}
+	for _, job := range jobs {
+		if err := visit(job.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Matches checks if the workflow is triggered by the event.
+// ref is the full name of the pushed ref or the base branch of a pull request.
+func (w *Workflow) Matches(event, ref string) bool {
+	filter, ok := w.Events[event]
+	if !ok {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(ref, git.BranchPrefix):
+		return matchFilter(strings.TrimPrefix(ref, git.BranchPrefix), filter.Branches, filter.BranchesIgnore, len(filter.Tags) > 0 || len(filter.TagsIgnore) > 0)
+	case strings.HasPrefix(ref, git.TagPrefix):
+		return matchFilter(strings.TrimPrefix(ref, git.TagPrefix), filter.Tags, filter.TagsIgnore, len(filter.Branches) > 0 || len(filter.BranchesIgnore) > 0)
+	}
+	return true
+}
+
+// matchFilter checks the name against the include and exclude patterns.
+// If no patterns are present but patterns for the other ref kind are, the name does not match.
+func matchFilter(name string, include, exclude []string, otherKindFiltered bool) bool {
+	if len(include) == 0 && len(exclude) == 0 {
+		return !otherKindFiltered
+	}
+	if len(include) > 0 && !matchAny(name, include) {
+		return false
+	}
+	return !matchAny(name, exclude)
+}
+
+func matchAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			continue
+		}
+		if g.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload returns the workflow reduced to the given job which is sent to the runner
+func (w *Workflow) Payload(job *Job) (string, error) {
+	doc := yaml.MapSlice{}
+	if w.Name != "" {
+		doc = append(doc, yaml.MapItem{Key: "name", Value: w.Name})
+	}
+	doc = append(doc,
+		yaml.MapItem{Key: "on", Value: w.rawOn},
+		yaml.MapItem{Key: "jobs", Value: yaml.MapSlice{{Key: job.ID, Value: job.raw}}},
+	)
+
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ListWorkflows lists the workflow files of the commit
+func ListWorkflows(commit *git.Commit) (git.Entries, error) {
+	for _, dir := range WorkflowDirs {
+		tree, err := commit.SubTree(dir)
+		if err != nil {
+			if git.IsErrNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		entries, err := tree.ListEntries()
+		if err != nil {
+			return nil, err
+		}
+
+		workflows := make(git.Entries, 0, len(entries))
+		for _, entry := range entries {
+			name := strings.ToLower(entry.Name())
+			if entry.IsRegular() && (strings.HasSuffix(name, ".yml") || strings.HasSuffix(name, ".yaml")) {
+				workflows = append(workflows, entry)
+			}
+		}
+		if len(workflows) > 0 {
+			return workflows, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetContentFromEntry reads the content of a workflow file
+func GetContentFromEntry(entry *git.TreeEntry) ([]byte, error) {
+	if entry.Blob().Size() > maxWorkflowSize {
+		return nil, fmt.Errorf("%w: file is too large", ErrInvalidWorkflow)
+	}
+
+	rc, err := entry.Blob().DataAsync()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+const testWorkflow = `name: CI
+on:
+  push:
+    branches: [main, "release/**"]
+    tags: "v*"
+  pull_request:
+jobs:
+  test:
+    runs-on: linux
+    steps:
+      - run: make test
+  build:
+    name: Build
+    runs-on: [linux, amd64]
+    needs: test
+    steps:
+      - run: make build
+`
+
+func TestParseWorkflow(t *testing.T) {
+	w, err := ParseWorkflow([]byte(testWorkflow))
+	assert.NoError(t, err)
+	assert.Equal(t, "CI", w.Name)
+	assert.Len(t, w.Events, 2)
+	assert.Equal(t, []string{"main", "release/**"}, w.Events[EventPush].Branches)
+	assert.Equal(t, []string{"v*"}, w.Events[EventPush].Tags)
+
+	assert.Len(t, w.Jobs, 2)
+	assert.Equal(t, "test", w.Jobs[0].ID)
+	assert.Equal(t, "test", w.Jobs[0].Name)
+	assert.Equal(t, []string{"linux"}, w.Jobs[0].RunsOn)
+	assert.Empty(t, w.Jobs[0].Needs)
+	assert.Equal(t, "build", w.Jobs[1].ID)
+	assert.Equal(t, "Build", w.Jobs[1].Name)
+	assert.Equal(t, []string{"linux", "amd64"}, w.Jobs[1].RunsOn)
+	assert.Equal(t, []string{"test"}, w.Jobs[1].Needs)
+
+	payload, err := w.Payload(w.Jobs[1])
+	assert.NoError(t, err)
+
+	var doc struct {
+		Name string                 `yaml:"name"`
+		Jobs map[string]interface{} `yaml:"jobs"`
+	}
+	assert.NoError(t, yaml.Unmarshal([]byte(payload), &doc))
+	assert.Equal(t, "CI", doc.Name)
+	assert.Len(t, doc.Jobs, 1)
+	assert.Contains(t, doc.Jobs, "build")
+
+	w, err = ParseWorkflow([]byte("on: [push, pull_request]\njobs:\n  a:\n    steps: []\n"))
+	assert.NoError(t, err)
+	assert.Len(t, w.Events, 2)
+	assert.Empty(t, w.Jobs[0].RunsOn)
+
+	cases := []string{
+		"jobs: {a: {}}",
+		"on: push",
+		"on: push\njobs:\n  a:\n    needs: b\n",
+		"on: push\njobs:\n  a:\n    needs: b\n  b:\n    needs: a\n",
+		"on: {push: {branches: ['[']}}\njobs: {a: {}}",
+		"on: push\njobs: [",
+	}
+	for _, c := range cases {
+		_, err := ParseWorkflow([]byte(c))
+		assert.True(t, errors.Is(err, ErrInvalidWorkflow), "%s: %v", c, err)
+	}
+}
+
+func TestWorkflowMatches(t *testing.T) {
+	w, err := ParseWorkflow([]byte(testWorkflow))
+	assert.NoError(t, err)
+
+	assert.True(t, w.Matches(EventPush, "refs/heads/main"))
+	assert.True(t, w.Matches(EventPush, "refs/heads/release/1.16"))
+	assert.False(t, w.Matches(EventPush, "refs/heads/feature"))
+	assert.True(t, w.Matches(EventPush, "refs/tags/v1.0.0"))
+	assert.False(t, w.Matches(EventPush, "refs/tags/test"))
+	assert.True(t, w.Matches(EventPullRequest, "refs/heads/feature"))
+
+	w, err = ParseWorkflow([]byte("on:\n  push:\n    branches-ignore: [wip/*]\njobs: {a: {}}"))
+	assert.NoError(t, err)
+	assert.True(t, w.Matches(EventPush, "refs/heads/main"))
+	assert.False(t, w.Matches(EventPush, "refs/heads/wip/test"))
+	// only branches are filtered, tags do not trigger the workflow
+	assert.False(t, w.Matches(EventPush, "refs/tags/v1.0.0"))
+	assert.False(t, w.Matches(EventPullRequest, "refs/heads/main"))
+}
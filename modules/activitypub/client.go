@@ -0,0 +1,159 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+)
+
+// Client sends requests which are signed with the key of an actor
+type Client struct {
+	httpClient *http.Client
+	keyID      string
+	key        *rsa.PrivateKey
+	maxSize    int64
+}
+
+// NewClient creates a client which signs its requests with the private key.
+// Responses larger than maxSize bytes are rejected.
+func NewClient(httpClient *http.Client, keyID, privPem string, maxSize int64) (*Client, error) {
+	key, err := ParsePrivateKey(privPem)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		httpClient: httpClient,
+		keyID:      keyID,
+		key:        key,
+		maxSize:    maxSize,
+	}, nil
+}
+
+// Post delivers the payload to the inbox
+func (c *Client) Post(ctx context.Context, inbox string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	if err := SignRequest(req, c.keyID, c.key, payload); err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, c.maxSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("delivery to %s failed with status %d", inbox, resp.StatusCode)
+	}
+	return nil
+}
+
+// Get fetches the document with the IRI and decodes it into v
+func (c *Client) Get(ctx context.Context, iri string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, iri, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", ContentType+", "+LDContentType)
+	if err := SignRequest(req, c.keyID, c.key, nil); err != nil {
+		return err
+	}
+	return c.do(req, v)
+}
+
+// FetchActor fetches the actor document with the IRI.
+// The id of the document must match the IRI to prevent spoofing.
+func (c *Client) FetchActor(ctx context.Context, iri string) (*Actor, error) {
+	actor := &Actor{}
+	if err := c.Get(ctx, iri, actor); err != nil {
+		return nil, err
+	}
+	if actor.ID != iri || actor.Inbox == "" {
+		return nil, ErrInvalidObject
+	}
+	if actor.PublicKey != nil && actor.PublicKey.Owner != actor.ID {
+		return nil, ErrInvalidObject
+	}
+	return actor, nil
+}
+
+// FetchObject fetches the object with the IRI.
+// The id of the document must match the IRI to prevent spoofing.
+func (c *Client) FetchObject(ctx context.Context, iri string) (*Object, error) {
+	obj := &Object{}
+	if err := c.Get(ctx, iri, obj); err != nil {
+		return nil, err
+	}
+	if obj.ID != iri {
+		return nil, ErrInvalidObject
+	}
+	return obj, nil
+}
+
+// WebFinger resolves a handle like user@example.com to the IRI of the actor
+func (c *Client) WebFinger(ctx context.Context, handle string) (string, error) {
+	handle = strings.TrimPrefix(strings.TrimPrefix(handle, "acct:"), "@")
+	parts := strings.SplitN(handle, "@", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid handle %q", handle)
+	}
+
+	u := url.URL{
+		Scheme:   "https",
+		Host:     parts[1],
+		Path:     "/.well-known/webfinger",
+		RawQuery: url.Values{"resource": {"acct:" + handle}}.Encode(),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", WebFingerContentType)
+
+	jrd := &WebFingerJRD{}
+	if err := c.do(req, jrd); err != nil {
+		return "", err
+	}
+	for _, link := range jrd.Links {
+		if link.Rel == "self" && (link.Type == ContentType || link.Type == LDContentType) {
+			return link.Href, nil
+		}
+	}
+	return "", fmt.Errorf("no actor found for %q", handle)
+}
+
+func (c *Client) do(req *http.Request, v interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed with status %d", req.URL, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.maxSize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(body)) > c.maxSize {
+		return fmt.Errorf("response of %s is too large", req.URL)
+	}
+	return json.Unmarshal(body, v)
+}
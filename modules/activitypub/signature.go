@@ -0,0 +1,218 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MaxSignatureAge is the maximum difference between the date of a signed request and now
+const MaxSignatureAge = 12 * time.Hour
+
+var (
+	// ErrMissingSignature is returned if a request is not signed
+	ErrMissingSignature = errors.New("missing HTTP signature")
+	// ErrInvalidSignature is returned if the signature of a request does not match
+	ErrInvalidSignature = errors.New("invalid HTTP signature")
+	// ErrInvalidKey is returned if a key can not be parsed
+	ErrInvalidKey = errors.New("invalid key")
+)
+
+// ParsePrivateKey parses a PEM encoded RSA private key
+func ParsePrivateKey(privPem string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privPem))
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return rsaKey, nil
+}
+
+// ParsePublicKey parses a PEM encoded RSA public key
+func ParsePublicKey(pubPem string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pubPem))
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+		return rsaKey, nil
+	}
+	key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+// Digest returns the value of the Digest header for the body
+func Digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// SignRequest signs the request following draft-cavage-http-signatures.
+// The signature covers the request target, the host, the date and for requests with a body its digest.
+func SignRequest(req *http.Request, keyID string, key *rsa.PrivateKey, body []byte) error {
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	if req.Host == "" {
+		req.Host = req.URL.Host
+	}
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		req.Header.Set("Digest", Digest(body))
+		headers = append(headers, "digest")
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(req, headers)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID,
+		strings.Join(headers, " "),
+		base64.StdEncoding.EncodeToString(signature),
+	))
+	return nil
+}
+
+// SignatureKeyID returns the id of the key the request claims to be signed with
+func SignatureKeyID(req *http.Request) (string, error) {
+	params, err := parseSignature(req)
+	if err != nil {
+		return "", err
+	}
+	return params["keyId"], nil
+}
+
+// VerifyRequest verifies the signature of the request with the public key.
+// The body is checked against the Digest header which must be signed if the request has a body.
+func VerifyRequest(req *http.Request, body []byte, pubPem string) error {
+	params, err := parseSignature(req)
+	if err != nil {
+		return err
+	}
+	switch params["algorithm"] {
+	case "", "rsa-sha256", "hs2019":
+	default:
+		return ErrInvalidSignature
+	}
+
+	headers := strings.Fields(strings.ToLower(params["headers"]))
+	if len(headers) == 0 {
+		headers = []string{"date"}
+	}
+	required := []string{"(request-target)", "host", "date"}
+	if len(body) > 0 {
+		required = append(required, "digest")
+	}
+	for _, name := range required {
+		if !containsString(headers, name) {
+			return ErrInvalidSignature
+		}
+	}
+
+	date, err := http.ParseTime(req.Header.Get("Date"))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(date); age > MaxSignatureAge || age < -MaxSignatureAge {
+		return ErrInvalidSignature
+	}
+
+	if len(body) > 0 && req.Header.Get("Digest") != Digest(body) {
+		return ErrInvalidSignature
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	key, err := ParsePublicKey(pubPem)
+	if err != nil {
+		return err
+	}
+	hashed := sha256.Sum256([]byte(signingString(req, headers)))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signingString(req *http.Request, headers []string) string {
+	lines := make([]string, 0, len(headers))
+	for _, name := range headers {
+		var value string
+		switch name {
+		case "(request-target)":
+			value = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			value = req.Host
+		default:
+			value = strings.Join(req.Header.Values(name), ", ")
+		}
+		lines = append(lines, name+": "+value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func parseSignature(req *http.Request) (map[string]string, error) {
+	header := req.Header.Get("Signature")
+	if header == "" {
+		auth := req.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Signature ") {
+			return nil, ErrMissingSignature
+		}
+		header = strings.TrimPrefix(auth, "Signature ")
+	}
+
+	params := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return nil, ErrInvalidSignature
+		}
+		params[kv[0]] = strings.Trim(kv[1], `"`)
+	}
+	if params["keyId"] == "" || params["signature"] == "" {
+		return nil, ErrInvalidSignature
+	}
+	return params, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package activitypub
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSignedRequest(t *testing.T, privPem string, body []byte) *http.Request {
+	key, err := ParsePrivateKey(privPem)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "https://example.com/api/v1/activitypub/user/alice/inbox", bytes.NewReader(body))
+	assert.NoError(t, SignRequest(req, "https://remote.example.com/users/bob#main-key", key, body))
+	return req
+}
+
+func TestSignAndVerifyRequest(t *testing.T) {
+	priv, pub, err := GenerateKeyPair()
+	assert.NoError(t, err)
+	body := []byte(`{"type":"Follow"}`)
+
+	req := newSignedRequest(t, priv, body)
+	keyID, err := SignatureKeyID(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://remote.example.com/users/bob#main-key", keyID)
+	assert.NoError(t, VerifyRequest(req, body, pub))
+
+	t.Run("TamperedBody", func(t *testing.T) {
+		req := newSignedRequest(t, priv, body)
+		assert.ErrorIs(t, VerifyRequest(req, []byte(`{"type":"Undo"}`), pub), ErrInvalidSignature)
+	})
+
+	t.Run("TamperedDigest", func(t *testing.T) {
+		req := newSignedRequest(t, priv, body)
+		other := []byte(`{"type":"Undo"}`)
+		req.Header.Set("Digest", Digest(other))
+		assert.ErrorIs(t, VerifyRequest(req, other, pub), ErrInvalidSignature)
+	})
+
+	t.Run("WrongKey", func(t *testing.T) {
+		_, otherPub, err := GenerateKeyPair()
+		assert.NoError(t, err)
+		req := newSignedRequest(t, priv, body)
+		assert.ErrorIs(t, VerifyRequest(req, body, otherPub), ErrInvalidSignature)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		req := newSignedRequest(t, priv, body)
+		req.Header.Set("Date", time.Now().Add(-2*MaxSignatureAge).UTC().Format(http.TimeFormat))
+		assert.ErrorIs(t, VerifyRequest(req, body, pub), ErrInvalidSignature)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "https://example.com/inbox", bytes.NewReader(body))
+		assert.ErrorIs(t, VerifyRequest(req, body, pub), ErrMissingSignature)
+	})
+}
+
+func TestActivityObject(t *testing.T) {
+	follow, err := NewActivity("https://example.com/follow/1", TypeFollow, "https://example.com/users/alice", "https://remote.example.com/users/bob")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://remote.example.com/users/bob", follow.ObjectIRI())
+	_, err = follow.ObjectActivity()
+	assert.ErrorIs(t, err, ErrInvalidObject)
+
+	undo, err := NewActivity("https://example.com/undo/1", TypeUndo, "https://example.com/users/alice", follow)
+	assert.NoError(t, err)
+	assert.NoError(t, undo.Validate())
+	assert.Equal(t, follow.ID, undo.ObjectIRI())
+	inner, err := undo.ObjectActivity()
+	assert.NoError(t, err)
+	assert.Equal(t, TypeFollow, inner.Type)
+	assert.Equal(t, follow.ObjectIRI(), inner.ObjectIRI())
+
+	assert.ErrorIs(t, (&Activity{Type: TypeFollow}).Validate(), ErrInvalidObject)
+}
@@ -0,0 +1,209 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package activitypub
+
+import (
+	"errors"
+
+	"code.gitea.io/gitea/modules/json"
+)
+
+const (
+	// ContentType is the content type of ActivityPub documents
+	ContentType = "application/activity+json"
+	// LDContentType is the JSON-LD content type which is accepted as an alias of ContentType
+	LDContentType = `application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+
+	// ActivityStreamsContext is the JSON-LD context of ActivityStreams
+	ActivityStreamsContext = "https://www.w3.org/ns/activitystreams"
+	// SecurityContext is the JSON-LD context of the public key properties
+	SecurityContext = "https://w3id.org/security/v1"
+	// ForgeFedContext is the JSON-LD context of the ForgeFed vocabulary
+	ForgeFedContext = "https://forgefed.org/ns"
+
+	// Public is the special collection which addresses everyone
+	Public = "https://www.w3.org/ns/activitystreams#Public"
+)
+
+// Actor and object types
+const (
+	TypePerson            = "Person"
+	TypeRepository        = "Repository"
+	TypeTicket            = "Ticket"
+	TypeNote              = "Note"
+	TypeOrderedCollection = "OrderedCollection"
+)
+
+// Activity types
+const (
+	TypeFollow = "Follow"
+	TypeAccept = "Accept"
+	TypeUndo   = "Undo"
+	TypeStar   = "Star"
+	TypeCreate = "Create"
+)
+
+// ErrInvalidObject is returned if a document is not a valid ActivityPub object
+var ErrInvalidObject = errors.New("invalid ActivityPub object")
+
+// DefaultLDContext returns the JSON-LD context used by all documents of Gitea
+func DefaultLDContext() []interface{} {
+	return []interface{}{ActivityStreamsContext, SecurityContext, ForgeFedContext}
+}
+
+// PublicKey is the public key of an actor used to verify its signatures
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+// Endpoints contains additional endpoints of an actor
+type Endpoints struct {
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+// Actor is a person or repository which can send and receive activities
+type Actor struct {
+	LDContext         interface{} `json:"@context,omitempty"`
+	ID                string      `json:"id"`
+	Type              string      `json:"type"`
+	PreferredUsername string      `json:"preferredUsername,omitempty"`
+	Name              string      `json:"name,omitempty"`
+	Summary           string      `json:"summary,omitempty"`
+	URL               string      `json:"url,omitempty"`
+	Icon              *Image      `json:"icon,omitempty"`
+	AttributedTo      string      `json:"attributedTo,omitempty"`
+	Inbox             string      `json:"inbox"`
+	Outbox            string      `json:"outbox"`
+	Followers         string      `json:"followers,omitempty"`
+	Following         string      `json:"following,omitempty"`
+	Endpoints         *Endpoints  `json:"endpoints,omitempty"`
+	PublicKey         *PublicKey  `json:"publicKey,omitempty"`
+}
+
+// Image is an image like the avatar of an actor
+type Image struct {
+	Type      string `json:"type"`
+	MediaType string `json:"mediaType,omitempty"`
+	URL       string `json:"url"`
+}
+
+// Source is the source of the content of an object
+type Source struct {
+	Content   string `json:"content"`
+	MediaType string `json:"mediaType"`
+}
+
+// Object is a note or ticket
+type Object struct {
+	LDContext    interface{} `json:"@context,omitempty"`
+	ID           string      `json:"id"`
+	Type         string      `json:"type"`
+	AttributedTo string      `json:"attributedTo,omitempty"`
+	Context      string      `json:"context,omitempty"`
+	InReplyTo    string      `json:"inReplyTo,omitempty"`
+	Name         string      `json:"name,omitempty"`
+	Summary      string      `json:"summary,omitempty"`
+	Content      string      `json:"content,omitempty"`
+	MediaType    string      `json:"mediaType,omitempty"`
+	Source       *Source     `json:"source,omitempty"`
+	URL          string      `json:"url,omitempty"`
+	To           []string    `json:"to,omitempty"`
+	Cc           []string    `json:"cc,omitempty"`
+	Published    string      `json:"published,omitempty"`
+}
+
+// Activity is an activity which is delivered to an inbox
+type Activity struct {
+	LDContext interface{}     `json:"@context,omitempty"`
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Actor     string          `json:"actor"`
+	Object    json.RawMessage `json:"object"`
+	To        []string        `json:"to,omitempty"`
+	Cc        []string        `json:"cc,omitempty"`
+	Published string          `json:"published,omitempty"`
+}
+
+// NewActivity creates an activity whose object is either an IRI or an embedded object
+func NewActivity(id, typ, actor string, object interface{}) (*Activity, error) {
+	obj, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	return &Activity{
+		LDContext: DefaultLDContext(),
+		ID:        id,
+		Type:      typ,
+		Actor:     actor,
+		Object:    obj,
+	}, nil
+}
+
+// ObjectIRI returns the IRI of the object of the activity.
+// The object may either be the IRI itself or an embedded object with an id.
+func (a *Activity) ObjectIRI() string {
+	var iri string
+	if err := json.Unmarshal(a.Object, &iri); err == nil {
+		return iri
+	}
+	var obj struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(a.Object, &obj); err == nil {
+		return obj.ID
+	}
+	return ""
+}
+
+// ObjectActivity decodes the object of the activity as an embedded activity
+func (a *Activity) ObjectActivity() (*Activity, error) {
+	inner := &Activity{}
+	if err := json.Unmarshal(a.Object, inner); err != nil || inner.Type == "" {
+		return nil, ErrInvalidObject
+	}
+	return inner, nil
+}
+
+// ObjectObject decodes the object of the activity as an embedded object
+func (a *Activity) ObjectObject() (*Object, error) {
+	obj := &Object{}
+	if err := json.Unmarshal(a.Object, obj); err != nil || obj.Type == "" {
+		return nil, ErrInvalidObject
+	}
+	return obj, nil
+}
+
+// Validate checks that the activity has all required properties
+func (a *Activity) Validate() error {
+	if a.ID == "" || a.Type == "" || a.Actor == "" || len(a.Object) == 0 {
+		return ErrInvalidObject
+	}
+	return nil
+}
+
+// OrderedCollection is a collection like the outbox or the followers of an actor
+type OrderedCollection struct {
+	LDContext    interface{}   `json:"@context,omitempty"`
+	ID           string        `json:"id"`
+	Type         string        `json:"type"`
+	TotalItems   int64         `json:"totalItems"`
+	OrderedItems []interface{} `json:"orderedItems"`
+}
+
+// NewOrderedCollection creates a collection of the given items
+func NewOrderedCollection(id string, total int64, items []interface{}) *OrderedCollection {
+	if items == nil {
+		items = []interface{}{}
+	}
+	return &OrderedCollection{
+		LDContext:    DefaultLDContext(),
+		ID:           id,
+		Type:         TypeOrderedCollection,
+		TotalItems:   total,
+		OrderedItems: items,
+	}
+}
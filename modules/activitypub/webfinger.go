@@ -0,0 +1,22 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package activitypub
+
+// WebFingerContentType is the content type of WebFinger responses
+const WebFingerContentType = "application/jrd+json"
+
+// WebFingerJRD is the JSON resource descriptor returned by WebFinger (RFC 7033)
+type WebFingerJRD struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases,omitempty"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+// WebFingerLink is a link of a WebFinger resource
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href"`
+}
@@ -0,0 +1,228 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// The types of the audited targets
+const (
+	TargetUser             = "user"
+	TargetOrganization     = "organization"
+	TargetTeam             = "team"
+	TargetRepository       = "repository"
+	TargetProtectedBranch  = "protected_branch"
+	TargetDeployKey        = "deploy_key"
+	TargetAccessToken      = "access_token"
+	TargetUnknownPrincipal = "unknown"
+)
+
+// Record writes an audit event. remoteAddr is the address of the client, the port is dropped.
+// before and after are snapshots of the target and have to encode to JSON objects.
+// Errors are only logged as the audited operation has already happened.
+func Record(action admin.AuditAction, doer *models.User, remoteAddr string, target interface{}, before, after interface{}) {
+	if !setting.Audit.Enabled {
+		return
+	}
+
+	event := &admin.AuditEvent{
+		Action:    action,
+		IPAddress: stripPort(remoteAddr),
+	}
+	if doer != nil {
+		event.ActorID = doer.ID
+		event.ActorName = doer.Name
+	}
+	event.TargetType, event.TargetID, event.TargetName = describeTarget(target)
+
+	var err error
+	if event.Before, err = encodeSnapshot(before); err != nil {
+		log.Error("Unable to encode audit snapshot of %s: %v", action, err)
+	}
+	if event.After, err = encodeSnapshot(after); err != nil {
+		log.Error("Unable to encode audit snapshot of %s: %v", action, err)
+	}
+
+	if err := admin.InsertAuditEvent(db.DefaultContext, event); err != nil {
+		log.Error("Unable to write audit event %s by %s on %s %s: %v", action, event.ActorName, event.TargetType, event.TargetName, err)
+	}
+}
+
+func stripPort(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+func describeTarget(target interface{}) (typ string, id int64, name string) {
+	switch t := target.(type) {
+	case *models.User:
+		if t.IsOrganization() {
+			return TargetOrganization, t.ID, t.Name
+		}
+		return TargetUser, t.ID, t.Name
+	case *models.Organization:
+		return TargetOrganization, t.ID, t.Name
+	case *models.Team:
+		return TargetTeam, t.ID, t.Name
+	case *models.Repository:
+		return TargetRepository, t.ID, t.FullName()
+	case *models.ProtectedBranch:
+		return TargetProtectedBranch, t.ID, t.BranchName
+	case *models.DeployKey:
+		return TargetDeployKey, t.ID, t.Name
+	case *models.AccessToken:
+		return TargetAccessToken, t.ID, t.Name
+	case string:
+		// a principal without account, e.g. the login name of a failed sign in
+		return TargetUnknownPrincipal, 0, t
+	case nil:
+		return "", 0, ""
+	}
+	log.Error("Unknown audit target type %T", target)
+	return "", 0, ""
+}
+
+func encodeSnapshot(snapshot interface{}) (string, error) {
+	if snapshot == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// UserSnapshot returns the security relevant state of a user
+func UserSnapshot(u *models.User) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                      u.Name,
+		"email":                     u.Email,
+		"is_admin":                  u.IsAdmin,
+		"is_active":                 u.IsActive,
+		"is_restricted":             u.IsRestricted,
+		"prohibit_login":            u.ProhibitLogin,
+		"visibility":                u.Visibility.String(),
+		"login_type":                u.LoginType.String(),
+		"login_source":              u.LoginSource,
+		"login_name":                u.LoginName,
+		"max_repo_creation":         u.MaxRepoCreation,
+		"allow_create_organization": u.AllowCreateOrganization,
+		"allow_git_hook":            u.AllowGitHook,
+		"allow_import_local":        u.AllowImportLocal,
+	}
+}
+
+// RepoSnapshot returns the name and the visibility of a repository
+func RepoSnapshot(repo *models.Repository) map[string]interface{} {
+	return map[string]interface{}{
+		"owner":      repo.OwnerName,
+		"name":       repo.Name,
+		"is_private": repo.IsPrivate,
+	}
+}
+
+// OrgSnapshot returns the settings of an organization
+func OrgSnapshot(org *models.Organization) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                          org.Name,
+		"full_name":                     org.FullName,
+		"visibility":                    org.Visibility.String(),
+		"repo_admin_change_team_access": org.RepoAdminChangeTeamAccess,
+		"max_repo_creation":             org.MaxRepoCreation,
+	}
+}
+
+// TeamSnapshot returns the permissions of a team
+func TeamSnapshot(t *models.Team) map[string]interface{} {
+	units := make([]string, 0, len(t.Units))
+	for _, u := range t.Units {
+		units = append(units, u.Type.String())
+	}
+	return map[string]interface{}{
+		"org_id":                    t.OrgID,
+		"name":                      t.Name,
+		"description":               t.Description,
+		"permission":                t.Authorize.String(),
+		"includes_all_repositories": t.IncludesAllRepositories,
+		"can_create_org_repo":       t.CanCreateOrgRepo,
+		"units":                     units,
+	}
+}
+
+// TeamMemberSnapshot returns the membership of a user in a team
+func TeamMemberSnapshot(t *models.Team, userID int64) map[string]interface{} {
+	return map[string]interface{}{
+		"org_id":  t.OrgID,
+		"team":    t.Name,
+		"user_id": userID,
+	}
+}
+
+// TeamRepositorySnapshot returns the access of a team to a repository, repoID is 0 for all repositories
+func TeamRepositorySnapshot(t *models.Team, repoID int64) map[string]interface{} {
+	return map[string]interface{}{
+		"org_id":  t.OrgID,
+		"team":    t.Name,
+		"repo_id": repoID,
+	}
+}
+
+// ProtectedBranchSnapshot returns the settings of a branch protection rule
+func ProtectedBranchSnapshot(pb *models.ProtectedBranch) interface{} {
+	return struct {
+		RepoID int64 `json:"repo_id"`
+		*api.BranchProtection
+	}{
+		RepoID:           pb.RepoID,
+		BranchProtection: convert.ToBranchProtection(pb),
+	}
+}
+
+// DeployKeySnapshot returns the state of a deploy key
+func DeployKeySnapshot(key *models.DeployKey) map[string]interface{} {
+	return map[string]interface{}{
+		"repo_id":     key.RepoID,
+		"name":        key.Name,
+		"fingerprint": key.Fingerprint,
+		"read_only":   key.Mode == models.AccessModeRead,
+	}
+}
+
+// AccessTokenSnapshot returns the state of an access token without its secret
+func AccessTokenSnapshot(t *models.AccessToken) map[string]interface{} {
+	return map[string]interface{}{
+		"name":             t.Name,
+		"owner_id":         t.UID,
+		"token_last_eight": t.TokenLastEight,
+	}
+}
+
+// ExportJSONLines writes all audit events matching opts as one JSON object per line, oldest first
+func ExportJSONLines(ctx context.Context, w io.Writer, opts *admin.FindAuditEventsOptions) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	if err := admin.IterateAuditEvents(ctx, opts, func(event *admin.AuditEvent) error {
+		return enc.Encode(convert.ToAuditEvent(event))
+	}); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/json"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	doer := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+	org := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 3}).(*models.User)
+	team := unittest.AssertExistsAndLoadBean(t, &models.Team{ID: 1}).(*models.Team)
+
+	Record(admin.AuditTeamMemberAdd, doer, "192.168.1.2:4711", team, nil, TeamMemberSnapshot(team, 2))
+	event := unittest.AssertExistsAndLoadBean(t, &admin.AuditEvent{Action: admin.AuditTeamMemberAdd, IPAddress: "192.168.1.2"}).(*admin.AuditEvent)
+	assert.EqualValues(t, doer.ID, event.ActorID)
+	assert.Equal(t, doer.Name, event.ActorName)
+	assert.Equal(t, TargetTeam, event.TargetType)
+	assert.EqualValues(t, team.ID, event.TargetID)
+	assert.Empty(t, event.Before)
+	assert.JSONEq(t, `{"org_id":3,"team":"Owners","user_id":2}`, event.After)
+
+	Record(admin.AuditOrgDelete, doer, "[::1]:4711", org, nil, nil)
+	event = unittest.AssertExistsAndLoadBean(t, &admin.AuditEvent{Action: admin.AuditOrgDelete}).(*admin.AuditEvent)
+	assert.Equal(t, "::1", event.IPAddress)
+	assert.Equal(t, TargetOrganization, event.TargetType)
+
+	Record(admin.AuditUserSignInFailed, nil, "", "nobody", nil, map[string]interface{}{"reason": "unknown user"})
+	event = unittest.AssertExistsAndLoadBean(t, &admin.AuditEvent{Action: admin.AuditUserSignInFailed, TargetName: "nobody"}).(*admin.AuditEvent)
+	assert.EqualValues(t, 0, event.ActorID)
+	assert.Equal(t, TargetUnknownPrincipal, event.TargetType)
+}
+
+func TestExportJSONLines(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	doer := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+	Record(admin.AuditUserSignIn, doer, "127.0.0.1", doer, nil, nil)
+	Record(admin.AuditUserPasswordChange, doer, "127.0.0.1", doer, nil, nil)
+	Record(admin.AuditUserSignOut, doer, "127.0.0.1", doer, nil, nil)
+
+	var buf bytes.Buffer
+	assert.NoError(t, ExportJSONLines(db.DefaultContext, &buf, &admin.FindAuditEventsOptions{ActorName: doer.Name}))
+
+	var actions []string
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var event api.AuditEvent
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		actions = append(actions, event.Action)
+	}
+	// the two fixtures come first as the export is sorted oldest first
+	assert.Equal(t, []string{"user_sign_in", "team_member_add", "user_sign_in", "user_password_change", "user_sign_out"}, actions)
+}
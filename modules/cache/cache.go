@@ -5,10 +5,12 @@
 package cache
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/tracing"
 
 	mc "gitea.com/go-chi/cache"
 
@@ -46,11 +48,15 @@ func GetCache() mc.Cache {
 }
 
 // GetString returns the key value from cache with callback when no key exists in cache
-func GetString(key string, getFunc func() (string, error)) (string, error) {
+func GetString(ctx context.Context, key string, getFunc func() (string, error)) (string, error) {
 	if conn == nil || setting.CacheService.TTL == 0 {
 		return getFunc()
 	}
+	span := startSpan(ctx, key)
+	defer span.End()
+
 	if !conn.IsExist(key) {
+		span.SetAttribute("cache.hit", false)
 		var (
 			value string
 			err   error
@@ -74,11 +80,15 @@ func GetString(key string, getFunc func() (string, error)) (string, error) {
 }
 
 // GetInt returns key value from cache with callback when no key exists in cache
-func GetInt(key string, getFunc func() (int, error)) (int, error) {
+func GetInt(ctx context.Context, key string, getFunc func() (int, error)) (int, error) {
 	if conn == nil || setting.CacheService.TTL == 0 {
 		return getFunc()
 	}
+	span := startSpan(ctx, key)
+	defer span.End()
+
 	if !conn.IsExist(key) {
+		span.SetAttribute("cache.hit", false)
 		var (
 			value int
 			err   error
@@ -106,11 +116,15 @@ func GetInt(key string, getFunc func() (int, error)) (int, error) {
 }
 
 // GetInt64 returns key value from cache with callback when no key exists in cache
-func GetInt64(key string, getFunc func() (int64, error)) (int64, error) {
+func GetInt64(ctx context.Context, key string, getFunc func() (int64, error)) (int64, error) {
 	if conn == nil || setting.CacheService.TTL == 0 {
 		return getFunc()
 	}
+	span := startSpan(ctx, key)
+	defer span.End()
+
 	if !conn.IsExist(key) {
+		span.SetAttribute("cache.hit", false)
 		var (
 			value int64
 			err   error
@@ -137,6 +151,15 @@ func GetInt64(key string, getFunc func() (int64, error)) (int64, error) {
 	}
 }
 
+// startSpan starts the span of a cache lookup if the context is traced
+func startSpan(ctx context.Context, key string) *tracing.Span {
+	_, span := tracing.StartChild(ctx, "cache get", tracing.SpanKindClient)
+	span.SetAttribute("cache.adapter", setting.CacheService.Adapter)
+	span.SetAttribute("cache.key", key)
+	span.SetAttribute("cache.hit", true)
+	return span
+}
+
 // Remove key from cache
 func Remove(key string) {
 	if conn == nil {
@@ -5,11 +5,13 @@
 package cache
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/tracing"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -43,32 +45,32 @@ func TestGetCache(t *testing.T) {
 func TestGetString(t *testing.T) {
 	createTestCache()
 
-	data, err := GetString("key", func() (string, error) {
+	data, err := GetString(context.Background(), "key", func() (string, error) {
 		return "", fmt.Errorf("some error")
 	})
 	assert.Error(t, err)
 	assert.Equal(t, "", data)
 
-	data, err = GetString("key", func() (string, error) {
+	data, err = GetString(context.Background(), "key", func() (string, error) {
 		return "", nil
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, "", data)
 
-	// data, err = GetString("key", func() (string, error) {
+	// data, err = GetString(context.Background(), "key", func() (string, error) {
 	// 	return "some data", nil
 	// })
 	// assert.NoError(t, err)
 	// assert.Equal(t, "", data)
 	// Remove("key")
 
-	data, err = GetString("key", func() (string, error) {
+	data, err = GetString(context.Background(), "key", func() (string, error) {
 		return "some data", nil
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, "some data", data)
 
-	// data, err = GetString("key", func() (string, error) {
+	// data, err = GetString(context.Background(), "key", func() (string, error) {
 	// 	return "", fmt.Errorf("some error")
 	// })
 	// assert.NoError(t, err)
@@ -80,32 +82,32 @@ func TestGetString(t *testing.T) {
 func TestGetInt(t *testing.T) {
 	createTestCache()
 
-	data, err := GetInt("key", func() (int, error) {
+	data, err := GetInt(context.Background(), "key", func() (int, error) {
 		return 0, fmt.Errorf("some error")
 	})
 	assert.Error(t, err)
 	assert.Equal(t, 0, data)
 
-	data, err = GetInt("key", func() (int, error) {
+	data, err = GetInt(context.Background(), "key", func() (int, error) {
 		return 0, nil
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, 0, data)
 
-	// data, err = GetInt("key", func() (int, error) {
+	// data, err = GetInt(context.Background(), "key", func() (int, error) {
 	// 	return 100, nil
 	// })
 	// assert.NoError(t, err)
 	// assert.Equal(t, 0, data)
 	// Remove("key")
 
-	data, err = GetInt("key", func() (int, error) {
+	data, err = GetInt(context.Background(), "key", func() (int, error) {
 		return 100, nil
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, 100, data)
 
-	// data, err = GetInt("key", func() (int, error) {
+	// data, err = GetInt(context.Background(), "key", func() (int, error) {
 	// 	return 0, fmt.Errorf("some error")
 	// })
 	// assert.NoError(t, err)
@@ -116,32 +118,32 @@ func TestGetInt(t *testing.T) {
 func TestGetInt64(t *testing.T) {
 	createTestCache()
 
-	data, err := GetInt64("key", func() (int64, error) {
+	data, err := GetInt64(context.Background(), "key", func() (int64, error) {
 		return 0, fmt.Errorf("some error")
 	})
 	assert.Error(t, err)
 	assert.EqualValues(t, 0, data)
 
-	data, err = GetInt64("key", func() (int64, error) {
+	data, err = GetInt64(context.Background(), "key", func() (int64, error) {
 		return 0, nil
 	})
 	assert.NoError(t, err)
 	assert.EqualValues(t, 0, data)
 
-	// data, err = GetInt64("key", func() (int64, error) {
+	// data, err = GetInt64(context.Background(), "key", func() (int64, error) {
 	// 	return 100, nil
 	// })
 	// assert.NoError(t, err)
 	// assert.EqualValues(t, 0, data)
 	// Remove("key")
 
-	data, err = GetInt64("key", func() (int64, error) {
+	data, err = GetInt64(context.Background(), "key", func() (int64, error) {
 		return 100, nil
 	})
 	assert.NoError(t, err)
 	assert.EqualValues(t, 100, data)
 
-	// data, err = GetInt64("key", func() (int, error) {
+	// data, err = GetInt64(context.Background(), "key", func() (int, error) {
 	// 	return 0, fmt.Errorf("some error")
 	// })
 	// assert.NoError(t, err)
@@ -149,3 +151,35 @@ func TestGetInt64(t *testing.T) {
 
 	// TODO: uncommented code works in IDE but not with go test
 }
+
+func TestGetStringTracing(t *testing.T) {
+	createTestCache()
+	defer func(ttl time.Duration) {
+		setting.CacheService.TTL = ttl
+	}(setting.CacheService.TTL)
+	setting.CacheService.TTL = time.Minute
+
+	exporter := tracing.NewInMemoryExporter()
+	tracing.SetExporter(exporter, 1)
+	defer tracing.SetExporter(nil, 1)
+
+	ctx, root := tracing.Start(context.Background(), "request", tracing.SpanKindServer)
+	for i := 0; i < 2; i++ {
+		data, err := GetString(ctx, "tracing-key", func() (string, error) {
+			return "some data", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "some data", data)
+	}
+	root.End()
+	Remove("tracing-key")
+
+	spans := exporter.Spans()
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, "cache get", spans[0].Name)
+		assert.Equal(t, root.Context().SpanID, spans[0].ParentSpanID)
+		assert.Equal(t, "tracing-key", spans[0].Attributes["cache.key"])
+		assert.Equal(t, false, spans[0].Attributes["cache.hit"])
+		assert.Equal(t, true, spans[1].Attributes["cache.hit"])
+	}
+}
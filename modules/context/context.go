@@ -66,8 +66,10 @@ type Context struct {
 	IsSigned    bool
 	IsBasicAuth bool
 
-	Repo *Repository
-	Org  *Organization
+	Repo         *Repository
+	Org          *Organization
+	Package      *Package
+	ProjectOwner *ProjectOwner
 }
 
 // TrHTMLEscapeArgs runs Tr but pre-escapes all arguments with html.EscapeString.
@@ -0,0 +1,67 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+)
+
+// Package contains owner and access mode of the package owner in the current context
+type Package struct {
+	Owner      *models.User
+	AccessMode models.AccessMode
+}
+
+// PackageAssignment returns a middleware to handle Context.Package assignment
+func PackageAssignment() func(ctx *Context) {
+	return func(ctx *Context) {
+		packageAssignment(ctx, func(status int, title string, err error) {
+			if status == http.StatusNotFound {
+				ctx.NotFound(title, err)
+			} else {
+				ctx.ServerError(title, err)
+			}
+		})
+	}
+}
+
+// PackageAssignmentAPI returns a middleware to handle Context.Package assignment
+func PackageAssignmentAPI() func(ctx *APIContext) {
+	return func(ctx *APIContext) {
+		packageAssignment(ctx.Context, func(status int, title string, err error) {
+			ctx.Error(status, title, err)
+		})
+	}
+}
+
+func packageAssignment(ctx *Context, errCb func(int, string, error)) {
+	owner, err := models.GetUserByName(ctx.Params("username"))
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			errCb(http.StatusNotFound, "GetUserByName", err)
+		} else {
+			errCb(http.StatusInternalServerError, "GetUserByName", err)
+		}
+		return
+	}
+
+	accessMode, err := models.GetPackageAccessMode(owner, ctx.User)
+	if err != nil {
+		errCb(http.StatusInternalServerError, "GetPackageAccessMode", err)
+		return
+	}
+	if accessMode == models.AccessModeNone {
+		errCb(http.StatusNotFound, "GetPackageAccessMode", models.ErrUserNotExist{Name: owner.Name})
+		return
+	}
+
+	ctx.Package = &Package{
+		Owner:      owner,
+		AccessMode: accessMode,
+	}
+	ctx.Data["ContextUser"] = owner
+}
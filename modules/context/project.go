@@ -0,0 +1,95 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/unit"
+)
+
+// ProjectOwner contains the user or organization owning the projects and the access mode to
+// them in the current context
+type ProjectOwner struct {
+	Owner      *models.User
+	AccessMode models.AccessMode
+}
+
+// CanWrite returns true if the projects of the owner can be written in the current context
+func (p *ProjectOwner) CanWrite() bool {
+	return p.AccessMode >= models.AccessModeWrite
+}
+
+// ProjectOwnerAssignment returns a middleware to handle Context.ProjectOwner assignment
+func ProjectOwnerAssignment() func(ctx *Context) {
+	return func(ctx *Context) {
+		projectOwnerAssignment(ctx, func(status int, title string, err error) {
+			if status == http.StatusNotFound {
+				ctx.NotFound(title, err)
+			} else {
+				ctx.ServerError(title, err)
+			}
+		})
+	}
+}
+
+// ProjectOwnerAssignmentAPI returns a middleware to handle Context.ProjectOwner assignment
+func ProjectOwnerAssignmentAPI() func(ctx *APIContext) {
+	return func(ctx *APIContext) {
+		projectOwnerAssignment(ctx.Context, func(status int, title string, err error) {
+			ctx.Error(status, title, err)
+		})
+	}
+}
+
+func projectOwnerAssignment(ctx *Context, errCb func(int, string, error)) {
+	if unit.TypeProjects.UnitGlobalDisabled() {
+		errCb(http.StatusNotFound, "ProjectOwnerAssignment", nil)
+		return
+	}
+
+	// the owner is named by the username or org parameter, it is the signed in user otherwise
+	name := ctx.Params("username")
+	if name == "" {
+		name = ctx.Params("org")
+	}
+
+	var owner *models.User
+	if name != "" {
+		var err error
+		owner, err = models.GetUserByName(name)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				errCb(http.StatusNotFound, "GetUserByName", err)
+			} else {
+				errCb(http.StatusInternalServerError, "GetUserByName", err)
+			}
+			return
+		}
+	} else if ctx.User != nil {
+		owner = ctx.User
+	} else {
+		errCb(http.StatusNotFound, "ProjectOwnerAssignment", nil)
+		return
+	}
+
+	accessMode, err := models.GetProjectOwnerAccessMode(owner, ctx.User)
+	if err != nil {
+		errCb(http.StatusInternalServerError, "GetProjectOwnerAccessMode", err)
+		return
+	}
+	if accessMode == models.AccessModeNone {
+		errCb(http.StatusNotFound, "GetProjectOwnerAccessMode", models.ErrUserNotExist{Name: owner.Name})
+		return
+	}
+
+	ctx.ProjectOwner = &ProjectOwner{
+		Owner:      owner,
+		AccessMode: accessMode,
+	}
+	ctx.Data["ContextUser"] = owner
+	ctx.Data["CanWriteProjects"] = ctx.ProjectOwner.CanWrite()
+}
@@ -105,7 +105,7 @@ type CanCommitToBranchResults struct {
 // CanCommitToBranch returns true if repository is editable and user has proper access level
 //   and branch is not protected for push
 func (r *Repository) CanCommitToBranch(doer *models.User) (CanCommitToBranchResults, error) {
-	protectedBranch, err := models.GetProtectedBranchBy(r.Repository.ID, r.BranchName)
+	protectedBranch, err := models.GetFirstMatchProtectedBranchRule(r.Repository.ID, r.BranchName)
 
 	if err != nil {
 		return CanCommitToBranchResults{}, err
@@ -160,7 +160,7 @@ func (r *Repository) CanCreateIssueDependencies(user *models.User, isPull bool)
 }
 
 // GetCommitsCount returns cached commit count for current view
-func (r *Repository) GetCommitsCount() (int64, error) {
+func (r *Repository) GetCommitsCount(ctx context.Context) (int64, error) {
 	var contextName string
 	if r.IsViewBranch {
 		contextName = r.BranchName
@@ -169,16 +169,16 @@ func (r *Repository) GetCommitsCount() (int64, error) {
 	} else {
 		contextName = r.CommitID
 	}
-	return cache.GetInt64(r.Repository.GetCommitsCountCacheKey(contextName, r.IsViewBranch || r.IsViewTag), func() (int64, error) {
+	return cache.GetInt64(ctx, r.Repository.GetCommitsCountCacheKey(contextName, r.IsViewBranch || r.IsViewTag), func() (int64, error) {
 		return r.Commit.CommitsCount()
 	})
 }
 
 // GetCommitGraphsCount returns cached commit count for current view
-func (r *Repository) GetCommitGraphsCount(hidePRRefs bool, branches []string, files []string) (int64, error) {
+func (r *Repository) GetCommitGraphsCount(ctx context.Context, hidePRRefs bool, branches []string, files []string) (int64, error) {
 	cacheKey := fmt.Sprintf("commits-count-%d-graph-%t-%s-%s", r.Repository.ID, hidePRRefs, branches, files)
 
-	return cache.GetInt64(cacheKey, func() (int64, error) {
+	return cache.GetInt64(ctx, cacheKey, func() (int64, error) {
 		if len(branches) == 0 {
 			return git.AllCommitsCount(r.Repository.RepoPath(), hidePRRefs, files...)
 		}
@@ -414,7 +414,7 @@ func RepoAssignment(ctx *Context) (cancel context.CancelFunc) {
 	if ctx.IsSigned && ctx.User.LowerName == strings.ToLower(userName) {
 		owner = ctx.User
 	} else {
-		owner, err = models.GetUserByName(userName)
+		owner, err = models.GetUserByNameCtx(ctx, userName)
 		if err != nil {
 			if models.IsErrUserNotExist(err) {
 				if ctx.FormString("go-get") == "1" {
@@ -432,7 +432,7 @@ func RepoAssignment(ctx *Context) (cancel context.CancelFunc) {
 	ctx.Data["Username"] = ctx.Repo.Owner.Name
 
 	// Get repository.
-	repo, err := models.GetRepositoryByName(owner.ID, repoName)
+	repo, err := models.GetRepositoryByNameCtx(ctx, owner.ID, repoName)
 	if err != nil {
 		if models.IsErrRepoNotExist(err) {
 			redirectRepoID, err := models.LookupRepoRedirect(owner.ID, repoName)
@@ -533,7 +533,7 @@ func RepoAssignment(ctx *Context) (cancel context.CancelFunc) {
 		return
 	}
 
-	gitRepo, err := git.OpenRepository(models.RepoPath(userName, repoName))
+	gitRepo, err := git.OpenRepositoryCtx(ctx, models.RepoPath(userName, repoName))
 	if err != nil {
 		if strings.Contains(err.Error(), "repository does not exist") || strings.Contains(err.Error(), "no such file or directory") {
 			log.Error("Repository %-v has a broken repository on the file system: %s Error: %v", ctx.Repo.Repository, ctx.Repo.Repository.RepoPath(), err)
@@ -791,7 +791,7 @@ func RepoRefByType(refType RepoRefType, ignoreNotExistErr ...bool) func(*Context
 
 		if ctx.Repo.GitRepo == nil {
 			repoPath := models.RepoPath(ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
-			ctx.Repo.GitRepo, err = git.OpenRepository(repoPath)
+			ctx.Repo.GitRepo, err = git.OpenRepositoryCtx(ctx, repoPath)
 			if err != nil {
 				ctx.ServerError("RepoRef Invalid repo "+repoPath, err)
 				return
@@ -909,7 +909,7 @@ func RepoRefByType(refType RepoRefType, ignoreNotExistErr ...bool) func(*Context
 		ctx.Data["IsViewCommit"] = ctx.Repo.IsViewCommit
 		ctx.Data["CanCreateBranch"] = ctx.Repo.CanCreateBranch()
 
-		ctx.Repo.CommitsCount, err = ctx.Repo.GetCommitsCount()
+		ctx.Repo.CommitsCount, err = ctx.Repo.GetCommitsCount(ctx)
 		if err != nil {
 			ctx.ServerError("GetCommitsCount", err)
 			return
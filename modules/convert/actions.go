@@ -0,0 +1,70 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	actions_model "code.gitea.io/gitea/models/actions"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+func optionalTime(t timeutil.TimeStamp) *time.Time {
+	if t == 0 {
+		return nil
+	}
+	at := t.AsTime()
+	return &at
+}
+
+// ToActionRun converts an actions_model.ActionRun to an api.ActionRun
+func ToActionRun(repo *models.Repository, run *actions_model.ActionRun, jobs []*actions_model.ActionRunJob) *api.ActionRun {
+	apiRun := &api.ActionRun{
+		ID:         run.ID,
+		WorkflowID: run.WorkflowID,
+		Name:       run.Name,
+		Title:      run.Title,
+		Event:      run.Event,
+		Ref:        run.Ref,
+		CommitSHA:  run.CommitSHA,
+		Status:     run.Status.String(),
+		Started:    optionalTime(run.StartedUnix),
+		Stopped:    optionalTime(run.StoppedUnix),
+		Created:    run.CreatedUnix.AsTime(),
+	}
+
+	if run.TriggerUserID != 0 {
+		trigger, err := models.GetUserByID(run.TriggerUserID)
+		if err != nil {
+			trigger = models.NewGhostUser()
+		}
+		apiRun.TriggerUser = ToUser(trigger, nil)
+	}
+
+	for _, job := range jobs {
+		apiRun.Jobs = append(apiRun.Jobs, ToActionRunJob(repo, job))
+	}
+
+	return apiRun
+}
+
+// ToActionRunJob converts an actions_model.ActionRunJob to an api.ActionRunJob
+func ToActionRunJob(repo *models.Repository, job *actions_model.ActionRunJob) *api.ActionRunJob {
+	return &api.ActionRunJob{
+		ID:        job.ID,
+		JobID:     job.JobID,
+		Name:      job.Name,
+		Needs:     job.Needs,
+		RunsOn:    job.RunsOn,
+		Status:    job.Status.String(),
+		LogLength: job.LogLength,
+		LogURL:    fmt.Sprintf("%s/actions/jobs/%d/logs", repo.APIURL(), job.ID),
+		Started:   optionalTime(job.StartedUnix),
+		Stopped:   optionalTime(job.StoppedUnix),
+	}
+}
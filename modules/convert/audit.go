@@ -0,0 +1,41 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToAuditEvent converts an admin.AuditEvent to an api.AuditEvent
+func ToAuditEvent(event *admin.AuditEvent) *api.AuditEvent {
+	return &api.AuditEvent{
+		ID:         event.ID,
+		Action:     string(event.Action),
+		ActorID:    event.ActorID,
+		ActorName:  event.ActorName,
+		IPAddress:  event.IPAddress,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		TargetName: event.TargetName,
+		Before:     toAuditSnapshot(event.ID, event.Before),
+		After:      toAuditSnapshot(event.ID, event.After),
+		Created:    event.CreatedUnix.AsTime(),
+	}
+}
+
+func toAuditSnapshot(id int64, data string) map[string]interface{} {
+	if data == "" {
+		return nil
+	}
+	snapshot := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+		log.Error("Unable to unmarshal snapshot of audit event %d: %v", id, err)
+		return nil
+	}
+	return snapshot
+}
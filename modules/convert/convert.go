@@ -110,6 +110,7 @@ func ToBranchProtection(bp *models.ProtectedBranch) *api.BranchProtection {
 
 	return &api.BranchProtection{
 		BranchName:                    bp.BranchName,
+		Priority:                      bp.Priority,
 		EnablePush:                    bp.CanPush,
 		EnablePushWhitelist:           bp.EnableWhitelist,
 		PushWhitelistUsernames:        pushWhitelistUsernames,
@@ -126,7 +127,9 @@ func ToBranchProtection(bp *models.ProtectedBranch) *api.BranchProtection {
 		ApprovalsWhitelistTeams:       approvalsWhitelistTeams,
 		BlockOnRejectedReviews:        bp.BlockOnRejectedReviews,
 		BlockOnOfficialReviewRequests: bp.BlockOnOfficialReviewRequests,
+		RequireCodeOwnerReviews:       bp.RequireCodeOwnerReviews,
 		BlockOnOutdatedBranch:         bp.BlockOnOutdatedBranch,
+		EnableMergeQueue:              bp.EnableMergeQueue,
 		DismissStaleApprovals:         bp.DismissStaleApprovals,
 		RequireSignedCommits:          bp.RequireSignedCommits,
 		ProtectedFilePatterns:         bp.ProtectedFilePatterns,
@@ -242,17 +245,45 @@ func ToHook(repoLink string, w *webhook.Webhook) *api.Hook {
 	}
 
 	return &api.Hook{
-		ID:      w.ID,
-		Type:    string(w.Type),
-		URL:     fmt.Sprintf("%s/settings/hooks/%d", repoLink, w.ID),
-		Active:  w.IsActive,
-		Config:  config,
-		Events:  w.EventsArray(),
-		Updated: w.UpdatedUnix.AsTime(),
-		Created: w.CreatedUnix.AsTime(),
+		ID:          w.ID,
+		Type:        string(w.Type),
+		URL:         fmt.Sprintf("%s/settings/hooks/%d", repoLink, w.ID),
+		Active:      w.IsActive,
+		Config:      config,
+		Events:      w.EventsArray(),
+		MaxAttempts: w.MaxAttempts,
+		Updated:     w.UpdatedUnix.AsTime(),
+		Created:     w.CreatedUnix.AsTime(),
 	}
 }
 
+// ToHookDelivery convert webhook.HookTask to api.HookDelivery
+func ToHookDelivery(t *webhook.HookTask) *api.HookDelivery {
+	delivery := &api.HookDelivery{
+		ID:        t.ID,
+		UUID:      t.UUID,
+		Event:     string(t.EventType),
+		Status:    string(t.Status()),
+		Attempts:  make([]*api.HookDeliveryAttempt, len(t.AttemptInfos)),
+		Delivered: time.Unix(0, t.Delivered),
+	}
+	if t.ResponseInfo != nil {
+		delivery.StatusCode = t.ResponseInfo.Status
+	}
+	for i, attempt := range t.AttemptInfos {
+		delivery.Attempts[i] = &api.HookDeliveryAttempt{
+			Delivered:  time.Unix(0, attempt.Delivered),
+			StatusCode: attempt.Status,
+			Error:      attempt.Error,
+		}
+	}
+	if !t.IsDelivered && t.NextAttemptUnix > 0 {
+		next := t.NextAttemptUnix.AsTime()
+		delivery.NextAttempt = &next
+	}
+	return delivery
+}
+
 // ToGitHook convert git.Hook to api.GitHook
 func ToGitHook(h *git.Hook) *api.GitHook {
 	return &api.GitHook{
@@ -0,0 +1,21 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	federation_model "code.gitea.io/gitea/models/federation"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToFederatedActor converts a remote actor to an api.FederatedActor
+func ToFederatedActor(actor *federation_model.Actor) *api.FederatedActor {
+	return &api.FederatedActor{
+		IRI:    actor.IRI,
+		Type:   actor.Type,
+		Handle: actor.Handle(),
+		Name:   actor.Name,
+		URL:    actor.URL,
+	}
+}
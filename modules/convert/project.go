@@ -0,0 +1,78 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToProject converts a Project to API format
+func ToProject(p *models.Project, doer *models.User) *api.Project {
+	apiProject := &api.Project{
+		ID:           p.ID,
+		URL:          p.APIURL(),
+		HTMLURL:      p.HTMLURL(),
+		Title:        p.Title,
+		Description:  p.Description,
+		State:        api.StateOpen,
+		OpenIssues:   p.NumOpenIssues(),
+		ClosedIssues: p.NumClosedIssues(),
+		Created:      p.CreatedUnix.AsTime(),
+		Updated:      p.UpdatedUnix.AsTime(),
+	}
+	if p.IsClosed {
+		apiProject.State = api.StateClosed
+		apiProject.Closed = p.ClosedDateUnix.AsTimePtr()
+	}
+
+	switch p.Type {
+	case models.ProjectTypeRepository:
+		apiProject.Type = "repository"
+		if err := p.LoadRepo(); err != nil {
+			log.Error("LoadRepo: %v", err)
+			return apiProject
+		}
+		apiProject.Repo = &api.RepositoryMeta{
+			ID:       p.Repo.ID,
+			Name:     p.Repo.Name,
+			Owner:    p.Repo.OwnerName,
+			FullName: p.Repo.FullName(),
+		}
+	default:
+		apiProject.Type = "individual"
+		if p.Type == models.ProjectTypeOrganization {
+			apiProject.Type = "organization"
+		}
+		if err := p.LoadOwner(); err != nil {
+			log.Error("LoadOwner: %v", err)
+			return apiProject
+		}
+		apiProject.Owner = ToUser(p.Owner, doer)
+	}
+	return apiProject
+}
+
+// ToProjectBoard converts a ProjectBoard to API format
+func ToProjectBoard(b *models.ProjectBoard) *api.ProjectBoard {
+	return &api.ProjectBoard{
+		ID:      b.ID,
+		Title:   b.Title,
+		Color:   b.Color,
+		Default: b.Default,
+		Sorting: int(b.Sorting),
+		Created: b.CreatedUnix.AsTime(),
+		Updated: b.UpdatedUnix.AsTime(),
+	}
+}
+
+// ToProjectCard converts an issue of a project to a project card in API format
+func ToProjectCard(issue *models.Issue) *api.ProjectCard {
+	return &api.ProjectCard{
+		BoardID: issue.ProjectBoardID(),
+		Issue:   ToAPIIssue(issue),
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/json"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/translation"
+)
+
+// ToTaskMessage returns the message of a task translated to the locale
+func ToTaskMessage(task *models.Task, locale translation.Locale) string {
+	if task.Message == "" || task.Message[0] != '{' {
+		return task.Message
+	}
+
+	// assume message is actually a translatable string
+	var translatableMessage models.TranslatableMessage
+	if err := json.Unmarshal([]byte(task.Message), &translatableMessage); err != nil {
+		translatableMessage = models.TranslatableMessage{
+			Format: "migrate.migrating_failed.error",
+			Args:   []interface{}{task.Message},
+		}
+	}
+	return locale.Tr(translatableMessage.Format, translatableMessage.Args...)
+}
+
+// ToRepoTask converts a repository export or import task to an api.RepoTask
+func ToRepoTask(task *models.Task, locale translation.Locale) *api.RepoTask {
+	apiTask := &api.RepoTask{
+		ID:      task.ID,
+		RepoID:  task.RepoID,
+		Status:  task.Status.String(),
+		Message: ToTaskMessage(task, locale),
+		Created: task.Created.AsTime(),
+	}
+	switch task.Type {
+	case api.TaskTypeExportRepo:
+		apiTask.Type = "export"
+	case api.TaskTypeImportRepo:
+		apiTask.Type = "import"
+	}
+	if !task.StartTime.IsZero() {
+		started := task.StartTime.AsTime()
+		apiTask.Started = &started
+	}
+	if !task.EndTime.IsZero() {
+		ended := task.EndTime.AsTime()
+		apiTask.Ended = &ended
+	}
+	return apiTask
+}
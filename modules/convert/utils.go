@@ -37,6 +37,14 @@ func ToGitServiceType(value string) structs.GitServiceType {
 		return structs.OneDevService
 	case "gitbucket":
 		return structs.GitBucketService
+	case "bitbucket":
+		return structs.BitbucketService
+	case "bitbucketserver":
+		return structs.BitbucketServerService
+	case "azuredevops":
+		return structs.AzureDevOpsService
+	case "gerrit":
+		return structs.GerritService
 	default:
 		return structs.PlainGitService
 	}
@@ -17,6 +17,7 @@ import (
 
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/process"
+	"code.gitea.io/gitea/modules/tracing"
 )
 
 var (
@@ -132,7 +133,17 @@ type RunContext struct {
 }
 
 // RunWithContext run the command with context
-func (c *Command) RunWithContext(rc *RunContext) error {
+func (c *Command) RunWithContext(rc *RunContext) (err error) {
+	start := time.Now()
+	_, span := tracing.StartChild(c.parentContext, "git "+c.subcommand(), tracing.SpanKindClient)
+	// the arguments are not recorded as they may contain credentials, e.g. in the remote URLs
+	span.SetAttribute("git.dir", rc.Dir)
+	defer func() {
+		observeCommand(c.subcommand(), start, err)
+		span.RecordError(err)
+		span.End()
+	}()
+
 	if rc.Timeout == -1 {
 		rc.Timeout = defaultCommandExecutionTimeout
 	}
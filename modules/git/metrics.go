@@ -0,0 +1,60 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	commandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "gitea",
+		Subsystem: "git",
+		Name:      "command_duration_seconds",
+		Help:      "Duration of the git subprocesses by git subcommand",
+		Buckets:   []float64{.005, .01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+	}, []string{"command"})
+
+	commandFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gitea",
+		Subsystem: "git",
+		Name:      "command_failures_total",
+		Help:      "Number of failed git subprocesses by git subcommand and reason, error or timeout",
+	}, []string{"command", "reason"})
+)
+
+func init() {
+	prometheus.MustRegister(commandDuration, commandFailures)
+}
+
+// subcommand returns the git subcommand run by the command, skipping the global options
+func (c *Command) subcommand() string {
+	for i := 0; i < len(c.args); i++ {
+		switch arg := c.args[i]; {
+		case arg == "-c" || arg == "-C":
+			// the option has a value
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			return arg
+		}
+	}
+	return "unknown"
+}
+
+// observeCommand records the duration and the result of a git subprocess
+func observeCommand(command string, start time.Time, err error) {
+	commandDuration.WithLabelValues(command).Observe(time.Since(start).Seconds())
+	switch {
+	case err == context.DeadlineExceeded:
+		commandFailures.WithLabelValues(command, "timeout").Inc()
+	case err != nil:
+		commandFailures.WithLabelValues(command, "error").Inc()
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommand_subcommand(t *testing.T) {
+	assert.Equal(t, "log", NewCommandNoGlobals("log", "-1").subcommand())
+	assert.Equal(t, "cat-file", NewCommandNoGlobals("-c", "core.quotepath=false", "-C", "/tmp", "cat-file", "--batch").subcommand())
+	assert.Equal(t, "unknown", NewCommandNoGlobals("--version").subcommand())
+}
+
+func TestObserveCommand(t *testing.T) {
+	failures := func(reason string) float64 {
+		families, err := prometheus.DefaultGatherer.Gather()
+		assert.NoError(t, err)
+		for _, family := range families {
+			if family.GetName() != "gitea_git_command_failures_total" {
+				continue
+			}
+			for _, m := range family.GetMetric() {
+				labels := map[string]string{}
+				for _, label := range m.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				if labels["command"] == "test-observe" && labels["reason"] == reason {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+		return 0
+	}
+
+	observeCommand("test-observe", time.Now(), nil)
+	observeCommand("test-observe", time.Now(), errors.New("exit status 1"))
+	observeCommand("test-observe", time.Now(), context.DeadlineExceeded)
+	observeCommand("test-observe", time.Now(), context.DeadlineExceeded)
+
+	assert.EqualValues(t, 1, failures("error"))
+	assert.EqualValues(t, 2, failures("timeout"))
+}
@@ -202,12 +202,13 @@ func Pull(repoPath string, opts PullRemoteOptions) error {
 
 // PushOptions options when push to remote
 type PushOptions struct {
-	Remote  string
-	Branch  string
-	Force   bool
-	Mirror  bool
-	Env     []string
-	Timeout time.Duration
+	Remote   string
+	Branch   string
+	Refspecs []string
+	Force    bool
+	Mirror   bool
+	Env      []string
+	Timeout  time.Duration
 }
 
 // Push pushs local commits to given remote branch.
@@ -223,6 +224,7 @@ func Push(repoPath string, opts PushOptions) error {
 	if len(opts.Branch) > 0 {
 		cmd.AddArguments(opts.Branch)
 	}
+	cmd.AddArguments(opts.Refspecs...)
 	var outbuf, errbuf strings.Builder
 
 	if opts.Timeout == 0 {
@@ -9,6 +9,7 @@
 package git
 
 import (
+	"context"
 	"errors"
 	"path/filepath"
 
@@ -24,6 +25,8 @@ import (
 // Repository represents a Git repository.
 type Repository struct {
 	Path string
+	// the context the repository is used in, e.g. a request, traced if it has a span
+	Ctx context.Context
 
 	tagCache *ObjectCache
 
@@ -34,6 +37,11 @@ type Repository struct {
 
 // OpenRepository opens the repository at the given path.
 func OpenRepository(repoPath string) (*Repository, error) {
+	return OpenRepositoryCtx(DefaultContext, repoPath)
+}
+
+// OpenRepositoryCtx opens the repository at the given path within the context.
+func OpenRepositoryCtx(ctx context.Context, repoPath string) (*Repository, error) {
 	repoPath, err := filepath.Abs(repoPath)
 	if err != nil {
 		return nil, err
@@ -57,6 +65,7 @@ func OpenRepository(repoPath string) (*Repository, error) {
 
 	return &Repository{
 		Path:         repoPath,
+		Ctx:          ctx,
 		gogitRepo:    gogitRepo,
 		gogitStorage: storage,
 		tagCache:     newObjectCache(),
@@ -15,11 +15,14 @@ import (
 	"path/filepath"
 
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/tracing"
 )
 
 // Repository represents a Git repository.
 type Repository struct {
 	Path string
+	// the context the repository is used in, e.g. a request, traced if it has a span
+	Ctx context.Context
 
 	tagCache *ObjectCache
 
@@ -36,6 +39,11 @@ type Repository struct {
 
 // OpenRepository opens the repository at the given path.
 func OpenRepository(repoPath string) (*Repository, error) {
+	return OpenRepositoryCtx(DefaultContext, repoPath)
+}
+
+// OpenRepositoryCtx opens the repository at the given path within the context.
+func OpenRepositoryCtx(ctx context.Context, repoPath string) (*Repository, error) {
 	repoPath, err := filepath.Abs(repoPath)
 	if err != nil {
 		return nil, err
@@ -45,6 +53,7 @@ func OpenRepository(repoPath string) (*Repository, error) {
 
 	repo := &Repository{
 		Path:     repoPath,
+		Ctx:      ctx,
 		tagCache: newObjectCache(),
 	}
 
@@ -56,20 +65,38 @@ func OpenRepository(repoPath string) (*Repository, error) {
 
 // CatFileBatch obtains a CatFileBatch for this repository
 func (repo *Repository) CatFileBatch() (WriteCloserError, *bufio.Reader, func()) {
+	span := repo.startCatFileSpan("git cat-file --batch")
 	if repo.batchCancel == nil || repo.batchReader.Buffered() > 0 {
 		log.Debug("Opening temporary cat file batch for: %s", repo.Path)
-		return CatFileBatch(repo.Path)
+		wr, rd, cancel := CatFileBatch(repo.Path)
+		return wr, rd, func() {
+			cancel()
+			span.End()
+		}
 	}
-	return repo.batchWriter, repo.batchReader, func() {}
+	return repo.batchWriter, repo.batchReader, span.End
 }
 
 // CatFileBatchCheck obtains a CatFileBatchCheck for this repository
 func (repo *Repository) CatFileBatchCheck() (WriteCloserError, *bufio.Reader, func()) {
+	span := repo.startCatFileSpan("git cat-file --batch-check")
 	if repo.checkCancel == nil || repo.checkReader.Buffered() > 0 {
 		log.Debug("Opening temporary cat file batch-check: %s", repo.Path)
-		return CatFileBatchCheck(repo.Path)
+		wr, rd, cancel := CatFileBatchCheck(repo.Path)
+		return wr, rd, func() {
+			cancel()
+			span.End()
+		}
 	}
-	return repo.checkWriter, repo.checkReader, func() {}
+	return repo.checkWriter, repo.checkReader, span.End
+}
+
+// startCatFileSpan starts a span covering the use of a cat-file batch, which is ended by its
+// cancel function, if the repository is used in a traced context
+func (repo *Repository) startCatFileSpan(name string) *tracing.Span {
+	_, span := tracing.StartChild(repo.Ctx, name, tracing.SpanKindClient)
+	span.SetAttribute("git.dir", repo.Path)
+	return span
 }
 
 // Close this repository, in particular close the underlying gogitStorage if this is not nil
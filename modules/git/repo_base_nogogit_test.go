@@ -0,0 +1,43 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !gogit
+// +build !gogit
+
+package git
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/gitea/modules/tracing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepositoryCatFileTracing(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	tracing.SetExporter(exporter, 1)
+	defer tracing.SetExporter(nil, 1)
+
+	ctx, root := tracing.Start(context.Background(), "request", tracing.SpanKindServer)
+	repo, err := OpenRepositoryCtx(ctx, filepath.Join(testReposDir, "repo1_bare"))
+	assert.NoError(t, err)
+	defer repo.Close()
+
+	_, err = repo.GetCommit("master")
+	assert.NoError(t, err)
+	root.End()
+
+	spans := exporter.Spans()
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name)
+		if span.SpanID != root.Context().SpanID {
+			assert.Equal(t, root.Context().SpanID, span.ParentSpanID, span.Name)
+		}
+	}
+	assert.Contains(t, names, "git cat-file --batch")
+}
@@ -157,6 +157,24 @@ func (repo *Repository) GetDiffNumChangedFiles(base, head string, directComparis
 	return w.numLines, nil
 }
 
+// GetFilesChangedBetween returns the paths of the files changed on head since its merge base with base
+func (repo *Repository) GetFilesChangedBetween(base, head string) ([]string, error) {
+	stdout, err := NewCommand("diff", "-z", "--name-only", base+"..."+head).RunInDir(repo.Path)
+	if err != nil && strings.Contains(err.Error(), "no merge base") {
+		// git >= 2.28 now returns an error if base and head have become unrelated.
+		// previously it would return the results of git diff -z --name-only base head so let's try that...
+		stdout, err = NewCommand("diff", "-z", "--name-only", base, head).RunInDir(repo.Path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	files := strings.Split(stdout, "\x00")
+	if len(files) > 0 && files[len(files)-1] == "" {
+		files = files[:len(files)-1]
+	}
+	return files, nil
+}
+
 // GetDiffShortStat counts number of changed files, number of additions and deletions
 func (repo *Repository) GetDiffShortStat(base, head string) (numFiles, totalAdditions, totalDeletions int, err error) {
 	numFiles, totalAdditions, totalDeletions, err = GetDiffShortStat(repo.Path, base+"..."+head)
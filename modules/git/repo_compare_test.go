@@ -32,3 +32,14 @@ func TestGetFormatPatch(t *testing.T) {
 	assert.Regexp(t, "^From 8d92fc95", patch)
 	assert.Contains(t, patch, "Subject: [PATCH] Add file2.txt")
 }
+
+func TestGetFilesChangedBetween(t *testing.T) {
+	bareRepo1Path := filepath.Join(testReposDir, "repo1_bare")
+	repo, err := OpenRepository(bareRepo1Path)
+	assert.NoError(t, err)
+	defer repo.Close()
+
+	files, err := repo.GetFilesChangedBetween("8d92fc95^", "8d92fc95")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"file2.txt"}, files)
+}
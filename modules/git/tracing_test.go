@@ -0,0 +1,44 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/gitea/modules/tracing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandTracing(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	tracing.SetExporter(exporter, 1)
+	defer tracing.SetExporter(nil, 1)
+
+	bareRepo1Path := filepath.Join(testReposDir, "repo1_bare")
+
+	// the commands run outside of a trace are not traced
+	_, err := NewCommand("rev-parse", "HEAD").RunInDir(bareRepo1Path)
+	assert.NoError(t, err)
+	assert.Empty(t, exporter.Spans())
+
+	ctx, root := tracing.Start(context.Background(), "request", tracing.SpanKindServer)
+	_, err = NewCommandContext(ctx, "rev-parse", "HEAD").RunInDir(bareRepo1Path)
+	assert.NoError(t, err)
+	_, err = NewCommandContext(ctx, "rev-parse", "no-such-ref").RunInDir(bareRepo1Path)
+	assert.Error(t, err)
+	root.End()
+
+	spans := exporter.Spans()
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, "git rev-parse", spans[0].Name)
+		assert.Equal(t, root.Context().SpanID, spans[0].ParentSpanID)
+		assert.Equal(t, bareRepo1Path, spans[0].Attributes["git.dir"])
+		assert.Empty(t, spans[0].Error)
+		assert.NotEmpty(t, spans[1].Error)
+	}
+}
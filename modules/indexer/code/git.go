@@ -25,6 +25,7 @@ type fileUpdate struct {
 type repoChanges struct {
 	Updates          []fileUpdate
 	RemovedFilenames []string
+	Genesis          bool // all the files of the repo are updated
 }
 
 func getDefaultBranchSha(repo *models.Repository) (string, error) {
@@ -90,7 +91,7 @@ func parseGitLsTreeOutput(stdout []byte) ([]fileUpdate, error) {
 
 // genesisChanges get changes to add repo to the indexer for the first time
 func genesisChanges(repo *models.Repository, revision string) (*repoChanges, error) {
-	var changes repoChanges
+	changes := repoChanges{Genesis: true}
 	stdout, err := git.NewCommand("ls-tree", "--full-tree", "-l", "-r", revision).
 		RunInDirBytes(repo.RepoPath())
 	if err != nil {
@@ -106,6 +106,12 @@ func index(indexer Indexer, repoID int64) error {
 		return err
 	}
 
+	if setting.Indexer.ExtractSymbols {
+		if err := indexSymbols(repo, sha, changes); err != nil {
+			return err
+		}
+	}
+
 	return repo.UpdateIndexerStatus(models.RepoIndexerTypeCode, sha)
 }
 
@@ -134,7 +140,7 @@ func Init() {
 
 	// Create the Queue
 	switch setting.Indexer.RepoType {
-	case "bleve", "elasticsearch":
+	case "bleve", "elasticsearch", "trigram":
 		handler := func(data ...queue.Data) {
 			idx, err := indexer.get()
 			if idx == nil || err != nil {
@@ -207,6 +213,23 @@ func Init() {
 				close(waitChannel)
 				log.Fatal("PID: %d Unable to initialize the elasticsearch Repository Indexer connstr: %s Error: %v", os.Getpid(), setting.Indexer.RepoConnStr, err)
 			}
+		case "trigram":
+			log.Info("PID: %d Initializing Repository Indexer at: %s", os.Getpid(), setting.Indexer.RepoPath)
+			defer func() {
+				if err := recover(); err != nil {
+					log.Error("PANIC whilst initializing repository indexer: %v\nStacktrace: %s", err, log.Stack(2))
+					log.Error("The indexer files are likely corrupted and may need to be deleted")
+					log.Error("You can completely remove the \"%s\" directory to make Gitea recreate the indexes", setting.Indexer.RepoPath)
+				}
+			}()
+
+			rIndexer, populate, err = NewTrigramIndexer(setting.Indexer.RepoPath)
+			if err != nil {
+				cancel()
+				indexer.Close()
+				close(waitChannel)
+				log.Fatal("PID: %d Unable to initialize the trigram Repository Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.RepoPath, err)
+			}
 		default:
 			log.Fatal("PID: %d Unknown Indexer type: %s", os.Getpid(), setting.Indexer.RepoType)
 		}
@@ -0,0 +1,127 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/analyze"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/symbol"
+	"code.gitea.io/gitea/modules/typesniffer"
+)
+
+// indexSymbols extracts the symbol definitions of the changed files of a repository
+func indexSymbols(repo *models.Repository, sha string, changes *repoChanges) error {
+	if changes.Genesis {
+		if err := models.DeleteRepoSymbols(repo.ID); err != nil {
+			return err
+		}
+	}
+
+	// files without symbols are kept with an empty list, so their previous symbols are removed
+	symbols := make(map[string][]*models.RepoSymbol, len(changes.Updates))
+	if len(changes.Updates) > 0 {
+		batchWriter, batchReader, cancel := git.CatFileBatch(repo.RepoPath())
+		defer cancel()
+
+		for _, update := range changes.Updates {
+			symbols[update.Filename] = nil
+
+			language := analyze.GetCodeLanguage(update.Filename, nil)
+			if (language != "" && !symbol.IsSupported(language)) ||
+				(setting.Indexer.ExcludeVendored && analyze.IsVendor(update.Filename)) ||
+				(update.Sized && update.Size > setting.Indexer.MaxIndexerFileSize) {
+				continue
+			}
+
+			if _, err := batchWriter.Write([]byte(update.BlobSha + "\n")); err != nil {
+				return err
+			}
+			_, _, size, err := git.ReadBatchLine(batchReader)
+			if err != nil {
+				return err
+			}
+			content, err := io.ReadAll(io.LimitReader(batchReader, size))
+			if err != nil {
+				return err
+			}
+			if _, err = batchReader.Discard(1); err != nil {
+				return err
+			}
+			if size > setting.Indexer.MaxIndexerFileSize || !typesniffer.DetectContentType(content).IsText() {
+				continue
+			}
+
+			if language == "" {
+				language = analyze.GetCodeLanguage(update.Filename, content)
+			}
+			for _, s := range symbol.Extract(language, content) {
+				symbols[update.Filename] = append(symbols[update.Filename], &models.RepoSymbol{
+					Name:     s.Name,
+					Kind:     string(s.Kind),
+					Language: language,
+					Line:     s.Line,
+					CommitID: sha,
+				})
+			}
+		}
+	}
+
+	return models.UpdateRepoSymbols(repo.ID, changes.RemovedFilenames, symbols)
+}
+
+// Reference represents an occurrence of a symbol in a file
+type Reference struct {
+	RepoID   int64
+	Filename string
+	CommitID string
+	Line     int
+	Content  string
+}
+
+// FindReferences returns the occurrences of a symbol as a whole word in the files of the
+// repositories. It returns at most limit references.
+func FindReferences(repoIDs []int64, name string, limit int) ([]*Reference, error) {
+	if len(name) == 0 {
+		return nil, nil
+	}
+	_, results, _, err := indexer.Search(repoIDs, "", name, 1, limit, true)
+	if IsErrTrigramQueryTooShort(err) {
+		// the trigram indexer cannot look up names shorter than a trigram
+		return []*Reference{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	pattern := regexp.MustCompile(`(^|[^\w$])` + regexp.QuoteMeta(name) + `($|[^\w$])`)
+	references := make([]*Reference, 0, limit)
+	for _, result := range results {
+		for i, line := range strings.Split(result.Content, "\n") {
+			if !pattern.MatchString(line) {
+				continue
+			}
+			if len(references) == limit {
+				return references, nil
+			}
+			line = strings.TrimSpace(line)
+			if runes := []rune(line); len(runes) > 200 {
+				line = string(runes[:200])
+			}
+			references = append(references, &Reference{
+				RepoID:   result.RepoID,
+				Filename: result.Filename,
+				CommitID: result.CommitID,
+				Line:     i + 1,
+				Content:  line,
+			})
+		}
+	}
+	return references, nil
+}
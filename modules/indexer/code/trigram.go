@@ -0,0 +1,738 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/analyze"
+	"code.gitea.io/gitea/modules/charset"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/typesniffer"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-enry/go-enry/v2"
+	"github.com/syndtr/goleveldb/leveldb"
+	leveldb_util "github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// The trigram index is stored in a LevelDB database with the keys:
+//
+//	v                    the version of the index
+//	d<id>                the document of a file, <id> is the filenameIndexerID
+//	t<trigram><id>       the posting of a trigram contained in the file
+//	c<trigram>           the number of files containing the trigram
+const (
+	trigramVersionKey    = "v"
+	trigramDocumentKey   = "d"
+	trigramPostingKey    = "t"
+	trigramCountKey      = "c"
+	trigramLatestVersion = 1
+
+	// the postings of the whole index are scanned instead of those of each repository
+	// when searching more repositories
+	trigramMaxRepoScans = 100
+	// the matches of a file which are counted for its rank
+	trigramMaxRankedMatches = 100
+	// the files which are matched against the pattern of a search
+	trigramMaxCandidates = 10000
+)
+
+// ErrTrigramQueryTooShort represents a search pattern which doesn't require any trigram, so
+// every indexed file would have to be matched against it
+type ErrTrigramQueryTooShort struct {
+	Keyword string
+}
+
+// IsErrTrigramQueryTooShort checks if an error is a ErrTrigramQueryTooShort.
+func IsErrTrigramQueryTooShort(err error) bool {
+	_, ok := err.(ErrTrigramQueryTooShort)
+	return ok
+}
+
+func (err ErrTrigramQueryTooShort) Error() string {
+	return fmt.Sprintf("search pattern must contain at least 3 consecutive characters [keyword: %s]", err.Keyword)
+}
+
+// trigramDocument represents a file stored in the trigram indexer
+type trigramDocument struct {
+	RepoID      int64
+	CommitID    string
+	Content     string
+	Language    string
+	UpdatedUnix timeutil.TimeStamp
+}
+
+var (
+	_ Indexer = &TrigramIndexer{}
+)
+
+// TrigramIndexer represents a code indexer which searches for substrings and regular
+// expressions in the files containing the trigrams of the searched text
+type TrigramIndexer struct {
+	indexDir string
+	db       *leveldb.DB
+	lock     sync.Mutex // serializes the updates of the trigram counts
+}
+
+// NewTrigramIndexer creates a new trigram local indexer
+func NewTrigramIndexer(indexDir string) (*TrigramIndexer, bool, error) {
+	indexer := &TrigramIndexer{
+		indexDir: indexDir,
+	}
+	created, err := indexer.init()
+	if err != nil {
+		indexer.Close()
+		return nil, false, err
+	}
+	return indexer, created, nil
+}
+
+// init opens the index, or recreates it if it was created by a previous version
+func (t *TrigramIndexer) init() (bool, error) {
+	_, err := os.Stat(t.indexDir)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	exists := err == nil
+
+	if exists {
+		if t.db, err = leveldb.OpenFile(t.indexDir, nil); err != nil {
+			return false, err
+		}
+		version, err := t.db.Get([]byte(trigramVersionKey), nil)
+		if err != nil && err != leveldb.ErrNotFound {
+			return false, err
+		}
+		if n, _ := strconv.Atoi(string(version)); n >= trigramLatestVersion {
+			return false, nil
+		}
+
+		// the index is using a previous version, so we should delete it and re-populate
+		if err := t.db.Close(); err != nil {
+			return false, err
+		}
+		t.db = nil
+		if err := util.RemoveAll(t.indexDir); err != nil {
+			return false, err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(t.indexDir), os.ModePerm); err != nil {
+		return false, err
+	}
+	if t.db, err = leveldb.OpenFile(t.indexDir, nil); err != nil {
+		return false, err
+	}
+	return true, t.db.Put([]byte(trigramVersionKey), []byte(strconv.Itoa(trigramLatestVersion)), nil)
+}
+
+// Close close the indexer
+func (t *TrigramIndexer) Close() {
+	log.Debug("Closing repo indexer")
+	if t.db != nil {
+		if err := t.db.Close(); err != nil {
+			log.Error("Error whilst closing the repository indexer: %v", err)
+		}
+	}
+	log.Info("PID: %d Repository Indexer closed", os.Getpid())
+}
+
+// trigramBatch collects the changes of the index and the trigram counts
+type trigramBatch struct {
+	db     *leveldb.DB
+	batch  *leveldb.Batch
+	counts map[string]int64
+}
+
+func (t *TrigramIndexer) newBatch() *trigramBatch {
+	return &trigramBatch{
+		db:     t.db,
+		batch:  new(leveldb.Batch),
+		counts: make(map[string]int64),
+	}
+}
+
+// index adds a file to the index, replacing its previous version
+func (b *trigramBatch) index(id string, doc *trigramDocument) error {
+	if err := b.delete(id); err != nil {
+		return err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	b.batch.Put([]byte(trigramDocumentKey+id), data)
+	for _, trigram := range extractTrigrams(doc.Content) {
+		b.batch.Put([]byte(trigramPostingKey+trigram+id), nil)
+		b.counts[trigram]++
+	}
+	return b.flushIfFull()
+}
+
+// delete removes a file from the index
+func (b *trigramBatch) delete(id string) error {
+	data, err := b.db.Get([]byte(trigramDocumentKey+id), nil)
+	if err == leveldb.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var doc trigramDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	b.batch.Delete([]byte(trigramDocumentKey + id))
+	for _, trigram := range extractTrigrams(doc.Content) {
+		b.batch.Delete([]byte(trigramPostingKey + trigram + id))
+		b.counts[trigram]--
+	}
+	return b.flushIfFull()
+}
+
+func (b *trigramBatch) flushIfFull() error {
+	if b.batch.Len() < 100000 {
+		return nil
+	}
+	return b.flush()
+}
+
+// flush writes the changes together with the updated trigram counts
+func (b *trigramBatch) flush() error {
+	for trigram, delta := range b.counts {
+		if delta == 0 {
+			continue
+		}
+		count, err := trigramCount(b.db, trigram)
+		if err != nil {
+			return err
+		}
+		if count += delta; count > 0 {
+			b.batch.Put([]byte(trigramCountKey+trigram), []byte(strconv.FormatInt(count, 10)))
+		} else {
+			b.batch.Delete([]byte(trigramCountKey + trigram))
+		}
+	}
+	if err := b.db.Write(b.batch, nil); err != nil {
+		return err
+	}
+	b.batch.Reset()
+	b.counts = make(map[string]int64)
+	return nil
+}
+
+func trigramCount(db *leveldb.DB, trigram string) (int64, error) {
+	data, err := db.Get([]byte(trigramCountKey+trigram), nil)
+	if err == leveldb.ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(data), 10, 64)
+}
+
+func (t *TrigramIndexer) addUpdate(batchWriter git.WriteCloserError, batchReader *bufio.Reader, commitSha string,
+	update fileUpdate, repo *models.Repository, batch *trigramBatch) error {
+	// Ignore vendored files in code search
+	if setting.Indexer.ExcludeVendored && analyze.IsVendor(update.Filename) {
+		return nil
+	}
+
+	size := update.Size
+
+	if !update.Sized {
+		stdout, err := git.NewCommand("cat-file", "-s", update.BlobSha).
+			RunInDir(repo.RepoPath())
+		if err != nil {
+			return err
+		}
+		if size, err = strconv.ParseInt(strings.TrimSpace(stdout), 10, 64); err != nil {
+			return fmt.Errorf("Misformatted git cat-file output: %v", err)
+		}
+	}
+
+	id := filenameIndexerID(repo.ID, update.Filename)
+	if size > setting.Indexer.MaxIndexerFileSize {
+		return batch.delete(id)
+	}
+
+	if _, err := batchWriter.Write([]byte(update.BlobSha + "\n")); err != nil {
+		return err
+	}
+
+	_, _, size, err := git.ReadBatchLine(batchReader)
+	if err != nil {
+		return err
+	}
+
+	fileContents, err := io.ReadAll(io.LimitReader(batchReader, size))
+	if err != nil {
+		return err
+	}
+	if _, err = batchReader.Discard(1); err != nil {
+		return err
+	}
+	if !typesniffer.DetectContentType(fileContents).IsText() {
+		// FIXME: UTF-16 files will probably fail here
+		return batch.delete(id)
+	}
+
+	return batch.index(id, &trigramDocument{
+		RepoID:      repo.ID,
+		CommitID:    commitSha,
+		Content:     string(charset.ToUTF8DropErrors(fileContents)),
+		Language:    analyze.GetCodeLanguage(update.Filename, fileContents),
+		UpdatedUnix: timeutil.TimeStampNow(),
+	})
+}
+
+// Index indexes the data
+func (t *TrigramIndexer) Index(repo *models.Repository, sha string, changes *repoChanges) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	batch := t.newBatch()
+	if len(changes.Updates) > 0 {
+		batchWriter, batchReader, cancel := git.CatFileBatch(repo.RepoPath())
+		defer cancel()
+
+		for _, update := range changes.Updates {
+			if err := t.addUpdate(batchWriter, batchReader, sha, update, repo, batch); err != nil {
+				return err
+			}
+		}
+		cancel()
+	}
+	for _, filename := range changes.RemovedFilenames {
+		if err := batch.delete(filenameIndexerID(repo.ID, filename)); err != nil {
+			return err
+		}
+	}
+	return batch.flush()
+}
+
+// Delete deletes indexes by ids
+func (t *TrigramIndexer) Delete(repoID int64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var ids []string
+	iter := t.db.NewIterator(leveldb_util.BytesPrefix([]byte(trigramDocumentKey+indexerID(repoID)+"_")), nil)
+	for iter.Next() {
+		ids = append(ids, string(iter.Key()[len(trigramDocumentKey):]))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	batch := t.newBatch()
+	for _, id := range ids {
+		if err := batch.delete(id); err != nil {
+			return err
+		}
+	}
+	return batch.flush()
+}
+
+// trigramHit represents a file matching a search
+type trigramHit struct {
+	doc        *trigramDocument
+	repoID     int64
+	filename   string
+	score      float64
+	startIndex int
+	endIndex   int
+}
+
+// trigramHitLess returns whether the hit a ranks before the hit b
+func trigramHitLess(a, b *trigramHit) bool {
+	if a.score != b.score {
+		return a.score > b.score
+	}
+	if a.repoID != b.repoID {
+		return a.repoID < b.repoID
+	}
+	return a.filename < b.filename
+}
+
+// Search searches for files in the specified repo.
+// Returns the matching file-paths
+func (t *TrigramIndexer) Search(repoIDs []int64, language, keyword string, page, pageSize int, isMatch bool) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+	query := parseTrigramSearchQuery(keyword)
+	trigrams := query.trigrams()
+	if trigrams.Op == trigramAll {
+		return 0, nil, nil, ErrTrigramQueryTooShort{Keyword: keyword}
+	}
+	matcher := query.matcher(isMatch)
+
+	ids, err := t.candidates(repoIDs, trigrams)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	var repoNames map[int64]string
+	if len(query.Repos) > 0 {
+		if repoNames, err = t.repoNames(ids); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
+	// only the hits up to the requested page are kept with their documents
+	limit := page * pageSize
+	top := make([]*trigramHit, 0, util.Min(limit+1, len(ids)))
+	var total int64
+	languageCounts := make(map[string]int)
+	for _, id := range ids {
+		repoID, filename := parseIndexerID(id)
+		if !matchAny(query.Repos, repoNames[repoID]) || !matchAll(query.Paths, filename) {
+			continue
+		}
+		doc, err := t.document(id)
+		if err != nil {
+			return 0, nil, nil, err
+		} else if doc == nil {
+			continue
+		}
+		if len(query.Languages) > 0 && !containsFold(query.Languages, doc.Language) {
+			continue
+		}
+		hit := rankTrigramHit(query, matcher, filename, doc.Content)
+		if hit == nil {
+			continue
+		}
+		hit.doc, hit.repoID, hit.filename = doc, repoID, filename
+
+		// the languages are counted before filtering them, so other languages can be selected
+		languageCounts[doc.Language]++
+		if len(language) > 0 && !strings.EqualFold(language, doc.Language) {
+			continue
+		}
+		total++
+
+		i := sort.Search(len(top), func(i int) bool {
+			return trigramHitLess(hit, top[i])
+		})
+		if i >= limit {
+			continue
+		}
+		top = append(top, nil)
+		copy(top[i+1:], top[i:])
+		top[i] = hit
+		if len(top) > limit {
+			top[limit] = nil
+			top = top[:limit]
+		}
+	}
+
+	start := util.Min((page-1)*pageSize, len(top))
+	searchResults := make([]*SearchResult, 0, len(top)-start)
+	for _, hit := range top[start:] {
+		searchResults = append(searchResults, &SearchResult{
+			RepoID:      hit.repoID,
+			StartIndex:  hit.startIndex,
+			EndIndex:    hit.endIndex,
+			Filename:    hit.filename,
+			Content:     hit.doc.Content,
+			CommitID:    hit.doc.CommitID,
+			UpdatedUnix: hit.doc.UpdatedUnix,
+			Language:    hit.doc.Language,
+			Color:       enry.GetColor(hit.doc.Language),
+		})
+	}
+
+	searchResultLanguages := make([]*SearchResultLanguages, 0, len(languageCounts))
+	for lang, count := range languageCounts {
+		if len(lang) == 0 {
+			continue
+		}
+		searchResultLanguages = append(searchResultLanguages, &SearchResultLanguages{
+			Language: lang,
+			Color:    enry.GetColor(lang),
+			Count:    count,
+		})
+	}
+	sort.Slice(searchResultLanguages, func(i, j int) bool {
+		if searchResultLanguages[i].Count != searchResultLanguages[j].Count {
+			return searchResultLanguages[i].Count > searchResultLanguages[j].Count
+		}
+		return searchResultLanguages[i].Language < searchResultLanguages[j].Language
+	})
+	if len(searchResultLanguages) > 10 {
+		searchResultLanguages = searchResultLanguages[:10]
+	}
+
+	return total, searchResults, searchResultLanguages, nil
+}
+
+// rankTrigramHit matches the pattern in a file and scores the match. Files with more matches,
+// matches of whole words, or matches in their name rank higher. It returns nil if the file
+// doesn't match.
+func rankTrigramHit(query *trigramSearchQuery, matcher *regexp.Regexp, filename, content string) *trigramHit {
+	hit := &trigramHit{}
+	if query.Pattern != "" {
+		matches := matcher.FindAllStringIndex(content, trigramMaxRankedMatches)
+		if len(matches) == 0 {
+			return nil
+		}
+		hit.startIndex, hit.endIndex = matches[0][0], matches[0][1]
+		hit.score = float64(len(matches))
+
+		wholeWord := false
+		for _, match := range matches {
+			if isWordBoundary(content, match[0]) && isWordBoundary(content, match[1]) {
+				if !wholeWord {
+					// prefer highlighting a whole word
+					hit.startIndex, hit.endIndex = match[0], match[1]
+					wholeWord = true
+				}
+				hit.score += 5
+			}
+		}
+		if !query.IsRegexp && strings.Contains(content, query.Pattern) {
+			// the pattern appears as it was typed
+			hit.score += 10
+		}
+		if matcher.MatchString(filepath.Base(filename)) {
+			hit.score += 50
+		}
+	}
+	// shorter files are more relevant than longer files with as many matches
+	hit.score -= float64(len(content)) / float64(setting.Indexer.MaxIndexerFileSize+1)
+	return hit
+}
+
+// isWordBoundary returns whether the index of the content is at the start or the end of a word
+func isWordBoundary(content string, index int) bool {
+	if index == 0 || index == len(content) {
+		return true
+	}
+	before, _ := utf8.DecodeLastRuneInString(content[:index])
+	after, _ := utf8.DecodeRuneInString(content[index:])
+	return isWordRune(before) != isWordRune(after)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func matchAny(patterns []*regexp.Regexp, text string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return len(patterns) == 0
+}
+
+func matchAll(patterns []*regexp.Regexp, text string) bool {
+	for _, pattern := range patterns {
+		if !pattern.MatchString(text) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// document returns the stored document of a file, or nil if it was removed from the index
+func (t *TrigramIndexer) document(id string) (*trigramDocument, error) {
+	data, err := t.db.Get([]byte(trigramDocumentKey+id), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	doc := &trigramDocument{}
+	return doc, json.Unmarshal(data, doc)
+}
+
+// repoNames returns the full names of the repositories of the files
+func (t *TrigramIndexer) repoNames(ids []string) (map[int64]string, error) {
+	repoIDs := make([]int64, 0, 10)
+	seen := make(map[int64]bool)
+	for _, id := range ids {
+		repoID, _ := parseIndexerID(id)
+		if !seen[repoID] {
+			seen[repoID] = true
+			repoIDs = append(repoIDs, repoID)
+		}
+	}
+	repos, err := models.GetRepositoriesMapByIDs(repoIDs)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int64]string, len(repos))
+	for id, repo := range repos {
+		names[id] = repo.OwnerName + "/" + repo.Name
+	}
+	return names, nil
+}
+
+// candidates returns the sorted ids of the files of the repositories containing the trigrams
+// of the query, at most trigramMaxCandidates of them. All repositories are searched if repoIDs
+// is empty.
+func (t *TrigramIndexer) candidates(repoIDs []int64, query *trigramQuery) ([]string, error) {
+	prefixes := []string{""}
+	var allowed map[int64]bool
+	if len(repoIDs) > trigramMaxRepoScans {
+		allowed = make(map[int64]bool, len(repoIDs))
+		for _, repoID := range repoIDs {
+			allowed[repoID] = true
+		}
+	} else if len(repoIDs) > 0 {
+		prefixes = make([]string, 0, len(repoIDs))
+		for _, repoID := range repoIDs {
+			prefixes = append(prefixes, indexerID(repoID)+"_")
+		}
+	}
+
+	set, err := t.evaluate(query, prefixes)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		if allowed != nil {
+			if repoID, _ := parseIndexerID(id); !allowed[repoID] {
+				continue
+			}
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if len(ids) > trigramMaxCandidates {
+		ids = ids[:trigramMaxCandidates]
+	}
+	return ids, nil
+}
+
+// evaluate returns the ids of the files with the given prefixes matching the query
+func (t *TrigramIndexer) evaluate(query *trigramQuery, prefixes []string) (map[string]bool, error) {
+	switch query.Op {
+	case trigramOr:
+		result := make(map[string]bool)
+		for _, sub := range query.Sub {
+			set, err := t.evaluate(sub, prefixes)
+			if err != nil {
+				return nil, err
+			}
+			for id := range set {
+				result[id] = true
+			}
+		}
+		return result, nil
+	case trigramAnd:
+		var result map[string]bool
+		if len(query.Trigrams) > 0 {
+			var err error
+			if result, err = t.evaluateTrigrams(query.Trigrams, prefixes); err != nil {
+				return nil, err
+			}
+		}
+		for _, sub := range query.Sub {
+			if result != nil && len(result) == 0 {
+				break
+			}
+			set, err := t.evaluate(sub, prefixes)
+			if err != nil {
+				return nil, err
+			}
+			if result == nil {
+				result = set
+				continue
+			}
+			for id := range result {
+				if !set[id] {
+					delete(result, id)
+				}
+			}
+		}
+		if result != nil {
+			return result, nil
+		}
+	}
+	// queries which any file may match are rejected by Search instead of scanning every file
+	return map[string]bool{}, nil
+}
+
+// evaluateTrigrams returns the ids of the files containing all the trigrams. The postings of
+// the rarest trigram are scanned and the others are looked up for each of its files.
+func (t *TrigramIndexer) evaluateTrigrams(trigrams []string, prefixes []string) (map[string]bool, error) {
+	rarest, rarestCount := "", int64(-1)
+	for _, trigram := range trigrams {
+		count, err := trigramCount(t.db, trigram)
+		if err != nil {
+			return nil, err
+		} else if count == 0 {
+			return map[string]bool{}, nil
+		}
+		if rarestCount < 0 || count < rarestCount {
+			rarest, rarestCount = trigram, count
+		}
+	}
+
+	set, err := t.scan(trigramPostingKey+rarest, prefixes)
+	if err != nil {
+		return nil, err
+	}
+	for id := range set {
+		for _, trigram := range trigrams {
+			if trigram == rarest {
+				continue
+			}
+			has, err := t.db.Has([]byte(trigramPostingKey+trigram+id), nil)
+			if err != nil {
+				return nil, err
+			}
+			if !has {
+				delete(set, id)
+				break
+			}
+		}
+	}
+	return set, nil
+}
+
+// scan returns the ids following the key in the keys starting with the key and one of the prefixes
+func (t *TrigramIndexer) scan(key string, prefixes []string) (map[string]bool, error) {
+	set := make(map[string]bool)
+	for _, prefix := range prefixes {
+		iter := t.db.NewIterator(leveldb_util.BytesPrefix([]byte(key+prefix)), nil)
+		for iter.Next() {
+			set[string(iter.Key()[len(key):])] = true
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
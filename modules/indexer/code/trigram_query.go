@@ -0,0 +1,189 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+var trigramFilterPattern = regexp.MustCompile(`(^|\s+)(repo|lang|path):(\S+)`)
+
+// trigramSearchQuery represents a parsed query of the trigram indexer. A keyword like
+// `lang:go path:_test /func Test\w+/` searches for the regular expression between the
+// slashes in the test files written in Go. A keyword without slashes is searched as a
+// substring.
+type trigramSearchQuery struct {
+	Pattern   string
+	IsRegexp  bool
+	Repos     []*regexp.Regexp // the name of the repository has to match one of them
+	Languages []string         // the language of the file has to be one of them
+	Paths     []*regexp.Regexp // the path of the file has to match all of them
+}
+
+// parseTrigramSearchQuery splits a keyword into its filters and the searched pattern
+func parseTrigramSearchQuery(keyword string) *trigramSearchQuery {
+	q := &trigramSearchQuery{}
+	pattern := trigramFilterPattern.ReplaceAllStringFunc(keyword, func(match string) string {
+		m := trigramFilterPattern.FindStringSubmatch(match)
+		switch m[2] {
+		case "repo":
+			q.Repos = append(q.Repos, compileFilterRegexp(m[3]))
+		case "lang":
+			q.Languages = append(q.Languages, m[3])
+		case "path":
+			q.Paths = append(q.Paths, compileFilterRegexp(m[3]))
+		}
+		return ""
+	})
+	q.Pattern = strings.TrimSpace(pattern)
+	if len(q.Pattern) > 2 && q.Pattern[0] == '/' && q.Pattern[len(q.Pattern)-1] == '/' {
+		if _, err := syntax.Parse(q.Pattern[1:len(q.Pattern)-1], syntax.Perl); err == nil {
+			q.Pattern = q.Pattern[1 : len(q.Pattern)-1]
+			q.IsRegexp = true
+		}
+	}
+	return q
+}
+
+// compileFilterRegexp compiles the case insensitive regular expression of a filter. Invalid
+// expressions match their text literally.
+func compileFilterRegexp(expr string) *regexp.Regexp {
+	re, err := regexp.Compile("(?i)" + expr)
+	if err != nil {
+		return regexp.MustCompile("(?i)" + regexp.QuoteMeta(expr))
+	}
+	return re
+}
+
+// matcher returns the regular expression matching the pattern in the content of a file
+func (q *trigramSearchQuery) matcher(caseSensitive bool) *regexp.Regexp {
+	expr := q.Pattern
+	if !q.IsRegexp {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if !caseSensitive {
+		expr = "(?i)" + expr
+	}
+	return regexp.MustCompile(expr)
+}
+
+// trigrams returns the trigrams a file has to contain to match the pattern
+func (q *trigramSearchQuery) trigrams() *trigramQuery {
+	if !q.IsRegexp {
+		return literalTrigramQuery(q.Pattern)
+	}
+	re, err := syntax.Parse(q.Pattern, syntax.Perl)
+	if err != nil {
+		return &trigramQuery{Op: trigramAll}
+	}
+	return regexpTrigramQuery(re.Simplify())
+}
+
+type trigramOp int
+
+const (
+	trigramAll trigramOp = iota // any file may match
+	trigramAnd                  // the file contains all the trigrams and matches all the sub queries
+	trigramOr                   // the file matches any of the sub queries
+)
+
+// trigramQuery represents the trigrams a file has to contain to possibly match a pattern
+type trigramQuery struct {
+	Op       trigramOp
+	Trigrams []string
+	Sub      []*trigramQuery
+}
+
+// literalTrigramQuery returns the query of the trigrams of a literal text
+func literalTrigramQuery(text string) *trigramQuery {
+	trigrams := extractTrigrams(text)
+	if len(trigrams) == 0 {
+		return &trigramQuery{Op: trigramAll}
+	}
+	return &trigramQuery{Op: trigramAnd, Trigrams: trigrams}
+}
+
+// regexpTrigramQuery returns the query of the trigrams required by a regular expression
+func regexpTrigramQuery(re *syntax.Regexp) *trigramQuery {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return literalTrigramQuery(string(re.Rune))
+	case syntax.OpCapture, syntax.OpPlus:
+		return regexpTrigramQuery(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return regexpTrigramQuery(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		// adjacent literals form a longer literal which has more trigrams than its parts
+		var queries []*trigramQuery
+		var literal strings.Builder
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral {
+				literal.WriteString(string(sub.Rune))
+				continue
+			}
+			queries = append(queries, literalTrigramQuery(literal.String()), regexpTrigramQuery(sub))
+			literal.Reset()
+		}
+		queries = append(queries, literalTrigramQuery(literal.String()))
+		return andTrigramQueries(queries)
+	case syntax.OpAlternate:
+		query := &trigramQuery{Op: trigramOr}
+		for _, sub := range re.Sub {
+			subQuery := regexpTrigramQuery(sub)
+			if subQuery.Op == trigramAll {
+				return subQuery
+			}
+			query.Sub = append(query.Sub, subQuery)
+		}
+		return query
+	}
+	return &trigramQuery{Op: trigramAll}
+}
+
+// andTrigramQueries combines queries which all have to match
+func andTrigramQueries(queries []*trigramQuery) *trigramQuery {
+	result := &trigramQuery{Op: trigramAnd}
+	seen := make(map[string]bool)
+	for _, query := range queries {
+		switch query.Op {
+		case trigramAnd:
+			for _, trigram := range query.Trigrams {
+				if !seen[trigram] {
+					seen[trigram] = true
+					result.Trigrams = append(result.Trigrams, trigram)
+				}
+			}
+			result.Sub = append(result.Sub, query.Sub...)
+		case trigramOr:
+			result.Sub = append(result.Sub, query)
+		}
+	}
+	if len(result.Trigrams) == 0 && len(result.Sub) == 0 {
+		return &trigramQuery{Op: trigramAll}
+	}
+	return result
+}
+
+// extractTrigrams returns the distinct trigrams of the lower cased text
+func extractTrigrams(text string) []string {
+	text = strings.ToLower(text)
+	if len(text) < 3 {
+		return nil
+	}
+	seen := make(map[string]bool, len(text))
+	trigrams := make([]string, 0, len(text))
+	for i := 0; i+3 <= len(text); i++ {
+		trigram := text[i : i+3]
+		if !seen[trigram] {
+			seen[trigram] = true
+			trigrams = append(trigrams, trigram)
+		}
+	}
+	return trigrams
+}
@@ -0,0 +1,148 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"os"
+	"path/filepath"
+	"regexp/syntax"
+	"testing"
+
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrigramIndexAndSearch(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	dir, err := os.MkdirTemp("", "trigram.index")
+	assert.NoError(t, err)
+	if err != nil {
+		assert.Fail(t, "Unable to create temporary directory")
+		return
+	}
+	defer util.RemoveAll(dir)
+
+	idx, _, err := NewTrigramIndexer(filepath.Join(dir, "repos.trigram"))
+	if err != nil {
+		assert.Fail(t, "Unable to create trigram indexer Error: %v", err)
+		if idx != nil {
+			idx.Close()
+		}
+		return
+	}
+	defer idx.Close()
+
+	testIndexer("trigram", t, idx)
+
+	// reset the indexer status to index the whole repository again
+	unittest.PrepareTestEnv(t)
+	assert.NoError(t, index(idx, 1))
+	for _, kw := range []struct {
+		Keyword string
+		IsMatch bool
+		Total   int64
+		Start   int
+		End     int
+	}{
+		{Keyword: "ion for rep", Total: 1, Start: 17, End: 28},
+		{Keyword: "DESCRIPTION", Total: 1, Start: 9, End: 20},
+		{Keyword: "DESCRIPTION", IsMatch: true, Total: 0},
+		{Keyword: `/desc\w+ (for|of) repo\d/`, Total: 1, Start: 9, End: 30},
+		{Keyword: `/desc\w+ of repo\d/`, Total: 0},
+		{Keyword: "repo:user2/repo1 Description", Total: 1, Start: 9, End: 20},
+		{Keyword: "repo:user2/repo2 Description", Total: 0},
+		{Keyword: "Description path:readme lang:markdown", Total: 1, Start: 9, End: 20},
+		{Keyword: "Description lang:go", Total: 0},
+	} {
+		t.Run(kw.Keyword, func(t *testing.T) {
+			total, res, _, err := idx.Search(nil, "", kw.Keyword, 1, 10, kw.IsMatch)
+			assert.NoError(t, err)
+			assert.EqualValues(t, kw.Total, total)
+			if kw.Total > 0 && assert.Len(t, res, 1) {
+				assert.EqualValues(t, "README.md", res[0].Filename)
+				if kw.End > 0 {
+					assert.EqualValues(t, kw.Start, res[0].StartIndex)
+					assert.EqualValues(t, kw.End, res[0].EndIndex)
+				}
+			}
+		})
+	}
+
+	// the total is counted for the pages after the hits
+	total, res, _, err := idx.Search(nil, "", "Description", 2, 10, false)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	assert.Empty(t, res)
+
+	// patterns without trigrams would have to be matched against every file
+	for _, keyword := range []string{"de", "path:readme", "/a.*b/"} {
+		_, _, _, err := idx.Search(nil, "", keyword, 1, 10, false)
+		assert.True(t, IsErrTrigramQueryTooShort(err), keyword)
+	}
+
+	// the references of symbols are looked up with the configured indexer
+	indexer.set(idx)
+	defer indexer.set(nil)
+	refs, err := FindReferences(nil, "Description", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, refs, 1) {
+		assert.EqualValues(t, "README.md", refs[0].Filename)
+		assert.EqualValues(t, 3, refs[0].Line)
+	}
+	refs, err = FindReferences(nil, "de", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, refs)
+
+	// the counts of the trigrams of removed files are removed
+	assert.NoError(t, idx.Delete(1))
+	count, err := trigramCount(idx.db, "des")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+}
+
+func TestParseTrigramSearchQuery(t *testing.T) {
+	q := parseTrigramSearchQuery("lang:go  path:_test path:^models/ /func Test\\w+/ repo:gitea")
+	assert.Equal(t, `func Test\w+`, q.Pattern)
+	assert.True(t, q.IsRegexp)
+	assert.Equal(t, []string{"go"}, q.Languages)
+	assert.Len(t, q.Paths, 2)
+	assert.True(t, matchAll(q.Paths, "models/user_test.go"))
+	assert.False(t, matchAll(q.Paths, "models/user.go"))
+	assert.True(t, matchAny(q.Repos, "go-gitea/Gitea"))
+
+	q = parseTrigramSearchQuery("foo->bar( /x")
+	assert.Equal(t, "foo->bar( /x", q.Pattern)
+	assert.False(t, q.IsRegexp)
+	assert.True(t, q.matcher(false).MatchString("FOO->bar( /x"))
+	assert.False(t, q.matcher(true).MatchString("FOO->bar( /x"))
+
+	// invalid regular expressions are searched as text
+	q = parseTrigramSearchQuery("/a(b/")
+	assert.Equal(t, "/a(b/", q.Pattern)
+	assert.False(t, q.IsRegexp)
+}
+
+func TestRegexpTrigramQuery(t *testing.T) {
+	query := func(expr string) *trigramQuery {
+		re, err := syntax.Parse(expr, syntax.Perl)
+		assert.NoError(t, err)
+		return regexpTrigramQuery(re.Simplify())
+	}
+
+	assert.Equal(t, &trigramQuery{Op: trigramAnd, Trigrams: []string{"fun", "unc", "tes", "est"}}, query(`func\s+Test`))
+	assert.Equal(t, &trigramQuery{Op: trigramAnd, Trigrams: []string{"abc"}}, query(`(?i)ABC(d|)`))
+	assert.Equal(t, &trigramQuery{Op: trigramAll}, query(`a.*b`))
+	assert.Equal(t, &trigramQuery{Op: trigramAll}, query(`(abc|de)`))
+	assert.Equal(t, &trigramQuery{Op: trigramAnd, Sub: []*trigramQuery{{
+		Op: trigramOr,
+		Sub: []*trigramQuery{
+			{Op: trigramAnd, Trigrams: []string{"abc"}},
+			{Op: trigramAnd, Trigrams: []string{"def"}},
+		},
+	}}}, query(`x(abc|def)+`))
+}
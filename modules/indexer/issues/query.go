@@ -0,0 +1,314 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// currentUserName is the user name matching the doer of a search
+const currentUserName = "@me"
+
+// querySortTypes maps the values of the sort qualifier to issue sort types
+var querySortTypes = map[string]string{
+	"created":       "newest",
+	"created-desc":  "newest",
+	"created-asc":   "oldest",
+	"updated":       "recentupdate",
+	"updated-desc":  "recentupdate",
+	"updated-asc":   "leastupdate",
+	"comments":      "mostcomment",
+	"comments-desc": "mostcomment",
+	"comments-asc":  "leastcomment",
+	"due":           "nearduedate",
+	"due-asc":       "nearduedate",
+	"due-desc":      "farduedate",
+}
+
+// Query represents an issue search query such as
+// `is:open label:bug -label:wontfix author:alice updated:>2021-01-01 sort:comments crash`.
+// The terms which are not qualifiers form the keyword searched by the issue indexer.
+type Query struct {
+	Keyword           string
+	IsClosed          util.OptionalBool
+	IsPull            util.OptionalBool
+	Labels            []string
+	ExcludedLabels    []string
+	Milestones        []string
+	Author            string
+	Assignee          string
+	Mentions          string
+	ReviewRequested   string
+	UpdatedAfterUnix  int64
+	UpdatedBeforeUnix int64
+	CreatedAfterUnix  int64
+	CreatedBeforeUnix int64
+	SortType          string
+}
+
+// ParseQuery parses an issue search query. Unknown qualifiers and qualifiers with an invalid
+// value are kept in the keyword.
+func ParseQuery(q string) *Query {
+	query := &Query{}
+	var keywords []string
+	for _, term := range splitQueryTerms(q) {
+		if !query.parseQualifier(term) {
+			keywords = append(keywords, strings.ReplaceAll(term, `"`, ""))
+		}
+	}
+	query.Keyword = strings.TrimSpace(strings.Join(keywords, " "))
+	return query
+}
+
+// parseQualifier applies a qualifier term to the query, it returns false if the term is not a
+// valid qualifier
+func (query *Query) parseQualifier(term string) bool {
+	negated := strings.HasPrefix(term, "-")
+	idx := strings.IndexByte(term, ':')
+	if idx <= 0 {
+		return false
+	}
+	key := strings.ToLower(strings.TrimPrefix(term[:idx], "-"))
+	value := unquote(term[idx+1:])
+	if value == "" {
+		return false
+	}
+
+	if negated {
+		if key != "label" {
+			return false
+		}
+		query.ExcludedLabels = append(query.ExcludedLabels, value)
+		return true
+	}
+
+	switch key {
+	case "is":
+		switch strings.ToLower(value) {
+		case "open":
+			query.IsClosed = util.OptionalBoolFalse
+		case "closed":
+			query.IsClosed = util.OptionalBoolTrue
+		case "issue":
+			query.IsPull = util.OptionalBoolFalse
+		case "pr", "pull":
+			query.IsPull = util.OptionalBoolTrue
+		default:
+			return false
+		}
+	case "label":
+		query.Labels = append(query.Labels, value)
+	case "milestone":
+		query.Milestones = append(query.Milestones, value)
+	case "author":
+		query.Author = value
+	case "assignee":
+		query.Assignee = value
+	case "mentions":
+		query.Mentions = value
+	case "review-requested":
+		query.ReviewRequested = value
+	case "updated":
+		after, before, ok := parseDateRange(value)
+		if !ok {
+			return false
+		}
+		query.UpdatedAfterUnix, query.UpdatedBeforeUnix = after, before
+	case "created":
+		after, before, ok := parseDateRange(value)
+		if !ok {
+			return false
+		}
+		query.CreatedAfterUnix, query.CreatedBeforeUnix = after, before
+	case "sort":
+		sortType, ok := querySortTypes[strings.ToLower(value)]
+		if !ok {
+			return false
+		}
+		query.SortType = sortType
+	default:
+		return false
+	}
+	return true
+}
+
+// HasFilters returns true if the query has other qualifiers than sort
+func (query *Query) HasFilters() bool {
+	return !query.IsClosed.IsNone() || !query.IsPull.IsNone() ||
+		len(query.Labels) > 0 || len(query.ExcludedLabels) > 0 || len(query.Milestones) > 0 ||
+		query.Author != "" || query.Assignee != "" || query.Mentions != "" || query.ReviewRequested != "" ||
+		query.UpdatedAfterUnix != 0 || query.UpdatedBeforeUnix != 0 ||
+		query.CreatedAfterUnix != 0 || query.CreatedBeforeUnix != 0
+}
+
+// Apply applies the qualifiers of the query to the issues options, overriding the options
+// they conflict with. The users are resolved by name, "@me" being the doer. It returns false
+// if no issue can match the query, e.g. when a user does not exist or when the query asks for
+// pull requests while the options only accept issues.
+func (query *Query) Apply(opts *models.IssuesOptions, doer *models.User) (bool, error) {
+	if !query.IsPull.IsNone() {
+		if !opts.IsPull.IsNone() && opts.IsPull != query.IsPull {
+			return false, nil
+		}
+		opts.IsPull = query.IsPull
+	}
+	if !query.IsClosed.IsNone() {
+		opts.IsClosed = query.IsClosed
+	}
+
+	opts.RequiredLabelNames = append(opts.RequiredLabelNames, query.Labels...)
+	opts.ExcludedLabelNames = append(opts.ExcludedLabelNames, query.ExcludedLabels...)
+	opts.IncludeMilestones = append(opts.IncludeMilestones, query.Milestones...)
+
+	for _, filter := range []struct {
+		name string
+		id   *int64
+	}{
+		{query.Author, &opts.PosterID},
+		{query.Assignee, &opts.AssigneeID},
+		{query.Mentions, &opts.MentionedID},
+		{query.ReviewRequested, &opts.ReviewRequestedID},
+	} {
+		if filter.name == "" {
+			continue
+		}
+		user, err := resolveQueryUser(filter.name, doer)
+		if err != nil {
+			return false, err
+		} else if user == nil {
+			return false, nil
+		}
+		*filter.id = user.ID
+	}
+
+	if query.UpdatedAfterUnix != 0 {
+		opts.UpdatedAfterUnix = query.UpdatedAfterUnix
+	}
+	if query.UpdatedBeforeUnix != 0 {
+		opts.UpdatedBeforeUnix = query.UpdatedBeforeUnix
+	}
+	if query.CreatedAfterUnix != 0 {
+		opts.CreatedAfterUnix = query.CreatedAfterUnix
+	}
+	if query.CreatedBeforeUnix != 0 {
+		opts.CreatedBeforeUnix = query.CreatedBeforeUnix
+	}
+	if query.SortType != "" {
+		opts.SortType = query.SortType
+	}
+	return true, nil
+}
+
+// resolveQueryUser returns the user named in a query, or nil if it does not exist
+func resolveQueryUser(name string, doer *models.User) (*models.User, error) {
+	if name == currentUserName {
+		return doer, nil
+	}
+	user, err := models.GetUserByName(strings.TrimPrefix(name, "@"))
+	if models.IsErrUserNotExist(err) {
+		return nil, nil
+	}
+	return user, err
+}
+
+// splitQueryTerms splits a query on spaces, keeping the double quoted parts together
+func splitQueryTerms(q string) []string {
+	var terms []string
+	var term strings.Builder
+	quoted := false
+	for _, r := range q {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			term.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if term.Len() > 0 {
+				terms = append(terms, term.String())
+				term.Reset()
+			}
+		default:
+			term.WriteRune(r)
+		}
+	}
+	if term.Len() > 0 {
+		terms = append(terms, term.String())
+	}
+	return terms
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// parseDateRange parses a date qualifier value: a date or a time, optionally prefixed by one of
+// the >, >=, < and <= operators, or a range "from..to" where "*" leaves a bound open. It returns
+// the inclusive bounds of the range as unix timestamps, 0 meaning no bound.
+func parseDateRange(value string) (after, before int64, ok bool) {
+	if idx := strings.Index(value, ".."); idx >= 0 {
+		from, to := value[:idx], value[idx+2:]
+		if from != "*" {
+			start, _, ok := parseDate(from)
+			if !ok {
+				return 0, 0, false
+			}
+			after = start.Unix()
+		}
+		if to != "*" {
+			_, end, ok := parseDate(to)
+			if !ok {
+				return 0, 0, false
+			}
+			before = end.Unix()
+		}
+		return after, before, after != 0 || before != 0
+	}
+
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if !strings.HasPrefix(value, op) {
+			continue
+		}
+		start, end, ok := parseDate(value[len(op):])
+		if !ok {
+			return 0, 0, false
+		}
+		switch op {
+		case ">=":
+			return start.Unix(), 0, true
+		case "<=":
+			return 0, end.Unix(), true
+		case ">":
+			return end.Unix() + 1, 0, true
+		default:
+			return 0, start.Unix() - 1, true
+		}
+	}
+
+	start, end, ok := parseDate(value)
+	if !ok {
+		return 0, 0, false
+	}
+	return start.Unix(), end.Unix(), true
+}
+
+// parseDate parses a date or a RFC 3339 time, it returns the first and the last second of the
+// day for a date and the time itself otherwise
+func parseDate(value string) (start, end time.Time, ok bool) {
+	if t, err := time.ParseInLocation("2006-01-02", value, setting.DefaultUILocation); err == nil {
+		return t, t.AddDate(0, 0, 1).Add(-time.Second), true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, t, true
+	}
+	return time.Time{}, time.Time{}, false
+}
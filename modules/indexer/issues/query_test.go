@@ -0,0 +1,124 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	oldLocation := setting.DefaultUILocation
+	setting.DefaultUILocation = time.UTC
+	defer func() {
+		setting.DefaultUILocation = oldLocation
+	}()
+
+	kases := []struct {
+		query    string
+		expected *Query
+	}{
+		{
+			query:    "crash on start",
+			expected: &Query{Keyword: "crash on start"},
+		},
+		{
+			query: `is:open label:bug -label:wontfix label:"help wanted" author:alice updated:>2021-01-01 sort:comments crash`,
+			expected: &Query{
+				Keyword:          "crash",
+				IsClosed:         util.OptionalBoolFalse,
+				Labels:           []string{"bug", "help wanted"},
+				ExcludedLabels:   []string{"wontfix"},
+				Author:           "alice",
+				UpdatedAfterUnix: 1609545600,
+				SortType:         "mostcomment",
+			},
+		},
+		{
+			query: "is:closed is:pr assignee:@me mentions:bob review-requested:carol milestone:v1.0 milestone:v1.1",
+			expected: &Query{
+				IsClosed:        util.OptionalBoolTrue,
+				IsPull:          util.OptionalBoolTrue,
+				Assignee:        "@me",
+				Mentions:        "bob",
+				ReviewRequested: "carol",
+				Milestones:      []string{"v1.0", "v1.1"},
+			},
+		},
+		{
+			query: "created:2021-01-01 updated:<=2021-01-01",
+			expected: &Query{
+				CreatedAfterUnix:  1609459200,
+				CreatedBeforeUnix: 1609545599,
+				UpdatedBeforeUnix: 1609545599,
+			},
+		},
+		{
+			query: "created:2021-01-01..2021-01-31 updated:2021-01-01T10:00:00Z..*",
+			expected: &Query{
+				CreatedAfterUnix:  1609459200,
+				CreatedBeforeUnix: 1612137599,
+				UpdatedAfterUnix:  1609495200,
+			},
+		},
+		{
+			query: `is:unknown foo:bar -author:alice updated:yesterday sort:stars "exact phrase"`,
+			expected: &Query{
+				Keyword: "is:unknown foo:bar -author:alice updated:yesterday sort:stars exact phrase",
+			},
+		},
+	}
+
+	for _, kase := range kases {
+		t.Run(kase.query, func(t *testing.T) {
+			assert.Equal(t, kase.expected, ParseQuery(kase.query))
+		})
+	}
+}
+
+func TestQueryApply(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	doer := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	opts := &models.IssuesOptions{
+		IsClosed:  util.OptionalBoolTrue,
+		PosterID:  1,
+		LabelIDs:  []int64{1},
+		SortType:  "newest",
+		ProjectID: 3,
+	}
+	ok, err := ParseQuery("is:open label:bug -label:wontfix author:user1 assignee:@me sort:updated-asc").Apply(opts, doer)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.EqualValues(t, &models.IssuesOptions{
+		IsClosed:           util.OptionalBoolFalse,
+		PosterID:           1,
+		AssigneeID:         2,
+		LabelIDs:           []int64{1},
+		RequiredLabelNames: []string{"bug"},
+		ExcludedLabelNames: []string{"wontfix"},
+		SortType:           "leastupdate",
+		ProjectID:          3,
+	}, opts)
+
+	ok, err = ParseQuery("author:nonexistent").Apply(&models.IssuesOptions{}, doer)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = ParseQuery("assignee:@me").Apply(&models.IssuesOptions{}, nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = ParseQuery("is:pr").Apply(&models.IssuesOptions{IsPull: util.OptionalBoolFalse}, doer)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -22,6 +22,9 @@ type Decoder interface {
 	Decode(v interface{}) error
 }
 
+// RawMessage is a raw encoded JSON value which is decoded later
+type RawMessage = json.RawMessage
+
 // Interface represents an interface to handle json data
 type Interface interface {
 	Marshal(v interface{}) ([]byte, error)
@@ -0,0 +1,131 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package asciidoc
+
+import (
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const AppURL = "http://localhost:3000/"
+const Repo = "gogits/gogs"
+const AppSubURL = AppURL + Repo + "/"
+
+func test(t *testing.T, input, expected string) {
+	setting.AppURL = AppURL
+	setting.AppSubURL = AppSubURL
+
+	buffer, err := RenderString(&markup.RenderContext{
+		URLPrefix: setting.AppSubURL,
+	}, input)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(buffer))
+}
+
+func TestRender_Headings(t *testing.T) {
+	test(t, `= Document Title
+Jane Doe <jane@example.com>
+
+== First Section
+
+=== Nested Section
+
+== First Section`, `<h1 id="user-content-document-title">Document Title</h1>
+<h2 id="user-content-first-section">First Section</h2>
+<h3 id="user-content-nested-section">Nested Section</h3>
+<h2 id="user-content-first-section-1">First Section</h2>`)
+
+	test(t, `= Title
+:toc:
+
+== One
+
+== Two`, `<h1 id="user-content-title">Title</h1>
+<details><summary>toc</summary>
+<ul>
+<li><a href="#user-content-one">One</a></li>
+<li><a href="#user-content-two">Two</a></li>
+</ul>
+</details>
+<h2 id="user-content-one">One</h2>
+<h2 id="user-content-two">Two</h2>`)
+}
+
+func TestRender_Inline(t *testing.T) {
+	test(t, "Some *bold*, _emphasis_, `code` and #mark#.",
+		`<p>Some <strong>bold</strong>, <em>emphasis</em>, <code>code</code> and <mark>mark</mark>.</p>`)
+	test(t, "A <tag> & an escaped \\*star*.",
+		`<p>A &lt;tag&gt; &amp; an escaped *star*.</p>`)
+	test(t, ":project: Gitea\n\nThis is {project}.",
+		`<p>This is Gitea.</p>`)
+}
+
+func TestRender_Links(t *testing.T) {
+	test(t, "Visit https://gitea.io[Gitea^] or link:docs/install.adoc[the docs].",
+		`<p>Visit <a href="https://gitea.io">Gitea</a> or <a href="`+util.URLJoin(AppSubURL, "docs/install.adoc")+`">the docs</a>.</p>`)
+
+	test(t, `See <<details>> and <<_details,below>>.
+
+== Details`, `<p>See <a href="#user-content-details">Details</a> and <a href="#user-content-details">below</a>.</p>
+<h2 id="user-content-details">Details</h2>`)
+
+	test(t, "image::images/logo.png[Logo]",
+		`<p><img src="images/logo.png" alt="Logo"/></p>`)
+}
+
+func TestRender_Lists(t *testing.T) {
+	test(t, `* one
+** nested
+* [x] done`, `<ul>
+<li>one<ul>
+<li>nested</li>
+</ul>
+</li>
+<li class="task-list-item"><input type="checkbox" disabled="" checked=""/>done</li>
+</ul>`)
+
+	test(t, `. first
+. second`, `<ol>
+<li>first</li>
+<li>second</li>
+</ol>`)
+}
+
+func TestRender_Blocks(t *testing.T) {
+	test(t, `[source,go]
+----
+func main() {}
+----`, `<pre class="code-block"><code class="chroma language-go"><span class="kd">func</span> <span class="nf">main</span><span class="p">()</span> <span class="p">{}</span></code></pre>`)
+
+	test(t, "NOTE: Take care.", `<blockquote>
+<p><strong>Note</strong></p>
+<p>Take care.</p>
+</blockquote>`)
+
+	test(t, `|===
+|A |B
+
+|1 |2
+|===`, `<table>
+<thead>
+<tr>
+<th>A</th>
+<th>B</th>
+</tr>
+</thead>
+<tbody>
+<tr>
+<td>1</td>
+<td>2</td>
+</tr>
+</tbody>
+</table>`)
+}
@@ -0,0 +1,819 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package asciidoc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/markup/common"
+)
+
+// The renderer supports the subset of AsciiDoc commonly used in READMEs and wikis:
+// sections, paragraphs, lists, delimited blocks, admonitions, tables, images, links,
+// cross references, footnotes, attributes and the inline formatting.
+
+var (
+	attributeEntryPattern  = regexp.MustCompile(`^:(!?\w[\w-]*!?):(?:\s+(.*))?$`)
+	anchorPattern          = regexp.MustCompile(`^\[\[([\p{L}_:][\w:.-]*)(?:,\s*(.*))?\]\]$`)
+	blockAttributesPattern = regexp.MustCompile(`^\[(.*)\]$`)
+	blockTitlePattern      = regexp.MustCompile(`^\.([^\s.].*)$`)
+	headingPattern         = regexp.MustCompile(`^(={1,6})\s+(.+?)(?:\s+=+)?$`)
+	delimiterPattern       = regexp.MustCompile("^(-{4,}|\\.{4,}|_{4,}|={4,}|\\*{4,}|\\+{4,}|/{4,}|--|```.*)$")
+	tableDelimiterPattern  = regexp.MustCompile(`^\|={3,}$`)
+	blockMacroPattern      = regexp.MustCompile(`^(image|video|audio|toc|include)::(\S*?)\[(.*)\]$`)
+	admonitionPattern      = regexp.MustCompile(`^(NOTE|TIP|IMPORTANT|WARNING|CAUTION):\s+(.*)$`)
+	unorderedItemPattern   = regexp.MustCompile(`^\s*(\*{1,5}|-)\s+(.*)$`)
+	orderedItemPattern     = regexp.MustCompile(`^\s*(\.{1,5}|\d+\.)\s+(.*)$`)
+	descriptionItemPattern = regexp.MustCompile(`^\s*(\S.*?)(:{2,4}|;;)(?:\s+(.*))?$`)
+	checkboxPattern        = regexp.MustCompile(`^\[([ xX*])\]\s+(.*)$`)
+	tableCellPattern       = regexp.MustCompile(`^(?:\d*(?:\.\d+)?[+*])?[<^>]?(?:\.[<^>])?[aehlmsv]?\|`)
+	tableCellSpecPattern   = regexp.MustCompile(`\s\d*(?:\.\d+)?[+*][<^>]?(?:\.[<^>])?[aehlmsv]?$`)
+	columnsPattern         = regexp.MustCompile(`^(\d+)\*`)
+	autoIDPattern          = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+	shorthandPattern       = regexp.MustCompile(`[#.%][^#.%]*`)
+)
+
+// admonitionCaptions are the default captions of the admonition blocks
+var admonitionCaptions = map[string]string{
+	"NOTE":      "Note",
+	"TIP":       "Tip",
+	"IMPORTANT": "Important",
+	"WARNING":   "Warning",
+	"CAUTION":   "Caution",
+}
+
+type document struct {
+	urlPrefix    string
+	isWiki       bool
+	attributes   map[string]string
+	ids          common.HeadingIDs
+	anchors      map[string]string
+	anchorTexts  map[string]string
+	headings     []common.Heading
+	footnotes    []string
+	placeholders common.Placeholders
+}
+
+func newDocument(ctx *markup.RenderContext) *document {
+	return &document{
+		urlPrefix:   ctx.URLPrefix,
+		isWiki:      ctx.IsWiki,
+		attributes:  map[string]string{},
+		ids:         common.HeadingIDs{},
+		anchors:     map[string]string{},
+		anchorTexts: map[string]string{},
+	}
+}
+
+// blockAttributes are the attributes, id and title given to the next block
+type blockAttributes struct {
+	id         string
+	title      string
+	positional []string
+	named      map[string]string
+	options    map[string]bool
+}
+
+func (a *blockAttributes) style() string {
+	if len(a.positional) == 0 {
+		return ""
+	}
+	return a.positional[0]
+}
+
+func (a *blockAttributes) parse(text string) {
+	if a.named == nil {
+		a.named = map[string]string{}
+		a.options = map[string]bool{}
+	}
+	for i, part := range splitAttributeList(text) {
+		if idx := strings.IndexByte(part, '='); idx > 0 && isAttributeName(part[:idx]) {
+			name, value := strings.TrimSpace(part[:idx]), unquote(part[idx+1:])
+			a.named[name] = value
+			switch name {
+			case "id":
+				a.id = value
+			case "options", "opts":
+				for _, option := range strings.Split(value, ",") {
+					a.options[strings.TrimSpace(option)] = true
+				}
+			}
+			continue
+		}
+		if i > 0 {
+			a.positional = append(a.positional, unquote(part))
+			continue
+		}
+
+		// the first positional attribute is the style followed by the shorthands of id, roles and options
+		style := part
+		if idx := strings.IndexAny(part, "#.%"); idx >= 0 {
+			style = part[:idx]
+			for _, shorthand := range shorthandPattern.FindAllString(part[idx:], -1) {
+				switch shorthand[0] {
+				case '#':
+					a.id = shorthand[1:]
+				case '%':
+					a.options[shorthand[1:]] = true
+				}
+			}
+		}
+		a.positional = append(a.positional, strings.TrimSpace(style))
+	}
+}
+
+func isAttributeName(name string) bool {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// splitAttributeList splits a comma separated list of attributes, keeping quoted commas
+func splitAttributeList(text string) []string {
+	var parts []string
+	var quote rune
+	start := 0
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			parts = append(parts, strings.TrimSpace(text[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(text[start:]))
+}
+
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+func isComment(line string) bool {
+	return strings.HasPrefix(line, "//") && !strings.HasPrefix(line, "///")
+}
+
+// closingDelimiter returns the index of the line closing the delimited block opened by lines[start]
+func closingDelimiter(lines []string, start int) int {
+	delimiter := strings.TrimRight(lines[start], " \t")
+	if strings.HasPrefix(delimiter, "```") {
+		delimiter = "```"
+	}
+	for i := start + 1; i < len(lines); i++ {
+		if strings.TrimRight(lines[i], " \t") == delimiter {
+			return i
+		}
+	}
+	return len(lines)
+}
+
+func (d *document) render(source string) string {
+	lines := strings.Split(strings.ReplaceAll(source, "\x00", ""), "\n")
+
+	var b strings.Builder
+	lines = d.renderHeader(&b, lines)
+	d.renderBlocks(&b, lines)
+	d.renderFootnotes(&b)
+	return d.placeholders.Restore(b.String())
+}
+
+func (d *document) setAttribute(name, value string) {
+	if strings.HasPrefix(name, "!") || strings.HasSuffix(name, "!") {
+		delete(d.attributes, strings.Trim(name, "!"))
+		return
+	}
+	d.attributes[name] = value
+}
+
+// renderHeader renders the document title and reads the attributes of the document header
+func (d *document) renderHeader(b *strings.Builder, lines []string) []string {
+	i := 0
+	for i < len(lines) && (strings.TrimSpace(lines[i]) == "" || isComment(lines[i])) {
+		i++
+	}
+
+	title := ""
+	if i < len(lines) {
+		if m := headingPattern.FindStringSubmatch(lines[i]); m != nil && len(m[1]) == 1 {
+			title = m[2]
+			i++
+			// skip the author and revision lines
+			for n := 0; n < 2 && i < len(lines) && strings.TrimSpace(lines[i]) != "" &&
+				!attributeEntryPattern.MatchString(lines[i]) && !isComment(lines[i]); n++ {
+				i++
+			}
+		}
+	}
+
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+		if m := attributeEntryPattern.FindStringSubmatch(strings.TrimRight(lines[i], " \t")); m != nil {
+			d.setAttribute(m[1], m[2])
+		} else if !isComment(lines[i]) {
+			break
+		}
+	}
+
+	if title != "" {
+		content := d.inline(title)
+		id := d.ids.Generate(common.PlainText(d.placeholders.Restore(content)))
+		b.WriteString(`<h1 id="` + id + `">` + content + "</h1>\n")
+	}
+	if toc, ok := d.attributes["toc"]; ok && toc != "macro" {
+		b.WriteString(d.toc())
+	}
+	return lines[i:]
+}
+
+// toc returns a placeholder for the table of contents, which is only known once the document is rendered
+func (d *document) toc() string {
+	return d.placeholders.Defer(func() string {
+		levels := 2
+		if value, err := strconv.Atoi(d.attributes["toclevels"]); err == nil && value > 0 {
+			levels = value
+		}
+		headings := make([]common.Heading, 0, len(d.headings))
+		for _, heading := range d.headings {
+			if heading.Level > 1 && heading.Level <= levels+1 {
+				headings = append(headings, heading)
+			}
+		}
+		var toc strings.Builder
+		_ = common.WriteTOC(&toc, headings)
+		return toc.String()
+	})
+}
+
+func (d *document) renderBlocks(b *strings.Builder, lines []string) {
+	var attrs blockAttributes
+	for i := 0; i < len(lines); {
+		line := strings.TrimRight(lines[i], " \t")
+		if line == "" || isComment(line) {
+			i++
+			continue
+		}
+
+		if m := attributeEntryPattern.FindStringSubmatch(line); m != nil {
+			d.setAttribute(m[1], m[2])
+			i++
+			continue
+		}
+		if m := anchorPattern.FindStringSubmatch(line); m != nil {
+			attrs.id = m[1]
+			i++
+			continue
+		}
+		if m := blockTitlePattern.FindStringSubmatch(line); m != nil {
+			attrs.title = m[1]
+			i++
+			continue
+		}
+		if m := blockAttributesPattern.FindStringSubmatch(line); m != nil && !strings.HasPrefix(line, "[[") {
+			attrs.parse(m[1])
+			i++
+			continue
+		}
+
+		switch {
+		case delimiterPattern.MatchString(line):
+			end := closingDelimiter(lines, i)
+			d.renderDelimitedBlock(b, line, lines[i+1:end], &attrs)
+			i = end + 1
+		case tableDelimiterPattern.MatchString(line):
+			end := closingDelimiter(lines, i)
+			d.renderTable(b, lines[i+1:end], &attrs)
+			i = end + 1
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			d.renderHeading(b, len(m[1]), m[2], &attrs)
+			i++
+		case line == "'''":
+			b.WriteString("<hr/>\n")
+			i++
+		case line == "<<<":
+			i++
+		case blockMacroPattern.MatchString(line):
+			m := blockMacroPattern.FindStringSubmatch(line)
+			d.renderBlockMacro(b, m[1], m[2], m[3], &attrs)
+			i++
+		case parseListItem(line) != nil:
+			i = d.renderList(b, lines, i)
+		default:
+			end := i + 1
+			for end < len(lines) {
+				next := strings.TrimRight(lines[end], " \t")
+				if next == "" || delimiterPattern.MatchString(next) || tableDelimiterPattern.MatchString(next) {
+					break
+				}
+				end++
+			}
+			d.renderParagraph(b, lines[i:end], &attrs)
+			i = end
+		}
+		attrs = blockAttributes{}
+	}
+}
+
+func (d *document) idAttribute(attrs *blockAttributes) string {
+	if attrs.id == "" {
+		return ""
+	}
+	id := "user-content-" + attrs.id
+	d.ids[id] = true
+	d.anchors[attrs.id] = id
+	return ` id="` + id + `"`
+}
+
+func (d *document) renderBlockTitle(b *strings.Builder, attrs *blockAttributes) {
+	if attrs.title != "" {
+		b.WriteString("<p><strong>" + d.inline(attrs.title) + "</strong></p>\n")
+	}
+}
+
+func (d *document) renderHeading(b *strings.Builder, level int, title string, attrs *blockAttributes) {
+	content := d.inline(title)
+	text := common.PlainText(d.placeholders.Restore(content))
+
+	var id string
+	if attrs.id != "" {
+		id = "user-content-" + attrs.id
+		d.ids[id] = true
+		d.anchors[attrs.id] = id
+	} else {
+		id = d.ids.Generate(text)
+	}
+	// cross references may use the ids generated by asciidoctor
+	autoID := "_" + strings.Trim(autoIDPattern.ReplaceAllString(strings.ToLower(text), "_"), "_")
+	if _, ok := d.anchors[autoID]; !ok {
+		d.anchors[autoID] = id
+	}
+	d.anchorTexts[id] = text
+
+	d.headings = append(d.headings, common.Heading{Level: level, Text: text, ID: id})
+	b.WriteString(fmt.Sprintf("<h%d id=\"%s\">%s</h%d>\n", level, id, content, level))
+}
+
+func (d *document) renderAdmonition(b *strings.Builder, kind string, content func()) {
+	caption, ok := d.attributes[strings.ToLower(kind)+"-caption"]
+	if !ok {
+		caption = admonitionCaptions[kind]
+	}
+	b.WriteString("<blockquote>\n<p><strong>" + d.inline(caption) + "</strong></p>\n")
+	content()
+	b.WriteString("</blockquote>\n")
+}
+
+func (d *document) renderQuote(b *strings.Builder, attrs *blockAttributes, content func()) {
+	b.WriteString("<blockquote" + d.idAttribute(attrs) + ">\n")
+	content()
+	if len(attrs.positional) > 1 {
+		attribution := make([]string, 0, 2)
+		for _, part := range attrs.positional[1:] {
+			if part != "" {
+				attribution = append(attribution, d.inline(part))
+			}
+		}
+		b.WriteString("<p>— " + strings.Join(attribution, ", ") + "</p>\n")
+	}
+	b.WriteString("</blockquote>\n")
+}
+
+// sourceLanguage returns the language of a source block
+func (d *document) sourceLanguage(attrs *blockAttributes) string {
+	if len(attrs.positional) > 1 {
+		return attrs.positional[1]
+	}
+	if language, ok := attrs.named["language"]; ok {
+		return language
+	}
+	if attrs.style() == "source" {
+		return d.attributes["source-language"]
+	}
+	return ""
+}
+
+func (d *document) renderDelimitedBlock(b *strings.Builder, delimiter string, content []string, attrs *blockAttributes) {
+	style := attrs.style()
+	if delimiter[0] == '/' {
+		return
+	}
+	d.renderBlockTitle(b, attrs)
+
+	if _, ok := admonitionCaptions[style]; ok && delimiter[0] != '-' && delimiter[0] != '.' {
+		d.renderAdmonition(b, style, func() {
+			d.renderBlocks(b, content)
+		})
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(delimiter, "```"):
+		language := strings.TrimSpace(strings.TrimPrefix(delimiter, "```"))
+		if language == "" {
+			language = d.sourceLanguage(attrs)
+		}
+		b.WriteString(common.HighlightCodeBlock(language, strings.Join(content, "\n")))
+	case delimiter == "--":
+		switch style {
+		case "source", "listing":
+			b.WriteString(common.HighlightCodeBlock(d.sourceLanguage(attrs), strings.Join(content, "\n")))
+		case "quote", "verse":
+			d.renderQuote(b, attrs, func() {
+				d.renderBlocks(b, content)
+			})
+		default:
+			b.WriteString("<div" + d.idAttribute(attrs) + ">\n")
+			d.renderBlocks(b, content)
+			b.WriteString("</div>\n")
+		}
+	case delimiter[0] == '-':
+		b.WriteString(common.HighlightCodeBlock(d.sourceLanguage(attrs), strings.Join(content, "\n")))
+	case delimiter[0] == '.':
+		b.WriteString(common.HighlightCodeBlock("", strings.Join(content, "\n")))
+	case delimiter[0] == '_':
+		d.renderQuote(b, attrs, func() {
+			if style == "verse" {
+				b.WriteString("<p>" + strings.ReplaceAll(d.inline(strings.Join(content, "\n")), "\n", "<br/>\n") + "</p>\n")
+			} else {
+				d.renderBlocks(b, content)
+			}
+		})
+	case delimiter[0] == '+':
+		b.WriteString(d.placeholders.Hold(strings.Join(content, "\n")) + "\n")
+	default:
+		// example and sidebar blocks
+		b.WriteString("<div" + d.idAttribute(attrs) + ">\n")
+		d.renderBlocks(b, content)
+		b.WriteString("</div>\n")
+	}
+}
+
+func (d *document) renderParagraph(b *strings.Builder, lines []string, attrs *blockAttributes) {
+	style := attrs.style()
+	text := strings.Join(lines, "\n")
+
+	if m := admonitionPattern.FindStringSubmatch(lines[0]); m != nil && style == "" {
+		style = m[1]
+		text = strings.Join(append([]string{m[2]}, lines[1:]...), "\n")
+	}
+
+	d.renderBlockTitle(b, attrs)
+	if _, ok := admonitionCaptions[style]; ok {
+		d.renderAdmonition(b, style, func() {
+			b.WriteString("<p>" + d.inline(text) + "</p>\n")
+		})
+		return
+	}
+
+	switch {
+	case style == "source" || style == "listing":
+		b.WriteString(common.HighlightCodeBlock(d.sourceLanguage(attrs), text))
+	case style == "literal" || style == "" && (lines[0][0] == ' ' || lines[0][0] == '\t'):
+		b.WriteString(common.HighlightCodeBlock("", dedent(lines)))
+	case style == "quote":
+		d.renderQuote(b, attrs, func() {
+			b.WriteString("<p>" + d.inline(text) + "</p>\n")
+		})
+	case style == "verse":
+		d.renderQuote(b, attrs, func() {
+			b.WriteString("<p>" + strings.ReplaceAll(d.inline(text), "\n", "<br/>\n") + "</p>\n")
+		})
+	default:
+		b.WriteString("<p" + d.idAttribute(attrs) + ">" + d.inline(text) + "</p>\n")
+	}
+}
+
+// dedent removes the indentation shared by all lines
+func dedent(lines []string) string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		result[i] = line
+	}
+	return strings.Join(result, "\n")
+}
+
+func (d *document) imageTarget(target string) string {
+	if dir, ok := d.attributes["imagesdir"]; ok && dir != "" && !strings.Contains(target, "://") && !strings.HasPrefix(target, "/") {
+		return strings.TrimSuffix(dir, "/") + "/" + target
+	}
+	return target
+}
+
+func (d *document) image(target string, attrs *blockAttributes) string {
+	alt := attrs.style()
+	if value, ok := attrs.named["alt"]; ok {
+		alt = value
+	}
+	if alt == "" {
+		alt = target
+	}
+	img := `<img src="` + escape(d.imageTarget(target)) + `" alt="` + escape(alt) + `"`
+	for _, name := range []string{"width", "height"} {
+		value, ok := attrs.named[name]
+		if !ok && len(attrs.positional) > 1 {
+			if name == "width" {
+				value = attrs.positional[1]
+			} else if len(attrs.positional) > 2 {
+				value = attrs.positional[2]
+			}
+		}
+		if value != "" {
+			img += " " + name + `="` + escape(value) + `"`
+		}
+	}
+	img += "/>"
+	if link, ok := attrs.named["link"]; ok {
+		img = `<a href="` + escape(common.ResolveLink(d.urlPrefix, d.isWiki, link)) + `">` + img + "</a>"
+	}
+	return img
+}
+
+func (d *document) renderBlockMacro(b *strings.Builder, name, target, attributeList string, attrs *blockAttributes) {
+	var macroAttrs blockAttributes
+	macroAttrs.parse(attributeList)
+
+	switch name {
+	case "image":
+		d.renderBlockTitle(b, attrs)
+		b.WriteString("<p" + d.idAttribute(attrs) + ">" + d.placeholders.Hold(d.image(target, &macroAttrs)) + "</p>\n")
+	case "toc":
+		if d.attributes["toc"] == "macro" {
+			b.WriteString(d.toc())
+		}
+	default:
+		// included files and media are linked
+		b.WriteString(`<p><a href="` + escape(common.ResolveLink(d.urlPrefix, d.isWiki, target)) + `">` + escape(target) + "</a></p>\n")
+	}
+}
+
+// listItem represents an item of an unordered, ordered or description list
+type listItem struct {
+	kind     string
+	marker   string
+	term     string
+	checkbox string
+	text     []string
+	blocks   []string
+}
+
+func parseListItem(line string) *listItem {
+	if delimiterPattern.MatchString(line) {
+		return nil
+	}
+	if m := unorderedItemPattern.FindStringSubmatch(line); m != nil {
+		item := &listItem{kind: "ul", marker: m[1], text: []string{m[2]}}
+		if c := checkboxPattern.FindStringSubmatch(m[2]); c != nil && m[1] != "-" {
+			item.checkbox = c[1]
+			item.text = []string{c[2]}
+		}
+		return item
+	}
+	if m := orderedItemPattern.FindStringSubmatch(line); m != nil {
+		marker := m[1]
+		if marker[0] != '.' {
+			marker = "."
+		}
+		return &listItem{kind: "ol", marker: marker, text: []string{m[2]}}
+	}
+	if m := descriptionItemPattern.FindStringSubmatch(line); m != nil && !blockMacroPattern.MatchString(line) {
+		item := &listItem{kind: "dl", marker: m[2], term: m[1]}
+		if m[3] != "" {
+			item.text = []string{m[3]}
+		}
+		return item
+	}
+	return nil
+}
+
+// renderList renders the list starting at lines[start] and returns the index of the line following it
+func (d *document) renderList(b *strings.Builder, lines []string, start int) int {
+	var items []*listItem
+	i := start
+loop:
+	for i < len(lines) {
+		line := strings.TrimRight(lines[i], " \t")
+		if item := parseListItem(line); item != nil {
+			items = append(items, item)
+			i++
+			continue
+		}
+
+		current := items[len(items)-1]
+		switch {
+		case line == "":
+			// items may be separated by blank lines
+			next := i
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next == len(lines) || parseListItem(strings.TrimRight(lines[next], " \t")) == nil {
+				break loop
+			}
+			i = next
+		case line == "+":
+			// a list continuation attaches the following block to the item
+			blockStart, blockEnd := i+1, i+1
+			if blockStart < len(lines) && delimiterPattern.MatchString(strings.TrimRight(lines[blockStart], " \t")) {
+				blockEnd = closingDelimiter(lines, blockStart) + 1
+			} else {
+				for blockEnd < len(lines) && strings.TrimSpace(lines[blockEnd]) != "" {
+					blockEnd++
+				}
+			}
+			if blockEnd > len(lines) {
+				blockEnd = len(lines)
+			}
+			current.blocks = append(append(current.blocks, lines[blockStart:blockEnd]...), "")
+			i = blockEnd
+		case isComment(line):
+			i++
+		case delimiterPattern.MatchString(line) || tableDelimiterPattern.MatchString(line) ||
+			headingPattern.MatchString(line) || blockAttributesPattern.MatchString(line) || len(current.blocks) > 0:
+			break loop
+		default:
+			current.text = append(current.text, strings.TrimSpace(line))
+			i++
+		}
+	}
+
+	type level struct {
+		kind   string
+		marker string
+	}
+	closeItem := func(kind string) string {
+		if kind == "dl" {
+			return "</dd>\n"
+		}
+		return "</li>\n"
+	}
+
+	var stack []level
+	for _, item := range items {
+		depth := -1
+		for k, l := range stack {
+			if l.kind == item.kind && l.marker == item.marker {
+				depth = k
+				break
+			}
+		}
+		if depth >= 0 {
+			for len(stack) > depth+1 {
+				top := stack[len(stack)-1]
+				b.WriteString(closeItem(top.kind) + "</" + top.kind + ">\n")
+				stack = stack[:len(stack)-1]
+			}
+			b.WriteString(closeItem(item.kind))
+		} else {
+			stack = append(stack, level{kind: item.kind, marker: item.marker})
+			b.WriteString("<" + item.kind + ">\n")
+		}
+		d.renderListItem(b, item)
+	}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		b.WriteString(closeItem(top.kind) + "</" + top.kind + ">\n")
+		stack = stack[:len(stack)-1]
+	}
+	return i
+}
+
+func (d *document) renderListItem(b *strings.Builder, item *listItem) {
+	text := d.inline(strings.Join(item.text, "\n"))
+	switch {
+	case item.kind == "dl":
+		b.WriteString("<dt>" + d.inline(item.term) + "</dt>\n<dd>")
+		if len(item.text) > 0 {
+			b.WriteString("<p>" + text + "</p>\n")
+		}
+	case item.checkbox != "":
+		checked := ""
+		if item.checkbox != " " {
+			checked = ` checked=""`
+		}
+		b.WriteString(`<li class="task-list-item"><input type="checkbox" disabled=""` + checked + `/>` + text)
+	default:
+		b.WriteString("<li>" + text)
+	}
+	if len(item.blocks) > 0 {
+		b.WriteString("\n")
+		d.renderBlocks(b, item.blocks)
+	}
+}
+
+func (d *document) renderTable(b *strings.Builder, lines []string, attrs *blockAttributes) {
+	columns := 0
+	if cols, ok := attrs.named["cols"]; ok {
+		if m := columnsPattern.FindStringSubmatch(cols); m != nil {
+			columns, _ = strconv.Atoi(m[1])
+		} else {
+			columns = len(strings.Split(cols, ","))
+		}
+	}
+
+	var cells []string
+	header := attrs.options["header"]
+	firstLine := true
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		line = strings.ReplaceAll(line, `\|`, "\uE000")
+		if loc := tableCellPattern.FindStringIndex(line); loc != nil {
+			parts := strings.Split(line[loc[1]:], "|")
+			for _, part := range parts {
+				// strip the specifiers of the following cell
+				if m := tableCellSpecPattern.FindStringIndex(part); m != nil {
+					part = part[:m[0]]
+				}
+				cells = append(cells, part)
+			}
+			if firstLine {
+				if columns == 0 {
+					columns = len(parts)
+				}
+				// a first line followed by a blank line is the header row
+				if !attrs.options["noheader"] && i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == "" {
+					header = true
+				}
+			}
+		} else if len(cells) > 0 {
+			cells[len(cells)-1] += "\n" + line
+		}
+		firstLine = false
+	}
+	if columns == 0 {
+		columns = 1
+	}
+
+	d.renderBlockTitle(b, attrs)
+	b.WriteString("<table" + d.idAttribute(attrs) + ">\n")
+	for row := 0; row*columns < len(cells); row++ {
+		tag := "td"
+		if row == 0 && header {
+			tag = "th"
+			b.WriteString("<thead>\n")
+		} else if row == 0 || row == 1 && header {
+			b.WriteString("<tbody>\n")
+		}
+		b.WriteString("<tr>\n")
+		for col := 0; col < columns; col++ {
+			text := ""
+			if idx := row*columns + col; idx < len(cells) {
+				text = strings.TrimSpace(strings.ReplaceAll(cells[idx], "\uE000", "|"))
+			}
+			b.WriteString("<" + tag + ">" + d.inline(text) + "</" + tag + ">\n")
+		}
+		b.WriteString("</tr>\n")
+		if row == 0 && header {
+			b.WriteString("</thead>\n")
+		}
+	}
+	if len(cells) > columns || len(cells) > 0 && !header {
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n")
+}
+
+func (d *document) renderFootnotes(b *strings.Builder) {
+	if len(d.footnotes) == 0 {
+		return
+	}
+	b.WriteString("<hr/>\n<ol>\n")
+	for i, footnote := range d.footnotes {
+		b.WriteString(fmt.Sprintf("<li id=\"user-content-footnote-%d\">%s</li>\n", i+1, footnote))
+	}
+	b.WriteString("</ol>\n")
+}
@@ -0,0 +1,211 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package asciidoc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/markup/common"
+)
+
+var (
+	attributeReferencePattern = regexp.MustCompile(`\{([\w-]+)\}`)
+	passthroughPattern        = regexp.MustCompile(`\+\+\+(.+?)\+\+\+|pass:\[(.*?)\]`)
+	backslashEscapePattern    = regexp.MustCompile("\\\\([*_`#^~<\\[{+])")
+	entityPattern             = regexp.MustCompile(`&(?:#\d+|#x[0-9a-fA-F]+|[a-zA-Z]\w*);`)
+	literalMonospacePattern   = regexp.MustCompile("`\\+(.+?)\\+`")
+	monospacePattern          = regexp.MustCompile("``(.+?)``|(^|[^\\p{L}\\p{N}_`])`([^`\\s](?:[^`]*?[^`\\s])?)`($|[^\\p{L}\\p{N}_`])")
+	inlinePassthroughPattern  = regexp.MustCompile(`(^|[^\p{L}\p{N}_+])\+([^+\s](?:[^+]*?[^+\s])?)\+($|[^\p{L}\p{N}_+])`)
+	footnotePattern           = regexp.MustCompile(`footnote:[\w-]*\[(.*?)\]`)
+	inlineAnchorPattern       = regexp.MustCompile(`\[\[([\p{L}_:][\w:.-]*)(?:,[^\]]*)?\]\]`)
+	inlineImagePattern        = regexp.MustCompile(`image:([^\s:\[][^\s\[]*)\[(.*?)\]`)
+	xrefPattern               = regexp.MustCompile(`<<([^,>\s]+)(?:,\s*(.*?))?>>|xref:([^\s\[]+)\[(.*?)\]`)
+	linkMacroPattern          = regexp.MustCompile(`link:([^\s\[]+)\[(.*?)\]|((?:https?|ftp|irc)://[^\s\[\]<>]+|mailto:[^\s\[\]<>]+)\[(.*?)\]`)
+	angleURLPattern           = regexp.MustCompile(`<((?:https?|ftp)://[^\s>]+)>`)
+	bareURLPattern            = regexp.MustCompile(`(?:https?|ftp)://[^\s<>\[\]]+`)
+	roleMarkPattern           = regexp.MustCompile(`\[[^\]\s]*\]#([^#]+?)#`)
+	hardBreakPattern          = regexp.MustCompile(`(?m) \+$`)
+	superscriptPattern        = regexp.MustCompile(`\^([^\s^]+?)\^`)
+	subscriptPattern          = regexp.MustCompile(`~([^\s~]+?)~`)
+
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	escaper     = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&#34;", "'", "&#39;")
+)
+
+// builtinAttributes are the predefined attributes for special characters
+var builtinAttributes = map[string]string{
+	"empty":   "",
+	"sp":      " ",
+	"nbsp":    "&#160;",
+	"zwsp":    "&#8203;",
+	"amp":     "&amp;",
+	"lt":      "&lt;",
+	"gt":      "&gt;",
+	"plus":    "&#43;",
+	"apos":    "&#39;",
+	"quot":    "&#34;",
+	"vbar":    "|",
+	"startsb": "[",
+	"endsb":   "]",
+}
+
+// formatting are the quoted text substitutions in the order they are applied
+var formatting = []struct {
+	unconstrained *regexp.Regexp
+	constrained   *regexp.Regexp
+	tag           string
+}{
+	{regexp.MustCompile(`\*\*(.+?)\*\*`), constrainedPattern(`\*`), "strong"},
+	{regexp.MustCompile(`__(.+?)__`), constrainedPattern(`_`), "em"},
+	{regexp.MustCompile(`##(.+?)##`), constrainedPattern(`#`), "mark"},
+}
+
+func constrainedPattern(mark string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[^\p{L}\p{N}_` + mark + `])` + mark + `([^\s` + mark + `](?:[^` + mark + `]*?[^\s` + mark + `])?)` + mark + `($|[^\p{L}\p{N}_` + mark + `])`)
+}
+
+func escape(text string) string {
+	return escaper.Replace(text)
+}
+
+// inline renders the inline markup of a text. Fragments of HTML are kept as placeholders
+// which are restored once the whole document is rendered.
+func (d *document) inline(text string) string {
+	text = attributeReferencePattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := match[1 : len(match)-1]
+		if value, ok := d.attributes[name]; ok {
+			return value
+		}
+		if value, ok := builtinAttributes[name]; ok {
+			return value
+		}
+		return match
+	})
+
+	text = passthroughPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := passthroughPattern.FindStringSubmatch(match)
+		return d.placeholders.Hold(m[1] + m[2])
+	})
+	text = backslashEscapePattern.ReplaceAllStringFunc(text, func(match string) string {
+		return d.placeholders.Hold(textEscaper.Replace(match[1:]))
+	})
+	text = entityPattern.ReplaceAllStringFunc(text, d.placeholders.Hold)
+	text = literalMonospacePattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := literalMonospacePattern.FindStringSubmatch(match)
+		return d.placeholders.Hold("<code>" + textEscaper.Replace(m[1]) + "</code>")
+	})
+	text = common.ReplaceConstrained(monospacePattern, text, func(m []string) string {
+		if m[1] != "" {
+			return d.placeholders.Hold("<code>" + textEscaper.Replace(m[1]) + "</code>")
+		}
+		return m[2] + d.placeholders.Hold("<code>"+textEscaper.Replace(m[3])+"</code>") + m[4]
+	})
+	text = common.ReplaceConstrained(inlinePassthroughPattern, text, func(m []string) string {
+		return m[1] + d.placeholders.Hold(textEscaper.Replace(m[2])) + m[3]
+	})
+
+	text = footnotePattern.ReplaceAllStringFunc(text, func(match string) string {
+		d.footnotes = append(d.footnotes, d.inline(footnotePattern.FindStringSubmatch(match)[1]))
+		n := len(d.footnotes)
+		return d.placeholders.Hold(fmt.Sprintf(`<sup><a href="#user-content-footnote-%d">[%d]</a></sup>`, n, n))
+	})
+	text = inlineAnchorPattern.ReplaceAllStringFunc(text, func(match string) string {
+		id := inlineAnchorPattern.FindStringSubmatch(match)[1]
+		d.anchors[id] = "user-content-" + id
+		return d.placeholders.Hold(`<a id="user-content-` + escape(id) + `"></a>`)
+	})
+	text = inlineImagePattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := inlineImagePattern.FindStringSubmatch(match)
+		var attrs blockAttributes
+		attrs.parse(m[2])
+		return d.placeholders.Hold(d.image(m[1], &attrs))
+	})
+	text = xrefPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := xrefPattern.FindStringSubmatch(match)
+		target, label := m[1]+m[3], m[2]+m[4]
+		if label != "" {
+			label = d.inline(label)
+		}
+		return d.placeholders.Defer(func() string {
+			return d.xref(target, label)
+		})
+	})
+	text = linkMacroPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := linkMacroPattern.FindStringSubmatch(match)
+		target, label := m[1]+m[3], strings.TrimSuffix(m[2]+m[4], "^")
+		if m[1] != "" {
+			target = common.ResolveLink(d.urlPrefix, d.isWiki, target)
+		}
+		if label == "" {
+			label = textEscaper.Replace(strings.TrimPrefix(target, "mailto:"))
+		} else {
+			label = d.inline(label)
+		}
+		return d.placeholders.Hold(`<a href="` + escape(target) + `">` + label + "</a>")
+	})
+	text = angleURLPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return d.placeholders.Hold(textEscaper.Replace(match[1 : len(match)-1]))
+	})
+	text = bareURLPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return d.placeholders.Hold(textEscaper.Replace(match))
+	})
+
+	text = textEscaper.Replace(text)
+
+	text = roleMarkPattern.ReplaceAllString(text, "<mark>$1</mark>")
+	for _, f := range formatting {
+		text = f.unconstrained.ReplaceAllString(text, "<"+f.tag+">$1</"+f.tag+">")
+		text = common.ReplaceConstrained(f.constrained, text, func(m []string) string {
+			return m[1] + "<" + f.tag + ">" + m[2] + "</" + f.tag + ">" + m[3]
+		})
+	}
+	text = superscriptPattern.ReplaceAllString(text, "<sup>$1</sup>")
+	text = subscriptPattern.ReplaceAllString(text, "<sub>$1</sub>")
+	text = hardBreakPattern.ReplaceAllString(text, "<br/>")
+	return text
+}
+
+// xref returns the link of a cross reference to an anchor of the document or another document
+func (d *document) xref(target, label string) string {
+	var href string
+	if path, fragment, isDocument := cutDocumentTarget(target); isDocument {
+		href = common.ResolveLink(d.urlPrefix, d.isWiki, path)
+		if fragment != "" {
+			href += "#user-content-" + fragment
+		}
+		if label == "" {
+			label = textEscaper.Replace(target)
+		}
+	} else {
+		id, ok := d.anchors[target]
+		if !ok {
+			id = "user-content-" + target
+		}
+		href = "#" + id
+		if label == "" {
+			if text, ok := d.anchorTexts[id]; ok {
+				label = textEscaper.Replace(text)
+			} else {
+				label = "[" + textEscaper.Replace(target) + "]"
+			}
+		}
+	}
+	return `<a href="` + escape(href) + `">` + label + "</a>"
+}
+
+// cutDocumentTarget splits the target of a cross reference to another document
+func cutDocumentTarget(target string) (path, fragment string, isDocument bool) {
+	path = target
+	if idx := strings.IndexByte(target, '#'); idx >= 0 {
+		path, fragment = target[:idx], target[idx+1:]
+		isDocument = path != ""
+	}
+	if strings.HasSuffix(path, ".adoc") || strings.HasSuffix(path, ".asciidoc") {
+		isDocument = true
+	}
+	return path, fragment, isDocument
+}
@@ -0,0 +1,196 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/highlight"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/unknwon/i18n"
+)
+
+// The helpers below are shared by the renderers writing HTML directly instead of using goldmark
+
+var (
+	tagPattern         = regexp.MustCompile(`<[^>]*>`)
+	languagePattern    = regexp.MustCompile(`^[\w-]+$`)
+	placeholderPattern = regexp.MustCompile("\x00([0-9]+)\x00")
+)
+
+// Placeholders keep fragments of a document out of the way of the inline markup substitutions.
+// The source of the document must not contain NUL characters.
+type Placeholders []func() string
+
+// Hold returns a placeholder for a fragment of HTML
+func (p *Placeholders) Hold(fragment string) string {
+	return p.Defer(func() string {
+		return fragment
+	})
+}
+
+// Defer returns a placeholder for a fragment of HTML generated when the placeholder is restored
+func (p *Placeholders) Defer(fn func() string) string {
+	*p = append(*p, fn)
+	return fmt.Sprintf("\x00%d\x00", len(*p)-1)
+}
+
+// Restore replaces the placeholders in a text by their fragments
+func (p Placeholders) Restore(text string) string {
+	for i := 0; i < 10 && strings.Contains(text, "\x00"); i++ {
+		text = placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+			idx, err := strconv.Atoi(placeholder[1 : len(placeholder)-1])
+			if err != nil || idx >= len(p) {
+				return ""
+			}
+			return p[idx]()
+		})
+	}
+	return strings.ReplaceAll(text, "\x00", "")
+}
+
+// ReplaceConstrained replaces the matches of a pattern which include the characters surrounding
+// the replaced text, repeating the replacement until adjacent matches are replaced as well
+func ReplaceConstrained(pattern *regexp.Regexp, text string, fn func(m []string) string) string {
+	for i := 0; i < 5; i++ {
+		replaced := pattern.ReplaceAllStringFunc(text, func(match string) string {
+			return fn(pattern.FindStringSubmatch(match))
+		})
+		if replaced == text {
+			break
+		}
+		text = replaced
+	}
+	return text
+}
+
+// Heading represents a heading of a rendered document listed in its table of contents
+type Heading struct {
+	Level int
+	Text  string
+	ID    string
+}
+
+// HeadingIDs generates unique ids for the headings of a rendered document
+type HeadingIDs map[string]bool
+
+// Generate returns a unique id for a heading with the given text
+func (ids HeadingIDs) Generate(text string) string {
+	result := string(CleanValue([]byte(text)))
+	if len(result) == 0 {
+		result = "heading"
+	}
+	result = "user-content-" + result
+	if !ids[result] {
+		ids[result] = true
+		return result
+	}
+	for i := 1; ; i++ {
+		newResult := fmt.Sprintf("%s-%d", result, i)
+		if !ids[newResult] {
+			ids[newResult] = true
+			return newResult
+		}
+	}
+}
+
+// PlainText returns the text of a HTML fragment without its tags
+func PlainText(fragment string) string {
+	return strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(fragment, "")))
+}
+
+// WriteTOC writes the table of contents of the headings of a rendered document
+func WriteTOC(w io.Writer, headings []Heading) error {
+	if len(headings) == 0 {
+		return nil
+	}
+
+	lang := "en-US"
+	if len(setting.Langs) > 0 {
+		lang = setting.Langs[0]
+	}
+
+	var b strings.Builder
+	b.WriteString("<details><summary>" + html.EscapeString(i18n.Tr(lang, "toc")) + "</summary>\n")
+	minLevel := headings[0].Level
+	for _, heading := range headings {
+		if heading.Level < minLevel {
+			minLevel = heading.Level
+		}
+	}
+
+	depth := 0
+	for _, heading := range headings {
+		level := heading.Level - minLevel + 1
+		if depth >= level {
+			b.WriteString("</li>\n")
+			for ; depth > level; depth-- {
+				b.WriteString("</ul>\n</li>\n")
+			}
+		}
+		for depth < level {
+			b.WriteString("<ul>\n")
+			depth++
+			if depth < level {
+				b.WriteString("<li>")
+			}
+		}
+		b.WriteString(`<li><a href="#` + url.PathEscape(heading.ID) + `">` + html.EscapeString(heading.Text) + "</a>")
+	}
+	b.WriteString("</li>\n")
+	for ; depth > 1; depth-- {
+		b.WriteString("</ul>\n</li>\n")
+	}
+	b.WriteString("</ul>\n")
+	b.WriteString("</details>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ResolveLink returns the link target of a rendered document, joining relative links with the url prefix
+func ResolveLink(urlPrefix string, isWiki bool, link string) string {
+	if len(link) == 0 {
+		return link
+	}
+	if link[0] == '#' {
+		if strings.HasPrefix(link, "#user-content-") {
+			return link
+		}
+		return "#user-content-" + link[1:]
+	}
+	if u, err := url.Parse(link); err != nil || u.Scheme != "" || u.Host != "" {
+		return link
+	}
+	if isWiki {
+		link = util.URLJoin("wiki", link)
+	}
+	return util.URLJoin(urlPrefix, link)
+}
+
+// HighlightCodeBlock returns the HTML of a code block highlighted like markdown code blocks
+func HighlightCodeBlock(language, source string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if !languagePattern.MatchString(language) {
+		return `<pre class="code-block"><code>` + html.EscapeString(source) + "</code></pre>\n"
+	}
+
+	code := html.EscapeString(source)
+	if lexer := lexers.Get(language); lexer != nil {
+		code = highlight.CodeFromLexer(chroma.Coalesce(lexer), source)
+	}
+	// include language-x class as part of commonmark spec
+	return `<pre class="code-block"><code class="chroma language-` + language + `">` + code + "</code></pre>\n"
+}
@@ -0,0 +1,239 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package jupyter
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/markup/common"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func init() {
+	markup.RegisterRenderer(Renderer{})
+}
+
+var (
+	ansiEscapePattern = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+	classPattern      = regexp.MustCompile(`^nb-[\w-]+( nb-[\w-]+)*$`)
+	attachmentPattern = regexp.MustCompile(`="[^"]*jupyter-attachment-(\d+)"`)
+)
+
+// imageMimeTypes are the image outputs which are displayed, in order of preference
+var imageMimeTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// Renderer implements markup.Renderer for Jupyter notebooks
+type Renderer struct {
+}
+
+// Name implements markup.Renderer
+func (Renderer) Name() string {
+	return "jupyter"
+}
+
+// NeedPostProcess implements markup.Renderer
+func (Renderer) NeedPostProcess() bool { return true }
+
+// Extensions implements markup.Renderer
+func (Renderer) Extensions() []string {
+	return []string{".ipynb"}
+}
+
+// SanitizerRules implements markup.Renderer
+func (Renderer) SanitizerRules() []setting.MarkupSanitizerRule {
+	return []setting.MarkupSanitizerRule{
+		{Element: "div", AllowAttr: "class", Regexp: classPattern},
+		{Element: "pre", AllowAttr: "class", Regexp: classPattern},
+		{AllowDataURIImages: true},
+	}
+}
+
+// Render renders a Jupyter notebook to HTML
+func Render(ctx *markup.RenderContext, input io.Reader, output io.Writer) error {
+	buf, err := io.ReadAll(input)
+	if err != nil {
+		return err
+	}
+
+	nb, err := ParseNotebook(bytes.NewReader(buf))
+	if err != nil {
+		// show the source of notebooks which can't be read instead of failing the whole page
+		log.Debug("Unable to parse notebook %s: %v", ctx.Filename, err)
+		_, err = io.WriteString(output, common.HighlightCodeBlock("json", string(buf)))
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(`<div class="nb-notebook">` + "\n")
+	for _, cell := range nb.Cells {
+		renderCell(ctx, &b, nb.Language(), cell)
+	}
+	b.WriteString("</div>\n")
+	_, err = io.WriteString(output, b.String())
+	return err
+}
+
+// RenderString renders a Jupyter notebook string to HTML string
+func RenderString(ctx *markup.RenderContext, content string) (string, error) {
+	var buf strings.Builder
+	if err := Render(ctx, strings.NewReader(content), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Render renders a Jupyter notebook to HTML
+func (Renderer) Render(ctx *markup.RenderContext, input io.Reader, output io.Writer) error {
+	return Render(ctx, input, output)
+}
+
+func renderCell(ctx *markup.RenderContext, b *strings.Builder, language string, cell *Cell) {
+	switch cell.CellType {
+	case "markdown":
+		b.WriteString(`<div class="nb-cell nb-markdown-cell">` + "\n")
+		b.WriteString(renderMarkdownCell(ctx, cell))
+		b.WriteString("</div>\n")
+	case "code":
+		b.WriteString(`<div class="nb-cell nb-code-cell">` + "\n")
+		b.WriteString(`<div class="nb-input">` + "\n")
+		b.WriteString(`<div class="nb-prompt">` + prompt("In", cell.ExecutionCount) + "</div>\n")
+		b.WriteString(common.HighlightCodeBlock(language, string(cell.Source)))
+		b.WriteString("</div>\n")
+		for _, output := range cell.Outputs {
+			renderOutput(ctx, b, output)
+		}
+		b.WriteString("</div>\n")
+	default:
+		b.WriteString(`<div class="nb-cell nb-raw-cell">` + "\n")
+		b.WriteString(`<pre class="nb-text">` + html.EscapeString(string(cell.Source)) + "</pre>\n")
+		b.WriteString("</div>\n")
+	}
+}
+
+func prompt(name string, executionCount *int) string {
+	if executionCount == nil {
+		return name + " [ ]:"
+	}
+	return fmt.Sprintf("%s [%d]:", name, *executionCount)
+}
+
+func renderOutput(ctx *markup.RenderContext, b *strings.Builder, output *Output) {
+	switch output.OutputType {
+	case "stream":
+		class := "nb-output nb-stream"
+		if output.Name == "stderr" {
+			class += " nb-stderr"
+		}
+		b.WriteString(`<div class="` + class + `">` + "\n")
+		b.WriteString(`<pre class="nb-text">` + html.EscapeString(ansiEscapePattern.ReplaceAllString(string(output.Text), "")) + "</pre>\n")
+		b.WriteString("</div>\n")
+	case "error":
+		b.WriteString(`<div class="nb-output nb-error">` + "\n")
+		traceback := strings.Join(output.Traceback, "\n")
+		if traceback == "" {
+			traceback = output.EName + ": " + output.EValue
+		}
+		b.WriteString(`<pre class="nb-text">` + html.EscapeString(ansiEscapePattern.ReplaceAllString(traceback, "")) + "</pre>\n")
+		b.WriteString("</div>\n")
+	case "execute_result", "display_data":
+		b.WriteString(`<div class="nb-output">` + "\n")
+		if output.OutputType == "execute_result" {
+			b.WriteString(`<div class="nb-prompt">` + prompt("Out", output.ExecutionCount) + "</div>\n")
+		}
+		b.WriteString(renderData(ctx, output.Data))
+		b.WriteString("</div>\n")
+	}
+}
+
+// renderData renders the richest representation of the data of an output which can be displayed
+func renderData(ctx *markup.RenderContext, data map[string]MultilineString) string {
+	for _, mimeType := range imageMimeTypes {
+		if value, ok := data[mimeType]; ok {
+			return `<img src="` + dataURI(mimeType, value) + `" alt="` + mimeType + `">` + "\n"
+		}
+	}
+	if value, ok := data["text/html"]; ok {
+		return balanceHTML(string(value))
+	}
+	if value, ok := data["text/markdown"]; ok {
+		return renderMarkdown(ctx, string(value))
+	}
+	for _, mimeType := range []string{"text/latex", "text/plain"} {
+		if value, ok := data[mimeType]; ok {
+			return `<pre class="nb-text">` + html.EscapeString(ansiEscapePattern.ReplaceAllString(string(value), "")) + "</pre>\n"
+		}
+	}
+	return ""
+}
+
+func renderMarkdown(ctx *markup.RenderContext, source string) string {
+	rendered, err := markdown.RenderRawString(ctx, source)
+	if err != nil {
+		log.Debug("Unable to render markdown of notebook %s: %v", ctx.Filename, err)
+		return `<pre class="nb-text">` + html.EscapeString(source) + "</pre>\n"
+	}
+	return rendered
+}
+
+// renderMarkdownCell renders a markdown cell with the images attached to it. As the rendered markdown
+// is sanitized without data URIs, the attachments are referenced by tokens replaced after rendering.
+func renderMarkdownCell(ctx *markup.RenderContext, cell *Cell) string {
+	source := string(cell.Source)
+	var uris []string
+	for name, data := range cell.Attachments {
+		for _, mimeType := range imageMimeTypes {
+			if value, ok := data[mimeType]; ok {
+				source = strings.ReplaceAll(source, "attachment:"+name, fmt.Sprintf("jupyter-attachment-%d", len(uris)))
+				uris = append(uris, dataURI(mimeType, value))
+				break
+			}
+		}
+	}
+
+	rendered := renderMarkdown(ctx, source)
+	return attachmentPattern.ReplaceAllStringFunc(rendered, func(match string) string {
+		idx, err := strconv.Atoi(attachmentPattern.FindStringSubmatch(match)[1])
+		if err != nil || idx >= len(uris) {
+			return match
+		}
+		return `="` + uris[idx] + `"`
+	})
+}
+
+func dataURI(mimeType string, value MultilineString) string {
+	return "data:" + mimeType + ";base64," + strings.Join(strings.Fields(string(value)), "")
+}
+
+// balanceHTML parses and re-renders a HTML output, so unbalanced tags of the output can't break the page
+func balanceHTML(fragment string) string {
+	nodes, err := nethtml.ParseFragment(strings.NewReader(fragment), &nethtml.Node{
+		Type:     nethtml.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+	})
+	if err != nil {
+		return `<pre class="nb-text">` + html.EscapeString(fragment) + "</pre>\n"
+	}
+	var b strings.Builder
+	for _, node := range nodes {
+		if err := nethtml.Render(&b, node); err != nil {
+			return `<pre class="nb-text">` + html.EscapeString(fragment) + "</pre>\n"
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package jupyter
+
+import (
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/markup"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNotebook(t *testing.T) {
+	nb, err := ParseNotebook(strings.NewReader(`{"nbformat":4,"metadata":{"kernelspec":{"language":"python"}},"cells":[
+{"cell_type":"code","execution_count":2,"source":["a = 1\n","b = 2"],"outputs":[]}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "python", nb.Language())
+	assert.Len(t, nb.Cells, 1)
+	assert.EqualValues(t, "a = 1\nb = 2", nb.Cells[0].Source)
+	assert.Equal(t, 2, *nb.Cells[0].ExecutionCount)
+
+	_, err = ParseNotebook(strings.NewReader(`{"nbformat":3,"worksheets":[]}`))
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	test := func(input, expected string) {
+		var buf strings.Builder
+		err := markup.Render(&markup.RenderContext{
+			Type:      "jupyter",
+			Filename:  "test.ipynb",
+			URLPrefix: "/user/repo/src/branch/master",
+		}, strings.NewReader(input), &buf)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(buf.String()))
+	}
+
+	test(`{"nbformat":4,"metadata":{"language_info":{"name":"python"}},"cells":[
+{"cell_type":"markdown","source":"# Title\nSome *text*"},
+{"cell_type":"code","execution_count":1,"source":"x","outputs":[
+ {"output_type":"stream","name":"stdout","text":["a < b\n"]},
+ {"output_type":"execute_result","execution_count":1,"data":{"text/plain":"2"}},
+ {"output_type":"display_data","data":{"image/png":"iVBORw0KGgo=\n","text/plain":"<Figure>"}},
+ {"output_type":"display_data","data":{"text/html":"<table><tr><td>x</td></tr></table></div><script>alert(1)</script>"}},
+ {"output_type":"error","ename":"ValueError","evalue":"bad","traceback":["\u001b[0;31mValueError\u001b[0m: bad"]}
+]}]}`, `<div class="nb-notebook">
+<div class="nb-cell nb-markdown-cell">
+<h1 id="user-content-title">Title</h1>
+<p>Some <em>text</em></p>
+</div>
+<div class="nb-cell nb-code-cell">
+<div class="nb-input">
+<div class="nb-prompt">In [1]:</div>
+<pre class="code-block"><code class="chroma language-python"><span class="n">x</span></code></pre>
+</div>
+<div class="nb-output nb-stream">
+<pre class="nb-text">a &lt; b
+</pre>
+</div>
+<div class="nb-output">
+<div class="nb-prompt">Out [1]:</div>
+<pre class="nb-text">2</pre>
+</div>
+<div class="nb-output">
+<img src="data:image/png;base64,iVBORw0KGgo=" alt="image/png"/>
+</div>
+<div class="nb-output">
+<table><tbody><tr><td>x</td></tr></tbody></table>
+</div>
+<div class="nb-output nb-error">
+<pre class="nb-text">ValueError: bad</pre>
+</div>
+</div>
+</div>`)
+
+	test(`{"nbformat":4,"cells":[{"cell_type":"markdown","source":"![plot](attachment:plot.png)",
+"attachments":{"plot.png":{"image/png":"iVBORw0KGgo="}}}]}`, `<div class="nb-notebook">
+<div class="nb-cell nb-markdown-cell">
+<p><a href="data:image/png;base64,iVBORw0KGgo=" target="_blank" rel="nofollow noopener"><img src="data:image/png;base64,iVBORw0KGgo=" alt="plot"/></a></p>
+</div>
+</div>`)
+
+	// invalid notebooks are shown as their source
+	test(`{"cells": [`, `<pre class="code-block"><code class="chroma language-json"><span class="p">{</span><span class="nt">&#34;cells&#34;</span><span class="p">:</span> <span class="p">[</span></code></pre>`)
+}
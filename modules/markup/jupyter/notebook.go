@@ -0,0 +1,85 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package jupyter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MultilineString is a notebook string which is stored either as a string or as a list of lines
+type MultilineString string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *MultilineString) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*s = MultilineString(strings.Join(lines, ""))
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*s = MultilineString(text)
+	return nil
+}
+
+// Output represents an output of a code cell
+type Output struct {
+	OutputType     string                     `json:"output_type"`
+	Name           string                     `json:"name"`
+	Text           MultilineString            `json:"text"`
+	Data           map[string]MultilineString `json:"data"`
+	ExecutionCount *int                       `json:"execution_count"`
+	EName          string                     `json:"ename"`
+	EValue         string                     `json:"evalue"`
+	Traceback      []string                   `json:"traceback"`
+}
+
+// Cell represents a cell of a notebook
+type Cell struct {
+	CellType       string                                `json:"cell_type"`
+	Source         MultilineString                       `json:"source"`
+	ExecutionCount *int                                  `json:"execution_count"`
+	Outputs        []*Output                             `json:"outputs"`
+	Attachments    map[string]map[string]MultilineString `json:"attachments"`
+}
+
+// Notebook represents a Jupyter notebook in the nbformat 4 format
+type Notebook struct {
+	NBFormat int     `json:"nbformat"`
+	Cells    []*Cell `json:"cells"`
+	Metadata struct {
+		KernelSpec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+// Language returns the programming language of the code cells
+func (nb *Notebook) Language() string {
+	if nb.Metadata.LanguageInfo.Name != "" {
+		return nb.Metadata.LanguageInfo.Name
+	}
+	return nb.Metadata.KernelSpec.Language
+}
+
+// ParseNotebook reads a notebook
+func ParseNotebook(r io.Reader) (*Notebook, error) {
+	nb := &Notebook{}
+	if err := json.NewDecoder(r).Decode(nb); err != nil {
+		return nil, err
+	}
+	if nb.NBFormat != 4 {
+		return nil, fmt.Errorf("unsupported notebook format %d", nb.NBFormat)
+	}
+	return nb, nil
+}
@@ -144,3 +144,41 @@ func IsIcon(node ast.Node) bool {
 	_, ok := node.(*Icon)
 	return ok
 }
+
+// Diagram is a block for a code block rendered to an SVG image
+type Diagram struct {
+	ast.BaseBlock
+	Language []byte
+	SVG      []byte
+}
+
+// Dump implements Node.Dump .
+func (n *Diagram) Dump(source []byte, level int) {
+	m := map[string]string{}
+	m["Language"] = string(n.Language)
+	ast.DumpHelper(n, source, level, m, nil)
+}
+
+// KindDiagram is the NodeKind for Diagram
+var KindDiagram = ast.NewNodeKind("Diagram")
+
+// Kind implements Node.Kind.
+func (n *Diagram) Kind() ast.NodeKind {
+	return KindDiagram
+}
+
+// NewDiagram returns a new Diagram node.
+func NewDiagram(language, svg []byte) *Diagram {
+	return &Diagram{
+		BaseBlock: ast.BaseBlock{},
+		Language:  language,
+		SVG:       svg,
+	}
+}
+
+// IsDiagram returns true if the given node implements the Diagram interface,
+// otherwise false.
+func IsDiagram(node ast.Node) bool {
+	_, ok := node.(*Diagram)
+	return ok
+}
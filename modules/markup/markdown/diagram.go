@@ -0,0 +1,217 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	"code.gitea.io/gitea/modules/cache"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/process"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"golang.org/x/net/html/charset"
+)
+
+// svgUnsafeElements are removed together with their content from rendered diagrams
+var svgUnsafeElements = map[string]bool{
+	"script": true,
+	"iframe": true,
+	"object": true,
+	"embed":  true,
+}
+
+var svgTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// getDiagramRenderer returns the enabled diagram renderer of a code block language
+func getDiagramRenderer(language string) *setting.MarkupDiagramRenderer {
+	if language == "" {
+		return nil
+	}
+	for _, renderer := range setting.MarkupDiagram.Renderers {
+		if renderer.Enabled && renderer.Language == language {
+			return renderer
+		}
+	}
+	return nil
+}
+
+// transformDiagram replaces a fenced code block by the diagram rendered from it.
+// The code block is kept if the diagram can't be rendered.
+func transformDiagram(pc parser.Context, block *ast.FencedCodeBlock, source []byte) {
+	language := block.Language(source)
+	renderer := getDiagramRenderer(string(language))
+	if renderer == nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	lines := block.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		buf.Write(segment.Value(source))
+	}
+
+	ctx, _ := pc.Get(renderContextKey).(context.Context)
+	svg, err := renderDiagram(ctx, renderer, buf.Bytes())
+	if err != nil {
+		log.Debug("Unable to render %s diagram: %v", renderer.Language, err)
+		return
+	}
+
+	parent := block.Parent()
+	parent.ReplaceChild(parent, block, NewDiagram([]byte(renderer.Language), svg))
+}
+
+// renderDiagram renders the source of a diagram to sanitized SVG. The results are cached
+// by the hash of the render command and the source.
+func renderDiagram(ctx context.Context, renderer *setting.MarkupDiagramRenderer, source []byte) ([]byte, error) {
+	if setting.MarkupDiagram.MaxSourceCharacters >= 0 && utf8.RuneCount(source) > setting.MarkupDiagram.MaxSourceCharacters {
+		return nil, fmt.Errorf("diagram source exceeds %d characters", setting.MarkupDiagram.MaxSourceCharacters)
+	}
+
+	hash := sha256.New()
+	_, _ = hash.Write([]byte(renderer.Command))
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write(source)
+
+	svg, err := cache.GetString(ctx, "MarkdownDiagram:"+hex.EncodeToString(hash.Sum(nil)), func() (string, error) {
+		output, err := runDiagramRenderer(ctx, renderer, source)
+		if err != nil {
+			return "", err
+		}
+		svg, err := sanitizeSVG(bytes.NewReader(output))
+		if err != nil {
+			return "", err
+		}
+		return string(svg), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(svg), nil
+}
+
+// runDiagramRenderer runs the render command with the diagram source on stdin and returns its output
+func runDiagramRenderer(ctx context.Context, renderer *setting.MarkupDiagramRenderer, source []byte) ([]byte, error) {
+	if ctx == nil {
+		ctx = graceful.GetManager().ShutdownContext()
+	}
+
+	var cancel context.CancelFunc
+	if setting.MarkupDiagram.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, setting.MarkupDiagram.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	commands := strings.Fields(renderer.Command)
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("render command of %s diagrams is empty", renderer.Language)
+	}
+	pid := process.GetManager().Add(fmt.Sprintf("Render %s diagram [%s]", renderer.Language, commands[0]), cancel)
+	defer process.GetManager().Remove(pid)
+
+	var stdout bytes.Buffer
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, commands[0], commands[1:]...)
+	cmd.Stdin = bytes.NewReader(source)
+	cmd.Stdout = &limitWriter{
+		w:     &stdout,
+		limit: setting.UI.MaxDisplayFileSize,
+	}
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("command %s timed out after %v", commands[0], setting.MarkupDiagram.Timeout)
+		}
+		return nil, fmt.Errorf("command %s failed: %v - %s", commands[0], err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+// sanitizeSVG rewrites an SVG document without scripts, event handlers, embedded documents
+// and references to external resources
+func sanitizeSVG(input io.Reader) ([]byte, error) {
+	decoder := xml.NewDecoder(input)
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	var buf bytes.Buffer
+	depth, skipDepth := 0, 0
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if skipDepth > 0 {
+				continue
+			}
+			if depth == 1 && t.Name.Local != "svg" {
+				return nil, fmt.Errorf("unexpected root element <%s>", t.Name.Local)
+			}
+			if svgUnsafeElements[strings.ToLower(t.Name.Local)] {
+				skipDepth = depth
+				continue
+			}
+			buf.WriteString("<" + xmlName(t.Name))
+			for _, attr := range t.Attr {
+				local := strings.ToLower(attr.Name.Local)
+				if strings.HasPrefix(local, "on") ||
+					local == "href" && !strings.HasPrefix(attr.Value, "#") {
+					continue
+				}
+				buf.WriteString(" " + xmlName(attr.Name) + `="`)
+				_ = xml.EscapeText(&buf, []byte(attr.Value))
+				buf.WriteString(`"`)
+			}
+			buf.WriteString(">")
+		case xml.EndElement:
+			depth--
+			if skipDepth > 0 {
+				if depth < skipDepth {
+					skipDepth = 0
+				}
+				continue
+			}
+			buf.WriteString("</" + xmlName(t.Name) + ">")
+		case xml.CharData:
+			if skipDepth == 0 && depth > 0 {
+				buf.WriteString(svgTextEscaper.Replace(string(t)))
+			}
+		}
+	}
+
+	if buf.Len() == 0 {
+		return nil, fmt.Errorf("no SVG document found")
+	}
+	return buf.Bytes(), nil
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
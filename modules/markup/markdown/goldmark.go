@@ -59,6 +59,7 @@ func (g *ASTTransformer) Transform(node *ast.Document, reader text.Reader, pc pa
 		toc = make([]Header, 0, 100)
 	}
 
+	diagrams := make([]*ast.FencedCodeBlock, 0, 5)
 	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
 			return ast.WalkContinue, nil
@@ -185,6 +186,10 @@ func (g *ASTTransformer) Transform(node *ast.Document, reader text.Reader, pc pa
 					v.AppendChild(v, newChild)
 				}
 			}
+		case *ast.FencedCodeBlock:
+			if getDiagramRenderer(string(v.Language(reader.Source()))) != nil {
+				diagrams = append(diagrams, v)
+			}
 		case *ast.Text:
 			if v.SoftLineBreak() && !v.HardLineBreak() {
 				renderMetas := pc.Get(renderMetasKey).(map[string]string)
@@ -199,6 +204,10 @@ func (g *ASTTransformer) Transform(node *ast.Document, reader text.Reader, pc pa
 		return ast.WalkContinue, nil
 	})
 
+	for _, block := range diagrams {
+		transformDiagram(pc, block, reader.Source())
+	}
+
 	if createTOC && len(toc) > 0 {
 		lang := rc.Lang
 		if len(lang) == 0 {
@@ -285,6 +294,7 @@ func (r *HTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
 	reg.Register(KindDetails, r.renderDetails)
 	reg.Register(KindSummary, r.renderSummary)
 	reg.Register(KindIcon, r.renderIcon)
+	reg.Register(KindDiagram, r.renderDiagram)
 	reg.Register(KindTaskCheckBoxListItem, r.renderTaskCheckBoxListItem)
 	reg.Register(east.KindTaskCheckBox, r.renderTaskCheckBox)
 }
@@ -375,6 +385,21 @@ func (r *HTMLRenderer) renderIcon(w util.BufWriter, source []byte, node ast.Node
 	return ast.WalkContinue, nil
 }
 
+func (r *HTMLRenderer) renderDiagram(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*Diagram)
+	_, err := w.WriteString(fmt.Sprintf(`<p><img alt="%s" src="%s"></p>`,
+		util.EscapeHTML(n.Language), markup.SignedSVGDataURI(n.SVG)))
+	if err != nil {
+		return ast.WalkStop, err
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
 func (r *HTMLRenderer) renderTaskCheckBoxListItem(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
 	n := node.(*TaskCheckBoxListItem)
 	if entering {
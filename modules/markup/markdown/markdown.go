@@ -34,6 +34,7 @@ var once = sync.Once{}
 var urlPrefixKey = parser.NewContextKey()
 var isWikiKey = parser.NewContextKey()
 var renderMetasKey = parser.NewContextKey()
+var renderContextKey = parser.NewContextKey()
 
 type limitWriter struct {
 	w     io.Writer
@@ -63,6 +64,7 @@ func newParserContext(ctx *markup.RenderContext) parser.Context {
 	pc.Set(urlPrefixKey, ctx.URLPrefix)
 	pc.Set(isWikiKey, ctx.IsWiki)
 	pc.Set(renderMetasKey, ctx.Metas)
+	pc.Set(renderContextKey, ctx.Ctx)
 	return pc
 }
 
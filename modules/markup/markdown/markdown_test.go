@@ -5,6 +5,8 @@
 package markdown_test
 
 import (
+	"encoding/base64"
+	"os/exec"
 	"strings"
 	"testing"
 
@@ -393,3 +395,55 @@ func TestRenderSiblingImages_Issue12925(t *testing.T) {
 	assert.Equal(t, expected, res)
 
 }
+
+func TestRender_Diagrams(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat is required to fake a diagram renderer")
+	}
+
+	defer func(renderers []*setting.MarkupDiagramRenderer) {
+		setting.MarkupDiagram.Renderers = renderers
+		markup.InitializeSanitizer()
+	}(setting.MarkupDiagram.Renderers)
+	setting.MarkupDiagram.Renderers = []*setting.MarkupDiagramRenderer{
+		{Enabled: true, Language: "svg", Command: "cat"},
+		{Enabled: true, Language: "broken", Command: "false"},
+		{Enabled: false, Language: "disabled", Command: "cat"},
+		{Enabled: true, Language: "blank", Command: " "},
+	}
+	markup.InitializeSanitizer()
+
+	test := func(input, expected string) {
+		buffer, err := RenderString(&markup.RenderContext{
+			URLPrefix: AppSubURL,
+		}, input)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(buffer))
+	}
+
+	svg := `<?xml version="1.0"?>
+<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd">
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 10 10" onload="alert(1)">
+<script>alert(1)</script><a xlink:href="https://example.com"><rect width="10" height="10"/></a><use xlink:href="#id"/><text>a &lt; b&nbsp;</text>
+</svg>`
+	sanitized := `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 10 10">
+<a><rect width="10" height="10"></rect></a><use xlink:href="#id"></use><text>a &lt; b` + " " + `</text>
+</svg>`
+	test("```svg\n"+svg+"\n```",
+		`<p><img alt="svg" src="`+markup.SignedSVGDataURI([]byte(sanitized))+`"/></p>`)
+
+	// the data URIs written by users are not sanitized, so they are removed
+	forged := "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(svg))
+	test(`<img alt="svg" src="`+forged+`">`, `<img alt="svg"/>`)
+	test(`<img alt="svg" src="`+forged+`#0123">`, `<img alt="svg"/>`)
+	test("[svg]("+forged+")", `<p>svg</p>`)
+
+	test("```broken\ngraph\n```", `<pre class="code-block"><code class="chroma language-broken">graph
+</code></pre>`)
+	test("```disabled\n<svg></svg>\n```", `<pre class="code-block"><code class="chroma language-disabled">&lt;svg&gt;&lt;/svg&gt;
+</code></pre>`)
+	test("```blank\n<svg></svg>\n```", `<pre class="code-block"><code class="chroma language-blank">&lt;svg&gt;&lt;/svg&gt;
+</code></pre>`)
+	test("```svg\n<html></html>\n```", `<pre class="code-block"><code class="chroma language-svg"><span class="nt">&lt;html&gt;&lt;/html&gt;</span>
+</code></pre>`)
+}
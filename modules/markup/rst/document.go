@@ -0,0 +1,907 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package rst
+
+import (
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/markup/common"
+)
+
+// The renderer supports the subset of reStructuredText commonly used in READMEs and
+// documentation: sections, transitions, paragraphs, lists, literal and quoted blocks,
+// line blocks, the common directives, hyperlink targets, substitutions, footnotes,
+// simple and grid tables and the inline markup. Raw and include directives are ignored.
+
+var (
+	bulletItemPattern      = regexp.MustCompile(`^([-*+•‣⁃])(?: +(.*))?$`)
+	enumeratedItemPattern  = regexp.MustCompile(`^(\(?)(\d+|#|[a-zA-Z]|[ivxlcdm]+|[IVXLCDM]+)([.)])(?: +(.*))?$`)
+	fieldPattern           = regexp.MustCompile(`^:([^:\s][^:]*?):(?:\s+(.*))?$`)
+	explicitMarkupPattern  = regexp.MustCompile(`^\.\.(?:\s+(.*))?$`)
+	targetPattern          = regexp.MustCompile("^_(`[^`]+`|[^:]+):(?:\\s+(.*))?$")
+	anonymousTargetPattern = regexp.MustCompile(`^(?:\.\. __:|__)(?:\s+(.*))?$`)
+	substitutionDefPattern = regexp.MustCompile(`^\|([^|]+)\|\s+([\w-]+)::(?:\s+(.*))?$`)
+	footnoteDefPattern     = regexp.MustCompile(`^\[(#[\w-]*|\d+|\*|[\p{L}_][\w.-]*)\](?:\s+(.*))?$`)
+	directivePattern       = regexp.MustCompile(`^([\w:-]+)::(?:\s+(.*))?$`)
+	optionPattern          = regexp.MustCompile(`^:([\w-]+):(?:\s+(.*))?$`)
+	lineBlockPattern       = regexp.MustCompile(`^\|(?: (.*))?$`)
+	simpleTableBorder      = regexp.MustCompile(`^=+(?: +=+)+$`)
+	simpleTableSpanBorder  = regexp.MustCompile(`^-+(?: +-+)*$`)
+	gridTableBorder        = regexp.MustCompile(`^\+(?:[-=]+\+)+$`)
+)
+
+// admonitionCaptions are the captions of the admonition directives
+var admonitionCaptions = map[string]string{
+	"attention": "Attention",
+	"caution":   "Caution",
+	"danger":    "Danger",
+	"error":     "Error",
+	"hint":      "Hint",
+	"important": "Important",
+	"note":      "Note",
+	"tip":       "Tip",
+	"warning":   "Warning",
+}
+
+// substitution is the definition of a substitution referenced as |name|
+type substitution struct {
+	directive string
+	argument  string
+	options   map[string]string
+	rendering bool
+}
+
+type document struct {
+	urlPrefix         string
+	isWiki            bool
+	language          string
+	ids               common.HeadingIDs
+	styles            []string
+	sections          map[string]string
+	headings          []common.Heading
+	targets           map[string]string
+	aliases           map[string]string
+	anonymousTargets  []string
+	anonymousRefs     int
+	substitutions     map[string]*substitution
+	footnoteLabels    map[string]int
+	autoFootnotes     []int
+	autoFootnoteDefs  int
+	autoFootnoteRefs  int
+	placeholders      common.Placeholders
+	explicitFootnotes map[int]bool
+}
+
+func newDocument(ctx *markup.RenderContext) *document {
+	return &document{
+		urlPrefix:         ctx.URLPrefix,
+		isWiki:            ctx.IsWiki,
+		ids:               common.HeadingIDs{},
+		sections:          map[string]string{},
+		targets:           map[string]string{},
+		aliases:           map[string]string{},
+		substitutions:     map[string]*substitution{},
+		footnoteLabels:    map[string]int{},
+		explicitFootnotes: map[int]bool{},
+	}
+}
+
+// normalizeName returns the reference name of a target, which is case and whitespace insensitive
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// anchorID returns the id of an internal hyperlink target
+func anchorID(name string) string {
+	return "user-content-" + string(common.CleanValue([]byte(name)))
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// isAdornment returns whether a line is a section title adornment or a transition
+func isAdornment(line string) bool {
+	if len(line) < 2 || !strings.ContainsRune("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", rune(line[0])) {
+		return false
+	}
+	return strings.Count(line, line[:1]) == len(line)
+}
+
+// blockEnd returns the end of the lines following start which are indented at least by the given
+// indentation, including the blank lines between them
+func blockEnd(lines []string, start, indent int) int {
+	end := start
+	for i := start; i < len(lines); i++ {
+		if isBlank(lines[i]) {
+			continue
+		}
+		if indentation(lines[i]) < indent {
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
+
+// dedent removes the common indentation of lines
+func dedent(lines []string) []string {
+	indent := -1
+	for _, line := range lines {
+		if isBlank(line) {
+			continue
+		}
+		if i := indentation(line); indent < 0 || i < indent {
+			indent = i
+		}
+	}
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		result[i] = strings.TrimRight(line, " \t")
+	}
+	return result
+}
+
+func (d *document) render(source string) string {
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(source, "\x00", ""), "\t", "        "), "\n")
+	d.collect(lines)
+
+	var b strings.Builder
+	d.renderBlocks(&b, lines, false)
+	return d.placeholders.Restore(b.String())
+}
+
+// collect reads the hyperlink targets, substitution definitions and footnote labels of the document,
+// which may be referenced before they are defined
+func (d *document) collect(lines []string) {
+	var footnotes []string
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if m := anonymousTargetPattern.FindStringSubmatch(line); m != nil {
+			d.anonymousTargets = append(d.anonymousTargets, d.continuedURI(lines, i, m[1]))
+			continue
+		}
+		m := explicitMarkupPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		text := m[1]
+		if m := targetPattern.FindStringSubmatch(text); m != nil {
+			name := normalizeName(strings.Trim(m[1], "`"))
+			uri := d.continuedURI(lines, i, m[2])
+			switch {
+			case uri == "":
+				d.targets[name] = "#" + anchorID(name)
+			case strings.HasSuffix(uri, "_") && !strings.HasSuffix(uri, `\_`):
+				d.aliases[name] = normalizeName(strings.Trim(strings.TrimSuffix(uri, "_"), "`"))
+			default:
+				d.targets[name] = strings.ReplaceAll(uri, `\_`, "_")
+			}
+		} else if m := substitutionDefPattern.FindStringSubmatch(text); m != nil {
+			options, _ := parseOptions(dedent(lines[i+1 : blockEnd(lines, i+1, indentation(lines[i])+1)]))
+			d.substitutions[normalizeName(m[1])] = &substitution{
+				directive: m[2],
+				argument:  m[3],
+				options:   options,
+			}
+		} else if m := footnoteDefPattern.FindStringSubmatch(text); m != nil {
+			footnotes = append(footnotes, m[1])
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				d.explicitFootnotes[n] = true
+			}
+		}
+	}
+
+	// auto-numbered footnotes take the numbers not used by the explicitly numbered ones
+	n := 0
+	for _, label := range footnotes {
+		if label[0] != '#' {
+			continue
+		}
+		for n++; d.explicitFootnotes[n]; n++ {
+		}
+		if label == "#" {
+			d.autoFootnotes = append(d.autoFootnotes, n)
+		} else {
+			d.footnoteLabels[label[1:]] = n
+		}
+	}
+}
+
+// continuedURI returns the URI of a hyperlink target, which may be continued on the following indented lines
+func (d *document) continuedURI(lines []string, i int, uri string) string {
+	parts := []string{strings.TrimSpace(uri)}
+	indent := indentation(lines[i])
+	for j := i + 1; j < len(lines) && !isBlank(lines[j]) && indentation(lines[j]) > indent; j++ {
+		parts = append(parts, strings.TrimSpace(lines[j]))
+	}
+	return strings.Join(parts, "")
+}
+
+// parseOptions splits the content of a directive into its options and its body
+func parseOptions(lines []string) (map[string]string, []string) {
+	options := map[string]string{}
+	i := 0
+	for ; i < len(lines); i++ {
+		m := optionPattern.FindStringSubmatch(lines[i])
+		if m == nil {
+			break
+		}
+		options[m[1]] = strings.TrimSpace(m[2])
+	}
+	for i < len(lines) && isBlank(lines[i]) {
+		i++
+	}
+	return options, lines[i:]
+}
+
+// renderBlocks renders the body elements of lines. The first paragraph of compact lines,
+// like the one of a list item, is rendered without a paragraph element.
+func (d *document) renderBlocks(b *strings.Builder, lines []string, compact bool) {
+	for i := 0; i < len(lines); {
+		line := strings.TrimRight(lines[i], " \t")
+		if line == "" {
+			i++
+			continue
+		}
+
+		var next string
+		if i+1 < len(lines) {
+			next = strings.TrimRight(lines[i+1], " \t")
+		}
+
+		switch {
+		case indentation(line) > 0:
+			end := blockEnd(lines, i, 1)
+			b.WriteString("<blockquote>\n")
+			d.renderBlocks(b, dedent(lines[i:end]), false)
+			b.WriteString("</blockquote>\n")
+			i = end
+		case isAdornment(line) && i+2 < len(lines) && !isBlank(next) &&
+			strings.TrimRight(lines[i+2], " \t") == line:
+			d.renderSection(b, "over"+line[:1], strings.TrimSpace(next))
+			i += 3
+		case isAdornment(next) && !isAdornment(line) &&
+			(len(next) >= 4 || len(next) >= utf8.RuneCountInString(line)):
+			d.renderSection(b, next[:1], line)
+			i += 2
+		case isAdornment(line) && len(line) >= 4 && isBlank(next):
+			b.WriteString("<hr/>\n")
+			i++
+		case explicitMarkupPattern.MatchString(line):
+			i = d.renderExplicitMarkup(b, lines, i)
+		case anonymousTargetPattern.MatchString(line):
+			i = blockEnd(lines, i+1, 1)
+		case gridTableBorder.MatchString(line):
+			i = d.renderGridTable(b, lines, i)
+		case simpleTableBorder.MatchString(line):
+			i = d.renderSimpleTable(b, lines, i)
+		case parseListItem(line) != nil:
+			i = d.renderList(b, lines, i)
+		case fieldPattern.MatchString(line):
+			i = d.renderFieldList(b, lines, i)
+		case lineBlockPattern.MatchString(line):
+			i = d.renderLineBlock(b, lines, i)
+		case strings.HasPrefix(line, ">>>"):
+			end := i
+			for end < len(lines) && !isBlank(lines[end]) {
+				end++
+			}
+			b.WriteString(common.HighlightCodeBlock("python", strings.Join(lines[i:end], "\n")))
+			i = end
+		case next != "" && indentation(next) > 0:
+			i = d.renderDefinitionList(b, lines, i)
+		default:
+			i = d.renderParagraph(b, lines, i, compact)
+		}
+		compact = false
+	}
+}
+
+func (d *document) renderSection(b *strings.Builder, style, title string) {
+	level := 0
+	for i, s := range d.styles {
+		if s == style {
+			level = i + 1
+			break
+		}
+	}
+	if level == 0 {
+		d.styles = append(d.styles, style)
+		level = len(d.styles)
+	}
+	if level > 6 {
+		level = 6
+	}
+
+	content := d.inline(title)
+	text := common.PlainText(d.placeholders.Restore(content))
+	id := d.ids.Generate(text)
+	if _, ok := d.sections[normalizeName(text)]; !ok {
+		d.sections[normalizeName(text)] = id
+	}
+	d.headings = append(d.headings, common.Heading{Level: level, Text: text, ID: id})
+	b.WriteString(fmt.Sprintf("<h%d id=\"%s\">%s</h%d>\n", level, id, content, level))
+}
+
+// toc returns a placeholder for the table of contents, which is only known once the document is rendered
+func (d *document) toc(depth int) string {
+	return d.placeholders.Defer(func() string {
+		headings := d.headings
+		// a single top level section is the title of the document
+		if len(headings) > 0 && headings[0].Level == 1 {
+			titles := 0
+			for _, heading := range headings {
+				if heading.Level == 1 {
+					titles++
+				}
+			}
+			if titles == 1 {
+				headings = headings[1:]
+			}
+		}
+		if len(headings) == 0 {
+			return ""
+		}
+
+		minLevel := headings[0].Level
+		for _, heading := range headings {
+			if heading.Level < minLevel {
+				minLevel = heading.Level
+			}
+		}
+		filtered := make([]common.Heading, 0, len(headings))
+		for _, heading := range headings {
+			if depth <= 0 || heading.Level-minLevel < depth {
+				filtered = append(filtered, heading)
+			}
+		}
+		var toc strings.Builder
+		_ = common.WriteTOC(&toc, filtered)
+		return toc.String()
+	})
+}
+
+func (d *document) renderParagraph(b *strings.Builder, lines []string, i int, compact bool) int {
+	end := i + 1
+	for end < len(lines) && !isBlank(lines[end]) && indentation(lines[end]) == 0 {
+		end++
+	}
+	text := strings.TrimSpace(strings.Join(lines[i:end], "\n"))
+
+	// a paragraph ending with :: introduces a literal block
+	literal := strings.HasSuffix(text, "::")
+	if literal {
+		switch {
+		case text == "::":
+			text = ""
+		case strings.HasSuffix(text, " ::"):
+			text = strings.TrimRight(strings.TrimSuffix(text, "::"), " ")
+		default:
+			text = strings.TrimSuffix(text, ":")
+		}
+	}
+
+	if text != "" {
+		if compact {
+			b.WriteString(d.inline(text))
+		} else {
+			b.WriteString("<p>" + d.inline(text) + "</p>\n")
+		}
+	}
+
+	if !literal {
+		return end
+	}
+	start := end
+	for start < len(lines) && isBlank(lines[start]) {
+		start++
+	}
+	if start == len(lines) || indentation(lines[start]) == 0 {
+		return end
+	}
+	blockEnd := blockEnd(lines, start, 1)
+	b.WriteString(common.HighlightCodeBlock(d.language, strings.Join(dedent(lines[start:blockEnd]), "\n")))
+	return blockEnd
+}
+
+// listItem is the first line of an item of a bullet or enumerated list
+type listItem struct {
+	kind   string
+	text   string
+	indent int
+}
+
+func parseListItem(line string) *listItem {
+	if m := bulletItemPattern.FindStringSubmatch(line); m != nil {
+		return &listItem{
+			kind:   m[1],
+			text:   m[2],
+			indent: len(line) - len(m[2]),
+		}
+	}
+	if m := enumeratedItemPattern.FindStringSubmatch(line); m != nil {
+		if m[1] == "(" && m[3] != ")" {
+			return nil
+		}
+		// a single letter or a roman numeral followed by a period needs some text to be an item
+		if _, err := strconv.Atoi(m[2]); err != nil && m[2] != "#" && m[4] == "" {
+			return nil
+		}
+		return &listItem{
+			kind:   m[1] + m[3],
+			text:   m[4],
+			indent: len(line) - len(m[4]),
+		}
+	}
+	return nil
+}
+
+// listItemLines returns the lines of the list item starting at i and the end of the item
+func listItemLines(lines []string, i int, item *listItem) ([]string, int) {
+	indent := item.indent
+	if item.text == "" {
+		indent = 1
+		for j := i + 1; j < len(lines); j++ {
+			if !isBlank(lines[j]) {
+				if indentation(lines[j]) > 0 {
+					indent = indentation(lines[j])
+				}
+				break
+			}
+		}
+	}
+	end := blockEnd(lines, i+1, indent)
+	body := []string{item.text}
+	for _, line := range lines[i+1 : end] {
+		if len(line) >= indent {
+			line = line[indent:]
+		} else {
+			line = strings.TrimLeft(line, " ")
+		}
+		body = append(body, line)
+	}
+	return body, end
+}
+
+func (d *document) renderList(b *strings.Builder, lines []string, i int) int {
+	first := parseListItem(lines[i])
+	tag := "ol"
+	if bulletItemPattern.MatchString(lines[i]) {
+		tag = "ul"
+	}
+
+	b.WriteString("<" + tag + ">\n")
+	for i < len(lines) {
+		item := parseListItem(lines[i])
+		if item == nil || item.kind != first.kind {
+			break
+		}
+		body, end := listItemLines(lines, i, item)
+		b.WriteString("<li>")
+		d.renderBlocks(b, body, true)
+		b.WriteString("</li>\n")
+
+		i = end
+		for i < len(lines) && isBlank(lines[i]) {
+			i++
+		}
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func (d *document) renderFieldList(b *strings.Builder, lines []string, i int) int {
+	b.WriteString("<dl>\n")
+	for i < len(lines) {
+		m := fieldPattern.FindStringSubmatch(lines[i])
+		if m == nil {
+			break
+		}
+		end := blockEnd(lines, i+1, 1)
+		body := append([]string{m[2]}, dedent(lines[i+1:end])...)
+		b.WriteString("<dt>" + d.inline(m[1]) + "</dt>\n<dd>")
+		d.renderBlocks(b, body, true)
+		b.WriteString("</dd>\n")
+
+		i = end
+		for i < len(lines) && isBlank(lines[i]) {
+			i++
+		}
+	}
+	b.WriteString("</dl>\n")
+	return i
+}
+
+func (d *document) renderDefinitionList(b *strings.Builder, lines []string, i int) int {
+	b.WriteString("<dl>\n")
+	for i+1 < len(lines) {
+		term := strings.TrimSpace(lines[i])
+		if term == "" || indentation(lines[i]) > 0 || isBlank(lines[i+1]) || indentation(lines[i+1]) == 0 {
+			break
+		}
+		// the classifiers following the term are not rendered
+		if idx := strings.Index(term, " : "); idx > 0 {
+			term = term[:idx]
+		}
+		end := blockEnd(lines, i+1, 1)
+		b.WriteString("<dt>" + d.inline(term) + "</dt>\n<dd>")
+		d.renderBlocks(b, dedent(lines[i+1:end]), false)
+		b.WriteString("</dd>\n")
+
+		i = end
+		for i < len(lines) && isBlank(lines[i]) {
+			i++
+		}
+	}
+	b.WriteString("</dl>\n")
+	return i
+}
+
+func (d *document) renderLineBlock(b *strings.Builder, lines []string, i int) int {
+	var parts []string
+	for ; i < len(lines) && !isBlank(lines[i]); i++ {
+		if m := lineBlockPattern.FindStringSubmatch(lines[i]); m != nil {
+			parts = append(parts, m[1])
+		} else if len(parts) > 0 {
+			// continuation lines are indented
+			parts[len(parts)-1] += " " + strings.TrimSpace(lines[i])
+		}
+	}
+	for j, part := range parts {
+		parts[j] = d.inline(part)
+	}
+	b.WriteString("<p>" + strings.Join(parts, "<br/>\n") + "</p>\n")
+	return i
+}
+
+// renderExplicitMarkup renders the explicit markup block starting at i:
+// footnotes, hyperlink targets, directives, substitution definitions and comments
+func (d *document) renderExplicitMarkup(b *strings.Builder, lines []string, i int) int {
+	text := explicitMarkupPattern.FindStringSubmatch(strings.TrimRight(lines[i], " \t"))[1]
+	end := blockEnd(lines, i+1, 1)
+	content := dedent(lines[i+1 : end])
+
+	switch {
+	case text == "":
+		// comment
+	case targetPattern.MatchString(text):
+		m := targetPattern.FindStringSubmatch(text)
+		if strings.TrimSpace(m[2]) == "" && end == i+1 {
+			b.WriteString(`<a id="` + anchorID(normalizeName(strings.Trim(m[1], "`"))) + `"></a>` + "\n")
+		}
+	case substitutionDefPattern.MatchString(text):
+	case footnoteDefPattern.MatchString(text):
+		m := footnoteDefPattern.FindStringSubmatch(text)
+		label := d.footnoteDefLabel(m[1])
+		b.WriteString("<dl>\n")
+		b.WriteString(`<dt id="user-content-footnote-` + escape(label) + `">[` + escape(label) + "]</dt>\n<dd>")
+		d.renderBlocks(b, append([]string{m[2]}, content...), true)
+		b.WriteString("</dd>\n</dl>\n")
+	case directivePattern.MatchString(text):
+		m := directivePattern.FindStringSubmatch(text)
+		options, body := parseOptions(content)
+		d.renderDirective(b, strings.ToLower(m[1]), strings.TrimSpace(m[2]), options, body)
+	}
+	return end
+}
+
+// footnoteDefLabel returns the label of a footnote in the order it is defined
+func (d *document) footnoteDefLabel(label string) string {
+	switch {
+	case label == "#":
+		if d.autoFootnoteDefs < len(d.autoFootnotes) {
+			d.autoFootnoteDefs++
+			return strconv.Itoa(d.autoFootnotes[d.autoFootnoteDefs-1])
+		}
+	case label[0] == '#':
+		if n, ok := d.footnoteLabels[label[1:]]; ok {
+			return strconv.Itoa(n)
+		}
+	}
+	return label
+}
+
+func (d *document) renderDirective(b *strings.Builder, name, argument string, options map[string]string, body []string) {
+	switch name {
+	case "code", "code-block", "sourcecode":
+		b.WriteString(common.HighlightCodeBlock(argument, strings.Join(body, "\n")))
+	case "highlight":
+		d.language = argument
+	case "math":
+		source := strings.Join(body, "\n")
+		if argument != "" {
+			source = strings.TrimSpace(argument + "\n" + source)
+		}
+		b.WriteString(common.HighlightCodeBlock("math", source))
+	case "image":
+		b.WriteString("<p>" + d.placeholders.Hold(d.image(argument, options)) + "</p>\n")
+	case "figure":
+		b.WriteString("<p>" + d.placeholders.Hold(d.image(argument, options)) + "</p>\n")
+		d.renderBlocks(b, body, false)
+	case "admonition":
+		b.WriteString("<blockquote>\n<p><strong>" + d.inline(argument) + "</strong></p>\n")
+		d.renderBlocks(b, body, false)
+		b.WriteString("</blockquote>\n")
+	case "topic", "sidebar":
+		b.WriteString("<p><strong>" + d.inline(argument) + "</strong></p>\n")
+		d.renderBlocks(b, body, false)
+	case "rubric":
+		b.WriteString("<p><strong>" + d.inline(argument) + "</strong></p>\n")
+	case "epigraph", "highlights", "pull-quote":
+		b.WriteString("<blockquote>\n")
+		d.renderBlocks(b, body, false)
+		b.WriteString("</blockquote>\n")
+	case "container", "compound", "class":
+		d.renderBlocks(b, body, false)
+	case "contents":
+		depth, _ := strconv.Atoi(options["depth"])
+		b.WriteString(d.toc(depth))
+	case "list-table":
+		d.renderListTable(b, options, body)
+	case "csv-table":
+		d.renderCSVTable(b, options, body)
+	default:
+		caption, ok := admonitionCaptions[name]
+		if !ok {
+			// raw, include and unknown directives are not rendered
+			return
+		}
+		if argument != "" {
+			body = append([]string{argument}, body...)
+		}
+		b.WriteString("<blockquote>\n<p><strong>" + caption + "</strong></p>\n")
+		d.renderBlocks(b, body, false)
+		b.WriteString("</blockquote>\n")
+	}
+}
+
+func (d *document) image(uri string, options map[string]string) string {
+	alt, ok := options["alt"]
+	if !ok {
+		alt = uri
+	}
+	img := `<img src="` + escape(uri) + `" alt="` + escape(alt) + `"`
+	for _, name := range []string{"width", "height"} {
+		if value := options[name]; value != "" {
+			img += " " + name + `="` + escape(value) + `"`
+		}
+	}
+	img += "/>"
+	if target, ok := options["target"]; ok && target != "" {
+		href := target
+		if strings.HasSuffix(target, "_") {
+			href, _ = d.target(strings.Trim(strings.TrimSuffix(target, "_"), "`"))
+		}
+		img = `<a href="` + escape(common.ResolveLink(d.urlPrefix, d.isWiki, href)) + `">` + img + "</a>"
+	}
+	return img
+}
+
+// writeTable writes a table of rendered cells
+func writeTable(b *strings.Builder, header, rows [][]string) {
+	b.WriteString("<table>\n")
+	writeRows := func(tag string, rows [][]string) {
+		for _, row := range rows {
+			b.WriteString("<tr>\n")
+			for _, cell := range row {
+				b.WriteString("<" + tag + ">" + cell + "</" + tag + ">\n")
+			}
+			b.WriteString("</tr>\n")
+		}
+	}
+	if len(header) > 0 {
+		b.WriteString("<thead>\n")
+		writeRows("th", header)
+		b.WriteString("</thead>\n")
+	}
+	if len(rows) > 0 {
+		b.WriteString("<tbody>\n")
+		writeRows("td", rows)
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n")
+}
+
+// renderCell renders the lines of a table cell
+func (d *document) renderCell(lines []string) string {
+	var b strings.Builder
+	d.renderBlocks(&b, dedent(lines), true)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (d *document) renderSimpleTable(b *strings.Builder, lines []string, i int) int {
+	// the columns are given by the runs of = of the top border
+	var columns []int
+	border := strings.TrimRight(lines[i], " \t")
+	for j := 0; j < len(border); j++ {
+		if border[j] == '=' && (j == 0 || border[j-1] == ' ') {
+			columns = append(columns, j)
+		}
+	}
+
+	var borders []int
+	var rows [][][]string
+	end := i
+	for j := i; j < len(lines); j++ {
+		line := strings.TrimRight(lines[j], " \t")
+		if simpleTableBorder.MatchString(line) {
+			borders = append(borders, len(rows))
+			end = j + 1
+			if len(borders) > 1 && (j+1 == len(lines) || isBlank(lines[j+1])) {
+				break
+			}
+			continue
+		}
+		if line == "" || simpleTableSpanBorder.MatchString(line) {
+			continue
+		}
+
+		runes := []rune(line)
+		cells := make([]string, len(columns))
+		for k, start := range columns {
+			stop := len(runes)
+			if k+1 < len(columns) && columns[k+1] < stop {
+				stop = columns[k+1]
+			}
+			if start < stop {
+				cells[k] = string(runes[start:stop])
+			}
+		}
+		// a row with an empty first column continues the previous row
+		if strings.TrimSpace(cells[0]) == "" && len(rows) > 0 && len(borders) > 0 && borders[len(borders)-1] < len(rows) {
+			row := rows[len(rows)-1]
+			for k, cell := range cells {
+				row[k] = append(row[k], cell)
+			}
+			continue
+		}
+		row := make([][]string, len(columns))
+		for k, cell := range cells {
+			row[k] = []string{cell}
+		}
+		rows = append(rows, row)
+	}
+
+	headerRows := 0
+	if len(borders) > 2 {
+		headerRows = borders[1]
+	}
+	rendered := make([][]string, len(rows))
+	for r, row := range rows {
+		rendered[r] = make([]string, len(row))
+		for k, cell := range row {
+			rendered[r][k] = d.renderCell(cell)
+		}
+	}
+	writeTable(b, rendered[:headerRows], rendered[headerRows:])
+	return end
+}
+
+func (d *document) renderGridTable(b *strings.Builder, lines []string, i int) int {
+	// the column boundaries are given by the + of the top border
+	var boundaries []int
+	for j, r := range []rune(strings.TrimRight(lines[i], " \t")) {
+		if r == '+' {
+			boundaries = append(boundaries, j)
+		}
+	}
+
+	var rows [][]string
+	var cells [][]string
+	headerRows := 0
+	end := i + 1
+	for ; end < len(lines); end++ {
+		line := strings.TrimRight(lines[end], " \t")
+		if gridTableBorder.MatchString(line) {
+			if cells != nil {
+				row := make([]string, len(cells))
+				for k, cell := range cells {
+					row[k] = d.renderCell(cell)
+				}
+				rows = append(rows, row)
+				cells = nil
+			}
+			if strings.Contains(line, "=") {
+				headerRows = len(rows)
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, "|") {
+			break
+		}
+
+		runes := []rune(line)
+		if cells == nil {
+			cells = make([][]string, len(boundaries)-1)
+		}
+		for k := 0; k+1 < len(boundaries); k++ {
+			start, stop := boundaries[k]+1, boundaries[k+1]
+			if stop > len(runes) {
+				stop = len(runes)
+			}
+			if start < stop {
+				cells[k] = append(cells[k], strings.TrimRight(string(runes[start:stop]), " |"))
+			}
+		}
+	}
+
+	writeTable(b, rows[:headerRows], rows[headerRows:])
+	return end
+}
+
+func (d *document) renderListTable(b *strings.Builder, options map[string]string, body []string) {
+	var rows [][]string
+	for i := 0; i < len(body); {
+		item := parseListItem(body[i])
+		if item == nil {
+			i++
+			continue
+		}
+		rowLines, end := listItemLines(body, i, item)
+		var row []string
+		for j := 0; j < len(rowLines); {
+			cell := parseListItem(rowLines[j])
+			if cell == nil {
+				j++
+				continue
+			}
+			cellLines, cellEnd := listItemLines(rowLines, j, cell)
+			row = append(row, d.renderCell(cellLines))
+			j = cellEnd
+		}
+		rows = append(rows, row)
+		i = end
+	}
+
+	headerRows, _ := strconv.Atoi(options["header-rows"])
+	if headerRows > len(rows) {
+		headerRows = len(rows)
+	}
+	writeTable(b, rows[:headerRows], rows[headerRows:])
+}
+
+func (d *document) renderCSVTable(b *strings.Builder, options map[string]string, body []string) {
+	parse := func(text string) [][]string {
+		reader := csv.NewReader(strings.NewReader(text))
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		reader.TrimLeadingSpace = true
+		records, _ := reader.ReadAll()
+		for _, record := range records {
+			for k, field := range record {
+				record[k] = d.inline(field)
+			}
+		}
+		return records
+	}
+
+	var header [][]string
+	if value, ok := options["header"]; ok {
+		header = parse(value)
+	}
+	rows := parse(strings.Join(body, "\n"))
+	if headerRows, _ := strconv.Atoi(options["header-rows"]); headerRows > 0 {
+		if headerRows > len(rows) {
+			headerRows = len(rows)
+		}
+		header = append(header, rows[:headerRows]...)
+		rows = rows[headerRows:]
+	}
+	writeTable(b, header, rows)
+}
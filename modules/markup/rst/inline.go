@@ -0,0 +1,248 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package rst
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/markup/common"
+)
+
+// inline markup starts after whitespace or an opening punctuation and ends before whitespace
+// or a closing punctuation
+const (
+	startBoundary = `(^|[\s'"(\[{<\-/:])`
+	endBoundary   = `($|[\s'")\]}>\-/:.,;!?\\])`
+)
+
+var (
+	inlineLiteralPattern   = regexp.MustCompile(startBoundary + "``([^`\\s](?:.*?[^\\s`])?)``" + endBoundary)
+	rolePattern            = regexp.MustCompile(startBoundary + ":([\\w+.:-]+):`([^`]+)`" + endBoundary)
+	backslashEscapePattern = regexp.MustCompile(`\\(\s|.)`)
+	embeddedURIPattern     = regexp.MustCompile("`([^`]*?)\\s*<([^<>`]+)>`(__?)")
+	phraseReferencePattern = regexp.MustCompile("`([^`]+)`(__?)")
+	footnoteRefPattern     = regexp.MustCompile(`\[(#[\w-]*|\d+|[\p{L}_][\w.-]*)\]_`)
+	substitutionRefPattern = regexp.MustCompile(startBoundary + `\|([^|\s](?:[^|]*[^|\s])?)\|(__?)?` + endBoundary)
+	interpretedPattern     = regexp.MustCompile(startBoundary + "`([^`\\s](?:[^`]*[^`\\s])?)`" + endBoundary)
+	simpleReferencePattern = regexp.MustCompile(`(^|[\s(\["'])([\p{L}\p{N}](?:[\w.+-]*[\p{L}\p{N}])?)(__?)($|[\s.,;:!?)\]"'])`)
+	uriPattern             = regexp.MustCompile(`(?:https?|ftp)://[^\s<>\[\]]+|mailto:[^\s<>\[\]]+`)
+	strongPattern          = regexp.MustCompile(startBoundary + `\*\*([^*\s](?:.*?[^*\s])?)\*\*` + endBoundary)
+	emphasisPattern        = regexp.MustCompile(startBoundary + `\*([^*\s](?:[^*]*?[^*\s])?)\*` + endBoundary)
+	roleTargetPattern      = regexp.MustCompile(`^(.*?)\s*<[^<>]+>$`)
+
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	escaper     = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&#34;", "'", "&#39;")
+)
+
+// roleElements are the elements of the interpreted text roles rendered as HTML
+var roleElements = map[string]string{
+	"code":            "code",
+	"literal":         "code",
+	"math":            "code",
+	"emphasis":        "em",
+	"strong":          "strong",
+	"sub":             "sub",
+	"subscript":       "sub",
+	"sup":             "sup",
+	"superscript":     "sup",
+	"title-reference": "cite",
+	"title":           "cite",
+	"t":               "cite",
+}
+
+func escape(text string) string {
+	return escaper.Replace(text)
+}
+
+// inline renders the inline markup of a text. Fragments of HTML are kept as placeholders
+// which are restored once the whole document is rendered.
+func (d *document) inline(text string) string {
+	text = common.ReplaceConstrained(inlineLiteralPattern, text, func(m []string) string {
+		return m[1] + d.placeholders.Hold("<code>"+textEscaper.Replace(m[2])+"</code>") + m[3]
+	})
+	text = common.ReplaceConstrained(rolePattern, text, func(m []string) string {
+		content := m[3]
+		tag, ok := roleElements[m[2]]
+		if !ok {
+			// the roles of extensions like :ref:`text <label>` are rendered as their text
+			if sm := roleTargetPattern.FindStringSubmatch(content); sm != nil && sm[1] != "" {
+				content = sm[1]
+			}
+			return m[1] + d.placeholders.Hold(textEscaper.Replace(content)) + m[4]
+		}
+		if tag != "code" {
+			content = d.inline(content)
+		} else {
+			content = textEscaper.Replace(content)
+		}
+		return m[1] + d.placeholders.Hold("<"+tag+">"+content+"</"+tag+">") + m[4]
+	})
+	text = backslashEscapePattern.ReplaceAllStringFunc(text, func(match string) string {
+		if strings.TrimSpace(match[1:]) == "" {
+			return ""
+		}
+		return d.placeholders.Hold(textEscaper.Replace(match[1:]))
+	})
+
+	text = embeddedURIPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := embeddedURIPattern.FindStringSubmatch(match)
+		label, uri := m[1], strings.Join(strings.Fields(m[2]), "")
+		if label == "" {
+			label = uri
+		}
+		if strings.HasSuffix(uri, "_") {
+			name := strings.Trim(strings.TrimSuffix(uri, "_"), "`")
+			return d.placeholders.Defer(func() string {
+				return d.link(name, textEscaper.Replace(label))
+			})
+		}
+		return d.placeholders.Hold(`<a href="` + escape(common.ResolveLink(d.urlPrefix, d.isWiki, uri)) + `">` + textEscaper.Replace(label) + "</a>")
+	})
+	text = phraseReferencePattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := phraseReferencePattern.FindStringSubmatch(match)
+		return d.reference(m[1], m[2] == "__")
+	})
+	text = footnoteRefPattern.ReplaceAllStringFunc(text, func(match string) string {
+		label := d.footnoteRefLabel(footnoteRefPattern.FindStringSubmatch(match)[1])
+		return d.placeholders.Hold(`<sup><a href="#user-content-footnote-` + escape(label) + `">[` + textEscaper.Replace(label) + "]</a></sup>")
+	})
+	text = common.ReplaceConstrained(substitutionRefPattern, text, func(m []string) string {
+		content := d.substitution(m[2])
+		if m[3] != "" {
+			content = d.placeholders.Defer(func() string {
+				return d.link(m[2], content)
+			})
+		}
+		return m[1] + content + m[4]
+	})
+	text = common.ReplaceConstrained(interpretedPattern, text, func(m []string) string {
+		return m[1] + d.placeholders.Hold("<cite>"+textEscaper.Replace(m[2])+"</cite>") + m[3]
+	})
+	text = uriPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return d.placeholders.Hold(textEscaper.Replace(match))
+	})
+	text = common.ReplaceConstrained(simpleReferencePattern, text, func(m []string) string {
+		if m[3] == "__" {
+			return m[1] + d.reference(m[2], true) + m[4]
+		}
+		// words ending with an underscore are only references if there is such a target
+		return m[1] + d.placeholders.Defer(func() string {
+			if _, ok := d.target(m[2]); !ok {
+				return textEscaper.Replace(m[2] + m[3])
+			}
+			return d.link(m[2], textEscaper.Replace(m[2]))
+		}) + m[4]
+	})
+
+	text = textEscaper.Replace(text)
+
+	text = common.ReplaceConstrained(strongPattern, text, func(m []string) string {
+		return m[1] + "<strong>" + m[2] + "</strong>" + m[3]
+	})
+	text = common.ReplaceConstrained(emphasisPattern, text, func(m []string) string {
+		return m[1] + "<em>" + m[2] + "</em>" + m[3]
+	})
+	return text
+}
+
+// reference returns a placeholder for the link of a named or anonymous hyperlink reference
+func (d *document) reference(name string, anonymous bool) string {
+	label := textEscaper.Replace(name)
+	if anonymous {
+		if d.anonymousRefs >= len(d.anonymousTargets) {
+			return d.placeholders.Hold(label)
+		}
+		uri := d.anonymousTargets[d.anonymousRefs]
+		d.anonymousRefs++
+		return d.placeholders.Hold(`<a href="` + escape(common.ResolveLink(d.urlPrefix, d.isWiki, uri)) + `">` + label + "</a>")
+	}
+	return d.placeholders.Defer(func() string {
+		return d.link(name, label)
+	})
+}
+
+// link returns the link to a named target, or the label if there is no such target
+func (d *document) link(name, label string) string {
+	uri, ok := d.target(name)
+	if !ok {
+		return label
+	}
+	return `<a href="` + escape(common.ResolveLink(d.urlPrefix, d.isWiki, uri)) + `">` + label + "</a>"
+}
+
+// target returns the URI of a hyperlink target or a section of the document
+func (d *document) target(name string) (string, bool) {
+	key := normalizeName(name)
+	for i := 0; i < 10; i++ {
+		alias, ok := d.aliases[key]
+		if !ok {
+			break
+		}
+		key = alias
+	}
+	if uri, ok := d.targets[key]; ok {
+		return uri, true
+	}
+	if id, ok := d.sections[key]; ok {
+		return "#" + id, true
+	}
+	return "", false
+}
+
+// footnoteRefLabel returns the label of a referenced footnote
+func (d *document) footnoteRefLabel(label string) string {
+	switch {
+	case label == "#":
+		if d.autoFootnoteRefs < len(d.autoFootnotes) {
+			d.autoFootnoteRefs++
+			return strconv.Itoa(d.autoFootnotes[d.autoFootnoteRefs-1])
+		}
+	case label[0] == '#':
+		if n, ok := d.footnoteLabels[label[1:]]; ok {
+			return strconv.Itoa(n)
+		}
+	}
+	return label
+}
+
+// substitution returns a placeholder for the rendered definition of a substitution
+func (d *document) substitution(name string) string {
+	def, ok := d.substitutions[normalizeName(name)]
+	if !ok || def.rendering {
+		return d.placeholders.Hold(textEscaper.Replace("|" + name + "|"))
+	}
+
+	def.rendering = true
+	defer func() {
+		def.rendering = false
+	}()
+	switch def.directive {
+	case "replace":
+		return d.placeholders.Hold(d.placeholders.Restore(d.inline(def.argument)))
+	case "image":
+		options := map[string]string{"alt": name}
+		for key, value := range def.options {
+			options[key] = value
+		}
+		return d.placeholders.Hold(d.image(def.argument, options))
+	case "unicode":
+		var b strings.Builder
+		for _, code := range strings.Fields(def.argument) {
+			if code == ".." {
+				break
+			}
+			code = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(code), "0x"), "u+")
+			if n, err := strconv.ParseInt(strings.TrimPrefix(code, "\\x"), 16, 32); err == nil {
+				b.WriteRune(rune(n))
+			} else {
+				b.WriteString(code)
+			}
+		}
+		return d.placeholders.Hold(textEscaper.Replace(b.String()))
+	}
+	return d.placeholders.Hold(textEscaper.Replace(name))
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package rst
+
+import (
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const AppURL = "http://localhost:3000/"
+const Repo = "gogits/gogs"
+const AppSubURL = AppURL + Repo + "/"
+
+func test(t *testing.T, input, expected string) {
+	setting.AppURL = AppURL
+	setting.AppSubURL = AppSubURL
+
+	buffer, err := RenderString(&markup.RenderContext{
+		URLPrefix: setting.AppSubURL,
+	}, input)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(buffer))
+}
+
+func TestRender_Sections(t *testing.T) {
+	test(t, `=====
+Title
+=====
+
+.. contents::
+
+Usage
+-----
+
+Details
+~~~~~~~
+
+Usage
+-----`, `<h1 id="user-content-title">Title</h1>
+<details><summary>toc</summary>
+<ul>
+<li><a href="#user-content-usage">Usage</a><ul>
+<li><a href="#user-content-details">Details</a></li>
+</ul>
+</li>
+<li><a href="#user-content-usage-1">Usage</a></li>
+</ul>
+</details>
+<h2 id="user-content-usage">Usage</h2>
+<h3 id="user-content-details">Details</h3>
+<h2 id="user-content-usage-1">Usage</h2>`)
+
+	test(t, "Before\n\n----\n\nAfter", `<p>Before</p>
+<hr/>
+<p>After</p>`)
+}
+
+func TestRender_Inline(t *testing.T) {
+	test(t, "Some **strong**, *emphasis*, ``literal`` and `title`.",
+		`<p>Some <strong>strong</strong>, <em>emphasis</em>, <code>literal</code> and <cite>title</cite>.</p>`)
+	test(t, `A <tag> & an escaped \*star*, H\ :sub:`+"`2`"+`\ O and a snake_case_ word.`,
+		`<p>A &lt;tag&gt; &amp; an escaped *star*, H<sub>2</sub>O and a snake_case_ word.</p>`)
+	test(t, "This is |name|.\n\n.. |name| replace:: *Gitea*",
+		`<p>This is <em>Gitea</em>.</p>`)
+}
+
+func TestRender_Links(t *testing.T) {
+	test(t, "Visit `Gitea <https://gitea.io>`_, gitea_ and `the docs`_.\n\n"+
+		".. _gitea: https://gitea.io\n.. _the docs: docs/install.rst",
+		`<p>Visit <a href="https://gitea.io">Gitea</a>, <a href="https://gitea.io">gitea</a> and <a href="`+util.URLJoin(AppSubURL, "docs/install.rst")+`">the docs</a>.</p>`)
+
+	test(t, "See `Details`_.\n\nDetails\n=======",
+		`<p>See <a href="#user-content-details">Details</a>.</p>
+<h1 id="user-content-details">Details</h1>`)
+
+	test(t, "A note [#]_.\n\n.. [#] The note.", `<p>A note <sup><a href="#user-content-footnote-1">[1]</a></sup>.</p>
+<dl>
+<dt id="user-content-footnote-1">[1]</dt>
+<dd>The note.</dd>
+</dl>`)
+}
+
+func TestRender_Lists(t *testing.T) {
+	test(t, `- one
+- two
+
+  - nested`, `<ul>
+<li>one</li>
+<li>two<ul>
+<li>nested</li>
+</ul>
+</li>
+</ul>`)
+
+	test(t, `#. first
+#. second`, `<ol>
+<li>first</li>
+<li>second</li>
+</ol>`)
+
+	test(t, `term
+   definition`, `<dl>
+<dt>term</dt>
+<dd><p>definition</p>
+</dd>
+</dl>`)
+}
+
+func TestRender_Blocks(t *testing.T) {
+	test(t, "Example::\n\n    x < 1", `<p>Example:</p>
+<pre class="code-block"><code>x &lt; 1</code></pre>`)
+
+	test(t, `.. code-block:: go
+
+   func main() {}`, `<pre class="code-block"><code class="chroma language-go"><span class="kd">func</span> <span class="nf">main</span><span class="p">()</span> <span class="p">{}</span></code></pre>`)
+
+	test(t, ".. warning:: Take care.", `<blockquote>
+<p><strong>Warning</strong></p>
+<p>Take care.</p>
+</blockquote>`)
+
+	test(t, ".. raw:: html\n\n   <script>alert(1)</script>", ``)
+}
+
+func TestRender_Tables(t *testing.T) {
+	expected := `<table>
+<thead>
+<tr>
+<th>A</th>
+<th>B</th>
+</tr>
+</thead>
+<tbody>
+<tr>
+<td>1</td>
+<td>2</td>
+</tr>
+</tbody>
+</table>`
+
+	test(t, `=====  =====
+A      B
+=====  =====
+1      2
+=====  =====`, expected)
+
+	test(t, `+-----+-----+
+| A   | B   |
++=====+=====+
+| 1   | 2   |
++-----+-----+`, expected)
+
+	test(t, `.. list-table::
+   :header-rows: 1
+
+   * - A
+     - B
+   * - 1
+     - 2`, expected)
+}
@@ -6,8 +6,15 @@
 package markup
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"io"
+	"net/url"
 	"regexp"
+	"strings"
 	"sync"
 
 	"code.gitea.io/gitea/modules/setting"
@@ -25,6 +32,39 @@ type Sanitizer struct {
 
 var sanitizer = &Sanitizer{}
 
+const svgDataURIPrefix = "image/svg+xml;base64,"
+
+// svgDataURIKey signs the data URIs of the SVG images sanitized by the server, so the
+// sanitizer can tell them from the data URIs written by users
+var svgDataURIKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+func svgDataURISignature(data string) string {
+	h := hmac.New(sha256.New, svgDataURIKey)
+	_, _ = h.Write([]byte(data))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SignedSVGDataURI returns the data URI of an already sanitized SVG image. Its signature is
+// stored in the fragment, only signed data URIs pass the sanitizer.
+func SignedSVGDataURI(svg []byte) string {
+	data := svgDataURIPrefix + base64.StdEncoding.EncodeToString(svg)
+	return "data:" + data + "#" + svgDataURISignature(data)
+}
+
+// isSignedSVGDataURI checks if a data URI was returned by SignedSVGDataURI
+func isSignedSVGDataURI(u *url.URL) bool {
+	if u.RawQuery != "" || !strings.HasPrefix(u.Opaque, svgDataURIPrefix) {
+		return false
+	}
+	return hmac.Equal([]byte(u.Fragment), []byte(svgDataURISignature(u.Opaque)))
+}
+
 // NewSanitizer initializes sanitizer with allowed attributes based on settings.
 // Multiple calls to this function will only create one instance of Sanitizer during
 // entire application lifecycle.
@@ -62,6 +102,12 @@ func createDefaultPolicy() *bluemonday.Policy {
 	policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
 	policy.AllowAttrs("checked", "disabled", "data-source-position").OnElements("input")
 
+	// Diagrams rendered to SVG images by the markdown renderer, the data URIs written by users
+	// are not signed
+	if len(setting.MarkupDiagram.Renderers) > 0 {
+		policy.AllowURLSchemeWithCustomPolicy("data", isSignedSVGDataURI)
+	}
+
 	// Custom URL-Schemes
 	if len(setting.Markdown.CustomURLSchemes) > 0 {
 		policy.AllowURLSchemes(setting.Markdown.CustomURLSchemes...)
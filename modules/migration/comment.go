@@ -9,6 +9,7 @@ import "time"
 
 // Comment is a standard comment information
 type Comment struct {
+	ID          int64  `yaml:"id,omitempty"`
 	IssueIndex  int64  `yaml:"issue_index"`
 	PosterID    int64  `yaml:"poster_id"`
 	PosterName  string `yaml:"poster_name"`
@@ -7,6 +7,7 @@ package migration
 
 import (
 	"context"
+	"time"
 
 	"code.gitea.io/gitea/modules/structs"
 )
@@ -27,9 +28,11 @@ type Downloader interface {
 	GetReleases() ([]*Release, error)
 	GetLabels() ([]*Label, error)
 	GetIssues(page, perPage int) ([]*Issue, bool, error)
+	GetNewIssues(page, perPage int, updatedAfter time.Time) ([]*Issue, bool, error)
 	GetComments(opts GetCommentOptions) ([]*Comment, bool, error)
 	SupportGetRepoComments() bool
 	GetPullRequests(page, perPage int) ([]*PullRequest, bool, error)
+	GetNewPullRequests(page, perPage int, updatedAfter time.Time) ([]*PullRequest, bool, error)
 	GetReviews(pullRequestContext IssueContext) ([]*Review, error)
 	FormatCloneURL(opts MigrateOptions, remoteAddr string) (string, error)
 }
@@ -149,6 +149,22 @@ func (d *RetryDownloader) GetIssues(page, perPage int) ([]*Issue, bool, error) {
 	return issues, isEnd, err
 }
 
+// GetNewIssues returns a repository's issues updated after the given time with retry
+func (d *RetryDownloader) GetNewIssues(page, perPage int, updatedAfter time.Time) ([]*Issue, bool, error) {
+	var (
+		issues []*Issue
+		isEnd  bool
+		err    error
+	)
+
+	err = d.retry(func() error {
+		issues, isEnd, err = d.Downloader.GetNewIssues(page, perPage, updatedAfter)
+		return err
+	})
+
+	return issues, isEnd, err
+}
+
 // GetComments returns a repository's comments with retry
 func (d *RetryDownloader) GetComments(opts GetCommentOptions) ([]*Comment, bool, error) {
 	var (
@@ -181,6 +197,22 @@ func (d *RetryDownloader) GetPullRequests(page, perPage int) ([]*PullRequest, bo
 	return prs, isEnd, err
 }
 
+// GetNewPullRequests returns a repository's pull requests updated after the given time with retry
+func (d *RetryDownloader) GetNewPullRequests(page, perPage int, updatedAfter time.Time) ([]*PullRequest, bool, error) {
+	var (
+		prs   []*PullRequest
+		err   error
+		isEnd bool
+	)
+
+	err = d.retry(func() error {
+		prs, isEnd, err = d.Downloader.GetNewPullRequests(page, perPage, updatedAfter)
+		return err
+	})
+
+	return prs, isEnd, err
+}
+
 // GetReviews returns pull requests reviews
 func (d *RetryDownloader) GetReviews(pullRequestContext IssueContext) ([]*Review, error) {
 	var (
@@ -0,0 +1,69 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/modules/audit"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/notification/base"
+)
+
+type auditNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &auditNotifier{}
+)
+
+// NewNotifier create a new auditNotifier notifier
+func NewNotifier() base.Notifier {
+	return &auditNotifier{}
+}
+
+// Notifications don't carry the address of the client, so the events are recorded without it
+
+func (a *auditNotifier) NotifyCreateRepository(doer, u *models.User, repo *models.Repository) {
+	audit.Record(admin.AuditRepoCreate, doer, "", repo, nil, audit.RepoSnapshot(repo))
+}
+
+func (a *auditNotifier) NotifyMigrateRepository(doer, u *models.User, repo *models.Repository) {
+	snapshot := audit.RepoSnapshot(repo)
+	snapshot["is_mirror"] = repo.IsMirror
+	snapshot["original_url"] = repo.OriginalURL
+	audit.Record(admin.AuditRepoMigrate, doer, "", repo, nil, snapshot)
+}
+
+func (a *auditNotifier) NotifyDeleteRepository(doer *models.User, repo *models.Repository) {
+	audit.Record(admin.AuditRepoDelete, doer, "", repo, audit.RepoSnapshot(repo), nil)
+}
+
+func (a *auditNotifier) NotifyRenameRepository(doer *models.User, repo *models.Repository, oldRepoName string) {
+	audit.Record(admin.AuditRepoRename, doer, "", repo,
+		map[string]interface{}{"owner": repo.OwnerName, "name": oldRepoName},
+		map[string]interface{}{"owner": repo.OwnerName, "name": repo.Name})
+}
+
+func (a *auditNotifier) NotifyTransferRepository(doer *models.User, repo *models.Repository, oldOwnerName string) {
+	audit.Record(admin.AuditRepoTransfer, doer, "", repo,
+		map[string]interface{}{"owner": oldOwnerName, "name": repo.Name},
+		map[string]interface{}{"owner": repo.OwnerName, "name": repo.Name})
+}
+
+func (a *auditNotifier) NotifyRepoPendingTransfer(doer, newOwner *models.User, repo *models.Repository) {
+	audit.Record(admin.AuditRepoTransferPending, doer, "", repo,
+		map[string]interface{}{"owner": repo.OwnerName},
+		map[string]interface{}{"owner": newOwner.Name})
+}
+
+func (a *auditNotifier) NotifyDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
+	if refType != "branch" {
+		return
+	}
+	audit.Record(admin.AuditRepoBranchDelete, doer, "", repo,
+		map[string]interface{}{"branch": git.RefEndName(refFullName)}, nil)
+}
@@ -59,4 +59,6 @@ type Notifier interface {
 	NotifySyncDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string)
 
 	NotifyRepoPendingTransfer(doer, newOwner *models.User, repo *models.Repository)
+
+	NotifyCreateCommitStatus(repo *models.Repository, sha string, creator *models.User, status *models.CommitStatus)
 }
@@ -170,3 +170,7 @@ func (*NullNotifier) NotifySyncDeleteRef(doer *models.User, repo *models.Reposit
 // NotifyRepoPendingTransfer places a place holder function
 func (*NullNotifier) NotifyRepoPendingTransfer(doer, newOwner *models.User, repo *models.Repository) {
 }
+
+// NotifyCreateCommitStatus places a place holder function
+func (*NullNotifier) NotifyCreateCommitStatus(repo *models.Repository, sha string, creator *models.User, status *models.CommitStatus) {
+}
@@ -0,0 +1,29 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/notification/base"
+	federation_service "code.gitea.io/gitea/services/federation"
+)
+
+type federationNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &federationNotifier{}
+)
+
+// NewNotifier create a new federationNotifier notifier
+func NewNotifier() base.Notifier {
+	return &federationNotifier{}
+}
+
+func (f *federationNotifier) NotifyCreateIssueComment(doer *models.User, repo *models.Repository,
+	issue *models.Issue, comment *models.Comment, mentions []*models.User) {
+	federation_service.NotifyComment(doer, repo, issue, comment)
+}
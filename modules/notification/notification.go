@@ -7,9 +7,12 @@ package notification
 import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/notification/action"
+	"code.gitea.io/gitea/modules/notification/audit"
 	"code.gitea.io/gitea/modules/notification/base"
+	"code.gitea.io/gitea/modules/notification/federation"
 	"code.gitea.io/gitea/modules/notification/indexer"
 	"code.gitea.io/gitea/modules/notification/mail"
+	"code.gitea.io/gitea/modules/notification/project"
 	"code.gitea.io/gitea/modules/notification/ui"
 	"code.gitea.io/gitea/modules/notification/webhook"
 	"code.gitea.io/gitea/modules/repository"
@@ -35,6 +38,13 @@ func NewContext() {
 	RegisterNotifier(indexer.NewNotifier())
 	RegisterNotifier(webhook.NewNotifier())
 	RegisterNotifier(action.NewNotifier())
+	RegisterNotifier(project.NewNotifier())
+	if setting.Federation.Enabled {
+		RegisterNotifier(federation.NewNotifier())
+	}
+	if setting.Audit.Enabled {
+		RegisterNotifier(audit.NewNotifier())
+	}
 }
 
 // NotifyCreateIssueComment notifies issue comment related message to notifiers
@@ -297,3 +307,10 @@ func NotifyRepoPendingTransfer(doer, newOwner *models.User, repo *models.Reposit
 		notifier.NotifyRepoPendingTransfer(doer, newOwner, repo)
 	}
 }
+
+// NotifyCreateCommitStatus notifies a new commit status to notifiers
+func NotifyCreateCommitStatus(repo *models.Repository, sha string, creator *models.User, status *models.CommitStatus) {
+	for _, notifier := range notifiers {
+		notifier.NotifyCreateCommitStatus(repo, sha, creator, status)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification/base"
+)
+
+type projectNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &projectNotifier{}
+)
+
+// NewNotifier create a new projectNotifier notifier applying the automation rules of the projects
+func NewNotifier() base.Notifier {
+	return &projectNotifier{}
+}
+
+func (p *projectNotifier) NotifyNewIssue(issue *models.Issue, mentions []*models.User) {
+	if err := issue.LoadPoster(); err != nil {
+		log.Error("LoadPoster [%d]: %v", issue.ID, err)
+		return
+	}
+	if err := models.AutoAddIssueToProject(issue, issue.Poster); err != nil {
+		log.Error("AutoAddIssueToProject [%d]: %v", issue.ID, err)
+	}
+}
+
+func (p *projectNotifier) NotifyIssueChangeLabels(doer *models.User, issue *models.Issue,
+	addedLabels []*models.Label, removedLabels []*models.Label) {
+	if len(addedLabels) == 0 {
+		return
+	}
+	if err := models.AutoAddIssueToProject(issue, doer); err != nil {
+		log.Error("AutoAddIssueToProject [%d]: %v", issue.ID, err)
+	}
+}
+
+func (p *projectNotifier) NotifyIssueChangeStatus(doer *models.User, issue *models.Issue, actionComment *models.Comment, isClosed bool) {
+	if !isClosed || issue.IsPull {
+		return
+	}
+	if err := models.MoveProjectCardsOnEvent([]*models.Issue{issue}, models.ProjectAutomationIssueClosed); err != nil {
+		log.Error("MoveProjectCardsOnEvent [%d]: %v", issue.ID, err)
+	}
+}
+
+func (p *projectNotifier) NotifyNewPullRequest(pr *models.PullRequest, mentions []*models.User) {
+	issues, err := models.GetIssuesReferencedByPull(pr)
+	if err != nil {
+		log.Error("GetIssuesReferencedByPull [%d]: %v", pr.ID, err)
+		return
+	}
+	if err := models.MoveProjectCardsOnEvent(issues, models.ProjectAutomationPullOpened); err != nil {
+		log.Error("MoveProjectCardsOnEvent [%d]: %v", pr.ID, err)
+	}
+}
+
+func (p *projectNotifier) NotifyMergePullRequest(pr *models.PullRequest, doer *models.User) {
+	if err := pr.LoadIssue(); err != nil {
+		log.Error("LoadIssue [%d]: %v", pr.ID, err)
+		return
+	}
+	issues, err := models.GetIssuesReferencedByPull(pr)
+	if err != nil {
+		log.Error("GetIssuesReferencedByPull [%d]: %v", pr.ID, err)
+		return
+	}
+	if err := models.MoveProjectCardsOnEvent(append(issues, pr.Issue), models.ProjectAutomationPullMerged); err != nil {
+		log.Error("MoveProjectCardsOnEvent [%d]: %v", pr.ID, err)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	unittest.MainTest(m, filepath.Join("..", "..", ".."))
+}
+
+func TestNotifyNewIssue(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	doer := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	issue := unittest.AssertExistsAndLoadBean(t, &models.Issue{ID: 1}).(*models.Issue)
+	assert.NoError(t, models.ChangeProjectAssign(issue, doer, 0))
+
+	NewNotifier().NotifyNewIssue(issue, nil)
+	unittest.AssertExistsAndLoadBean(t, &models.ProjectIssue{IssueID: 1, ProjectID: 1, ProjectBoardID: 1})
+}
+
+func TestNotifyIssueChangeStatus(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	doer := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	issue := unittest.AssertExistsAndLoadBean(t, &models.Issue{ID: 1}).(*models.Issue)
+
+	NewNotifier().NotifyIssueChangeStatus(doer, issue, nil, false)
+	unittest.AssertExistsAndLoadBean(t, &models.ProjectIssue{IssueID: 1, ProjectBoardID: 1})
+
+	NewNotifier().NotifyIssueChangeStatus(doer, issue, nil, true)
+	unittest.AssertExistsAndLoadBean(t, &models.ProjectIssue{IssueID: 1, ProjectBoardID: 3})
+}
+
+func TestNotifyPullRequest(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	doer := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	pr := unittest.AssertExistsAndLoadBean(t, &models.PullRequest{ID: 1}).(*models.PullRequest)
+
+	// issue 1 is referenced by the pull request
+	_, err := db.GetEngine(db.DefaultContext).Insert(&models.Comment{
+		Type:       models.CommentTypePullRef,
+		IssueID:    1,
+		RefIssueID: pr.IssueID,
+		RefIsPull:  true,
+	})
+	assert.NoError(t, err)
+
+	NewNotifier().NotifyNewPullRequest(pr, nil)
+	unittest.AssertExistsAndLoadBean(t, &models.ProjectIssue{IssueID: 1, ProjectBoardID: 2})
+	unittest.AssertExistsAndLoadBean(t, &models.ProjectIssue{IssueID: pr.IssueID, ProjectBoardID: 0})
+
+	NewNotifier().NotifyMergePullRequest(pr, doer)
+	unittest.AssertExistsAndLoadBean(t, &models.ProjectIssue{IssueID: 1, ProjectBoardID: 3})
+	unittest.AssertExistsAndLoadBean(t, &models.ProjectIssue{IssueID: pr.IssueID, ProjectBoardID: 3})
+}
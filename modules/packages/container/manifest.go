@@ -0,0 +1,142 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"errors"
+	"io"
+	"regexp"
+
+	"code.gitea.io/gitea/modules/json"
+)
+
+// Supported manifest media types
+const (
+	ContentTypeDockerDistributionManifestV2   = "application/vnd.docker.distribution.manifest.v2+json"
+	ContentTypeDockerDistributionManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	ContentTypeOCIImageManifest               = "application/vnd.oci.image.manifest.v1+json"
+	ContentTypeOCIImageIndex                  = "application/vnd.oci.image.index.v1+json"
+
+	// UploadVersion is the name of the internal version which holds blobs not yet referenced by a manifest
+	UploadVersion = "_upload"
+)
+
+var (
+	// ErrInvalidManifest indicates an invalid manifest
+	ErrInvalidManifest = errors.New("The manifest is invalid")
+	// ErrUnsupportedMediaType indicates an unsupported manifest media type
+	ErrUnsupportedMediaType = errors.New("The manifest media type is not supported")
+
+	// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pulling-manifests
+	imageNamePattern = regexp.MustCompile(`\A[a-z0-9]+([._-][a-z0-9]+)*(/[a-z0-9]+([._-][a-z0-9]+)*)*\z`)
+	referencePattern = regexp.MustCompile(`\A[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}\z`)
+	digestPattern    = regexp.MustCompile(`\Asha256:[a-f0-9]{64}\z`)
+)
+
+// IsValidImageName checks if the name is a valid image name
+func IsValidImageName(name string) bool {
+	return imageNamePattern.MatchString(name)
+}
+
+// IsValidTag checks if the reference is a valid tag
+func IsValidTag(reference string) bool {
+	return referencePattern.MatchString(reference)
+}
+
+// IsValidDigest checks if the reference is a supported digest.
+// Only sha256 digests are supported because blobs are addressed by their sha256 hash.
+func IsValidDigest(reference string) bool {
+	return digestPattern.MatchString(reference)
+}
+
+// Descriptor describes the content of a blob or manifest
+type Descriptor struct {
+	MediaType string    `json:"mediaType"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	Platform  *Platform `json:"platform,omitempty"`
+}
+
+// Platform describes the platform of an image referenced by an index
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// Manifest represents an image manifest or an image index
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Config        *Descriptor       `json:"config,omitempty"`
+	Layers        []*Descriptor     `json:"layers,omitempty"`
+	Manifests     []*Descriptor     `json:"manifests,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// ParseManifest parses a manifest. The media type of the request is used if the manifest does not contain one.
+func ParseManifest(r io.Reader, contentType string) (*Manifest, error) {
+	var m *Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, ErrInvalidManifest
+	}
+	if m == nil || m.SchemaVersion != 2 {
+		return nil, ErrInvalidManifest
+	}
+
+	if m.MediaType == "" {
+		m.MediaType = contentType
+	}
+
+	switch m.MediaType {
+	case ContentTypeDockerDistributionManifestV2, ContentTypeOCIImageManifest:
+		if m.Config == nil || !IsValidDigest(m.Config.Digest) {
+			return nil, ErrInvalidManifest
+		}
+		for _, l := range m.Layers {
+			if !IsValidDigest(l.Digest) {
+				return nil, ErrInvalidManifest
+			}
+		}
+	case ContentTypeDockerDistributionManifestList, ContentTypeOCIImageIndex:
+		for _, d := range m.Manifests {
+			if !IsValidDigest(d.Digest) {
+				return nil, ErrInvalidManifest
+			}
+		}
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+
+	return m, nil
+}
+
+// IsIndex returns true if the manifest references other manifests
+func (m *Manifest) IsIndex() bool {
+	return m.MediaType == ContentTypeDockerDistributionManifestList || m.MediaType == ContentTypeOCIImageIndex
+}
+
+// BlobDigests returns the digests of all blobs referenced by an image manifest
+func (m *Manifest) BlobDigests() []string {
+	if m.IsIndex() {
+		return nil
+	}
+
+	digests := make([]string, 0, len(m.Layers)+1)
+	digests = append(digests, m.Config.Digest)
+	for _, l := range m.Layers {
+		digests = append(digests, l.Digest)
+	}
+	return digests
+}
+
+// ManifestDigests returns the digests of all manifests referenced by an image index
+func (m *Manifest) ManifestDigests() []string {
+	digests := make([]string, 0, len(m.Manifests))
+	for _, d := range m.Manifests {
+		digests = append(digests, d.Digest)
+	}
+	return digests
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	configDigest = "sha256:4607e093bec406eaadb6f3a340f63400c9d3a7038680744c406903766b938f0d"
+	layerDigest  = "sha256:a3ed95caeb02ffe68cdd9fd84406680ae93d633cb16422d00e8a7c22955b46d4"
+)
+
+func TestParseManifest(t *testing.T) {
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ParseManifest(strings.NewReader("{}"), "")
+		assert.ErrorIs(t, err, ErrInvalidManifest)
+
+		_, err = ParseManifest(strings.NewReader(`{"schemaVersion":2,"mediaType":"unknown"}`), "")
+		assert.ErrorIs(t, err, ErrUnsupportedMediaType)
+
+		_, err = ParseManifest(strings.NewReader(`{"schemaVersion":2,"config":{"digest":"sha512:invalid"}}`), ContentTypeOCIImageManifest)
+		assert.ErrorIs(t, err, ErrInvalidManifest)
+	})
+
+	t.Run("ImageManifest", func(t *testing.T) {
+		content := `{"schemaVersion":2,"mediaType":"` + ContentTypeDockerDistributionManifestV2 + `","config":{"mediaType":"application/vnd.docker.container.image.v1+json","digest":"` + configDigest + `","size":1069},"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","digest":"` + layerDigest + `","size":32}]}`
+
+		m, err := ParseManifest(strings.NewReader(content), "")
+		assert.NoError(t, err)
+		assert.False(t, m.IsIndex())
+		assert.Equal(t, []string{configDigest, layerDigest}, m.BlobDigests())
+		assert.Empty(t, m.ManifestDigests())
+	})
+
+	t.Run("ImageIndex", func(t *testing.T) {
+		content := `{"schemaVersion":2,"manifests":[{"mediaType":"` + ContentTypeOCIImageManifest + `","digest":"` + configDigest + `","size":7143,"platform":{"architecture":"arm64","os":"linux"}}]}`
+
+		m, err := ParseManifest(strings.NewReader(content), ContentTypeOCIImageIndex)
+		assert.NoError(t, err)
+		assert.True(t, m.IsIndex())
+		assert.Equal(t, ContentTypeOCIImageIndex, m.MediaType)
+		assert.Empty(t, m.BlobDigests())
+		assert.Equal(t, []string{configDigest}, m.ManifestDigests())
+
+		metadata, err := ParseMetadata(m, nil)
+		assert.NoError(t, err)
+		assert.True(t, metadata.IsIndex)
+		assert.Equal(t, []string{"linux/arm64"}, metadata.Manifests)
+	})
+}
+
+func TestParseMetadata(t *testing.T) {
+	m := &Manifest{
+		SchemaVersion: 2,
+		MediaType:     ContentTypeOCIImageManifest,
+		Config:        &Descriptor{Digest: configDigest},
+		Annotations: map[string]string{
+			AnnotationDescription: "Image Description",
+		},
+	}
+	config := `{"architecture":"amd64","os":"linux","config":{"Labels":{"` + AnnotationDescription + `":"Label Description","` + AnnotationAuthors + `":"Gitea, KN4CK3R","` + AnnotationURL + `":"https://gitea.io"}}}`
+
+	metadata, err := ParseMetadata(m, strings.NewReader(config))
+	assert.NoError(t, err)
+	assert.False(t, metadata.IsIndex)
+	assert.Equal(t, "linux/amd64", metadata.Platform)
+	assert.Equal(t, "Image Description", metadata.Description)
+	assert.Equal(t, []string{"Gitea", "KN4CK3R"}, metadata.Authors)
+	assert.Equal(t, "https://gitea.io", metadata.ProjectURL)
+	assert.Len(t, metadata.Labels, 3)
+}
+
+func TestValidation(t *testing.T) {
+	assert.True(t, IsValidImageName("gitea/test-image"))
+	assert.True(t, IsValidImageName("a/b/c"))
+	assert.False(t, IsValidImageName("Gitea/image"))
+	assert.False(t, IsValidImageName("gitea/-image"))
+
+	assert.True(t, IsValidTag("latest"))
+	assert.True(t, IsValidTag("v1.0.0-rc.1"))
+	assert.False(t, IsValidTag(".hidden"))
+
+	assert.True(t, IsValidDigest(configDigest))
+	assert.False(t, IsValidDigest("sha256:invalid"))
+}
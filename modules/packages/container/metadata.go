@@ -0,0 +1,104 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"io"
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/validation"
+)
+
+// Annotation keys defined by https://github.com/opencontainers/image-spec/blob/main/annotations.md
+const (
+	AnnotationDescription = "org.opencontainers.image.description"
+	AnnotationURL         = "org.opencontainers.image.url"
+	AnnotationLicenses    = "org.opencontainers.image.licenses"
+	AnnotationAuthors     = "org.opencontainers.image.authors"
+)
+
+// Metadata represents the metadata of a container image or index
+type Metadata struct {
+	MediaType   string            `json:"media_type"`
+	IsIndex     bool              `json:"is_index"`
+	Platform    string            `json:"platform,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Authors     []string          `json:"authors,omitempty"`
+	Licenses    string            `json:"licenses,omitempty"`
+	ProjectURL  string            `json:"project_url,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Manifests   []string          `json:"manifests,omitempty"`
+}
+
+// imageConfig contains the fields of an image configuration which are of interest
+// https://github.com/opencontainers/image-spec/blob/main/config.md
+type imageConfig struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant"`
+	Config       struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"config"`
+}
+
+// ParseMetadata creates the metadata of a manifest. The image configuration is only available for image manifests.
+func ParseMetadata(m *Manifest, config io.Reader) (*Metadata, error) {
+	metadata := &Metadata{
+		MediaType: m.MediaType,
+		IsIndex:   m.IsIndex(),
+		Labels:    map[string]string{},
+	}
+
+	if metadata.IsIndex {
+		for _, d := range m.Manifests {
+			if d.Platform != nil {
+				metadata.Manifests = append(metadata.Manifests, formatPlatform(d.Platform.OS, d.Platform.Architecture, d.Platform.Variant))
+			}
+		}
+	} else if config != nil {
+		var ic imageConfig
+		if err := json.NewDecoder(config).Decode(&ic); err != nil {
+			return nil, err
+		}
+		metadata.Platform = formatPlatform(ic.OS, ic.Architecture, ic.Variant)
+		for k, v := range ic.Config.Labels {
+			metadata.Labels[k] = v
+		}
+	}
+
+	// annotations of the manifest have a higher priority than labels of the image
+	values := make(map[string]string, len(metadata.Labels)+len(m.Annotations))
+	for k, v := range metadata.Labels {
+		values[k] = v
+	}
+	for k, v := range m.Annotations {
+		values[k] = v
+	}
+
+	metadata.Description = values[AnnotationDescription]
+	metadata.Licenses = values[AnnotationLicenses]
+	if authors, ok := values[AnnotationAuthors]; ok && authors != "" {
+		for _, author := range strings.Split(authors, ",") {
+			metadata.Authors = append(metadata.Authors, strings.TrimSpace(author))
+		}
+	}
+	if url := values[AnnotationURL]; validation.IsValidURL(url) {
+		metadata.ProjectURL = url
+	}
+
+	return metadata, nil
+}
+
+func formatPlatform(os, arch, variant string) string {
+	if os == "" && arch == "" {
+		return ""
+	}
+	platform := os + "/" + arch
+	if variant != "" {
+		platform += "/" + variant
+	}
+	return platform
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// HashedSizeReader provide methods to read, sum hashes and a Size method
+type HashedSizeReader interface {
+	io.Reader
+	Sums() (hashMD5, hashSHA1, hashSHA256, hashSHA512 string)
+	Size() int64
+}
+
+// HashedBuffer is buffer which calculates multiple checksums of the content.
+// Small contents are held in memory, bigger contents are spilled to a temporary file.
+type HashedBuffer struct {
+	io.ReadSeeker
+
+	hash *MultiHasher
+	size int64
+	file *os.File
+}
+
+// DefaultMemorySize is the maximum size of a buffer held in memory
+const DefaultMemorySize = 32 * 1024 * 1024
+
+// NewHashedBuffer creates a hashed buffer with the default memory size
+func NewHashedBuffer(r io.Reader) (*HashedBuffer, error) {
+	return NewHashedBufferWithSize(r, DefaultMemorySize)
+}
+
+// NewHashedBufferWithSize creates a hashed buffer which holds at most maxMemorySize bytes in memory
+func NewHashedBufferWithSize(r io.Reader, maxMemorySize int64) (*HashedBuffer, error) {
+	hash := NewMultiHasher()
+
+	var buf bytes.Buffer
+	n, err := io.CopyN(io.MultiWriter(&buf, hash), r, maxMemorySize+1)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if n <= maxMemorySize {
+		return &HashedBuffer{
+			ReadSeeker: bytes.NewReader(buf.Bytes()),
+			hash:       hash,
+			size:       n,
+		}, nil
+	}
+
+	file, err := os.CreateTemp("", "gitea-package-buffer-")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		_ = cleanupTempFile(file)
+		return nil, err
+	}
+	written, err := io.Copy(io.MultiWriter(file, hash), r)
+	if err != nil {
+		_ = cleanupTempFile(file)
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		_ = cleanupTempFile(file)
+		return nil, err
+	}
+
+	return &HashedBuffer{
+		ReadSeeker: file,
+		hash:       hash,
+		size:       n + written,
+		file:       file,
+	}, nil
+}
+
+// Sums gets the MD5, SHA1, SHA256 and SHA512 checksums of the data
+func (b *HashedBuffer) Sums() (hashMD5, hashSHA1, hashSHA256, hashSHA512 string) {
+	return b.hash.Sums()
+}
+
+// Size returns the size of the data
+func (b *HashedBuffer) Size() int64 {
+	return b.size
+}
+
+// Close implements io.Closer and removes the temporary file if one was used
+func (b *HashedBuffer) Close() error {
+	if b.file != nil {
+		return cleanupTempFile(b.file)
+	}
+	return nil
+}
+
+func cleanupTempFile(f *os.File) error {
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"io"
+)
+
+// MultiHasher calculates multiple hashes at the same time
+type MultiHasher struct {
+	md5    hash.Hash
+	sha1   hash.Hash
+	sha256 hash.Hash
+	sha512 hash.Hash
+
+	combinedWriter io.Writer
+}
+
+// NewMultiHasher creates a multi hasher
+func NewMultiHasher() *MultiHasher {
+	md5 := md5.New()
+	sha1 := sha1.New()
+	sha256 := sha256.New()
+	sha512 := sha512.New()
+
+	return &MultiHasher{
+		md5:            md5,
+		sha1:           sha1,
+		sha256:         sha256,
+		sha512:         sha512,
+		combinedWriter: io.MultiWriter(md5, sha1, sha256, sha512),
+	}
+}
+
+// Write implements io.Writer
+func (h *MultiHasher) Write(p []byte) (int, error) {
+	return h.combinedWriter.Write(p)
+}
+
+// Sums gets the MD5, SHA1, SHA256 and SHA512 checksums of the data as hex strings
+func (h *MultiHasher) Sums() (hashMD5, hashSHA1, hashSHA256, hashSHA512 string) {
+	hashMD5 = hex.EncodeToString(h.md5.Sum(nil))
+	hashSHA1 = hex.EncodeToString(h.sha1.Sum(nil))
+	hashSHA256 = hex.EncodeToString(h.sha256.Sum(nil))
+	hashSHA512 = hex.EncodeToString(h.sha512.Sum(nil))
+	return
+}
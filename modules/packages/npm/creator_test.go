@@ -0,0 +1,118 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package npm
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/json"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePackage(t *testing.T) {
+	packageScope := "@scope"
+	packageName := "test-package"
+	packageFullName := packageScope + "/" + packageName
+	packageVersion := "1.0.1-pre"
+	packageAuthor := "KN4CK3R"
+	packageDescription := "Test Description"
+	data := "ZHVtbXkgbnBtIHBhY2thZ2UgdGFyYmFsbCBjb250ZW50"
+	integrity := "sha512-fT+NsCdlDJaHgBt6raxTxAtcVaH7oO/ZM4scl+Fbms5NGPKyJrL/OYeICZ4dbrzM4QG+GjYbcX5g+mOunIXgwQ=="
+
+	// upload builds the request body which is sent by "npm publish"
+	upload := func(name, version, integrity, attachment string) string {
+		body := fmt.Sprintf(`{"_id":%q,"name":%q,"description":%q,"dist-tags":{"latest":%q},"versions":{%q:{"_id":"%s@%s","name":%q,"version":%q,"description":%q,"author":%q,"license":"MIT","homepage":"https://gitea.io/","dist":{"integrity":%q}}}`,
+			name, name, packageDescription, version, version, name, version, name, version, packageDescription, packageAuthor, integrity)
+		if attachment != "" {
+			body += fmt.Sprintf(`,"_attachments":{"%s-%s.tgz":{"content_type":"application/octet-stream","data":%q}}`, name, version, attachment)
+		}
+		return body + "}"
+	}
+
+	t.Run("InvalidUpload", func(t *testing.T) {
+		p, err := ParsePackage(strings.NewReader("\x00"))
+		assert.Nil(t, p)
+		assert.Error(t, err)
+	})
+
+	t.Run("InvalidUploadNoData", func(t *testing.T) {
+		p, err := ParsePackage(strings.NewReader("{}"))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidPackage)
+	})
+
+	t.Run("InvalidPackageName", func(t *testing.T) {
+		test := func(t *testing.T, name string) {
+			p, err := ParsePackage(strings.NewReader(upload(name, packageVersion, integrity, data)))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidPackageName)
+		}
+
+		test(t, " test ")
+		test(t, " test")
+		test(t, "test ")
+		test(t, "te st")
+		test(t, "invalid/scope")
+		test(t, "@invalid/_name")
+		test(t, "@invalid/.name")
+	})
+
+	t.Run("InvalidPackageVersion", func(t *testing.T) {
+		p, err := ParsePackage(strings.NewReader(upload(packageFullName, "first-version", integrity, data)))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidPackageVersion)
+	})
+
+	t.Run("InvalidAttachment", func(t *testing.T) {
+		p, err := ParsePackage(strings.NewReader(upload(packageFullName, packageVersion, integrity, "")))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidAttachment)
+	})
+
+	t.Run("InvalidData", func(t *testing.T) {
+		p, err := ParsePackage(strings.NewReader(upload(packageFullName, packageVersion, integrity, "/")))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidAttachment)
+	})
+
+	t.Run("InvalidIntegrity", func(t *testing.T) {
+		p, err := ParsePackage(strings.NewReader(upload(packageFullName, packageVersion, "sha512-test==", data)))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidIntegrity)
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		p, err := ParsePackage(strings.NewReader(upload(packageFullName, packageVersion, integrity, data)))
+		assert.NotNil(t, p)
+		assert.NoError(t, err)
+
+		assert.Equal(t, packageFullName, p.Name)
+		assert.Equal(t, packageVersion, p.Version)
+		assert.Equal(t, fmt.Sprintf("%s-%s.tgz", packageName, packageVersion), p.Filename)
+		b, _ := base64.StdEncoding.DecodeString(data)
+		assert.Equal(t, b, p.Data)
+		assert.Equal(t, packageScope, p.Metadata.Scope)
+		assert.Equal(t, packageName, p.Metadata.Name)
+		assert.Equal(t, packageDescription, p.Metadata.Description)
+		assert.Equal(t, packageAuthor, p.Metadata.Author)
+		assert.Equal(t, "MIT", p.Metadata.License)
+		assert.Equal(t, "https://gitea.io/", p.Metadata.ProjectURL)
+	})
+}
+
+func TestUserUnmarshalJSON(t *testing.T) {
+	var u User
+	assert.NoError(t, json.Unmarshal([]byte(`"KN4CK3R"`), &u))
+	assert.Equal(t, "KN4CK3R", u.Name)
+
+	u = User{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"name":"KN4CK3R","email":"dummy@gitea.io"}`), &u))
+	assert.Equal(t, "KN4CK3R", u.Name)
+	assert.Equal(t, "dummy@gitea.io", u.Email)
+}
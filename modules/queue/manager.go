@@ -58,6 +58,8 @@ type Flushable interface {
 type ManagedPool interface {
 	// AddWorkers adds a number of worker as group to the pool with the provided timeout. A CancelFunc is provided to cancel the group
 	AddWorkers(number int, timeout time.Duration) context.CancelFunc
+	// NumberInQueue returns the number of items waiting or being processed in the pool
+	NumberInQueue() int64
 	// NumberOfWorkers returns the total number of workers in the pool
 	NumberOfWorkers() int
 	// MaxNumberOfWorkers returns the maximum number of workers the pool can dynamically grow to
@@ -325,6 +327,14 @@ func (q *ManagedQueue) NumberOfWorkers() int {
 	return -1
 }
 
+// NumberInQueue returns the number of items waiting or being processed in the queue
+func (q *ManagedQueue) NumberInQueue() int64 {
+	if pool, ok := q.Managed.(ManagedPool); ok {
+		return pool.NumberInQueue()
+	}
+	return -1
+}
+
 // MaxNumberOfWorkers returns the maximum number of workers for the pool
 func (q *ManagedQueue) MaxNumberOfWorkers() int {
 	if pool, ok := q.Managed.(ManagedPool); ok {
@@ -0,0 +1,61 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package queue
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsCollector exposes the length and the workers of the managed queues at scrape time
+type metricsCollector struct {
+	length     *prometheus.Desc
+	workers    *prometheus.Desc
+	maxWorkers *prometheus.Desc
+}
+
+func init() {
+	prometheus.MustRegister(newMetricsCollector())
+}
+
+func newMetricsCollector() *metricsCollector {
+	labels := []string{"name", "type"}
+	return &metricsCollector{
+		length: prometheus.NewDesc(
+			"gitea_queue_length",
+			"Number of items waiting or being processed in the queue",
+			labels, nil,
+		),
+		workers: prometheus.NewDesc(
+			"gitea_queue_workers",
+			"Number of workers of the queue",
+			labels, nil,
+		),
+		maxWorkers: prometheus.NewDesc(
+			"gitea_queue_max_workers",
+			"Maximum number of workers the queue can grow to",
+			labels, nil,
+		),
+	}
+}
+
+// Describe returns all possible prometheus.Desc
+func (c *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.length
+	ch <- c.workers
+	ch <- c.maxWorkers
+}
+
+// Collect returns the metrics of the queues which have a pool of workers
+func (c *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, q := range GetManager().ManagedQueues() {
+		if _, ok := q.Managed.(ManagedPool); !ok {
+			// wrapping queues are exposed through the queues they wrap
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.length, prometheus.GaugeValue, float64(q.NumberInQueue()), q.Name, string(q.Type))
+		ch <- prometheus.MustNewConstMetric(c.workers, prometheus.GaugeValue, float64(q.NumberOfWorkers()), q.Name, string(q.Type))
+		ch <- prometheus.MustNewConstMetric(c.maxWorkers, prometheus.GaugeValue, float64(q.MaxNumberOfWorkers()), q.Name, string(q.Type))
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	// the items are held by the workers until the end of the test
+	release := make(chan struct{})
+	defer close(release)
+	queue, err := NewChannelQueue(func(data ...Data) {
+		<-release
+	},
+		ChannelQueueConfiguration{
+			WorkerPoolConfiguration: WorkerPoolConfiguration{
+				QueueLength:  10,
+				MaxWorkers:   3,
+				BlockTimeout: 1 * time.Second,
+				BoostTimeout: 5 * time.Minute,
+				BoostWorkers: 1,
+			},
+			Workers: 1,
+			Name:    "TestMetricsCollector",
+		}, &testData{})
+	assert.NoError(t, err)
+	defer GetManager().Remove(queue.(*ChannelQueue).qid)
+	go queue.Run(func(_ func()) {}, func(_ func()) {})
+
+	assert.NoError(t, queue.Push(&testData{"A", 1}))
+	assert.NoError(t, queue.Push(&testData{"B", 2}))
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(newMetricsCollector())
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "name" && label.GetValue() == "TestMetricsCollector" {
+					values[family.GetName()] = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	assert.EqualValues(t, 2, values["gitea_queue_length"])
+	assert.EqualValues(t, 3, values["gitea_queue_max_workers"])
+	// the pool may have been boosted
+	assert.GreaterOrEqual(t, values["gitea_queue_workers"], float64(1))
+}
@@ -98,6 +98,13 @@ func (q *ByteFIFOQueue) PushFunc(data Data, fn func() error) error {
 	return q.byteFIFO.PushFunc(q.terminateCtx, bs, fn)
 }
 
+// NumberInQueue returns the number of items in the fifo and in the pool
+func (q *ByteFIFOQueue) NumberInQueue() int64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.WorkerPool.NumberInQueue() + q.byteFIFO.Len(q.terminateCtx)
+}
+
 // IsEmpty checks if the queue is empty
 func (q *ByteFIFOQueue) IsEmpty() bool {
 	q.lock.Lock()
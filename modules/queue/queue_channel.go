@@ -51,7 +51,7 @@ func NewChannelQueue(handle HandlerFunc, cfg, exemplar interface{}) (Queue, erro
 	shutdownCtx, shutdownCtxCancel := context.WithCancel(terminateCtx)
 
 	queue := &ChannelQueue{
-		WorkerPool:         NewWorkerPool(handle, config.WorkerPoolConfiguration),
+		WorkerPool:         NewWorkerPool(tracedHandler(config.Name, handle), config.WorkerPoolConfiguration),
 		shutdownCtx:        shutdownCtx,
 		shutdownCtxCancel:  shutdownCtxCancel,
 		terminateCtx:       terminateCtx,
@@ -81,6 +81,15 @@ func (q *ChannelQueue) Push(data Data) error {
 	return nil
 }
 
+// PushContext will push data into the queue, the worker handling it runs in the trace of the context
+func (q *ChannelQueue) PushContext(ctx context.Context, data Data) error {
+	if !assignableTo(data, q.exemplar) {
+		return fmt.Errorf("Unable to assign data: %v to same type as exemplar: %v in queue: %s", data, q.exemplar, q.name)
+	}
+	q.WorkerPool.Push(withSpanContext(ctx, data))
+	return nil
+}
+
 // Shutdown processing from this queue
 func (q *ChannelQueue) Shutdown() {
 	q.lock.Lock()
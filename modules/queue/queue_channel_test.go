@@ -5,9 +5,12 @@
 package queue
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"code.gitea.io/gitea/modules/tracing"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -95,3 +98,61 @@ func TestChannelQueue_Batch(t *testing.T) {
 	err = queue.Push(test1)
 	assert.Error(t, err)
 }
+
+func TestChannelQueue_PushContext(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	tracing.SetExporter(exporter, 1)
+	defer tracing.SetExporter(nil, 1)
+
+	handleChan := make(chan *testData)
+	handle := func(data ...Data) {
+		for _, datum := range data {
+			handleChan <- datum.(*testData)
+		}
+	}
+
+	nilFn := func(_ func()) {}
+
+	queue, err := NewChannelQueue(handle,
+		ChannelQueueConfiguration{
+			WorkerPoolConfiguration: WorkerPoolConfiguration{
+				QueueLength:  20,
+				BatchLength:  1,
+				MaxWorkers:   10,
+				BlockTimeout: 1 * time.Second,
+				BoostTimeout: 5 * time.Minute,
+				BoostWorkers: 5,
+			},
+			Workers: 1,
+			Name:    "TestChannelQueue_PushContext",
+		}, &testData{})
+	assert.NoError(t, err)
+
+	go queue.Run(nilFn, nilFn)
+
+	ctx, root := tracing.Start(context.Background(), "request", tracing.SpanKindServer)
+	assert.NoError(t, PushContext(ctx, queue, &testData{"A", 1}))
+	root.End()
+	result := <-handleChan
+	assert.Equal(t, "A", result.TestString)
+
+	// the data pushed without a trace is not traced
+	assert.NoError(t, PushContext(context.Background(), queue, &testData{"B", 2}))
+	result = <-handleChan
+	assert.Equal(t, "B", result.TestString)
+
+	assert.Error(t, PushContext(ctx, queue, testData{"C", 3}))
+
+	var spans []*tracing.SpanData
+	assert.Eventually(t, func() bool {
+		spans = exporter.Spans()
+		return len(spans) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "request", spans[0].Name)
+		assert.Equal(t, "queue TestChannelQueue_PushContext", spans[1].Name)
+		assert.Equal(t, tracing.SpanKindConsumer, spans[1].Kind)
+		assert.Equal(t, root.Context().TraceID, spans[1].TraceID)
+		assert.Equal(t, root.Context().SpanID, spans[1].ParentSpanID)
+	}
+}
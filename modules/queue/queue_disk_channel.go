@@ -132,6 +132,17 @@ func (q *PersistableChannelQueue) Push(data Data) error {
 	}
 }
 
+// PushContext will push the data to the queue, the trace of the context is lost if the data is
+// persisted
+func (q *PersistableChannelQueue) PushContext(ctx context.Context, data Data) error {
+	select {
+	case <-q.closed:
+		return q.internal.Push(data)
+	default:
+		return q.channelQueue.PushContext(ctx, data)
+	}
+}
+
 // Run starts to run the queue
 func (q *PersistableChannelQueue) Run(atShutdown, atTerminate func(func())) {
 	log.Debug("PersistableChannelQueue: %s Starting", q.delayedStarter.name)
@@ -255,7 +266,7 @@ func (q *PersistableChannelQueue) Shutdown() {
 	go func() {
 		log.Trace("PersistableChannelQueue: %s Redirecting remaining data", q.delayedStarter.name)
 		for data := range q.channelQueue.dataChan {
-			_ = q.internal.Push(data)
+			_ = q.internal.Push(unwrapData(data))
 			atomic.AddInt64(&q.channelQueue.numInQueue, -1)
 		}
 		log.Trace("PersistableChannelQueue: %s Done Redirecting remaining data", q.delayedStarter.name)
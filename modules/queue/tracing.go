@@ -0,0 +1,74 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package queue
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/modules/tracing"
+)
+
+// ContextPusher represents a queue which can propagate the trace of the context of a push to
+// the worker handling the data
+type ContextPusher interface {
+	PushContext(ctx context.Context, data Data) error
+}
+
+// PushContext pushes the data to the queue, with the trace of the context if the queue supports it
+func PushContext(ctx context.Context, q Queue, data Data) error {
+	if pusher, ok := q.(ContextPusher); ok {
+		return pusher.PushContext(ctx, data)
+	}
+	return q.Push(data)
+}
+
+// tracedData is data pushed with the span context of the pusher, it only ever lives in memory
+type tracedData struct {
+	spanContext tracing.SpanContext
+	data        Data
+}
+
+// withSpanContext wraps the data with the span context of the context if it is traced
+func withSpanContext(ctx context.Context, data Data) Data {
+	sc := tracing.SpanContextFromContext(ctx)
+	if !sc.IsValid() || !sc.Sampled {
+		return data
+	}
+	return &tracedData{spanContext: sc, data: data}
+}
+
+// unwrapData returns the data without its span context
+func unwrapData(data Data) Data {
+	if traced, ok := data.(*tracedData); ok {
+		return traced.data
+	}
+	return data
+}
+
+// tracedHandler returns a handler unwrapping the data, which runs in a span of the trace of the
+// first traced data if any
+func tracedHandler(name string, handle HandlerFunc) HandlerFunc {
+	return func(data ...Data) {
+		var parent tracing.SpanContext
+		unwrapped := make([]Data, 0, len(data))
+		for _, datum := range data {
+			if traced, ok := datum.(*tracedData); ok {
+				if !parent.IsValid() {
+					parent = traced.spanContext
+				}
+				datum = traced.data
+			}
+			unwrapped = append(unwrapped, datum)
+		}
+
+		if parent.IsValid() {
+			_, span := tracing.Start(tracing.ContextWithRemoteSpanContext(context.Background(), parent), "queue "+name, tracing.SpanKindConsumer)
+			span.SetAttribute("queue.name", name)
+			span.SetAttribute("queue.batch_length", len(unwrapped))
+			defer span.End()
+		}
+		handle(unwrapped...)
+	}
+}
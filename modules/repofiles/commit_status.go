@@ -9,6 +9,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/notification"
 )
 
 // CreateCommitStatus creates a new CommitStatus given a bunch of parameters
@@ -37,5 +38,7 @@ func CreateCommitStatus(repo *models.Repository, creator *models.User, sha strin
 		return fmt.Errorf("NewCommitStatus[repo_id: %d, user_id: %d, sha: %s]: %v", repo.ID, creator.ID, sha, err)
 	}
 
+	notification.NotifyCreateCommitStatus(repo, sha, creator, status)
+
 	return nil
 }
@@ -0,0 +1,353 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+)
+
+// Filter is a node of a parsed filter expression (RFC 7644 section 3.4.2.2).
+// It is one of *LogicalExpression, *NotExpression or *AttributeExpression.
+type Filter interface{}
+
+// LogicalExpression combines two filters with "and" or "or"
+type LogicalExpression struct {
+	Operator string
+	Left     Filter
+	Right    Filter
+}
+
+// NotExpression negates a filter
+type NotExpression struct {
+	Filter Filter
+}
+
+// AttributeExpression compares an attribute with a value.
+// Path is lower cased and stripped of its schema, sub attributes are joined with a dot:
+// emails[value eq "a@b.c"] is parsed as the path "emails.value".
+// Operator is lower cased, Value is a string, bool, float64 or nil and always nil for "pr".
+type AttributeExpression struct {
+	Path     string
+	Operator string
+	Value    interface{}
+}
+
+// ErrInvalidFilter represents an error of a malformed filter or attribute path
+type ErrInvalidFilter struct {
+	Filter string
+	Reason string
+}
+
+// IsErrInvalidFilter checks if an error is a ErrInvalidFilter.
+func IsErrInvalidFilter(err error) bool {
+	_, ok := err.(ErrInvalidFilter)
+	return ok
+}
+
+func (err ErrInvalidFilter) Error() string {
+	return fmt.Sprintf("invalid filter [filter: %s]: %s", err.Filter, err.Reason)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOpenParen
+	tokenCloseParen
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(s string) ([]token, error) {
+	tokens := make([]token, 0, 8)
+	for i := 0; i < len(s); {
+		switch c := s[i]; c {
+		case ' ', '\t', '\r', '\n':
+			i++
+		case '(':
+			tokens = append(tokens, token{kind: tokenOpenParen, text: "("})
+			i++
+		case ')':
+			tokens = append(tokens, token{kind: tokenCloseParen, text: ")"})
+			i++
+		case '[':
+			tokens = append(tokens, token{kind: tokenOpenBracket, text: "["})
+			i++
+		case ']':
+			tokens = append(tokens, token{kind: tokenCloseBracket, text: "]"})
+			i++
+		case '"':
+			end := i + 1
+			for ; end < len(s) && s[end] != '"'; end++ {
+				if s[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(s) {
+				return nil, ErrInvalidFilter{Filter: s, Reason: "unterminated string"}
+			}
+			var value string
+			if err := json.Unmarshal([]byte(s[i:end+1]), &value); err != nil {
+				return nil, ErrInvalidFilter{Filter: s, Reason: "invalid string " + s[i:end+1]}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value})
+			i = end + 1
+		default:
+			end := i
+			for ; end < len(s) && !strings.ContainsRune(" \t\r\n()[]\"", rune(s[end])); end++ {
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: s[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	input  string
+	tokens []token
+	pos    int
+	// prefix is the attribute of the enclosing value path, e.g. "emails" in emails[type eq "work"]
+	prefix string
+}
+
+func (p *parser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{kind: tokenEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return ErrInvalidFilter{Filter: p.input, Reason: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func (p *parser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalExpression{Operator: "or", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalExpression{Operator: "and", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Filter, error) {
+	if p.isKeyword("not") {
+		p.next()
+		if p.next().kind != tokenOpenParen {
+			return nil, p.errorf(`expected "(" after "not"`)
+		}
+		f, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpression{Filter: f}, nil
+	}
+	if p.peek().kind == tokenOpenParen {
+		p.next()
+		return p.parseGroup()
+	}
+	return p.parseAttribute()
+}
+
+// parseGroup parses the rest of a parenthesized filter
+func (p *parser) parseGroup() (Filter, error) {
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.next().kind != tokenCloseParen {
+		return nil, p.errorf(`expected ")"`)
+	}
+	return f, nil
+}
+
+func (p *parser) parseAttribute() (Filter, error) {
+	t := p.next()
+	if t.kind != tokenWord {
+		return nil, p.errorf("expected attribute path, got %q", t.text)
+	}
+	path := normalizeAttribute(t.text)
+	if p.prefix != "" {
+		path = p.prefix + "." + path
+	}
+
+	if p.peek().kind == tokenOpenBracket {
+		if p.prefix != "" {
+			return nil, p.errorf("nested value paths are not allowed")
+		}
+		p.next()
+		p.prefix = path
+		f, err := p.parseOr()
+		p.prefix = ""
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenCloseBracket {
+			return nil, p.errorf(`expected "]"`)
+		}
+		return f, nil
+	}
+
+	op := p.next()
+	if op.kind != tokenWord {
+		return nil, p.errorf("expected operator after %s", t.text)
+	}
+	operator := strings.ToLower(op.text)
+	switch operator {
+	case "pr":
+		return &AttributeExpression{Path: path, Operator: operator}, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, p.errorf("unknown operator %s", op.text)
+	}
+
+	v := p.next()
+	expr := &AttributeExpression{Path: path, Operator: operator}
+	switch v.kind {
+	case tokenString:
+		expr.Value = v.text
+	case tokenWord:
+		switch strings.ToLower(v.text) {
+		case "true":
+			expr.Value = true
+		case "false":
+			expr.Value = false
+		case "null":
+		default:
+			n, err := strconv.ParseFloat(v.text, 64)
+			if err != nil {
+				return nil, p.errorf("invalid value %s", v.text)
+			}
+			expr.Value = n
+		}
+	default:
+		return nil, p.errorf("expected value after %s %s", t.text, op.text)
+	}
+	return expr, nil
+}
+
+// normalizeAttribute lower cases an attribute path and strips the schema URN,
+// urn:ietf:params:scim:schemas:core:2.0:User:userName becomes username
+func normalizeAttribute(attr string) string {
+	attr = strings.ToLower(attr)
+	if strings.HasPrefix(attr, "urn:") {
+		attr = attr[strings.LastIndex(attr, ":")+1:]
+	}
+	return attr
+}
+
+// ParseFilter parses the filter query parameter of a list request
+func ParseFilter(filter string) (Filter, error) {
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{input: filter, tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf("unexpected %q", t.text)
+	}
+	return f, nil
+}
+
+// Path is a parsed attribute path of a PATCH operation like
+// active, members[value eq "2"] or emails[type eq "work"].value
+type Path struct {
+	Attribute    string
+	Filter       Filter
+	SubAttribute string
+}
+
+// ParsePath parses the path of a PATCH operation
+func ParsePath(path string) (*Path, error) {
+	open := strings.IndexByte(path, '[')
+	if open < 0 {
+		attr := normalizeAttribute(path)
+		if attr == "" {
+			return nil, ErrInvalidFilter{Filter: path, Reason: "empty path"}
+		}
+		if idx := strings.IndexByte(attr, '.'); idx >= 0 {
+			return &Path{Attribute: attr[:idx], SubAttribute: attr[idx+1:]}, nil
+		}
+		return &Path{Attribute: attr}, nil
+	}
+
+	end := strings.LastIndexByte(path, ']')
+	if end < open {
+		return nil, ErrInvalidFilter{Filter: path, Reason: `expected "]"`}
+	}
+	result := &Path{Attribute: normalizeAttribute(path[:open])}
+
+	tokens, err := tokenize(path[open+1 : end])
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{input: path, tokens: tokens, prefix: result.Attribute}
+	if result.Filter, err = p.parseOr(); err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf("unexpected %q", t.text)
+	}
+
+	if rest := path[end+1:]; rest != "" {
+		if rest[0] != '.' || len(rest) == 1 {
+			return nil, ErrInvalidFilter{Filter: path, Reason: "invalid sub attribute " + rest}
+		}
+		result.SubAttribute = strings.ToLower(rest[1:])
+	}
+	return result, nil
+}
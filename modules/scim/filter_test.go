@@ -0,0 +1,96 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter(`userName eq "bjensen"`)
+	assert.NoError(t, err)
+	assert.Equal(t, &AttributeExpression{Path: "username", Operator: "eq", Value: "bjensen"}, f)
+
+	f, err = ParseFilter(`urn:ietf:params:scim:schemas:core:2.0:User:userName Eq "b\"j"`)
+	assert.NoError(t, err)
+	assert.Equal(t, &AttributeExpression{Path: "username", Operator: "eq", Value: `b"j`}, f)
+
+	f, err = ParseFilter(`active eq true and emails[type eq "work" and value co "@example.com"] or not (name.formatted pr)`)
+	assert.NoError(t, err)
+	assert.Equal(t, &LogicalExpression{
+		Operator: "or",
+		Left: &LogicalExpression{
+			Operator: "and",
+			Left:     &AttributeExpression{Path: "active", Operator: "eq", Value: true},
+			Right: &LogicalExpression{
+				Operator: "and",
+				Left:     &AttributeExpression{Path: "emails.type", Operator: "eq", Value: "work"},
+				Right:    &AttributeExpression{Path: "emails.value", Operator: "co", Value: "@example.com"},
+			},
+		},
+		Right: &NotExpression{Filter: &AttributeExpression{Path: "name.formatted", Operator: "pr"}},
+	}, f)
+
+	f, err = ParseFilter(`id gt 10 and (meta.created lt "2021-01-01T00:00:00Z" or externalId eq null)`)
+	assert.NoError(t, err)
+	assert.Equal(t, &LogicalExpression{
+		Operator: "and",
+		Left:     &AttributeExpression{Path: "id", Operator: "gt", Value: float64(10)},
+		Right: &LogicalExpression{
+			Operator: "or",
+			Left:     &AttributeExpression{Path: "meta.created", Operator: "lt", Value: "2021-01-01T00:00:00Z"},
+			Right:    &AttributeExpression{Path: "externalid", Operator: "eq"},
+		},
+	}, f)
+
+	for _, invalid := range []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName like "a"`,
+		`userName eq "a`,
+		`userName eq "a" and`,
+		`(userName eq "a"`,
+		`userName eq "a")`,
+		`emails[value eq "a"`,
+		`emails[type[value eq "a"]]`,
+		`not userName eq "a"`,
+	} {
+		_, err = ParseFilter(invalid)
+		assert.True(t, IsErrInvalidFilter(err), "filter %q", invalid)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	p, err := ParsePath("active")
+	assert.NoError(t, err)
+	assert.Equal(t, &Path{Attribute: "active"}, p)
+
+	p, err = ParsePath("name.givenName")
+	assert.NoError(t, err)
+	assert.Equal(t, &Path{Attribute: "name", SubAttribute: "givenname"}, p)
+
+	p, err = ParsePath(`members[value eq "2"]`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Path{
+		Attribute: "members",
+		Filter:    &AttributeExpression{Path: "members.value", Operator: "eq", Value: "2"},
+	}, p)
+
+	p, err = ParsePath(`emails[type eq "work"].value`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Path{
+		Attribute:    "emails",
+		Filter:       &AttributeExpression{Path: "emails.type", Operator: "eq", Value: "work"},
+		SubAttribute: "value",
+	}, p)
+
+	for _, invalid := range []string{``, `members[value eq "2"`, `members[value eq]`, `emails[type eq "work"]value`} {
+		_, err = ParsePath(invalid)
+		assert.True(t, IsErrInvalidFilter(err), "path %q", invalid)
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+// Actions settings
+var (
+	Actions = struct {
+		Storage
+		Enabled           bool
+		ZombieTimeout     time.Duration
+		MaxLogSize        int64
+		TaskTokenLifetime time.Duration
+	}{
+		Enabled:           false,
+		ZombieTimeout:     10 * time.Minute,
+		MaxLogSize:        32 << 20,
+		TaskTokenLifetime: 3 * time.Hour,
+	}
+)
+
+func newActionsService() {
+	sec := Cfg.Section("actions")
+	if err := sec.MapTo(&Actions); err != nil {
+		log.Fatal("Failed to map Actions settings: %v", err)
+	}
+
+	Actions.Storage = getStorage("actions_log", sec.Key("STORAGE_TYPE").MustString(""), sec)
+}
@@ -0,0 +1,22 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import "code.gitea.io/gitea/modules/log"
+
+// Audit settings
+var (
+	Audit = struct {
+		Enabled bool
+	}{
+		Enabled: true,
+	}
+)
+
+func newAuditService() {
+	if err := Cfg.Section("audit").MapTo(&Audit); err != nil {
+		log.Fatal("Failed to map Audit settings: %v", err)
+	}
+}
@@ -9,9 +9,15 @@ import "code.gitea.io/gitea/modules/log"
 // Federation settings
 var (
 	Federation = struct {
-		Enabled bool
+		Enabled         bool
+		AllowedHostList string
+		MaxSize         int64
+		DeliverTimeout  int
 	}{
-		Enabled: true,
+		Enabled:         true,
+		AllowedHostList: "external",
+		MaxSize:         4 << 20,
+		DeliverTimeout:  10,
 	}
 )
 
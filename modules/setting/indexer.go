@@ -44,6 +44,7 @@ var (
 		IncludePatterns    []glob.Glob
 		ExcludePatterns    []glob.Glob
 		ExcludeVendored    bool
+		ExtractSymbols     bool
 	}{
 		IssueType:        "bleve",
 		IssuePath:        "indexers/issues.bleve",
@@ -58,6 +59,7 @@ var (
 		RepoIndexerName:    "gitea_codes",
 		MaxIndexerFileSize: 1024 * 1024,
 		ExcludeVendored:    true,
+		ExtractSymbols:     true,
 	}
 )
 
@@ -80,7 +82,11 @@ func newIndexerService() {
 
 	Indexer.RepoIndexerEnabled = sec.Key("REPO_INDEXER_ENABLED").MustBool(false)
 	Indexer.RepoType = sec.Key("REPO_INDEXER_TYPE").MustString("bleve")
-	Indexer.RepoPath = filepath.ToSlash(sec.Key("REPO_INDEXER_PATH").MustString(filepath.ToSlash(filepath.Join(AppDataPath, "indexers/repos.bleve"))))
+	defaultRepoPath := "indexers/repos.bleve"
+	if Indexer.RepoType == "trigram" {
+		defaultRepoPath = "indexers/repos.trigram"
+	}
+	Indexer.RepoPath = filepath.ToSlash(sec.Key("REPO_INDEXER_PATH").MustString(filepath.ToSlash(filepath.Join(AppDataPath, defaultRepoPath))))
 	if !filepath.IsAbs(Indexer.RepoPath) {
 		Indexer.RepoPath = filepath.ToSlash(filepath.Join(AppWorkPath, Indexer.RepoPath))
 	}
@@ -90,6 +96,7 @@ func newIndexerService() {
 	Indexer.IncludePatterns = IndexerGlobFromString(sec.Key("REPO_INDEXER_INCLUDE").MustString(""))
 	Indexer.ExcludePatterns = IndexerGlobFromString(sec.Key("REPO_INDEXER_EXCLUDE").MustString(""))
 	Indexer.ExcludeVendored = sec.Key("REPO_INDEXER_EXCLUDE_VENDORED").MustBool(true)
+	Indexer.ExtractSymbols = sec.Key("REPO_INDEXER_EXTRACT_SYMBOLS").MustBool(true)
 	Indexer.MaxIndexerFileSize = sec.Key("MAX_FILE_SIZE").MustInt64(1024 * 1024)
 	Indexer.StartupTimeout = sec.Key("STARTUP_TIMEOUT").MustDuration(30 * time.Second)
 }
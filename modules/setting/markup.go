@@ -7,6 +7,7 @@ package setting
 import (
 	"regexp"
 	"strings"
+	"time"
 
 	"code.gitea.io/gitea/modules/log"
 
@@ -20,6 +21,24 @@ var (
 	MermaidMaxSourceCharacters int
 )
 
+// MarkupDiagram represents the settings of the diagrams rendered server-side in markdown code blocks
+var MarkupDiagram = struct {
+	Timeout             time.Duration
+	MaxSourceCharacters int
+	Renderers           []*MarkupDiagramRenderer
+}{
+	Timeout:             10 * time.Second,
+	MaxSourceCharacters: 50000,
+}
+
+// MarkupDiagramRenderer defines a local command configured in ini rendering the diagrams
+// of a code block language to SVG
+type MarkupDiagramRenderer struct {
+	Enabled  bool
+	Language string
+	Command  string
+}
+
 // MarkupRenderer defines the external parser configured in ini
 type MarkupRenderer struct {
 	Enabled              bool
@@ -44,6 +63,7 @@ func newMarkup() {
 	MermaidMaxSourceCharacters = Cfg.Section("markup").Key("MERMAID_MAX_SOURCE_CHARACTERS").MustInt(5000)
 	ExternalMarkupRenderers = make([]*MarkupRenderer, 0, 10)
 	ExternalSanitizerRules = make([]MarkupSanitizerRule, 0, 10)
+	MarkupDiagram.Renderers = make([]*MarkupDiagramRenderer, 0, 3)
 
 	for _, sec := range Cfg.Section("markup").ChildSections() {
 		name := strings.TrimPrefix(sec.Name(), "markup.")
@@ -54,12 +74,35 @@ func newMarkup() {
 
 		if name == "sanitizer" || strings.HasPrefix(name, "sanitizer.") {
 			newMarkupSanitizer(name, sec)
+		} else if name == "diagram" || strings.HasPrefix(name, "diagram.") {
+			newMarkupDiagram(name, sec)
 		} else {
 			newMarkupRenderer(name, sec)
 		}
 	}
 }
 
+func newMarkupDiagram(name string, sec *ini.Section) {
+	if name == "diagram" {
+		MarkupDiagram.Timeout = sec.Key("TIMEOUT").MustDuration(MarkupDiagram.Timeout)
+		MarkupDiagram.MaxSourceCharacters = sec.Key("MAX_SOURCE_CHARACTERS").MustInt(MarkupDiagram.MaxSourceCharacters)
+		return
+	}
+
+	language := strings.TrimPrefix(name, "diagram.")
+	command := sec.Key("RENDER_COMMAND").MustString("")
+	if len(strings.Fields(command)) == 0 {
+		log.Warn(" RENDER_COMMAND is empty, diagram renderer " + language + " ignored")
+		return
+	}
+
+	MarkupDiagram.Renderers = append(MarkupDiagram.Renderers, &MarkupDiagramRenderer{
+		Enabled:  sec.Key("ENABLED").MustBool(false),
+		Language: language,
+		Command:  command,
+	})
+}
+
 func newMarkupSanitizer(name string, sec *ini.Section) {
 	rule, ok := createMarkupSanitizerRule(name, sec)
 	if ok {
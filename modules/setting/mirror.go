@@ -18,12 +18,17 @@ var (
 		DisableNewPush  bool
 		DefaultInterval time.Duration
 		MinInterval     time.Duration
+		// PushDebounce is the delay without further pushes before a push mirror synced on push is synced
+		PushDebounce      time.Duration
+		PushHistoryLength int
 	}{
-		Enabled:         true,
-		DisableNewPull:  false,
-		DisableNewPush:  false,
-		MinInterval:     10 * time.Minute,
-		DefaultInterval: 8 * time.Hour,
+		Enabled:           true,
+		DisableNewPull:    false,
+		DisableNewPush:    false,
+		MinInterval:       10 * time.Minute,
+		DefaultInterval:   8 * time.Hour,
+		PushDebounce:      10 * time.Second,
+		PushHistoryLength: 10,
 	}
 )
 
@@ -56,4 +61,8 @@ func newMirror() {
 		}
 		log.Warn("Mirror.DefaultInterval is less than Mirror.MinInterval, set to %s", Mirror.DefaultInterval.String())
 	}
+	if Mirror.PushHistoryLength < 1 {
+		log.Warn("Mirror.PushHistoryLength is too low, set to 1")
+		Mirror.PushHistoryLength = 1
+	}
 }
@@ -60,6 +60,12 @@ var (
 			MaxFiles     int
 		} `ini:"-"`
 
+		// Repository import settings
+		Import struct {
+			MaxSize  int64
+			MaxFiles int
+		} `ini:"-"`
+
 		// Repository local settings
 		Local struct {
 			LocalCopyPath string
@@ -178,6 +184,15 @@ var (
 			MaxFiles:     5,
 		},
 
+		// Repository import settings
+		Import: struct {
+			MaxSize  int64
+			MaxFiles int
+		}{
+			MaxSize:  1024,
+			MaxFiles: 100000,
+		},
+
 		// Repository local settings
 		Local: struct {
 			LocalCopyPath string
@@ -252,6 +267,10 @@ var (
 	RepoArchive = struct {
 		Storage
 	}{}
+
+	RepoExport = struct {
+		Storage
+	}{}
 )
 
 func newRepository() {
@@ -281,6 +300,8 @@ func newRepository() {
 		log.Fatal("Failed to map Repository.Editor settings: %v", err)
 	} else if err = Cfg.Section("repository.upload").MapTo(&Repository.Upload); err != nil {
 		log.Fatal("Failed to map Repository.Upload settings: %v", err)
+	} else if err = Cfg.Section("repository.import").MapTo(&Repository.Import); err != nil {
+		log.Fatal("Failed to map Repository.Import settings: %v", err)
 	} else if err = Cfg.Section("repository.local").MapTo(&Repository.Local); err != nil {
 		log.Fatal("Failed to map Repository.Local settings: %v", err)
 	} else if err = Cfg.Section("repository.pull-request").MapTo(&Repository.PullRequest); err != nil {
@@ -331,4 +352,5 @@ func newRepository() {
 	}
 
 	RepoArchive.Storage = getStorage("repo-archive", "", nil)
+	RepoExport.Storage = getStorage("repo-export", "", nil)
 }
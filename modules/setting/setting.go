@@ -872,6 +872,8 @@ func NewContext() {
 
 	newAttachmentService()
 	newLFSService()
+	newPackagesService()
+	newActionsService()
 
 	timeFormatKey := Cfg.Section("time").Key("FORMAT").MustString("")
 	if timeFormatKey != "" {
@@ -1222,6 +1224,9 @@ func NewServices() {
 	newProject()
 	newMimeTypeMap()
 	newFederationService()
+	newAuditService()
+	newSCIMService()
+	newTracingService()
 }
 
 // NewServicesForInstall initializes the services for install
@@ -42,6 +42,8 @@ func getStorage(name, typ string, targetSec *ini.Section) Storage {
 	sec.Key("MINIO_BUCKET").MustString("gitea")
 	sec.Key("MINIO_LOCATION").MustString("us-east-1")
 	sec.Key("MINIO_USE_SSL").MustBool(false)
+	sec.Key("AZURE_BLOB_CONTAINER").MustString("gitea")
+	sec.Key("GCS_BUCKET").MustString("gitea")
 
 	if targetSec == nil {
 		targetSec, _ = Cfg.NewSection(name)
@@ -86,6 +88,8 @@ func getStorage(name, typ string, targetSec *ini.Section) Storage {
 		storage.Section.Key("PATH").SetValue(storage.Path)
 	}
 	storage.Section.Key("MINIO_BASE_PATH").MustString(name + "/")
+	storage.Section.Key("AZURE_BLOB_BASE_PATH").MustString(name + "/")
+	storage.Section.Key("GCS_BASE_PATH").MustString(name + "/")
 
 	return storage
 }
@@ -0,0 +1,53 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+// Tracing settings
+var (
+	Tracing = struct {
+		Enabled       bool
+		Endpoint      string
+		Headers       map[string]string `ini:"-"`
+		ServiceName   string
+		SampleRatio   float64
+		Timeout       time.Duration
+		FlushInterval time.Duration
+	}{
+		Enabled:       false,
+		Endpoint:      "http://localhost:4318/v1/traces",
+		ServiceName:   "gitea",
+		SampleRatio:   1,
+		Timeout:       10 * time.Second,
+		FlushInterval: 5 * time.Second,
+	}
+)
+
+func newTracingService() {
+	sec := Cfg.Section("tracing")
+	if err := sec.MapTo(&Tracing); err != nil {
+		log.Fatal("Failed to map Tracing settings: %v", err)
+	}
+
+	Tracing.Headers = map[string]string{}
+	for _, header := range sec.Key("HEADERS").Strings(",") {
+		if i := strings.IndexByte(header, '='); i > 0 {
+			Tracing.Headers[strings.TrimSpace(header[:i])] = strings.TrimSpace(header[i+1:])
+		}
+	}
+
+	if Tracing.SampleRatio < 0 || Tracing.SampleRatio > 1 {
+		log.Fatal("tracing.SAMPLE_RATIO must be between 0 and 1, got %v", Tracing.SampleRatio)
+	}
+	if Tracing.FlushInterval <= 0 {
+		log.Fatal("tracing.FLUSH_INTERVAL must be positive, got %v", Tracing.FlushInterval)
+	}
+}
@@ -6,6 +6,7 @@ package setting
 
 import (
 	"net/url"
+	"time"
 
 	"code.gitea.io/gitea/modules/log"
 )
@@ -22,13 +23,22 @@ var (
 		ProxyURL        string
 		ProxyURLFixed   *url.URL
 		ProxyHosts      []string
+
+		MaxAttempts          int
+		RetryBaseDelay       time.Duration
+		RetryMaxDelay        time.Duration
+		DisableAfterFailures int
 	}{
-		QueueLength:    1000,
-		DeliverTimeout: 5,
-		SkipTLSVerify:  false,
-		PagingNum:      10,
-		ProxyURL:       "",
-		ProxyHosts:     []string{},
+		QueueLength:          1000,
+		DeliverTimeout:       5,
+		SkipTLSVerify:        false,
+		PagingNum:            10,
+		ProxyURL:             "",
+		ProxyHosts:           []string{},
+		MaxAttempts:          5,
+		RetryBaseDelay:       30 * time.Second,
+		RetryMaxDelay:        time.Hour,
+		DisableAfterFailures: 20,
 	}
 )
 
@@ -50,4 +60,11 @@ func newWebhookService() {
 		}
 	}
 	Webhook.ProxyHosts = sec.Key("PROXY_HOSTS").Strings(",")
+	Webhook.MaxAttempts = sec.Key("MAX_ATTEMPTS").MustInt(5)
+	if Webhook.MaxAttempts < 1 {
+		Webhook.MaxAttempts = 1
+	}
+	Webhook.RetryBaseDelay = sec.Key("RETRY_BASE_DELAY").MustDuration(30 * time.Second)
+	Webhook.RetryMaxDelay = sec.Key("RETRY_MAX_DELAY").MustDuration(time.Hour)
+	Webhook.DisableAfterFailures = sec.Key("DISABLE_AFTER_FAILURES").MustInt(20)
 }
@@ -0,0 +1,29 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ssh
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	sessionsActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "gitea",
+		Subsystem: "ssh",
+		Name:      "sessions_active",
+		Help:      "Number of open sessions of the builtin SSH server",
+	})
+
+	sessionsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "gitea",
+		Subsystem: "ssh",
+		Name:      "sessions_total",
+		Help:      "Number of sessions opened on the builtin SSH server",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(sessionsActive, sessionsTotal)
+}
@@ -60,6 +60,10 @@ func getExitStatusFromError(err error) int {
 }
 
 func sessionHandler(session ssh.Session) {
+	sessionsTotal.Inc()
+	sessionsActive.Inc()
+	defer sessionsActive.Dec()
+
 	keyID := fmt.Sprintf("%d", session.Context().Value(giteaKeyID).(int64))
 
 	command := session.RawCommand()
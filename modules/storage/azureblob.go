@@ -0,0 +1,462 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+var _ ObjectStorage = &AzureBlobStorage{}
+
+// AzureBlobStorageType is the type descriptor for azure blob storage
+const AzureBlobStorageType Type = "azureblob"
+
+// azureBlobAPIVersion is the version of the Blob service REST API which is used
+const azureBlobAPIVersion = "2019-12-12"
+
+// azureBlobBlockSize is the size of the blocks which large files are uploaded in
+const azureBlobBlockSize = 8 * 1024 * 1024
+
+// AzureBlobStorageConfig represents the configuration for an azure blob storage
+type AzureBlobStorageConfig struct {
+	Endpoint    string `ini:"AZURE_BLOB_ENDPOINT"`
+	AccountName string `ini:"AZURE_BLOB_ACCOUNT_NAME"`
+	AccountKey  string `ini:"AZURE_BLOB_ACCOUNT_KEY"`
+	Container   string `ini:"AZURE_BLOB_CONTAINER"`
+	BasePath    string `ini:"AZURE_BLOB_BASE_PATH"`
+}
+
+// AzureBlobStorage returns an azure blob storage container
+type AzureBlobStorage struct {
+	ctx         context.Context
+	client      *http.Client
+	endpoint    *url.URL
+	accountName string
+	accountKey  []byte
+	container   string
+	basePath    string
+}
+
+// azureBlobError is the error returned by the Blob service
+type azureBlobError struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (err *azureBlobError) Error() string {
+	return fmt.Sprintf("azure blob storage: %d %s: %s", err.StatusCode, err.Code, strings.TrimSpace(err.Message))
+}
+
+func convertAzureBlobErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	errResp, ok := err.(*azureBlobError)
+	if !ok {
+		return err
+	}
+
+	switch errResp.StatusCode {
+	case http.StatusNotFound:
+		return os.ErrNotExist
+	case http.StatusForbidden:
+		return os.ErrPermission
+	}
+	return err
+}
+
+// NewAzureBlobStorage returns an azure blob storage
+func NewAzureBlobStorage(ctx context.Context, cfg interface{}) (ObjectStorage, error) {
+	configInterface, err := toConfig(AzureBlobStorageConfig{}, cfg)
+	if err != nil {
+		return nil, err
+	}
+	config := configInterface.(AzureBlobStorageConfig)
+
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", config.AccountName)
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil {
+		return nil, ErrInvalidConfiguration{cfg: cfg, err: err}
+	}
+	accountKey, err := base64.StdEncoding.DecodeString(config.AccountKey)
+	if err != nil {
+		return nil, ErrInvalidConfiguration{cfg: cfg, err: fmt.Errorf("invalid account key: %v", err)}
+	}
+
+	log.Info("Creating Azure Blob storage at %s:%s with base path %s", config.Endpoint, config.Container, config.BasePath)
+
+	a := &AzureBlobStorage{
+		ctx:         ctx,
+		client:      newObjectStorageClient(),
+		endpoint:    endpoint,
+		accountName: config.AccountName,
+		accountKey:  accountKey,
+		container:   config.Container,
+		basePath:    config.BasePath,
+	}
+
+	resp, err := a.do(http.MethodPut, "", url.Values{"restype": {"container"}}, nil, nil)
+	if err != nil {
+		// Check to see if we already own this container (which happens if you run this twice)
+		if errResp, ok := err.(*azureBlobError); !ok || errResp.Code != "ContainerAlreadyExists" {
+			return nil, convertAzureBlobErr(err)
+		}
+	} else {
+		resp.Body.Close()
+	}
+
+	return a, nil
+}
+
+func (a *AzureBlobStorage) buildAzureBlobPath(p string) string {
+	return strings.TrimPrefix(path.Join(a.basePath, p), "/")
+}
+
+// blobURL returns the URL of the container or of a blob of it
+func (a *AzureBlobStorage) blobURL(blobPath string, query url.Values) *url.URL {
+	u := *a.endpoint
+	u.Path = a.endpoint.Path + "/" + a.container
+	u.RawPath = ""
+	if blobPath != "" {
+		u.Path += "/" + blobPath
+	}
+	u.RawQuery = query.Encode()
+	return &u
+}
+
+// do sends a request authorized with the shared key of the account to the Blob service.
+// Responses with an error status are returned as *azureBlobError.
+func (a *AzureBlobStorage) do(method, blobPath string, query url.Values, header http.Header, body io.Reader) (*http.Response, error) {
+	req, err := newObjectStorageRequest(a.ctx, method, a.blobURL(blobPath, query).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, values := range header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureBlobAPIVersion)
+	req.Header.Set("Authorization", "SharedKey "+a.accountName+":"+a.sign(a.stringToSign(req)))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		errResp := &azureBlobError{StatusCode: resp.StatusCode}
+		if data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024)); err == nil && len(data) > 0 {
+			_ = xml.Unmarshal(data, errResp)
+		}
+		if errResp.Code == "" {
+			errResp.Code = resp.Header.Get("x-ms-error-code")
+		}
+		return nil, errResp
+	}
+	return resp, nil
+}
+
+// stringToSign builds the string which is signed by the shared key authorization of a request
+func (a *AzureBlobStorage) stringToSign(req *http.Request) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	msHeaders := make([]string, 0, 4)
+	for k := range req.Header {
+		if k = strings.ToLower(k); strings.HasPrefix(k, "x-ms-") {
+			msHeaders = append(msHeaders, k)
+		}
+	}
+	sort.Strings(msHeaders)
+	var canonicalizedHeaders strings.Builder
+	for _, k := range msHeaders {
+		canonicalizedHeaders.WriteString(k + ":" + strings.Join(req.Header.Values(k), ",") + "\n")
+	}
+
+	var canonicalizedResource strings.Builder
+	canonicalizedResource.WriteString("/" + a.accountName + req.URL.EscapedPath())
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for k := range query {
+		params = append(params, k)
+	}
+	sort.Strings(params)
+	for _, k := range params {
+		values := query[k]
+		sort.Strings(values)
+		canonicalizedResource.WriteString("\n" + strings.ToLower(k) + ":" + strings.Join(values, ","))
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, x-ms-date is used instead
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		canonicalizedHeaders.String() + canonicalizedResource.String(),
+	}, "\n")
+}
+
+func (a *AzureBlobStorage) sign(stringToSign string) string {
+	h := hmac.New(sha256.New, a.accountKey)
+	_, _ = h.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (a *AzureBlobStorage) newObject(info *objectFileInfo) *rangeObject {
+	return &rangeObject{
+		info: info,
+		openRange: func(offset int64) (io.ReadCloser, error) {
+			resp, err := a.do(http.MethodGet, info.name, nil, http.Header{
+				"x-ms-range": {fmt.Sprintf("bytes=%d-", offset)},
+			}, nil)
+			if err != nil {
+				return nil, convertAzureBlobErr(err)
+			}
+			return resp.Body, nil
+		},
+	}
+}
+
+// Open open a file
+func (a *AzureBlobStorage) Open(path string) (Object, error) {
+	info, err := a.stat(a.buildAzureBlobPath(path))
+	if err != nil {
+		return nil, err
+	}
+	return a.newObject(info), nil
+}
+
+// Save save a file to azure blob storage. Files of a known size up to one block are streamed,
+// larger files or files of an unknown size are uploaded in blocks which are committed at the end.
+func (a *AzureBlobStorage) Save(path string, r io.Reader, size int64) (int64, error) {
+	blobPath := a.buildAzureBlobPath(path)
+	header := http.Header{
+		"x-ms-blob-type":         {"BlockBlob"},
+		"x-ms-blob-content-type": {"application/octet-stream"},
+	}
+
+	if size >= 0 && size <= azureBlobBlockSize {
+		resp, err := a.do(http.MethodPut, blobPath, nil, header, &sizedReader{Reader: r, size: size})
+		if err != nil {
+			return 0, convertAzureBlobErr(err)
+		}
+		resp.Body.Close()
+		return size, nil
+	}
+
+	buf := make([]byte, azureBlobBlockSize)
+
+	var written int64
+	blockIDs := make([]string, 0, 1)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		isLast := err != nil
+
+		if isLast && len(blockIDs) == 0 {
+			// the whole file fits into one request
+			resp, err := a.do(http.MethodPut, blobPath, nil, header, bytes.NewReader(buf[:n]))
+			if err != nil {
+				return 0, convertAzureBlobErr(err)
+			}
+			resp.Body.Close()
+			return int64(n), nil
+		}
+
+		if n > 0 {
+			blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%010d", len(blockIDs))))
+			resp, err := a.do(http.MethodPut, blobPath, url.Values{"comp": {"block"}, "blockid": {blockID}}, nil, bytes.NewReader(buf[:n]))
+			if err != nil {
+				return 0, convertAzureBlobErr(err)
+			}
+			resp.Body.Close()
+			blockIDs = append(blockIDs, blockID)
+			written += int64(n)
+		}
+
+		if isLast {
+			break
+		}
+	}
+
+	var blockList bytes.Buffer
+	blockList.WriteString(xml.Header + "<BlockList>")
+	for _, blockID := range blockIDs {
+		blockList.WriteString("<Latest>" + blockID + "</Latest>")
+	}
+	blockList.WriteString("</BlockList>")
+	delete(header, "x-ms-blob-type")
+	resp, err := a.do(http.MethodPut, blobPath, url.Values{"comp": {"blocklist"}}, header, &blockList)
+	if err != nil {
+		return 0, convertAzureBlobErr(err)
+	}
+	resp.Body.Close()
+	return written, nil
+}
+
+func (a *AzureBlobStorage) stat(blobPath string) (*objectFileInfo, error) {
+	resp, err := a.do(http.MethodHead, blobPath, nil, nil, nil)
+	if err != nil {
+		return nil, convertAzureBlobErr(err)
+	}
+	resp.Body.Close()
+
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &objectFileInfo{
+		name:    blobPath,
+		size:    size,
+		modTime: modTime,
+	}, nil
+}
+
+// Stat returns the stat information of the object
+func (a *AzureBlobStorage) Stat(path string) (os.FileInfo, error) {
+	return a.stat(a.buildAzureBlobPath(path))
+}
+
+// Delete delete a file
+func (a *AzureBlobStorage) Delete(path string) error {
+	resp, err := a.do(http.MethodDelete, a.buildAzureBlobPath(path), nil, nil, nil)
+	if err != nil {
+		if errResp, ok := err.(*azureBlobError); ok && errResp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return convertAzureBlobErr(err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// URL gets the redirect URL to a file. The shared access signature of the link is valid for 5 minutes.
+func (a *AzureBlobStorage) URL(path, name string) (*url.URL, error) {
+	blobPath := a.buildAzureBlobPath(path)
+	if _, err := a.stat(blobPath); err != nil {
+		return nil, err
+	}
+
+	expiry := time.Now().UTC().Add(5 * time.Minute).Format("2006-01-02T15:04:05Z")
+	contentDisposition := "attachment; filename=\"" + quoteEscaper.Replace(name) + "\""
+	stringToSign := strings.Join([]string{
+		"r",    // signed permissions
+		"",     // signed start
+		expiry, // signed expiry
+		"/blob/" + a.accountName + "/" + a.container + "/" + blobPath,
+		"",                  // signed identifier
+		"",                  // signed IP
+		"",                  // signed protocol
+		azureBlobAPIVersion, // signed version
+		"b",                 // signed resource
+		"",                  // signed snapshot time
+		"",                  // response cache control
+		contentDisposition,  // response content disposition
+		"",                  // response content encoding
+		"",                  // response content language
+		"",                  // response content type
+	}, "\n")
+
+	return a.blobURL(blobPath, url.Values{
+		"sv":   {azureBlobAPIVersion},
+		"sr":   {"b"},
+		"sp":   {"r"},
+		"se":   {expiry},
+		"rscd": {contentDisposition},
+		"sig":  {a.sign(stringToSign)},
+	}), nil
+}
+
+type azureBlobList struct {
+	Blobs []struct {
+		Name       string `xml:"Name"`
+		Properties struct {
+			ContentLength int64  `xml:"Content-Length"`
+			LastModified  string `xml:"Last-Modified"`
+		} `xml:"Properties"`
+	} `xml:"Blobs>Blob"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+// IterateObjects iterates across the objects in the azure blob storage
+func (a *AzureBlobStorage) IterateObjects(fn func(path string, obj Object) error) error {
+	marker := ""
+	for {
+		query := url.Values{
+			"restype": {"container"},
+			"comp":    {"list"},
+			"prefix":  {a.basePath},
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp, err := a.do(http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return convertAzureBlobErr(err)
+		}
+		var list azureBlobList
+		err = xml.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, blob := range list.Blobs {
+			modTime, _ := http.ParseTime(blob.Properties.LastModified)
+			object := a.newObject(&objectFileInfo{
+				name:    blob.Name,
+				size:    blob.Properties.ContentLength,
+				modTime: modTime,
+			})
+			if err := func() error {
+				defer object.Close()
+				return fn(strings.TrimPrefix(blob.Name, a.basePath), object)
+			}(); err != nil {
+				return err
+			}
+		}
+
+		if list.NextMarker == "" {
+			return nil
+		}
+		marker = list.NextMarker
+	}
+}
+
+func init() {
+	RegisterStorageType(AzureBlobStorageType, NewAzureBlobStorage)
+}
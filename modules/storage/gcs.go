@@ -0,0 +1,521 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+var _ ObjectStorage = &GCSStorage{}
+
+// GCSStorageType is the type descriptor for google cloud storage
+const GCSStorageType Type = "gcs"
+
+// gcsDefaultEndpoint is the endpoint of google cloud storage, others are only used by emulators
+const gcsDefaultEndpoint = "https://storage.googleapis.com"
+
+// gcsChunkSize is the size of the chunks which files are uploaded in, it has to be a multiple of 256 KiB
+const gcsChunkSize = 8 * 1024 * 1024
+
+// GCSStorageConfig represents the configuration for a google cloud storage
+type GCSStorageConfig struct {
+	Endpoint        string `ini:"GCS_ENDPOINT"`
+	Bucket          string `ini:"GCS_BUCKET"`
+	ProjectID       string `ini:"GCS_PROJECT_ID"`
+	CredentialsFile string `ini:"GCS_CREDENTIALS_FILE"`
+	BasePath        string `ini:"GCS_BASE_PATH"`
+}
+
+// GCSStorage returns a google cloud storage bucket
+type GCSStorage struct {
+	ctx      context.Context
+	client   *http.Client
+	endpoint string
+	bucket   string
+	basePath string
+
+	// the service account signing the redirect URLs, URLs are not supported without one
+	signerEmail string
+	signerKey   *rsa.PrivateKey
+}
+
+// gcsError is the error returned by the JSON API
+type gcsError struct {
+	StatusCode int
+	Message    string
+}
+
+func (err *gcsError) Error() string {
+	return fmt.Sprintf("google cloud storage: %d: %s", err.StatusCode, err.Message)
+}
+
+func convertGCSErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	errResp, ok := err.(*gcsError)
+	if !ok {
+		return err
+	}
+
+	switch errResp.StatusCode {
+	case http.StatusNotFound:
+		return os.ErrNotExist
+	case http.StatusForbidden:
+		return os.ErrPermission
+	}
+	return err
+}
+
+// gcsObjectInfo is the metadata of an object returned by the JSON API
+type gcsObjectInfo struct {
+	Name    string    `json:"name"`
+	Size    string    `json:"size"`
+	Updated time.Time `json:"updated"`
+}
+
+func (info *gcsObjectInfo) toFileInfo() *objectFileInfo {
+	size, _ := strconv.ParseInt(info.Size, 10, 64)
+	return &objectFileInfo{
+		name:    info.Name,
+		size:    size,
+		modTime: info.Updated,
+	}
+}
+
+// NewGCSStorage returns a google cloud storage
+func NewGCSStorage(ctx context.Context, cfg interface{}) (ObjectStorage, error) {
+	configInterface, err := toConfig(GCSStorageConfig{}, cfg)
+	if err != nil {
+		return nil, err
+	}
+	config := configInterface.(GCSStorageConfig)
+
+	if config.Endpoint == "" {
+		config.Endpoint = gcsDefaultEndpoint
+	}
+
+	log.Info("Creating Google Cloud Storage at %s:%s with base path %s", config.Endpoint, config.Bucket, config.BasePath)
+
+	g := &GCSStorage{
+		ctx:      ctx,
+		client:   newObjectStorageClient(),
+		endpoint: strings.TrimSuffix(config.Endpoint, "/"),
+		bucket:   config.Bucket,
+		basePath: config.BasePath,
+	}
+
+	// the clients of the credentials are based on the client in the context
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, g.client)
+	const scope = "https://www.googleapis.com/auth/devstorage.read_write"
+	switch {
+	case config.CredentialsFile != "":
+		data, err := os.ReadFile(config.CredentialsFile)
+		if err != nil {
+			return nil, ErrInvalidConfiguration{cfg: cfg, err: err}
+		}
+		creds, err := google.CredentialsFromJSON(ctx, data, scope)
+		if err != nil {
+			return nil, ErrInvalidConfiguration{cfg: cfg, err: err}
+		}
+		if config.ProjectID == "" {
+			config.ProjectID = creds.ProjectID
+		}
+		g.client = oauth2.NewClient(ctx, creds.TokenSource)
+
+		// only service account keys can sign URLs
+		if jwtConfig, err := google.JWTConfigFromJSON(data); err == nil {
+			if key, err := parseGCSPrivateKey(jwtConfig.PrivateKey); err == nil {
+				g.signerEmail = jwtConfig.Email
+				g.signerKey = key
+			} else {
+				log.Warn("Unable to parse the private key of %s, redirect URLs are not supported: %v", jwtConfig.Email, err)
+			}
+		}
+	case g.endpoint == gcsDefaultEndpoint:
+		creds, err := google.FindDefaultCredentials(ctx, scope)
+		if err != nil {
+			return nil, ErrInvalidConfiguration{cfg: cfg, err: err}
+		}
+		if config.ProjectID == "" {
+			config.ProjectID = creds.ProjectID
+		}
+		g.client = oauth2.NewClient(ctx, creds.TokenSource)
+	default:
+		// emulators do not need credentials
+	}
+
+	if _, err := g.doJSON(http.MethodGet, g.endpoint+"/storage/v1/b/"+url.PathEscape(g.bucket), nil, nil); err != nil {
+		if errResp, ok := err.(*gcsError); !ok || errResp.StatusCode != http.StatusNotFound {
+			return nil, convertGCSErr(err)
+		}
+		body, err := json.Marshal(map[string]string{"name": g.bucket})
+		if err != nil {
+			return nil, err
+		}
+		createURL := g.endpoint + "/storage/v1/b?" + url.Values{"project": {config.ProjectID}}.Encode()
+		if _, err := g.doJSON(http.MethodPost, createURL, bytes.NewReader(body), nil); err != nil {
+			return nil, convertGCSErr(err)
+		}
+	}
+
+	return g, nil
+}
+
+func parseGCSPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block != nil {
+		data = block.Bytes
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(data); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+func (g *GCSStorage) buildGCSPath(p string) string {
+	return strings.TrimPrefix(path.Join(g.basePath, p), "/")
+}
+
+func (g *GCSStorage) objectURL(objectPath string) string {
+	return g.endpoint + "/storage/v1/b/" + url.PathEscape(g.bucket) + "/o/" + url.PathEscape(objectPath)
+}
+
+// do sends a request to the JSON API, responses with an error status are returned as *gcsError
+func (g *GCSStorage) do(method, u string, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := newObjectStorageRequest(g.ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, values := range header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// 308 is used by resumable uploads to confirm a chunk
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusPermanentRedirect {
+		defer resp.Body.Close()
+		errResp := &gcsError{StatusCode: resp.StatusCode}
+		var errBody struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024)); err == nil {
+			if json.Unmarshal(data, &errBody) == nil && errBody.Error.Message != "" {
+				errResp.Message = errBody.Error.Message
+			} else {
+				errResp.Message = strings.TrimSpace(string(data))
+			}
+		}
+		return nil, errResp
+	}
+	return resp, nil
+}
+
+// doJSON sends a request to the JSON API and returns the object metadata of the response
+func (g *GCSStorage) doJSON(method, u string, body io.Reader, header http.Header) (*gcsObjectInfo, error) {
+	if header == nil {
+		header = http.Header{}
+	}
+	if body != nil && header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/json")
+	}
+	resp, err := g.do(method, u, body, header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	info := &gcsObjectInfo{}
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusPermanentRedirect {
+		return info, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(info); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (g *GCSStorage) newObject(info *objectFileInfo) *rangeObject {
+	return &rangeObject{
+		info: info,
+		openRange: func(offset int64) (io.ReadCloser, error) {
+			resp, err := g.do(http.MethodGet, g.objectURL(info.name)+"?alt=media", nil, http.Header{
+				"Range": {fmt.Sprintf("bytes=%d-", offset)},
+			})
+			if err != nil {
+				return nil, convertGCSErr(err)
+			}
+			return resp.Body, nil
+		},
+	}
+}
+
+// Open open a file
+func (g *GCSStorage) Open(path string) (Object, error) {
+	info, err := g.stat(g.buildGCSPath(path))
+	if err != nil {
+		return nil, err
+	}
+	return g.newObject(info), nil
+}
+
+// Save save a file to google cloud storage. Files of a known size up to one chunk are streamed,
+// larger files or files of an unknown size are uploaded in chunks with a resumable upload.
+func (g *GCSStorage) Save(path string, r io.Reader, size int64) (int64, error) {
+	objectPath := g.buildGCSPath(path)
+	uploadURL := g.endpoint + "/upload/storage/v1/b/" + url.PathEscape(g.bucket) + "/o?"
+
+	if size >= 0 && size <= gcsChunkSize {
+		info, err := g.doJSON(http.MethodPost, uploadURL+url.Values{
+			"uploadType": {"media"},
+			"name":       {objectPath},
+		}.Encode(), &sizedReader{Reader: r, size: size}, http.Header{
+			"Content-Type": {"application/octet-stream"},
+		})
+		if err != nil {
+			return 0, convertGCSErr(err)
+		}
+		if info.Size != "" {
+			return strconv.ParseInt(info.Size, 10, 64)
+		}
+		return size, nil
+	}
+
+	header := http.Header{
+		"X-Upload-Content-Type": {"application/octet-stream"},
+	}
+	if size >= 0 {
+		header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+	}
+	resp, err := g.do(http.MethodPost, uploadURL+url.Values{
+		"uploadType": {"resumable"},
+		"name":       {objectPath},
+	}.Encode(), nil, header)
+	if err != nil {
+		return 0, convertGCSErr(err)
+	}
+	resp.Body.Close()
+	sessionURL := resp.Header.Get("Location")
+	if sessionURL == "" {
+		return 0, errors.New("google cloud storage: no resumable upload session returned")
+	}
+
+	buf := make([]byte, gcsChunkSize)
+	var written int64
+	for {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		isLast := err != nil
+
+		var contentRange string
+		switch {
+		case isLast && n == 0:
+			contentRange = fmt.Sprintf("bytes */%d", written)
+		case isLast:
+			contentRange = fmt.Sprintf("bytes %d-%d/%d", written, written+int64(n)-1, written+int64(n))
+		default:
+			contentRange = fmt.Sprintf("bytes %d-%d/*", written, written+int64(n)-1)
+		}
+
+		info, err := g.doJSON(http.MethodPut, sessionURL, bytes.NewReader(buf[:n]), http.Header{
+			"Content-Range": {contentRange},
+			"Content-Type":  {"application/octet-stream"},
+		})
+		if err != nil {
+			return 0, convertGCSErr(err)
+		}
+		written += int64(n)
+
+		if isLast {
+			if info.Size != "" {
+				return strconv.ParseInt(info.Size, 10, 64)
+			}
+			return written, nil
+		}
+	}
+}
+
+func (g *GCSStorage) stat(objectPath string) (*objectFileInfo, error) {
+	info, err := g.doJSON(http.MethodGet, g.objectURL(objectPath), nil, nil)
+	if err != nil {
+		return nil, convertGCSErr(err)
+	}
+	return info.toFileInfo(), nil
+}
+
+// Stat returns the stat information of the object
+func (g *GCSStorage) Stat(path string) (os.FileInfo, error) {
+	return g.stat(g.buildGCSPath(path))
+}
+
+// Delete delete a file
+func (g *GCSStorage) Delete(path string) error {
+	resp, err := g.do(http.MethodDelete, g.objectURL(g.buildGCSPath(path)), nil, nil)
+	if err != nil {
+		if errResp, ok := err.(*gcsError); ok && errResp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return convertGCSErr(err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// gcsEscapeQuery escapes a query component for the canonical request of signed URLs
+func gcsEscapeQuery(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// URL gets the redirect URL to a file. The V4 signed link is valid for 5 minutes.
+// Signing requires the credentials of a service account.
+func (g *GCSStorage) URL(path, name string) (*url.URL, error) {
+	if g.signerKey == nil {
+		return nil, ErrURLNotSupported
+	}
+	objectPath := g.buildGCSPath(path)
+	if _, err := g.stat(objectPath); err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(g.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	segments := strings.Split(objectPath, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	escapedPath := "/" + url.PathEscape(g.bucket) + "/" + strings.Join(segments, "/")
+
+	now := time.Now().UTC()
+	scope := now.Format("20060102") + "/auto/storage/goog4_request"
+	query := map[string]string{
+		"X-Goog-Algorithm":             "GOOG4-RSA-SHA256",
+		"X-Goog-Credential":            g.signerEmail + "/" + scope,
+		"X-Goog-Date":                  now.Format("20060102T150405Z"),
+		"X-Goog-Expires":               strconv.Itoa(int((5 * time.Minute).Seconds())),
+		"X-Goog-SignedHeaders":         "host",
+		"response-content-disposition": "attachment; filename=\"" + quoteEscaper.Replace(name) + "\"",
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	params := make([]string, 0, len(keys))
+	for _, k := range keys {
+		params = append(params, gcsEscapeQuery(k)+"="+gcsEscapeQuery(query[k]))
+	}
+	canonicalQuery := strings.Join(params, "&")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		escapedPath,
+		canonicalQuery,
+		"host:" + base.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"GOOG4-RSA-SHA256",
+		query["X-Goog-Date"],
+		scope,
+		hex.EncodeToString(hashedRequest[:]),
+	}, "\n")
+
+	hashed := sha256.Sum256([]byte(stringToSign))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, g.signerKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return url.Parse(base.Scheme + "://" + base.Host + escapedPath + "?" + canonicalQuery + "&X-Goog-Signature=" + hex.EncodeToString(signature))
+}
+
+// IterateObjects iterates across the objects in the google cloud storage
+func (g *GCSStorage) IterateObjects(fn func(path string, obj Object) error) error {
+	pageToken := ""
+	for {
+		query := url.Values{"prefix": {g.basePath}}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		resp, err := g.do(http.MethodGet, g.endpoint+"/storage/v1/b/"+url.PathEscape(g.bucket)+"/o?"+query.Encode(), nil, nil)
+		if err != nil {
+			return convertGCSErr(err)
+		}
+		var list struct {
+			Items         []*gcsObjectInfo `json:"items"`
+			NextPageToken string           `json:"nextPageToken"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, item := range list.Items {
+			object := g.newObject(item.toFileInfo())
+			if err := func() error {
+				defer object.Close()
+				return fn(strings.TrimPrefix(item.Name, g.basePath), object)
+			}(); err != nil {
+				return err
+			}
+		}
+
+		if list.NextPageToken == "" {
+			return nil
+		}
+		pageToken = list.NextPageToken
+	}
+}
+
+func init() {
+	RegisterStorageType(GCSStorageType, NewGCSStorage)
+}
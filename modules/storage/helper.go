@@ -5,7 +5,16 @@
 package storage
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
 	"reflect"
+	"strings"
+	"time"
 
 	"code.gitea.io/gitea/modules/json"
 )
@@ -62,3 +71,134 @@ func toConfig(exemplar, cfg interface{}) (interface{}, error) {
 	}
 	return newVal.Elem().Interface(), nil
 }
+
+// newObjectStorageClient returns the HTTP client of a remote storage. Connecting and waiting for
+// a response time out, but reading and writing the bodies doesn't, so large files can be transferred.
+func newObjectStorageClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 5 * time.Minute,
+			ExpectContinueTimeout: time.Second,
+			IdleConnTimeout:       90 * time.Second,
+			MaxIdleConnsPerHost:   10,
+		},
+	}
+}
+
+// sizedReader is a request body of a remote storage which is sent with its size as Content-Length
+type sizedReader struct {
+	io.Reader
+	size int64
+}
+
+// newObjectStorageRequest returns a request whose body is sent with its Content-Length if it is known
+func newObjectStorageRequest(ctx context.Context, method, u string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if sized, ok := body.(*sizedReader); ok {
+		req.ContentLength = sized.size
+		if sized.size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	return req, nil
+}
+
+// objectFileInfo is the os.FileInfo of an object of a remote storage
+type objectFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (info *objectFileInfo) Name() string {
+	return path.Base(info.name)
+}
+
+func (info *objectFileInfo) Size() int64 {
+	return info.size
+}
+
+func (info *objectFileInfo) ModTime() time.Time {
+	return info.modTime
+}
+
+func (info *objectFileInfo) IsDir() bool {
+	return strings.HasSuffix(info.name, "/")
+}
+
+func (info *objectFileInfo) Mode() os.FileMode {
+	return os.ModePerm
+}
+
+func (info *objectFileInfo) Sys() interface{} {
+	return nil
+}
+
+// rangeObject is an Object of a remote storage which is read with range requests.
+// The request is only sent on the first read after opening or seeking the object.
+type rangeObject struct {
+	info      *objectFileInfo
+	openRange func(offset int64) (io.ReadCloser, error)
+	rc        io.ReadCloser
+	offset    int64
+}
+
+func (o *rangeObject) Read(p []byte) (int, error) {
+	if o.offset >= o.info.size {
+		return 0, io.EOF
+	}
+	if o.rc == nil {
+		rc, err := o.openRange(o.offset)
+		if err != nil {
+			return 0, err
+		}
+		o.rc = rc
+	}
+	n, err := o.rc.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *rangeObject) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.info.size
+	case io.SeekStart:
+	default:
+		return o.offset, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return o.offset, errors.New("negative position")
+	}
+	if offset != o.offset {
+		if err := o.Close(); err != nil {
+			return o.offset, err
+		}
+		o.offset = offset
+	}
+	return o.offset, nil
+}
+
+func (o *rangeObject) Close() error {
+	if o.rc == nil {
+		return nil
+	}
+	err := o.rc.Close()
+	o.rc = nil
+	return err
+}
+
+func (o *rangeObject) Stat() (os.FileInfo, error) {
+	return o.info, nil
+}
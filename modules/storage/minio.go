@@ -6,6 +6,8 @@ package storage
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
 	"io"
 	"net/url"
 	"os"
@@ -17,6 +19,7 @@ import (
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
 )
 
 var (
@@ -41,15 +44,23 @@ func (m *minioObject) Stat() (os.FileInfo, error) {
 // MinioStorageType is the type descriptor for minio storage
 const MinioStorageType Type = "minio"
 
+// Server side encryptions supported by the minio storage
+const (
+	MinioSSES3 = "sse-s3" // keys managed by the server
+	MinioSSEC  = "sse-c"  // key provided by Gitea with every request
+)
+
 // MinioStorageConfig represents the configuration for a minio storage
 type MinioStorageConfig struct {
-	Endpoint        string `ini:"MINIO_ENDPOINT"`
-	AccessKeyID     string `ini:"MINIO_ACCESS_KEY_ID"`
-	SecretAccessKey string `ini:"MINIO_SECRET_ACCESS_KEY"`
-	Bucket          string `ini:"MINIO_BUCKET"`
-	Location        string `ini:"MINIO_LOCATION"`
-	BasePath        string `ini:"MINIO_BASE_PATH"`
-	UseSSL          bool   `ini:"MINIO_USE_SSL"`
+	Endpoint                string `ini:"MINIO_ENDPOINT"`
+	AccessKeyID             string `ini:"MINIO_ACCESS_KEY_ID"`
+	SecretAccessKey         string `ini:"MINIO_SECRET_ACCESS_KEY"`
+	Bucket                  string `ini:"MINIO_BUCKET"`
+	Location                string `ini:"MINIO_LOCATION"`
+	BasePath                string `ini:"MINIO_BASE_PATH"`
+	UseSSL                  bool   `ini:"MINIO_USE_SSL"`
+	ServerSideEncryption    string `ini:"MINIO_SERVER_SIDE_ENCRYPTION"`
+	ServerSideEncryptionKey string `ini:"MINIO_SERVER_SIDE_ENCRYPTION_KEY"`
 }
 
 // MinioStorage returns a minio bucket storage
@@ -58,6 +69,7 @@ type MinioStorage struct {
 	client   *minio.Client
 	bucket   string
 	basePath string
+	sse      encrypt.ServerSide
 }
 
 func convertMinioErr(err error) error {
@@ -98,6 +110,11 @@ func NewMinioStorage(ctx context.Context, cfg interface{}) (ObjectStorage, error
 		return nil, convertMinioErr(err)
 	}
 
+	sse, err := newMinioServerSideEncryption(config.ServerSideEncryption, config.ServerSideEncryptionKey)
+	if err != nil {
+		return nil, ErrInvalidConfiguration{cfg: cfg, err: err}
+	}
+
 	if err := minioClient.MakeBucket(ctx, config.Bucket, minio.MakeBucketOptions{
 		Region: config.Location,
 	}); err != nil {
@@ -113,16 +130,48 @@ func NewMinioStorage(ctx context.Context, cfg interface{}) (ObjectStorage, error
 		client:   minioClient,
 		bucket:   config.Bucket,
 		basePath: config.BasePath,
+		sse:      sse,
 	}, nil
 }
 
+// newMinioServerSideEncryption returns the server side encryption of the objects, nil if they are not encrypted.
+// The key of SSE-C is the base64 encoded 256 bit key.
+func newMinioServerSideEncryption(typ, key string) (encrypt.ServerSide, error) {
+	switch strings.ToLower(typ) {
+	case "":
+		return nil, nil
+	case MinioSSES3:
+		return encrypt.NewSSE(), nil
+	case MinioSSEC:
+		rawKey, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server side encryption key: %v", err)
+		}
+		return encrypt.NewSSEC(rawKey)
+	}
+	return nil, fmt.Errorf("unsupported server side encryption: %s", typ)
+}
+
+// isCustomerKeyEncrypted returns true if every request has to provide the encryption key of the objects
+func (m *MinioStorage) isCustomerKeyEncrypted() bool {
+	return m.sse != nil && m.sse.Type() == encrypt.SSEC
+}
+
+// getObjectOptions returns the options to read objects, which need the key if it is provided by Gitea
+func (m *MinioStorage) getObjectOptions() minio.GetObjectOptions {
+	if m.isCustomerKeyEncrypted() {
+		return minio.GetObjectOptions{ServerSideEncryption: m.sse}
+	}
+	return minio.GetObjectOptions{}
+}
+
 func (m *MinioStorage) buildMinioPath(p string) string {
 	return strings.TrimPrefix(path.Join(m.basePath, p), "/")
 }
 
 // Open open a file
 func (m *MinioStorage) Open(path string) (Object, error) {
-	var opts = minio.GetObjectOptions{}
+	var opts = m.getObjectOptions()
 	object, err := m.client.GetObject(m.ctx, m.bucket, m.buildMinioPath(path), opts)
 	if err != nil {
 		return nil, convertMinioErr(err)
@@ -138,7 +187,10 @@ func (m *MinioStorage) Save(path string, r io.Reader, size int64) (int64, error)
 		m.buildMinioPath(path),
 		r,
 		size,
-		minio.PutObjectOptions{ContentType: "application/octet-stream"},
+		minio.PutObjectOptions{
+			ContentType:          "application/octet-stream",
+			ServerSideEncryption: m.sse,
+		},
 	)
 	if err != nil {
 		return 0, convertMinioErr(err)
@@ -180,7 +232,7 @@ func (m *MinioStorage) Stat(path string) (os.FileInfo, error) {
 		m.ctx,
 		m.bucket,
 		m.buildMinioPath(path),
-		minio.StatObjectOptions{},
+		m.getObjectOptions(),
 	)
 	if err != nil {
 		return nil, convertMinioErr(err)
@@ -196,7 +248,12 @@ func (m *MinioStorage) Delete(path string) error {
 }
 
 // URL gets the redirect URL to a file. The presigned link is valid for 5 minutes.
+// Objects encrypted with a customer provided key cannot be downloaded without the key, so they do not support URLs.
 func (m *MinioStorage) URL(path, name string) (*url.URL, error) {
+	if m.isCustomerKeyEncrypted() {
+		return nil, ErrURLNotSupported
+	}
+
 	reqParams := make(url.Values)
 	// TODO it may be good to embed images with 'inline' like ServeData does, but we don't want to have to read the file, do we?
 	reqParams.Set("response-content-disposition", "attachment; filename=\""+quoteEscaper.Replace(name)+"\"")
@@ -206,7 +263,7 @@ func (m *MinioStorage) URL(path, name string) (*url.URL, error) {
 
 // IterateObjects iterates across the objects in the miniostorage
 func (m *MinioStorage) IterateObjects(fn func(path string, obj Object) error) error {
-	var opts = minio.GetObjectOptions{}
+	var opts = m.getObjectOptions()
 	lobjectCtx, cancel := context.WithCancel(m.ctx)
 	defer cancel()
 	for mObjInfo := range m.client.ListObjects(lobjectCtx, m.bucket, minio.ListObjectsOptions{
@@ -125,6 +125,15 @@ var (
 
 	// RepoArchives represents repository archives storage
 	RepoArchives ObjectStorage
+
+	// RepoExports represents repository export and import archives storage
+	RepoExports ObjectStorage
+
+	// Packages represents packages storage
+	Packages ObjectStorage
+
+	// ActionsLog represents actions job log storage
+	ActionsLog ObjectStorage
 )
 
 // Init init the stoarge
@@ -145,7 +154,19 @@ func Init() error {
 		return err
 	}
 
-	return initRepoArchives()
+	if err := initRepoArchives(); err != nil {
+		return err
+	}
+
+	if err := initRepoExports(); err != nil {
+		return err
+	}
+
+	if err := initPackages(); err != nil {
+		return err
+	}
+
+	return initActionsLog()
 }
 
 // NewStorage takes a storage type and some config and returns an ObjectStorage or an error
@@ -190,3 +211,27 @@ func initRepoArchives() (err error) {
 	RepoArchives, err = NewStorage(setting.RepoArchive.Storage.Type, &setting.RepoArchive.Storage)
 	return
 }
+
+func initRepoExports() (err error) {
+	log.Info("Initialising Repository Export storage with type: %s", setting.RepoExport.Storage.Type)
+	RepoExports, err = NewStorage(setting.RepoExport.Storage.Type, &setting.RepoExport.Storage)
+	return
+}
+
+func initPackages() (err error) {
+	if !setting.Packages.Enabled {
+		return nil
+	}
+	log.Info("Initialising Packages storage with type: %s", setting.Packages.Storage.Type)
+	Packages, err = NewStorage(setting.Packages.Storage.Type, &setting.Packages.Storage)
+	return
+}
+
+func initActionsLog() (err error) {
+	if !setting.Actions.Enabled {
+		return nil
+	}
+	log.Info("Initialising Actions log storage with type: %s", setting.Actions.Storage.Type)
+	ActionsLog, err = NewStorage(setting.Actions.Storage.Type, &setting.Actions.Storage)
+	return
+}
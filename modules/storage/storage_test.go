@@ -0,0 +1,181 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testObjectStorage runs the same round trip against any ObjectStorage
+func testObjectStorage(t *testing.T, s ObjectStorage) {
+	content := []byte("gitea object storage test content")
+
+	n, err := s.Save("a/b/test.txt", bytes.NewReader(content), int64(len(content)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, len(content), n)
+
+	info, err := s.Stat("a/b/test.txt")
+	assert.NoError(t, err)
+	assert.EqualValues(t, len(content), info.Size())
+
+	obj, err := s.Open("a/b/test.txt")
+	assert.NoError(t, err)
+	_, err = obj.Seek(6, io.SeekStart)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, content[6:], data)
+	assert.NoError(t, obj.Close())
+
+	found := false
+	assert.NoError(t, s.IterateObjects(func(path string, obj Object) error {
+		defer obj.Close()
+		if path == "a/b/test.txt" {
+			found = true
+		}
+		return nil
+	}))
+	assert.True(t, found)
+
+	assert.NoError(t, s.Delete("a/b/test.txt"))
+	_, err = s.Stat("a/b/test.txt")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = s.Open("a/b/test.txt")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRangeObject(t *testing.T) {
+	content := "0123456789"
+	opened := 0
+	obj := &rangeObject{
+		info: &objectFileInfo{name: "test", size: int64(len(content))},
+		openRange: func(offset int64) (io.ReadCloser, error) {
+			opened++
+			return io.NopCloser(strings.NewReader(content[offset:])), nil
+		},
+	}
+
+	buf := make([]byte, 3)
+	n, err := obj.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "012", string(buf[:n]))
+
+	pos, err := obj.Seek(-2, io.SeekEnd)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 8, pos)
+	data, err := io.ReadAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "89", string(data))
+	assert.Equal(t, 2, opened)
+
+	info, err := obj.Stat()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 10, info.Size())
+	assert.NoError(t, obj.Close())
+}
+
+func TestNewMinioServerSideEncryption(t *testing.T) {
+	sse, err := newMinioServerSideEncryption("", "")
+	assert.NoError(t, err)
+	assert.Nil(t, sse)
+
+	sse, err = newMinioServerSideEncryption(MinioSSES3, "")
+	assert.NoError(t, err)
+	assert.NotNil(t, sse)
+
+	_, err = newMinioServerSideEncryption(MinioSSEC, "c2hvcnQ=")
+	assert.Error(t, err)
+
+	sse, err = newMinioServerSideEncryption(MinioSSEC, "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	assert.NoError(t, err)
+	assert.NotNil(t, sse)
+
+	_, err = newMinioServerSideEncryption("unknown", "")
+	assert.Error(t, err)
+}
+
+// TestAzureBlobStorage runs against Azurite, e.g.
+// TEST_AZURITE_ENDPOINT=http://127.0.0.1:10000/devstoreaccount1
+func TestAzureBlobStorage(t *testing.T) {
+	endpoint := os.Getenv("TEST_AZURITE_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("TEST_AZURITE_ENDPOINT not set")
+	}
+	s, err := NewAzureBlobStorage(context.Background(), AzureBlobStorageConfig{
+		Endpoint:    endpoint,
+		AccountName: "devstoreaccount1",
+		AccountKey:  "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==",
+		Container:   "gitea-test",
+		BasePath:    "test/",
+	})
+	assert.NoError(t, err)
+	testObjectStorage(t, s)
+}
+
+// TestGCSStorage runs against fake-gcs-server, e.g.
+// TEST_FAKE_GCS_ENDPOINT=http://127.0.0.1:4443
+func TestGCSStorage(t *testing.T) {
+	endpoint := os.Getenv("TEST_FAKE_GCS_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("TEST_FAKE_GCS_ENDPOINT not set")
+	}
+	s, err := NewGCSStorage(context.Background(), GCSStorageConfig{
+		Endpoint:  endpoint,
+		Bucket:    "gitea-test",
+		ProjectID: "test",
+		BasePath:  "test/",
+	})
+	assert.NoError(t, err)
+	testObjectStorage(t, s)
+}
+
+func TestObjectStorageSaveContentLength(t *testing.T) {
+	content := []byte("gitea object storage test content")
+	var uploads []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		if len(data) > 0 {
+			assert.Equal(t, content, data)
+			uploads = append(uploads, r)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	azure, err := NewAzureBlobStorage(context.Background(), AzureBlobStorageConfig{
+		Endpoint:    server.URL,
+		AccountName: "devstoreaccount1",
+		Container:   "gitea-test",
+	})
+	assert.NoError(t, err)
+	gcs, err := NewGCSStorage(context.Background(), GCSStorageConfig{
+		Endpoint: server.URL,
+		Bucket:   "gitea-test",
+	})
+	assert.NoError(t, err)
+
+	for _, s := range []ObjectStorage{azure, gcs} {
+		uploads = nil
+		// the reader hides its size, so only the given size can be sent
+		n, err := s.Save("test.txt", io.MultiReader(bytes.NewReader(content)), int64(len(content)))
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(content), n)
+		if assert.Len(t, uploads, 1) {
+			assert.EqualValues(t, len(content), uploads[0].ContentLength)
+			assert.Empty(t, uploads[0].TransferEncoding)
+		}
+	}
+}
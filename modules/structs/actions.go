@@ -0,0 +1,93 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// ActionRun represents a single execution of a workflow
+type ActionRun struct {
+	ID          int64  `json:"id"`
+	WorkflowID  string `json:"workflow_id"`
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Event       string `json:"event"`
+	Ref         string `json:"ref"`
+	CommitSHA   string `json:"commit_sha"`
+	Status      string `json:"status"`
+	TriggerUser *User  `json:"trigger_user"`
+	// swagger:strfmt date-time
+	Started *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	Stopped *time.Time `json:"stopped_at"`
+	// swagger:strfmt date-time
+	Created time.Time       `json:"created_at"`
+	Jobs    []*ActionRunJob `json:"jobs,omitempty"`
+}
+
+// ActionRunJob represents a job of a workflow run
+type ActionRunJob struct {
+	ID        int64    `json:"id"`
+	JobID     string   `json:"job_id"`
+	Name      string   `json:"name"`
+	Needs     []string `json:"needs"`
+	RunsOn    []string `json:"runs_on"`
+	Status    string   `json:"status"`
+	LogLength int64    `json:"log_length"`
+	LogURL    string   `json:"log_url"`
+	// swagger:strfmt date-time
+	Started *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	Stopped *time.Time `json:"stopped_at"`
+}
+
+// ActionRunnerRegistrationToken is used to register new runners
+type ActionRunnerRegistrationToken struct {
+	Token string `json:"token"`
+}
+
+// RegisterActionRunnerOption options to register a runner
+type RegisterActionRunnerOption struct {
+	Token  string   `json:"token"`
+	Name   string   `json:"name"`
+	Labels []string `json:"labels"`
+}
+
+// ActionRunnerCredentials are returned to a newly registered runner
+type ActionRunnerCredentials struct {
+	UUID   string   `json:"uuid"`
+	Token  string   `json:"token"`
+	Name   string   `json:"name"`
+	Labels []string `json:"labels"`
+}
+
+// ActionTask is a job handed to a runner
+type ActionTask struct {
+	ID         int64  `json:"id"`
+	RunID      int64  `json:"run_id"`
+	JobID      string `json:"job_id"`
+	Name       string `json:"name"`
+	Repository string `json:"repository"`
+	CloneURL   string `json:"clone_url"`
+	Event      string `json:"event"`
+	Ref        string `json:"ref"`
+	SHA        string `json:"sha"`
+	Workflow   string `json:"workflow"`
+	// Token can be used as password to clone the repository until the task is done
+	Token string `json:"token"`
+}
+
+// UpdateActionTaskStateOption is sent by a runner to report the state of a task
+type UpdateActionTaskStateOption struct {
+	// one of running, success, failure or cancelled
+	Status string `json:"status"`
+}
+
+// ActionTaskState is the state of a task as seen by the server
+type ActionTaskState struct {
+	Status    string `json:"status"`
+	LogLength int64  `json:"log_length"`
+}
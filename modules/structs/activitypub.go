@@ -0,0 +1,44 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+// ActivityPub is an ActivityPub document like an actor or a collection
+type ActivityPub struct {
+	Context interface{} `json:"@context"`
+	ID      string      `json:"id"`
+	Type    string      `json:"type"`
+}
+
+// FederatedActor represents a user or repository on another instance
+type FederatedActor struct {
+	IRI    string `json:"iri"`
+	Type   string `json:"type"`
+	Handle string `json:"handle"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+}
+
+// FollowRemoteOption options to follow a user or repository on another instance
+type FollowRemoteOption struct {
+	// IRI of the actor or handle like user@example.com
+	// required: true
+	Actor string `json:"actor" binding:"Required"`
+}
+
+// StarRemoteOption options to star a repository on another instance
+type StarRemoteOption struct {
+	// IRI of the repository
+	// required: true
+	Repository string `json:"repository" binding:"Required"`
+}
+
+// CommentRemoteOption options to comment on an issue on another instance
+type CommentRemoteOption struct {
+	// IRI of the issue
+	// required: true
+	Issue string `json:"issue" binding:"Required"`
+	// required: true
+	Body string `json:"body" binding:"Required"`
+}
@@ -0,0 +1,25 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import "time"
+
+// AuditEvent represents an entry of the audit log
+type AuditEvent struct {
+	ID         int64  `json:"id"`
+	Action     string `json:"action"`
+	ActorID    int64  `json:"actor_id"`
+	ActorName  string `json:"actor_name"`
+	IPAddress  string `json:"ip_address"`
+	TargetType string `json:"target_type"`
+	TargetID   int64  `json:"target_id"`
+	TargetName string `json:"target_name"`
+	// state of the target before the event
+	Before map[string]interface{} `json:"before,omitempty"`
+	// state of the target after the event
+	After map[string]interface{} `json:"after,omitempty"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created"`
+}
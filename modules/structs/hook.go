@@ -26,6 +26,8 @@ type Hook struct {
 	Config map[string]string `json:"config"`
 	Events []string          `json:"events"`
 	Active bool              `json:"active"`
+	// maximum number of attempts to deliver a hook, 0 for the default one
+	MaxAttempts int `json:"max_attempts"`
 	// swagger:strfmt date-time
 	Updated time.Time `json:"updated_at"`
 	// swagger:strfmt date-time
@@ -50,6 +52,9 @@ type CreateHookOption struct {
 	BranchFilter string                 `json:"branch_filter" binding:"GlobPattern"`
 	// default: false
 	Active bool `json:"active"`
+	// maximum number of attempts to deliver a hook, 0 for the default one
+	// maximum: 100
+	MaxAttempts int `json:"max_attempts"`
 }
 
 // EditHookOption options when modify one hook
@@ -58,6 +63,40 @@ type EditHookOption struct {
 	Events       []string          `json:"events"`
 	BranchFilter string            `json:"branch_filter" binding:"GlobPattern"`
 	Active       *bool             `json:"active"`
+	// maximum number of attempts to deliver a hook, 0 for the default one
+	// maximum: 100
+	MaxAttempts *int `json:"max_attempts"`
+}
+
+// HookDelivery represents a delivery of a hook
+type HookDelivery struct {
+	ID    int64  `json:"id"`
+	UUID  string `json:"uuid"`
+	Event string `json:"event"`
+	// enum: pending,succeeded,failed
+	Status string `json:"status"`
+	// status code of the last response, 0 if there was no response
+	StatusCode int                    `json:"status_code"`
+	Attempts   []*HookDeliveryAttempt `json:"attempts"`
+	// swagger:strfmt date-time
+	Delivered time.Time `json:"delivered_at"`
+	// swagger:strfmt date-time
+	NextAttempt *time.Time `json:"next_attempt_at,omitempty"`
+}
+
+// HookDeliveryAttempt represents an attempt to deliver a hook
+type HookDeliveryAttempt struct {
+	// swagger:strfmt date-time
+	Delivered time.Time `json:"delivered_at"`
+	// status code of the response, 0 if there was no response
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+}
+
+// RedeliverHookOption options to deliver again the failed deliveries of a hook
+type RedeliverHookOption struct {
+	// IDs of the failed deliveries to redeliver, all of them if empty
+	IDs []int64 `json:"ids"`
 }
 
 // Payloader payload is some part of one hook
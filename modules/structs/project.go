@@ -0,0 +1,105 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// Project represents a project board of a repository, a user or an organization
+type Project struct {
+	ID          int64  `json:"id"`
+	URL         string `json:"url"`
+	HTMLURL     string `json:"html_url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// enum: individual,repository,organization
+	Type string `json:"type"`
+	// the repository of a repository project
+	Repo *RepositoryMeta `json:"repository,omitempty"`
+	// the user or organization owning an individual or organization project
+	Owner        *User     `json:"owner,omitempty"`
+	State        StateType `json:"state"`
+	OpenIssues   int       `json:"open_issues"`
+	ClosedIssues int       `json:"closed_issues"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+	// swagger:strfmt date-time
+	Closed *time.Time `json:"closed_at"`
+}
+
+// CreateProjectOption options for creating a project
+type CreateProjectOption struct {
+	// required:true
+	Title       string `json:"title" binding:"Required;MaxSize(100)"`
+	Description string `json:"description"`
+	// the boards to create with the project
+	// enum: none,basic_kanban,bug_triage
+	BoardType string `json:"board_type"`
+}
+
+// EditProjectOption options for editing a project
+type EditProjectOption struct {
+	Title       *string `json:"title" binding:"MaxSize(100)"`
+	Description *string `json:"description"`
+	// enum: open,closed
+	State *string `json:"state"`
+}
+
+// ProjectBoard represents a board of a project
+type ProjectBoard struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Color string `json:"color"`
+	// the issues which are not on a board are shown on the default board
+	Default bool `json:"default"`
+	Sorting int  `json:"sorting"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// CreateProjectBoardOption options for creating a project board
+type CreateProjectBoardOption struct {
+	// required:true
+	Title string `json:"title" binding:"Required;MaxSize(100)"`
+	// the color of the board as #rrggbb
+	Color string `json:"color" binding:"MaxSize(7)"`
+}
+
+// EditProjectBoardOption options for editing a project board
+type EditProjectBoardOption struct {
+	Title *string `json:"title" binding:"MaxSize(100)"`
+	Color *string `json:"color" binding:"MaxSize(7)"`
+	// the position of the board in the project
+	Sorting *int8 `json:"sorting"`
+	// set the board as the default board of the project
+	Default *bool `json:"default"`
+}
+
+// ProjectCard represents an issue or a pull request in a project
+type ProjectCard struct {
+	// the board holding the card, 0 if it is not on a board
+	BoardID int64  `json:"board_id"`
+	Issue   *Issue `json:"issue"`
+}
+
+// AddProjectCardOption options for adding an issue or a pull request to a project
+type AddProjectCardOption struct {
+	// the id of the issue or the pull request to add
+	// required:true
+	IssueID int64 `json:"issue_id" binding:"Required"`
+	// the board to put the card on, the card is not on a board if not set
+	BoardID int64 `json:"board_id"`
+}
+
+// MoveProjectCardOption options for moving a project card to another board
+type MoveProjectCardOption struct {
+	// the board to move the card to, 0 to remove it from its board
+	BoardID int64 `json:"board_id"`
+}
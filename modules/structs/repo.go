@@ -242,20 +242,24 @@ type GitServiceType int
 
 // enumerate all GitServiceType
 const (
-	NotMigrated      GitServiceType = iota // 0 not migrated from external sites
-	PlainGitService                        // 1 plain git service
-	GithubService                          // 2 github.com
-	GiteaService                           // 3 gitea service
-	GitlabService                          // 4 gitlab service
-	GogsService                            // 5 gogs service
-	OneDevService                          // 6 onedev service
-	GitBucketService                       // 7 gitbucket service
+	NotMigrated            GitServiceType = iota // 0 not migrated from external sites
+	PlainGitService                              // 1 plain git service
+	GithubService                                // 2 github.com
+	GiteaService                                 // 3 gitea service
+	GitlabService                                // 4 gitlab service
+	GogsService                                  // 5 gogs service
+	OneDevService                                // 6 onedev service
+	GitBucketService                             // 7 gitbucket service
+	BitbucketService                             // 8 bitbucket.org
+	BitbucketServerService                       // 9 bitbucket server service
+	AzureDevOpsService                           // 10 azure devops service
+	GerritService                                // 11 gerrit service
 )
 
 // Name represents the service type's name
 // WARNNING: the name have to be equal to that on goth's library
 func (gt GitServiceType) Name() string {
-	return strings.ToLower(gt.Title())
+	return strings.ToLower(strings.ReplaceAll(gt.Title(), " ", ""))
 }
 
 // Title represents the service type's proper title
@@ -273,6 +277,14 @@ func (gt GitServiceType) Title() string {
 		return "OneDev"
 	case GitBucketService:
 		return "GitBucket"
+	case BitbucketService:
+		return "Bitbucket"
+	case BitbucketServerService:
+		return "Bitbucket Server"
+	case AzureDevOpsService:
+		return "Azure DevOps"
+	case GerritService:
+		return "Gerrit"
 	case PlainGitService:
 		return "Git"
 	}
@@ -291,7 +303,7 @@ type MigrateRepoOptions struct {
 	// required: true
 	RepoName string `json:"repo_name" binding:"Required;AlphaDashDot;MaxSize(100)"`
 
-	// enum: git,github,gitea,gitlab
+	// enum: git,github,gitea,gitlab,gogs,onedev,gitbucket,bitbucket,bitbucketserver,azuredevops,gerrit
 	Service      string `json:"service"`
 	AuthUsername string `json:"auth_username"`
 	AuthPassword string `json:"auth_password"`
@@ -314,7 +326,7 @@ type MigrateRepoOptions struct {
 // TokenAuth represents whether a service type supports token-based auth
 func (gt GitServiceType) TokenAuth() bool {
 	switch gt {
-	case GithubService, GiteaService, GitlabService:
+	case GithubService, GiteaService, GitlabService, BitbucketServerService, AzureDevOpsService:
 		return true
 	}
 	return false
@@ -330,5 +342,9 @@ var (
 		GogsService,
 		OneDevService,
 		GitBucketService,
+		BitbucketService,
+		BitbucketServerService,
+		AzureDevOpsService,
+		GerritService,
 	}
 )
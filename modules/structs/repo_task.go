@@ -0,0 +1,25 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import "time"
+
+// RepoTask represents a background task exporting or importing a repository
+type RepoTask struct {
+	ID int64 `json:"id"`
+	// enum: export,import
+	Type   string `json:"type"`
+	RepoID int64  `json:"repo_id"`
+	// enum: queued,running,stopped,failed,finished
+	Status string `json:"status"`
+	// progress of the task or the reason it failed
+	Message string `json:"message"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Started *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	Ended *time.Time `json:"ended_at"`
+}
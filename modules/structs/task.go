@@ -10,6 +10,8 @@ type TaskType int
 // all kinds of task types
 const (
 	TaskTypeMigrateRepo TaskType = iota // migrate repository from external or local disk
+	TaskTypeExportRepo                  // export repository to an archive
+	TaskTypeImportRepo                  // import repository from an uploaded archive
 )
 
 // Name returns the task type name
@@ -17,6 +19,10 @@ func (taskType TaskType) Name() string {
 	switch taskType {
 	case TaskTypeMigrateRepo:
 		return "Migrate Repository"
+	case TaskTypeExportRepo:
+		return "Export Repository"
+	case TaskTypeImportRepo:
+		return "Import Repository"
 	}
 	return ""
 }
@@ -32,3 +38,20 @@ const (
 	TaskStatusFailed                     // 3 task is failed
 	TaskStatusFinished                   // 4 task is finished
 )
+
+// String returns the name of the task status
+func (status TaskStatus) String() string {
+	switch status {
+	case TaskStatusQueue:
+		return "queued"
+	case TaskStatusRunning:
+		return "running"
+	case TaskStatusStopped:
+		return "stopped"
+	case TaskStatusFailed:
+		return "failed"
+	case TaskStatusFinished:
+		return "finished"
+	}
+	return ""
+}
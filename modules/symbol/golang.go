@@ -0,0 +1,60 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// extractGo returns the top level declarations of a Go file. Files with syntax errors
+// return the declarations which could be parsed.
+func extractGo(content []byte) []*Symbol {
+	fset := token.NewFileSet()
+	file, _ := parser.ParseFile(fset, "", content, 0)
+	if file == nil {
+		return nil
+	}
+
+	var symbols []*Symbol
+	add := func(ident *ast.Ident, kind Kind) {
+		if ident == nil || ident.Name == "_" {
+			return
+		}
+		symbols = append(symbols, &Symbol{Name: ident.Name, Kind: kind, Line: fset.Position(ident.Pos()).Line})
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil {
+				add(decl.Name, KindMethod)
+			} else {
+				add(decl.Name, KindFunction)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if _, ok := spec.Type.(*ast.InterfaceType); ok {
+						add(spec.Name, KindInterface)
+					} else {
+						add(spec.Name, KindType)
+					}
+				case *ast.ValueSpec:
+					kind := KindVariable
+					if decl.Tok == token.CONST {
+						kind = KindConstant
+					}
+					for _, name := range spec.Names {
+						add(name, kind)
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// Kind is the kind of a symbol
+type Kind string
+
+// The kinds of the symbols
+const (
+	KindFunction  Kind = "function"
+	KindMethod    Kind = "method"
+	KindClass     Kind = "class"
+	KindInterface Kind = "interface"
+	KindType      Kind = "type"
+	KindConstant  Kind = "constant"
+	KindVariable  Kind = "variable"
+)
+
+// Symbol represents the definition of a symbol in a file
+type Symbol struct {
+	Name string
+	Kind Kind
+	Line int
+}
+
+// extractors are the symbol extractors of the supported languages, keyed by their linguist name
+var extractors = map[string]func(content []byte) []*Symbol{
+	"Go":         extractGo,
+	"JavaScript": newRuleExtractor(javaScriptRules),
+	"JSX":        newRuleExtractor(javaScriptRules),
+	"TypeScript": newRuleExtractor(typeScriptRules),
+	"TSX":        newRuleExtractor(typeScriptRules),
+	"Python":     newRuleExtractor(pythonRules),
+	"Java":       newRuleExtractor(javaRules),
+}
+
+// IsSupported returns whether the symbols of the language can be extracted
+func IsSupported(language string) bool {
+	_, ok := extractors[language]
+	return ok
+}
+
+// Extract returns the definitions of the symbols in a file written in the language
+func Extract(language string, content []byte) []*Symbol {
+	extract, ok := extractors[language]
+	if !ok {
+		return nil
+	}
+	return extract(content)
+}
+
+// rule extracts the symbol named by the first group of the pattern from a line
+type rule struct {
+	pattern *regexp.Regexp
+	kind    Kind
+	// indentedKind is the kind of the symbol if the line is indented, e.g. a method of a class
+	indentedKind Kind
+}
+
+// keywords are names which are matched by the method rules but are statements
+var keywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true,
+	"function": true, "new": true, "else": true, "do": true, "try": true, "throw": true,
+	"super": true, "this": true, "synchronized": true, "with": true, "typeof": true,
+}
+
+var (
+	javaScriptRules = []rule{
+		{pattern: regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)\s*\(`), kind: KindFunction},
+		{pattern: regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?class\s+([A-Za-z_$][\w$]*)`), kind: KindClass},
+		{pattern: regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*=>|[A-Za-z_$][\w$]*\s*=>)`), kind: KindFunction},
+		{pattern: regexp.MustCompile(`^(?:export\s+)?const\s+([A-Za-z_$][\w$]*)\s*=`), kind: KindConstant},
+		{pattern: regexp.MustCompile(`^\s+(?:static\s+|async\s+|get\s+|set\s+)*\*?([A-Za-z_$][\w$]*)\s*\([^)]*\)\s*\{\s*$`), kind: KindMethod},
+	}
+	typeScriptRules = append([]rule{
+		{pattern: regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?interface\s+([A-Za-z_$][\w$]*)`), kind: KindInterface},
+		{pattern: regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?type\s+([A-Za-z_$][\w$]*)\s*(?:<[^=]*>)?\s*=`), kind: KindType},
+		{pattern: regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+([A-Za-z_$][\w$]*)`), kind: KindType},
+		{pattern: regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*)`), kind: KindClass},
+		{pattern: regexp.MustCompile(`^\s+(?:(?:public|private|protected|static|async|readonly|abstract|override)\s+)*([A-Za-z_$][\w$]*)\s*(?:<[^>]*>)?\([^)]*\)\s*(?::\s*[^{]+)?\{\s*$`), kind: KindMethod},
+	}, javaScriptRules...)
+	pythonRules = []rule{
+		{pattern: regexp.MustCompile(`^\s*(?:async\s+)?def\s+([A-Za-z_]\w*)\s*\(`), kind: KindFunction, indentedKind: KindMethod},
+		{pattern: regexp.MustCompile(`^\s*class\s+([A-Za-z_]\w*)`), kind: KindClass},
+		{pattern: regexp.MustCompile(`^([A-Z][A-Z0-9_]*)\s*(?::[^=]+)?=[^=]`), kind: KindConstant},
+		{pattern: regexp.MustCompile(`^([A-Za-z_]\w*)\s*(?::[^=]+)?=[^=]`), kind: KindVariable},
+	}
+	javaRules = []rule{
+		{pattern: regexp.MustCompile(`^\s*(?:(?:public|protected|private|static|final|abstract|sealed|non-sealed|strictfp)\s+)*(?:class|record)\s+([A-Za-z_$][\w$]*)`), kind: KindClass},
+		{pattern: regexp.MustCompile(`^\s*(?:(?:public|protected|private|static|abstract|sealed|non-sealed|strictfp)\s+)*(?:interface|@interface)\s+([A-Za-z_$][\w$]*)`), kind: KindInterface},
+		{pattern: regexp.MustCompile(`^\s*(?:(?:public|protected|private|static|final|strictfp)\s+)*enum\s+([A-Za-z_$][\w$]*)`), kind: KindType},
+		{pattern: regexp.MustCompile(`^\s+(?:(?:public|protected|private|static|final|abstract|synchronized|native|default|strictfp)\s+)+(?:<[^>]+>\s+)?(?:[\w$.]+(?:<[^()]*>)?(?:\[\])*\s+)?([A-Za-z_$][\w$]*)\s*\([^;]*$`), kind: KindMethod},
+		{pattern: regexp.MustCompile(`^\s+(?:<[^>]+>\s+)?[\w$.]+(?:<[^()]*>)?(?:\[\])*\s+([A-Za-z_$][\w$]*)\s*\([^;]*$`), kind: KindMethod},
+		{pattern: regexp.MustCompile(`^\s+(?:(?:public|protected|private)\s+)?static\s+final\s+[\w$.<>,\[\] ]+?\s+([A-Z_$][A-Z0-9_$]*)\s*=`), kind: KindConstant},
+	}
+)
+
+// newRuleExtractor returns an extractor matching the rules against each line. The first
+// matching rule of a line defines its symbol.
+func newRuleExtractor(rules []rule) func(content []byte) []*Symbol {
+	return func(content []byte) []*Symbol {
+		var symbols []*Symbol
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		inBlockComment := false
+		for lineNum := 1; scanner.Scan(); lineNum++ {
+			line := scanner.Text()
+			trimmed := strings.TrimSpace(line)
+
+			if inBlockComment {
+				inBlockComment = !strings.Contains(trimmed, "*/")
+				continue
+			}
+			if strings.HasPrefix(trimmed, "/*") {
+				inBlockComment = !strings.Contains(trimmed[2:], "*/")
+				continue
+			}
+			if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "*") {
+				continue
+			}
+
+			for _, r := range rules {
+				m := r.pattern.FindStringSubmatch(line)
+				if m == nil || keywords[m[1]] {
+					continue
+				}
+				kind := r.kind
+				if r.indentedKind != "" && strings.TrimLeft(line, " \t") != line {
+					kind = r.indentedKind
+				}
+				symbols = append(symbols, &Symbol{Name: m[1], Kind: kind, Line: lineNum})
+				break
+			}
+		}
+		return symbols
+	}
+}
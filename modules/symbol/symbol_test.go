@@ -0,0 +1,167 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package symbol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtract(t *testing.T) {
+	kases := []struct {
+		language string
+		content  string
+		expected []*Symbol
+	}{
+		{
+			language: "Go",
+			content: `package main
+
+const Version = "1.0"
+
+var (
+	a, _ = 1, 2
+)
+
+type Reader interface {
+	Read() error
+}
+
+type file struct{}
+
+func (f *file) Read() error {
+	return nil
+}
+
+func main() {
+	x := 1
+}
+`,
+			expected: []*Symbol{
+				{Name: "Version", Kind: KindConstant, Line: 3},
+				{Name: "a", Kind: KindVariable, Line: 6},
+				{Name: "Reader", Kind: KindInterface, Line: 9},
+				{Name: "file", Kind: KindType, Line: 13},
+				{Name: "Read", Kind: KindMethod, Line: 15},
+				{Name: "main", Kind: KindFunction, Line: 19},
+			},
+		},
+		{
+			language: "JavaScript",
+			content: `// function commented(
+export const API_URL = '/api';
+export async function fetchData(url) {
+  if (url) {
+    return null;
+  }
+}
+const handler = (e) => e;
+export default class Popup {
+  async show(target) {
+    for (const a of b) {
+    }
+  }
+}
+`,
+			expected: []*Symbol{
+				{Name: "API_URL", Kind: KindConstant, Line: 2},
+				{Name: "fetchData", Kind: KindFunction, Line: 3},
+				{Name: "handler", Kind: KindFunction, Line: 8},
+				{Name: "Popup", Kind: KindClass, Line: 9},
+				{Name: "show", Kind: KindMethod, Line: 10},
+			},
+		},
+		{
+			language: "TypeScript",
+			content: `export interface Options {
+  name: string;
+}
+export type ID = string | number;
+enum Color { Red }
+class Store<T> {
+  public get(id: ID): T {
+  }
+}
+`,
+			expected: []*Symbol{
+				{Name: "Options", Kind: KindInterface, Line: 1},
+				{Name: "ID", Kind: KindType, Line: 4},
+				{Name: "Color", Kind: KindType, Line: 5},
+				{Name: "Store", Kind: KindClass, Line: 6},
+				{Name: "get", Kind: KindMethod, Line: 7},
+			},
+		},
+		{
+			language: "Python",
+			content: `MAX_SIZE = 10
+cache: dict = {}
+
+class Parser(object):
+    def parse(self, text):
+        if text == "":
+            return None
+
+async def main():
+    pass
+`,
+			expected: []*Symbol{
+				{Name: "MAX_SIZE", Kind: KindConstant, Line: 1},
+				{Name: "cache", Kind: KindVariable, Line: 2},
+				{Name: "Parser", Kind: KindClass, Line: 4},
+				{Name: "parse", Kind: KindMethod, Line: 5},
+				{Name: "main", Kind: KindFunction, Line: 9},
+			},
+		},
+		{
+			language: "Java",
+			content: `package org.example;
+
+/*
+ * class Commented {
+ */
+public class Greeter implements Runnable {
+    private static final int MAX_COUNT = 3;
+
+    public Greeter(String name) {
+        this.name = name;
+    }
+
+    @Override
+    public void run() {
+        if (name != null) {
+            System.out.println(greet(name));
+        }
+    }
+
+    List<String> names(int count) {
+        return null;
+    }
+}
+
+interface Named {
+}
+`,
+			expected: []*Symbol{
+				{Name: "Greeter", Kind: KindClass, Line: 6},
+				{Name: "MAX_COUNT", Kind: KindConstant, Line: 7},
+				{Name: "Greeter", Kind: KindMethod, Line: 9},
+				{Name: "run", Kind: KindMethod, Line: 14},
+				{Name: "names", Kind: KindMethod, Line: 20},
+				{Name: "Named", Kind: KindInterface, Line: 25},
+			},
+		},
+		{
+			language: "Markdown",
+			content:  "# Title",
+		},
+	}
+
+	for _, kase := range kases {
+		t.Run(kase.language, func(t *testing.T) {
+			assert.Equal(t, kase.expected, Extract(kase.language, []byte(kase.content)))
+		})
+	}
+}
@@ -235,6 +235,9 @@ func NewFuncMap() []template.FuncMap {
 		"DisableWebhooks": func() bool {
 			return setting.DisableWebhooks
 		},
+		"MaxWebhookAttempts": func() int {
+			return setting.Webhook.MaxAttempts
+		},
 		"DisableImportLocal": func() bool {
 			return !setting.ImportLocalPaths
 		},
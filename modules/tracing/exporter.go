@@ -0,0 +1,199 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+// Exporter sends the ended spans to a tracing backend
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []*SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+var (
+	globalLock     sync.RWMutex
+	globalExporter Exporter
+	sampleRatio    = 1.0
+)
+
+func getExporter() Exporter {
+	globalLock.RLock()
+	defer globalLock.RUnlock()
+	return globalExporter
+}
+
+func getSampleRatio() float64 {
+	globalLock.RLock()
+	defer globalLock.RUnlock()
+	return sampleRatio
+}
+
+// IsEnabled returns true if the spans are exported
+func IsEnabled() bool {
+	return getExporter() != nil
+}
+
+// SetExporter sets the exporter of the spans and the ratio of the new traces which are sampled,
+// a nil exporter disables tracing. It returns the previous exporter.
+func SetExporter(exporter Exporter, ratio float64) Exporter {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	previous := globalExporter
+	globalExporter = exporter
+	sampleRatio = ratio
+	return previous
+}
+
+// Shutdown disables tracing and flushes the spans which have not been exported yet
+func Shutdown(ctx context.Context) error {
+	if exporter := SetExporter(nil, 1); exporter != nil {
+		return exporter.Shutdown(ctx)
+	}
+	return nil
+}
+
+// InMemoryExporter keeps the exported spans in memory, it is meant for the tests
+type InMemoryExporter struct {
+	lock  sync.Mutex
+	spans []*SpanData
+}
+
+// NewInMemoryExporter creates an in-memory exporter
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpans keeps the spans
+func (e *InMemoryExporter) ExportSpans(_ context.Context, spans []*SpanData) error {
+	e.lock.Lock()
+	e.spans = append(e.spans, spans...)
+	e.lock.Unlock()
+	return nil
+}
+
+// Shutdown does nothing
+func (e *InMemoryExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// Spans returns the exported spans in the order they ended
+func (e *InMemoryExporter) Spans() []*SpanData {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return append([]*SpanData{}, e.spans...)
+}
+
+// Reset forgets the exported spans
+func (e *InMemoryExporter) Reset() {
+	e.lock.Lock()
+	e.spans = nil
+	e.lock.Unlock()
+}
+
+const (
+	batchQueueLength = 2048
+	batchMaxLength   = 512
+)
+
+// BatchExporter queues the spans and sends them in batches to another exporter in the background,
+// so that the spans are not exported on the hot path. The spans are dropped if the queue is full.
+type BatchExporter struct {
+	next     Exporter
+	interval time.Duration
+	timeout  time.Duration
+	lock     sync.RWMutex
+	closed   bool
+	queue    chan *SpanData
+	done     chan struct{}
+}
+
+// NewBatchExporter creates a batch exporter sending the spans to next at least every interval
+func NewBatchExporter(next Exporter, interval, timeout time.Duration) *BatchExporter {
+	e := &BatchExporter{
+		next:     next,
+		interval: interval,
+		timeout:  timeout,
+		queue:    make(chan *SpanData, batchQueueLength),
+		done:     make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// ExportSpans queues the spans
+func (e *BatchExporter) ExportSpans(_ context.Context, spans []*SpanData) error {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	if e.closed {
+		return nil
+	}
+	for _, span := range spans {
+		select {
+		case e.queue <- span:
+		default:
+			log.Trace("Tracing queue is full, dropping span %s", span.Name)
+		}
+	}
+	return nil
+}
+
+// Shutdown exports the queued spans and shuts down the next exporter
+func (e *BatchExporter) Shutdown(ctx context.Context) error {
+	e.lock.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.queue)
+	}
+	e.lock.Unlock()
+
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return e.next.Shutdown(ctx)
+}
+
+func (e *BatchExporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	batch := make([]*SpanData, 0, batchMaxLength)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+		defer cancel()
+		if err := e.next.ExportSpans(ctx, batch); err != nil {
+			log.Warn("Unable to export %d spans: %v", len(batch), err)
+		}
+		batch = make([]*SpanData, 0, batchMaxLength)
+	}
+
+	for {
+		select {
+		case span, ok := <-e.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= batchMaxLength {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// OTLPExporter exports the spans to an OpenTelemetry collector with the OTLP/HTTP protocol,
+// encoded in JSON
+type OTLPExporter struct {
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	client      *http.Client
+}
+
+// NewOTLPExporter creates an exporter posting the spans to the traces endpoint of a collector,
+// e.g. http://localhost:4318/v1/traces
+func NewOTLPExporter(endpoint, serviceName string, headers map[string]string) *OTLPExporter {
+	return &OTLPExporter{
+		endpoint:    endpoint,
+		headers:     headers,
+		serviceName: serviceName,
+		client:      &http.Client{},
+	}
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+const (
+	otlpStatusUnset = 0
+	otlpStatusError = 2
+)
+
+func toOTLPValue(value interface{}) otlpValue {
+	switch v := value.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int:
+		s := strconv.Itoa(v)
+		return otlpValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return otlpValue{IntValue: &s}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	default:
+		s := fmt.Sprint(v)
+		return otlpValue{StringValue: &s}
+	}
+}
+
+func toOTLPAttributes(attributes map[string]interface{}) []otlpAttribute {
+	result := make([]otlpAttribute, 0, len(attributes))
+	for key, value := range attributes {
+		result = append(result, otlpAttribute{Key: key, Value: toOTLPValue(value)})
+	}
+	return result
+}
+
+func (e *OTLPExporter) newRequest(spans []*SpanData) *otlpRequest {
+	scopeSpans := otlpScopeSpans{
+		Spans: make([]otlpSpan, 0, len(spans)),
+	}
+	scopeSpans.Scope.Name = "code.gitea.io/gitea"
+	scopeSpans.Scope.Version = setting.AppVer
+
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceID:           span.TraceID.String(),
+			SpanID:            span.SpanID.String(),
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        toOTLPAttributes(span.Attributes),
+			Status:            otlpStatus{Code: otlpStatusUnset},
+		}
+		if span.ParentSpanID.IsValid() {
+			s.ParentSpanID = span.ParentSpanID.String()
+		}
+		if span.Error != "" {
+			s.Status = otlpStatus{Code: otlpStatusError, Message: span.Error}
+		}
+		scopeSpans.Spans = append(scopeSpans.Spans, s)
+	}
+
+	resourceSpans := otlpResourceSpans{
+		ScopeSpans: []otlpScopeSpans{scopeSpans},
+	}
+	resourceSpans.Resource.Attributes = toOTLPAttributes(map[string]interface{}{
+		"service.name":    e.serviceName,
+		"service.version": setting.AppVer,
+	})
+	return &otlpRequest{ResourceSpans: []otlpResourceSpans{resourceSpans}}
+}
+
+// ExportSpans posts the spans to the collector
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []*SpanData) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(e.newRequest(spans))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status from %s: %s", e.endpoint, resp.Status)
+	}
+	return nil
+}
+
+// Shutdown does nothing, the spans are exported synchronously
+func (e *OTLPExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// Init starts exporting the spans to the OTLP endpoint of the settings if tracing is enabled
+func Init() {
+	if !setting.Tracing.Enabled {
+		return
+	}
+	exporter := NewOTLPExporter(setting.Tracing.Endpoint, setting.Tracing.ServiceName, setting.Tracing.Headers)
+	SetExporter(NewBatchExporter(exporter, setting.Tracing.FlushInterval, setting.Tracing.Timeout), setting.Tracing.SampleRatio)
+	log.Info("Tracing enabled, exporting the spans to %s", setting.Tracing.Endpoint)
+
+	graceful.GetManager().RunAtTerminate(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), setting.Tracing.Timeout)
+		defer cancel()
+		if err := Shutdown(ctx); err != nil {
+			log.Warn("Unable to export the remaining spans: %v", err)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/json"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	received := make(chan *otlpRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		req := new(otlpRequest)
+		assert.NoError(t, json.Unmarshal(body, req))
+		received <- req
+	}))
+	defer server.Close()
+
+	exporter := NewBatchExporter(NewOTLPExporter(server.URL, "gitea-test", map[string]string{"Authorization": "secret"}), time.Hour, time.Minute)
+	SetExporter(exporter, 1)
+	defer SetExporter(nil, 1)
+
+	ctx, root := Start(context.Background(), "GET /{username}", SpanKindServer)
+	root.SetAttribute("http.status_code", 200)
+	_, child := StartChild(ctx, "git cat-file", SpanKindClient)
+	child.RecordError(context.DeadlineExceeded)
+	child.End()
+	root.End()
+
+	// the shutdown flushes the queued spans
+	assert.NoError(t, Shutdown(context.Background()))
+	assert.False(t, IsEnabled())
+
+	req := <-received
+	if assert.Len(t, req.ResourceSpans, 1) && assert.Len(t, req.ResourceSpans[0].ScopeSpans, 1) {
+		var serviceName string
+		for _, attribute := range req.ResourceSpans[0].Resource.Attributes {
+			if attribute.Key == "service.name" {
+				serviceName = *attribute.Value.StringValue
+			}
+		}
+		assert.Equal(t, "gitea-test", serviceName)
+
+		spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+		if assert.Len(t, spans, 2) {
+			assert.Equal(t, "git cat-file", spans[0].Name)
+			assert.Equal(t, SpanKindClient, spans[0].Kind)
+			assert.Equal(t, otlpStatusError, spans[0].Status.Code)
+			assert.Equal(t, root.Context().SpanID.String(), spans[0].ParentSpanID)
+			assert.Equal(t, root.Context().TraceID.String(), spans[0].TraceID)
+
+			assert.Equal(t, "GET /{username}", spans[1].Name)
+			assert.Empty(t, spans[1].ParentSpanID)
+			assert.Equal(t, otlpStatusUnset, spans[1].Status.Code)
+			if assert.Len(t, spans[1].Attributes, 1) {
+				assert.Equal(t, "http.status_code", spans[1].Attributes[0].Key)
+				assert.Equal(t, "200", *spans[1].Attributes[0].Value.IntValue)
+			}
+		}
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+// TraceID is the identifier of a trace
+type TraceID [16]byte
+
+// IsValid returns true if the identifier is not only zeros
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// String returns the hexadecimal representation of the identifier
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID is the identifier of a span in a trace
+type SpanID [8]byte
+
+// IsValid returns true if the identifier is not only zeros
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// String returns the hexadecimal representation of the identifier
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanContext identifies a span, it is what is propagated to the children of the span, even
+// across processes
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid returns true if the span context identifies a span
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// SpanKind is the kind of a span, as defined by OpenTelemetry
+type SpanKind int
+
+// The kinds of span
+const (
+	SpanKindInternal SpanKind = iota + 1
+	SpanKindServer
+	SpanKindClient
+	SpanKindProducer
+	SpanKindConsumer
+)
+
+// SpanData is the snapshot of an ended span sent to the exporters
+type SpanData struct {
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Name         string
+	Kind         SpanKind
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]interface{}
+	// the description of the error if the operation failed, empty otherwise
+	Error string
+}
+
+// Span is an operation of a trace. All its methods can be called on a nil span, which is what
+// is started when tracing is disabled or the trace is not sampled.
+type Span struct {
+	lock  sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// Context returns the span context of the span
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return SpanContext{
+		TraceID: s.data.TraceID,
+		SpanID:  s.data.SpanID,
+		Sampled: true,
+	}
+}
+
+// SetName changes the name of the span, e.g. once a request is routed
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.data.Name = name
+	s.lock.Unlock()
+}
+
+// SetAttribute sets an attribute of the span, the value should be a string, a bool, an integer
+// or a float
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.data.Attributes[key] = value
+	s.lock.Unlock()
+}
+
+// RecordError marks the span as failed if the error is not nil
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.lock.Lock()
+	s.data.Error = err.Error()
+	s.lock.Unlock()
+}
+
+// End ends the span and sends it to the exporter, only the first call has an effect
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.lock.Unlock()
+
+	if exporter := getExporter(); exporter != nil {
+		if err := exporter.ExportSpans(context.Background(), []*SpanData{&data}); err != nil {
+			log.Warn("Unable to export span %s: %v", data.Name, err)
+		}
+	}
+}
+
+type contextKey int
+
+const (
+	spanKey contextKey = iota
+	remoteSpanContextKey
+)
+
+// SpanFromContext returns the span of the context, nil if none
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext returns a context with a span started elsewhere, e.g. in another
+// process or before a task was queued, as parent of the spans started from it
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanContextKey, sc)
+}
+
+// SpanContextFromContext returns the span context of the span of the context, or the remote one
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return SpanContext{}
+	}
+	if span := SpanFromContext(ctx); span != nil {
+		return span.Context()
+	}
+	sc, _ := ctx.Value(remoteSpanContextKey).(SpanContext)
+	return sc
+}
+
+// Start starts a span, as a child of the span of the context if any or as the root of a new
+// trace otherwise. The span must be ended by the caller. It returns a nil span if tracing is
+// disabled or if the trace is not sampled.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if !IsEnabled() {
+		return ctx, nil
+	}
+	parent := SpanContextFromContext(ctx)
+	if parent.IsValid() {
+		if !parent.Sampled {
+			return ctx, nil
+		}
+	} else if !sample() {
+		return ctx, nil
+	}
+	return startSpan(ctx, parent, name, kind)
+}
+
+// StartChild starts a span only if the context has a span, it is used by the lower layers, e.g.
+// the database or git commands, which are not worth a trace on their own
+func StartChild(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if !IsEnabled() {
+		return ctx, nil
+	}
+	parent := SpanContextFromContext(ctx)
+	if !parent.IsValid() || !parent.Sampled {
+		return ctx, nil
+	}
+	return startSpan(ctx, parent, name, kind)
+}
+
+func startSpan(ctx context.Context, parent SpanContext, name string, kind SpanKind) (context.Context, *Span) {
+	span := &Span{
+		data: SpanData{
+			TraceID:      parent.TraceID,
+			ParentSpanID: parent.SpanID,
+			Name:         name,
+			Kind:         kind,
+			Start:        time.Now(),
+			Attributes:   map[string]interface{}{},
+		},
+	}
+	if !span.data.TraceID.IsValid() {
+		_, _ = rand.Read(span.data.TraceID[:])
+	}
+	_, _ = rand.Read(span.data.SpanID[:])
+	return context.WithValue(ctx, spanKey, span), span
+}
+
+func sample() bool {
+	ratio := getSampleRatio()
+	if ratio >= 1 {
+		return true
+	} else if ratio <= 0 {
+		return false
+	}
+	const precision = 1 << 30
+	n, err := rand.Int(rand.Reader, big.NewInt(precision))
+	if err != nil {
+		return false
+	}
+	return float64(n.Int64()) < ratio*precision
+}
+
+// ParseTraceParent parses a W3C traceparent header, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceParent(header string) (SpanContext, error) {
+	var sc SpanContext
+	if len(header) < 55 || header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return sc, fmt.Errorf("invalid traceparent: %q", header)
+	}
+	version, err := hex.DecodeString(header[:2])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(header) != 55) {
+		return sc, fmt.Errorf("invalid traceparent version: %q", header)
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(header[3:35])); err != nil {
+		return sc, fmt.Errorf("invalid traceparent trace id: %q", header)
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(header[36:52])); err != nil {
+		return sc, fmt.Errorf("invalid traceparent span id: %q", header)
+	}
+	flags, err := hex.DecodeString(header[53:55])
+	if err != nil {
+		return sc, fmt.Errorf("invalid traceparent flags: %q", header)
+	}
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("invalid traceparent identifiers: %q", header)
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+// FormatTraceParent formats a span context as a W3C traceparent header
+func FormatTraceParent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
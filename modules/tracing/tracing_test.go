@@ -0,0 +1,100 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStart(t *testing.T) {
+	// disabled
+	ctx, span := Start(context.Background(), "disabled", SpanKindServer)
+	assert.Nil(t, span)
+	assert.Nil(t, SpanFromContext(ctx))
+	span.SetAttribute("key", "value")
+	span.End()
+
+	exporter := NewInMemoryExporter()
+	SetExporter(exporter, 1)
+	defer SetExporter(nil, 1)
+
+	// a child is only started below a span
+	_, child := StartChild(context.Background(), "orphan", SpanKindClient)
+	assert.Nil(t, child)
+
+	ctx, root := Start(context.Background(), "root", SpanKindServer)
+	assert.NotNil(t, root)
+	assert.Equal(t, root, SpanFromContext(ctx))
+	_, child = StartChild(ctx, "child", SpanKindClient)
+	child.SetAttribute("db.statement", "SELECT 1")
+	child.RecordError(errors.New("failed"))
+	child.End()
+	root.SetName("renamed")
+	root.End()
+	root.End()
+
+	spans := exporter.Spans()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "child", spans[0].Name)
+		assert.Equal(t, "renamed", spans[1].Name)
+		assert.Equal(t, spans[1].TraceID, spans[0].TraceID)
+		assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+		assert.False(t, spans[1].ParentSpanID.IsValid())
+		assert.Equal(t, "SELECT 1", spans[0].Attributes["db.statement"])
+		assert.Equal(t, "failed", spans[0].Error)
+		assert.False(t, spans[1].End.Before(spans[1].Start))
+	}
+
+	// not sampled
+	exporter.Reset()
+	SetExporter(exporter, 0)
+	ctx, root = Start(context.Background(), "root", SpanKindServer)
+	assert.Nil(t, root)
+	_, child = StartChild(ctx, "child", SpanKindClient)
+	assert.Nil(t, child)
+	assert.Empty(t, exporter.Spans())
+}
+
+func TestRemoteSpanContext(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	SetExporter(exporter, 0)
+	defer SetExporter(nil, 1)
+
+	sc, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.NoError(t, err)
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", FormatTraceParent(sc))
+
+	// the sampling decision of the remote parent is followed
+	_, span := Start(ContextWithRemoteSpanContext(context.Background(), sc), "remote", SpanKindServer)
+	if assert.NotNil(t, span) {
+		span.End()
+		assert.Equal(t, sc.TraceID, exporter.Spans()[0].TraceID)
+		assert.Equal(t, sc.SpanID, exporter.Spans()[0].ParentSpanID)
+	}
+
+	sc.Sampled = false
+	_, span = Start(ContextWithRemoteSpanContext(context.Background(), sc), "remote", SpanKindServer)
+	assert.Nil(t, span)
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, err := ParseTraceParent(header)
+		assert.Error(t, err, header)
+	}
+}
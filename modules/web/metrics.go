@@ -0,0 +1,54 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"code.gitea.io/gitea/modules/context"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "gitea",
+	Subsystem: "http",
+	Name:      "request_duration_seconds",
+	Help:      "Duration of the HTTP requests by method, route pattern and status code",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+func init() {
+	prometheus.MustRegister(requestDuration)
+}
+
+// RequestMetrics returns a middleware recording the duration of the requests by route pattern.
+// The routes are only known once routed, so it must be used on the root router.
+func RequestMetrics() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			res := context.NewResponse(resp)
+			next.ServeHTTP(res, req)
+
+			route := ""
+			if rctx := chi.RouteContext(req.Context()); rctx != nil {
+				route = rctx.RoutePattern()
+			}
+			if route == "" {
+				// not routed, keep the cardinality of the label bounded
+				route = "unknown"
+			}
+			status := res.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			requestDuration.WithLabelValues(req.Method, route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+		})
+	}
+}
@@ -10,7 +10,10 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"code.gitea.io/gitea/modules/tracing"
+
 	chi "github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -167,3 +170,67 @@ func TestRoute3(t *testing.T) {
 	assert.EqualValues(t, http.StatusOK, recorder.Code)
 	assert.EqualValues(t, 4, route)
 }
+
+func TestRequestMetrics(t *testing.T) {
+	r := NewRoute()
+	r.Use(RequestMetrics())
+	r.Get("/{username}/{reponame}/metrics-test", func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusAccepted)
+	})
+
+	req, err := http.NewRequest("GET", "http://localhost:8000/gitea/gitea/metrics-test", nil)
+	assert.NoError(t, err)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.NoError(t, err)
+	var count uint64
+	for _, family := range families {
+		if family.GetName() != "gitea_http_request_duration_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["route"] == "/{username}/{reponame}/metrics-test" && labels["status"] == "202" && labels["method"] == "GET" {
+				count = m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	assert.EqualValues(t, 1, count)
+}
+
+func TestRequestTracing(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	tracing.SetExporter(exporter, 1)
+	defer tracing.SetExporter(nil, 1)
+
+	r := NewRoute()
+	r.Use(RequestTracing())
+	r.Get("/{username}/{reponame}/tracing-test", func(resp http.ResponseWriter, req *http.Request) {
+		_, span := tracing.StartChild(req.Context(), "child", tracing.SpanKindInternal)
+		span.End()
+		resp.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req, err := http.NewRequest("GET", "http://localhost:8000/gitea/gitea/tracing-test", nil)
+	assert.NoError(t, err)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.Spans()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "child", spans[0].Name)
+		assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+
+		assert.Equal(t, "GET /{username}/{reponame}/tracing-test", spans[1].Name)
+		assert.Equal(t, tracing.SpanKindServer, spans[1].Kind)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[1].TraceID.String())
+		assert.Equal(t, "00f067aa0ba902b7", spans[1].ParentSpanID.String())
+		assert.Equal(t, http.StatusInternalServerError, spans[1].Attributes["http.status_code"])
+		assert.Equal(t, "/gitea/gitea/tracing-test", spans[1].Attributes["http.target"])
+		assert.NotEmpty(t, spans[1].Error)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/tracing"
+
+	chi "github.com/go-chi/chi/v5"
+)
+
+// RequestTracing returns a middleware starting a span for each request, continuing the trace of
+// the traceparent header if any. The span is named after the route pattern once routed, so it
+// must be used on the root router.
+func RequestTracing() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			if sc, err := tracing.ParseTraceParent(req.Header.Get("traceparent")); err == nil {
+				ctx = tracing.ContextWithRemoteSpanContext(ctx, sc)
+			}
+			ctx, span := tracing.Start(ctx, req.Method, tracing.SpanKindServer)
+			if span == nil {
+				next.ServeHTTP(resp, req)
+				return
+			}
+			defer span.End()
+
+			res := context.NewResponse(resp)
+			next.ServeHTTP(res, req.WithContext(ctx))
+
+			route := ""
+			if rctx := chi.RouteContext(req.Context()); rctx != nil {
+				route = rctx.RoutePattern()
+			}
+			if route != "" {
+				span.SetName(req.Method + " " + route)
+				span.SetAttribute("http.route", route)
+			}
+			status := res.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttribute("http.method", req.Method)
+			span.SetAttribute("http.target", req.URL.Path)
+			span.SetAttribute("http.status_code", status)
+			if status >= http.StatusInternalServerError {
+				span.RecordError(fmt.Errorf("%d %s", status, http.StatusText(status)))
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/web"
+)
+
+// Routes provides the endpoints external runners use to register, fetch jobs and report their progress
+func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
+	r := web.NewRoute()
+
+	r.Use(sessioner)
+	r.Use(context.APIContexter())
+
+	r.Post("/runners/register", RegisterRunner)
+	r.Group("", func() {
+		r.Post("/runners/fetch-task", FetchTask)
+		r.Group("/tasks/{id}", func() {
+			r.Post("/logs", UploadLog)
+			r.Post("/state", UpdateTaskState)
+		}, reqTask)
+	}, reqRunner)
+
+	return r
+}
@@ -0,0 +1,210 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+	actions_service "code.gitea.io/gitea/services/actions"
+)
+
+const (
+	runnerUUIDHeader  = "X-Runner-UUID"
+	runnerTokenHeader = "X-Runner-Token"
+)
+
+func apiError(ctx *context.APIContext, status int, obj interface{}) {
+	message := fmt.Sprint(obj)
+	if err, ok := obj.(error); ok {
+		message = err.Error()
+	}
+	if status == http.StatusInternalServerError {
+		log.Error("actions runner api: %s", message)
+	}
+	ctx.JSON(status, map[string]string{
+		"error": message,
+	})
+}
+
+func runnerFromContext(ctx *context.APIContext) *actions_model.ActionRunner {
+	return ctx.Data["ActionsRunner"].(*actions_model.ActionRunner)
+}
+
+func jobFromContext(ctx *context.APIContext) *actions_model.ActionRunJob {
+	return ctx.Data["ActionsJob"].(*actions_model.ActionRunJob)
+}
+
+// reqRunner authenticates the runner by its credentials
+func reqRunner(ctx *context.APIContext) {
+	runner, err := actions_model.GetRunnerByCredentials(ctx, ctx.Req.Header.Get(runnerUUIDHeader), ctx.Req.Header.Get(runnerTokenHeader))
+	if err != nil {
+		if err == actions_model.ErrRunnerNotExist {
+			apiError(ctx, http.StatusUnauthorized, "invalid runner credentials")
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := actions_model.UpdateRunnerLastOnline(ctx, runner); err != nil {
+		log.Error("UpdateRunnerLastOnline: %v", err)
+	}
+
+	ctx.Data["ActionsRunner"] = runner
+}
+
+// reqTask loads the task which must be assigned to the runner
+func reqTask(ctx *context.APIContext) {
+	job, err := actions_model.GetRunJobByID(ctx, ctx.ParamsInt64("id"))
+	if err != nil {
+		if err == actions_model.ErrRunJobNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if job.RunnerID != runnerFromContext(ctx).ID {
+		apiError(ctx, http.StatusNotFound, actions_model.ErrRunJobNotExist)
+		return
+	}
+
+	ctx.Data["ActionsJob"] = job
+}
+
+// RegisterRunner registers a new runner with a registration token
+func RegisterRunner(ctx *context.APIContext) {
+	var form api.RegisterActionRunnerOption
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&form); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if form.Token == "" {
+		apiError(ctx, http.StatusBadRequest, "token is required")
+		return
+	}
+	if len(form.Name) > 255 {
+		apiError(ctx, http.StatusBadRequest, "name is too long")
+		return
+	}
+
+	runner, err := actions_service.RegisterRunner(ctx, form.Token, form.Name, form.Labels)
+	if err != nil {
+		if err == actions_model.ErrRunnerTokenNotExist {
+			apiError(ctx, http.StatusUnauthorized, "invalid registration token")
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, &api.ActionRunnerCredentials{
+		UUID:   runner.UUID,
+		Token:  runner.Token,
+		Name:   runner.Name,
+		Labels: runner.Labels,
+	})
+}
+
+// FetchTask assigns a waiting job to the runner.
+// 204 No Content is returned if there is no job for the runner.
+func FetchTask(ctx *context.APIContext) {
+	job, err := actions_service.PickJob(ctx, runnerFromContext(ctx))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if job == nil {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+
+	run, err := actions_model.GetRunByID(ctx, job.RunID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	repo, err := models.GetRepositoryByIDCtx(ctx, job.RepoID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &api.ActionTask{
+		ID:         job.ID,
+		RunID:      run.ID,
+		JobID:      job.JobID,
+		Name:       job.Name,
+		Repository: repo.FullName(),
+		CloneURL:   repo.CloneLink().HTTPS,
+		Event:      run.Event,
+		Ref:        run.Ref,
+		SHA:        job.CommitSHA,
+		Workflow:   job.WorkflowPayload,
+		Token:      job.Token,
+	})
+}
+
+func taskState(job *actions_model.ActionRunJob) *api.ActionTaskState {
+	return &api.ActionTaskState{
+		Status:    job.Status.String(),
+		LogLength: job.LogLength,
+	}
+}
+
+// UploadLog appends the request body to the log of the task.
+// The offset query parameter must match the current log length.
+func UploadLog(ctx *context.APIContext) {
+	job := jobFromContext(ctx)
+
+	if err := actions_service.AppendJobLog(ctx, job, ctx.FormInt64("offset"), ctx.Req.Body); err != nil {
+		switch err {
+		case actions_service.ErrInvalidLogOffset:
+			ctx.JSON(http.StatusConflict, taskState(job))
+		case actions_service.ErrLogTooLarge:
+			apiError(ctx, http.StatusRequestEntityTooLarge, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, taskState(job))
+}
+
+// UpdateTaskState reports the state of the task.
+// The response contains the state stored on the server so the runner can detect cancelled tasks.
+func UpdateTaskState(ctx *context.APIContext) {
+	job := jobFromContext(ctx)
+
+	var form api.UpdateActionTaskStateOption
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&form); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	status := actions_model.StatusFromString(strings.ToLower(form.Status))
+	if err := actions_service.UpdateJobStatus(ctx, job, status); err != nil {
+		switch err {
+		case actions_service.ErrInvalidStatusChange:
+			apiError(ctx, http.StatusBadRequest, err)
+		case actions_model.ErrRunJobStateConflict:
+			apiError(ctx, http.StatusConflict, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, taskState(job))
+}
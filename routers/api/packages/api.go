@@ -0,0 +1,81 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/packages/container"
+	"code.gitea.io/gitea/routers/api/packages/maven"
+	"code.gitea.io/gitea/routers/api/packages/npm"
+	"code.gitea.io/gitea/services/auth"
+)
+
+func reqPackageAccess(accessMode models.AccessMode) func(ctx *context.APIContext) {
+	return func(ctx *context.APIContext) {
+		if ctx.Package.AccessMode < accessMode {
+			if !ctx.IsSigned {
+				ctx.Resp.Header().Set("WWW-Authenticate", `Basic realm="Gitea Package API"`)
+				ctx.Error(http.StatusUnauthorized, "reqPackageAccess", "user should be signed in")
+				return
+			}
+			ctx.Error(http.StatusForbidden, "reqPackageAccess", "user should have specific permission or be a site admin")
+		}
+	}
+}
+
+// Routes provides the endpoints of the npm and Maven registries
+func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
+	r := web.NewRoute()
+
+	r.Use(sessioner)
+	r.Use(context.APIContexter())
+	r.Use(context.APIAuth(auth.NewGroup(auth.Methods()...)))
+
+	r.Group("/{username}", func() {
+		r.Group("/maven", func() {
+			r.Put("/*", reqPackageAccess(models.AccessModeWrite), maven.UploadPackageFile)
+			r.Get("/*", maven.DownloadPackageFile)
+			r.Head("/*", maven.DownloadPackageFile)
+		}, reqPackageAccess(models.AccessModeRead))
+		r.Group("/npm", func() {
+			r.Group("/@{scope}/{id}", func() {
+				r.Get("", npm.PackageMetadata)
+				r.Put("", reqPackageAccess(models.AccessModeWrite), npm.UploadPackage)
+				r.Get("/-/{version}/{filename}", npm.DownloadPackageFile)
+				r.Delete("/-/{version}/{filename}/-rev/{revision}", reqPackageAccess(models.AccessModeWrite), npm.DeletePackageVersion)
+				r.Delete("/-rev/{revision}", reqPackageAccess(models.AccessModeWrite), npm.DeletePackage)
+			})
+			r.Group("/{id}", func() {
+				r.Get("", npm.PackageMetadata)
+				r.Put("", reqPackageAccess(models.AccessModeWrite), npm.UploadPackage)
+				r.Get("/-/{version}/{filename}", npm.DownloadPackageFile)
+				r.Delete("/-/{version}/{filename}/-rev/{revision}", reqPackageAccess(models.AccessModeWrite), npm.DeletePackageVersion)
+				r.Delete("/-rev/{revision}", reqPackageAccess(models.AccessModeWrite), npm.DeletePackage)
+			})
+		}, reqPackageAccess(models.AccessModeRead))
+	}, context.PackageAssignmentAPI())
+
+	return r
+}
+
+// ContainerRoutes provides the endpoints of the OCI distribution API which are mounted at /v2
+func ContainerRoutes(sessioner func(http.Handler) http.Handler) *web.Route {
+	r := web.NewRoute()
+
+	r.Use(sessioner)
+	r.Use(context.APIContexter())
+	r.Use(context.APIAuth(auth.NewGroup(auth.Methods()...)))
+
+	r.Get("/", container.ReqContainerAccess, container.DetermineSupport)
+	r.Group("/{username}", func() {
+		r.Any("/*", container.ReqContainerAccess, container.Dispatch)
+	}, context.PackageAssignmentAPI())
+
+	return r
+}
@@ -0,0 +1,384 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+var contentRangePattern = regexp.MustCompile(`\A(\d+)-(\d+)\z`)
+
+// saveAsPackageBlob stores the content as blob of the image. The blob is added to the internal upload version.
+func saveAsPackageBlob(ctx *context.APIContext, image string, buf *packages_module.HashedBuffer) (string, error) {
+	_, _, hashSHA256, _ := buf.Sums()
+	digest := "sha256:" + hashSHA256
+
+	_, _, err := packages_service.CreatePackageOrAddFileToExisting(
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeContainer,
+				Name:        image,
+				Version:     container_module.UploadVersion,
+			},
+			Creator:    ctx.User,
+			IsInternal: true,
+		},
+		&packages_service.PackageFileInfo{
+			Filename: digest,
+		},
+		buf,
+	)
+	if err != nil && err != packages_model.ErrDuplicatePackageFile {
+		return "", err
+	}
+	return digest, nil
+}
+
+// saveBlobFromReader reads the content, verifies the digest and stores it as blob of the image
+func saveBlobFromReader(ctx *context.APIContext, image, digest string, r io.Reader) bool {
+	buf, err := packages_module.NewHashedBuffer(r)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return false
+	}
+	defer buf.Close()
+
+	if _, _, hashSHA256, _ := buf.Sums(); digest != "sha256:"+hashSHA256 {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return false
+	}
+
+	if _, err := saveAsPackageBlob(ctx, image, buf); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return false
+	}
+	return true
+}
+
+// mountBlob links a blob of another image of the same owner
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#mounting-a-blob-from-another-repository
+func mountBlob(ctx *context.APIContext, image, from, digest string) bool {
+	p, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypeContainer, from)
+	if err != nil {
+		return false
+	}
+	pf, err := packages_model.GetFileForPackageByName(ctx, p.ID, digest)
+	if err != nil {
+		return false
+	}
+	s, _, err := packages_service.GetPackageFileStream(ctx, nil, pf)
+	if err != nil {
+		return false
+	}
+	defer s.Close()
+
+	return saveBlobFromReader(ctx, image, digest, s)
+}
+
+// InitiateUploadBlob starts an upload or stores a monolithic upload
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pushing-blobs
+func InitiateUploadBlob(ctx *context.APIContext, image string) {
+	if mount := ctx.FormTrim("mount"); mount != "" {
+		from := ctx.FormTrim("from")
+		if container_module.IsValidDigest(mount) && container_module.IsValidImageName(from) && mountBlob(ctx, image, from, mount) {
+			setResponseHeaders(ctx.Resp, &containerHeaders{
+				Location:      blobLocation(ctx, image, mount),
+				ContentDigest: mount,
+				Status:        http.StatusCreated,
+			})
+			return
+		}
+		if ctx.Written() {
+			return
+		}
+	}
+
+	if digest := ctx.FormTrim("digest"); digest != "" {
+		if !container_module.IsValidDigest(digest) {
+			apiErrorDefined(ctx, errDigestInvalid)
+			return
+		}
+		if !saveBlobFromReader(ctx, image, digest, ctx.Req.Body) {
+			return
+		}
+
+		setResponseHeaders(ctx.Resp, &containerHeaders{
+			Location:      blobLocation(ctx, image, digest),
+			ContentDigest: digest,
+			Status:        http.StatusCreated,
+		})
+		return
+	}
+
+	pbu, err := packages_service.CreateBlobUpload(ctx)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	setResponseHeaders(ctx.Resp, &containerHeaders{
+		Location:   uploadLocation(ctx, image, pbu.ID),
+		Range:      "0-0",
+		UploadUUID: pbu.ID,
+		Status:     http.StatusAccepted,
+	})
+}
+
+func getBlobUpload(ctx *context.APIContext, uuid string) *packages_model.PackageBlobUpload {
+	pbu, err := packages_model.GetBlobUploadByID(ctx, uuid)
+	if err != nil {
+		if err == packages_model.ErrPackageBlobUploadNotExist {
+			apiErrorDefined(ctx, errBlobUploadUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil
+	}
+	return pbu
+}
+
+func uploadRange(pbu *packages_model.PackageBlobUpload) string {
+	if pbu.BytesReceived == 0 {
+		return "0-0"
+	}
+	return fmt.Sprintf("0-%d", pbu.BytesReceived-1)
+}
+
+// GetUploadBlob returns the status of an upload
+// https://docs.docker.com/registry/spec/api/#get-blob-upload
+func GetUploadBlob(ctx *context.APIContext, image, uuid string) {
+	pbu := getBlobUpload(ctx, uuid)
+	if pbu == nil {
+		return
+	}
+
+	setResponseHeaders(ctx.Resp, &containerHeaders{
+		Location:   uploadLocation(ctx, image, pbu.ID),
+		Range:      uploadRange(pbu),
+		UploadUUID: pbu.ID,
+		Status:     http.StatusNoContent,
+	})
+}
+
+// UploadBlob appends a chunk to an upload
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pushing-a-blob-in-chunks
+func UploadBlob(ctx *context.APIContext, image, uuid string) {
+	pbu := getBlobUpload(ctx, uuid)
+	if pbu == nil {
+		return
+	}
+
+	offset := pbu.BytesReceived
+	if contentRange := ctx.Req.Header.Get("Content-Range"); contentRange != "" {
+		m := contentRangePattern.FindStringSubmatch(contentRange)
+		if m == nil {
+			apiErrorDefined(ctx, errRangeInvalid)
+			return
+		}
+		offset, _ = strconv.ParseInt(m[1], 10, 64)
+	}
+
+	if err := packages_service.AppendToBlobUpload(ctx, pbu, offset, ctx.Req.Body); err != nil {
+		if err == packages_service.ErrInvalidUploadOffset {
+			apiErrorDefined(ctx, errRangeInvalid)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	setResponseHeaders(ctx.Resp, &containerHeaders{
+		Location:   uploadLocation(ctx, image, pbu.ID),
+		Range:      uploadRange(pbu),
+		UploadUUID: pbu.ID,
+		Status:     http.StatusAccepted,
+	})
+}
+
+// EndUploadBlob finishes an upload. The request can contain the last chunk.
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pushing-a-blob-in-chunks
+func EndUploadBlob(ctx *context.APIContext, image, uuid string) {
+	pbu := getBlobUpload(ctx, uuid)
+	if pbu == nil {
+		return
+	}
+
+	digest := ctx.FormTrim("digest")
+	if !container_module.IsValidDigest(digest) {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return
+	}
+
+	if ctx.Req.Body != nil {
+		if err := packages_service.AppendToBlobUpload(ctx, pbu, pbu.BytesReceived, ctx.Req.Body); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	f, err := packages_service.OpenBlobUpload(pbu)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ok := saveBlobFromReader(ctx, image, digest, f)
+	f.Close()
+	if !ok {
+		return
+	}
+
+	if err := packages_service.RemoveBlobUploadByID(ctx, pbu.ID); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	setResponseHeaders(ctx.Resp, &containerHeaders{
+		Location:      blobLocation(ctx, image, digest),
+		ContentDigest: digest,
+		Status:        http.StatusCreated,
+	})
+}
+
+// CancelUploadBlob aborts an upload
+// https://docs.docker.com/registry/spec/api/#delete-blob-upload
+func CancelUploadBlob(ctx *context.APIContext, image, uuid string) {
+	if pbu := getBlobUpload(ctx, uuid); pbu == nil {
+		return
+	}
+
+	if err := packages_service.RemoveBlobUploadByID(ctx, uuid); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	setResponseHeaders(ctx.Resp, &containerHeaders{
+		Status: http.StatusNoContent,
+	})
+}
+
+func getBlobFile(ctx *context.APIContext, image, digest string) (*packages_model.PackageFile, *packages_model.PackageBlob) {
+	if !container_module.IsValidDigest(digest) {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return nil, nil
+	}
+
+	p, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypeContainer, image)
+	if err != nil {
+		if packages_model.IsErrPackageNotExist(err) {
+			apiErrorDefined(ctx, errBlobUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil, nil
+	}
+
+	pf, err := packages_model.GetFileForPackageByName(ctx, p.ID, digest)
+	if err != nil {
+		if err == packages_model.ErrPackageFileNotExist {
+			apiErrorDefined(ctx, errBlobUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil, nil
+	}
+
+	pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil, nil
+	}
+	return pf, pb
+}
+
+// HeadBlob checks if a blob exists
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#checking-if-content-exists-in-the-registry
+func HeadBlob(ctx *context.APIContext, image, digest string) {
+	_, pb := getBlobFile(ctx, image, digest)
+	if pb == nil {
+		return
+	}
+
+	setResponseHeaders(ctx.Resp, &containerHeaders{
+		ContentDigest: digest,
+		ContentLength: pb.Size,
+		Status:        http.StatusOK,
+	})
+}
+
+// GetBlob serves the content of a blob
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pulling-blobs
+func GetBlob(ctx *context.APIContext, image, digest string) {
+	pf, pb := getBlobFile(ctx, image, digest)
+	if pf == nil {
+		return
+	}
+
+	s, _, err := packages_service.GetPackageFileStream(ctx, nil, pf)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer s.Close()
+
+	setResponseHeaders(ctx.Resp, &containerHeaders{
+		ContentDigest: digest,
+		ContentType:   "application/octet-stream",
+		ContentLength: pb.Size,
+		Status:        http.StatusOK,
+	})
+	if _, err := io.Copy(ctx.Resp, s); err != nil {
+		log.Error("Error whilst copying content to response: %v", err)
+	}
+}
+
+// DeleteBlob removes a blob from the uploaded blobs of the image. Blobs referenced by manifests are kept.
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#deleting-blobs
+func DeleteBlob(ctx *context.APIContext, image, digest string) {
+	if !container_module.IsValidDigest(digest) {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return
+	}
+
+	pv, err := packages_model.GetInternalVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeContainer, image, container_module.UploadVersion)
+	if err != nil {
+		if packages_model.IsErrPackageVersionNotExist(err) {
+			apiErrorDefined(ctx, errBlobUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, digest)
+	if err != nil {
+		if err == packages_model.ErrPackageFileNotExist {
+			apiErrorDefined(ctx, errBlobUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if err := packages_model.DeleteFileByID(ctx, pf.ID); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	setResponseHeaders(ctx.Resp, &containerHeaders{
+		Status: http.StatusAccepted,
+	})
+}
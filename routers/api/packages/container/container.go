@@ -0,0 +1,248 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+)
+
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#endpoints
+var imagePathPattern = regexp.MustCompile(`\A(.+)/(?:blobs/uploads(?:/([^/]*))?|blobs/([^/]+)|manifests/([^/]+)|(tags/list))\z`)
+
+type containerHeaders struct {
+	Status        int
+	ContentDigest string
+	UploadUUID    string
+	Range         string
+	Location      string
+	ContentType   string
+	ContentLength int64
+}
+
+// https://docs.docker.com/registry/spec/api/#api-version-check
+func setResponseHeaders(resp http.ResponseWriter, h *containerHeaders) {
+	if h.Location != "" {
+		resp.Header().Set("Location", h.Location)
+	}
+	if h.Range != "" {
+		resp.Header().Set("Range", h.Range)
+	}
+	if h.ContentType != "" {
+		resp.Header().Set("Content-Type", h.ContentType)
+	}
+	if h.ContentLength != 0 {
+		resp.Header().Set("Content-Length", strconv.FormatInt(h.ContentLength, 10))
+	}
+	if h.UploadUUID != "" {
+		resp.Header().Set("Docker-Upload-Uuid", h.UploadUUID)
+	}
+	if h.ContentDigest != "" {
+		resp.Header().Set("Docker-Content-Digest", h.ContentDigest)
+		resp.Header().Set("ETag", fmt.Sprintf(`"%s"`, h.ContentDigest))
+	}
+	resp.Header().Set("Docker-Distribution-Api-Version", "registry/2.0")
+	resp.WriteHeader(h.Status)
+}
+
+func jsonResponse(ctx *context.APIContext, status int, obj interface{}) {
+	ctx.Resp.Header().Set("Docker-Distribution-Api-Version", "registry/2.0")
+	ctx.JSON(status, obj)
+}
+
+func apiError(ctx *context.APIContext, status int, err error) {
+	if status == http.StatusInternalServerError {
+		log.Error("container registry: %v", err)
+	}
+	apiErrorDefined(ctx, &namedError{
+		Code:       "UNKNOWN",
+		StatusCode: status,
+		Message:    err.Error(),
+	})
+}
+
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#error-codes
+func apiErrorDefined(ctx *context.APIContext, err *namedError) {
+	type ContainerError struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	type ContainerErrors struct {
+		Errors []ContainerError `json:"errors"`
+	}
+
+	jsonResponse(ctx, err.StatusCode, ContainerErrors{
+		Errors: []ContainerError{
+			{
+				Code:    err.Code,
+				Message: err.Message,
+			},
+		},
+	})
+}
+
+func apiUnauthorizedError(ctx *context.APIContext) {
+	ctx.Resp.Header().Set("WWW-Authenticate", `Basic realm="Gitea Container Registry"`)
+	apiErrorDefined(ctx, errUnauthorized)
+}
+
+// ReqContainerAccess is a middleware which checks if the user is signed in
+func ReqContainerAccess(ctx *context.APIContext) {
+	if ctx.User == nil {
+		apiUnauthorizedError(ctx)
+	}
+}
+
+// DetermineSupport is used to test if the registry supports OCI
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#determining-support
+func DetermineSupport(ctx *context.APIContext) {
+	setResponseHeaders(ctx.Resp, &containerHeaders{
+		Status: http.StatusOK,
+	})
+}
+
+// Dispatch routes the request to the handler of the endpoint.
+// The image name can contain slashes which makes it impossible to use the router for this.
+func Dispatch(ctx *context.APIContext) {
+	m := imagePathPattern.FindStringSubmatch(ctx.Params("*"))
+	if m == nil {
+		apiErrorDefined(ctx, errNameUnknown)
+		return
+	}
+
+	image := m[1]
+	if !container_module.IsValidImageName(image) {
+		apiErrorDefined(ctx, errNameInvalid)
+		return
+	}
+
+	requiredMode := models.AccessModeWrite
+	if ctx.Req.Method == http.MethodGet || ctx.Req.Method == http.MethodHead {
+		requiredMode = models.AccessModeRead
+	}
+	if ctx.Package.AccessMode < requiredMode {
+		if ctx.User == nil {
+			apiUnauthorizedError(ctx)
+		} else {
+			apiErrorDefined(ctx, errDenied)
+		}
+		return
+	}
+
+	switch {
+	case strings.Contains(m[0], "/blobs/uploads"):
+		uuid := m[2]
+		switch {
+		case ctx.Req.Method == http.MethodPost && uuid == "":
+			InitiateUploadBlob(ctx, image)
+		case ctx.Req.Method == http.MethodGet && uuid != "":
+			GetUploadBlob(ctx, image, uuid)
+		case ctx.Req.Method == http.MethodPatch && uuid != "":
+			UploadBlob(ctx, image, uuid)
+		case ctx.Req.Method == http.MethodPut && uuid != "":
+			EndUploadBlob(ctx, image, uuid)
+		case ctx.Req.Method == http.MethodDelete && uuid != "":
+			CancelUploadBlob(ctx, image, uuid)
+		default:
+			apiErrorDefined(ctx, errUnsupported)
+		}
+	case m[3] != "":
+		digest := m[3]
+		switch ctx.Req.Method {
+		case http.MethodHead:
+			HeadBlob(ctx, image, digest)
+		case http.MethodGet:
+			GetBlob(ctx, image, digest)
+		case http.MethodDelete:
+			DeleteBlob(ctx, image, digest)
+		default:
+			apiErrorDefined(ctx, errUnsupported)
+		}
+	case m[4] != "":
+		reference := m[4]
+		switch ctx.Req.Method {
+		case http.MethodHead:
+			HeadManifest(ctx, image, reference)
+		case http.MethodGet:
+			GetManifest(ctx, image, reference)
+		case http.MethodPut:
+			UploadManifest(ctx, image, reference)
+		case http.MethodDelete:
+			DeleteManifest(ctx, image, reference)
+		default:
+			apiErrorDefined(ctx, errUnsupported)
+		}
+	default:
+		if ctx.Req.Method != http.MethodGet {
+			apiErrorDefined(ctx, errUnsupported)
+			return
+		}
+		GetTagList(ctx, image)
+	}
+}
+
+func blobLocation(ctx *context.APIContext, image, digest string) string {
+	return fmt.Sprintf("/v2/%s/%s/blobs/%s", url.PathEscape(ctx.Package.Owner.Name), image, digest)
+}
+
+func uploadLocation(ctx *context.APIContext, image, uuid string) string {
+	return fmt.Sprintf("/v2/%s/%s/blobs/uploads/%s", url.PathEscape(ctx.Package.Owner.Name), image, uuid)
+}
+
+// GetTagList returns the tags of an image
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#content-discovery
+func GetTagList(ctx *context.APIContext, image string) {
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeContainer, image)
+	if err != nil {
+		if packages_model.IsErrPackageNotExist(err) {
+			apiErrorDefined(ctx, errNameUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	tags := make([]string, 0, len(pvs))
+	for _, pv := range pvs {
+		if !container_module.IsValidDigest(pv.Version) {
+			tags = append(tags, pv.Version)
+		}
+	}
+	sort.Strings(tags)
+
+	if last := ctx.FormTrim("last"); last != "" {
+		idx := sort.SearchStrings(tags, last)
+		if idx < len(tags) && tags[idx] == last {
+			idx++
+		}
+		tags = tags[idx:]
+	}
+	if n := ctx.FormInt("n"); n > 0 && n < len(tags) {
+		tags = tags[:n]
+		ctx.Resp.Header().Set("Link", fmt.Sprintf(`</v2/%s/%s/tags/list?n=%d&last=%s>; rel="next"`, url.PathEscape(ctx.Package.Owner.Name), image, n, url.QueryEscape(tags[len(tags)-1])))
+	}
+
+	type TagList struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+
+	jsonResponse(ctx, http.StatusOK, TagList{
+		Name: strings.ToLower(ctx.Package.Owner.Name + "/" + image),
+		Tags: tags,
+	})
+}
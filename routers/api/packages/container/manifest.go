@@ -0,0 +1,277 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+// maxManifestSize limits the size of an uploaded manifest
+const maxManifestSize = 10 * 1024 * 1024
+
+func isValidReference(reference string) bool {
+	if container_module.IsValidDigest(reference) {
+		return true
+	}
+	return container_module.IsValidTag(reference) && reference != container_module.UploadVersion
+}
+
+// UploadManifest stores a manifest under a tag or digest
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pushing-manifests
+func UploadManifest(ctx *context.APIContext, image, reference string) {
+	if !isValidReference(reference) {
+		apiErrorDefined(ctx, errManifestInvalid.WithMessage("Reference is invalid"))
+		return
+	}
+
+	buf, err := packages_module.NewHashedBuffer(io.LimitReader(ctx.Req.Body, maxManifestSize))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	_, _, hashSHA256, _ := buf.Sums()
+	digest := "sha256:" + hashSHA256
+	if container_module.IsValidDigest(reference) && reference != digest {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return
+	}
+
+	m, err := container_module.ParseManifest(buf, ctx.Req.Header.Get("Content-Type"))
+	if err != nil {
+		apiErrorDefined(ctx, errManifestInvalid.WithMessage(err.Error()))
+		return
+	}
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	p, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypeContainer, image)
+	if err != nil {
+		if packages_model.IsErrPackageNotExist(err) {
+			apiErrorDefined(ctx, errManifestBlobUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	// all referenced blobs and manifests must exist already
+	references := make(map[string]int64)
+	for _, d := range append(m.BlobDigests(), m.ManifestDigests()...) {
+		pf, err := packages_model.GetFileForPackageByName(ctx, p.ID, d)
+		if err != nil {
+			if err == packages_model.ErrPackageFileNotExist {
+				apiErrorDefined(ctx, errManifestBlobUnknown.WithMessage(d))
+			} else {
+				apiError(ctx, http.StatusInternalServerError, err)
+			}
+			return
+		}
+		references[d] = pf.BlobID
+	}
+
+	var config io.ReadCloser
+	if !m.IsIndex() {
+		pb, err := packages_model.GetBlobByID(ctx, references[m.Config.Digest])
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		config, err = packages_module.NewContentStore().Get(packages_module.BlobHash256Key(pb.HashSHA256))
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	metadata, err := container_module.ParseMetadata(m, config)
+	if config != nil {
+		config.Close()
+	}
+	if err != nil {
+		apiErrorDefined(ctx, errManifestInvalid.WithMessage(err.Error()))
+		return
+	}
+
+	// tags are mutable, an existing version with the same tag is replaced
+	if pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeContainer, image, reference); err == nil {
+		if err := packages_service.RemovePackageVersion(ctx.User, pv); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	} else if !packages_model.IsErrPackageVersionNotExist(err) {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, _, err = packages_service.CreatePackageAndAddFileWithReferences(
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeContainer,
+				Name:        image,
+				Version:     reference,
+			},
+			Creator:  ctx.User,
+			Metadata: metadata,
+		},
+		&packages_service.PackageFileInfo{
+			Filename: digest,
+			IsLead:   true,
+		},
+		buf,
+		references,
+	)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	setResponseHeaders(ctx.Resp, &containerHeaders{
+		Location:      manifestLocation(ctx, image, digest),
+		ContentDigest: digest,
+		Status:        http.StatusCreated,
+	})
+}
+
+func manifestLocation(ctx *context.APIContext, image, reference string) string {
+	return fmt.Sprintf("/v2/%s/%s/manifests/%s", url.PathEscape(ctx.Package.Owner.Name), image, reference)
+}
+
+// getManifestVersion gets the version and the manifest file of a tag or digest
+func getManifestVersion(ctx *context.APIContext, image, reference string) (*packages_model.PackageVersion, *packages_model.PackageFile) {
+	if !isValidReference(reference) {
+		apiErrorDefined(ctx, errManifestUnknown)
+		return nil, nil
+	}
+
+	var pv *packages_model.PackageVersion
+	var pf *packages_model.PackageFile
+	var err error
+	if container_module.IsValidDigest(reference) {
+		var p *packages_model.Package
+		p, err = packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypeContainer, image)
+		if err == nil {
+			pf, err = packages_model.GetLeadFileForPackageByName(ctx, p.ID, reference)
+			if err == nil {
+				pv, err = packages_model.GetVersionByID(ctx, pf.VersionID)
+			}
+		}
+	} else {
+		pv, err = packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeContainer, image, reference)
+		if err == nil {
+			var pfs []*packages_model.PackageFile
+			pfs, err = packages_model.GetFilesByVersionID(ctx, pv.ID)
+			for _, f := range pfs {
+				if f.IsLead {
+					pf = f
+				}
+			}
+			if err == nil && pf == nil {
+				err = packages_model.ErrPackageFileNotExist
+			}
+		}
+	}
+	if err != nil {
+		if packages_model.IsErrPackageNotExist(err) || packages_model.IsErrPackageVersionNotExist(err) || err == packages_model.ErrPackageFileNotExist {
+			apiErrorDefined(ctx, errManifestUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil, nil
+	}
+	return pv, pf
+}
+
+func manifestHeaders(ctx *context.APIContext, pv *packages_model.PackageVersion, pf *packages_model.PackageFile) (*containerHeaders, *packages_model.PackageBlob) {
+	var metadata *container_module.Metadata
+	if err := json.Unmarshal([]byte(pv.MetadataJSON), &metadata); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil, nil
+	}
+
+	pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil, nil
+	}
+
+	return &containerHeaders{
+		ContentDigest: pf.Name,
+		ContentType:   metadata.MediaType,
+		ContentLength: pb.Size,
+		Status:        http.StatusOK,
+	}, pb
+}
+
+// HeadManifest checks if a manifest exists
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#checking-if-content-exists-in-the-registry
+func HeadManifest(ctx *context.APIContext, image, reference string) {
+	pv, pf := getManifestVersion(ctx, image, reference)
+	if pv == nil {
+		return
+	}
+
+	if h, _ := manifestHeaders(ctx, pv, pf); h != nil {
+		setResponseHeaders(ctx.Resp, h)
+	}
+}
+
+// GetManifest serves the content of a manifest
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pulling-manifests
+func GetManifest(ctx *context.APIContext, image, reference string) {
+	pv, pf := getManifestVersion(ctx, image, reference)
+	if pv == nil {
+		return
+	}
+
+	h, _ := manifestHeaders(ctx, pv, pf)
+	if h == nil {
+		return
+	}
+
+	s, _, err := packages_service.GetPackageFileStream(ctx, pv, pf)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer s.Close()
+
+	setResponseHeaders(ctx.Resp, h)
+	if _, err := io.Copy(ctx.Resp, s); err != nil {
+		log.Error("Error whilst copying content to response: %v", err)
+	}
+}
+
+// DeleteManifest deletes the version of a tag or digest
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#deleting-tags
+func DeleteManifest(ctx *context.APIContext, image, reference string) {
+	pv, _ := getManifestVersion(ctx, image, reference)
+	if pv == nil {
+		return
+	}
+
+	if err := packages_service.RemovePackageVersion(ctx.User, pv); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	setResponseHeaders(ctx.Resp, &containerHeaders{
+		Status: http.StatusAccepted,
+	})
+}
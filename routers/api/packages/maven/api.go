@@ -0,0 +1,44 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package maven
+
+import (
+	"encoding/xml"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+)
+
+// MetadataResponse https://maven.apache.org/ref/3.2.5/maven-repository-metadata/repository-metadata.html
+type MetadataResponse struct {
+	XMLName    xml.Name `xml:"metadata"`
+	GroupID    string   `xml:"groupId"`
+	ArtifactID string   `xml:"artifactId"`
+	Release    string   `xml:"versioning>release,omitempty"`
+	Latest     string   `xml:"versioning>latest"`
+	Version    []string `xml:"versioning>versions>version"`
+}
+
+// createMetadataResponse expects the versions ordered by creation, the newest first
+func createMetadataResponse(params *parameters, pvs []*packages_model.PackageVersion) *MetadataResponse {
+	resp := &MetadataResponse{
+		GroupID:    params.GroupID,
+		ArtifactID: params.ArtifactID,
+		Latest:     pvs[0].Version,
+		Version:    make([]string, 0, len(pvs)),
+	}
+
+	for i := len(pvs) - 1; i >= 0; i-- {
+		resp.Version = append(resp.Version, pvs[i].Version)
+	}
+	for _, pv := range pvs {
+		if !strings.HasSuffix(pv.Version, "-SNAPSHOT") {
+			resp.Release = pv.Version
+			break
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,358 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package maven
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	maven_module "code.gitea.io/gitea/modules/packages/maven"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+const (
+	mavenMetadataFile = "maven-metadata.xml"
+	extensionMD5      = ".md5"
+	extensionSHA1     = ".sha1"
+	extensionSHA256   = ".sha256"
+	extensionSHA512   = ".sha512"
+)
+
+var (
+	errInvalidParameters = errors.New("request parameters are invalid")
+	errChecksumMismatch  = errors.New("checksum does not match")
+
+	illegalCharacters = regexp.MustCompile(`[\\/:"<>|?\*]`)
+)
+
+func apiError(ctx *context.APIContext, status int, obj interface{}) {
+	message := fmt.Sprint(obj)
+	if err, ok := obj.(error); ok {
+		message = err.Error()
+	}
+	if status == http.StatusInternalServerError {
+		log.Error("maven registry: %s", message)
+	}
+	ctx.PlainText(status, []byte(message))
+}
+
+type parameters struct {
+	GroupID    string
+	ArtifactID string
+	Version    string
+	Filename   string
+}
+
+func (p *parameters) packageName() string {
+	return p.GroupID + ":" + p.ArtifactID
+}
+
+func (p *parameters) isMetadata() bool {
+	return strings.TrimSuffix(p.Filename, filepath.Ext(p.Filename)) == mavenMetadataFile || p.Filename == mavenMetadataFile
+}
+
+// extractPathParameters splits the path into group id, artifact id, version and filename
+// e.g. /com/example/lib/1.0/lib-1.0.jar or /com/example/lib/maven-metadata.xml
+func extractPathParameters(ctx *context.APIContext) (*parameters, error) {
+	parts := strings.Split(strings.Trim(ctx.Params("*"), "/"), "/")
+
+	p := &parameters{
+		Filename: parts[len(parts)-1],
+	}
+
+	if p.isMetadata() {
+		if len(parts) < 3 {
+			return nil, errInvalidParameters
+		}
+		p.ArtifactID = parts[len(parts)-2]
+		p.GroupID = strings.Join(parts[:len(parts)-2], ".")
+	} else {
+		if len(parts) < 4 {
+			return nil, errInvalidParameters
+		}
+		p.Version = parts[len(parts)-2]
+		p.ArtifactID = parts[len(parts)-3]
+		p.GroupID = strings.Join(parts[:len(parts)-3], ".")
+	}
+
+	if illegalCharacters.MatchString(p.GroupID) || illegalCharacters.MatchString(p.ArtifactID) || illegalCharacters.MatchString(p.Filename) {
+		return nil, errInvalidParameters
+	}
+
+	return p, nil
+}
+
+// splitChecksumExtension returns the filename without a checksum extension and the extension
+func splitChecksumExtension(filename string) (string, string) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	switch ext {
+	case extensionMD5, extensionSHA1, extensionSHA256, extensionSHA512:
+		return strings.TrimSuffix(filename, filepath.Ext(filename)), ext
+	}
+	return filename, ""
+}
+
+func checksumFromBlob(pb *packages_model.PackageBlob, ext string) string {
+	switch ext {
+	case extensionMD5:
+		return pb.HashMD5
+	case extensionSHA1:
+		return pb.HashSHA1
+	case extensionSHA256:
+		return pb.HashSHA256
+	default:
+		return pb.HashSHA512
+	}
+}
+
+func newChecksumHash(ext string) hash.Hash {
+	switch ext {
+	case extensionMD5:
+		return md5.New()
+	case extensionSHA1:
+		return sha1.New()
+	case extensionSHA256:
+		return sha256.New()
+	default:
+		return sha512.New()
+	}
+}
+
+// DownloadPackageFile serves the content of a package file or the generated maven-metadata.xml
+func DownloadPackageFile(ctx *context.APIContext) {
+	params, err := extractPathParameters(ctx)
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if params.isMetadata() {
+		serveMavenMetadata(ctx, params)
+		return
+	}
+
+	filename, ext := splitChecksumExtension(params.Filename)
+
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeMaven, params.packageName(), params.Version)
+	if err != nil {
+		if packages_model.IsErrPackageVersionNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if ext != "" {
+		pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, filename)
+		if err != nil {
+			if err == packages_model.ErrPackageFileNotExist {
+				apiError(ctx, http.StatusNotFound, err)
+				return
+			}
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		ctx.PlainText(http.StatusOK, []byte(checksumFromBlob(pb, ext)))
+		return
+	}
+
+	s, pf, err := packages_service.GetFileStreamByPackageVersion(ctx, pv, filename)
+	if err != nil {
+		if err == packages_model.ErrPackageFileNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer s.Close()
+
+	ctx.ServeStream(s, pf.Name)
+}
+
+func serveMavenMetadata(ctx *context.APIContext, params *parameters) {
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeMaven, params.packageName())
+	if err != nil {
+		if packages_model.IsErrPackageNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pvs) == 0 {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist{Name: params.packageName()})
+		return
+	}
+
+	xmlMetadata, err := xml.Marshal(createMetadataResponse(params, pvs))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	xmlMetadataWithHeader := append([]byte(xml.Header), xmlMetadata...)
+
+	if _, ext := splitChecksumExtension(params.Filename); ext != "" {
+		h := newChecksumHash(ext)
+		_, _ = h.Write(xmlMetadataWithHeader)
+		ctx.PlainText(http.StatusOK, []byte(hex.EncodeToString(h.Sum(nil))))
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "text/xml")
+	ctx.PlainText(http.StatusOK, xmlMetadataWithHeader)
+}
+
+// UploadPackageFile adds a file to the package. If the package does not exist, it gets created.
+func UploadPackageFile(ctx *context.APIContext) {
+	params, err := extractPathParameters(ctx)
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	log.Trace("Parameters: %+v", params)
+
+	// maven-metadata.xml is generated on request, uploaded versions are ignored
+	if params.isMetadata() {
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	filename, ext := splitChecksumExtension(params.Filename)
+	if ext != "" {
+		verifyChecksum(ctx, params, filename, ext)
+		return
+	}
+
+	buf, err := packages_module.NewHashedBuffer(ctx.Req.Body)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	pvci := &packages_service.PackageCreationInfo{
+		PackageInfo: packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeMaven,
+			Name:        params.packageName(),
+			Version:     params.Version,
+		},
+		Creator: ctx.User,
+		Metadata: &maven_module.Metadata{
+			GroupID:    params.GroupID,
+			ArtifactID: params.ArtifactID,
+		},
+	}
+
+	baseName := strings.TrimSuffix(params.Filename, filepath.Ext(params.Filename))
+	isPom := strings.ToLower(filepath.Ext(params.Filename)) == ".pom"
+
+	pv, _, err := packages_service.CreatePackageOrAddFileToExisting(
+		pvci,
+		&packages_service.PackageFileInfo{
+			Filename: params.Filename,
+			IsLead:   !isPom && baseName == params.ArtifactID+"-"+params.Version,
+		},
+		buf,
+	)
+	if err != nil {
+		if err == packages_model.ErrDuplicatePackageFile {
+			apiError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if isPom {
+		if _, err := buf.Seek(0, io.SeekStart); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		metadata, err := maven_module.ParsePackageMetaData(buf)
+		if err != nil {
+			log.Error("Error parsing package metadata: %v", err)
+		} else if metadata != nil {
+			metadataJSON, err := json.Marshal(metadata)
+			if err != nil {
+				apiError(ctx, http.StatusInternalServerError, err)
+				return
+			}
+			pv.MetadataJSON = string(metadataJSON)
+			if err := packages_model.UpdateVersion(ctx, pv); err != nil {
+				apiError(ctx, http.StatusInternalServerError, err)
+				return
+			}
+		}
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// verifyChecksum compares an uploaded checksum with the hash of the stored file
+func verifyChecksum(ctx *context.APIContext, params *parameters, filename, ext string) {
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeMaven, params.packageName(), params.Version)
+	if err != nil {
+		if packages_model.IsErrPackageVersionNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, filename)
+	if err != nil {
+		if err == packages_model.ErrPackageFileNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	checksum, err := io.ReadAll(io.LimitReader(ctx.Req.Body, 1024))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(string(checksum)), checksumFromBlob(pb, ext)) {
+		apiError(ctx, http.StatusBadRequest, errChecksumMismatch)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package npm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/json"
+	npm_module "code.gitea.io/gitea/modules/packages/npm"
+)
+
+func createPackageMetadataResponse(ctx context.Context, registryURL, packageName string, pvs []*packages_model.PackageVersion) (*npm_module.PackageMetadata, error) {
+	resp := &npm_module.PackageMetadata{
+		ID:       packageName,
+		Name:     packageName,
+		DistTags: map[string]string{},
+		Versions: make(map[string]*npm_module.PackageMetadataVersion, len(pvs)),
+	}
+
+	// versions are ordered by creation, the newest version is the latest one
+	for i, pv := range pvs {
+		var metadata *npm_module.Metadata
+		if err := json.Unmarshal([]byte(pv.MetadataJSON), &metadata); err != nil {
+			return nil, err
+		}
+
+		pfs, err := packages_model.GetFilesByVersionID(ctx, pv.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(pfs) == 0 {
+			continue
+		}
+		pb, err := packages_model.GetBlobByID(ctx, pfs[0].BlobID)
+		if err != nil {
+			return nil, err
+		}
+		hashSHA512, err := hex.DecodeString(pb.HashSHA512)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			resp.Description = metadata.Description
+			resp.Readme = metadata.Readme
+			resp.DistTags["latest"] = pv.Version
+		}
+
+		resp.Versions[pv.Version] = &npm_module.PackageMetadataVersion{
+			ID:                   fmt.Sprintf("%s@%s", packageName, pv.Version),
+			Name:                 packageName,
+			Version:              pv.Version,
+			Description:          metadata.Description,
+			Author:               npm_module.User{Name: metadata.Author},
+			Homepage:             metadata.ProjectURL,
+			License:              metadata.License,
+			Keywords:             metadata.Keywords,
+			Dependencies:         metadata.Dependencies,
+			DevDependencies:      metadata.DevelopmentDependencies,
+			PeerDependencies:     metadata.PeerDependencies,
+			OptionalDependencies: metadata.OptionalDependencies,
+			Readme:               metadata.Readme,
+			Dist: npm_module.PackageDistribution{
+				Shasum:    pb.HashSHA1,
+				Integrity: "sha512-" + base64.StdEncoding.EncodeToString(hashSHA512),
+				Tarball:   fmt.Sprintf("%s/%s/-/%s/%s", registryURL, url.PathEscape(packageName), url.PathEscape(pv.Version), url.PathEscape(pfs[0].Name)),
+			},
+		}
+	}
+
+	return resp, nil
+}
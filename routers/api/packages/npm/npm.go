@@ -0,0 +1,190 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package npm
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	npm_module "code.gitea.io/gitea/modules/packages/npm"
+	"code.gitea.io/gitea/modules/setting"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+func apiError(ctx *context.APIContext, status int, obj interface{}) {
+	message := fmt.Sprint(obj)
+	if err, ok := obj.(error); ok {
+		message = err.Error()
+	}
+	if status == http.StatusInternalServerError {
+		log.Error("npm registry: %s", message)
+	}
+	ctx.JSON(status, map[string]string{
+		"error": message,
+	})
+}
+
+// packageNameFromParams gets the package name from the url parameters.
+// Scoped packages are requested as /@scope/name or /@scope%2fname.
+func packageNameFromParams(ctx *context.APIContext) string {
+	id := ctx.Params("id")
+	if scope := ctx.Params("scope"); scope != "" {
+		return "@" + scope + "/" + id
+	}
+	return id
+}
+
+// PackageMetadata returns the metadata of all versions of a package
+func PackageMetadata(ctx *context.APIContext) {
+	packageName := packageNameFromParams(ctx)
+
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeNpm, packageName)
+	if err != nil {
+		if packages_model.IsErrPackageNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pvs) == 0 {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist{Name: packageName})
+		return
+	}
+
+	registryURL := setting.AppURL + "api/packages/" + url.PathEscape(ctx.Package.Owner.Name) + "/npm"
+
+	resp, err := createPackageMetadataResponse(ctx, registryURL, packageName, pvs)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// DownloadPackageFile serves the content of a package
+func DownloadPackageFile(ctx *context.APIContext) {
+	s, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(
+		&packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeNpm,
+			Name:        packageNameFromParams(ctx),
+			Version:     ctx.Params("version"),
+		},
+		ctx.Params("filename"),
+	)
+	if err != nil {
+		if packages_model.IsErrPackageVersionNotExist(err) || err == packages_model.ErrPackageFileNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer s.Close()
+
+	ctx.ServeStream(s, pf.Name)
+}
+
+// UploadPackage creates a new package
+func UploadPackage(ctx *context.APIContext) {
+	npmPackage, err := npm_module.ParsePackage(ctx.Req.Body)
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if !strings.EqualFold(npmPackage.Name, packageNameFromParams(ctx)) {
+		apiError(ctx, http.StatusBadRequest, npm_module.ErrInvalidPackageName)
+		return
+	}
+
+	buf, err := packages_module.NewHashedBuffer(bytes.NewReader(npmPackage.Data))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	_, _, err = packages_service.CreatePackageAndAddFile(
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeNpm,
+				Name:        npmPackage.Name,
+				Version:     npmPackage.Version,
+			},
+			Creator:  ctx.User,
+			Metadata: npmPackage.Metadata,
+		},
+		&packages_service.PackageFileInfo{
+			Filename: npmPackage.Filename,
+			IsLead:   true,
+		},
+		buf,
+	)
+	if err != nil {
+		if err == packages_model.ErrDuplicatePackageVersion {
+			apiError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// DeletePackageVersion deletes the package version
+func DeletePackageVersion(ctx *context.APIContext) {
+	err := packages_service.RemovePackageVersionByNameAndVersion(
+		ctx.User,
+		&packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeNpm,
+			Name:        packageNameFromParams(ctx),
+			Version:     ctx.Params("version"),
+		},
+	)
+	if err != nil {
+		if packages_model.IsErrPackageVersionNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+// DeletePackage deletes all versions of the package
+func DeletePackage(ctx *context.APIContext) {
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeNpm, packageNameFromParams(ctx))
+	if err != nil {
+		if packages_model.IsErrPackageNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, pv := range pvs {
+		if err := packages_service.RemovePackageVersion(ctx.User, pv); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	ctx.Status(http.StatusOK)
+}
@@ -0,0 +1,274 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/scim"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/auth"
+)
+
+// Routes provides the SCIM 2.0 endpoints which are mounted at /api/scim/v2
+func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
+	r := web.NewRoute()
+
+	r.Use(sessioner)
+	r.Use(context.APIContexter())
+	// only bearer tokens are accepted, SCIM clients neither use sessions nor basic auth
+	r.Use(context.APIAuth(auth.NewGroup(&auth.OAuth2{})))
+
+	r.Group("", func() {
+		r.Get("/ServiceProviderConfig", ServiceProviderConfig)
+		r.Get("/ResourceTypes", ResourceTypes)
+		r.Group("/Users", func() {
+			r.Get("", ListUsers)
+			r.Post("", CreateUser)
+			r.Group("/{id}", func() {
+				r.Get("", GetUser)
+				r.Put("", ReplaceUser)
+				r.Patch("", PatchUser)
+				r.Delete("", DeleteUser)
+			}, userAssignment)
+		})
+		r.Group("/Groups", func() {
+			r.Get("", ListGroups)
+			r.Post("", CreateGroup)
+			r.Group("/{id}", func() {
+				r.Get("", GetGroup)
+				r.Put("", ReplaceGroup)
+				r.Patch("", PatchGroup)
+				r.Delete("", DeleteGroup)
+			}, groupAssignment)
+		})
+	}, reqSiteAdminToken)
+
+	return r
+}
+
+// reqSiteAdminToken requires an access token of a site administrator
+func reqSiteAdminToken(ctx *context.APIContext) {
+	if !ctx.IsSigned {
+		ctx.Resp.Header().Set("WWW-Authenticate", `Bearer realm="Gitea SCIM"`)
+		apiError(ctx, http.StatusUnauthorized, "", "a bearer token is required")
+		return
+	}
+	if !ctx.User.IsAdmin {
+		apiError(ctx, http.StatusForbidden, "", "the token must belong to a site administrator")
+	}
+}
+
+func writeResponse(ctx *context.APIContext, status int, obj interface{}) {
+	ctx.Resp.Header().Set("Content-Type", scim.ContentType)
+	ctx.Resp.WriteHeader(status)
+	if err := json.NewEncoder(ctx.Resp).Encode(obj); err != nil {
+		log.Error("Unable to write SCIM response: %v", err)
+	}
+}
+
+func apiError(ctx *context.APIContext, status int, scimType string, obj interface{}) {
+	message := fmt.Sprint(obj)
+	if err, ok := obj.(error); ok {
+		message = err.Error()
+	}
+	if status == http.StatusInternalServerError {
+		log.Error("scim api: %s", message)
+	}
+	writeResponse(ctx, status, &scim.Error{
+		Schemas:  []string{scim.SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   message,
+	})
+}
+
+// errBadRequest is a mistake of the client which is answered with 400 Bad Request
+type errBadRequest struct {
+	scimType string
+	reason   string
+}
+
+func (err errBadRequest) Error() string {
+	return err.reason
+}
+
+func invalidValue(reason string) error {
+	return errBadRequest{scimType: scim.ErrorTypeInvalidValue, reason: reason}
+}
+
+// writeError answers with the status matching the error
+func writeError(ctx *context.APIContext, err error) {
+	var badRequest errBadRequest
+	switch {
+	case errors.As(err, &badRequest):
+		apiError(ctx, http.StatusBadRequest, badRequest.scimType, badRequest.reason)
+	case scim.IsErrInvalidFilter(err):
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidFilter, err)
+	case models.IsErrUserAlreadyExist(err),
+		user_model.IsErrEmailAlreadyUsed(err),
+		models.IsErrTeamAlreadyExist(err):
+		apiError(ctx, http.StatusConflict, scim.ErrorTypeUniqueness, err)
+	case models.IsErrNameReserved(err),
+		models.IsErrNamePatternNotAllowed(err),
+		models.IsErrNameCharsNotAllowed(err),
+		user_model.IsErrEmailInvalid(err):
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err)
+	case models.IsErrLastOrgOwner(err):
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeMutability, err)
+	default:
+		apiError(ctx, http.StatusInternalServerError, "", err)
+	}
+}
+
+// applyPatchOperation calls apply for every attribute changed by a PATCH operation with the lower cased op.
+// Operations without a path carry an object of the changed attributes as value.
+func applyPatchOperation(op scim.PatchOperation, apply func(op string, path *scim.Path, value json.RawMessage) error) error {
+	kind := strings.ToLower(op.Op)
+	switch kind {
+	case "add", "replace", "remove":
+	default:
+		return errBadRequest{scimType: scim.ErrorTypeInvalidSyntax, reason: "unknown operation " + op.Op}
+	}
+
+	if op.Path != "" {
+		path, err := scim.ParsePath(op.Path)
+		if err != nil {
+			return errBadRequest{scimType: scim.ErrorTypeInvalidPath, reason: err.Error()}
+		}
+		return apply(kind, path, op.Value)
+	}
+
+	if kind == "remove" {
+		return errBadRequest{scimType: scim.ErrorTypeNoTarget, reason: "remove requires a path"}
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(op.Value, &values); err != nil {
+		return errBadRequest{scimType: scim.ErrorTypeInvalidSyntax, reason: "the value of an operation without path has to be an object"}
+	}
+	for attr, value := range values {
+		path, err := scim.ParsePath(attr)
+		if err != nil {
+			return errBadRequest{scimType: scim.ErrorTypeInvalidPath, reason: err.Error()}
+		}
+		if err := apply(kind, path, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeBody reads the JSON body of the request, an error response is written if that fails
+func decodeBody(ctx *context.APIContext, v interface{}) bool {
+	if err := json.NewDecoder(ctx.Req.Body).Decode(v); err != nil {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, err)
+		return false
+	}
+	return true
+}
+
+func location(resourceType string, id int64) string {
+	return fmt.Sprintf("%sapi/scim/v2/%s/%d", setting.AppURL, resourceType, id)
+}
+
+// listRange returns the 1-based startIndex and the count requested by a list request
+func listRange(ctx *context.APIContext) (startIndex, count int) {
+	startIndex = ctx.FormInt("startIndex")
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count = setting.API.MaxResponseItems
+	if ctx.FormString("count") != "" {
+		count = ctx.FormInt("count")
+	}
+	if count < 0 {
+		count = 0
+	} else if count > setting.API.MaxResponseItems {
+		count = setting.API.MaxResponseItems
+	}
+	return startIndex, count
+}
+
+func writeList(ctx *context.APIContext, startIndex, itemsPerPage int, total int64, resources interface{}) {
+	writeResponse(ctx, http.StatusOK, &scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: itemsPerPage,
+		Resources:    resources,
+	})
+}
+
+// isExcluded checks if an attribute is listed in the excludedAttributes parameter
+func isExcluded(ctx *context.APIContext, attribute string) bool {
+	for _, excluded := range strings.Split(ctx.FormString("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(excluded), attribute) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceProviderConfig describes the supported features
+func ServiceProviderConfig(ctx *context.APIContext) {
+	writeResponse(ctx, http.StatusOK, &scim.ServiceProviderConfig{
+		Schemas:          []string{scim.SchemaServiceProviderConfig},
+		DocumentationURI: "https://docs.gitea.io/en-us/scim/",
+		Patch:            scim.Supported{Supported: true},
+		Filter:           scim.FilterSupport{Supported: true, MaxResults: setting.API.MaxResponseItems},
+		AuthenticationSchemes: []scim.AuthenticationScheme{
+			{
+				Type:        "oauthbearertoken",
+				Name:        "OAuth Bearer Token",
+				Description: "Access token of a site administrator",
+			},
+		},
+		Meta: &scim.Meta{
+			ResourceType: "ServiceProviderConfig",
+			Location:     setting.AppURL + "api/scim/v2/ServiceProviderConfig",
+		},
+	})
+}
+
+// ResourceTypes lists the supported resource types
+func ResourceTypes(ctx *context.APIContext) {
+	resourceTypes := []*scim.ResourceType{
+		{
+			Schemas:     []string{scim.SchemaResourceType},
+			ID:          "User",
+			Name:        "User",
+			Endpoint:    "/Users",
+			Description: "User Account",
+			Schema:      scim.SchemaUser,
+			Meta: &scim.Meta{
+				ResourceType: "ResourceType",
+				Location:     setting.AppURL + "api/scim/v2/ResourceTypes/User",
+			},
+		},
+		{
+			Schemas:     []string{scim.SchemaResourceType},
+			ID:          "Group",
+			Name:        "Group",
+			Endpoint:    "/Groups",
+			Description: "Organization team",
+			Schema:      scim.SchemaGroup,
+			Meta: &scim.Meta{
+				ResourceType: "ResourceType",
+				Location:     setting.AppURL + "api/scim/v2/ResourceTypes/Group",
+			},
+		},
+	}
+	writeList(ctx, 1, len(resourceTypes), int64(len(resourceTypes)), resourceTypes)
+}
@@ -0,0 +1,225 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/scim"
+
+	"xorm.io/builder"
+)
+
+// filterCond converts a filter into a database condition, attrCond converts the comparisons
+func filterCond(f scim.Filter, attrCond func(e *scim.AttributeExpression) (builder.Cond, error)) (builder.Cond, error) {
+	switch e := f.(type) {
+	case *scim.LogicalExpression:
+		left, err := filterCond(e.Left, attrCond)
+		if err != nil {
+			return nil, err
+		}
+		right, err := filterCond(e.Right, attrCond)
+		if err != nil {
+			return nil, err
+		}
+		if e.Operator == "and" {
+			return builder.And(left, right), nil
+		}
+		return builder.Or(left, right), nil
+	case *scim.NotExpression:
+		cond, err := filterCond(e.Filter, attrCond)
+		if err != nil {
+			return nil, err
+		}
+		return builder.Not{cond}, nil
+	case *scim.AttributeExpression:
+		return attrCond(e)
+	}
+	return nil, fmt.Errorf("unknown filter expression %T", f)
+}
+
+// parseFilter converts the filter query parameter, an empty filter matches everything
+func parseFilter(filter string, attrCond func(e *scim.AttributeExpression) (builder.Cond, error)) (builder.Cond, error) {
+	if strings.TrimSpace(filter) == "" {
+		return builder.NewCond(), nil
+	}
+	f, err := scim.ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return filterCond(f, attrCond)
+}
+
+func unsupportedFilter(e *scim.AttributeExpression) error {
+	if e.Value == nil {
+		return scim.ErrInvalidFilter{Filter: e.Path + " " + e.Operator, Reason: "unsupported attribute or operator"}
+	}
+	return scim.ErrInvalidFilter{Filter: fmt.Sprintf("%s %s %v", e.Path, e.Operator, e.Value), Reason: "unsupported attribute or operator"}
+}
+
+// idCond compares the numeric id of a resource, SCIM ids are strings
+func idCond(column string, e *scim.AttributeExpression) (builder.Cond, error) {
+	if e.Operator == "pr" {
+		return builder.NotNull{column}, nil
+	}
+	value, ok := e.Value.(string)
+	if !ok || (e.Operator != "eq" && e.Operator != "ne") {
+		return nil, unsupportedFilter(e)
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		// no resource has such an id
+		id = -1
+	}
+	if e.Operator == "ne" {
+		return builder.Neq{column: id}, nil
+	}
+	return builder.Eq{column: id}, nil
+}
+
+// stringCond compares a case insensitive string attribute, column has to contain lower case values
+func stringCond(column string, e *scim.AttributeExpression) (builder.Cond, error) {
+	if e.Operator == "pr" {
+		return builder.Neq{column: ""}, nil
+	}
+	value, ok := e.Value.(string)
+	if !ok {
+		return nil, unsupportedFilter(e)
+	}
+	value = strings.ToLower(value)
+	switch e.Operator {
+	case "eq":
+		return builder.Eq{column: value}, nil
+	case "ne":
+		return builder.Neq{column: value}, nil
+	case "co":
+		return builder.Like{column, value}, nil
+	case "sw":
+		return builder.Like{column, value + "%"}, nil
+	case "ew":
+		return builder.Like{column, "%" + value}, nil
+	case "gt":
+		return builder.Gt{column: value}, nil
+	case "ge":
+		return builder.Gte{column: value}, nil
+	case "lt":
+		return builder.Lt{column: value}, nil
+	case "le":
+		return builder.Lte{column: value}, nil
+	}
+	return nil, unsupportedFilter(e)
+}
+
+// timeCond compares a timestamp with a RFC 3339 date
+func timeCond(column string, e *scim.AttributeExpression) (builder.Cond, error) {
+	if e.Operator == "pr" {
+		return builder.NotNull{column}, nil
+	}
+	value, ok := e.Value.(string)
+	if !ok {
+		return nil, unsupportedFilter(e)
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, scim.ErrInvalidFilter{Filter: value, Reason: "invalid date"}
+	}
+	switch e.Operator {
+	case "eq":
+		return builder.Eq{column: t.Unix()}, nil
+	case "ne":
+		return builder.Neq{column: t.Unix()}, nil
+	case "gt":
+		return builder.Gt{column: t.Unix()}, nil
+	case "ge":
+		return builder.Gte{column: t.Unix()}, nil
+	case "lt":
+		return builder.Lt{column: t.Unix()}, nil
+	case "le":
+		return builder.Lte{column: t.Unix()}, nil
+	}
+	return nil, unsupportedFilter(e)
+}
+
+// activeCond matches users who are activated and allowed to sign in
+func activeCond(e *scim.AttributeExpression) (builder.Cond, error) {
+	if e.Operator == "pr" {
+		return builder.NotNull{"is_active"}, nil
+	}
+	value, ok := e.Value.(bool)
+	if !ok || (e.Operator != "eq" && e.Operator != "ne") {
+		return nil, unsupportedFilter(e)
+	}
+	if e.Operator == "ne" {
+		value = !value
+	}
+	if value {
+		return builder.Eq{"is_active": true, "prohibit_login": false}, nil
+	}
+	return builder.Or(builder.Eq{"is_active": false}, builder.Eq{"prohibit_login": true}), nil
+}
+
+func userAttributeCond(e *scim.AttributeExpression) (builder.Cond, error) {
+	switch e.Path {
+	case "id":
+		return idCond("id", e)
+	case "username":
+		return stringCond("lower_name", e)
+	case "displayname", "name.formatted":
+		return stringCond("LOWER(full_name)", e)
+	case "emails", "emails.value":
+		return stringCond("email", e)
+	case "active":
+		return activeCond(e)
+	case "meta.created":
+		return timeCond("created_unix", e)
+	case "meta.lastmodified":
+		return timeCond("updated_unix", e)
+	}
+	return nil, unsupportedFilter(e)
+}
+
+func groupAttributeCond(e *scim.AttributeExpression) (builder.Cond, error) {
+	switch e.Path {
+	case "id":
+		return idCond("id", e)
+	case "displayname":
+		// the display name is "organization/team", only the equality checks consider the organization
+		value, ok := e.Value.(string)
+		if ok && (e.Operator == "eq" || e.Operator == "ne") {
+			var cond builder.Cond
+			if orgName, teamName, ok := splitGroupName(value); ok {
+				cond = builder.And(
+					builder.Eq{"lower_name": strings.ToLower(teamName)},
+					builder.In("org_id", builder.Select("id").From("`user`").Where(builder.Eq{
+						"lower_name": strings.ToLower(orgName),
+						"type":       models.UserTypeOrganization,
+					})),
+				)
+			} else {
+				cond = builder.Eq{"lower_name": strings.ToLower(value)}
+			}
+			if e.Operator == "ne" {
+				return builder.Not{cond}, nil
+			}
+			return cond, nil
+		}
+		return stringCond("lower_name", e)
+	case "members", "members.value":
+		value, ok := e.Value.(string)
+		if !ok || e.Operator != "eq" {
+			return nil, unsupportedFilter(e)
+		}
+		uid, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			uid = -1
+		}
+		return builder.In("id", builder.Select("team_id").From("team_user").Where(builder.Eq{"uid": uid})), nil
+	}
+	return nil, unsupportedFilter(e)
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/scim"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserFilter(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	findUsers := func(filter string) []int64 {
+		cond, err := parseFilter(filter, userAttributeCond)
+		assert.NoError(t, err)
+		users, total, err := models.FindIndividualUsersByCond(cond, 0, 50)
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(users), total)
+		ids := make([]int64, 0, len(users))
+		for _, u := range users {
+			ids = append(ids, u.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []int64{2}, findUsers(`userName eq "USER2"`))
+	assert.Equal(t, []int64{2}, findUsers(`emails[value eq "user2@example.com"]`))
+	assert.Equal(t, []int64{2}, findUsers(`id eq "2"`))
+	assert.Empty(t, findUsers(`id eq "abc"`))
+	assert.Equal(t, []int64{1, 2}, findUsers(`userName eq "user1" or userName eq "user2"`))
+	assert.NotContains(t, findUsers(`not (userName eq "user2")`), int64(2))
+	// organizations are no users
+	assert.Empty(t, findUsers(`userName eq "user3"`))
+
+	for _, id := range findUsers(`active eq false`) {
+		u := unittest.AssertExistsAndLoadBean(t, &models.User{ID: id}).(*models.User)
+		assert.True(t, !u.IsActive || u.ProhibitLogin)
+	}
+
+	_, err := parseFilter(`title eq "boss"`, userAttributeCond)
+	assert.True(t, scim.IsErrInvalidFilter(err))
+	_, err = parseFilter(`active co "true"`, userAttributeCond)
+	assert.True(t, scim.IsErrInvalidFilter(err))
+}
+
+func TestGroupFilter(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	findTeams := func(filter string) []int64 {
+		cond, err := parseFilter(filter, groupAttributeCond)
+		assert.NoError(t, err)
+		teams, _, err := models.FindTeamsByCond(cond, 0, 50)
+		assert.NoError(t, err)
+		ids := make([]int64, 0, len(teams))
+		for _, t := range teams {
+			ids = append(ids, t.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []int64{1}, findTeams(`displayName eq "user3/Owners"`))
+	assert.Equal(t, []int64{2}, findTeams(`displayName eq "team1"`))
+	assert.Equal(t, []int64{1, 2, 8}, findTeams(`members[value eq "2"]`))
+	assert.Equal(t, []int64{2, 8}, findTeams(`members eq "2" and not (displayName eq "user3/owners")`))
+
+	_, err := parseFilter(`members co "2"`, groupAttributeCond)
+	assert.True(t, scim.IsErrInvalidFilter(err))
+}
+
+func TestFilterMemberIDs(t *testing.T) {
+	path, err := scim.ParsePath(`members[value eq "2" or value eq "5"]`)
+	assert.NoError(t, err)
+	ids, err := filterMemberIDs(path.Filter)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2, 5}, ids)
+
+	path, err = scim.ParsePath(`members[display eq "user2"]`)
+	assert.NoError(t, err)
+	_, err = filterMemberIDs(path.Filter)
+	assert.Error(t, err)
+}
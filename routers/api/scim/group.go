@@ -0,0 +1,442 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
+	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/audit"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/scim"
+	"code.gitea.io/gitea/modules/util"
+)
+
+func groupFromContext(ctx *context.APIContext) *models.Team {
+	return ctx.Data["SCIMGroup"].(*models.Team)
+}
+
+// groupAssignment loads the team of the id path parameter
+func groupAssignment(ctx *context.APIContext) {
+	t, err := models.GetTeamByID(ctx.ParamsInt64("id"))
+	if err != nil {
+		if models.IsErrTeamNotExist(err) {
+			apiError(ctx, http.StatusNotFound, "", "group not found")
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	ctx.Data["SCIMGroup"] = t
+}
+
+// splitGroupName splits the display name of a group into the organization and the team name
+func splitGroupName(displayName string) (orgName, teamName string, ok bool) {
+	idx := strings.IndexByte(displayName, '/')
+	if idx <= 0 || idx == len(displayName)-1 {
+		return "", "", false
+	}
+	return displayName[:idx], displayName[idx+1:], true
+}
+
+// toSCIMGroup converts a team, its members have to be loaded unless they are excluded
+func toSCIMGroup(t *models.Team, orgName string, withMembers bool) *scim.Group {
+	result := &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          strconv.FormatInt(t.ID, 10),
+		DisplayName: orgName + "/" + t.Name,
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Location:     location("Groups", t.ID),
+		},
+	}
+	if withMembers {
+		result.Members = make([]scim.MultiValue, 0, len(t.Members))
+		for _, u := range t.Members {
+			result.Members = append(result.Members, scim.MultiValue{
+				Value:   strconv.FormatInt(u.ID, 10),
+				Display: u.Name,
+				Ref:     location("Users", u.ID),
+			})
+		}
+	}
+	return result
+}
+
+// writeGroup answers with the current state of the team
+func writeGroup(ctx *context.APIContext, status int, t *models.Team) {
+	org, err := models.GetUserByID(t.OrgID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	withMembers := !isExcluded(ctx, "members")
+	if withMembers {
+		if err := t.GetMembers(&models.SearchMembersOptions{}); err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return
+		}
+	}
+	writeResponse(ctx, status, toSCIMGroup(t, org.Name, withMembers))
+}
+
+func parseMemberIDs(members []scim.MultiValue) ([]int64, error) {
+	ids := make([]int64, 0, len(members))
+	for _, m := range members {
+		id, err := strconv.ParseInt(m.Value, 10, 64)
+		if err != nil {
+			return nil, invalidValue("invalid member " + m.Value)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// filterMemberIDs returns the ids selected by a path filter like members[value eq "2"]
+func filterMemberIDs(f scim.Filter) ([]int64, error) {
+	switch e := f.(type) {
+	case *scim.AttributeExpression:
+		if value, ok := e.Value.(string); ok && e.Path == "members.value" && e.Operator == "eq" {
+			return parseMemberIDs([]scim.MultiValue{{Value: value}})
+		}
+	case *scim.LogicalExpression:
+		if e.Operator == "or" {
+			left, err := filterMemberIDs(e.Left)
+			if err != nil {
+				return nil, err
+			}
+			right, err := filterMemberIDs(e.Right)
+			if err != nil {
+				return nil, err
+			}
+			return append(left, right...), nil
+		}
+	}
+	return nil, errBadRequest{scimType: scim.ErrorTypeInvalidFilter, reason: "members can only be selected by their value"}
+}
+
+func addGroupMembers(ctx *context.APIContext, t *models.Team, ids []int64) error {
+	for _, id := range ids {
+		u, err := models.GetUserByID(id)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				return invalidValue("user " + strconv.FormatInt(id, 10) + " does not exist")
+			}
+			return err
+		}
+		if u.IsOrganization() {
+			return invalidValue(u.Name + " is an organization")
+		}
+		if t.IsMember(id) {
+			continue
+		}
+		if err := models.AddTeamMember(t, id); err != nil {
+			return err
+		}
+		audit.Record(admin.AuditTeamMemberAdd, ctx.User, ctx.RemoteAddr(), t, nil, audit.TeamMemberSnapshot(t, id))
+	}
+	return nil
+}
+
+func removeGroupMembers(ctx *context.APIContext, t *models.Team, ids []int64) error {
+	for _, id := range ids {
+		if !t.IsMember(id) {
+			continue
+		}
+		if err := models.RemoveTeamMember(t, id); err != nil {
+			return err
+		}
+		audit.Record(admin.AuditTeamMemberRemove, ctx.User, ctx.RemoteAddr(), t, audit.TeamMemberSnapshot(t, id), nil)
+	}
+	return nil
+}
+
+// replaceGroupMembers makes the given users the only members of the team
+func replaceGroupMembers(ctx *context.APIContext, t *models.Team, ids []int64) error {
+	members, err := models.GetTeamMembers(t.ID)
+	if err != nil {
+		return err
+	}
+	keep := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		keep[id] = true
+	}
+	remove := make([]int64, 0, len(members))
+	for _, u := range members {
+		if !keep[u.ID] {
+			remove = append(remove, u.ID)
+		}
+	}
+	// add first, the owners team must never become empty
+	if err := addGroupMembers(ctx, t, ids); err != nil {
+		return err
+	}
+	return removeGroupMembers(ctx, t, remove)
+}
+
+// renameGroup renames a team, groups cannot be moved to another organization
+func renameGroup(ctx *context.APIContext, t *models.Team, displayName string) error {
+	teamName := displayName
+	if orgName, name, ok := splitGroupName(displayName); ok {
+		org, err := models.GetUserByID(t.OrgID)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(org.Name, orgName) {
+			return errBadRequest{scimType: scim.ErrorTypeMutability, reason: "groups cannot be moved to another organization"}
+		}
+		teamName = name
+	}
+	if teamName == t.Name {
+		return nil
+	}
+	if t.IsOwnerTeam() {
+		return errBadRequest{scimType: scim.ErrorTypeMutability, reason: "the owners team cannot be renamed"}
+	}
+	if err := models.IsUsableTeamName(teamName); err != nil {
+		return err
+	}
+
+	if err := t.GetUnits(); err != nil {
+		return err
+	}
+	before := audit.TeamSnapshot(t)
+	t.Name = teamName
+	if err := models.UpdateTeam(t, false, false); err != nil {
+		return err
+	}
+	audit.Record(admin.AuditTeamUpdate, ctx.User, ctx.RemoteAddr(), t, before, audit.TeamSnapshot(t))
+	return nil
+}
+
+// applyGroupAttribute executes a PATCH operation on an attribute of a team
+func applyGroupAttribute(ctx *context.APIContext, t *models.Team, op string, path *scim.Path, value json.RawMessage) error {
+	switch path.Attribute {
+	case "displayname":
+		if op == "remove" {
+			return invalidValue("displayName is required")
+		}
+		var displayName string
+		if err := json.Unmarshal(value, &displayName); err != nil {
+			return invalidValue("invalid displayName: " + err.Error())
+		}
+		return renameGroup(ctx, t, displayName)
+	case "members":
+		var members []scim.MultiValue
+		if len(value) > 0 && string(value) != "null" {
+			if err := json.Unmarshal(value, &members); err != nil {
+				return invalidValue("invalid members: " + err.Error())
+			}
+		}
+		ids, err := parseMemberIDs(members)
+		if err != nil {
+			return err
+		}
+		if path.Filter != nil {
+			if op != "remove" {
+				return errBadRequest{scimType: scim.ErrorTypeInvalidPath, reason: "members can only be removed by a filter"}
+			}
+			if ids, err = filterMemberIDs(path.Filter); err != nil {
+				return err
+			}
+			return removeGroupMembers(ctx, t, ids)
+		}
+		switch op {
+		case "add":
+			return addGroupMembers(ctx, t, ids)
+		case "remove":
+			if len(ids) == 0 {
+				// without value all members are removed
+				return replaceGroupMembers(ctx, t, nil)
+			}
+			return removeGroupMembers(ctx, t, ids)
+		default:
+			return replaceGroupMembers(ctx, t, ids)
+		}
+	}
+	// the other attributes like externalId are not stored by Gitea
+	return nil
+}
+
+// ListGroups lists the teams of all organizations matching the filter
+func ListGroups(ctx *context.APIContext) {
+	cond, err := parseFilter(ctx.FormString("filter"), groupAttributeCond)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	startIndex, count := listRange(ctx)
+	// count 0 only asks for the number of results
+	teams, total, err := models.FindTeamsByCond(cond, startIndex-1, util.Max(count, 1))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	if count == 0 {
+		teams = teams[:0]
+	}
+
+	orgIDs := make([]int64, 0, len(teams))
+	for _, t := range teams {
+		orgIDs = append(orgIDs, t.OrgID)
+	}
+	orgs, err := models.GetUsersByIDs(orgIDs)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	orgNames := make(map[int64]string, len(orgs))
+	for _, org := range orgs {
+		orgNames[org.ID] = org.Name
+	}
+
+	withMembers := !isExcluded(ctx, "members")
+	resources := make([]*scim.Group, 0, len(teams))
+	for _, t := range teams {
+		if withMembers {
+			if err := t.GetMembers(&models.SearchMembersOptions{}); err != nil {
+				apiError(ctx, http.StatusInternalServerError, "", err)
+				return
+			}
+		}
+		resources = append(resources, toSCIMGroup(t, orgNames[t.OrgID], withMembers))
+	}
+	writeList(ctx, startIndex, len(resources), total, resources)
+}
+
+// GetGroup returns a team
+func GetGroup(ctx *context.APIContext) {
+	writeGroup(ctx, http.StatusOK, groupFromContext(ctx))
+}
+
+// CreateGroup creates a team with read access, the display name has to be "organization/team"
+func CreateGroup(ctx *context.APIContext) {
+	var form scim.Group
+	if !decodeBody(ctx, &form) {
+		return
+	}
+	orgName, teamName, ok := splitGroupName(form.DisplayName)
+	if !ok {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "displayName has to be organization/team")
+		return
+	}
+	ids, err := parseMemberIDs(form.Members)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	org, err := models.GetOrgByName(orgName)
+	if err != nil {
+		if models.IsErrOrgNotExist(err) {
+			apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "organization "+orgName+" does not exist")
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	t := &models.Team{
+		OrgID:     org.ID,
+		Name:      teamName,
+		Authorize: models.AccessModeRead,
+	}
+	for _, tp := range unit_model.DefaultRepoUnits {
+		t.Units = append(t.Units, &models.TeamUnit{
+			OrgID: org.ID,
+			Type:  tp,
+		})
+	}
+	if err := models.NewTeam(t); err != nil {
+		writeError(ctx, err)
+		return
+	}
+	log.Trace("Team created through SCIM by %s: %s/%s", ctx.User.Name, org.Name, t.Name)
+	audit.Record(admin.AuditTeamCreate, ctx.User, ctx.RemoteAddr(), t, nil, audit.TeamSnapshot(t))
+
+	if err := addGroupMembers(ctx, t, ids); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Resp.Header().Set("Location", location("Groups", t.ID))
+	writeGroup(ctx, http.StatusCreated, t)
+}
+
+// ReplaceGroup renames a team and replaces its members
+func ReplaceGroup(ctx *context.APIContext) {
+	var form scim.Group
+	if !decodeBody(ctx, &form) {
+		return
+	}
+	t := groupFromContext(ctx)
+
+	ids, err := parseMemberIDs(form.Members)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if form.DisplayName != "" {
+		if err := renameGroup(ctx, t, form.DisplayName); err != nil {
+			writeError(ctx, err)
+			return
+		}
+	}
+	if err := replaceGroupMembers(ctx, t, ids); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	writeGroup(ctx, http.StatusOK, t)
+}
+
+// PatchGroup renames a team or adds and removes members
+func PatchGroup(ctx *context.APIContext) {
+	var form scim.PatchRequest
+	if !decodeBody(ctx, &form) {
+		return
+	}
+	t := groupFromContext(ctx)
+
+	for _, op := range form.Operations {
+		if err := applyPatchOperation(op, func(op string, path *scim.Path, value json.RawMessage) error {
+			return applyGroupAttribute(ctx, t, op, path, value)
+		}); err != nil {
+			writeError(ctx, err)
+			return
+		}
+	}
+
+	writeGroup(ctx, http.StatusOK, t)
+}
+
+// DeleteGroup deletes a team, the owners team cannot be deleted
+func DeleteGroup(ctx *context.APIContext) {
+	t := groupFromContext(ctx)
+	if t.IsOwnerTeam() {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeMutability, "the owners team cannot be deleted")
+		return
+	}
+	if err := t.GetUnits(); err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	if err := models.DeleteTeam(t); err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	log.Trace("Team deleted through SCIM by %s: %d", ctx.User.Name, t.ID)
+	audit.Record(admin.AuditTeamDelete, ctx.User, ctx.RemoteAddr(), t, audit.TeamSnapshot(t), nil)
+
+	ctx.Status(http.StatusNoContent)
+}
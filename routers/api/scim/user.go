@@ -0,0 +1,360 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/models/login"
+	"code.gitea.io/gitea/modules/audit"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/password"
+	"code.gitea.io/gitea/modules/scim"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/agit"
+	user_service "code.gitea.io/gitea/services/user"
+)
+
+func userFromContext(ctx *context.APIContext) *models.User {
+	return ctx.Data["SCIMUser"].(*models.User)
+}
+
+// userAssignment loads the individual user of the id path parameter
+func userAssignment(ctx *context.APIContext) {
+	u, err := models.GetUserByID(ctx.ParamsInt64("id"))
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			apiError(ctx, http.StatusNotFound, "", "user not found")
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	if u.IsOrganization() {
+		apiError(ctx, http.StatusNotFound, "", "user not found")
+		return
+	}
+	ctx.Data["SCIMUser"] = u
+}
+
+func toSCIMUser(u *models.User) *scim.User {
+	active := u.IsActive && !u.ProhibitLogin
+	created := u.CreatedUnix.AsTime()
+	lastModified := u.UpdatedUnix.AsTime()
+
+	result := &scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          strconv.FormatInt(u.ID, 10),
+		UserName:    u.Name,
+		DisplayName: u.FullName,
+		Active:      &active,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      &created,
+			LastModified: &lastModified,
+			Location:     location("Users", u.ID),
+		},
+	}
+	if u.FullName != "" {
+		result.Name = &scim.Name{Formatted: u.FullName}
+	}
+	if u.Email != "" {
+		result.Emails = []scim.MultiValue{{Value: u.Email, Type: "work", Primary: true}}
+	}
+	return result
+}
+
+// setActive (de)activates a user, deactivated users are not allowed to sign in
+func setActive(u *models.User, active bool) {
+	u.IsActive = active
+	u.ProhibitLogin = !active
+}
+
+// renameUser changes the name of a local user like the user settings do
+func renameUser(u *models.User, newName string) error {
+	if !u.IsLocal() {
+		return invalidValue("the name of a user of an external authentication source cannot be changed")
+	}
+	if u.LowerName != strings.ToLower(newName) {
+		if err := models.ChangeUserName(u, newName); err != nil {
+			return err
+		}
+	} else if err := models.UpdateRepositoryOwnerNames(u.ID, newName); err != nil {
+		return err
+	}
+	if err := agit.UserNameChanged(u, newName); err != nil {
+		return err
+	}
+	u.Name = newName
+	u.LowerName = strings.ToLower(newName)
+	return nil
+}
+
+func parseBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	// some clients send booleans as strings like "False"
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(s)
+}
+
+// applyUserAttribute changes an attribute of u, a nil value removes it.
+// A requested rename is stored in newName and a requested primary email in newEmail, as both
+// are applied by dedicated functions. Attributes which are not stored by Gitea are ignored.
+func applyUserAttribute(u *models.User, newName, newEmail *string, attr string, value json.RawMessage) error {
+	switch attr {
+	case "username":
+		if value == nil {
+			return invalidValue("userName is required")
+		}
+		if err := json.Unmarshal(value, newName); err != nil {
+			return invalidValue("invalid userName: " + err.Error())
+		}
+	case "displayname", "name.formatted":
+		u.FullName = ""
+		if value != nil {
+			if err := json.Unmarshal(value, &u.FullName); err != nil {
+				return invalidValue("invalid " + attr + ": " + err.Error())
+			}
+		}
+	case "name":
+		var name scim.Name
+		if value != nil {
+			if err := json.Unmarshal(value, &name); err != nil {
+				return invalidValue("invalid name: " + err.Error())
+			}
+		}
+		u.FullName = (&scim.User{Name: &name}).FullName()
+	case "emails":
+		var emails []scim.MultiValue
+		if value != nil {
+			if err := json.Unmarshal(value, &emails); err != nil {
+				return invalidValue("invalid emails: " + err.Error())
+			}
+		}
+		*newEmail = (&scim.User{Emails: emails}).PrimaryEmail()
+		if *newEmail == "" {
+			return invalidValue("an email is required")
+		}
+	case "emails.value":
+		*newEmail = ""
+		if value != nil {
+			if err := json.Unmarshal(value, newEmail); err != nil {
+				return invalidValue("invalid email: " + err.Error())
+			}
+		}
+		if *newEmail == "" {
+			return invalidValue("an email is required")
+		}
+	case "active":
+		if value == nil {
+			return invalidValue("active cannot be removed")
+		}
+		active, err := parseBool(value)
+		if err != nil {
+			return invalidValue("invalid active: " + err.Error())
+		}
+		setActive(u, active)
+	}
+	return nil
+}
+
+// ListUsers lists the individual users matching the filter
+func ListUsers(ctx *context.APIContext) {
+	cond, err := parseFilter(ctx.FormString("filter"), userAttributeCond)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	startIndex, count := listRange(ctx)
+	// count 0 only asks for the number of results
+	users, total, err := models.FindIndividualUsersByCond(cond, startIndex-1, util.Max(count, 1))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	if count == 0 {
+		users = users[:0]
+	}
+
+	resources := make([]*scim.User, 0, len(users))
+	for _, u := range users {
+		resources = append(resources, toSCIMUser(u))
+	}
+	writeList(ctx, startIndex, len(resources), total, resources)
+}
+
+// GetUser returns a user
+func GetUser(ctx *context.APIContext) {
+	writeResponse(ctx, http.StatusOK, toSCIMUser(userFromContext(ctx)))
+}
+
+// CreateUser creates a local user. Without a password the user has to sign in through an external authentication source.
+func CreateUser(ctx *context.APIContext) {
+	var form scim.User
+	if !decodeBody(ctx, &form) {
+		return
+	}
+	if form.UserName == "" {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "userName is required")
+		return
+	}
+	email := form.PrimaryEmail()
+	if email == "" {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "an email is required")
+		return
+	}
+
+	passwd := form.Password
+	if passwd == "" {
+		var err error
+		if passwd, err = util.RandomString(32); err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return
+		}
+	} else if !password.IsComplexEnough(passwd) {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "the password does not meet the complexity requirements")
+		return
+	}
+
+	u := &models.User{
+		Name:      form.UserName,
+		FullName:  form.FullName(),
+		Email:     email,
+		Passwd:    passwd,
+		IsActive:  true,
+		LoginType: login.Plain,
+	}
+	if form.Active != nil {
+		setActive(u, *form.Active)
+	}
+
+	if err := models.CreateUser(u); err != nil {
+		writeError(ctx, err)
+		return
+	}
+	log.Trace("Account created through SCIM by %s: %s", ctx.User.Name, u.Name)
+	audit.Record(admin.AuditUserCreate, ctx.User, ctx.RemoteAddr(), u, nil, audit.UserSnapshot(u))
+
+	ctx.Resp.Header().Set("Location", location("Users", u.ID))
+	writeResponse(ctx, http.StatusCreated, toSCIMUser(u))
+}
+
+// ReplaceUser replaces the attributes of a user
+func ReplaceUser(ctx *context.APIContext) {
+	var form scim.User
+	if !decodeBody(ctx, &form) {
+		return
+	}
+	u := userFromContext(ctx)
+	before := audit.UserSnapshot(u)
+
+	u.FullName = form.FullName()
+	if form.Active != nil {
+		setActive(u, *form.Active)
+	}
+	if form.UserName != "" && form.UserName != u.Name {
+		if err := renameUser(u, form.UserName); err != nil {
+			writeError(ctx, err)
+			return
+		}
+	}
+	if email := form.PrimaryEmail(); email != "" {
+		if err := models.ReplacePrimaryEmailAddress(u, email); err != nil {
+			writeError(ctx, err)
+			return
+		}
+	}
+
+	if err := models.UpdateUser(u); err != nil {
+		writeError(ctx, err)
+		return
+	}
+	audit.Record(admin.AuditUserUpdate, ctx.User, ctx.RemoteAddr(), u, before, audit.UserSnapshot(u))
+
+	writeResponse(ctx, http.StatusOK, toSCIMUser(u))
+}
+
+// PatchUser modifies single attributes of a user, setting active to false deactivates the user
+func PatchUser(ctx *context.APIContext) {
+	var form scim.PatchRequest
+	if !decodeBody(ctx, &form) {
+		return
+	}
+	u := userFromContext(ctx)
+	before := audit.UserSnapshot(u)
+
+	newName, newEmail := u.Name, u.Email
+	for _, op := range form.Operations {
+		if err := applyPatchOperation(op, func(op string, path *scim.Path, value json.RawMessage) error {
+			if op == "remove" {
+				value = nil
+			}
+			attr := path.Attribute
+			if path.SubAttribute != "" {
+				attr += "." + path.SubAttribute
+			}
+			return applyUserAttribute(u, &newName, &newEmail, attr, value)
+		}); err != nil {
+			writeError(ctx, err)
+			return
+		}
+	}
+
+	if newName != u.Name {
+		if err := renameUser(u, newName); err != nil {
+			writeError(ctx, err)
+			return
+		}
+	}
+	if err := models.ReplacePrimaryEmailAddress(u, newEmail); err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if err := models.UpdateUser(u); err != nil {
+		writeError(ctx, err)
+		return
+	}
+	audit.Record(admin.AuditUserUpdate, ctx.User, ctx.RemoteAddr(), u, before, audit.UserSnapshot(u))
+
+	writeResponse(ctx, http.StatusOK, toSCIMUser(u))
+}
+
+// DeleteUser deletes a user. Users who still own repositories, organizations or packages can only be deactivated.
+func DeleteUser(ctx *context.APIContext) {
+	u := userFromContext(ctx)
+	if u.ID == ctx.User.ID {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeMutability, "the owner of the token cannot be deleted")
+		return
+	}
+
+	if err := user_service.DeleteUser(u); err != nil {
+		if models.IsErrUserOwnRepos(err) ||
+			models.IsErrUserHasOrgs(err) ||
+			models.IsErrUserOwnPackages(err) {
+			apiError(ctx, http.StatusConflict, "", errors.New(err.Error()+", deactivate the user instead"))
+		} else {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+		}
+		return
+	}
+	log.Trace("Account deleted through SCIM by %s: %s", ctx.User.Name, u.Name)
+	audit.Record(admin.AuditUserDelete, ctx.User, ctx.RemoteAddr(), u, audit.UserSnapshot(u), nil)
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,52 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockUserContext(t *testing.T, userID int64, body string) *context.APIContext {
+	ctx := test.MockContext(t, "api/scim/v2/Users")
+	test.LoadUser(t, ctx, 1)
+	ctx.Req.Body = io.NopCloser(strings.NewReader(body))
+	ctx.Data["SCIMUser"] = unittest.AssertExistsAndLoadBean(t, &models.User{ID: userID}).(*models.User)
+	return &context.APIContext{Context: ctx}
+}
+
+func TestReplaceUserEmail(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	ctx := mockUserContext(t, 2, `{"userName":"user2","emails":[{"value":"user1@example.com","primary":true}]}`)
+	ReplaceUser(ctx)
+	assert.EqualValues(t, http.StatusConflict, ctx.Resp.Status())
+	unittest.AssertExistsAndLoadBean(t, &models.User{ID: 2, Email: "user2@example.com"})
+
+	ctx = mockUserContext(t, 2, `{"userName":"user2","emails":[{"value":"user2-scim@example.com","primary":true}]}`)
+	ReplaceUser(ctx)
+	assert.EqualValues(t, http.StatusOK, ctx.Resp.Status())
+	unittest.AssertExistsAndLoadBean(t, &models.User{ID: 2, Email: "user2-scim@example.com"})
+	unittest.AssertExistsAndLoadBean(t, &user_model.EmailAddress{UID: 2, Email: "user2-scim@example.com", IsPrimary: true})
+}
+
+func TestPatchUserEmail(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	ctx := mockUserContext(t, 2, `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],"Operations":[{"op":"replace","path":"emails.value","value":"user1@example.com"}]}`)
+	PatchUser(ctx)
+	assert.EqualValues(t, http.StatusConflict, ctx.Resp.Status())
+	unittest.AssertExistsAndLoadBean(t, &models.User{ID: 2, Email: "user2@example.com"})
+}
@@ -0,0 +1,172 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package activitypub_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/db"
+	federation_model "code.gitea.io/gitea/models/federation"
+	"code.gitea.io/gitea/models/unittest"
+	ap "code.gitea.io/gitea/modules/activitypub"
+	"code.gitea.io/gitea/modules/hostmatcher"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/translation"
+	"code.gitea.io/gitea/modules/web"
+	v1 "code.gitea.io/gitea/routers/api/v1"
+	federation_service "code.gitea.io/gitea/services/federation"
+
+	"gitea.com/go-chi/session"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/ini.v1"
+)
+
+const (
+	// instanceEnv makes the test binary serve the other instance of TestFederation
+	instanceEnv       = "GITEA_TEST_FEDERATION_INSTANCE"
+	instanceURLPrefix = "federation instance: "
+)
+
+// startInstance serves the API routes of this process on a loopback address
+func startInstance(t *testing.T) string {
+	setting.Cfg = ini.Empty()
+	setting.NewQueueService()
+	// activities are delivered while they are published, so they were processed by the receiving
+	// instance when the sending call returns
+	_, err := setting.Cfg.Section("queue.federation_delivery").NewKey("TYPE", "immediate")
+	assert.NoError(t, err)
+	setting.Federation.Enabled = true
+	setting.Federation.AllowedHostList = hostmatcher.MatchBuiltinLoopback
+	setting.Names = []string{"english"}
+	setting.Langs = []string{"en-US"}
+	translation.InitLocales()
+	assert.NoError(t, federation_service.Init())
+
+	server := httptest.NewUnstartedServer(nil)
+	setting.AppURL = "http://" + server.Listener.Addr().String() + "/"
+
+	r := web.NewRoute()
+	r.Mount("/api/v1", v1.Routes(session.Sessioner(session.Options{Provider: "memory"})))
+	server.Config.Handler = r
+	server.Start()
+	t.Cleanup(server.Close)
+	return setting.AppURL
+}
+
+// startOtherInstance runs the test binary again to serve an instance with its own database
+func startOtherInstance(t *testing.T) string {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFederationInstance$")
+	cmd.Env = append(os.Environ(), instanceEnv+"=1")
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	assert.NoError(t, err)
+	stdout, err := cmd.StdoutPipe()
+	assert.NoError(t, err)
+	if !assert.NoError(t, cmd.Start()) {
+		t.FailNow()
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(nil, 1<<20)
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		stdin.Close()
+		<-done
+		_ = cmd.Wait()
+	})
+
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), instanceURLPrefix) {
+			appURL := strings.TrimPrefix(scanner.Text(), instanceURLPrefix)
+			// the output is drained so the instance never blocks on its logs
+			go func() {
+				for scanner.Scan() {
+				}
+				close(done)
+			}()
+			return appURL
+		}
+	}
+	close(done)
+	t.Fatal("the other instance did not start")
+	return ""
+}
+
+func getDocument(t *testing.T, url string, v interface{}) {
+	resp, err := http.Get(url)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, url)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}
+
+// TestFederationInstance serves the other instance of TestFederation in a child process
+// until its stdin is closed
+func TestFederationInstance(t *testing.T) {
+	if os.Getenv(instanceEnv) == "" {
+		return
+	}
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	fmt.Println(instanceURLPrefix + startInstance(t))
+	_, _ = io.Copy(io.Discard, os.Stdin)
+}
+
+func TestFederation(t *testing.T) {
+	if os.Getenv(instanceEnv) != "" {
+		return
+	}
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	otherURL := startOtherInstance(t)
+	oldAppURL := setting.AppURL
+	defer func() {
+		setting.AppURL = oldAppURL
+	}()
+	startInstance(t)
+
+	user := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	otherUserIRI := otherURL + "api/v1/activitypub/user/user2"
+
+	t.Run("Follow", func(t *testing.T) {
+		actor, err := federation_service.FollowRemote(db.DefaultContext, user, otherUserIRI)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, otherUserIRI, actor.IRI)
+
+		// the other instance verified the signature of the follow with the key of our actor
+		// and accepted it
+		following, err := federation_model.GetFollowing(db.DefaultContext, user.ID, actor.ID)
+		assert.NoError(t, err)
+		assert.True(t, following.IsAccepted)
+
+		followers := &ap.OrderedCollection{}
+		getDocument(t, otherUserIRI+"/followers", followers)
+		assert.Contains(t, followers.OrderedItems, federation_service.UserIRI(user))
+	})
+
+	t.Run("CommentOnRemoteIssue", func(t *testing.T) {
+		ticketIRI := otherURL + "api/v1/activitypub/repo/user2/repo1/issues/1"
+		assert.NoError(t, federation_service.CommentRemote(db.DefaultContext, user, ticketIRI, "Hello from another instance"))
+
+		var comments []*api.Comment
+		getDocument(t, otherURL+"api/v1/repos/user2/repo1/issues/1/comments", &comments)
+		if assert.NotEmpty(t, comments) {
+			assert.Equal(t, "Hello from another instance", comments[len(comments)-1].Body)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package activitypub
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/routers/api/v1/user"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	federation_service "code.gitea.io/gitea/services/federation"
+)
+
+func getFederatedUser(ctx *context.APIContext) *models.User {
+	u := user.GetUserByParams(ctx)
+	if ctx.Written() {
+		return nil
+	}
+	if !federation_service.IsUserFederated(u) {
+		ctx.NotFound()
+		return nil
+	}
+	return u
+}
+
+// Person returns the Person actor of a user
+func Person(ctx *context.APIContext) {
+	// swagger:operation GET /activitypub/user/{username} activitypub activitypubPerson
+	// ---
+	// summary: Returns the Person actor of a user
+	// produces:
+	// - application/activity+json
+	// parameters:
+	// - name: username
+	//   in: path
+	//   description: username of the user
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActivityPub"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	u := getFederatedUser(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	actor, err := federation_service.UserActor(ctx, u)
+	if err != nil {
+		serveError(ctx, "UserActor", err)
+		return
+	}
+	serveDocument(ctx, actor)
+}
+
+// PersonInbox receives an activity for a user
+func PersonInbox(ctx *context.APIContext) {
+	// swagger:operation POST /activitypub/user/{username}/inbox activitypub activitypubPersonInbox
+	// ---
+	// summary: Send an activity to the inbox of a user
+	// description: The request must be signed with the key of the actor of the activity.
+	// consumes:
+	// - application/activity+json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: username
+	//   in: path
+	//   description: username of the user
+	//   type: string
+	//   required: true
+	// responses:
+	//   "202":
+	//     "$ref": "#/responses/empty"
+	//   "400":
+	//     "$ref": "#/responses/error"
+	//   "401":
+	//     "$ref": "#/responses/error"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	u := getFederatedUser(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	receive(ctx, &federation_service.Target{User: u})
+}
+
+// PersonOutbox returns the activities sent by a user
+func PersonOutbox(ctx *context.APIContext) {
+	// swagger:operation GET /activitypub/user/{username}/outbox activitypub activitypubPersonOutbox
+	// ---
+	// summary: Returns the outbox of a user
+	// produces:
+	// - application/activity+json
+	// parameters:
+	// - name: username
+	//   in: path
+	//   description: username of the user
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActivityPub"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	u := getFederatedUser(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	outbox, err := federation_service.Outbox(ctx, u.ID, 0, federation_service.UserIRI(u)+"/outbox", utils.GetListOptions(ctx))
+	if err != nil {
+		serveError(ctx, "Outbox", err)
+		return
+	}
+	serveDocument(ctx, outbox)
+}
+
+// PersonFollowers returns the remote followers of a user
+func PersonFollowers(ctx *context.APIContext) {
+	// swagger:operation GET /activitypub/user/{username}/followers activitypub activitypubPersonFollowers
+	// ---
+	// summary: Returns the remote followers of a user
+	// produces:
+	// - application/activity+json
+	// parameters:
+	// - name: username
+	//   in: path
+	//   description: username of the user
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActivityPub"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	u := getFederatedUser(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	followers, err := federation_service.Followers(ctx, u.ID, 0, federation_service.UserIRI(u)+"/followers")
+	if err != nil {
+		serveError(ctx, "Followers", err)
+		return
+	}
+	serveDocument(ctx, followers)
+}
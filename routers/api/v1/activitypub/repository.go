@@ -0,0 +1,237 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package activitypub
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	federation_service "code.gitea.io/gitea/services/federation"
+)
+
+func getFederatedRepo(ctx *context.APIContext) *models.Repository {
+	repo := ctx.Repo.Repository
+	if !federation_service.IsRepoFederated(repo) {
+		ctx.NotFound()
+		return nil
+	}
+	return repo
+}
+
+// Repository returns the Repository actor of a repository
+func Repository(ctx *context.APIContext) {
+	// swagger:operation GET /activitypub/repo/{owner}/{repo} activitypub activitypubRepository
+	// ---
+	// summary: Returns the Repository actor of a repository
+	// produces:
+	// - application/activity+json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActivityPub"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	repo := getFederatedRepo(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	actor, err := federation_service.RepoActor(ctx, repo)
+	if err != nil {
+		serveError(ctx, "RepoActor", err)
+		return
+	}
+	serveDocument(ctx, actor)
+}
+
+// RepositoryInbox receives an activity for a repository
+func RepositoryInbox(ctx *context.APIContext) {
+	// swagger:operation POST /activitypub/repo/{owner}/{repo}/inbox activitypub activitypubRepositoryInbox
+	// ---
+	// summary: Send an activity to the inbox of a repository
+	// description: The request must be signed with the key of the actor of the activity.
+	// consumes:
+	// - application/activity+json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "202":
+	//     "$ref": "#/responses/empty"
+	//   "400":
+	//     "$ref": "#/responses/error"
+	//   "401":
+	//     "$ref": "#/responses/error"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	repo := getFederatedRepo(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	receive(ctx, &federation_service.Target{Repo: repo})
+}
+
+// RepositoryOutbox returns the activities sent by a repository
+func RepositoryOutbox(ctx *context.APIContext) {
+	// swagger:operation GET /activitypub/repo/{owner}/{repo}/outbox activitypub activitypubRepositoryOutbox
+	// ---
+	// summary: Returns the outbox of a repository
+	// produces:
+	// - application/activity+json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActivityPub"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	repo := getFederatedRepo(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	outbox, err := federation_service.Outbox(ctx, 0, repo.ID, federation_service.RepoIRI(repo)+"/outbox", utils.GetListOptions(ctx))
+	if err != nil {
+		serveError(ctx, "Outbox", err)
+		return
+	}
+	serveDocument(ctx, outbox)
+}
+
+// RepositoryFollowers returns the remote followers of a repository
+func RepositoryFollowers(ctx *context.APIContext) {
+	// swagger:operation GET /activitypub/repo/{owner}/{repo}/followers activitypub activitypubRepositoryFollowers
+	// ---
+	// summary: Returns the remote followers of a repository
+	// produces:
+	// - application/activity+json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActivityPub"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	repo := getFederatedRepo(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	followers, err := federation_service.Followers(ctx, 0, repo.ID, federation_service.RepoIRI(repo)+"/followers")
+	if err != nil {
+		serveError(ctx, "Followers", err)
+		return
+	}
+	serveDocument(ctx, followers)
+}
+
+// Ticket returns the Ticket object of an issue
+func Ticket(ctx *context.APIContext) {
+	// swagger:operation GET /activitypub/repo/{owner}/{repo}/issues/{index} activitypub activitypubTicket
+	// ---
+	// summary: Returns the Ticket object of an issue
+	// produces:
+	// - application/activity+json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the issue
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActivityPub"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	repo := getFederatedRepo(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	issue, err := models.GetIssueByIndex(repo.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByIndex", err)
+		}
+		return
+	}
+	issue.Repo = repo
+
+	ticket, err := federation_service.IssueTicket(ctx, repo, issue)
+	if err != nil {
+		serveError(ctx, "IssueTicket", err)
+		return
+	}
+	serveDocument(ctx, ticket)
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package activitypub
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	ap "code.gitea.io/gitea/modules/activitypub"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	federation_service "code.gitea.io/gitea/services/federation"
+)
+
+// serveDocument writes the ActivityPub document with the ActivityPub content type
+func serveDocument(ctx *context.APIContext, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "Marshal", err)
+		return
+	}
+	ctx.Resp.Header().Set("Content-Type", ap.ContentType+"; charset=utf-8")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	if _, err := ctx.Resp.Write(data); err != nil {
+		log.Error("Write: %v", err)
+	}
+}
+
+// serveError maps the errors of the federation service to a response
+func serveError(ctx *context.APIContext, name string, err error) {
+	switch {
+	case errors.Is(err, federation_service.ErrNotFederated):
+		ctx.NotFound()
+	case errors.Is(err, ap.ErrMissingSignature),
+		errors.Is(err, ap.ErrInvalidSignature),
+		errors.Is(err, ap.ErrInvalidKey),
+		errors.Is(err, federation_service.ErrSignatureMismatch):
+		ctx.Error(http.StatusUnauthorized, name, err)
+	case errors.Is(err, federation_service.ErrForbiddenActivity):
+		ctx.Error(http.StatusForbidden, name, err)
+	case errors.Is(err, federation_service.ErrInvalidActivity),
+		errors.Is(err, ap.ErrInvalidObject):
+		ctx.Error(http.StatusBadRequest, name, err)
+	default:
+		ctx.Error(http.StatusInternalServerError, name, err)
+	}
+}
+
+// receive passes the activity posted to the inbox of the target to the federation service
+func receive(ctx *context.APIContext, target *federation_service.Target) {
+	body, err := io.ReadAll(io.LimitReader(ctx.Req.Body, setting.Federation.MaxSize+1))
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ReadAll", err)
+		return
+	}
+	if int64(len(body)) > setting.Federation.MaxSize {
+		ctx.Error(http.StatusRequestEntityTooLarge, "", "activity is too large")
+		return
+	}
+
+	if err := federation_service.ReceiveActivity(ctx, target, ctx.Req, body); err != nil {
+		serveError(ctx, "ReceiveActivity", err)
+		return
+	}
+	ctx.Status(http.StatusAccepted)
+}
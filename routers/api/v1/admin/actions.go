@@ -0,0 +1,35 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// GetActionRunnerRegistrationToken returns the token to register runners for all repositories
+func GetActionRunnerRegistrationToken(ctx *context.APIContext) {
+	// swagger:operation GET /admin/actions/runners/registration-token admin adminGetActionRunnerRegistrationToken
+	// ---
+	// summary: Get the token to register runners for all repositories
+	// produces:
+	// - application/json
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionRunnerRegistrationToken"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	t, err := actions_model.GetOrCreateRunnerToken(ctx, 0, 0)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetOrCreateRunnerToken", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &api.ActionRunnerRegistrationToken{Token: t.Token})
+}
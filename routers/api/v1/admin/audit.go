@@ -0,0 +1,172 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"net/http"
+
+	admin_model "code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/modules/audit"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+func auditEventsOptions(ctx *context.APIContext) *admin_model.FindAuditEventsOptions {
+	before, since, err := utils.GetQueryBeforeSince(ctx)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "GetQueryBeforeSince", err)
+		return nil
+	}
+	return &admin_model.FindAuditEventsOptions{
+		ListOptions: utils.GetListOptions(ctx),
+		Action:      admin_model.AuditAction(ctx.FormTrim("action")),
+		ActorName:   ctx.FormTrim("actor"),
+		TargetType:  ctx.FormTrim("target_type"),
+		TargetID:    ctx.FormInt64("target_id"),
+		IPAddress:   ctx.FormTrim("ip"),
+		Since:       timeutil.TimeStamp(since),
+		Before:      timeutil.TimeStamp(before),
+	}
+}
+
+// ListAuditEvents api for listing the audit log
+func ListAuditEvents(ctx *context.APIContext) {
+	// swagger:operation GET /admin/audit admin adminListAuditEvents
+	// ---
+	// summary: List the audit log, newest first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: action
+	//   in: query
+	//   description: only events of this action, e.g. user_sign_in
+	//   type: string
+	// - name: actor
+	//   in: query
+	//   description: only events of this user
+	//   type: string
+	// - name: target_type
+	//   in: query
+	//   description: only events on targets of this type
+	//   type: string
+	// - name: target_id
+	//   in: query
+	//   description: only events on the target with this id
+	//   type: integer
+	//   format: int64
+	// - name: ip
+	//   in: query
+	//   description: only events from this address
+	//   type: string
+	// - name: since
+	//   in: query
+	//   description: Only show events created after the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: before
+	//   in: query
+	//   description: Only show events created before the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/AuditEventList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	opts := auditEventsOptions(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	events, total, err := admin_model.FindAuditEvents(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindAuditEvents", err)
+		return
+	}
+
+	apiEvents := make([]*api.AuditEvent, len(events))
+	for i, event := range events {
+		apiEvents[i] = convert.ToAuditEvent(event)
+	}
+
+	ctx.SetLinkHeader(int(total), opts.PageSize)
+	ctx.SetTotalCountHeader(total)
+	ctx.JSON(http.StatusOK, apiEvents)
+}
+
+// ExportAuditEvents api for exporting the audit log as JSON lines
+func ExportAuditEvents(ctx *context.APIContext) {
+	// swagger:operation GET /admin/audit/export admin adminExportAuditEvents
+	// ---
+	// summary: Export the audit log as JSON lines, oldest first
+	// produces:
+	// - application/x-ndjson
+	// parameters:
+	// - name: action
+	//   in: query
+	//   description: only events of this action, e.g. user_sign_in
+	//   type: string
+	// - name: actor
+	//   in: query
+	//   description: only events of this user
+	//   type: string
+	// - name: target_type
+	//   in: query
+	//   description: only events on targets of this type
+	//   type: string
+	// - name: target_id
+	//   in: query
+	//   description: only events on the target with this id
+	//   type: integer
+	//   format: int64
+	// - name: ip
+	//   in: query
+	//   description: only events from this address
+	//   type: string
+	// - name: since
+	//   in: query
+	//   description: Only export events created after the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: before
+	//   in: query
+	//   description: Only export events created before the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// responses:
+	//   "200":
+	//     description: one AuditEvent JSON object per line
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	opts := auditEventsOptions(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "application/x-ndjson")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	if err := audit.ExportJSONLines(ctx, ctx.Resp, opts); err != nil {
+		// the headers are already sent
+		log.Error("ExportJSONLines: %v", err)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
@@ -76,6 +78,7 @@ func CreateOrg(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit.Record(admin.AuditOrgCreate, ctx.User, ctx.RemoteAddr(), org, nil, audit.OrgSnapshot(org))
 
 	ctx.JSON(http.StatusCreated, convert.ToOrganization(org))
 }
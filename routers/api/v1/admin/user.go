@@ -11,8 +11,10 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
 	"code.gitea.io/gitea/models/login"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/log"
@@ -123,6 +125,7 @@ func CreateUser(ctx *context.APIContext) {
 		return
 	}
 	log.Trace("Account created by admin (%s): %s", ctx.User.Name, u.Name)
+	audit.Record(admin.AuditUserCreate, ctx.User, ctx.RemoteAddr(), u, nil, audit.UserSnapshot(u))
 
 	// Send email notification.
 	if form.SendNotify {
@@ -162,6 +165,7 @@ func EditUser(ctx *context.APIContext) {
 	if ctx.Written() {
 		return
 	}
+	before := audit.UserSnapshot(u)
 
 	parseLoginSource(ctx, u, form.SourceID, form.LoginName)
 	if ctx.Written() {
@@ -255,6 +259,7 @@ func EditUser(ctx *context.APIContext) {
 		return
 	}
 	log.Trace("Account profile updated by admin (%s): %s", ctx.User.Name, u.Name)
+	audit.Record(admin.AuditUserUpdate, ctx.User, ctx.RemoteAddr(), u, before, audit.UserSnapshot(u))
 
 	ctx.JSON(http.StatusOK, convert.ToUser(u, ctx.User))
 }
@@ -292,7 +297,8 @@ func DeleteUser(ctx *context.APIContext) {
 
 	if err := user_service.DeleteUser(u); err != nil {
 		if models.IsErrUserOwnRepos(err) ||
-			models.IsErrUserHasOrgs(err) {
+			models.IsErrUserHasOrgs(err) ||
+			models.IsErrUserOwnPackages(err) {
 			ctx.Error(http.StatusUnprocessableEntity, "", err)
 		} else {
 			ctx.Error(http.StatusInternalServerError, "DeleteUser", err)
@@ -300,6 +306,7 @@ func DeleteUser(ctx *context.APIContext) {
 		return
 	}
 	log.Trace("Account deleted by admin(%s): %s", ctx.User.Name, u.Name)
+	audit.Record(admin.AuditUserDelete, ctx.User, ctx.RemoteAddr(), u, audit.UserSnapshot(u), nil)
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -78,10 +78,12 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/activitypub"
 	"code.gitea.io/gitea/routers/api/v1/admin"
 	"code.gitea.io/gitea/routers/api/v1/misc"
 	"code.gitea.io/gitea/routers/api/v1/notify"
 	"code.gitea.io/gitea/routers/api/v1/org"
+	"code.gitea.io/gitea/routers/api/v1/project"
 	"code.gitea.io/gitea/routers/api/v1/repo"
 	"code.gitea.io/gitea/routers/api/v1/settings"
 	"code.gitea.io/gitea/routers/api/v1/user"
@@ -138,7 +140,7 @@ func repoAssignment() func(ctx *context.APIContext) {
 		if ctx.IsSigned && ctx.User.LowerName == strings.ToLower(userName) {
 			owner = ctx.User
 		} else {
-			owner, err = models.GetUserByName(userName)
+			owner, err = models.GetUserByNameCtx(ctx, userName)
 			if err != nil {
 				if models.IsErrUserNotExist(err) {
 					if redirectUserID, err := user_model.LookupUserRedirect(userName); err == nil {
@@ -157,7 +159,7 @@ func repoAssignment() func(ctx *context.APIContext) {
 		ctx.Repo.Owner = owner
 
 		// Get repository.
-		repo, err := models.GetRepositoryByName(owner.ID, repoName)
+		repo, err := models.GetRepositoryByNameCtx(ctx, owner.ID, repoName)
 		if err != nil {
 			if models.IsErrRepoNotExist(err) {
 				redirectRepoID, err := models.LookupRepoRedirect(owner.ID, repoName)
@@ -408,6 +410,16 @@ func reqWebhooksEnabled() func(ctx *context.APIContext) {
 	}
 }
 
+// reqActionsEnabled requires actions to be enabled by admin.
+func reqActionsEnabled() func(ctx *context.APIContext) {
+	return func(ctx *context.APIContext) {
+		if !setting.Actions.Enabled {
+			ctx.Error(http.StatusForbidden, "", "actions disabled by administrator")
+			return
+		}
+	}
+}
+
 func orgAssignment(args ...bool) func(ctx *context.APIContext) {
 	var (
 		assignOrg  bool
@@ -596,6 +608,21 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 		m.Get("/version", misc.Version)
 		if setting.Federation.Enabled {
 			m.Get("/nodeinfo", misc.NodeInfo)
+			m.Group("/activitypub", func() {
+				m.Group("/user/{username}", func() {
+					m.Get("", activitypub.Person)
+					m.Post("/inbox", activitypub.PersonInbox)
+					m.Get("/outbox", activitypub.PersonOutbox)
+					m.Get("/followers", activitypub.PersonFollowers)
+				})
+				m.Group("/repo/{username}/{reponame}", func() {
+					m.Get("", activitypub.Repository)
+					m.Post("/inbox", activitypub.RepositoryInbox)
+					m.Get("/outbox", activitypub.RepositoryOutbox)
+					m.Get("/followers", activitypub.RepositoryFollowers)
+					m.Get("/issues/{index}", activitypub.Ticket)
+				}, repoAssignment())
+			})
 		}
 		m.Get("/signing-key.gpg", misc.SigningKey)
 		m.Post("/markdown", bind(api.MarkdownOption{}), misc.Markdown)
@@ -630,6 +657,7 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 				}
 
 				m.Get("/repos", reqExploreSignIn(), user.ListUserRepos)
+				m.Get("/projects", reqExploreSignIn(), context.ProjectOwnerAssignmentAPI(), project.ListUserProjects)
 				m.Group("/tokens", func() {
 					m.Combo("").Get(user.ListAccessTokens).
 						Post(bind(api.CreateAccessTokenOption{}), user.CreateAccessToken)
@@ -699,6 +727,7 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 
 			m.Combo("/repos").Get(user.ListMyRepos).
 				Post(bind(api.CreateRepoOption{}), repo.Create)
+			m.Post("/projects", context.ProjectOwnerAssignmentAPI(), bind(api.CreateProjectOption{}), project.CreateUserProject)
 
 			m.Group("/starred", func() {
 				m.Get("", user.GetMyStarredRepos)
@@ -715,6 +744,16 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 			m.Get("/subscriptions", user.GetMyWatchedRepos)
 
 			m.Get("/teams", org.ListUserTeams)
+
+			if setting.Federation.Enabled {
+				m.Group("/federation", func() {
+					m.Combo("/following").
+						Post(bind(api.FollowRemoteOption{}), user.FollowRemote).
+						Delete(user.UnfollowRemote)
+					m.Post("/starred", bind(api.StarRemoteOption{}), user.StarRemote)
+					m.Post("/comments", bind(api.CommentRemoteOption{}), user.CommentRemote)
+				})
+			}
 		}, reqToken())
 
 		// Repositories
@@ -728,6 +767,7 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 			m.Get("/issues/search", repo.SearchIssues)
 
 			m.Post("/migrate", reqToken(), bind(api.MigrateRepoOptions{}), repo.Migrate)
+			m.Post("/import", reqToken(), repo.Import)
 
 			m.Group("/{username}/{reponame}", func() {
 				m.Combo("").Get(reqAnyRepoReader(), repo.Get).
@@ -754,6 +794,8 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 							Patch(bind(api.EditHookOption{}), repo.EditHook).
 							Delete(repo.DeleteHook)
 						m.Post("/tests", context.RepoRefForAPI, repo.TestHook)
+						m.Get("/deliveries", repo.ListHookDeliveries)
+						m.Post("/deliveries/redeliver", bind(api.RedeliverHookOption{}), repo.RedeliverHookDeliveries)
 					})
 				}, reqToken(), reqAdmin(), reqWebhooksEnabled())
 				m.Group("/collaborators", func() {
@@ -880,6 +922,8 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 				})
 				m.Post("/markdown", bind(api.MarkdownOption{}), misc.Markdown)
 				m.Post("/markdown/raw", misc.MarkdownRaw)
+				m.Combo("/projects").Get(reqRepoReader(unit.TypeProjects), project.ListRepoProjects).
+					Post(reqToken(), reqRepoWriter(unit.TypeProjects), bind(api.CreateProjectOption{}), project.CreateRepoProject)
 				m.Group("/milestones", func() {
 					m.Combo("").Get(repo.ListMilestones).
 						Post(reqToken(), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), bind(api.CreateMilestoneOption{}), repo.CreateMilestone)
@@ -916,6 +960,13 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 					})
 				}, reqRepoReader(unit.TypeReleases))
 				m.Post("/mirror-sync", reqToken(), reqRepoWriter(unit.TypeCode), repo.MirrorSync)
+				m.Group("/exports", func() {
+					m.Post("", repo.CreateExport)
+					m.Group("/{id}", func() {
+						m.Get("", repo.GetExport)
+						m.Get("/archive", repo.GetExportArchive)
+					})
+				}, reqToken(), reqAdmin())
 				m.Get("/editorconfig/{filename}", context.RepoRefForAPI, reqRepoReader(unit.TypeCode), repo.GetEditorconfig)
 				m.Group("/pulls", func() {
 					m.Combo("").Get(repo.ListPullRequests).
@@ -927,7 +978,8 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 						m.Post("/update", reqToken(), repo.UpdatePullRequest)
 						m.Get("/commits", repo.GetPullRequestCommits)
 						m.Combo("/merge").Get(repo.IsPullRequestMerged).
-							Post(reqToken(), mustNotBeArchived, bind(forms.MergePullRequestForm{}), repo.MergePullRequest)
+							Post(reqToken(), mustNotBeArchived, bind(forms.MergePullRequestForm{}), repo.MergePullRequest).
+							Delete(reqToken(), mustNotBeArchived, repo.CancelScheduledMerge)
 						m.Group("/reviews", func() {
 							m.Combo("").
 								Get(repo.ListPullReviews).
@@ -991,6 +1043,15 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 				}, reqAnyRepoReader())
 				m.Get("/issue_templates", context.ReferencesGitRepo(false), repo.GetIssueTemplates)
 				m.Get("/languages", reqRepoReader(unit.TypeCode), repo.GetLanguages)
+				m.Group("/actions", func() {
+					m.Group("/runs", func() {
+						m.Get("", repo.ListActionRuns)
+						m.Get("/{run}", repo.GetActionRun)
+						m.Post("/{run}/cancel", reqToken(), reqRepoWriter(unit.TypeCode), repo.CancelActionRun)
+					})
+					m.Get("/jobs/{job}/logs", repo.GetActionJobLogs)
+					m.Get("/runners/registration-token", reqToken(), reqAdmin(), repo.GetActionRunnerRegistrationToken)
+				}, reqActionsEnabled(), reqRepoReader(unit.TypeCode))
 			}, repoAssignment())
 		})
 
@@ -1008,6 +1069,8 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 				Delete(reqToken(), reqOrgOwnership(), org.Delete)
 			m.Combo("/repos").Get(user.ListOrgRepos).
 				Post(reqToken(), bind(api.CreateRepoOption{}), repo.CreateOrgRepo)
+			m.Combo("/projects", context.ProjectOwnerAssignmentAPI()).Get(project.ListOrgProjects).
+				Post(reqToken(), bind(api.CreateProjectOption{}), project.CreateOrgProject)
 			m.Group("/members", func() {
 				m.Get("", org.ListMembers)
 				m.Combo("/{username}").Get(org.IsMember).
@@ -1037,8 +1100,30 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 				m.Combo("/{id}").Get(org.GetHook).
 					Patch(bind(api.EditHookOption{}), org.EditHook).
 					Delete(org.DeleteHook)
+				m.Get("/{id}/deliveries", org.ListHookDeliveries)
+				m.Post("/{id}/deliveries/redeliver", bind(api.RedeliverHookOption{}), org.RedeliverHookDeliveries)
 			}, reqToken(), reqOrgOwnership(), reqWebhooksEnabled())
+			m.Get("/actions/runners/registration-token", reqToken(), reqOrgOwnership(), reqActionsEnabled(), org.GetActionRunnerRegistrationToken)
 		}, orgAssignment(true))
+		m.Group("/projects/{id}", func() {
+			m.Combo("").Get(project.GetProject).
+				Patch(reqToken(), bind(api.EditProjectOption{}), project.EditProject).
+				Delete(reqToken(), project.DeleteProject)
+			m.Group("/boards", func() {
+				m.Combo("").Get(project.ListProjectBoards).
+					Post(reqToken(), bind(api.CreateProjectBoardOption{}), project.CreateProjectBoard)
+				m.Combo("/{boardID}", reqToken()).
+					Patch(bind(api.EditProjectBoardOption{}), project.EditProjectBoard).
+					Delete(project.DeleteProjectBoard)
+			})
+			m.Group("/cards", func() {
+				m.Combo("").Get(project.ListProjectCards).
+					Post(reqToken(), bind(api.AddProjectCardOption{}), project.AddProjectCard)
+				m.Combo("/{issueID}", reqToken()).
+					Patch(bind(api.MoveProjectCardOption{}), project.MoveProjectCard).
+					Delete(project.RemoveProjectCard)
+			})
+		})
 		m.Group("/teams/{teamid}", func() {
 			m.Combo("").Get(org.GetTeam).
 				Patch(reqOrgOwnership(), bind(api.EditTeamOption{}), org.EditTeam).
@@ -1064,6 +1149,10 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 				m.Post("/{task}", admin.PostCronTask)
 			})
 			m.Get("/orgs", admin.GetAllOrgs)
+			m.Group("/audit", func() {
+				m.Get("", admin.ListAuditEvents)
+				m.Get("/export", admin.ExportAuditEvents)
+			})
 			m.Group("/users", func() {
 				m.Get("", admin.GetAllUsers)
 				m.Post("", bind(api.CreateUserOption{}), admin.CreateUser)
@@ -1079,6 +1168,7 @@ func Routes(sessioner func(http.Handler) http.Handler) *web.Route {
 					m.Post("/repos", bind(api.CreateRepoOption{}), admin.CreateRepo)
 				})
 			})
+			m.Get("/actions/runners/registration-token", reqActionsEnabled(), admin.GetActionRunnerRegistrationToken)
 			m.Group("/unadopted", func() {
 				m.Get("", admin.ListUnadoptedRepositories)
 				m.Post("/{username}/{reponame}", admin.AdoptRepository)
@@ -0,0 +1,41 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org
+
+import (
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// GetActionRunnerRegistrationToken returns the token to register runners for all repositories of the organization
+func GetActionRunnerRegistrationToken(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/actions/runners/registration-token organization orgGetActionRunnerRegistrationToken
+	// ---
+	// summary: Get the token to register runners for an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionRunnerRegistrationToken"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	t, err := actions_model.GetOrCreateRunnerToken(ctx, ctx.Org.Organization.ID, 0)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetOrCreateRunnerToken", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &api.ActionRunnerRegistrationToken{Token: t.Token})
+}
@@ -201,3 +201,88 @@ func DeleteHook(ctx *context.APIContext) {
 	}
 	ctx.Status(http.StatusNoContent)
 }
+
+// ListHookDeliveries list the deliveries of an organization's hook
+func ListHookDeliveries(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/hooks/{id}/deliveries organization orgListHookDeliveries
+	// ---
+	// summary: List the deliveries of a hook, the most recent first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the hook
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: status
+	//   in: query
+	//   description: filter the deliveries by status
+	//   type: string
+	//   enum: [pending, succeeded, failed]
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/HookDeliveryList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	hook, err := utils.GetOrgHook(ctx, ctx.Org.Organization.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		return
+	}
+	utils.ListHookDeliveries(ctx, hook)
+}
+
+// RedeliverHookDeliveries deliver again the failed deliveries of an organization's hook
+func RedeliverHookDeliveries(ctx *context.APIContext) {
+	// swagger:operation POST /orgs/{org}/hooks/{id}/deliveries/redeliver organization orgRedeliverHookDeliveries
+	// ---
+	// summary: Deliver again the failed deliveries of a hook
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the hook
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/RedeliverHookOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/HookDeliveryList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	hook, err := utils.GetOrgHook(ctx, ctx.Org.Organization.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		return
+	}
+	utils.RedeliverHookDeliveries(ctx, web.GetForm(ctx).(*api.RedeliverHookOption), hook)
+}
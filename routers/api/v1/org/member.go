@@ -9,6 +9,8 @@ import (
 	"net/url"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/setting"
@@ -310,6 +312,8 @@ func DeleteMember(ctx *context.APIContext) {
 	}
 	if err := ctx.Org.Organization.RemoveMember(member.ID); err != nil {
 		ctx.Error(http.StatusInternalServerError, "RemoveMember", err)
+		return
 	}
+	audit.Record(admin.AuditOrgMemberRemove, ctx.User, ctx.RemoteAddr(), ctx.Org.Organization, map[string]interface{}{"user_id": member.ID}, nil)
 	ctx.Status(http.StatusNoContent)
 }
@@ -9,7 +9,9 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
 	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
@@ -281,6 +283,7 @@ func Create(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit.Record(admin.AuditOrgCreate, ctx.User, ctx.RemoteAddr(), org, nil, audit.OrgSnapshot(org))
 
 	ctx.JSON(http.StatusCreated, convert.ToOrganization(org))
 }
@@ -334,6 +337,7 @@ func Edit(ctx *context.APIContext) {
 	//     "$ref": "#/responses/Organization"
 	form := web.GetForm(ctx).(*api.EditOrgOption)
 	org := ctx.Org.Organization
+	before := audit.OrgSnapshot(org)
 	org.FullName = form.FullName
 	org.Description = form.Description
 	org.Website = form.Website
@@ -351,6 +355,7 @@ func Edit(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "EditOrganization", err)
 		return
 	}
+	audit.Record(admin.AuditOrgUpdate, ctx.User, ctx.RemoteAddr(), org, before, audit.OrgSnapshot(org))
 
 	ctx.JSON(http.StatusOK, convert.ToOrganization(org))
 }
@@ -376,5 +381,6 @@ func Delete(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteOrganization", err)
 		return
 	}
+	audit.Record(admin.AuditOrgDelete, ctx.User, ctx.RemoteAddr(), ctx.Org.Organization, audit.OrgSnapshot(ctx.Org.Organization), nil)
 	ctx.Status(http.StatusNoContent)
 }
@@ -9,7 +9,9 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
 	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/log"
@@ -194,6 +196,7 @@ func CreateTeam(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit.Record(admin.AuditTeamCreate, ctx.User, ctx.RemoteAddr(), team, nil, audit.TeamSnapshot(team))
 
 	ctx.JSON(http.StatusCreated, convert.ToTeam(team))
 }
@@ -228,6 +231,7 @@ func EditTeam(ctx *context.APIContext) {
 		ctx.InternalServerError(err)
 		return
 	}
+	before := audit.TeamSnapshot(team)
 
 	if form.CanCreateOrgRepo != nil {
 		team.CanCreateOrgRepo = *form.CanCreateOrgRepo
@@ -276,6 +280,7 @@ func EditTeam(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "EditTeam", err)
 		return
 	}
+	audit.Record(admin.AuditTeamUpdate, ctx.User, ctx.RemoteAddr(), team, before, audit.TeamSnapshot(team))
 	ctx.JSON(http.StatusOK, convert.ToTeam(team))
 }
 
@@ -295,10 +300,16 @@ func DeleteTeam(ctx *context.APIContext) {
 	//   "204":
 	//     description: team deleted
 
+	if err := ctx.Org.Team.GetUnits(); err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	before := audit.TeamSnapshot(ctx.Org.Team)
 	if err := models.DeleteTeam(ctx.Org.Team); err != nil {
 		ctx.Error(http.StatusInternalServerError, "DeleteTeam", err)
 		return
 	}
+	audit.Record(admin.AuditTeamDelete, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, before, nil)
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -426,6 +437,7 @@ func AddTeamMember(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "AddMember", err)
 		return
 	}
+	audit.Record(admin.AuditTeamMemberAdd, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, nil, audit.TeamMemberSnapshot(ctx.Org.Team, u.ID))
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -463,6 +475,7 @@ func RemoveTeamMember(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "RemoveMember", err)
 		return
 	}
+	audit.Record(admin.AuditTeamMemberRemove, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, audit.TeamMemberSnapshot(ctx.Org.Team, u.ID), nil)
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -569,6 +582,7 @@ func AddTeamRepository(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "AddRepository", err)
 		return
 	}
+	audit.Record(admin.AuditTeamRepositoryAdd, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, nil, audit.TeamRepositorySnapshot(ctx.Org.Team, repo.ID))
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -619,6 +633,7 @@ func RemoveTeamRepository(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "RemoveRepository", err)
 		return
 	}
+	audit.Record(admin.AuditTeamRepositoryRemove, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, audit.TeamRepositorySnapshot(ctx.Org.Team, repo.ID), nil)
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -0,0 +1,252 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+)
+
+// ListProjectBoards list the boards of a project
+func ListProjectBoards(ctx *context.APIContext) {
+	// swagger:operation GET /projects/{id}/boards project projectListBoards
+	// ---
+	// summary: List the boards of a project
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoardList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := getProject(ctx, models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+
+	boards, err := models.GetProjectBoards(p.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProjectBoards", err)
+		return
+	}
+
+	apiBoards := make([]*api.ProjectBoard, 0, len(boards))
+	for _, board := range boards {
+		// skip the placeholder of the default board when none is set
+		if board.ID == 0 {
+			continue
+		}
+		apiBoards = append(apiBoards, convert.ToProjectBoard(board))
+	}
+
+	ctx.JSON(http.StatusOK, &apiBoards)
+}
+
+// CreateProjectBoard create a board in a project
+func CreateProjectBoard(ctx *context.APIContext) {
+	// swagger:operation POST /projects/{id}/boards project projectCreateBoard
+	// ---
+	// summary: Create a board in a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectBoardOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectBoard"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateProjectBoardOption)
+	p := getProject(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	if len(form.Color) > 0 && !models.BoardColorPattern.MatchString(form.Color) {
+		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid color")
+		return
+	}
+
+	board := &models.ProjectBoard{
+		ProjectID: p.ID,
+		Title:     form.Title,
+		Color:     form.Color,
+		CreatorID: ctx.User.ID,
+	}
+	if err := models.NewProjectBoard(board); err != nil {
+		ctx.Error(http.StatusInternalServerError, "NewProjectBoard", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, convert.ToProjectBoard(board))
+}
+
+// getProjectBoard returns the project and its board from the URL if the doer has at least the
+// access mode to the project
+func getProjectBoard(ctx *context.APIContext, mode models.AccessMode) (*models.Project, *models.ProjectBoard) {
+	p := getProject(ctx, mode)
+	if ctx.Written() {
+		return nil, nil
+	}
+
+	board, err := models.GetProjectBoard(ctx.ParamsInt64(":boardID"))
+	if err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetProjectBoard", err)
+		}
+		return nil, nil
+	}
+	if board.ProjectID != p.ID {
+		ctx.NotFound()
+		return nil, nil
+	}
+	return p, board
+}
+
+// EditProjectBoard edit a board of a project
+func EditProjectBoard(ctx *context.APIContext) {
+	// swagger:operation PATCH /projects/{id}/boards/{boardID} project projectEditBoard
+	// ---
+	// summary: Edit a board of a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: boardID
+	//   in: path
+	//   description: id of the board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectBoardOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoard"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.EditProjectBoardOption)
+	p, board := getProjectBoard(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	if form.Color != nil && len(*form.Color) > 0 && !models.BoardColorPattern.MatchString(*form.Color) {
+		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid color")
+		return
+	}
+
+	if form.Title != nil && len(*form.Title) > 0 {
+		board.Title = *form.Title
+	}
+	if form.Color != nil {
+		board.Color = *form.Color
+	}
+	if form.Sorting != nil {
+		board.Sorting = *form.Sorting
+	}
+	if err := models.UpdateProjectBoard(board); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateProjectBoard", err)
+		return
+	}
+
+	if form.Default != nil && *form.Default != board.Default {
+		defaultID := int64(0)
+		if *form.Default {
+			defaultID = board.ID
+		}
+		if err := models.SetDefaultBoard(p.ID, defaultID); err != nil {
+			ctx.Error(http.StatusInternalServerError, "SetDefaultBoard", err)
+			return
+		}
+		board.Default = *form.Default
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToProjectBoard(board))
+}
+
+// DeleteProjectBoard delete a board of a project
+func DeleteProjectBoard(ctx *context.APIContext) {
+	// swagger:operation DELETE /projects/{id}/boards/{boardID} project projectDeleteBoard
+	// ---
+	// summary: Delete a board of a project, its cards are not on a board anymore
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: boardID
+	//   in: path
+	//   description: id of the board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	_, board := getProjectBoard(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectBoardByID(board.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteProjectBoardByID", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,312 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// ListProjectCards list the cards of a project
+func ListProjectCards(ctx *context.APIContext) {
+	// swagger:operation GET /projects/{id}/cards project projectListCards
+	// ---
+	// summary: List the issues and pull requests of a project which the user can read
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: board_id
+	//   in: query
+	//   description: only list the cards on this board, 0 for the cards which are not on a board
+	//   type: integer
+	//   format: int64
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectCardList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := getProject(ctx, models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+
+	// the projects of users and organizations may hold issues from any repository
+	repoCond, err := models.ReadableProjectIssuesCond(p.ID, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ReadableProjectIssuesCond", err)
+		return
+	}
+
+	opts := &models.IssuesOptions{
+		ListOptions: utils.GetListOptions(ctx),
+		ProjectID:   p.ID,
+		RepoCond:    repoCond,
+	}
+	if ctx.FormString("board_id") != "" {
+		opts.ProjectBoardID = ctx.FormInt64("board_id")
+		if opts.ProjectBoardID == 0 {
+			opts.ProjectBoardID = -1 // issues which are not on a board
+		}
+	}
+
+	issues, err := models.Issues(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "Issues", err)
+		return
+	}
+
+	apiCards := make([]*api.ProjectCard, len(issues))
+	for i := range issues {
+		apiCards[i] = convert.ToProjectCard(issues[i])
+	}
+
+	ctx.JSON(http.StatusOK, &apiCards)
+}
+
+// getCardBoard returns the board of the project to put a card on, a placeholder if boardID is 0
+func getCardBoard(ctx *context.APIContext, p *models.Project, boardID int64) *models.ProjectBoard {
+	if boardID == 0 {
+		return &models.ProjectBoard{ProjectID: p.ID}
+	}
+
+	board, err := models.GetProjectBoard(boardID)
+	if err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", "Board does not exist")
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetProjectBoard", err)
+		}
+		return nil
+	}
+	if board.ProjectID != p.ID {
+		ctx.Error(http.StatusUnprocessableEntity, "", "Board does not exist")
+		return nil
+	}
+	return board
+}
+
+// AddProjectCard add an issue or a pull request to a project
+func AddProjectCard(ctx *context.APIContext) {
+	// swagger:operation POST /projects/{id}/cards project projectAddCard
+	// ---
+	// summary: Add an issue or a pull request to a project, removing it from its current project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/AddProjectCardOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectCard"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.AddProjectCardOption)
+	p := getProject(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	issue, err := models.GetIssueByID(form.IssueID)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", "Issue does not exist")
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByID", err)
+		}
+		return
+	}
+	canAdd, err := models.CanUserAddIssueToProject(p, issue, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "CanUserAddIssueToProject", err)
+		return
+	}
+	if !canAdd {
+		// do not disclose the issues the doer cannot read
+		ctx.Error(http.StatusUnprocessableEntity, "", "Issue does not exist")
+		return
+	}
+
+	board := getCardBoard(ctx, p, form.BoardID)
+	if ctx.Written() {
+		return
+	}
+
+	if issue.ProjectID() != p.ID {
+		if err := models.ChangeProjectAssign(issue, ctx.User, p.ID); err != nil {
+			ctx.Error(http.StatusInternalServerError, "ChangeProjectAssign", err)
+			return
+		}
+	}
+	if err := models.MoveIssueAcrossProjectBoards(issue, board); err != nil {
+		ctx.Error(http.StatusInternalServerError, "MoveIssueAcrossProjectBoards", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, convert.ToProjectCard(issue))
+}
+
+// getProjectCard returns the project and its issue from the URL if the doer can read the issue
+// and has at least the access mode to the project
+func getProjectCard(ctx *context.APIContext, mode models.AccessMode) (*models.Project, *models.Issue) {
+	p := getProject(ctx, mode)
+	if ctx.Written() {
+		return nil, nil
+	}
+
+	issue, err := models.GetIssueByID(ctx.ParamsInt64(":issueID"))
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByID", err)
+		}
+		return nil, nil
+	}
+	if issue.ProjectID() != p.ID {
+		ctx.NotFound()
+		return nil, nil
+	}
+
+	issues, err := models.IssueList{issue}.FilterReadableByUser(ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FilterReadableByUser", err)
+		return nil, nil
+	}
+	if len(issues) == 0 {
+		ctx.NotFound()
+		return nil, nil
+	}
+	return p, issue
+}
+
+// MoveProjectCard move a card of a project to another board
+func MoveProjectCard(ctx *context.APIContext) {
+	// swagger:operation PATCH /projects/{id}/cards/{issueID} project projectMoveCard
+	// ---
+	// summary: Move a card of a project to another board
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: issueID
+	//   in: path
+	//   description: id of the issue or the pull request of the card
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/MoveProjectCardOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectCard"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.MoveProjectCardOption)
+	p, issue := getProjectCard(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	board := getCardBoard(ctx, p, form.BoardID)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.MoveIssueAcrossProjectBoards(issue, board); err != nil {
+		ctx.Error(http.StatusInternalServerError, "MoveIssueAcrossProjectBoards", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToProjectCard(issue))
+}
+
+// RemoveProjectCard remove an issue or a pull request from a project
+func RemoveProjectCard(ctx *context.APIContext) {
+	// swagger:operation DELETE /projects/{id}/cards/{issueID} project projectRemoveCard
+	// ---
+	// summary: Remove an issue or a pull request from a project
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: issueID
+	//   in: path
+	//   description: id of the issue or the pull request of the card
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	_, issue := getProjectCard(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.ChangeProjectAssign(issue, ctx.User, 0); err != nil {
+		ctx.Error(http.StatusInternalServerError, "ChangeProjectAssign", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
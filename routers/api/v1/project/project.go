@@ -0,0 +1,467 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+var projectBoardTypes = map[string]models.ProjectBoardType{
+	"":             models.ProjectBoardTypeNone,
+	"none":         models.ProjectBoardTypeNone,
+	"basic_kanban": models.ProjectBoardTypeBasicKanban,
+	"bug_triage":   models.ProjectBoardTypeBugTriage,
+}
+
+// ListUserProjects list the projects of a user
+func ListUserProjects(ctx *context.APIContext) {
+	// swagger:operation GET /users/{username}/projects project projectListUserProjects
+	// ---
+	// summary: List the projects of a user
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: username
+	//   in: path
+	//   description: username of the user
+	//   type: string
+	//   required: true
+	// - name: state
+	//   in: query
+	//   description: Project state, Recognised values are open, closed and all. Defaults to "open"
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	listProjects(ctx, models.ProjectSearchOptions{
+		OwnerID: ctx.ProjectOwner.Owner.ID,
+		Type:    models.ProjectTypeIndividual,
+	})
+}
+
+// ListOrgProjects list the projects of an organization
+func ListOrgProjects(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/projects project projectListOrgProjects
+	// ---
+	// summary: List the projects of an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: state
+	//   in: query
+	//   description: Project state, Recognised values are open, closed and all. Defaults to "open"
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	listProjects(ctx, models.ProjectSearchOptions{
+		OwnerID: ctx.ProjectOwner.Owner.ID,
+		Type:    models.ProjectTypeOrganization,
+	})
+}
+
+// ListRepoProjects list the projects of a repository
+func ListRepoProjects(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects project projectListRepoProjects
+	// ---
+	// summary: List the projects of a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: state
+	//   in: query
+	//   description: Project state, Recognised values are open, closed and all. Defaults to "open"
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	listProjects(ctx, models.ProjectSearchOptions{
+		RepoID: ctx.Repo.Repository.ID,
+		Type:   models.ProjectTypeRepository,
+	})
+}
+
+func listProjects(ctx *context.APIContext, opts models.ProjectSearchOptions) {
+	listOptions := utils.GetListOptions(ctx)
+	opts.Page = listOptions.Page
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	opts.PageSize = listOptions.PageSize
+
+	switch ctx.FormString("state") {
+	case "closed":
+		opts.IsClosed = util.OptionalBoolTrue
+	case "all":
+		opts.IsClosed = util.OptionalBoolNone
+	default:
+		opts.IsClosed = util.OptionalBoolFalse
+	}
+
+	projects, total, err := models.GetProjects(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProjects", err)
+		return
+	}
+
+	apiProjects := make([]*api.Project, len(projects))
+	for i := range projects {
+		apiProjects[i] = convert.ToProject(projects[i], ctx.User)
+	}
+
+	ctx.SetTotalCountHeader(total)
+	ctx.JSON(http.StatusOK, &apiProjects)
+}
+
+// CreateUserProject create a project for the authenticated user
+func CreateUserProject(ctx *context.APIContext) {
+	// swagger:operation POST /user/projects project projectCreateUserProject
+	// ---
+	// summary: Create a project for the authenticated user
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Project"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateProjectOption)
+	createProject(ctx, form, &models.Project{
+		OwnerID: ctx.ProjectOwner.Owner.ID,
+		Owner:   ctx.ProjectOwner.Owner,
+		Type:    models.ProjectTypeIndividual,
+	})
+}
+
+// CreateOrgProject create a project for an organization
+func CreateOrgProject(ctx *context.APIContext) {
+	// swagger:operation POST /orgs/{org}/projects project projectCreateOrgProject
+	// ---
+	// summary: Create a project for an organization
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Project"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	if !ctx.ProjectOwner.CanWrite() {
+		ctx.Error(http.StatusForbidden, "", "Must be able to write the projects of the organization")
+		return
+	}
+
+	form := web.GetForm(ctx).(*api.CreateProjectOption)
+	createProject(ctx, form, &models.Project{
+		OwnerID: ctx.ProjectOwner.Owner.ID,
+		Owner:   ctx.ProjectOwner.Owner,
+		Type:    models.ProjectTypeOrganization,
+	})
+}
+
+// CreateRepoProject create a project for a repository
+func CreateRepoProject(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/projects project projectCreateRepoProject
+	// ---
+	// summary: Create a project for a repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Project"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	if ctx.Repo.Repository.IsArchived {
+		ctx.Error(http.StatusForbidden, "", "Repository is archived")
+		return
+	}
+
+	form := web.GetForm(ctx).(*api.CreateProjectOption)
+	createProject(ctx, form, &models.Project{
+		RepoID: ctx.Repo.Repository.ID,
+		Repo:   ctx.Repo.Repository,
+		Type:   models.ProjectTypeRepository,
+	})
+}
+
+func createProject(ctx *context.APIContext, form *api.CreateProjectOption, p *models.Project) {
+	boardType, ok := projectBoardTypes[form.BoardType]
+	if !ok {
+		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid board type")
+		return
+	}
+
+	p.Title = form.Title
+	p.Description = form.Description
+	p.BoardType = boardType
+	p.CreatorID = ctx.User.ID
+	if err := models.NewProject(p); err != nil {
+		ctx.Error(http.StatusInternalServerError, "NewProject", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, convert.ToProject(p, ctx.User))
+}
+
+// getProject returns the project from the URL if the doer has at least the access mode to it
+func getProject(ctx *context.APIContext, mode models.AccessMode) *models.Project {
+	if unit.TypeProjects.UnitGlobalDisabled() {
+		ctx.NotFound()
+		return nil
+	}
+
+	p, err := models.GetProjectByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrProjectNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetProjectByID", err)
+		}
+		return nil
+	}
+
+	accessMode, err := models.GetUserProjectAccessMode(p, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetUserProjectAccessMode", err)
+		return nil
+	}
+	if accessMode < models.AccessModeRead {
+		ctx.NotFound()
+		return nil
+	}
+	if accessMode < mode || (mode >= models.AccessModeWrite && p.Repo != nil && p.Repo.IsArchived) {
+		ctx.Error(http.StatusForbidden, "", "Must be able to write the project")
+		return nil
+	}
+	return p
+}
+
+// GetProject get a project
+func GetProject(ctx *context.APIContext) {
+	// swagger:operation GET /projects/{id} project projectGetProject
+	// ---
+	// summary: Get a project
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := getProject(ctx, models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToProject(p, ctx.User))
+}
+
+// EditProject edit a project
+func EditProject(ctx *context.APIContext) {
+	// swagger:operation PATCH /projects/{id} project projectEditProject
+	// ---
+	// summary: Edit a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.EditProjectOption)
+	p := getProject(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	if form.State != nil && *form.State != string(api.StateOpen) && *form.State != string(api.StateClosed) {
+		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid state")
+		return
+	}
+
+	if form.Title != nil && len(*form.Title) > 0 {
+		p.Title = *form.Title
+	}
+	if form.Description != nil {
+		p.Description = *form.Description
+	}
+	if err := models.UpdateProject(p); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateProject", err)
+		return
+	}
+
+	if form.State != nil {
+		if isClosed := *form.State == string(api.StateClosed); isClosed != p.IsClosed {
+			if err := models.ChangeProjectStatus(p, isClosed); err != nil {
+				ctx.Error(http.StatusInternalServerError, "ChangeProjectStatus", err)
+				return
+			}
+		}
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToProject(p, ctx.User))
+}
+
+// DeleteProject delete a project
+func DeleteProject(ctx *context.APIContext) {
+	// swagger:operation DELETE /projects/{id} project projectDeleteProject
+	// ---
+	// summary: Delete a project
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := getProject(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectByID(p.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteProjectByID", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
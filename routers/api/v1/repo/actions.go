@@ -0,0 +1,270 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"io"
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	actions_service "code.gitea.io/gitea/services/actions"
+)
+
+// ListActionRuns lists the workflow runs of a repository
+func ListActionRuns(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/runs repository repoListActionRuns
+	// ---
+	// summary: List a repository's workflow runs
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: status
+	//   in: query
+	//   description: filter by status
+	//   type: string
+	//   enum: [waiting, running, success, failure, cancelled]
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionRunList"
+
+	listOptions := utils.GetListOptions(ctx)
+
+	runs, count, err := actions_model.FindRuns(ctx, &actions_model.FindRunOptions{
+		ListOptions: listOptions,
+		RepoID:      ctx.Repo.Repository.ID,
+		Status:      actions_model.StatusFromString(ctx.FormTrim("status")),
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindRuns", err)
+		return
+	}
+
+	apiRuns := make([]*api.ActionRun, 0, len(runs))
+	for _, run := range runs {
+		apiRuns = append(apiRuns, convert.ToActionRun(ctx.Repo.Repository, run, nil))
+	}
+
+	ctx.SetLinkHeader(int(count), listOptions.PageSize)
+	ctx.SetTotalCountHeader(count)
+	ctx.JSON(http.StatusOK, &apiRuns)
+}
+
+func getActionRun(ctx *context.APIContext) *actions_model.ActionRun {
+	run, err := actions_model.GetRunByRepoAndID(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64("run"))
+	if err != nil {
+		if err == actions_model.ErrRunNotExist {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetRunByRepoAndID", err)
+		}
+		return nil
+	}
+	return run
+}
+
+// GetActionRun gets a workflow run and its jobs
+func GetActionRun(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/runs/{run} repository repoGetActionRun
+	// ---
+	// summary: Get a workflow run with its jobs
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: run
+	//   in: path
+	//   description: id of the run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionRun"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	run := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	jobs, err := actions_model.GetRunJobsByRunID(ctx, run.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetRunJobsByRunID", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToActionRun(ctx.Repo.Repository, run, jobs))
+}
+
+// CancelActionRun cancels all unfinished jobs of a workflow run
+func CancelActionRun(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/actions/runs/{run}/cancel repository repoCancelActionRun
+	// ---
+	// summary: Cancel a workflow run
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: run
+	//   in: path
+	//   description: id of the run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionRun"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	run := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := actions_service.CancelRun(ctx, run); err != nil {
+		ctx.Error(http.StatusInternalServerError, "CancelRun", err)
+		return
+	}
+
+	jobs, err := actions_model.GetRunJobsByRunID(ctx, run.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetRunJobsByRunID", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToActionRun(ctx.Repo.Repository, run, jobs))
+}
+
+// GetActionJobLogs returns the log of a job
+func GetActionJobLogs(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/jobs/{job}/logs repository repoGetActionJobLogs
+	// ---
+	// summary: Get the log of a workflow job
+	// produces:
+	// - text/plain
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: job
+	//   in: path
+	//   description: id of the job
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     description: the log of the job
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	job, err := actions_model.GetRunJobByID(ctx, ctx.ParamsInt64("job"))
+	if err != nil {
+		if err == actions_model.ErrRunJobNotExist {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetRunJobByID", err)
+		}
+		return
+	}
+	if job.RepoID != ctx.Repo.Repository.ID {
+		ctx.NotFound()
+		return
+	}
+
+	rc := actions_service.OpenJobLog(job)
+	defer rc.Close()
+
+	ctx.Resp.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	ctx.Status(http.StatusOK)
+	if _, err := io.Copy(ctx.Resp, rc); err != nil {
+		log.Error("Error streaming log of job %d: %v", job.ID, err)
+	}
+}
+
+// GetActionRunnerRegistrationToken returns the token to register runners for the repository
+func GetActionRunnerRegistrationToken(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/runners/registration-token repository repoGetActionRunnerRegistrationToken
+	// ---
+	// summary: Get the token to register runners for a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionRunnerRegistrationToken"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	t, err := actions_model.GetOrCreateRunnerToken(ctx, 0, ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetOrCreateRunnerToken", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &api.ActionRunnerRegistrationToken{Token: t.Token})
+}
@@ -11,6 +11,8 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/git"
@@ -349,6 +351,10 @@ func ListBranchProtections(ctx *context.APIContext) {
 	//   description: name of the repo
 	//   type: string
 	//   required: true
+	// - name: branch
+	//   in: query
+	//   description: only list the protections matching this branch, the first one applies to it
+	//   type: string
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/BranchProtectionList"
@@ -359,6 +365,9 @@ func ListBranchProtections(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "GetProtectedBranches", err)
 		return
 	}
+	if branch := ctx.FormString("branch"); branch != "" {
+		bps = bps.Match(branch)
+	}
 	apiBps := make([]*api.BranchProtection, len(bps))
 	for i := range bps {
 		apiBps[i] = convert.ToBranchProtection(bps[i])
@@ -404,8 +413,13 @@ func CreateBranchProtection(ctx *context.APIContext) {
 	form := web.GetForm(ctx).(*api.CreateBranchProtectionOption)
 	repo := ctx.Repo.Repository
 
-	// Currently protection must match an actual branch
-	if !git.IsBranchExist(ctx.Repo.Repository.RepoPath(), form.BranchName) {
+	if !models.IsValidProtectedBranchRuleName(form.BranchName) {
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("invalid branch name or glob pattern: %s", form.BranchName))
+		return
+	}
+
+	// Protections of a single branch must match an actual branch
+	if !models.IsProtectedBranchRuleNamePattern(form.BranchName) && !git.IsBranchExist(ctx.Repo.Repository.RepoPath(), form.BranchName) {
 		ctx.NotFound()
 		return
 	}
@@ -485,6 +499,7 @@ func CreateBranchProtection(ctx *context.APIContext) {
 	protectBranch = &models.ProtectedBranch{
 		RepoID:                        ctx.Repo.Repository.ID,
 		BranchName:                    form.BranchName,
+		Priority:                      form.Priority,
 		CanPush:                       form.EnablePush,
 		EnableWhitelist:               form.EnablePush && form.EnablePushWhitelist,
 		EnableMergeWhitelist:          form.EnableMergeWhitelist,
@@ -495,11 +510,13 @@ func CreateBranchProtection(ctx *context.APIContext) {
 		RequiredApprovals:             requiredApprovals,
 		BlockOnRejectedReviews:        form.BlockOnRejectedReviews,
 		BlockOnOfficialReviewRequests: form.BlockOnOfficialReviewRequests,
+		RequireCodeOwnerReviews:       form.RequireCodeOwnerReviews,
 		DismissStaleApprovals:         form.DismissStaleApprovals,
 		RequireSignedCommits:          form.RequireSignedCommits,
 		ProtectedFilePatterns:         form.ProtectedFilePatterns,
 		UnprotectedFilePatterns:       form.UnprotectedFilePatterns,
 		BlockOnOutdatedBranch:         form.BlockOnOutdatedBranch,
+		EnableMergeQueue:              form.EnableMergeQueue,
 	}
 
 	err = models.UpdateProtectBranch(ctx.Repo.Repository, protectBranch, models.WhitelistOptions{
@@ -514,9 +531,10 @@ func CreateBranchProtection(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "UpdateProtectBranch", err)
 		return
 	}
+	audit.Record(admin.AuditBranchProtectionCreate, ctx.User, ctx.RemoteAddr(), protectBranch, nil, audit.ProtectedBranchSnapshot(protectBranch))
 
-	if err = pull_service.CheckPrsForBaseBranch(ctx.Repo.Repository, protectBranch.BranchName); err != nil {
-		ctx.Error(http.StatusInternalServerError, "CheckPrsForBaseBranch", err)
+	if err = pull_service.CheckPrsForProtectedBranch(ctx.Repo.Repository, protectBranch); err != nil {
+		ctx.Error(http.StatusInternalServerError, "CheckPrsForProtectedBranch", err)
 		return
 	}
 
@@ -583,6 +601,11 @@ func EditBranchProtection(ctx *context.APIContext) {
 		ctx.NotFound()
 		return
 	}
+	before := audit.ProtectedBranchSnapshot(protectBranch)
+
+	if form.Priority != nil {
+		protectBranch.Priority = *form.Priority
+	}
 
 	if form.EnablePush != nil {
 		if !*form.EnablePush {
@@ -632,6 +655,10 @@ func EditBranchProtection(ctx *context.APIContext) {
 		protectBranch.BlockOnOfficialReviewRequests = *form.BlockOnOfficialReviewRequests
 	}
 
+	if form.RequireCodeOwnerReviews != nil {
+		protectBranch.RequireCodeOwnerReviews = *form.RequireCodeOwnerReviews
+	}
+
 	if form.DismissStaleApprovals != nil {
 		protectBranch.DismissStaleApprovals = *form.DismissStaleApprovals
 	}
@@ -652,6 +679,10 @@ func EditBranchProtection(ctx *context.APIContext) {
 		protectBranch.BlockOnOutdatedBranch = *form.BlockOnOutdatedBranch
 	}
 
+	if form.EnableMergeQueue != nil {
+		protectBranch.EnableMergeQueue = *form.EnableMergeQueue
+	}
+
 	var whitelistUsers []int64
 	if form.PushWhitelistUsernames != nil {
 		whitelistUsers, err = models.GetUserIDsByNames(form.PushWhitelistUsernames, false)
@@ -750,9 +781,10 @@ func EditBranchProtection(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "UpdateProtectBranch", err)
 		return
 	}
+	audit.Record(admin.AuditBranchProtectionUpdate, ctx.User, ctx.RemoteAddr(), protectBranch, before, audit.ProtectedBranchSnapshot(protectBranch))
 
-	if err = pull_service.CheckPrsForBaseBranch(ctx.Repo.Repository, protectBranch.BranchName); err != nil {
-		ctx.Error(http.StatusInternalServerError, "CheckPrsForBaseBranch", err)
+	if err = pull_service.CheckPrsForProtectedBranch(ctx.Repo.Repository, protectBranch); err != nil {
+		ctx.Error(http.StatusInternalServerError, "CheckPrsForProtectedBranch", err)
 		return
 	}
 
@@ -815,6 +847,7 @@ func DeleteBranchProtection(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteProtectedBranch", err)
 		return
 	}
+	audit.Record(admin.AuditBranchProtectionDelete, ctx.User, ctx.RemoteAddr(), bp, audit.ProtectedBranchSnapshot(bp), nil)
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -0,0 +1,264 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/log"
+	base "code.gitea.io/gitea/modules/migration"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/services/task"
+)
+
+// CreateExport starts exporting a repository to an archive
+func CreateExport(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/exports repository repoCreateExport
+	// ---
+	// summary: Start exporting a repository to an archive
+	// description: Only the archive of the latest export is kept. If an export is already queued or running, it is returned instead.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "202":
+	//     "$ref": "#/responses/RepoTask"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	t, err := task.ExportRepository(ctx.User, ctx.Repo.Repository)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ExportRepository", err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, convert.ToRepoTask(t, ctx.Locale))
+}
+
+func getExportTask(ctx *context.APIContext) *models.Task {
+	t, err := models.GetRepoTask(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"), api.TaskTypeExportRepo)
+	if err != nil {
+		if models.IsErrTaskDoesNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetRepoTask", err)
+		}
+		return nil
+	}
+	return t
+}
+
+// GetExport returns the status of an export of a repository
+func GetExport(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/exports/{id} repository repoGetExport
+	// ---
+	// summary: Get the status of an export of a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the export
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/RepoTask"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	t := getExportTask(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToRepoTask(t, ctx.Locale))
+}
+
+// GetExportArchive downloads the archive of a finished export of a repository
+func GetExportArchive(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/exports/{id}/archive repository repoGetExportArchive
+	// ---
+	// summary: Download the archive of a finished export of a repository
+	// produces:
+	// - application/zip
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the export
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   200:
+	//     description: success
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	t := getExportTask(ctx)
+	if ctx.Written() {
+		return
+	}
+	if t.Status != api.TaskStatusFinished {
+		ctx.NotFound()
+		return
+	}
+
+	fr, err := storage.RepoExports.Open(t.ArchiveRelativePath())
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "Open", err)
+		return
+	}
+	defer fr.Close()
+
+	ctx.ServeStream(fr, fmt.Sprintf("%s-%s.zip", ctx.Repo.Repository.OwnerName, ctx.Repo.Repository.Name))
+}
+
+// Import imports a repository from an archive created by an export
+func Import(ctx *context.APIContext) {
+	// swagger:operation POST /repos/import repository repoImport
+	// ---
+	// summary: Import a repository from an archive created by an export
+	// consumes:
+	// - multipart/form-data
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: archive
+	//   in: formData
+	//   description: archive to import
+	//   type: file
+	//   required: true
+	// - name: repo_name
+	//   in: formData
+	//   description: name of the repository to create
+	//   type: string
+	//   required: true
+	// - name: repo_owner
+	//   in: formData
+	//   description: name of the user or organization owning the repository, defaults to the authenticated user
+	//   type: string
+	// - name: description
+	//   in: formData
+	//   description: description of the repository to create
+	//   type: string
+	// - name: private
+	//   in: formData
+	//   description: whether the repository is private
+	//   type: boolean
+	// responses:
+	//   "202":
+	//     "$ref": "#/responses/RepoTask"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "409":
+	//     description: The repository with the same name already exists.
+	//   "413":
+	//     description: The archive is larger than the import limits.
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	if setting.Repository.DisableMigrations {
+		ctx.Error(http.StatusForbidden, "MigrationsGlobalDisabled", fmt.Errorf("the site administrator has disabled migrations"))
+		return
+	}
+
+	repoOwner := ctx.User
+	if ownerName := ctx.FormString("repo_owner"); len(ownerName) != 0 {
+		var err error
+		repoOwner, err = models.GetUserByName(ownerName)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "", err)
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetUserByName", err)
+			}
+			return
+		}
+	}
+
+	if !ctx.User.IsAdmin {
+		if !repoOwner.IsOrganization() && ctx.User.ID != repoOwner.ID {
+			ctx.Error(http.StatusForbidden, "", "Given user is not an organization.")
+			return
+		}
+
+		if repoOwner.IsOrganization() {
+			// Check ownership of organization.
+			isOwner, err := models.OrgFromUser(repoOwner).IsOwnedBy(ctx.User.ID)
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, "IsOwnedBy", err)
+				return
+			} else if !isOwner {
+				ctx.Error(http.StatusForbidden, "", "Given user is not owner of organization.")
+				return
+			}
+		}
+	}
+
+	repoName := ctx.FormString("repo_name")
+	if len(repoName) == 0 {
+		ctx.Error(http.StatusUnprocessableEntity, "", "repo_name is required")
+		return
+	}
+
+	file, header, err := ctx.Req.FormFile("archive")
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Sprintf("archive is required: %v", err))
+		return
+	}
+	defer file.Close()
+
+	t, err := task.ImportRepository(ctx.User, repoOwner, base.MigrateOptions{
+		CloneAddr:   header.Filename,
+		RepoName:    repoName,
+		Description: ctx.FormString("description"),
+		Private:     ctx.FormBool("private") || setting.Repository.ForcePrivate,
+	}, file, header.Size)
+	if err != nil {
+		handleMigrateError(ctx, repoOwner, header.Filename, err)
+		return
+	}
+
+	log.Trace("Repository import queued: %s/%s", repoOwner.Name, repoName)
+	ctx.JSON(http.StatusAccepted, convert.ToRepoTask(t, ctx.Locale))
+}
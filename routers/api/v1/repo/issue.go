@@ -50,7 +50,7 @@ func SearchIssues(ctx *context.APIContext) {
 	//   type: string
 	// - name: q
 	//   in: query
-	//   description: search string
+	//   description: "search string, which may contain the qualifiers is:, label:, -label:, milestone:, author:, assignee:, mentions:, review-requested:, updated:, created: and sort:"
 	//   type: string
 	// - name: priority_repo_id
 	//   in: query
@@ -181,10 +181,12 @@ func SearchIssues(ctx *context.APIContext) {
 	var issues []*models.Issue
 	var filteredCount int64
 
-	keyword := ctx.FormTrim("q")
-	if strings.IndexByte(keyword, 0) >= 0 {
-		keyword = ""
+	q := ctx.FormTrim("q")
+	if strings.IndexByte(q, 0) >= 0 {
+		q = ""
 	}
+	query := issue_indexer.ParseQuery(q)
+	keyword := query.Keyword
 	var issueIDs []int64
 	if len(keyword) > 0 && len(repoIDs) > 0 {
 		if issueIDs, err = issue_indexer.SearchIssuesByKeyword(repoIDs, keyword); err != nil {
@@ -258,17 +260,25 @@ func SearchIssues(ctx *context.APIContext) {
 			issuesOpt.ReviewRequestedID = ctx.User.ID
 		}
 
-		if issues, err = models.Issues(issuesOpt); err != nil {
-			ctx.Error(http.StatusInternalServerError, "Issues", err)
+		matchable, err := query.Apply(issuesOpt, ctx.User)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "ParseQuery", err)
 			return
 		}
 
-		issuesOpt.ListOptions = db.ListOptions{
-			Page: -1,
-		}
-		if filteredCount, err = models.CountIssues(issuesOpt); err != nil {
-			ctx.Error(http.StatusInternalServerError, "CountIssues", err)
-			return
+		if matchable {
+			if issues, err = models.Issues(issuesOpt); err != nil {
+				ctx.Error(http.StatusInternalServerError, "Issues", err)
+				return
+			}
+
+			issuesOpt.ListOptions = db.ListOptions{
+				Page: -1,
+			}
+			if filteredCount, err = models.CountIssues(issuesOpt); err != nil {
+				ctx.Error(http.StatusInternalServerError, "CountIssues", err)
+				return
+			}
 		}
 	}
 
@@ -705,7 +715,7 @@ func EditIssue(ctx *context.APIContext) {
 	//     "$ref": "#/responses/error"
 
 	form := web.GetForm(ctx).(*api.EditIssueOption)
-	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	issue, err := models.GetIssueByIndexCtx(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
 	if err != nil {
 		if models.IsErrIssueNotExist(err) {
 			ctx.NotFound()
@@ -871,7 +881,7 @@ func UpdateIssueDeadline(ctx *context.APIContext) {
 	//   "404":
 	//     "$ref": "#/responses/notFound"
 	form := web.GetForm(ctx).(*api.EditDeadlineOption)
-	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	issue, err := models.GetIssueByIndexCtx(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
 	if err != nil {
 		if models.IsErrIssueNotExist(err) {
 			ctx.NotFound()
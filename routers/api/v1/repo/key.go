@@ -11,6 +11,8 @@ import (
 	"net/url"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/setting"
@@ -234,6 +236,7 @@ func CreateDeployKey(ctx *context.APIContext) {
 	}
 
 	key.Content = content
+	audit.Record(admin.AuditDeployKeyAdd, ctx.User, ctx.RemoteAddr(), key, nil, audit.DeployKeySnapshot(key))
 	apiLink := composeDeployKeysAPILink(ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
 	ctx.JSON(http.StatusCreated, convert.ToDeployKey(apiLink, key))
 }
@@ -266,7 +269,17 @@ func DeleteDeploykey(ctx *context.APIContext) {
 	//   "403":
 	//     "$ref": "#/responses/forbidden"
 
-	if err := models.DeleteDeployKey(ctx.User, ctx.ParamsInt64(":id")); err != nil {
+	key, err := models.GetDeployKeyByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrDeployKeyNotExist(err) {
+			ctx.Status(http.StatusNoContent)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetDeployKeyByID", err)
+		}
+		return
+	}
+
+	if err := models.DeleteDeployKey(ctx.User, key.ID); err != nil {
 		if models.IsErrKeyAccessDenied(err) {
 			ctx.Error(http.StatusForbidden, "", "You do not have access to this key")
 		} else {
@@ -274,6 +287,7 @@ func DeleteDeploykey(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit.Record(admin.AuditDeployKeyRemove, ctx.User, ctx.RemoteAddr(), key, audit.DeployKeySnapshot(key), nil)
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -154,15 +154,6 @@ func Migrate(ctx *context.APIContext) {
 		GitServiceType: gitServiceType,
 		MirrorInterval: form.MirrorInterval,
 	}
-	if opts.Mirror {
-		opts.Issues = false
-		opts.Milestones = false
-		opts.Labels = false
-		opts.Comments = false
-		opts.PullRequests = false
-		opts.Releases = false
-	}
-
 	repo, err := repo_module.CreateRepository(ctx.User, repoOwner, models.CreateRepoOptions{
 		Name:           opts.RepoName,
 		Description:    opts.Description,
@@ -230,6 +221,8 @@ func handleMigrateError(ctx *context.APIContext, repoOwner *models.User, remoteA
 		ctx.Error(http.StatusUnprocessableEntity, "", err)
 	case base.IsErrNotSupported(err):
 		ctx.Error(http.StatusUnprocessableEntity, "", err)
+	case migrations.IsErrImportArchiveTooLarge(err):
+		ctx.Error(http.StatusRequestEntityTooLarge, "", err)
 	default:
 		err = util.NewStringURLSanitizedError(err, remoteAddr, true)
 		if strings.Contains(err.Error(), "Authentication failed") ||
@@ -705,6 +705,8 @@ func MergePullRequest(ctx *context.APIContext) {
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/empty"
+	//   "202":
+	//     "$ref": "#/responses/empty"
 	//   "405":
 	//     "$ref": "#/responses/empty"
 	//   "409":
@@ -801,7 +803,7 @@ func MergePullRequest(ctx *context.APIContext) {
 			} else if !isRepoAdmin {
 				ctx.Error(http.StatusMethodNotAllowed, "Merge", "Only repository admin can merge if not all checks are ok (force merge)")
 			}
-		} else {
+		} else if !form.MergeWhenChecksSucceed {
 			ctx.Error(http.StatusMethodNotAllowed, "PR is not ready to be merged", err)
 			return
 		}
@@ -835,6 +837,40 @@ func MergePullRequest(ctx *context.APIContext) {
 		message += "\n\n" + form.MergeMessageField
 	}
 
+	if form.MergeWhenChecksSucceed {
+		scheduled, err := pull_service.ScheduleAutoMerge(ctx.User, pr, models.MergeStyle(form.Do), message)
+		if err != nil {
+			if models.IsErrInvalidMergeStyle(err) {
+				ctx.Error(http.StatusMethodNotAllowed, "Invalid merge style", fmt.Errorf("%s is not allowed an allowed merge style for this repository", models.MergeStyle(form.Do)))
+				return
+			} else if models.IsErrPullAutoMergeAlreadyScheduled(err) {
+				ctx.Error(http.StatusConflict, "ScheduleAutoMerge", "PR is already scheduled to be merged automatically")
+				return
+			}
+			ctx.Error(http.StatusInternalServerError, "ScheduleAutoMerge", err)
+			return
+		} else if scheduled {
+			ctx.Status(http.StatusAccepted)
+			return
+		}
+	}
+
+	if pr.ProtectedBranch != nil && pr.ProtectedBranch.EnableMergeQueue {
+		if err := pull_service.AddToMergeQueue(pr, ctx.User, models.MergeStyle(form.Do), message); err != nil {
+			if models.IsErrInvalidMergeStyle(err) {
+				ctx.Error(http.StatusMethodNotAllowed, "Invalid merge style", fmt.Errorf("%s is not allowed an allowed merge style for this repository", models.MergeStyle(form.Do)))
+				return
+			} else if models.IsErrMergeQueueEntryAlreadyExist(err) {
+				ctx.Error(http.StatusConflict, "AddToMergeQueue", "PR is already in the merge queue")
+				return
+			}
+			ctx.Error(http.StatusInternalServerError, "AddToMergeQueue", err)
+			return
+		}
+		ctx.Status(http.StatusAccepted)
+		return
+	}
+
 	if err := pull_service.Merge(pr, ctx.User, ctx.Repo.GitRepo, models.MergeStyle(form.Do), message); err != nil {
 		if models.IsErrInvalidMergeStyle(err) {
 			ctx.Error(http.StatusMethodNotAllowed, "Invalid merge style", fmt.Errorf("%s is not allowed an allowed merge style for this repository", models.MergeStyle(form.Do)))
@@ -900,6 +936,78 @@ func MergePullRequest(ctx *context.APIContext) {
 	ctx.Status(http.StatusOK)
 }
 
+// CancelScheduledMerge cancels the scheduled automatic merge of a PR or removes it from the merge queue
+func CancelScheduledMerge(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/pulls/{index}/merge repository repoCancelScheduledMerge
+	// ---
+	// summary: Cancel the scheduled automatic merge of a pull request or remove it from the merge queue
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	pr, err := models.GetPullRequestByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrPullRequestNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPullRequestByIndex", err)
+		}
+		return
+	}
+
+	allowedMerge, err := pull_service.IsUserAllowedToMerge(pr, ctx.Repo.Permission, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "IsUserAllowedToMerge", err)
+		return
+	}
+	if !allowedMerge {
+		ctx.Error(http.StatusForbidden, "CancelScheduledMerge", "User not allowed to cancel the merge of the PR")
+		return
+	}
+
+	err = pull_service.CancelAutoMerge(pr, ctx.User)
+	if err == nil {
+		ctx.Status(http.StatusNoContent)
+		return
+	} else if !models.IsErrPullAutoMergeNotExist(err) {
+		ctx.Error(http.StatusInternalServerError, "CancelAutoMerge", err)
+		return
+	}
+
+	if err := pull_service.RemoveFromMergeQueue(pr, ctx.User); err != nil {
+		if models.IsErrMergeQueueEntryNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "RemoveFromMergeQueue", err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
 func parseCompareInfo(ctx *context.APIContext, form api.CreatePullRequestOption) (*models.User, *models.Repository, *git.Repository, *git.CompareInfo, string, string) {
 	baseRepo := ctx.Repo.Repository
 
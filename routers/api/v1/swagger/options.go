@@ -27,6 +27,8 @@ type swaggerParameterBodies struct {
 	CreateHookOption api.CreateHookOption
 	// in:body
 	EditHookOption api.EditHookOption
+	// in:body
+	RedeliverHookOption api.RedeliverHookOption
 
 	// in:body
 	EditGitHookOption api.EditGitHookOption
@@ -172,4 +174,31 @@ type swaggerParameterBodies struct {
 
 	// in:body
 	CreateWikiPageOptions api.CreateWikiPageOptions
+
+	// in:body
+	FollowRemoteOption api.FollowRemoteOption
+
+	// in:body
+	StarRemoteOption api.StarRemoteOption
+
+	// in:body
+	CommentRemoteOption api.CommentRemoteOption
+
+	// in:body
+	CreateProjectOption api.CreateProjectOption
+
+	// in:body
+	EditProjectOption api.EditProjectOption
+
+	// in:body
+	CreateProjectBoardOption api.CreateProjectBoardOption
+
+	// in:body
+	EditProjectBoardOption api.EditProjectBoardOption
+
+	// in:body
+	AddProjectCardOption api.AddProjectCardOption
+
+	// in:body
+	MoveProjectCardOption api.MoveProjectCardOption
 }
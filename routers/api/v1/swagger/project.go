@@ -0,0 +1,51 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package swagger
+
+import (
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// Project
+// swagger:response Project
+type swaggerResponseProject struct {
+	// in:body
+	Body api.Project `json:"body"`
+}
+
+// ProjectList
+// swagger:response ProjectList
+type swaggerResponseProjectList struct {
+	// in:body
+	Body []api.Project `json:"body"`
+}
+
+// ProjectBoard
+// swagger:response ProjectBoard
+type swaggerResponseProjectBoard struct {
+	// in:body
+	Body api.ProjectBoard `json:"body"`
+}
+
+// ProjectBoardList
+// swagger:response ProjectBoardList
+type swaggerResponseProjectBoardList struct {
+	// in:body
+	Body []api.ProjectBoard `json:"body"`
+}
+
+// ProjectCard
+// swagger:response ProjectCard
+type swaggerResponseProjectCard struct {
+	// in:body
+	Body api.ProjectCard `json:"body"`
+}
+
+// ProjectCardList
+// swagger:response ProjectCardList
+type swaggerResponseProjectCardList struct {
+	// in:body
+	Body []api.ProjectCard `json:"body"`
+}
@@ -99,6 +99,13 @@ type swaggerResponseHookList struct {
 	Body []api.Hook `json:"body"`
 }
 
+// HookDeliveryList
+// swagger:response HookDeliveryList
+type swaggerResponseHookDeliveryList struct {
+	// in:body
+	Body []api.HookDelivery `json:"body"`
+}
+
 // GitHook
 // swagger:response GitHook
 type swaggerResponseGitHook struct {
@@ -183,6 +190,27 @@ type swaggerResponseCommitStatusList struct {
 	Body []api.CommitStatus `json:"body"`
 }
 
+// ActionRun
+// swagger:response ActionRun
+type swaggerResponseActionRun struct {
+	// in:body
+	Body api.ActionRun `json:"body"`
+}
+
+// ActionRunList
+// swagger:response ActionRunList
+type swaggerResponseActionRunList struct {
+	// in:body
+	Body []api.ActionRun `json:"body"`
+}
+
+// ActionRunnerRegistrationToken
+// swagger:response ActionRunnerRegistrationToken
+type swaggerResponseActionRunnerRegistrationToken struct {
+	// in:body
+	Body api.ActionRunnerRegistrationToken `json:"body"`
+}
+
 // WatchInfo
 // swagger:response WatchInfo
 type swaggerResponseWatchInfo struct {
@@ -344,3 +372,10 @@ type swaggerWikiCommitList struct {
 	// in:body
 	Body api.WikiCommitList `json:"body"`
 }
+
+// RepoTask
+// swagger:response RepoTask
+type swaggerResponseRepoTask struct {
+	// in: body
+	Body api.RepoTask `json:"body"`
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/tracing"
+	"code.gitea.io/gitea/modules/translation"
+	"code.gitea.io/gitea/modules/web"
+
+	"gitea.com/go-chi/session"
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm"
+)
+
+func TestRequestDatabaseSpans(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	db.GetEngine(db.DefaultContext).(*xorm.Engine).AddHook(&db.TracingHook{})
+	setting.Names = []string{"english"}
+	setting.Langs = []string{"en-US"}
+	translation.InitLocales()
+
+	exporter := tracing.NewInMemoryExporter()
+	tracing.SetExporter(exporter, 1)
+	defer tracing.SetExporter(nil, 1)
+
+	r := web.NewRoute()
+	r.Use(web.RequestTracing())
+	r.Mount("/api/v1", Routes(session.Sessioner(session.Options{Provider: "memory"})))
+
+	req, err := http.NewRequest("GET", "http://localhost:3000/api/v1/users/user2", nil)
+	assert.NoError(t, err)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	spans := exporter.Spans()
+	if assert.NotEmpty(t, spans) {
+		root := spans[len(spans)-1]
+		assert.Equal(t, "GET /api/v1/users/{username}", root.Name)
+
+		// the user is looked up with the context of the request
+		var lookup *tracing.SpanData
+		for _, span := range spans[:len(spans)-1] {
+			if span.Name == "db SELECT" && span.ParentSpanID == root.SpanID {
+				lookup = span
+				break
+			}
+		}
+		if assert.NotNil(t, lookup) {
+			assert.Equal(t, root.TraceID, lookup.TraceID)
+			assert.Equal(t, tracing.SpanKindClient, lookup.Kind)
+			assert.Contains(t, lookup.Attributes["db.statement"], "`user`")
+		}
+	}
+}
@@ -12,7 +12,9 @@ import (
 	"strconv"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
 	"code.gitea.io/gitea/models/login"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
@@ -117,6 +119,7 @@ func CreateAccessToken(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "NewAccessToken", err)
 		return
 	}
+	audit.Record(admin.AuditAccessTokenCreate, ctx.User, ctx.RemoteAddr(), t, nil, audit.AccessTokenSnapshot(t))
 	ctx.JSON(http.StatusCreated, &api.AccessToken{
 		Name:           t.Name,
 		Token:          t.Token,
@@ -188,6 +191,7 @@ func DeleteAccessToken(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit.Record(admin.AuditAccessTokenDelete, ctx.User, ctx.RemoteAddr(), &models.AccessToken{ID: tokenID, UID: ctx.User.ID}, nil, nil)
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -0,0 +1,156 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"errors"
+	"net/http"
+
+	federation_model "code.gitea.io/gitea/models/federation"
+	ap "code.gitea.io/gitea/modules/activitypub"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+	federation_service "code.gitea.io/gitea/services/federation"
+)
+
+func handleFederationError(ctx *context.APIContext, name string, err error) {
+	switch {
+	case errors.Is(err, federation_service.ErrNotFederated):
+		ctx.Error(http.StatusForbidden, name, "the profile of the user must be public")
+	case errors.Is(err, federation_model.ErrActorNotExist):
+		ctx.NotFound()
+	case errors.Is(err, federation_service.ErrRemoteRequest),
+		errors.Is(err, federation_service.ErrInvalidActivity),
+		errors.Is(err, ap.ErrInvalidObject):
+		ctx.Error(http.StatusUnprocessableEntity, name, err)
+	default:
+		ctx.Error(http.StatusInternalServerError, name, err)
+	}
+}
+
+// FollowRemote follows a user or repository on another instance
+func FollowRemote(ctx *context.APIContext) {
+	// swagger:operation POST /user/federation/following user userFollowRemote
+	// ---
+	// summary: Follow a user or repository on another instance
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/FollowRemoteOption"
+	// responses:
+	//   "202":
+	//     "$ref": "#/responses/FederatedActor"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.FollowRemoteOption)
+
+	actor, err := federation_service.FollowRemote(ctx, ctx.User, form.Actor)
+	if err != nil {
+		handleFederationError(ctx, "FollowRemote", err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, convert.ToFederatedActor(actor))
+}
+
+// UnfollowRemote unfollows a user or repository on another instance
+func UnfollowRemote(ctx *context.APIContext) {
+	// swagger:operation DELETE /user/federation/following user userUnfollowRemote
+	// ---
+	// summary: Unfollow a user or repository on another instance
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: actor
+	//   in: query
+	//   description: IRI of the followed actor
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	if err := federation_service.UnfollowRemote(ctx, ctx.User, ctx.FormString("actor")); err != nil {
+		handleFederationError(ctx, "UnfollowRemote", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// StarRemote stars a repository on another instance
+func StarRemote(ctx *context.APIContext) {
+	// swagger:operation POST /user/federation/starred user userStarRemote
+	// ---
+	// summary: Star a repository on another instance
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/StarRemoteOption"
+	// responses:
+	//   "202":
+	//     "$ref": "#/responses/FederatedActor"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.StarRemoteOption)
+
+	actor, err := federation_service.StarRemote(ctx, ctx.User, form.Repository)
+	if err != nil {
+		handleFederationError(ctx, "StarRemote", err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, convert.ToFederatedActor(actor))
+}
+
+// CommentRemote comments on an issue on another instance
+func CommentRemote(ctx *context.APIContext) {
+	// swagger:operation POST /user/federation/comments user userCommentRemote
+	// ---
+	// summary: Comment on an issue on another instance
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CommentRemoteOption"
+	// responses:
+	//   "202":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CommentRemoteOption)
+
+	if err := federation_service.CommentRemote(ctx, ctx.User, form.Issue, form.Body); err != nil {
+		handleFederationError(ctx, "CommentRemote", err)
+		return
+	}
+	ctx.Status(http.StatusAccepted)
+}
@@ -15,7 +15,7 @@ import (
 // GetUserByParamsName get user by name
 func GetUserByParamsName(ctx *context.APIContext, name string) *models.User {
 	username := ctx.Params(name)
-	user, err := models.GetUserByName(username)
+	user, err := models.GetUserByNameCtx(ctx, username)
 	if err != nil {
 		if models.IsErrUserNotExist(err) {
 			if redirectUserID, err2 := user_model.LookupUserRedirect(username); err2 == nil {
@@ -20,6 +20,9 @@ import (
 	webhook_service "code.gitea.io/gitea/services/webhook"
 )
 
+// maxHookAttempts is the highest maximum number of attempts which can be set on a hook
+const maxHookAttempts = 100
+
 // GetOrgHook get an organization's webhook. If there is an error, write to
 // `ctx` accordingly and return the error
 func GetOrgHook(ctx *context.APIContext, orgID, hookID int64) (*webhook.Webhook, error) {
@@ -67,6 +70,16 @@ func CheckCreateHookOption(ctx *context.APIContext, form *api.CreateHookOption)
 		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid content type")
 		return false
 	}
+	return checkHookMaxAttempts(ctx, form.MaxAttempts)
+}
+
+// checkHookMaxAttempts check if the maximum number of attempts of a hook is valid. If invalid,
+// write the appropriate error to `ctx`. Return whether it is valid
+func checkHookMaxAttempts(ctx *context.APIContext, maxAttempts int) bool {
+	if maxAttempts < 0 || maxAttempts > maxHookAttempts {
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Sprintf("Invalid max attempts: must be between 0 and %d", maxHookAttempts))
+		return false
+	}
 	return true
 }
 
@@ -133,8 +146,9 @@ func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID
 			},
 			BranchFilter: form.BranchFilter,
 		},
-		IsActive: form.Active,
-		Type:     webhook.HookType(form.Type),
+		IsActive:    form.Active,
+		MaxAttempts: form.MaxAttempts,
+		Type:        webhook.HookType(form.Type),
 	}
 	if w.Type == webhook.SLACK {
 		channel, ok := form.Config["channel"]
@@ -267,9 +281,62 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *webhook.Webh
 		w.IsActive = *form.Active
 	}
 
+	if form.MaxAttempts != nil {
+		if !checkHookMaxAttempts(ctx, *form.MaxAttempts) {
+			return false
+		}
+		w.MaxAttempts = *form.MaxAttempts
+	}
+
 	if err := webhook.UpdateWebhook(w); err != nil {
 		ctx.Error(http.StatusInternalServerError, "UpdateWebhook", err)
 		return false
 	}
 	return true
 }
+
+// ListHookDeliveries list the deliveries of the webhook `w`, filtered by the
+// `status` query parameter. Writes to `ctx` accordingly
+func ListHookDeliveries(ctx *context.APIContext, w *webhook.Webhook) {
+	status := webhook.HookTaskStatus(ctx.FormString("status"))
+	switch status {
+	case "", webhook.HookTaskStatusPending, webhook.HookTaskStatusSucceeded, webhook.HookTaskStatusFailed:
+	default:
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Sprintf("Invalid status: %s", status))
+		return
+	}
+
+	tasks, count, err := webhook.FindHookTasks(&webhook.FindHookTasksOptions{
+		ListOptions: GetListOptions(ctx),
+		HookID:      w.ID,
+		Status:      status,
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindHookTasks", err)
+		return
+	}
+
+	deliveries := make([]*api.HookDelivery, len(tasks))
+	for i := range tasks {
+		deliveries[i] = convert.ToHookDelivery(tasks[i])
+	}
+
+	ctx.SetTotalCountHeader(count)
+	ctx.JSON(http.StatusOK, &deliveries)
+}
+
+// RedeliverHookDeliveries deliver again the failed deliveries of the webhook `w`
+// selected by `form`. Writes to `ctx` accordingly
+func RedeliverHookDeliveries(ctx *context.APIContext, form *api.RedeliverHookOption, w *webhook.Webhook) {
+	tasks, err := webhook_service.Redeliver(w, form.IDs)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "Redeliver", err)
+		return
+	}
+
+	deliveries := make([]*api.HookDelivery, len(tasks))
+	for i := range tasks {
+		deliveries[i] = convert.ToHookDelivery(tasks[i])
+	}
+	ctx.JSON(http.StatusOK, &deliveries)
+}
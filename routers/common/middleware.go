@@ -12,6 +12,7 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/web"
 
 	"github.com/chi-middleware/proxy"
 	"github.com/go-chi/chi/middleware"
@@ -41,6 +42,14 @@ func Middlewares() []func(http.Handler) http.Handler {
 		handlers = append(handlers, proxy.ForwardedHeaders(opt))
 	}
 
+	if setting.Metrics.Enabled {
+		handlers = append(handlers, web.RequestMetrics())
+	}
+
+	if setting.Tracing.Enabled {
+		handlers = append(handlers, web.RequestTracing())
+	}
+
 	handlers = append(handlers, middleware.StripSlashes)
 
 	if !setting.DisableRouterLog && setting.RouterLogLevel != log.NONE {
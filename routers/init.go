@@ -29,16 +29,22 @@ import (
 	"code.gitea.io/gitea/modules/ssh"
 	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/svg"
+	"code.gitea.io/gitea/modules/tracing"
 	"code.gitea.io/gitea/modules/translation"
 	"code.gitea.io/gitea/modules/web"
+	actions_router "code.gitea.io/gitea/routers/api/actions"
+	packages_router "code.gitea.io/gitea/routers/api/packages"
+	scim_router "code.gitea.io/gitea/routers/api/scim"
 	apiv1 "code.gitea.io/gitea/routers/api/v1"
 	"code.gitea.io/gitea/routers/common"
 	"code.gitea.io/gitea/routers/private"
 	web_routers "code.gitea.io/gitea/routers/web"
+	actions_service "code.gitea.io/gitea/services/actions"
 	"code.gitea.io/gitea/services/archiver"
 	"code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/auth/source/oauth2"
 	"code.gitea.io/gitea/services/cron"
+	federation_service "code.gitea.io/gitea/services/federation"
 	"code.gitea.io/gitea/services/mailer"
 	repo_migrations "code.gitea.io/gitea/services/migrations"
 	mirror_service "code.gitea.io/gitea/services/mirror"
@@ -102,6 +108,8 @@ func GlobalInit(ctx context.Context) {
 		log.Fatal("Gitea is not installed")
 	}
 
+	tracing.Init()
+
 	mustInitCtx(ctx, git.Init)
 	log.Info(git.VersionInfo())
 
@@ -150,6 +158,12 @@ func GlobalInit(ctx context.Context) {
 	mustInit(pull_service.Init)
 	mustInit(task.Init)
 	mustInit(repo_migrations.Init)
+	if setting.Actions.Enabled {
+		mustInit(actions_service.Init)
+	}
+	if setting.Federation.Enabled {
+		mustInit(federation_service.Init)
+	}
 	eventsource.GetManager().Init()
 
 	mustInitCtx(ctx, syncAppPathForGit)
@@ -188,5 +202,15 @@ func NormalRoutes() *web.Route {
 	r.Mount("/", web_routers.Routes(sessioner))
 	r.Mount("/api/v1", apiv1.Routes(sessioner))
 	r.Mount("/api/internal", private.Routes())
+	if setting.Packages.Enabled {
+		r.Mount("/api/packages", packages_router.Routes(sessioner))
+		r.Mount("/v2", packages_router.ContainerRoutes(sessioner))
+	}
+	if setting.Actions.Enabled {
+		r.Mount("/api/actions", actions_router.Routes(sessioner))
+	}
+	if setting.SCIM.Enabled {
+		r.Mount("/api/scim/v2", scim_router.Routes(sessioner))
+	}
 	return r
 }
@@ -0,0 +1,106 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	admin_model "code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/audit"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+const (
+	tplAudit base.TplName = "admin/audit"
+)
+
+// auditEventsOptions reads the filters of the audit log from the query string
+func auditEventsOptions(ctx *context.Context) *admin_model.FindAuditEventsOptions {
+	opts := &admin_model.FindAuditEventsOptions{
+		Action:     admin_model.AuditAction(strings.TrimSpace(ctx.FormString("action"))),
+		ActorName:  strings.TrimSpace(ctx.FormString("actor")),
+		TargetType: strings.TrimSpace(ctx.FormString("target_type")),
+		TargetID:   ctx.FormInt64("target_id"),
+		IPAddress:  strings.TrimSpace(ctx.FormString("ip")),
+	}
+	if since, err := time.ParseInLocation("2006-01-02", ctx.FormString("since"), setting.DefaultUILocation); err == nil {
+		opts.Since = timeutil.TimeStamp(since.Unix())
+	}
+	// the until date is inclusive
+	if until, err := time.ParseInLocation("2006-01-02", ctx.FormString("until"), setting.DefaultUILocation); err == nil {
+		opts.Before = timeutil.TimeStamp(until.AddDate(0, 0, 1).Unix())
+	}
+	return opts
+}
+
+// Audit shows the audit log
+func Audit(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.audit")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminAudit"] = true
+
+	page := ctx.FormInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	opts := auditEventsOptions(ctx)
+	opts.ListOptions = db.ListOptions{
+		Page:     page,
+		PageSize: setting.UI.Admin.NoticePagingNum,
+	}
+	events, total, err := admin_model.FindAuditEvents(opts)
+	if err != nil {
+		ctx.ServerError("FindAuditEvents", err)
+		return
+	}
+
+	query := make(url.Values)
+	filters := make(map[string]string)
+	for _, key := range []string{"action", "actor", "target_type", "target_id", "ip", "since", "until"} {
+		if value := ctx.FormString(key); value != "" {
+			query.Set(key, value)
+			filters[key] = value
+		}
+	}
+	ctx.Data["Filters"] = filters
+	ctx.Data["TargetTypes"] = []string{
+		audit.TargetUser, audit.TargetOrganization, audit.TargetTeam, audit.TargetRepository,
+		audit.TargetProtectedBranch, audit.TargetDeployKey, audit.TargetAccessToken, audit.TargetUnknownPrincipal,
+	}
+
+	ctx.Data["Events"] = events
+	ctx.Data["Total"] = total
+	ctx.Data["Query"] = query.Encode()
+	ctx.Data["AuditEnabled"] = setting.Audit.Enabled
+
+	pager := context.NewPagination(int(total), setting.UI.Admin.NoticePagingNum, page, 5)
+	for key := range query {
+		pager.AddParamString(key, query.Get(key))
+	}
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(http.StatusOK, tplAudit)
+}
+
+// AuditExport exports the audit log as JSON lines
+func AuditExport(ctx *context.Context) {
+	ctx.Resp.Header().Set("Content-Type", "application/x-ndjson")
+	ctx.Resp.Header().Set("Content-Disposition", `attachment; filename="audit-`+time.Now().Format("20060102150405")+`.jsonl"`)
+	ctx.Resp.WriteHeader(http.StatusOK)
+
+	if err := audit.ExportJSONLines(ctx, ctx.Resp, auditEventsOptions(ctx)); err != nil {
+		// the headers are already sent
+		log.Error("ExportJSONLines: %v", err)
+	}
+}
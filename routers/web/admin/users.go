@@ -12,9 +12,11 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/login"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
@@ -182,6 +184,7 @@ func NewUserPost(ctx *context.Context) {
 		return
 	}
 	log.Trace("Account created by admin (%s): %s", ctx.User.Name, u.Name)
+	audit.Record(admin.AuditUserCreate, ctx.User, ctx.RemoteAddr(), u, nil, audit.UserSnapshot(u))
 
 	// Send email notification.
 	if form.SendNotify {
@@ -264,6 +267,7 @@ func EditUserPost(ctx *context.Context) {
 		ctx.HTML(http.StatusOK, tplUserEdit)
 		return
 	}
+	before := audit.UserSnapshot(u)
 
 	fields := strings.Split(form.LoginType, "-")
 	if len(fields) == 2 {
@@ -365,6 +369,7 @@ func EditUserPost(ctx *context.Context) {
 		return
 	}
 	log.Trace("Account profile updated by admin (%s): %s", ctx.User.Name, u.Name)
+	audit.Record(admin.AuditUserUpdate, ctx.User, ctx.RemoteAddr(), u, before, audit.UserSnapshot(u))
 
 	ctx.Flash.Success(ctx.Tr("admin.users.update_profile_success"))
 	ctx.Redirect(setting.AppSubURL + "/admin/users/" + url.PathEscape(ctx.Params(":userid")))
@@ -390,12 +395,18 @@ func DeleteUser(ctx *context.Context) {
 			ctx.JSON(http.StatusOK, map[string]interface{}{
 				"redirect": setting.AppSubURL + "/admin/users/" + url.PathEscape(ctx.Params(":userid")),
 			})
+		case models.IsErrUserOwnPackages(err):
+			ctx.Flash.Error(ctx.Tr("admin.users.still_own_packages"))
+			ctx.JSON(http.StatusOK, map[string]interface{}{
+				"redirect": setting.AppSubURL + "/admin/users/" + url.PathEscape(ctx.Params(":userid")),
+			})
 		default:
 			ctx.ServerError("DeleteUser", err)
 		}
 		return
 	}
 	log.Trace("Account deleted by admin (%s): %s", ctx.User.Name, u.Name)
+	audit.Record(admin.AuditUserDelete, ctx.User, ctx.RemoteAddr(), u, audit.UserSnapshot(u), nil)
 
 	ctx.Flash.Success(ctx.Tr("admin.users.deletion_success"))
 	ctx.JSON(http.StatusOK, map[string]interface{}{
@@ -28,40 +28,7 @@ import (
 
 func storageHandler(storageSetting setting.Storage, prefix string, objStore storage.ObjectStorage) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
-		if storageSetting.ServeDirect {
-			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-				if req.Method != "GET" && req.Method != "HEAD" {
-					next.ServeHTTP(w, req)
-					return
-				}
-
-				if !strings.HasPrefix(req.URL.RequestURI(), "/"+prefix) {
-					next.ServeHTTP(w, req)
-					return
-				}
-
-				rPath := strings.TrimPrefix(req.URL.RequestURI(), "/"+prefix)
-				u, err := objStore.URL(rPath, path.Base(rPath))
-				if err != nil {
-					if os.IsNotExist(err) || errors.Is(err, os.ErrNotExist) {
-						log.Warn("Unable to find %s %s", prefix, rPath)
-						http.Error(w, "file not found", 404)
-						return
-					}
-					log.Error("Error whilst getting URL for %s %s. Error: %v", prefix, rPath, err)
-					http.Error(w, fmt.Sprintf("Error whilst getting URL for %s %s", prefix, rPath), 500)
-					return
-				}
-				http.Redirect(
-					w,
-					req,
-					u.String(),
-					301,
-				)
-			})
-		}
-
-		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		serveProxied := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			if req.Method != "GET" && req.Method != "HEAD" {
 				next.ServeHTTP(w, req)
 				return
@@ -109,6 +76,46 @@ func storageHandler(storageSetting setting.Storage, prefix string, objStore stor
 				return
 			}
 		})
+
+		if !storageSetting.ServeDirect {
+			return serveProxied
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != "GET" && req.Method != "HEAD" {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			if !strings.HasPrefix(req.URL.RequestURI(), "/"+prefix) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			rPath := strings.TrimPrefix(req.URL.RequestURI(), "/"+prefix)
+			u, err := objStore.URL(rPath, path.Base(rPath))
+			if err != nil {
+				if errors.Is(err, storage.ErrURLNotSupported) {
+					// e.g. objects encrypted with customer provided keys cannot be downloaded directly
+					serveProxied.ServeHTTP(w, req)
+					return
+				}
+				if os.IsNotExist(err) || errors.Is(err, os.ErrNotExist) {
+					log.Warn("Unable to find %s %s", prefix, rPath)
+					http.Error(w, "file not found", 404)
+					return
+				}
+				log.Error("Error whilst getting URL for %s %s. Error: %v", prefix, rPath, err)
+				http.Error(w, fmt.Sprintf("Error whilst getting URL for %s %s", prefix, rPath), 500)
+				return
+			}
+			http.Redirect(
+				w,
+				req,
+				u.String(),
+				301,
+			)
+		})
 	}
 }
 
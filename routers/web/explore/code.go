@@ -87,14 +87,18 @@ func Code(ctx *context.Context) {
 		ctx.Data["RepoMaps"] = rightRepoMap
 
 		total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(repoIDs, language, keyword, page, setting.UI.RepoSearchPagingNum, isMatch)
-		if err != nil {
+		if code_indexer.IsErrTrigramQueryTooShort(err) {
+			ctx.Flash.Error(ctx.Tr("explore.code_search_too_short"), true)
+		} else if err != nil {
 			ctx.ServerError("SearchResults", err)
 			return
 		}
 		// if non-login user or isAdmin, no need to check UnitTypeCode
 	} else if (ctx.User == nil && len(repoIDs) > 0) || isAdmin {
 		total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(repoIDs, language, keyword, page, setting.UI.RepoSearchPagingNum, isMatch)
-		if err != nil {
+		if code_indexer.IsErrTrigramQueryTooShort(err) {
+			ctx.Flash.Error(ctx.Tr("explore.code_search_too_short"), true)
+		} else if err != nil {
 			ctx.ServerError("SearchResults", err)
 			return
 		}
@@ -9,6 +9,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/markup"
@@ -143,6 +144,19 @@ func Home(ctx *context.Context) {
 	ctx.Data["Teams"] = ctx.Org.Teams
 	ctx.Data["DisableNewPullMirrors"] = setting.Mirror.DisableNewPull
 
+	if setting.Packages.Enabled {
+		pkgs, pkgsCount, err := packages_model.SearchPackages(ctx, &packages_model.PackageSearchOptions{
+			ListOptions: db.ListOptions{Page: 1, PageSize: 10},
+			OwnerID:     org.ID,
+		})
+		if err != nil {
+			ctx.ServerError("SearchPackages", err)
+			return
+		}
+		ctx.Data["Packages"] = pkgs
+		ctx.Data["PackagesTotal"] = pkgsCount
+	}
+
 	pager := context.NewPagination(int(count), setting.UI.User.RepoPagingNum, page, 5)
 	pager.SetDefaultParams(ctx)
 	ctx.Data["Page"] = pager
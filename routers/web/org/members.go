@@ -9,6 +9,8 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
@@ -97,6 +99,9 @@ func MembersAction(ctx *context.Context) {
 			return
 		}
 		err = org.RemoveMember(uid)
+		if err == nil {
+			audit.Record(admin.AuditOrgMemberRemove, ctx.User, ctx.RemoteAddr(), org, map[string]interface{}{"user_id": uid}, nil)
+		}
 		if models.IsErrLastOrgOwner(err) {
 			ctx.Flash.Error(ctx.Tr("form.last_org_owner"))
 			ctx.JSON(http.StatusOK, map[string]interface{}{
@@ -106,6 +111,9 @@ func MembersAction(ctx *context.Context) {
 		}
 	case "leave":
 		err = org.RemoveMember(ctx.User.ID)
+		if err == nil {
+			audit.Record(admin.AuditOrgMemberRemove, ctx.User, ctx.RemoteAddr(), org, map[string]interface{}{"user_id": ctx.User.ID}, nil)
+		}
 		if models.IsErrLastOrgOwner(err) {
 			ctx.Flash.Error(ctx.Tr("form.last_org_owner"))
 			ctx.JSON(http.StatusOK, map[string]interface{}{
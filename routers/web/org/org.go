@@ -10,6 +10,8 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
@@ -74,6 +76,7 @@ func CreatePost(ctx *context.Context) {
 		return
 	}
 	log.Trace("Organization created: %s", org.Name)
+	audit.Record(admin.AuditOrgCreate, ctx.User, ctx.RemoteAddr(), org, nil, audit.OrgSnapshot(org))
 
 	ctx.Redirect(org.AsUser().DashboardLink())
 }
@@ -11,8 +11,10 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/webhook"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
@@ -57,6 +59,7 @@ func SettingsPost(ctx *context.Context) {
 	}
 
 	org := ctx.Org.Organization
+	before := audit.OrgSnapshot(org)
 	nameChanged := org.Name != form.Name
 
 	// Check if organization name has been changed.
@@ -129,6 +132,7 @@ func SettingsPost(ctx *context.Context) {
 	}
 
 	log.Trace("Organization setting updated: %s", org.Name)
+	audit.Record(admin.AuditOrgUpdate, ctx.User, ctx.RemoteAddr(), org, before, audit.OrgSnapshot(org))
 	ctx.Flash.Success(ctx.Tr("org.settings.update_setting_success"))
 	ctx.Redirect(ctx.Org.OrgLink + "/settings")
 }
@@ -171,11 +175,15 @@ func SettingsDelete(ctx *context.Context) {
 			if models.IsErrUserOwnRepos(err) {
 				ctx.Flash.Error(ctx.Tr("form.org_still_own_repo"))
 				ctx.Redirect(ctx.Org.OrgLink + "/settings/delete")
+			} else if models.IsErrUserOwnPackages(err) {
+				ctx.Flash.Error(ctx.Tr("form.org_still_own_packages"))
+				ctx.Redirect(ctx.Org.OrgLink + "/settings/delete")
 			} else {
 				ctx.ServerError("DeleteOrganization", err)
 			}
 		} else {
 			log.Trace("Organization deleted: %s", ctx.Org.Organization.Name)
+			audit.Record(admin.AuditOrgDelete, ctx.User, ctx.RemoteAddr(), ctx.Org.Organization, audit.OrgSnapshot(ctx.Org.Organization), nil)
 			ctx.Redirect(setting.AppSubURL + "/")
 		}
 		return
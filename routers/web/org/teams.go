@@ -12,7 +12,9 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
 	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
@@ -66,9 +68,14 @@ func TeamsAction(ctx *context.Context) {
 			return
 		}
 		err = ctx.Org.Team.AddMember(ctx.User.ID)
+		if err == nil {
+			audit.Record(admin.AuditTeamMemberAdd, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, nil, audit.TeamMemberSnapshot(ctx.Org.Team, ctx.User.ID))
+		}
 	case "leave":
 		err = ctx.Org.Team.RemoveMember(ctx.User.ID)
-		if err != nil {
+		if err == nil {
+			audit.Record(admin.AuditTeamMemberRemove, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, audit.TeamMemberSnapshot(ctx.Org.Team, ctx.User.ID), nil)
+		} else {
 			if models.IsErrLastOrgOwner(err) {
 				ctx.Flash.Error(ctx.Tr("form.last_org_owner"))
 			} else {
@@ -91,7 +98,9 @@ func TeamsAction(ctx *context.Context) {
 			return
 		}
 		err = ctx.Org.Team.RemoveMember(uid)
-		if err != nil {
+		if err == nil {
+			audit.Record(admin.AuditTeamMemberRemove, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, audit.TeamMemberSnapshot(ctx.Org.Team, uid), nil)
+		} else {
 			if models.IsErrLastOrgOwner(err) {
 				ctx.Flash.Error(ctx.Tr("form.last_org_owner"))
 			} else {
@@ -134,8 +143,8 @@ func TeamsAction(ctx *context.Context) {
 
 		if ctx.Org.Team.IsMember(u.ID) {
 			ctx.Flash.Error(ctx.Tr("org.teams.add_duplicate_users"))
-		} else {
-			err = ctx.Org.Team.AddMember(u.ID)
+		} else if err = ctx.Org.Team.AddMember(u.ID); err == nil {
+			audit.Record(admin.AuditTeamMemberAdd, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, nil, audit.TeamMemberSnapshot(ctx.Org.Team, u.ID))
 		}
 
 		page = "team"
@@ -187,13 +196,22 @@ func TeamsRepoAction(ctx *context.Context) {
 			ctx.ServerError("GetRepositoryByName", err)
 			return
 		}
-		err = ctx.Org.Team.AddRepository(repo)
+		if err = ctx.Org.Team.AddRepository(repo); err == nil {
+			audit.Record(admin.AuditTeamRepositoryAdd, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, nil, audit.TeamRepositorySnapshot(ctx.Org.Team, repo.ID))
+		}
 	case "remove":
-		err = ctx.Org.Team.RemoveRepository(ctx.FormInt64("repoid"))
+		repoID := ctx.FormInt64("repoid")
+		if err = ctx.Org.Team.RemoveRepository(repoID); err == nil {
+			audit.Record(admin.AuditTeamRepositoryRemove, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, audit.TeamRepositorySnapshot(ctx.Org.Team, repoID), nil)
+		}
 	case "addall":
-		err = ctx.Org.Team.AddAllRepositories()
+		if err = ctx.Org.Team.AddAllRepositories(); err == nil {
+			audit.Record(admin.AuditTeamRepositoryAdd, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, nil, audit.TeamRepositorySnapshot(ctx.Org.Team, 0))
+		}
 	case "removeall":
-		err = ctx.Org.Team.RemoveAllRepositories()
+		if err = ctx.Org.Team.RemoveAllRepositories(); err == nil {
+			audit.Record(admin.AuditTeamRepositoryRemove, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, audit.TeamRepositorySnapshot(ctx.Org.Team, 0), nil)
+		}
 	}
 
 	if err != nil {
@@ -273,6 +291,7 @@ func NewTeamPost(ctx *context.Context) {
 		return
 	}
 	log.Trace("Team created: %s/%s", ctx.Org.Organization.Name, t.Name)
+	audit.Record(admin.AuditTeamCreate, ctx.User, ctx.RemoteAddr(), t, nil, audit.TeamSnapshot(t))
 	ctx.Redirect(ctx.Org.OrgLink + "/teams/" + url.PathEscape(t.LowerName))
 }
 
@@ -319,6 +338,12 @@ func EditTeamPost(ctx *context.Context) {
 	ctx.Data["Team"] = t
 	ctx.Data["Units"] = unit_model.Units
 
+	if err := t.GetUnits(); err != nil {
+		ctx.ServerError("GetUnits", err)
+		return
+	}
+	before := audit.TeamSnapshot(t)
+
 	isAuthChanged := false
 	isIncludeAllChanged := false
 	var includesAllRepositories = form.RepoAccess == "all"
@@ -375,14 +400,24 @@ func EditTeamPost(ctx *context.Context) {
 		}
 		return
 	}
+	t.Units = nil
+	if err := t.GetUnits(); err != nil {
+		log.Error("GetUnits: %v", err)
+	}
+	audit.Record(admin.AuditTeamUpdate, ctx.User, ctx.RemoteAddr(), t, before, audit.TeamSnapshot(t))
 	ctx.Redirect(ctx.Org.OrgLink + "/teams/" + url.PathEscape(t.LowerName))
 }
 
 // DeleteTeam response for the delete team request
 func DeleteTeam(ctx *context.Context) {
+	if err := ctx.Org.Team.GetUnits(); err != nil {
+		log.Error("GetUnits: %v", err)
+	}
+	before := audit.TeamSnapshot(ctx.Org.Team)
 	if err := models.DeleteTeam(ctx.Org.Team); err != nil {
 		ctx.Flash.Error("DeleteTeam: " + err.Error())
 	} else {
+		audit.Record(admin.AuditTeamDelete, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, before, nil)
 		ctx.Flash.Success(ctx.Tr("org.teams.delete_team_success"))
 	}
 
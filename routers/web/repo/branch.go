@@ -222,7 +222,7 @@ func loadBranches(ctx *context.Context, skip, limit int) ([]*Branch, int) {
 	return branches, totalNumOfBranches
 }
 
-func loadOneBranch(ctx *context.Context, rawBranch *git.Branch, protectedBranches []*models.ProtectedBranch,
+func loadOneBranch(ctx *context.Context, rawBranch *git.Branch, protectedBranches models.ProtectedBranchRules,
 	repoIDToRepo map[int64]*models.Repository,
 	repoIDToGitRepo map[int64]*git.Repository) *Branch {
 	log.Trace("loadOneBranch: '%s'", rawBranch.Name)
@@ -234,13 +234,7 @@ func loadOneBranch(ctx *context.Context, rawBranch *git.Branch, protectedBranche
 	}
 
 	branchName := rawBranch.Name
-	var isProtected bool
-	for _, b := range protectedBranches {
-		if b.BranchName == branchName {
-			isProtected = true
-			break
-		}
-	}
+	isProtected := protectedBranches.GetFirstMatched(branchName) != nil
 
 	divergence, divergenceError := repofiles.CountDivergingCommits(ctx.Repo.Repository, git.BranchPrefix+branchName)
 	if divergenceError != nil {
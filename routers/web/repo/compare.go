@@ -57,6 +57,7 @@ func setCompareContext(ctx *context.Context, base *git.Commit, head *git.Commit,
 	setPathsCompareContext(ctx, base, head, headOwner, headName)
 	setImageCompareContext(ctx)
 	setCsvCompareContext(ctx)
+	setNotebookCompareContext(ctx)
 }
 
 // SourceCommitURL creates a relative URL for a commit in the given repository
@@ -161,6 +162,57 @@ func setCsvCompareContext(ctx *context.Context) {
 	}
 }
 
+// setNotebookCompareContext sets context data that is required by the Jupyter notebook compare template
+func setNotebookCompareContext(ctx *context.Context) {
+	ctx.Data["IsNotebookFile"] = func(diffFile *gitdiff.DiffFile) bool {
+		return strings.ToLower(filepath.Ext(diffFile.Name)) == ".ipynb"
+	}
+
+	type NotebookDiffResult struct {
+		Cells []*gitdiff.NotebookDiffCell
+		Error string
+	}
+
+	ctx.Data["CreateNotebookDiff"] = func(diffFile *gitdiff.DiffFile, baseCommit *git.Commit, headCommit *git.Commit) NotebookDiffResult {
+		if diffFile == nil || baseCommit == nil || headCommit == nil {
+			return NotebookDiffResult{nil, ""}
+		}
+
+		errTooLarge := errors.New(ctx.Locale.Tr("repo.error.notebook.too_large"))
+
+		readerFromCommit := func(c *git.Commit, name string) (io.ReadCloser, error) {
+			blob, err := c.GetBlobByPath(name)
+			if err != nil {
+				return nil, err
+			}
+			if blob.Size() > setting.UI.MaxDisplayFileSize {
+				return nil, errTooLarge
+			}
+			return blob.DataAsync()
+		}
+
+		baseReader, err := readerFromCommit(baseCommit, diffFile.OldName)
+		if err == errTooLarge {
+			return NotebookDiffResult{nil, err.Error()}
+		} else if baseReader != nil {
+			defer baseReader.Close()
+		}
+		headReader, err := readerFromCommit(headCommit, diffFile.Name)
+		if err == errTooLarge {
+			return NotebookDiffResult{nil, err.Error()}
+		} else if headReader != nil {
+			defer headReader.Close()
+		}
+
+		cells, err := gitdiff.CreateNotebookDiff(baseReader, headReader)
+		if err != nil {
+			log.Debug("CreateNotebookDiff failed: %v", err)
+			return NotebookDiffResult{nil, ctx.Locale.Tr("repo.error.notebook.invalid")}
+		}
+		return NotebookDiffResult{cells, ""}
+	}
+}
+
 // CompareInfo represents the collected results from ParseCompareInfo
 type CompareInfo struct {
 	HeadUser         *models.User
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	actions_model "code.gitea.io/gitea/models/actions"
 	"code.gitea.io/gitea/models/login"
 	"code.gitea.io/gitea/models/unit"
 	user_model "code.gitea.io/gitea/models/user"
@@ -166,6 +167,11 @@ func httpBase(ctx *context.Context) (h *serviceHandler) {
 		askAuth = askAuth || (repo.Owner.Visibility != structs.VisibleTypePublic)
 	}
 
+	// the token of a running actions task lets its runner clone the repository of the task
+	if askAuth && !ctx.IsSigned && isPull && !isWiki && repoExist && isActionsTaskToken(ctx, repo) {
+		askAuth = false
+	}
+
 	// check access
 	if askAuth {
 		// rely on the results of Contexter
@@ -303,6 +309,28 @@ func httpBase(ctx *context.Context) (h *serviceHandler) {
 	return &serviceHandler{cfg, w, r, dir, cfg.Env}
 }
 
+// isActionsTaskToken returns true if the request is authenticated with the token of a running
+// actions task of the repository, given as username or password like an access token
+func isActionsTaskToken(ctx *context.Context, repo *models.Repository) bool {
+	username, password, ok := ctx.Req.BasicAuth()
+	if !ok {
+		return false
+	}
+	token := password
+	if len(password) == 0 || password == "x-oauth-basic" {
+		token = username
+	}
+
+	job, err := actions_model.GetRunJobByToken(ctx, token)
+	if err != nil {
+		if err != actions_model.ErrRunJobNotExist {
+			log.Error("GetRunJobByToken: %v", err)
+		}
+		return false
+	}
+	return job.RepoID == repo.ID
+}
+
 var (
 	infoRefsCache []byte
 	infoRefsOnce  sync.Once
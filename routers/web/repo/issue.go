@@ -158,14 +158,40 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption uti
 		keyword = ""
 	}
 
-	var issueIDs []int64
-	if len(keyword) > 0 {
-		issueIDs, err = issue_indexer.SearchIssuesByKeyword([]int64{repo.ID}, keyword)
+	var mileIDs []int64
+	if milestoneID > 0 {
+		mileIDs = []int64{milestoneID}
+	}
+
+	opts := &models.IssuesOptions{
+		RepoIDs:           []int64{repo.ID},
+		AssigneeID:        assigneeID,
+		PosterID:          posterID,
+		MentionedID:       mentionedID,
+		ReviewRequestedID: reviewRequestedID,
+		MilestoneIDs:      mileIDs,
+		ProjectID:         projectID,
+		IsPull:            isPullOption,
+		LabelIDs:          labelIDs,
+		SortType:          sortType,
+	}
+
+	// the qualifiers of the query override the filters of the menus
+	query := issue_indexer.ParseQuery(keyword)
+	matchable, err := query.Apply(opts, ctx.User)
+	if err != nil {
+		ctx.ServerError("ParseQuery", err)
+		return
+	}
+	forceEmpty = !matchable
+
+	if !forceEmpty && len(query.Keyword) > 0 {
+		opts.IssueIDs, err = issue_indexer.SearchIssuesByKeyword([]int64{repo.ID}, query.Keyword)
 		if err != nil {
 			ctx.ServerError("issueIndexer.Search", err)
 			return
 		}
-		if len(issueIDs) == 0 {
+		if len(opts.IssueIDs) == 0 {
 			forceEmpty = true
 		}
 	}
@@ -174,26 +200,18 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption uti
 	if forceEmpty {
 		issueStats = &models.IssueStats{}
 	} else {
-		issueStats, err = models.GetIssueStats(&models.IssueStatsOptions{
-			RepoID:            repo.ID,
-			Labels:            selectLabels,
-			MilestoneID:       milestoneID,
-			AssigneeID:        assigneeID,
-			MentionedID:       mentionedID,
-			PosterID:          posterID,
-			ReviewRequestedID: reviewRequestedID,
-			IsPull:            isPullOption,
-			IssueIDs:          issueIDs,
-		})
+		issueStats, err = models.CountIssueStats(opts)
 		if err != nil {
-			ctx.ServerError("GetIssueStats", err)
+			ctx.ServerError("CountIssueStats", err)
 			return
 		}
 	}
 
 	isShowClosed := ctx.FormString("state") == "closed"
-	// if open issues are zero and close don't, use closed as default
-	if len(ctx.FormString("state")) == 0 && issueStats.OpenCount == 0 && issueStats.ClosedCount != 0 {
+	if !query.IsClosed.IsNone() {
+		isShowClosed = query.IsClosed.IsTrue()
+	} else if len(ctx.FormString("state")) == 0 && issueStats.OpenCount == 0 && issueStats.ClosedCount != 0 {
+		// if open issues are zero and close don't, use closed as default
 		isShowClosed = true
 	}
 
@@ -210,33 +228,16 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption uti
 	}
 	pager := context.NewPagination(total, setting.UI.IssuePagingNum, page, 5)
 
-	var mileIDs []int64
-	if milestoneID > 0 {
-		mileIDs = []int64{milestoneID}
-	}
-
 	var issues []*models.Issue
 	if forceEmpty {
 		issues = []*models.Issue{}
 	} else {
-		issues, err = models.Issues(&models.IssuesOptions{
-			ListOptions: db.ListOptions{
-				Page:     pager.Paginater.Current(),
-				PageSize: setting.UI.IssuePagingNum,
-			},
-			RepoIDs:           []int64{repo.ID},
-			AssigneeID:        assigneeID,
-			PosterID:          posterID,
-			MentionedID:       mentionedID,
-			ReviewRequestedID: reviewRequestedID,
-			MilestoneIDs:      mileIDs,
-			ProjectID:         projectID,
-			IsClosed:          util.OptionalBoolOf(isShowClosed),
-			IsPull:            isPullOption,
-			LabelIDs:          labelIDs,
-			SortType:          sortType,
-			IssueIDs:          issueIDs,
-		})
+		opts.ListOptions = db.ListOptions{
+			Page:     pager.Paginater.Current(),
+			PageSize: setting.UI.IssuePagingNum,
+		}
+		opts.IsClosed = util.OptionalBoolOf(isShowClosed)
+		issues, err = models.Issues(opts)
 		if err != nil {
 			ctx.ServerError("Issues", err)
 			return
@@ -1091,7 +1092,7 @@ func ViewIssue(ctx *context.Context) {
 		}
 	}
 
-	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	issue, err := models.GetIssueByIndexCtx(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
 	if err != nil {
 		if models.IsErrIssueNotExist(err) {
 			ctx.NotFound("GetIssueByIndex", err)
@@ -1575,6 +1576,37 @@ func ViewIssue(ctx *context.Context) {
 			ctx.Data["IsBlockedByChangedProtectedFiles"] = len(pull.ChangedProtectedFiles) != 0
 			ctx.Data["ChangedProtectedFilesNum"] = len(pull.ChangedProtectedFiles)
 			ctx.Data["ShowMergeInstructions"] = showMergeInstructions
+			ctx.Data["EnableMergeQueue"] = pull.ProtectedBranch.EnableMergeQueue
+			if pull.ProtectedBranch.RequireCodeOwnerReviews && !pull.HasMerged && !issue.IsClosed {
+				if unapproved, err := pull_service.GetUnapprovedCodeOwnerFiles(pull); err != nil {
+					log.Error("GetUnapprovedCodeOwnerFiles: %v", err)
+				} else {
+					ctx.Data["IsBlockedByCodeOwners"] = len(unapproved) != 0
+					ctx.Data["UnapprovedCodeOwnerFiles"] = unapproved
+				}
+			}
+		}
+		if entry, err := models.GetMergeQueueEntryByPullID(pull.ID); err == nil {
+			position, err := entry.Position()
+			if err != nil {
+				ctx.ServerError("Position", err)
+				return
+			}
+			ctx.Data["MergeQueueEntry"] = entry
+			ctx.Data["MergeQueuePosition"] = position
+		} else if !models.IsErrMergeQueueEntryNotExist(err) {
+			ctx.ServerError("GetMergeQueueEntryByPullID", err)
+			return
+		}
+		if autoMerge, err := models.GetPullAutoMergeByPullID(pull.ID); err == nil {
+			if err := autoMerge.LoadDoer(); err != nil && !models.IsErrUserNotExist(err) {
+				ctx.ServerError("LoadDoer", err)
+				return
+			}
+			ctx.Data["AutoMerge"] = autoMerge
+		} else if !models.IsErrPullAutoMergeNotExist(err) {
+			ctx.ServerError("GetPullAutoMergeByPullID", err)
+			return
 		}
 		ctx.Data["WillSign"] = false
 		if ctx.User != nil {
@@ -1642,7 +1674,7 @@ func ViewIssue(ctx *context.Context) {
 
 // GetActionIssue will return the issue which is used in the context.
 func GetActionIssue(ctx *context.Context) *models.Issue {
-	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	issue, err := models.GetIssueByIndexCtx(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
 	if err != nil {
 		ctx.NotFoundOrServerError("GetIssueByIndex", models.IsErrIssueNotExist, err)
 		return nil
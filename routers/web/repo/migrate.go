@@ -26,6 +26,7 @@ import (
 
 const (
 	tplMigrate base.TplName = "repo/migrate/migrate"
+	tplImport  base.TplName = "repo/migrate/import"
 )
 
 // Migrate render migration of repository page
@@ -219,15 +220,6 @@ func MigratePost(ctx *context.Context) {
 		PullRequests:   form.PullRequests,
 		Releases:       form.Releases,
 	}
-	if opts.Mirror {
-		opts.Issues = false
-		opts.Milestones = false
-		opts.Labels = false
-		opts.Comments = false
-		opts.PullRequests = false
-		opts.Releases = false
-	}
-
 	err = models.CheckCreateRepository(ctx.User, ctxUser, opts.RepoName, false)
 	if err != nil {
 		handleMigrateError(ctx, ctxUser, err, "MigratePost", tpl, form)
@@ -254,3 +246,102 @@ func setMigrationContextData(ctx *context.Context, serviceType structs.GitServic
 	ctx.Data["Services"] = append([]structs.GitServiceType{structs.PlainGitService}, structs.SupportedFullGitService...)
 	ctx.Data["service"] = serviceType
 }
+
+// Import render the page importing a repository from an exported archive
+func Import(ctx *context.Context) {
+	if setting.Repository.DisableMigrations {
+		ctx.Error(http.StatusForbidden, "Import: the site administrator has disabled migrations")
+		return
+	}
+
+	ctx.Data["Title"] = ctx.Tr("repo.import.title")
+	ctx.Data["IsForcedPrivate"] = setting.Repository.ForcePrivate
+	ctx.Data["private"] = getRepoPrivate(ctx)
+
+	ctxUser := checkContextUser(ctx, ctx.FormInt64("org"))
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["ContextUser"] = ctxUser
+
+	ctx.HTML(http.StatusOK, tplImport)
+}
+
+// ImportPost response for importing a repository from an exported archive
+func ImportPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.ImportRepoForm)
+	if setting.Repository.DisableMigrations {
+		ctx.Error(http.StatusForbidden, "ImportPost: the site administrator has disabled migrations")
+		return
+	}
+
+	ctx.Data["Title"] = ctx.Tr("repo.import.title")
+	ctx.Data["IsForcedPrivate"] = setting.Repository.ForcePrivate
+
+	ctxUser := checkContextUser(ctx, form.UID)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["ContextUser"] = ctxUser
+
+	if ctx.HasError() {
+		ctx.HTML(http.StatusOK, tplImport)
+		return
+	}
+
+	if form.Archive == nil || form.Archive.Size == 0 {
+		ctx.Data["Err_Archive"] = true
+		ctx.RenderWithErr(ctx.Tr("repo.import.archive_required"), tplImport, form)
+		return
+	}
+
+	if err := models.CheckCreateRepository(ctx.User, ctxUser, form.RepoName, false); err != nil {
+		handleImportError(ctx, ctxUser, err, form)
+		return
+	}
+
+	archive, err := form.Archive.Open()
+	if err != nil {
+		ctx.ServerError("Open", err)
+		return
+	}
+	defer archive.Close()
+
+	_, err = task.ImportRepository(ctx.User, ctxUser, migrations.MigrateOptions{
+		CloneAddr:   form.Archive.Filename,
+		RepoName:    form.RepoName,
+		Description: form.Description,
+		Private:     form.Private || setting.Repository.ForcePrivate,
+	}, archive, form.Archive.Size)
+	if err != nil {
+		handleImportError(ctx, ctxUser, err, form)
+		return
+	}
+
+	log.Trace("Repository import queued: %s/%s", ctxUser.Name, form.RepoName)
+	ctx.Redirect(ctxUser.HomeLink() + "/" + url.PathEscape(form.RepoName))
+}
+
+func handleImportError(ctx *context.Context, owner *models.User, err error, form *forms.ImportRepoForm) {
+	switch {
+	case models.IsErrReachLimitOfRepo(err):
+		ctx.RenderWithErr(ctx.Tr("repo.form.reach_limit_of_creation", owner.MaxCreationLimit()), tplImport, form)
+	case models.IsErrRepoAlreadyExist(err):
+		ctx.Data["Err_RepoName"] = true
+		ctx.RenderWithErr(ctx.Tr("form.repo_name_been_taken"), tplImport, form)
+	case models.IsErrRepoFilesAlreadyExist(err):
+		ctx.Data["Err_RepoName"] = true
+		ctx.RenderWithErr(ctx.Tr("form.repository_files_already_exist"), tplImport, form)
+	case models.IsErrNameReserved(err):
+		ctx.Data["Err_RepoName"] = true
+		ctx.RenderWithErr(ctx.Tr("repo.form.name_reserved", err.(models.ErrNameReserved).Name), tplImport, form)
+	case models.IsErrNamePatternNotAllowed(err):
+		ctx.Data["Err_RepoName"] = true
+		ctx.RenderWithErr(ctx.Tr("repo.form.name_pattern_not_allowed", err.(models.ErrNamePatternNotAllowed).Pattern), tplImport, form)
+	case migrations.IsErrImportArchiveTooLarge(err):
+		ctx.Data["Err_Archive"] = true
+		ctx.RenderWithErr(ctx.Tr("repo.import.archive_too_large", setting.Repository.Import.MaxSize, setting.Repository.Import.MaxFiles), tplImport, form)
+	default:
+		ctx.ServerError("ImportRepository", err)
+	}
+}
@@ -23,10 +23,11 @@ import (
 )
 
 const (
-	tplProjects           base.TplName = "repo/projects/list"
-	tplProjectsNew        base.TplName = "repo/projects/new"
-	tplProjectsView       base.TplName = "repo/projects/view"
-	tplGenericProjectsNew base.TplName = "user/project"
+	tplProjects     base.TplName = "repo/projects/list"
+	tplProjectsNew  base.TplName = "repo/projects/new"
+	tplProjectsView base.TplName = "repo/projects/view"
+
+	tplProjectsAutomation base.TplName = "repo/projects/automation"
 )
 
 // MustEnableProjects check if projects are enabled in settings
@@ -267,6 +268,108 @@ func EditProjectPost(ctx *context.Context) {
 	ctx.Redirect(ctx.Repo.RepoLink + "/projects")
 }
 
+// getProjectOfRepo returns the project from the URL if it belongs to the current repository
+func getProjectOfRepo(ctx *context.Context) *models.Project {
+	p, err := models.GetProjectByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrProjectNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectByID", err)
+		}
+		return nil
+	}
+	if p.RepoID != ctx.Repo.Repository.ID {
+		ctx.NotFound("", nil)
+		return nil
+	}
+	return p
+}
+
+func renderProjectAutomation(ctx *context.Context, p *models.Project) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.automation")
+	ctx.Data["IsProjectsPage"] = true
+	ctx.Data["Project"] = p
+	ctx.Data["ProjectLink"] = fmt.Sprintf("%s/projects/%d", ctx.Repo.RepoLink, p.ID)
+	ctx.Data["AutomationEvents"] = models.ProjectAutomationEvents
+
+	boards, err := models.GetProjectBoards(p.ID)
+	if err != nil {
+		ctx.ServerError("GetProjectBoards", err)
+		return
+	}
+	ctx.Data["Boards"] = boards
+
+	rules, err := models.GetProjectAutomationRules(p.ID)
+	if err != nil {
+		ctx.ServerError("GetProjectAutomationRules", err)
+		return
+	}
+	ctx.Data["AutomationRules"] = rules
+
+	ctx.HTML(http.StatusOK, tplProjectsAutomation)
+}
+
+// ProjectAutomation renders the automation rules of a project
+func ProjectAutomation(ctx *context.Context) {
+	p := getProjectOfRepo(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	renderProjectAutomation(ctx, p)
+}
+
+// ProjectAutomationPost adds an automation rule to a project
+func ProjectAutomationPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.ProjectAutomationRuleForm)
+	p := getProjectOfRepo(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if ctx.HasError() {
+		renderProjectAutomation(ctx, p)
+		return
+	}
+
+	if err := models.NewProjectAutomationRule(&models.ProjectAutomationRule{
+		ProjectID: p.ID,
+		Event:     form.Event,
+		BoardID:   form.BoardID,
+		Labels:    form.LabelNames(),
+		CreatorID: ctx.User.ID,
+	}); err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("NewProjectAutomationRule", err)
+		}
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.automation.add_success"))
+	ctx.Redirect(fmt.Sprintf("%s/projects/%d/automation", ctx.Repo.RepoLink, p.ID))
+}
+
+// DeleteProjectAutomationRule deletes an automation rule of a project
+func DeleteProjectAutomationRule(ctx *context.Context) {
+	p := getProjectOfRepo(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectAutomationRule(p.ID, ctx.ParamsInt64(":ruleID")); err != nil {
+		ctx.Flash.Error("DeleteProjectAutomationRule: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.automation.deletion_success"))
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"redirect": fmt.Sprintf("%s/projects/%d/automation", ctx.Repo.RepoLink, p.ID),
+	})
+}
+
 // ViewProject renders the project board for a project
 func ViewProject(ctx *context.Context) {
 	project, err := models.GetProjectByID(ctx.ParamsInt64(":id"))
@@ -332,8 +435,9 @@ func ViewProject(ctx *context.Context) {
 	}
 
 	ctx.Data["IsProjectsPage"] = true
-	ctx.Data["CanWriteProjects"] = ctx.Repo.Permission.CanWrite(unit.TypeProjects)
+	ctx.Data["CanWriteProjects"] = ctx.Repo.Permission.CanWrite(unit.TypeProjects) && !ctx.Repo.Repository.IsArchived
 	ctx.Data["Project"] = project
+	ctx.Data["ProjectLink"] = fmt.Sprintf("%s/projects/%d", ctx.Repo.RepoLink, project.ID)
 	ctx.Data["Boards"] = boards
 
 	ctx.HTML(http.StatusOK, tplProjectsView)
@@ -347,6 +451,31 @@ func UpdateIssueProject(ctx *context.Context) {
 	}
 
 	projectID := ctx.FormInt64("id")
+	if projectID > 0 {
+		project, err := models.GetProjectByID(projectID)
+		if err != nil {
+			if models.IsErrProjectNotExist(err) {
+				ctx.NotFound("", nil)
+			} else {
+				ctx.ServerError("GetProjectByID", err)
+			}
+			return
+		}
+		// the issues may be added to the projects of the repository and to the projects of other
+		// owners the doer can write
+		if project.RepoID != ctx.Repo.Repository.ID {
+			mode, err := models.GetUserProjectAccessMode(project, ctx.User)
+			if err != nil {
+				ctx.ServerError("GetUserProjectAccessMode", err)
+				return
+			}
+			if project.Type == models.ProjectTypeRepository || mode < models.AccessModeWrite {
+				ctx.NotFound("", nil)
+				return
+			}
+		}
+	}
+
 	for _, issue := range issues {
 		oldProjectID := issue.ProjectID()
 		if oldProjectID == projectID {
@@ -623,48 +752,3 @@ func MoveIssueAcrossBoards(ctx *context.Context) {
 		"ok": true,
 	})
 }
-
-// CreateProject renders the generic project creation page
-func CreateProject(ctx *context.Context) {
-	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
-	ctx.Data["ProjectTypes"] = models.GetProjectsConfig()
-	ctx.Data["CanWriteProjects"] = ctx.Repo.Permission.CanWrite(unit.TypeProjects)
-
-	ctx.HTML(http.StatusOK, tplGenericProjectsNew)
-}
-
-// CreateProjectPost creates an individual and/or organization project
-func CreateProjectPost(ctx *context.Context, form forms.UserCreateProjectForm) {
-
-	user := checkContextUser(ctx, form.UID)
-	if ctx.Written() {
-		return
-	}
-
-	ctx.Data["ContextUser"] = user
-
-	if ctx.HasError() {
-		ctx.Data["CanWriteProjects"] = ctx.Repo.Permission.CanWrite(unit.TypeProjects)
-		ctx.HTML(http.StatusOK, tplGenericProjectsNew)
-		return
-	}
-
-	var projectType = models.ProjectTypeIndividual
-	if user.IsOrganization() {
-		projectType = models.ProjectTypeOrganization
-	}
-
-	if err := models.NewProject(&models.Project{
-		Title:       form.Title,
-		Description: form.Content,
-		CreatorID:   user.ID,
-		BoardType:   form.BoardType,
-		Type:        projectType,
-	}); err != nil {
-		ctx.ServerError("NewProject", err)
-		return
-	}
-
-	ctx.Flash.Success(ctx.Tr("repo.projects.create_success", form.Title))
-	ctx.Redirect(setting.AppSubURL + "/")
-}
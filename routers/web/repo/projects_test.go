@@ -5,10 +5,14 @@
 package repo
 
 import (
+	"net/http"
 	"testing"
 
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/models/unittest"
 	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/forms"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -26,3 +30,37 @@ func TestCheckProjectBoardChangePermissions(t *testing.T) {
 	assert.NotNil(t, board)
 	assert.False(t, ctx.Written())
 }
+
+func TestProjectAutomationPost(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+	ctx := test.MockContext(t, "user2/repo1/projects/1/automation")
+	test.LoadUser(t, ctx, 2)
+	test.LoadRepo(t, ctx, 1)
+	ctx.SetParams(":id", "1")
+	web.SetForm(ctx, &forms.ProjectAutomationRuleForm{
+		Event:   models.ProjectAutomationIssueOpened,
+		BoardID: 2,
+		Labels:  "bug, ,enhancement",
+	})
+	ProjectAutomationPost(ctx)
+
+	assert.EqualValues(t, http.StatusFound, ctx.Resp.Status())
+	rules, err := models.GetProjectAutomationRules(1)
+	assert.NoError(t, err)
+	if assert.Len(t, rules, 5) {
+		assert.EqualValues(t, 2, rules[1].BoardID)
+		assert.Equal(t, []string{"bug", "enhancement"}, rules[1].Labels)
+	}
+
+	// the board of another project
+	ctx = test.MockContext(t, "user2/repo1/projects/1/automation")
+	test.LoadUser(t, ctx, 2)
+	test.LoadRepo(t, ctx, 1)
+	ctx.SetParams(":id", "1")
+	web.SetForm(ctx, &forms.ProjectAutomationRuleForm{
+		Event:   models.ProjectAutomationIssueClosed,
+		BoardID: 4,
+	})
+	ProjectAutomationPost(ctx)
+	assert.EqualValues(t, http.StatusNotFound, ctx.Resp.Status())
+}
@@ -886,7 +886,7 @@ func MergePullRequest(ctx *context.Context) {
 		if isRepoAdmin, err := models.IsUserRepoAdmin(pr.BaseRepo, ctx.User); err != nil {
 			ctx.ServerError("IsUserRepoAdmin", err)
 			return
-		} else if !isRepoAdmin {
+		} else if !isRepoAdmin && !form.MergeWhenChecksSucceed {
 			ctx.Flash.Error(ctx.Tr("repo.pulls.no_merge_not_ready"))
 			ctx.Redirect(issue.Link())
 			return
@@ -931,6 +931,46 @@ func MergePullRequest(ctx *context.Context) {
 		return
 	}
 
+	if form.MergeWhenChecksSucceed {
+		scheduled, err := pull_service.ScheduleAutoMerge(ctx.User, pr, models.MergeStyle(form.Do), message)
+		if err != nil {
+			if models.IsErrInvalidMergeStyle(err) {
+				ctx.Flash.Error(ctx.Tr("repo.pulls.invalid_merge_option"))
+				ctx.Redirect(issue.Link())
+				return
+			} else if models.IsErrPullAutoMergeAlreadyScheduled(err) {
+				ctx.Flash.Error(ctx.Tr("repo.pulls.auto_merge_already_scheduled"))
+				ctx.Redirect(issue.Link())
+				return
+			}
+			ctx.ServerError("ScheduleAutoMerge", err)
+			return
+		} else if scheduled {
+			ctx.Flash.Success(ctx.Tr("repo.pulls.auto_merge_scheduled"))
+			ctx.Redirect(issue.Link())
+			return
+		}
+	}
+
+	if pr.ProtectedBranch != nil && pr.ProtectedBranch.EnableMergeQueue {
+		if err = pull_service.AddToMergeQueue(pr, ctx.User, models.MergeStyle(form.Do), message); err != nil {
+			if models.IsErrInvalidMergeStyle(err) {
+				ctx.Flash.Error(ctx.Tr("repo.pulls.invalid_merge_option"))
+				ctx.Redirect(issue.Link())
+				return
+			} else if models.IsErrMergeQueueEntryAlreadyExist(err) {
+				ctx.Flash.Error(ctx.Tr("repo.pulls.merge_queue_already_added"))
+				ctx.Redirect(issue.Link())
+				return
+			}
+			ctx.ServerError("AddToMergeQueue", err)
+			return
+		}
+		ctx.Flash.Success(ctx.Tr("repo.pulls.merge_queue_added"))
+		ctx.Redirect(issue.Link())
+		return
+	}
+
 	if err = pull_service.Merge(pr, ctx.User, ctx.Repo.GitRepo, models.MergeStyle(form.Do), message); err != nil {
 		if models.IsErrInvalidMergeStyle(err) {
 			ctx.Flash.Error(ctx.Tr("repo.pulls.invalid_merge_option"))
@@ -1201,6 +1241,66 @@ func TriggerTask(ctx *context.Context) {
 	ctx.Status(202)
 }
 
+// RemoveFromMergeQueue removes a pull request from the merge queue of its base branch
+func RemoveFromMergeQueue(ctx *context.Context) {
+	issue := checkPullInfo(ctx)
+	if ctx.Written() {
+		return
+	}
+	pr := issue.PullRequest
+
+	allowedMerge, err := pull_service.IsUserAllowedToMerge(pr, ctx.Repo.Permission, ctx.User)
+	if err != nil {
+		ctx.ServerError("IsUserAllowedToMerge", err)
+		return
+	}
+	if !allowedMerge {
+		ctx.Flash.Error(ctx.Tr("repo.pulls.no_merge_access"))
+		ctx.Redirect(issue.Link())
+		return
+	}
+
+	if err := pull_service.RemoveFromMergeQueue(pr, ctx.User); err != nil {
+		if !models.IsErrMergeQueueEntryNotExist(err) {
+			ctx.ServerError("RemoveFromMergeQueue", err)
+			return
+		}
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.pulls.merge_queue_removed"))
+	}
+	ctx.Redirect(issue.Link())
+}
+
+// CancelAutoMergePullRequest cancels the scheduled automatic merge of a pull request
+func CancelAutoMergePullRequest(ctx *context.Context) {
+	issue := checkPullInfo(ctx)
+	if ctx.Written() {
+		return
+	}
+	pr := issue.PullRequest
+
+	allowedMerge, err := pull_service.IsUserAllowedToMerge(pr, ctx.Repo.Permission, ctx.User)
+	if err != nil {
+		ctx.ServerError("IsUserAllowedToMerge", err)
+		return
+	}
+	if !allowedMerge {
+		ctx.Flash.Error(ctx.Tr("repo.pulls.no_merge_access"))
+		ctx.Redirect(issue.Link())
+		return
+	}
+
+	if err := pull_service.CancelAutoMerge(pr, ctx.User); err != nil {
+		if !models.IsErrPullAutoMergeNotExist(err) {
+			ctx.ServerError("CancelAutoMerge", err)
+			return
+		}
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.pulls.auto_merge_canceled"))
+	}
+	ctx.Redirect(issue.Link())
+}
+
 // CleanUpPullRequest responses for delete merged branch when PR has been merged
 func CleanUpPullRequest(ctx *context.Context) {
 	issue := checkPullInfo(ctx)
@@ -32,7 +32,9 @@ func Search(ctx *context.Context) {
 
 	total, searchResults, searchResultLanguages, err := code_indexer.PerformSearch([]int64{ctx.Repo.Repository.ID},
 		language, keyword, page, setting.UI.RepoSearchPagingNum, isMatch)
-	if err != nil {
+	if code_indexer.IsErrTrigramQueryTooShort(err) {
+		ctx.Flash.Error(ctx.Tr("explore.code_search_too_short"), true)
+	} else if err != nil {
 		ctx.ServerError("SearchResults", err)
 		return
 	}
@@ -15,15 +15,19 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
 	"code.gitea.io/gitea/models/db"
 	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/lfs"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/repository"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/typesniffer"
@@ -36,6 +40,7 @@ import (
 	"code.gitea.io/gitea/services/migrations"
 	mirror_service "code.gitea.io/gitea/services/mirror"
 	repo_service "code.gitea.io/gitea/services/repository"
+	"code.gitea.io/gitea/services/task"
 	wiki_service "code.gitea.io/gitea/services/wiki"
 )
 
@@ -63,6 +68,27 @@ func Settings(ctx *context.Context) {
 	ctx.Data["SigningKeyAvailable"] = len(signing) > 0
 	ctx.Data["SigningSettings"] = setting.Repository.Signing
 
+	pushMirrorAttempts := make(map[int64][]*models.PushMirrorAttempt, len(ctx.Repo.Repository.PushMirrors))
+	for _, m := range ctx.Repo.Repository.PushMirrors {
+		attempts, err := models.GetPushMirrorAttempts(m.ID)
+		if err != nil {
+			ctx.ServerError("GetPushMirrorAttempts", err)
+			return
+		}
+		pushMirrorAttempts[m.ID] = attempts
+	}
+	ctx.Data["PushMirrorAttempts"] = pushMirrorAttempts
+
+	exportTask, err := models.GetLatestRepoTask(ctx.Repo.Repository.ID, structs.TaskTypeExportRepo)
+	if err != nil && !models.IsErrTaskDoesNotExist(err) {
+		ctx.ServerError("GetLatestRepoTask", err)
+		return
+	}
+	if exportTask != nil {
+		ctx.Data["ExportTask"] = exportTask
+		ctx.Data["ExportTaskMessage"] = convert.ToTaskMessage(exportTask, ctx.Locale)
+	}
+
 	ctx.HTML(http.StatusOK, tplSettingsOptions)
 }
 
@@ -280,6 +306,44 @@ func SettingsPost(ctx *context.Context) {
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
 		ctx.Redirect(repo.Link() + "/settings")
 
+	case "push-mirror-update":
+		if !setting.Mirror.Enabled {
+			ctx.NotFound("", nil)
+			return
+		}
+
+		// This section doesn't require repo_name/RepoName to be set in the form, don't show it
+		// as an error on the UI for this action
+		ctx.Data["Err_RepoName"] = nil
+
+		m, err := selectPushMirrorByForm(form, repo)
+		if err != nil {
+			ctx.NotFound("", nil)
+			return
+		}
+
+		interval, err := time.ParseDuration(form.PushMirrorInterval)
+		if err != nil || (interval != 0 && interval < setting.Mirror.MinInterval) {
+			ctx.Data["Err_PushMirrorInterval"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.mirror_interval_invalid"), tplSettingsOptions, &form)
+			return
+		}
+		if !validatePushMirrorRefPatterns(ctx, form) {
+			return
+		}
+
+		m.Interval = interval
+		m.SyncOnPush = form.PushMirrorSyncOnPush
+		m.IncludeRefs = form.PushMirrorIncludeRefs
+		m.ExcludeRefs = form.PushMirrorExcludeRefs
+		if err := models.UpdatePushMirror(m); err != nil {
+			ctx.ServerError("UpdatePushMirror", err)
+			return
+		}
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
+		ctx.Redirect(repo.Link() + "/settings")
+
 	case "push-mirror-add":
 		if setting.Mirror.DisableNewPush {
 			ctx.NotFound("", nil)
@@ -297,6 +361,10 @@ func SettingsPost(ctx *context.Context) {
 			return
 		}
 
+		if !validatePushMirrorRefPatterns(ctx, form) {
+			return
+		}
+
 		address, err := forms.ParseRemoteAddr(form.PushMirrorAddress, form.PushMirrorUsername, form.PushMirrorPassword)
 		if err == nil {
 			err = migrations.IsMigrateURLAllowed(address, ctx.User)
@@ -314,10 +382,13 @@ func SettingsPost(ctx *context.Context) {
 		}
 
 		m := &models.PushMirror{
-			RepoID:     repo.ID,
-			Repo:       repo,
-			RemoteName: fmt.Sprintf("remote_mirror_%s", remoteSuffix),
-			Interval:   interval,
+			RepoID:      repo.ID,
+			Repo:        repo,
+			RemoteName:  fmt.Sprintf("remote_mirror_%s", remoteSuffix),
+			Interval:    interval,
+			SyncOnPush:  form.PushMirrorSyncOnPush,
+			IncludeRefs: form.PushMirrorIncludeRefs,
+			ExcludeRefs: form.PushMirrorExcludeRefs,
 		}
 		if err := models.InsertPushMirror(m); err != nil {
 			ctx.ServerError("InsertPushMirror", err)
@@ -485,6 +556,16 @@ func SettingsPost(ctx *context.Context) {
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
 		ctx.Redirect(ctx.Repo.RepoLink + "/settings")
 
+	case "export":
+		if _, err := task.ExportRepository(ctx.User, repo); err != nil {
+			ctx.ServerError("ExportRepository", err)
+			return
+		}
+		log.Trace("Repository export queued: %s/%s", ctx.Repo.Owner.Name, repo.Name)
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.export.queued"))
+		ctx.Redirect(ctx.Repo.RepoLink + "/settings")
+
 	case "admin":
 		if !ctx.User.IsAdmin {
 			ctx.Error(http.StatusForbidden)
@@ -1085,15 +1166,22 @@ func DeployKeysPost(ctx *context.Context) {
 	}
 
 	log.Trace("Deploy key added: %d", ctx.Repo.Repository.ID)
+	audit.Record(admin.AuditDeployKeyAdd, ctx.User, ctx.RemoteAddr(), key, nil, audit.DeployKeySnapshot(key))
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_key_success", key.Name))
 	ctx.Redirect(ctx.Repo.RepoLink + "/settings/keys")
 }
 
 // DeleteDeployKey response for deleting a deploy key
 func DeleteDeployKey(ctx *context.Context) {
-	if err := models.DeleteDeployKey(ctx.User, ctx.FormInt64("id")); err != nil {
+	key, err := models.GetDeployKeyByID(ctx.FormInt64("id"))
+	if err != nil && !models.IsErrDeployKeyNotExist(err) {
+		ctx.Flash.Error("DeleteDeployKey: " + err.Error())
+	} else if err := models.DeleteDeployKey(ctx.User, ctx.FormInt64("id")); err != nil {
 		ctx.Flash.Error("DeleteDeployKey: " + err.Error())
 	} else {
+		if key != nil {
+			audit.Record(admin.AuditDeployKeyRemove, ctx.User, ctx.RemoteAddr(), key, audit.DeployKeySnapshot(key), nil)
+		}
 		ctx.Flash.Success(ctx.Tr("repo.settings.deploy_key_deletion_success"))
 	}
 
@@ -1160,6 +1248,18 @@ func SettingsDeleteAvatar(ctx *context.Context) {
 	ctx.Redirect(ctx.Repo.RepoLink + "/settings")
 }
 
+// validatePushMirrorRefPatterns renders the settings with an error if the ref patterns of the push mirror are invalid
+func validatePushMirrorRefPatterns(ctx *context.Context, form *forms.RepoSettingForm) bool {
+	for _, patterns := range []string{form.PushMirrorIncludeRefs, form.PushMirrorExcludeRefs} {
+		if err := models.ValidateRefPatterns(patterns); err != nil {
+			ctx.Data["Err_PushMirrorRefs"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.settings.mirror_settings.push_mirror.refs_invalid", err.Error()), tplSettingsOptions, form)
+			return false
+		}
+	}
+	return true
+}
+
 func selectPushMirrorByForm(form *forms.RepoSettingForm, repo *models.Repository) (*models.PushMirror, error) {
 	id, err := strconv.ParseInt(form.PushMirrorID, 10, 64)
 	if err != nil {
@@ -1178,3 +1278,29 @@ func selectPushMirrorByForm(form *forms.RepoSettingForm, repo *models.Repository
 
 	return nil, fmt.Errorf("PushMirror[%v] not associated to repository %v", id, repo)
 }
+
+// SettingsExportArchive downloads the archive of the latest export of a repository
+func SettingsExportArchive(ctx *context.Context) {
+	t, err := models.GetRepoTask(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"), structs.TaskTypeExportRepo)
+	if err != nil {
+		if models.IsErrTaskDoesNotExist(err) {
+			ctx.NotFound("GetRepoTask", err)
+		} else {
+			ctx.ServerError("GetRepoTask", err)
+		}
+		return
+	}
+	if t.Status != structs.TaskStatusFinished {
+		ctx.NotFound("GetRepoTask", nil)
+		return
+	}
+
+	fr, err := storage.RepoExports.Open(t.ArchiveRelativePath())
+	if err != nil {
+		ctx.ServerError("Open", err)
+		return
+	}
+	defer fr.Close()
+
+	ctx.ServeStream(fr, fmt.Sprintf("%s-%s.zip", ctx.Repo.Repository.OwnerName, ctx.Repo.Repository.Name))
+}
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/git"
@@ -91,35 +93,65 @@ func ProtectedBranchPost(ctx *context.Context) {
 
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
 		ctx.Redirect(setting.AppSubURL + ctx.Req.URL.EscapedPath())
+	case "add_rule":
+		ruleName := strings.TrimSpace(ctx.FormString("rule_name"))
+		if !models.IsValidProtectedBranchRuleName(ruleName) {
+			ctx.Flash.Error(ctx.Tr("repo.settings.protected_branch_rule_name_invalid", ruleName))
+			ctx.Redirect(fmt.Sprintf("%s/settings/branches", ctx.Repo.RepoLink))
+			return
+		}
+		ctx.Redirect(fmt.Sprintf("%s/settings/branches/%s", ctx.Repo.RepoLink, util.PathEscapeSegments(ruleName)))
 	default:
 		ctx.NotFound("", nil)
 	}
 }
 
+// getProtectedBranchRule returns the protection rule named by the route, a new rule if it does not exist yet.
+// Rules which do not exist yet have to be a glob pattern or name an existing branch.
+func getProtectedBranchRule(ctx *context.Context) *models.ProtectedBranch {
+	ruleName := ctx.Params("*")
+
+	protectBranch, err := models.GetProtectedBranchBy(ctx.Repo.Repository.ID, ruleName)
+	if err != nil {
+		ctx.ServerError("GetProtectBranchOfRepoByName", err)
+		return nil
+	}
+	if protectBranch != nil {
+		return protectBranch
+	}
+
+	// No options found, create defaults.
+	protectBranch = &models.ProtectedBranch{
+		RepoID:     ctx.Repo.Repository.ID,
+		BranchName: ruleName,
+	}
+	if !models.IsValidProtectedBranchRuleName(ruleName) ||
+		(!models.IsProtectedBranchRuleNamePattern(ruleName) && !ctx.Repo.GitRepo.IsBranchExist(ruleName)) {
+		ctx.NotFound("IsBranchExist", nil)
+		return nil
+	}
+	return protectBranch
+}
+
 // SettingsProtectedBranch renders the protected branch setting page
 func SettingsProtectedBranch(c *context.Context) {
-	branch := c.Params("*")
-	if !c.Repo.GitRepo.IsBranchExist(branch) {
-		c.NotFound("IsBranchExist", nil)
+	protectBranch := getProtectedBranchRule(c)
+	if c.Written() {
 		return
 	}
 
-	c.Data["Title"] = c.Tr("repo.settings.protected_branch") + " - " + branch
+	c.Data["Title"] = c.Tr("repo.settings.protected_branch") + " - " + protectBranch.BranchName
 	c.Data["PageIsSettingsBranches"] = true
 
-	protectBranch, err := models.GetProtectedBranchBy(c.Repo.Repository.ID, branch)
-	if err != nil {
-		if !git.IsErrBranchNotExist(err) {
-			c.ServerError("GetProtectBranchOfRepoByName", err)
-			return
-		}
-	}
-
-	if protectBranch == nil {
-		// No options found, create defaults.
-		protectBranch = &models.ProtectedBranch{
-			BranchName: branch,
+	if protectBranch.IsRuleNamePattern() {
+		branches := c.Data["Branches"].([]string)
+		matchedBranches := make([]string, 0, len(branches))
+		for _, b := range branches {
+			if protectBranch.Match(b) {
+				matchedBranches = append(matchedBranches, b)
+			}
 		}
+		c.Data["MatchedBranches"] = matchedBranches
 	}
 
 	users, err := c.Repo.Repository.GetReaders()
@@ -174,31 +206,21 @@ func SettingsProtectedBranch(c *context.Context) {
 // SettingsProtectedBranchPost updates the protected branch settings
 func SettingsProtectedBranchPost(ctx *context.Context) {
 	f := web.GetForm(ctx).(*forms.ProtectBranchForm)
-	branch := ctx.Params("*")
-	if !ctx.Repo.GitRepo.IsBranchExist(branch) {
-		ctx.NotFound("IsBranchExist", nil)
+	protectBranch := getProtectedBranchRule(ctx)
+	if ctx.Written() {
 		return
 	}
-
-	protectBranch, err := models.GetProtectedBranchBy(ctx.Repo.Repository.ID, branch)
-	if err != nil {
-		if !git.IsErrBranchNotExist(err) {
-			ctx.ServerError("GetProtectBranchOfRepoByName", err)
-			return
-		}
+	branch := protectBranch.BranchName
+	var before interface{}
+	if protectBranch.IsProtected() {
+		before = audit.ProtectedBranchSnapshot(protectBranch)
 	}
 
 	if f.Protected {
-		if protectBranch == nil {
-			// No options found, create defaults.
-			protectBranch = &models.ProtectedBranch{
-				RepoID:     ctx.Repo.Repository.ID,
-				BranchName: branch,
-			}
-		}
 		if f.RequiredApprovals < 0 {
 			ctx.Flash.Error(ctx.Tr("repo.settings.protected_branch_required_approvals_min"))
 			ctx.Redirect(fmt.Sprintf("%s/settings/branches/%s", ctx.Repo.RepoLink, util.PathEscapeSegments(branch)))
+			return
 		}
 
 		var whitelistUsers, whitelistTeams, mergeWhitelistUsers, mergeWhitelistTeams, approvalsWhitelistUsers, approvalsWhitelistTeams []int64
@@ -252,13 +274,16 @@ func SettingsProtectedBranchPost(ctx *context.Context) {
 		}
 		protectBranch.BlockOnRejectedReviews = f.BlockOnRejectedReviews
 		protectBranch.BlockOnOfficialReviewRequests = f.BlockOnOfficialReviewRequests
+		protectBranch.RequireCodeOwnerReviews = f.RequireCodeOwnerReviews
 		protectBranch.DismissStaleApprovals = f.DismissStaleApprovals
 		protectBranch.RequireSignedCommits = f.RequireSignedCommits
 		protectBranch.ProtectedFilePatterns = f.ProtectedFilePatterns
 		protectBranch.UnprotectedFilePatterns = f.UnprotectedFilePatterns
 		protectBranch.BlockOnOutdatedBranch = f.BlockOnOutdatedBranch
+		protectBranch.EnableMergeQueue = f.EnableMergeQueue
+		protectBranch.Priority = f.Priority
 
-		err = models.UpdateProtectBranch(ctx.Repo.Repository, protectBranch, models.WhitelistOptions{
+		err := models.UpdateProtectBranch(ctx.Repo.Repository, protectBranch, models.WhitelistOptions{
 			UserIDs:          whitelistUsers,
 			TeamIDs:          whitelistTeams,
 			MergeUserIDs:     mergeWhitelistUsers,
@@ -270,18 +295,24 @@ func SettingsProtectedBranchPost(ctx *context.Context) {
 			ctx.ServerError("UpdateProtectBranch", err)
 			return
 		}
-		if err = pull_service.CheckPrsForBaseBranch(ctx.Repo.Repository, protectBranch.BranchName); err != nil {
-			ctx.ServerError("CheckPrsForBaseBranch", err)
+		if before == nil {
+			audit.Record(admin.AuditBranchProtectionCreate, ctx.User, ctx.RemoteAddr(), protectBranch, nil, audit.ProtectedBranchSnapshot(protectBranch))
+		} else {
+			audit.Record(admin.AuditBranchProtectionUpdate, ctx.User, ctx.RemoteAddr(), protectBranch, before, audit.ProtectedBranchSnapshot(protectBranch))
+		}
+		if err := pull_service.CheckPrsForProtectedBranch(ctx.Repo.Repository, protectBranch); err != nil {
+			ctx.ServerError("CheckPrsForProtectedBranch", err)
 			return
 		}
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_protect_branch_success", branch))
 		ctx.Redirect(fmt.Sprintf("%s/settings/branches/%s", ctx.Repo.RepoLink, util.PathEscapeSegments(branch)))
 	} else {
-		if protectBranch != nil {
+		if protectBranch.IsProtected() {
 			if err := ctx.Repo.Repository.DeleteProtectedBranch(protectBranch.ID); err != nil {
 				ctx.ServerError("DeleteProtectedBranch", err)
 				return
 			}
+			audit.Record(admin.AuditBranchProtectionDelete, ctx.User, ctx.RemoteAddr(), protectBranch, before, nil)
 		}
 		ctx.Flash.Success(ctx.Tr("repo.settings.remove_protected_branch_success", branch))
 		ctx.Redirect(fmt.Sprintf("%s/settings/branches", ctx.Repo.RepoLink))
@@ -0,0 +1,129 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/context"
+	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// maxSymbolLocations is the maximum number of definitions and of references returned
+const maxSymbolLocations = 50
+
+// symbolLocation is the location of a definition or a reference of a symbol
+type symbolLocation struct {
+	Repo    string `json:"repo"`
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Kind    string `json:"kind,omitempty"`
+	Content string `json:"content,omitempty"`
+	URL     string `json:"url"`
+}
+
+// Symbols returns the definitions and references of a symbol in the repository, or in the
+// repositories of its organization if scope is "org"
+func Symbols(ctx *context.Context) {
+	if !setting.Indexer.RepoIndexerEnabled || !setting.Indexer.ExtractSymbols {
+		ctx.NotFound("Symbols", nil)
+		return
+	}
+	name := ctx.FormTrim("name")
+	if name == "" {
+		ctx.Error(http.StatusBadRequest, "name is required")
+		return
+	}
+
+	repos := map[int64]*models.Repository{ctx.Repo.Repository.ID: ctx.Repo.Repository}
+	if ctx.FormString("scope") == "org" && ctx.Repo.Owner.IsOrganization() {
+		var err error
+		if repos, err = readableOwnerRepos(ctx); err != nil {
+			ctx.ServerError("readableOwnerRepos", err)
+			return
+		}
+	}
+	repoIDs := make([]int64, 0, len(repos))
+	for id := range repos {
+		repoIDs = append(repoIDs, id)
+	}
+
+	symbols, err := models.FindRepoSymbols(&models.FindRepoSymbolsOptions{
+		ListOptions: db.ListOptions{PageSize: maxSymbolLocations},
+		RepoIDs:     repoIDs,
+		Name:        name,
+	})
+	if err != nil {
+		ctx.ServerError("FindRepoSymbols", err)
+		return
+	}
+	definitions := make([]*symbolLocation, 0, len(symbols))
+	for _, symbol := range symbols {
+		repo := repos[symbol.RepoID]
+		definitions = append(definitions, &symbolLocation{
+			Repo: repo.FullName(),
+			Path: symbol.Path,
+			Line: symbol.Line,
+			Kind: symbol.Kind,
+			URL:  symbolURL(repo, symbol.CommitID, symbol.Path, symbol.Line),
+		})
+	}
+
+	refs, err := code_indexer.FindReferences(repoIDs, name, maxSymbolLocations)
+	if err != nil {
+		ctx.ServerError("FindReferences", err)
+		return
+	}
+	references := make([]*symbolLocation, 0, len(refs))
+	for _, ref := range refs {
+		repo, ok := repos[ref.RepoID]
+		if !ok {
+			continue
+		}
+		references = append(references, &symbolLocation{
+			Repo:    repo.FullName(),
+			Path:    ref.Filename,
+			Line:    ref.Line,
+			Content: ref.Content,
+			URL:     symbolURL(repo, ref.CommitID, ref.Filename, ref.Line),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"definitions": definitions,
+		"references":  references,
+	})
+}
+
+// readableOwnerRepos returns the repositories of the owner of the current repository whose
+// code can be read by the user
+func readableOwnerRepos(ctx *context.Context) (map[int64]*models.Repository, error) {
+	repos, _, err := models.SearchRepository(&models.SearchRepoOptions{
+		ListOptions: db.ListOptions{PageSize: setting.API.MaxResponseItems},
+		Actor:       ctx.User,
+		OwnerID:     ctx.Repo.Owner.ID,
+		Private:     ctx.IsSigned,
+	})
+	if err != nil {
+		return nil, err
+	}
+	readable := map[int64]*models.Repository{ctx.Repo.Repository.ID: ctx.Repo.Repository}
+	for _, repo := range repos {
+		if repo.CheckUnitUser(ctx.User, unit.TypeCode) {
+			readable[repo.ID] = repo
+		}
+	}
+	return readable, nil
+}
+
+func symbolURL(repo *models.Repository, commitID, path string, line int) string {
+	return fmt.Sprintf("%s/src/commit/%s/%s#L%d", repo.Link(), commitID, util.PathEscapeSegments(path), line)
+}
@@ -22,6 +22,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/models/db"
 	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/analyze"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/cache"
 	"code.gitea.io/gitea/modules/charset"
@@ -33,6 +34,7 @@ import (
 	"code.gitea.io/gitea/modules/markup"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/symbol"
 	"code.gitea.io/gitea/modules/typesniffer"
 	"code.gitea.io/gitea/modules/util"
 )
@@ -529,6 +531,13 @@ func renderFile(ctx *context.Context, entry *git.TreeEntry, treeLink, rawLink st
 				}
 			}
 			ctx.Data["FileContent"] = highlight.File(lineNums, blob.Name(), language, buf)
+
+			if setting.Indexer.RepoIndexerEnabled && setting.Indexer.ExtractSymbols {
+				if language == "" {
+					language = analyze.GetCodeLanguage(blob.Name(), buf)
+				}
+				ctx.Data["SymbolNavigation"] = symbol.IsSupported(language)
+			}
 		}
 		if !isLFSFile {
 			if ctx.Repo.CanEnableEditor() {
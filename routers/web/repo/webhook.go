@@ -219,6 +219,7 @@ func GiteaHooksNewPost(ctx *context.Context) {
 		Secret:          form.Secret,
 		HookEvent:       ParseHookEvent(form.WebhookForm),
 		IsActive:        form.Active,
+		MaxAttempts:     form.MaxAttempts,
 		Type:            webhook.GITEA,
 		OrgID:           orCtx.OrgID,
 		IsSystemWebhook: orCtx.IsSystemWebhook,
@@ -273,6 +274,7 @@ func newGogsWebhookPost(ctx *context.Context, form forms.NewGogshookForm, kind w
 		Secret:          form.Secret,
 		HookEvent:       ParseHookEvent(form.WebhookForm),
 		IsActive:        form.Active,
+		MaxAttempts:     form.MaxAttempts,
 		Type:            kind,
 		OrgID:           orCtx.OrgID,
 		IsSystemWebhook: orCtx.IsSystemWebhook,
@@ -324,6 +326,7 @@ func DiscordHooksNewPost(ctx *context.Context) {
 		ContentType:     webhook.ContentTypeJSON,
 		HookEvent:       ParseHookEvent(form.WebhookForm),
 		IsActive:        form.Active,
+		MaxAttempts:     form.MaxAttempts,
 		Type:            webhook.DISCORD,
 		Meta:            string(meta),
 		OrgID:           orCtx.OrgID,
@@ -367,6 +370,7 @@ func DingtalkHooksNewPost(ctx *context.Context) {
 		ContentType:     webhook.ContentTypeJSON,
 		HookEvent:       ParseHookEvent(form.WebhookForm),
 		IsActive:        form.Active,
+		MaxAttempts:     form.MaxAttempts,
 		Type:            webhook.DINGTALK,
 		Meta:            "",
 		OrgID:           orCtx.OrgID,
@@ -419,6 +423,7 @@ func TelegramHooksNewPost(ctx *context.Context) {
 		ContentType:     webhook.ContentTypeJSON,
 		HookEvent:       ParseHookEvent(form.WebhookForm),
 		IsActive:        form.Active,
+		MaxAttempts:     form.MaxAttempts,
 		Type:            webhook.TELEGRAM,
 		Meta:            string(meta),
 		OrgID:           orCtx.OrgID,
@@ -474,6 +479,7 @@ func MatrixHooksNewPost(ctx *context.Context) {
 		HTTPMethod:      "PUT",
 		HookEvent:       ParseHookEvent(form.WebhookForm),
 		IsActive:        form.Active,
+		MaxAttempts:     form.MaxAttempts,
 		Type:            webhook.MATRIX,
 		Meta:            string(meta),
 		OrgID:           orCtx.OrgID,
@@ -517,6 +523,7 @@ func MSTeamsHooksNewPost(ctx *context.Context) {
 		ContentType:     webhook.ContentTypeJSON,
 		HookEvent:       ParseHookEvent(form.WebhookForm),
 		IsActive:        form.Active,
+		MaxAttempts:     form.MaxAttempts,
 		Type:            webhook.MSTEAMS,
 		Meta:            "",
 		OrgID:           orCtx.OrgID,
@@ -577,6 +584,7 @@ func SlackHooksNewPost(ctx *context.Context) {
 		ContentType:     webhook.ContentTypeJSON,
 		HookEvent:       ParseHookEvent(form.WebhookForm),
 		IsActive:        form.Active,
+		MaxAttempts:     form.MaxAttempts,
 		Type:            webhook.SLACK,
 		Meta:            string(meta),
 		OrgID:           orCtx.OrgID,
@@ -620,6 +628,7 @@ func FeishuHooksNewPost(ctx *context.Context) {
 		ContentType:     webhook.ContentTypeJSON,
 		HookEvent:       ParseHookEvent(form.WebhookForm),
 		IsActive:        form.Active,
+		MaxAttempts:     form.MaxAttempts,
 		Type:            webhook.FEISHU,
 		Meta:            "",
 		OrgID:           orCtx.OrgID,
@@ -664,6 +673,7 @@ func WechatworkHooksNewPost(ctx *context.Context) {
 		ContentType:     webhook.ContentTypeJSON,
 		HookEvent:       ParseHookEvent(form.WebhookForm),
 		IsActive:        form.Active,
+		MaxAttempts:     form.MaxAttempts,
 		Type:            webhook.WECHATWORK,
 		Meta:            "",
 		OrgID:           orCtx.OrgID,
@@ -720,9 +730,18 @@ func checkWebhook(ctx *context.Context) (*orgRepoCtx, *webhook.Webhook) {
 		ctx.Data["MatrixHook"] = webhook_service.GetMatrixHook(w)
 	}
 
-	ctx.Data["History"], err = w.History(1)
+	status := webhook.HookTaskStatus(ctx.FormString("status"))
+	if status != webhook.HookTaskStatusFailed && status != webhook.HookTaskStatusPending {
+		status = ""
+	}
+	ctx.Data["HistoryStatus"] = status
+	ctx.Data["History"], _, err = webhook.FindHookTasks(&webhook.FindHookTasksOptions{
+		ListOptions: db.ListOptions{Page: 1, PageSize: setting.Webhook.PagingNum},
+		HookID:      w.ID,
+		Status:      status,
+	})
 	if err != nil {
-		ctx.ServerError("History", err)
+		ctx.ServerError("FindHookTasks", err)
 	}
 	return orCtx, w
 }
@@ -770,6 +789,7 @@ func WebHooksEditPost(ctx *context.Context) {
 	w.Secret = form.Secret
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
+	w.MaxAttempts = form.MaxAttempts
 	w.HTTPMethod = form.HTTPMethod
 	if err := w.UpdateEvent(); err != nil {
 		ctx.ServerError("UpdateEvent", err)
@@ -811,6 +831,7 @@ func GogsHooksEditPost(ctx *context.Context) {
 	w.Secret = form.Secret
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
+	w.MaxAttempts = form.MaxAttempts
 	if err := w.UpdateEvent(); err != nil {
 		ctx.ServerError("UpdateEvent", err)
 		return
@@ -862,6 +883,7 @@ func SlackHooksEditPost(ctx *context.Context) {
 	w.Meta = string(meta)
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
+	w.MaxAttempts = form.MaxAttempts
 	if err := w.UpdateEvent(); err != nil {
 		ctx.ServerError("UpdateEvent", err)
 		return
@@ -905,6 +927,7 @@ func DiscordHooksEditPost(ctx *context.Context) {
 	w.Meta = string(meta)
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
+	w.MaxAttempts = form.MaxAttempts
 	if err := w.UpdateEvent(); err != nil {
 		ctx.ServerError("UpdateEvent", err)
 		return
@@ -938,6 +961,7 @@ func DingtalkHooksEditPost(ctx *context.Context) {
 	w.URL = form.PayloadURL
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
+	w.MaxAttempts = form.MaxAttempts
 	if err := w.UpdateEvent(); err != nil {
 		ctx.ServerError("UpdateEvent", err)
 		return
@@ -980,6 +1004,7 @@ func TelegramHooksEditPost(ctx *context.Context) {
 	w.URL = fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage?chat_id=%s", url.PathEscape(form.BotToken), url.QueryEscape(form.ChatID))
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
+	w.MaxAttempts = form.MaxAttempts
 	if err := w.UpdateEvent(); err != nil {
 		ctx.ServerError("UpdateEvent", err)
 		return
@@ -1025,6 +1050,7 @@ func MatrixHooksEditPost(ctx *context.Context) {
 
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
+	w.MaxAttempts = form.MaxAttempts
 	if err := w.UpdateEvent(); err != nil {
 		ctx.ServerError("UpdateEvent", err)
 		return
@@ -1058,6 +1084,7 @@ func MSTeamsHooksEditPost(ctx *context.Context) {
 	w.URL = form.PayloadURL
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
+	w.MaxAttempts = form.MaxAttempts
 	if err := w.UpdateEvent(); err != nil {
 		ctx.ServerError("UpdateEvent", err)
 		return
@@ -1091,6 +1118,7 @@ func FeishuHooksEditPost(ctx *context.Context) {
 	w.URL = form.PayloadURL
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
+	w.MaxAttempts = form.MaxAttempts
 	if err := w.UpdateEvent(); err != nil {
 		ctx.ServerError("UpdateEvent", err)
 		return
@@ -1124,6 +1152,7 @@ func WechatworkHooksEditPost(ctx *context.Context) {
 	w.URL = form.PayloadURL
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
+	w.MaxAttempts = form.MaxAttempts
 	if err := w.UpdateEvent(); err != nil {
 		ctx.ServerError("UpdateEvent", err)
 		return
@@ -1193,6 +1222,29 @@ func TestWebhook(ctx *context.Context) {
 	}
 }
 
+// RedeliverWebhook delivers again the failed deliveries of a webhook, or only the given one
+func RedeliverWebhook(ctx *context.Context) {
+	orCtx, w := checkWebhook(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	var ids []int64
+	if id := ctx.FormInt64("id"); id > 0 {
+		ids = []int64{id}
+	}
+	tasks, err := webhook_service.Redeliver(w, ids)
+	if err != nil {
+		ctx.ServerError("Redeliver", err)
+		return
+	}
+
+	ctx.Flash.Info(ctx.Tr("repo.settings.webhook.redeliver_success", len(tasks)))
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"redirect": fmt.Sprintf("%s/%d", orCtx.Link, w.ID),
+	})
+}
+
 // DeleteWebhook delete a webhook
 func DeleteWebhook(ctx *context.Context) {
 	if err := webhook.DeleteWebhookByRepoID(ctx.Repo.Repository.ID, ctx.FormInt64("id")); err != nil {
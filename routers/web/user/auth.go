@@ -13,9 +13,11 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/login"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/eventsource"
@@ -180,6 +182,9 @@ func SignInPost(ctx *context.Context) {
 	form := web.GetForm(ctx).(*forms.SignInForm)
 	u, source, err := auth.UserSignIn(form.UserName, form.Password)
 	if err != nil {
+		audit.Record(admin.AuditUserSignInFailed, nil, ctx.RemoteAddr(), form.UserName, nil, map[string]interface{}{
+			"reason": err.Error(),
+		})
 		if models.IsErrUserNotExist(err) {
 			ctx.RenderWithErr(ctx.Tr("form.username_password_incorrect"), tplSignIn, &form)
 			log.Info("Failed authentication attempt for %s from %s: %v", form.UserName, ctx.RemoteAddr(), err)
@@ -344,6 +349,11 @@ func TwoFactorPost(ctx *context.Context) {
 		return
 	}
 
+	if u, err := models.GetUserByID(id); err == nil {
+		audit.Record(admin.AuditUserSignInFailed, u, ctx.RemoteAddr(), u, nil, map[string]interface{}{
+			"reason": "incorrect two factor passcode",
+		})
+	}
 	ctx.RenderWithErr(ctx.Tr("auth.twofa_passcode_incorrect"), tplTwofa, forms.TwoFactorAuthForm{})
 }
 
@@ -536,6 +546,8 @@ func handleSignIn(ctx *context.Context, u *models.User, remember bool) {
 }
 
 func handleSignInFull(ctx *context.Context, u *models.User, remember bool, obeyRedirect bool) string {
+	audit.Record(admin.AuditUserSignIn, u, ctx.RemoteAddr(), u, nil, nil)
+
 	if remember {
 		days := 86400 * setting.LogInRememberDays
 		ctx.SetCookie(setting.CookieUserName, u.Name, days)
@@ -1137,6 +1149,7 @@ func HandleSignOut(ctx *context.Context) {
 // SignOut sign out from login status
 func SignOut(ctx *context.Context) {
 	if ctx.User != nil {
+		audit.Record(admin.AuditUserSignOut, ctx.User, ctx.RemoteAddr(), ctx.User, nil, nil)
 		eventsource.GetManager().SendMessageBlocking(ctx.User.ID, &eventsource.Event{
 			Name: "logout",
 			Data: ctx.Session.ID(),
@@ -439,25 +439,41 @@ func buildIssueOverview(ctx *context.Context, unitType unit.Type) {
 	keyword := strings.Trim(ctx.FormString("q"), " ")
 	ctx.Data["Keyword"] = keyword
 
+	// Apply the qualifiers of the search query, they override the filters of the menus.
+	// The issues are still limited to the repositories the user has access to.
+	query := issue_indexer.ParseQuery(keyword)
+	if query.HasFilters() && len(opts.RepoIDs) == 0 {
+		opts.RepoIDs = userRepoIDs
+	}
+	matchable, err := query.Apply(opts, ctx.User)
+	if err != nil {
+		ctx.ServerError("ParseQuery", err)
+		return
+	}
+
 	// Execute keyword search for issues.
 	// USING NON-FINAL STATE OF opts FOR A QUERY.
-	issueIDsFromSearch, err := issueIDsFromSearch(ctxUser, keyword, opts)
+	issueIDsFromSearch, err := issueIDsFromSearch(ctxUser, query.Keyword, opts)
 	if err != nil {
 		ctx.ServerError("issueIDsFromSearch", err)
 		return
 	}
 
-	// Ensure no issues are returned if a keyword was provided that didn't match any issues.
-	var forceEmpty bool
+	// Ensure no issues are returned if the query cannot match any issues,
+	// or if a keyword was provided that didn't match any issues.
+	forceEmpty := !matchable
 
 	if len(issueIDsFromSearch) > 0 {
 		opts.IssueIDs = issueIDsFromSearch
-	} else if len(keyword) > 0 {
+	} else if len(query.Keyword) > 0 {
 		forceEmpty = true
 	}
 
 	// Educated guess: Do or don't show closed issues.
 	isShowClosed := ctx.FormString("state") == "closed"
+	if !query.IsClosed.IsNone() {
+		isShowClosed = query.IsClosed.IsTrue()
+	}
 	opts.IsClosed = util.OptionalBoolOf(isShowClosed)
 
 	// Filter repos and count issues in them. Count will be used later.
@@ -576,7 +592,16 @@ func buildIssueOverview(ctx *context.Context, unitType unit.Type) {
 	}
 
 	var shownIssueStats *models.IssueStats
-	if !forceEmpty {
+	if forceEmpty {
+		shownIssueStats = &models.IssueStats{}
+	} else if query.HasFilters() {
+		// the user issue stats know nothing about the qualifiers of the query
+		shownIssueStats, err = models.CountIssueStats(opts)
+		if err != nil {
+			ctx.ServerError("CountIssueStats", err)
+			return
+		}
+	} else {
 		statsOpts := models.UserIssueStatsOptions{
 			UserID:      ctx.User.ID,
 			UserRepoIDs: userRepoIDs,
@@ -597,8 +622,6 @@ func buildIssueOverview(ctx *context.Context, unitType unit.Type) {
 			ctx.ServerError("GetUserIssueStats Shown", err)
 			return
 		}
-	} else {
-		shownIssueStats = &models.IssueStats{}
 	}
 
 	var allIssueStats *models.IssueStats
@@ -13,6 +13,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/markup"
@@ -25,7 +26,7 @@ import (
 
 // GetUserByName get user by name
 func GetUserByName(ctx *context.Context, name string) *models.User {
-	user, err := models.GetUserByName(name)
+	user, err := models.GetUserByNameCtx(ctx, name)
 	if err != nil {
 		if models.IsErrUserNotExist(err) {
 			if redirectUserID, err := user_model.LookupUserRedirect(name); err == nil {
@@ -187,6 +188,7 @@ func Profile(ctx *context.Context) {
 
 	tab := ctx.FormString("tab")
 	ctx.Data["TabName"] = tab
+	ctx.Data["EnablePackages"] = setting.Packages.Enabled
 
 	page := ctx.FormInt("page")
 	if page <= 0 {
@@ -299,6 +301,39 @@ func Profile(ctx *context.Context) {
 			ctx.ServerError("GetProjects", err)
 			return
 		}
+	case "packages":
+		if !setting.Packages.Enabled {
+			ctx.NotFound("Packages", nil)
+			return
+		}
+
+		var pkgs []*packages_model.Package
+		pkgs, count, err = packages_model.SearchPackages(ctx, &packages_model.PackageSearchOptions{
+			ListOptions: db.ListOptions{
+				PageSize: setting.UI.User.RepoPagingNum,
+				Page:     page,
+			},
+			OwnerID: ctxUser.ID,
+			Query:   keyword,
+		})
+		if err != nil {
+			ctx.ServerError("SearchPackages", err)
+			return
+		}
+
+		versions := make(map[int64]*packages_model.PackageVersion, len(pkgs))
+		for _, p := range pkgs {
+			pv, err := packages_model.GetLatestVersionByPackageID(ctx, p.ID)
+			if err != nil && !packages_model.IsErrPackageVersionNotExist(err) {
+				ctx.ServerError("GetLatestVersionByPackageID", err)
+				return
+			}
+			versions[p.ID] = pv
+		}
+
+		ctx.Data["Packages"] = pkgs
+		ctx.Data["PackageVersions"] = versions
+		total = int(count)
 	case "watching":
 		repos, count, err = models.SearchRepository(&models.SearchRepoOptions{
 			ListOptions: db.ListOptions{
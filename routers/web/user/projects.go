@@ -0,0 +1,634 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/forms"
+)
+
+const (
+	tplProjects     base.TplName = "user/project/list"
+	tplProjectsNew  base.TplName = "user/project/new"
+	tplProjectsView base.TplName = "user/project/view"
+
+	tplProjectsAutomation base.TplName = "user/project/automation"
+)
+
+// projectsLink returns the link to the projects of the owner in the context
+func projectsLink(ctx *context.Context) string {
+	return ctx.ProjectOwner.Owner.HomeLink() + "/-/projects"
+}
+
+// projectType returns the type of the projects of the owner in the context
+func projectType(ctx *context.Context) models.ProjectType {
+	if ctx.ProjectOwner.Owner.IsOrganization() {
+		return models.ProjectTypeOrganization
+	}
+	return models.ProjectTypeIndividual
+}
+
+// MustWriteProjects checks if the projects of the owner in the context can be written
+func MustWriteProjects(ctx *context.Context) {
+	if !ctx.ProjectOwner.CanWrite() {
+		ctx.NotFound("MustWriteProjects", nil)
+	}
+}
+
+// Projects renders the projects of a user or an organization
+func Projects(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.project_board")
+
+	sortType := ctx.FormTrim("sort")
+
+	isShowClosed := strings.ToLower(ctx.FormTrim("state")) == "closed"
+	page := ctx.FormInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	opts := models.ProjectSearchOptions{
+		OwnerID:  ctx.ProjectOwner.Owner.ID,
+		Page:     page,
+		IsClosed: util.OptionalBoolOf(isShowClosed),
+		SortType: sortType,
+		Type:     projectType(ctx),
+	}
+	projects, count, err := models.GetProjects(opts)
+	if err != nil {
+		ctx.ServerError("GetProjects", err)
+		return
+	}
+
+	opts.IsClosed = util.OptionalBoolOf(!isShowClosed)
+	otherCount, err := models.CountProjects(opts)
+	if err != nil {
+		ctx.ServerError("CountProjects", err)
+		return
+	}
+	if isShowClosed {
+		ctx.Data["OpenCount"], ctx.Data["ClosedCount"] = otherCount, count
+	} else {
+		ctx.Data["OpenCount"], ctx.Data["ClosedCount"] = count, otherCount
+	}
+
+	for i := range projects {
+		projects[i].RenderedContent, err = renderProjectDescription(ctx, projects[i])
+		if err != nil {
+			ctx.ServerError("RenderString", err)
+			return
+		}
+	}
+
+	ctx.Data["Projects"] = projects
+
+	if isShowClosed {
+		ctx.Data["State"] = "closed"
+	} else {
+		ctx.Data["State"] = "open"
+	}
+
+	pager := context.NewPagination(int(count), setting.UI.IssuePagingNum, page, 5)
+	pager.AddParam(ctx, "state", "State")
+	ctx.Data["Page"] = pager
+
+	ctx.Data["ProjectsLink"] = projectsLink(ctx)
+	ctx.Data["IsShowClosed"] = isShowClosed
+	ctx.Data["SortType"] = sortType
+
+	ctx.HTML(http.StatusOK, tplProjects)
+}
+
+func renderProjectDescription(ctx *context.Context, p *models.Project) (string, error) {
+	return markdown.RenderString(&markup.RenderContext{
+		URLPrefix: ctx.ProjectOwner.Owner.HomeLink(),
+		Ctx:       ctx,
+	}, p.Description)
+}
+
+// NewProject render creating a project page
+func NewProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.Data["ProjectTypes"] = models.GetProjectsConfig()
+	ctx.Data["ProjectsLink"] = projectsLink(ctx)
+	ctx.HTML(http.StatusOK, tplProjectsNew)
+}
+
+// NewProjectPost creates a new project
+func NewProjectPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.CreateProjectForm)
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.Data["ProjectsLink"] = projectsLink(ctx)
+
+	if ctx.HasError() {
+		ctx.Data["ProjectTypes"] = models.GetProjectsConfig()
+		ctx.HTML(http.StatusOK, tplProjectsNew)
+		return
+	}
+
+	if err := models.NewProject(&models.Project{
+		OwnerID:     ctx.ProjectOwner.Owner.ID,
+		Title:       form.Title,
+		Description: form.Content,
+		CreatorID:   ctx.User.ID,
+		BoardType:   form.BoardType,
+		Type:        projectType(ctx),
+	}); err != nil {
+		ctx.ServerError("NewProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.create_success", form.Title))
+	ctx.Redirect(projectsLink(ctx))
+}
+
+// getProject returns the project of the owner in the context from the URL
+func getProject(ctx *context.Context) *models.Project {
+	p, err := models.GetProjectByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrProjectNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectByID", err)
+		}
+		return nil
+	}
+	if p.OwnerID != ctx.ProjectOwner.Owner.ID || p.Type == models.ProjectTypeRepository {
+		ctx.NotFound("", nil)
+		return nil
+	}
+	p.Owner = ctx.ProjectOwner.Owner
+	return p
+}
+
+// ChangeProjectStatus updates the status of a project between "open" and "close"
+func ChangeProjectStatus(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.ChangeProjectStatus(p, ctx.Params(":action") == "close"); err != nil {
+		ctx.ServerError("ChangeProjectStatus", err)
+		return
+	}
+	ctx.Redirect(projectsLink(ctx) + "?state=" + url.QueryEscape(ctx.Params(":action")))
+}
+
+// DeleteProject delete a project
+func DeleteProject(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectByID(p.ID); err != nil {
+		ctx.Flash.Error("DeleteProjectByID: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.deletion_success"))
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"redirect": projectsLink(ctx),
+	})
+}
+
+// EditProject allows a project to be edited
+func EditProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsEditProjects"] = true
+	ctx.Data["ProjectsLink"] = projectsLink(ctx)
+
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.Data["title"] = p.Title
+	ctx.Data["content"] = p.Description
+
+	ctx.HTML(http.StatusOK, tplProjectsNew)
+}
+
+// EditProjectPost response for editing a project
+func EditProjectPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.CreateProjectForm)
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsEditProjects"] = true
+	ctx.Data["ProjectsLink"] = projectsLink(ctx)
+
+	if ctx.HasError() {
+		ctx.HTML(http.StatusOK, tplProjectsNew)
+		return
+	}
+
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	p.Title = form.Title
+	p.Description = form.Content
+	if err := models.UpdateProject(p); err != nil {
+		ctx.ServerError("UpdateProjects", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.edit_success", p.Title))
+	ctx.Redirect(projectsLink(ctx))
+}
+
+// ViewProject renders the project board for a project
+func ViewProject(ctx *context.Context) {
+	project := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	boards, err := models.GetProjectBoards(project.ID)
+	if err != nil {
+		ctx.ServerError("GetProjectBoards", err)
+		return
+	}
+
+	if boards[0].ID == 0 {
+		boards[0].Title = ctx.Tr("repo.projects.type.uncategorized")
+	}
+
+	if _, err := boards.LoadIssues(); err != nil {
+		ctx.ServerError("LoadIssuesOfBoards", err)
+		return
+	}
+
+	// the projects of users and organizations may hold issues from any repository, only the
+	// ones the doer can read are shown
+	issueList := make(models.IssueList, 0, 10)
+	for _, board := range boards {
+		board.Issues, err = models.IssueList(board.Issues).FilterReadableByUser(ctx.User)
+		if err != nil {
+			ctx.ServerError("FilterReadableByUser", err)
+			return
+		}
+		issueList = append(issueList, board.Issues...)
+	}
+	ctx.Data["Issues"] = issueList
+
+	linkedPrsMap := make(map[int64][]*models.Issue)
+	for _, issue := range issueList {
+		var referencedIds []int64
+		for _, comment := range issue.Comments {
+			if comment.RefIssueID != 0 && comment.RefIsPull {
+				referencedIds = append(referencedIds, comment.RefIssueID)
+			}
+		}
+
+		if len(referencedIds) > 0 {
+			if linkedPrs, err := models.Issues(&models.IssuesOptions{
+				IssueIDs: referencedIds,
+				IsPull:   util.OptionalBoolTrue,
+			}); err == nil {
+				if linkedPrs, err = models.IssueList(linkedPrs).FilterReadableByUser(ctx.User); err == nil {
+					linkedPrsMap[issue.ID] = linkedPrs
+				}
+			}
+		}
+	}
+	ctx.Data["LinkedPRs"] = linkedPrsMap
+
+	project.RenderedContent, err = renderProjectDescription(ctx, project)
+	if err != nil {
+		ctx.ServerError("RenderString", err)
+		return
+	}
+
+	ctx.Data["Title"] = project.Title
+	ctx.Data["Project"] = project
+	ctx.Data["ProjectsLink"] = projectsLink(ctx)
+	ctx.Data["ProjectLink"] = project.Link()
+	ctx.Data["Boards"] = boards
+
+	ctx.HTML(http.StatusOK, tplProjectsView)
+}
+
+func renderProjectAutomation(ctx *context.Context, p *models.Project) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.automation")
+	ctx.Data["ProjectsLink"] = projectsLink(ctx)
+	ctx.Data["Project"] = p
+	ctx.Data["ProjectLink"] = p.Link()
+	ctx.Data["AutomationEvents"] = models.ProjectAutomationEvents
+
+	boards, err := models.GetProjectBoards(p.ID)
+	if err != nil {
+		ctx.ServerError("GetProjectBoards", err)
+		return
+	}
+	ctx.Data["Boards"] = boards
+
+	rules, err := models.GetProjectAutomationRules(p.ID)
+	if err != nil {
+		ctx.ServerError("GetProjectAutomationRules", err)
+		return
+	}
+	ctx.Data["AutomationRules"] = rules
+
+	ctx.HTML(http.StatusOK, tplProjectsAutomation)
+}
+
+// ProjectAutomation renders the automation rules of a project
+func ProjectAutomation(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	renderProjectAutomation(ctx, p)
+}
+
+// ProjectAutomationPost adds an automation rule to a project
+func ProjectAutomationPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.ProjectAutomationRuleForm)
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if ctx.HasError() {
+		renderProjectAutomation(ctx, p)
+		return
+	}
+
+	if err := models.NewProjectAutomationRule(&models.ProjectAutomationRule{
+		ProjectID: p.ID,
+		Event:     form.Event,
+		BoardID:   form.BoardID,
+		Labels:    form.LabelNames(),
+		CreatorID: ctx.User.ID,
+	}); err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("NewProjectAutomationRule", err)
+		}
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.automation.add_success"))
+	ctx.Redirect(p.Link() + "/automation")
+}
+
+// DeleteProjectAutomationRule deletes an automation rule of a project
+func DeleteProjectAutomationRule(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectAutomationRule(p.ID, ctx.ParamsInt64(":ruleID")); err != nil {
+		ctx.Flash.Error("DeleteProjectAutomationRule: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.automation.deletion_success"))
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"redirect": p.Link() + "/automation",
+	})
+}
+
+// AddIssueToProjectPost adds an issue or a pull request, from any repository the doer can
+// read, to a project
+func AddIssueToProjectPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.AddProjectIssueForm)
+	project := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	issue, err := getIssueByReference(form.Issue)
+	if err != nil {
+		ctx.ServerError("getIssueByReference", err)
+		return
+	}
+	if issue != nil {
+		canAdd, err := models.CanUserAddIssueToProject(project, issue, ctx.User)
+		if err != nil {
+			ctx.ServerError("CanUserAddIssueToProject", err)
+			return
+		}
+		if !canAdd {
+			issue = nil
+		}
+	}
+	if issue == nil {
+		ctx.Flash.Error(ctx.Tr("repo.projects.issue_not_found", form.Issue))
+		ctx.Redirect(project.Link())
+		return
+	}
+
+	if issue.ProjectID() != project.ID {
+		if err := models.ChangeProjectAssign(issue, ctx.User, project.ID); err != nil {
+			ctx.ServerError("ChangeProjectAssign", err)
+			return
+		}
+	}
+	ctx.Redirect(project.Link())
+}
+
+// getIssueByReference returns the issue or the pull request referenced as owner/repo#index, nil
+// if it does not exist
+func getIssueByReference(ref string) (*models.Issue, error) {
+	idx := strings.LastIndexByte(ref, '#')
+	if idx < 0 {
+		return nil, nil
+	}
+	index, err := strconv.ParseInt(ref[idx+1:], 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+	names := strings.SplitN(strings.TrimSpace(ref[:idx]), "/", 2)
+	if len(names) != 2 {
+		return nil, nil
+	}
+	repo, err := models.GetRepositoryByOwnerAndName(names[0], names[1])
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	issue, err := models.GetIssueByIndex(repo.ID, index)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	issue.Repo = repo
+	return issue, nil
+}
+
+// AddBoardToProjectPost allows a new board to be added to a project.
+func AddBoardToProjectPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.EditProjectBoardForm)
+	project := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.NewProjectBoard(&models.ProjectBoard{
+		ProjectID: project.ID,
+		Title:     form.Title,
+		Color:     form.Color,
+		CreatorID: ctx.User.ID,
+	}); err != nil {
+		ctx.ServerError("NewProjectBoard", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// getProjectBoard returns the project of the owner in the context and its board from the URL
+func getProjectBoard(ctx *context.Context) (*models.Project, *models.ProjectBoard) {
+	project := getProject(ctx)
+	if ctx.Written() {
+		return nil, nil
+	}
+
+	board, err := models.GetProjectBoard(ctx.ParamsInt64(":boardID"))
+	if err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectBoard", err)
+		}
+		return nil, nil
+	}
+	if board.ProjectID != project.ID {
+		ctx.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"message": fmt.Sprintf("ProjectBoard[%d] is not in Project[%d] as expected", board.ID, project.ID),
+		})
+		return nil, nil
+	}
+	return project, board
+}
+
+// EditProjectBoard allows a project board's to be updated
+func EditProjectBoard(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.EditProjectBoardForm)
+	_, board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if form.Title != "" {
+		board.Title = form.Title
+	}
+
+	board.Color = form.Color
+
+	if form.Sorting != 0 {
+		board.Sorting = form.Sorting
+	}
+
+	if err := models.UpdateProjectBoard(board); err != nil {
+		ctx.ServerError("UpdateProjectBoard", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// DeleteProjectBoard allows for the deletion of a project board
+func DeleteProjectBoard(ctx *context.Context) {
+	_, board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectBoardByID(board.ID); err != nil {
+		ctx.ServerError("DeleteProjectBoardByID", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// SetDefaultProjectBoard set default board for uncategorized issues/pulls
+func SetDefaultProjectBoard(ctx *context.Context) {
+	project, board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.SetDefaultBoard(project.ID, board.ID); err != nil {
+		ctx.ServerError("SetDefaultBoard", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// MoveIssueAcrossBoards move a card from one board to another in a project
+func MoveIssueAcrossBoards(ctx *context.Context) {
+	var project *models.Project
+	var board *models.ProjectBoard
+	if ctx.ParamsInt64(":boardID") == 0 {
+		project = getProject(ctx)
+		board = &models.ProjectBoard{
+			ID:        0,
+			ProjectID: 0,
+			Title:     ctx.Tr("repo.projects.type.uncategorized"),
+		}
+	} else {
+		project, board = getProjectBoard(ctx)
+	}
+	if ctx.Written() {
+		return
+	}
+
+	issue, err := models.GetIssueByID(ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetIssueByID", err)
+		}
+		return
+	}
+	if issue.ProjectID() != project.ID {
+		ctx.NotFound("", nil)
+		return
+	}
+
+	if err := models.MoveIssueAcrossProjectBoards(issue, board); err != nil {
+		ctx.ServerError("MoveIssueAcrossProjectBoards", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"ok": true,
+	})
+}
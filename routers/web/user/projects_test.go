@@ -0,0 +1,141 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/forms"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockProjectOwner(t *testing.T, ctx *context.Context, ownerID int64) {
+	owner := unittest.AssertExistsAndLoadBean(t, &models.User{ID: ownerID}).(*models.User)
+	accessMode, err := models.GetProjectOwnerAccessMode(owner, ctx.User)
+	assert.NoError(t, err)
+	ctx.ProjectOwner = &context.ProjectOwner{
+		Owner:      owner,
+		AccessMode: accessMode,
+	}
+}
+
+func TestProjects(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	ctx := test.MockContext(t, "user3/-/projects")
+	test.LoadUser(t, ctx, 2)
+	mockProjectOwner(t, ctx, 3)
+	Projects(ctx)
+
+	assert.EqualValues(t, http.StatusOK, ctx.Resp.Status())
+	assert.EqualValues(t, 1, ctx.Data["OpenCount"])
+	assert.EqualValues(t, 0, ctx.Data["ClosedCount"])
+	if projects := ctx.Data["Projects"].([]*models.Project); assert.Len(t, projects, 1) {
+		assert.EqualValues(t, 4, projects[0].ID)
+	}
+}
+
+func TestViewProject(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	// issue 4 is in a private repository of user2
+	issue := unittest.AssertExistsAndLoadBean(t, &models.Issue{ID: 4}).(*models.Issue)
+	doer := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	assert.NoError(t, models.ChangeProjectAssign(issue, doer, 4))
+
+	for userID, issueIDs := range map[int64][]int64{2: {4}, 5: {}} {
+		ctx := test.MockContext(t, "user3/-/projects/4")
+		test.LoadUser(t, ctx, userID)
+		mockProjectOwner(t, ctx, 3)
+		ctx.SetParams(":id", "4")
+		ViewProject(ctx)
+
+		assert.EqualValues(t, http.StatusOK, ctx.Resp.Status())
+		ids := make([]int64, 0, len(issueIDs))
+		for _, issue := range ctx.Data["Issues"].(models.IssueList) {
+			ids = append(ids, issue.ID)
+		}
+		assert.Equal(t, issueIDs, ids, "user %d", userID)
+	}
+
+	// the projects of another owner are not found
+	ctx := test.MockContext(t, "user2/-/projects/4")
+	ctx.Repo = &context.Repository{}
+	test.LoadUser(t, ctx, 2)
+	mockProjectOwner(t, ctx, 2)
+	ctx.SetParams(":id", "4")
+	ViewProject(ctx)
+	assert.EqualValues(t, http.StatusNotFound, ctx.Resp.Status())
+}
+
+func TestAddIssueToProjectPost(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	ctx := test.MockContext(t, "user2/-/projects/5/issues")
+	test.LoadUser(t, ctx, 2)
+	mockProjectOwner(t, ctx, 2)
+	ctx.SetParams(":id", "5")
+	web.SetForm(ctx, &forms.AddProjectIssueForm{Issue: "user2/repo1#1"})
+	AddIssueToProjectPost(ctx)
+
+	assert.EqualValues(t, http.StatusFound, ctx.Resp.Status())
+	unittest.AssertExistsAndLoadBean(t, &models.ProjectIssue{IssueID: 1, ProjectID: 5})
+
+	ctx = test.MockContext(t, "user2/-/projects/5/issues")
+	test.LoadUser(t, ctx, 2)
+	mockProjectOwner(t, ctx, 2)
+	ctx.SetParams(":id", "5")
+	web.SetForm(ctx, &forms.AddProjectIssueForm{Issue: "user2/repo1#9999"})
+	AddIssueToProjectPost(ctx)
+
+	assert.EqualValues(t, http.StatusFound, ctx.Resp.Status())
+	assert.NotEmpty(t, ctx.Flash.ErrorMsg)
+}
+
+func TestDeleteProjectAutomationRule(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	// the rule 1 belongs to a project of a repository
+	ctx := test.MockContext(t, "user3/-/projects/4/automation/1/delete")
+	test.LoadUser(t, ctx, 2)
+	mockProjectOwner(t, ctx, 3)
+	ctx.SetParams(":id", "4")
+	ctx.SetParams(":ruleID", "1")
+	DeleteProjectAutomationRule(ctx)
+	assert.EqualValues(t, http.StatusOK, ctx.Resp.Status())
+	unittest.AssertExistsAndLoadBean(t, &models.ProjectAutomationRule{ID: 1})
+
+	rule := &models.ProjectAutomationRule{
+		ProjectID: 4,
+		Event:     models.ProjectAutomationIssueClosed,
+		BoardID:   4,
+	}
+	assert.NoError(t, models.NewProjectAutomationRule(rule))
+
+	ctx = test.MockContext(t, "user3/-/projects/4/automation")
+	test.LoadUser(t, ctx, 2)
+	mockProjectOwner(t, ctx, 3)
+	ctx.SetParams(":id", "4")
+	ProjectAutomation(ctx)
+	assert.EqualValues(t, http.StatusOK, ctx.Resp.Status())
+	assert.Len(t, ctx.Data["AutomationRules"], 1)
+
+	ctx = test.MockContext(t, fmt.Sprintf("user3/-/projects/4/automation/%d/delete", rule.ID))
+	test.LoadUser(t, ctx, 2)
+	mockProjectOwner(t, ctx, 3)
+	ctx.SetParams(":id", "4")
+	ctx.SetParams(":ruleID", fmt.Sprint(rule.ID))
+	DeleteProjectAutomationRule(ctx)
+	assert.EqualValues(t, http.StatusOK, ctx.Resp.Status())
+	unittest.AssertNotExistsBean(t, &models.ProjectAutomationRule{ID: rule.ID})
+}
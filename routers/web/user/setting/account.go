@@ -11,8 +11,10 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
 	"code.gitea.io/gitea/models/db"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
@@ -80,6 +82,7 @@ func AccountPost(ctx *context.Context) {
 			return
 		}
 		log.Trace("User password updated: %s", ctx.User.Name)
+		audit.Record(admin.AuditUserPasswordChange, ctx.User, ctx.RemoteAddr(), ctx.User, nil, nil)
 		ctx.Flash.Success(ctx.Tr("settings.change_password_success"))
 	}
 
@@ -251,6 +254,9 @@ func DeleteAccount(ctx *context.Context) {
 		case models.IsErrUserHasOrgs(err):
 			ctx.Flash.Error(ctx.Tr("form.still_has_org"))
 			ctx.Redirect(setting.AppSubURL + "/user/settings/account")
+		case models.IsErrUserOwnPackages(err):
+			ctx.Flash.Error(ctx.Tr("form.still_own_packages"))
+			ctx.Redirect(setting.AppSubURL + "/user/settings/account")
 		default:
 			ctx.ServerError("DeleteUser", err)
 		}
@@ -9,7 +9,9 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/admin"
 	"code.gitea.io/gitea/models/login"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/setting"
@@ -64,6 +66,7 @@ func ApplicationsPost(ctx *context.Context) {
 		ctx.ServerError("NewAccessToken", err)
 		return
 	}
+	audit.Record(admin.AuditAccessTokenCreate, ctx.User, ctx.RemoteAddr(), t, nil, audit.AccessTokenSnapshot(t))
 
 	ctx.Flash.Success(ctx.Tr("settings.generate_token_success"))
 	ctx.Flash.Info(t.Token)
@@ -73,9 +76,11 @@ func ApplicationsPost(ctx *context.Context) {
 
 // DeleteApplication response for delete user access token
 func DeleteApplication(ctx *context.Context) {
-	if err := models.DeleteAccessTokenByID(ctx.FormInt64("id"), ctx.User.ID); err != nil {
+	id := ctx.FormInt64("id")
+	if err := models.DeleteAccessTokenByID(id, ctx.User.ID); err != nil {
 		ctx.Flash.Error("DeleteAccessTokenByID: " + err.Error())
 	} else {
+		audit.Record(admin.AuditAccessTokenDelete, ctx.User, ctx.RemoteAddr(), &models.AccessToken{ID: id, UID: ctx.User.ID}, nil, nil)
 		ctx.Flash.Success(ctx.Tr("settings.delete_token_success"))
 	}
 
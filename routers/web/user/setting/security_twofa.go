@@ -13,7 +13,9 @@ import (
 	"net/http"
 	"strings"
 
+	"code.gitea.io/gitea/models/admin"
 	"code.gitea.io/gitea/models/login"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
@@ -78,6 +80,9 @@ func DisableTwoFactor(ctx *context.Context) {
 		ctx.ServerError("SettingsTwoFactor: Failed to DeleteTwoFactorByID", err)
 		return
 	}
+	audit.Record(admin.AuditUserTwoFactorDisable, ctx.User, ctx.RemoteAddr(), ctx.User, nil, map[string]interface{}{
+		"type": "totp",
+	})
 
 	ctx.Flash.Success(ctx.Tr("settings.twofa_disabled"))
 	ctx.Redirect(setting.AppSubURL + "/user/settings/security")
@@ -244,6 +249,9 @@ func EnrollTwoFactorPost(ctx *context.Context) {
 		ctx.ServerError("SettingsTwoFactor: Failed to save two factor", err)
 		return
 	}
+	audit.Record(admin.AuditUserTwoFactorEnable, ctx.User, ctx.RemoteAddr(), ctx.User, nil, map[string]interface{}{
+		"type": "totp",
+	})
 
 	ctx.Flash.Success(ctx.Tr("settings.twofa_enrolled", token))
 	ctx.Redirect(setting.AppSubURL + "/user/settings/security")
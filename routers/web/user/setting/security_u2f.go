@@ -8,7 +8,9 @@ import (
 	"errors"
 	"net/http"
 
+	"code.gitea.io/gitea/models/admin"
 	"code.gitea.io/gitea/models/login"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
@@ -82,6 +84,10 @@ func U2FRegisterPost(ctx *context.Context) {
 		ctx.ServerError("u2f.Register", err)
 		return
 	}
+	audit.Record(admin.AuditUserTwoFactorEnable, ctx.User, ctx.RemoteAddr(), ctx.User, nil, map[string]interface{}{
+		"type": "u2f",
+		"name": name,
+	})
 	ctx.Status(200)
 }
 
@@ -105,6 +111,10 @@ func U2FDelete(ctx *context.Context) {
 		ctx.ServerError("DeleteRegistration", err)
 		return
 	}
+	audit.Record(admin.AuditUserTwoFactorDisable, ctx.User, ctx.RemoteAddr(), ctx.User, map[string]interface{}{
+		"type": "u2f",
+		"name": reg.Name,
+	}, nil)
 	ctx.JSON(http.StatusOK, map[string]interface{}{
 		"redirect": setting.AppSubURL + "/user/settings/security",
 	})
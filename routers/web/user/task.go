@@ -10,7 +10,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
-	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/convert"
 )
 
 // TaskStatus returns task's status
@@ -29,19 +29,7 @@ func TaskStatus(ctx *context.Context) {
 		return
 	}
 
-	message := task.Message
-
-	if task.Message != "" && task.Message[0] == '{' {
-		// assume message is actually a translatable string
-		var translatableMessage models.TranslatableMessage
-		if err := json.Unmarshal([]byte(message), &translatableMessage); err != nil {
-			translatableMessage = models.TranslatableMessage{
-				Format: "migrate.migrating_failed.error",
-				Args:   []interface{}{task.Message},
-			}
-		}
-		message = ctx.Tr(translatableMessage.Format, translatableMessage.Args...)
-	}
+	message := convert.ToTaskMessage(task, ctx.Locale)
 
 	ctx.JSON(http.StatusOK, map[string]interface{}{
 		"status":    task.Status,
@@ -235,6 +235,7 @@ func RegisterRoutes(m *web.Route) {
 	m.Get("/.well-known/openid-configuration", user.OIDCWellKnown)
 	if setting.Federation.Enabled {
 		m.Get("/.well-known/nodeinfo", NodeInfoLinks)
+		m.Get("/.well-known/webfinger", WebFinger)
 	}
 	m.Group("/explore", func() {
 		m.Get("", func(ctx *context.Context) {
@@ -430,6 +431,7 @@ func RegisterRoutes(m *web.Route) {
 			m.Get("", admin.DefaultOrSystemWebhooks)
 			m.Post("/delete", admin.DeleteDefaultOrSystemWebhook)
 			m.Get("/{id}", repo.WebHooksEdit)
+			m.Post("/{id}/redeliver", repo.RedeliverWebhook)
 			m.Post("/gitea/{id}", bindIgnErr(forms.NewWebhookForm{}), repo.WebHooksEditPost)
 			m.Post("/gogs/{id}", bindIgnErr(forms.NewGogshookForm{}), repo.GogsHooksEditPost)
 			m.Post("/slack/{id}", bindIgnErr(forms.NewSlackHookForm{}), repo.SlackHooksEditPost)
@@ -470,6 +472,11 @@ func RegisterRoutes(m *web.Route) {
 			m.Post("/delete", admin.DeleteNotices)
 			m.Post("/empty", admin.EmptyNotices)
 		})
+
+		m.Group("/audit", func() {
+			m.Get("", admin.Audit)
+			m.Get("/export", admin.AuditExport)
+		})
 	}, adminReq)
 	// ***** END: Admin *****
 
@@ -482,6 +489,36 @@ func RegisterRoutes(m *web.Route) {
 		m.Post("/action/{action}", user.Action)
 	}, reqSignIn)
 
+	m.Group("/{username}/-/projects", func() {
+		m.Get("", user.Projects)
+		m.Get("/{id}", user.ViewProject)
+		m.Group("", func() {
+			m.Get("/new", user.NewProject)
+			m.Post("/new", bindIgnErr(forms.CreateProjectForm{}), user.NewProjectPost)
+			m.Group("/{id}", func() {
+				m.Post("", bindIgnErr(forms.EditProjectBoardForm{}), user.AddBoardToProjectPost)
+				m.Post("/delete", user.DeleteProject)
+
+				m.Get("/edit", user.EditProject)
+				m.Post("/edit", bindIgnErr(forms.CreateProjectForm{}), user.EditProjectPost)
+				m.Post("/{action:open|close}", user.ChangeProjectStatus)
+				m.Post("/issues", bindIgnErr(forms.AddProjectIssueForm{}), user.AddIssueToProjectPost)
+
+				m.Get("/automation", user.ProjectAutomation)
+				m.Post("/automation", bindIgnErr(forms.ProjectAutomationRuleForm{}), user.ProjectAutomationPost)
+				m.Post("/automation/{ruleID}/delete", user.DeleteProjectAutomationRule)
+
+				m.Group("/{boardID}", func() {
+					m.Put("", bindIgnErr(forms.EditProjectBoardForm{}), user.EditProjectBoard)
+					m.Delete("", user.DeleteProjectBoard)
+					m.Post("/default", user.SetDefaultProjectBoard)
+
+					m.Post("/{index}", user.MoveIssueAcrossBoards)
+				})
+			})
+		}, reqSignIn, user.MustWriteProjects)
+	}, ignSignIn, context.ProjectOwnerAssignment())
+
 	if !setting.IsProd {
 		m.Get("/template/*", dev.TemplatePreview)
 	}
@@ -555,6 +592,7 @@ func RegisterRoutes(m *web.Route) {
 					m.Post("/msteams/new", bindIgnErr(forms.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
 					m.Post("/feishu/new", bindIgnErr(forms.NewFeishuHookForm{}), repo.FeishuHooksNewPost)
 					m.Get("/{id}", repo.WebHooksEdit)
+					m.Post("/{id}/redeliver", repo.RedeliverWebhook)
 					m.Post("/gitea/{id}", bindIgnErr(forms.NewWebhookForm{}), repo.WebHooksEditPost)
 					m.Post("/gogs/{id}", bindIgnErr(forms.NewGogshookForm{}), repo.GogsHooksEditPost)
 					m.Post("/slack/{id}", bindIgnErr(forms.NewSlackHookForm{}), repo.SlackHooksEditPost)
@@ -586,6 +624,8 @@ func RegisterRoutes(m *web.Route) {
 		m.Post("/create", bindIgnErr(forms.CreateRepoForm{}), repo.CreatePost)
 		m.Get("/migrate", repo.Migrate)
 		m.Post("/migrate", bindIgnErr(forms.MigrateRepoForm{}), repo.MigratePost)
+		m.Get("/import", repo.Import)
+		m.Post("/import", bindIgnErr(forms.ImportRepoForm{}), repo.ImportPost)
 		m.Group("/fork", func() {
 			m.Combo("/{repoid}").Get(repo.Fork).
 				Post(bindIgnErr(forms.CreateRepoForm{}), repo.ForkPost)
@@ -601,6 +641,7 @@ func RegisterRoutes(m *web.Route) {
 				Post(bindIgnErr(forms.RepoSettingForm{}), repo.SettingsPost)
 			m.Post("/avatar", bindIgnErr(forms.AvatarForm{}), repo.SettingsAvatar)
 			m.Post("/avatar/delete", repo.SettingsDeleteAvatar)
+			m.Get("/export/{id}/archive", repo.SettingsExportArchive)
 
 			m.Group("/collaboration", func() {
 				m.Combo("").Get(repo.Collaboration).Post(repo.CollaborationPost)
@@ -649,6 +690,7 @@ func RegisterRoutes(m *web.Route) {
 				m.Post("/wechatwork/new", bindIgnErr(forms.NewWechatWorkHookForm{}), repo.WechatworkHooksNewPost)
 				m.Get("/{id}", repo.WebHooksEdit)
 				m.Post("/{id}/test", repo.TestWebhook)
+				m.Post("/{id}/redeliver", repo.RedeliverWebhook)
 				m.Post("/gitea/{id}", bindIgnErr(forms.NewWebhookForm{}), repo.WebHooksEditPost)
 				m.Post("/gogs/{id}", bindIgnErr(forms.NewGogshookForm{}), repo.GogsHooksEditPost)
 				m.Post("/slack/{id}", bindIgnErr(forms.NewSlackHookForm{}), repo.SlackHooksEditPost)
@@ -838,7 +880,7 @@ func RegisterRoutes(m *web.Route) {
 				ctx.ServerError("GetBranchCommit", err)
 				return
 			}
-			ctx.Repo.CommitsCount, err = ctx.Repo.GetCommitsCount()
+			ctx.Repo.CommitsCount, err = ctx.Repo.GetCommitsCount(ctx)
 			if err != nil {
 				ctx.ServerError("GetCommitsCount", err)
 				return
@@ -884,6 +926,10 @@ func RegisterRoutes(m *web.Route) {
 					m.Post("/edit", bindIgnErr(forms.CreateProjectForm{}), repo.EditProjectPost)
 					m.Post("/{action:open|close}", repo.ChangeProjectStatus)
 
+					m.Get("/automation", repo.ProjectAutomation)
+					m.Post("/automation", bindIgnErr(forms.ProjectAutomationRuleForm{}), repo.ProjectAutomationPost)
+					m.Post("/automation/{ruleID}/delete", repo.DeleteProjectAutomationRule)
+
 					m.Group("/{boardID}", func() {
 						m.Put("", bindIgnErr(forms.EditProjectBoardForm{}), repo.EditProjectBoard)
 						m.Delete("", repo.DeleteProjectBoard)
@@ -948,6 +994,8 @@ func RegisterRoutes(m *web.Route) {
 			m.Get(".patch", repo.DownloadPullPatch)
 			m.Get("/commits", context.RepoRef(), repo.ViewPullCommits)
 			m.Post("/merge", context.RepoMustNotBeArchived(), bindIgnErr(forms.MergePullRequestForm{}), repo.MergePullRequest)
+			m.Post("/merge_queue/remove", context.RepoMustNotBeArchived(), repo.RemoveFromMergeQueue)
+			m.Post("/cancel_auto_merge", context.RepoMustNotBeArchived(), repo.CancelAutoMergePullRequest)
 			m.Post("/update", repo.UpdatePullRequest)
 			m.Post("/cleanup", context.RepoMustNotBeArchived(), context.RepoRef(), repo.CleanUpPullRequest)
 			m.Group("/files", func() {
@@ -1018,6 +1066,7 @@ func RegisterRoutes(m *web.Route) {
 		m.Get("/stars", repo.Stars)
 		m.Get("/watchers", repo.Watchers)
 		m.Get("/search", reqRepoCodeReader, repo.Search)
+		m.Get("/symbols", reqRepoCodeReader, repo.Symbols)
 	}, ignSignIn, context.RepoAssignment, context.RepoRef(), context.UnitTypes())
 
 	m.Group("/{username}", func() {
@@ -0,0 +1,48 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	ap "code.gitea.io/gitea/modules/activitypub"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	federation_service "code.gitea.io/gitea/services/federation"
+)
+
+// WebFinger returns the WebFinger descriptor (RFC 7033) of a user or repository
+func WebFinger(ctx *context.Context) {
+	resource := ctx.FormString("resource")
+	if resource == "" {
+		ctx.Error(http.StatusBadRequest)
+		return
+	}
+
+	jrd, err := federation_service.WebFinger(resource)
+	if err != nil {
+		if errors.Is(err, federation_service.ErrNotFederated) || models.IsErrUserNotExist(err) || models.IsErrRepoNotExist(err) {
+			ctx.Error(http.StatusNotFound)
+		} else {
+			ctx.ServerError("WebFinger", err)
+		}
+		return
+	}
+
+	data, err := json.Marshal(jrd)
+	if err != nil {
+		ctx.ServerError("Marshal", err)
+		return
+	}
+	ctx.Resp.Header().Set("Content-Type", ap.WebFingerContentType)
+	ctx.Resp.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	if _, err := ctx.Resp.Write(data); err != nil {
+		log.Error("Write: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/queue"
+)
+
+// DetectRequest describes an event which may trigger the workflows of a repository
+type DetectRequest struct {
+	RepoID    int64
+	DoerID    int64
+	Event     string
+	Ref       string // the ref the commit belongs to
+	MatchRef  string // the ref the workflow filters are checked against
+	CommitSHA string
+	Title     string
+}
+
+// detectQueue is the queue of events whose workflows need to be detected
+var detectQueue queue.Queue
+
+// Init starts the queue which detects and creates workflow runs
+func Init() error {
+	detectQueue = queue.CreateQueue("actions_detect", handle, &DetectRequest{})
+	if detectQueue == nil {
+		return fmt.Errorf("Unable to create actions_detect Queue")
+	}
+
+	go graceful.GetManager().RunWithShutdownFns(detectQueue.Run)
+
+	notification.RegisterNotifier(NewNotifier())
+
+	return nil
+}
+
+func handle(data ...queue.Data) {
+	for _, datum := range data {
+		req := datum.(*DetectRequest)
+		if err := detectAndCreateRuns(graceful.GetManager().HammerContext(), req); err != nil {
+			log.Error("Detecting workflows of repo %d for %s at %s failed: %v", req.RepoID, req.Event, req.CommitSHA, err)
+		}
+	}
+}
+
+// Detect queues the event to detect and create the workflow runs it triggers
+func Detect(req *DetectRequest) {
+	if detectQueue == nil {
+		return
+	}
+	if err := detectQueue.Push(req); err != nil {
+		log.Error("Unable to push %s event of repo %d to the actions queue: %v", req.Event, req.RepoID, err)
+	}
+}
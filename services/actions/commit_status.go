@@ -0,0 +1,67 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/notification"
+)
+
+var statusDescriptions = map[actions_model.Status]string{
+	actions_model.StatusWaiting:   "Waiting for a runner",
+	actions_model.StatusBlocked:   "Waiting for required jobs",
+	actions_model.StatusRunning:   "Running",
+	actions_model.StatusSuccess:   "Successful",
+	actions_model.StatusFailure:   "Failing",
+	actions_model.StatusCancelled: "Cancelled",
+	actions_model.StatusSkipped:   "Skipped",
+}
+
+// JobLogURL returns the url of the log of the job
+func JobLogURL(repo *models.Repository, job *actions_model.ActionRunJob) string {
+	return fmt.Sprintf("%s/actions/jobs/%d/logs", repo.APIURL(), job.ID)
+}
+
+// publishCommitStatus loads the repository and trigger user of the run and publishes the commit status of the job
+func publishCommitStatus(ctx context.Context, run *actions_model.ActionRun, job *actions_model.ActionRunJob) error {
+	repo, err := models.GetRepositoryByIDCtx(ctx, run.RepoID)
+	if err != nil {
+		return err
+	}
+
+	creator, err := models.GetUserByIDCtx(ctx, run.TriggerUserID)
+	if err != nil {
+		if !models.IsErrUserNotExist(err) {
+			return err
+		}
+		creator = models.NewGhostUser()
+	}
+
+	return createCommitStatus(ctx, repo, creator, run, job)
+}
+
+func createCommitStatus(ctx context.Context, repo *models.Repository, creator *models.User, run *actions_model.ActionRun, job *actions_model.ActionRunJob) error {
+	status := &models.CommitStatus{
+		State:       job.Status.CommitStatusState(),
+		TargetURL:   JobLogURL(repo, job),
+		Description: statusDescriptions[job.Status],
+		Context:     fmt.Sprintf("%s / %s (%s)", run.Name, job.Name, run.Event),
+	}
+	if err := models.NewCommitStatus(models.NewCommitStatusOptions{
+		Repo:         repo,
+		Creator:      creator,
+		SHA:          job.CommitSHA,
+		CommitStatus: status,
+	}); err != nil {
+		return err
+	}
+
+	notification.NotifyCreateCommitStatus(repo, job.CommitSHA, creator, status)
+	return nil
+}
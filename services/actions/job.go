@@ -0,0 +1,230 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+var (
+	// ErrInvalidStatusChange indicates an invalid status transition of a job
+	ErrInvalidStatusChange = errors.New("Invalid job status change")
+)
+
+// maxPickCandidates is the number of waiting jobs which are checked when a runner asks for work
+const maxPickCandidates = 50
+
+// PickJob assigns the oldest waiting job the runner can execute to the runner, with a token to
+// clone its repository. nil is returned if no such job is available.
+func PickJob(ctx context.Context, runner *actions_model.ActionRunner) (*actions_model.ActionRunJob, error) {
+	candidates, err := actions_model.FindWaitingJobsForRunner(ctx, runner, maxPickCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, job := range candidates {
+		if !runner.CanRunJob(job) {
+			continue
+		}
+
+		// the job is only claimed together with its token, so it can't be left running without one
+		err := db.WithTx(func(ctx context.Context) error {
+			if err := actions_model.ClaimRunJob(ctx, job, runner); err != nil {
+				return err
+			}
+			return actions_model.GenerateRunJobToken(ctx, job, setting.Actions.TaskTokenLifetime)
+		})
+		if err == actions_model.ErrRunJobStateConflict {
+			// another runner was faster
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if err := onJobStatusChanged(ctx, job); err != nil {
+			log.Error("onJobStatusChanged[%d]: %v", job.ID, err)
+		}
+		return job, nil
+	}
+
+	return nil, nil
+}
+
+// UpdateJobStatus is called by the runner executing the job to report its status.
+// Reporting the running status marks the job as alive. Reports for finished jobs are ignored
+// so the runner can detect a cancelled job by the returned status.
+func UpdateJobStatus(ctx context.Context, job *actions_model.ActionRunJob, status actions_model.Status) error {
+	if job.Status.IsDone() {
+		return nil
+	}
+	if job.Status != actions_model.StatusRunning {
+		return ErrInvalidStatusChange
+	}
+
+	switch status {
+	case actions_model.StatusRunning, actions_model.StatusSuccess, actions_model.StatusFailure, actions_model.StatusCancelled:
+	default:
+		return ErrInvalidStatusChange
+	}
+
+	if err := actions_model.UpdateRunJobStatus(ctx, job, status); err != nil {
+		return err
+	}
+	if status == actions_model.StatusRunning {
+		return nil
+	}
+	return onJobStatusChanged(ctx, job)
+}
+
+// CancelRun cancels all unfinished jobs of the run
+func CancelRun(ctx context.Context, run *actions_model.ActionRun) error {
+	jobs, err := actions_model.GetRunJobsByRunID(ctx, run.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if job.Status.IsDone() {
+			continue
+		}
+		if err := actions_model.UpdateRunJobStatus(ctx, job, actions_model.StatusCancelled); err != nil {
+			if err != actions_model.ErrRunJobStateConflict {
+				return err
+			}
+			// the job changed in the meantime, retry with the current state
+			if job, err = actions_model.GetRunJobByID(ctx, job.ID); err != nil {
+				return err
+			}
+			if job.Status.IsDone() {
+				continue
+			}
+			if err := actions_model.UpdateRunJobStatus(ctx, job, actions_model.StatusCancelled); err != nil {
+				return err
+			}
+		}
+		if err := publishCommitStatus(ctx, run, job); err != nil {
+			log.Error("Unable to create commit status for job %d: %v", job.ID, err)
+		}
+	}
+
+	return updateRunStatus(ctx, run)
+}
+
+// StopZombieJobs marks all running jobs as failed whose runner did not report for too long
+func StopZombieJobs(ctx context.Context) error {
+	jobs, err := actions_model.FindStaleRunningJobs(ctx, timeutil.TimeStamp(time.Now().Add(-setting.Actions.ZombieTimeout).Unix()))
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Aborted due to shutdown")
+		default:
+		}
+
+		log.Trace("Stopping zombie job %d of run %d", job.ID, job.RunID)
+		if err := UpdateJobStatus(ctx, job, actions_model.StatusFailure); err != nil && err != actions_model.ErrRunJobStateConflict {
+			return err
+		}
+	}
+	return nil
+}
+
+// onJobStatusChanged publishes the new status of the job, unblocks dependent jobs and updates the run
+func onJobStatusChanged(ctx context.Context, job *actions_model.ActionRunJob) error {
+	run, err := actions_model.GetRunByID(ctx, job.RunID)
+	if err != nil {
+		return err
+	}
+
+	if err := publishCommitStatus(ctx, run, job); err != nil {
+		log.Error("Unable to create commit status for job %d: %v", job.ID, err)
+	}
+
+	if job.Status.IsDone() {
+		if err := resolveBlockedJobs(ctx, run); err != nil {
+			return err
+		}
+	}
+
+	return updateRunStatus(ctx, run)
+}
+
+// resolveBlockedJobs unblocks all jobs whose needs succeeded and skips all jobs with a failed need
+func resolveBlockedJobs(ctx context.Context, run *actions_model.ActionRun) error {
+	jobs, err := actions_model.GetRunJobsByRunID(ctx, run.ID)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]*actions_model.ActionRunJob, len(jobs))
+	for _, job := range jobs {
+		byID[job.JobID] = job
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, job := range jobs {
+			if job.Status != actions_model.StatusBlocked {
+				continue
+			}
+
+			status := actions_model.StatusWaiting
+			for _, need := range job.Needs {
+				needStatus := actions_model.StatusSkipped
+				if dep, ok := byID[need]; ok {
+					needStatus = dep.Status
+				}
+				if !needStatus.IsDone() {
+					status = actions_model.StatusBlocked
+					break
+				}
+				if needStatus != actions_model.StatusSuccess {
+					status = actions_model.StatusSkipped
+				}
+			}
+			if status == actions_model.StatusBlocked {
+				continue
+			}
+
+			if err := actions_model.UpdateRunJobStatus(ctx, job, status); err != nil {
+				if err == actions_model.ErrRunJobStateConflict {
+					continue
+				}
+				return err
+			}
+			if err := publishCommitStatus(ctx, run, job); err != nil {
+				log.Error("Unable to create commit status for job %d: %v", job.ID, err)
+			}
+			changed = true
+		}
+	}
+	return nil
+}
+
+func updateRunStatus(ctx context.Context, run *actions_model.ActionRun) error {
+	jobs, err := actions_model.GetRunJobsByRunID(ctx, run.ID)
+	if err != nil {
+		return err
+	}
+
+	states := make([]actions_model.Status, 0, len(jobs))
+	for _, job := range jobs {
+		states = append(states, job.Status)
+	}
+
+	return actions_model.UpdateRunStatus(ctx, run, actions_model.AggregateStatus(states))
+}
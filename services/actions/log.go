@@ -0,0 +1,110 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+)
+
+var (
+	// ErrInvalidLogOffset indicates an invalid log upload offset
+	ErrInvalidLogOffset = errors.New("Invalid log offset")
+	// ErrLogTooLarge indicates that the log exceeds the configured maximum size
+	ErrLogTooLarge = errors.New("Log is too large")
+)
+
+// AppendJobLog appends the content to the log of the job.
+// The offset must match the current log length to detect lost or repeated uploads.
+// Logs are stored as a sequence of chunks because object storages do not support appending.
+func AppendJobLog(ctx context.Context, job *actions_model.ActionRunJob, offset int64, r io.Reader) error {
+	if offset != job.LogLength {
+		return ErrInvalidLogOffset
+	}
+
+	remaining := setting.Actions.MaxLogSize - job.LogLength
+	data, err := io.ReadAll(io.LimitReader(r, remaining+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > remaining {
+		return ErrLogTooLarge
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	p := job.LogChunkPath(job.LogChunks)
+	if _, err := storage.ActionsLog.Save(p, bytes.NewReader(data), int64(len(data))); err != nil {
+		return err
+	}
+
+	if err := actions_model.AddRunJobLogChunk(ctx, job, int64(len(data))); err != nil {
+		if err == actions_model.ErrRunJobStateConflict {
+			// a concurrent upload claimed the chunk, its content was overwritten
+			return ErrInvalidLogOffset
+		}
+		if err := storage.ActionsLog.Delete(p); err != nil {
+			log.Error("Error deleting log chunk %s: %v", p, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// OpenJobLog returns a reader of the full log of the job
+func OpenJobLog(job *actions_model.ActionRunJob) io.ReadCloser {
+	return &logReader{job: job}
+}
+
+// logReader reads the log chunks of a job one after another
+type logReader struct {
+	job     *actions_model.ActionRunJob
+	chunk   int64
+	current io.ReadCloser
+}
+
+func (r *logReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if r.chunk >= r.job.LogChunks {
+				return 0, io.EOF
+			}
+			obj, err := storage.ActionsLog.Open(r.job.LogChunkPath(r.chunk))
+			if err != nil {
+				return 0, err
+			}
+			r.current = obj
+			r.chunk++
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			if err := r.current.Close(); err != nil {
+				return n, err
+			}
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *logReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification/base"
+	"code.gitea.io/gitea/modules/repository"
+)
+
+type actionsNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &actionsNotifier{}
+)
+
+// NewNotifier create a new actionsNotifier notifier
+func NewNotifier() base.Notifier {
+	return &actionsNotifier{}
+}
+
+func (a *actionsNotifier) NotifyPushCommits(pusher *models.User, repo *models.Repository, opts *repository.PushUpdateOptions, commits *repository.PushCommits) {
+	if opts.IsDelRef() || repo.IsMirror {
+		return
+	}
+
+	title := opts.RefName()
+	if commits != nil && commits.HeadCommit != nil {
+		title = strings.SplitN(strings.TrimSpace(commits.HeadCommit.Message), "\n", 2)[0]
+	}
+
+	Detect(&DetectRequest{
+		RepoID:    repo.ID,
+		DoerID:    pusher.ID,
+		Event:     actions_module.EventPush,
+		Ref:       opts.RefFullName,
+		MatchRef:  opts.RefFullName,
+		CommitSHA: opts.NewCommitID,
+		Title:     title,
+	})
+}
+
+func (a *actionsNotifier) NotifyNewPullRequest(pr *models.PullRequest, mentions []*models.User) {
+	if err := pr.LoadIssue(); err != nil {
+		log.Error("LoadIssue: %v", err)
+		return
+	}
+	if err := pr.Issue.LoadPoster(); err != nil {
+		log.Error("LoadPoster: %v", err)
+		return
+	}
+
+	detectPullRequest(pr.Issue.Poster, pr)
+}
+
+func (a *actionsNotifier) NotifyPullRequestSynchronized(doer *models.User, pr *models.PullRequest) {
+	if err := pr.LoadIssue(); err != nil {
+		log.Error("LoadIssue: %v", err)
+		return
+	}
+
+	detectPullRequest(doer, pr)
+}
+
+func detectPullRequest(doer *models.User, pr *models.PullRequest) {
+	Detect(&DetectRequest{
+		RepoID:   pr.BaseRepoID,
+		DoerID:   doer.ID,
+		Event:    actions_module.EventPullRequest,
+		Ref:      pr.GetGitRefName(),
+		MatchRef: git.BranchPrefix + pr.BaseBranch,
+		Title:    pr.Issue.Title,
+	})
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	actions_model "code.gitea.io/gitea/models/actions"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+)
+
+func detectAndCreateRuns(ctx context.Context, req *DetectRequest) error {
+	repo, err := models.GetRepositoryByIDCtx(ctx, req.RepoID)
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if repo.IsEmpty || repo.IsArchived {
+		return nil
+	}
+
+	doer, err := models.GetUserByIDCtx(ctx, req.DoerID)
+	if err != nil {
+		return err
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+
+	if req.CommitSHA == "" {
+		if req.CommitSHA, err = gitRepo.GetRefCommitID(req.Ref); err != nil {
+			return err
+		}
+	}
+
+	commit, err := gitRepo.GetCommit(req.CommitSHA)
+	if err != nil {
+		return err
+	}
+	// a pushed tag may reference an annotated tag object
+	req.CommitSHA = commit.ID.String()
+
+	entries, err := actions_module.ListWorkflows(commit)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		content, err := actions_module.GetContentFromEntry(entry)
+		if err != nil {
+			log.Warn("Unable to read workflow %s of repo %d at %s: %v", entry.Name(), repo.ID, req.CommitSHA, err)
+			continue
+		}
+
+		w, err := actions_module.ParseWorkflow(content)
+		if err != nil {
+			log.Warn("Unable to parse workflow %s of repo %d at %s: %v", entry.Name(), repo.ID, req.CommitSHA, err)
+			continue
+		}
+
+		if !w.Matches(req.Event, req.MatchRef) {
+			continue
+		}
+
+		if err := createRun(ctx, repo, doer, req, entry.Name(), w); err != nil {
+			return fmt.Errorf("createRun[%s]: %w", entry.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func createRun(ctx context.Context, repo *models.Repository, doer *models.User, req *DetectRequest, workflowID string, w *actions_module.Workflow) error {
+	run := &actions_model.ActionRun{
+		RepoID:        repo.ID,
+		OwnerID:       repo.OwnerID,
+		WorkflowID:    workflowID,
+		Name:          w.Name,
+		Title:         req.Title,
+		TriggerUserID: doer.ID,
+		Ref:           req.Ref,
+		CommitSHA:     req.CommitSHA,
+		Event:         req.Event,
+	}
+	if run.Name == "" {
+		run.Name = workflowID
+	}
+
+	jobs := make([]*actions_model.ActionRunJob, 0, len(w.Jobs))
+	for _, job := range w.Jobs {
+		payload, err := w.Payload(job)
+		if err != nil {
+			return err
+		}
+
+		jobs = append(jobs, &actions_model.ActionRunJob{
+			JobID:           job.ID,
+			Name:            job.Name,
+			Needs:           job.Needs,
+			RunsOn:          job.RunsOn,
+			WorkflowPayload: payload,
+		})
+	}
+
+	if err := actions_model.InsertRun(ctx, run, jobs); err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if err := createCommitStatus(ctx, repo, doer, run, job); err != nil {
+			log.Error("Unable to create commit status for job %d: %v", job.ID, err)
+		}
+	}
+
+	return nil
+}
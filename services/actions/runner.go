@@ -0,0 +1,42 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"context"
+	"strings"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+)
+
+// RegisterRunner creates a runner in the scope of the registration token
+func RegisterRunner(ctx context.Context, registrationToken, name string, labels []string) (*actions_model.ActionRunner, error) {
+	t, err := actions_model.GetRunnerToken(ctx, registrationToken)
+	if err != nil {
+		return nil, err
+	}
+
+	cleaned := make([]string, 0, len(labels))
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || seen[strings.ToLower(label)] {
+			continue
+		}
+		seen[strings.ToLower(label)] = true
+		cleaned = append(cleaned, label)
+	}
+
+	runner := &actions_model.ActionRunner{
+		Name:    strings.TrimSpace(name),
+		OwnerID: t.OwnerID,
+		RepoID:  t.RepoID,
+		Labels:  cleaned,
+	}
+	if err := actions_model.CreateRunner(ctx, runner); err != nil {
+		return nil, err
+	}
+	return runner, nil
+}
@@ -104,6 +104,14 @@ func isGitRawReleaseOrLFSPath(req *http.Request) bool {
 	return false
 }
 
+// isContainerPath checks if the request targets the container registry, which is served at /v2
+func isContainerPath(req *http.Request) bool {
+	if setting.Packages.Enabled {
+		return req.URL.Path == "/v2" || strings.HasPrefix(req.URL.Path, "/v2/")
+	}
+	return false
+}
+
 // handleSignIn clears existing session variables and stores new ones for the specified user object
 func handleSignIn(resp http.ResponseWriter, req *http.Request, sess SessionStore, user *models.User) {
 	_ = sess.Delete("openid_verified_uri")
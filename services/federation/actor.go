@@ -0,0 +1,144 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/db"
+	federation_model "code.gitea.io/gitea/models/federation"
+	ap "code.gitea.io/gitea/modules/activitypub"
+	"code.gitea.io/gitea/modules/json"
+)
+
+// UserActor returns the actor document of the user
+func UserActor(ctx context.Context, u *models.User) (*ap.Actor, error) {
+	if !IsUserFederated(u) {
+		return nil, ErrNotFederated
+	}
+	key, err := federation_model.GetOrCreateKeyPair(ctx, u.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	iri := UserIRI(u)
+	return &ap.Actor{
+		LDContext:         ap.DefaultLDContext(),
+		ID:                iri,
+		Type:              ap.TypePerson,
+		PreferredUsername: u.Name,
+		Name:              u.DisplayName(),
+		Summary:           u.Description,
+		URL:               u.HTMLURL(),
+		Icon: &ap.Image{
+			Type:      "Image",
+			MediaType: "image/png",
+			URL:       u.AvatarLink(),
+		},
+		Inbox:     iri + "/inbox",
+		Outbox:    iri + "/outbox",
+		Followers: iri + "/followers",
+		PublicKey: &ap.PublicKey{
+			ID:           keyID(iri),
+			Owner:        iri,
+			PublicKeyPem: key.PublicKey,
+		},
+	}, nil
+}
+
+// RepoActor returns the actor document of the repository
+func RepoActor(ctx context.Context, repo *models.Repository) (*ap.Actor, error) {
+	if !IsRepoFederated(repo) {
+		return nil, ErrNotFederated
+	}
+	key, err := federation_model.GetOrCreateKeyPair(ctx, 0, repo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	iri := RepoIRI(repo)
+	actor := &ap.Actor{
+		LDContext:         ap.DefaultLDContext(),
+		ID:                iri,
+		Type:              ap.TypeRepository,
+		PreferredUsername: repo.Name,
+		Name:              repo.FullName(),
+		Summary:           repo.Description,
+		URL:               repo.HTMLURL(),
+		Inbox:             iri + "/inbox",
+		Outbox:            iri + "/outbox",
+		Followers:         iri + "/followers",
+		PublicKey: &ap.PublicKey{
+			ID:           keyID(iri),
+			Owner:        iri,
+			PublicKeyPem: key.PublicKey,
+		},
+	}
+	if IsUserFederated(repo.Owner) {
+		actor.AttributedTo = UserIRI(repo.Owner)
+	}
+	return actor, nil
+}
+
+// IssueTicket returns the ticket object of the issue
+func IssueTicket(ctx context.Context, repo *models.Repository, issue *models.Issue) (*ap.Object, error) {
+	if !IsRepoFederated(repo) {
+		return nil, ErrNotFederated
+	}
+	if err := issue.LoadPoster(); err != nil {
+		return nil, err
+	}
+
+	ticket := &ap.Object{
+		LDContext: ap.DefaultLDContext(),
+		ID:        IssueIRI(repo, issue),
+		Type:      ap.TypeTicket,
+		Context:   RepoIRI(repo),
+		Name:      issue.Title,
+		Content:   issue.Content,
+		MediaType: "text/markdown",
+		Source: &ap.Source{
+			Content:   issue.Content,
+			MediaType: "text/markdown",
+		},
+		URL:       issue.HTMLURL(),
+		To:        []string{ap.Public},
+		Published: issue.CreatedUnix.AsTime().UTC().Format(time.RFC3339),
+	}
+	if IsUserFederated(issue.Poster) {
+		ticket.AttributedTo = UserIRI(issue.Poster)
+	}
+	return ticket, nil
+}
+
+// Outbox returns the activities the user or repository sent
+func Outbox(ctx context.Context, userID, repoID int64, iri string, opts db.ListOptions) (*ap.OrderedCollection, error) {
+	activities, total, err := federation_model.FindOutboxActivities(ctx, userID, repoID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]interface{}, 0, len(activities))
+	for _, a := range activities {
+		items = append(items, json.RawMessage(a.Content))
+	}
+	return ap.NewOrderedCollection(iri, total, items), nil
+}
+
+// Followers returns the remote actors which follow the user or repository
+func Followers(ctx context.Context, userID, repoID int64, iri string) (*ap.OrderedCollection, error) {
+	actors, err := federation_model.GetFollowerActors(ctx, userID, repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]interface{}, 0, len(actors))
+	for _, a := range actors {
+		items = append(items, a.IRI)
+	}
+	return ap.NewOrderedCollection(iri, int64(len(items)), items), nil
+}
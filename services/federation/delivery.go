@@ -0,0 +1,92 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+
+	federation_model "code.gitea.io/gitea/models/federation"
+	ap "code.gitea.io/gitea/modules/activitypub"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/queue"
+
+	gouuid "github.com/google/uuid"
+)
+
+// deliveryTask is an activity which is delivered to a single inbox
+type deliveryTask struct {
+	UserID   int64
+	RepoID   int64
+	ActorIRI string
+	Inbox    string
+	Payload  []byte
+}
+
+func handleDelivery(data ...queue.Data) {
+	for _, datum := range data {
+		task := datum.(*deliveryTask)
+		if err := deliver(graceful.GetManager().HammerContext(), task); err != nil {
+			log.Error("Delivering activity of %s to %s failed: %v", task.ActorIRI, task.Inbox, err)
+		}
+	}
+}
+
+func deliver(ctx context.Context, task *deliveryTask) error {
+	s := &signer{UserID: task.UserID, RepoID: task.RepoID, IRI: task.ActorIRI}
+	client, err := s.client(ctx)
+	if err != nil {
+		return err
+	}
+	return client.Post(ctx, task.Inbox, task.Payload)
+}
+
+// newActivityIRI generates a unique IRI for an activity of the actor
+func newActivityIRI(actorIRI, typ string) string {
+	return actorIRI + "#" + typ + "-" + gouuid.New().String()
+}
+
+// publish stores the activity in the outbox of the signer and queues its delivery to the inboxes
+func publish(ctx context.Context, s *signer, activity *ap.Activity, inboxes []string) error {
+	payload, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+
+	if err := federation_model.InsertActivity(ctx, &federation_model.Activity{
+		IRI:        activity.ID,
+		Type:       activity.Type,
+		UserID:     s.UserID,
+		RepoID:     s.RepoID,
+		IsOutbound: true,
+		Content:    string(payload),
+	}); err != nil {
+		return err
+	}
+
+	if deliveryQueue == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(inboxes))
+	for _, inbox := range inboxes {
+		if seen[inbox] {
+			continue
+		}
+		seen[inbox] = true
+
+		if err := deliveryQueue.Push(&deliveryTask{
+			UserID:   s.UserID,
+			RepoID:   s.RepoID,
+			ActorIRI: s.IRI,
+			Inbox:    inbox,
+			Payload:  payload,
+		}); err != nil {
+			log.Error("Unable to push activity %s to the federation queue: %v", activity.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/hostmatcher"
+	"code.gitea.io/gitea/modules/proxy"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+var (
+	// ErrInvalidActivity is returned if an activity can not be processed
+	ErrInvalidActivity = errors.New("invalid activity")
+	// ErrForbiddenActivity is returned if the sender of an activity is not allowed to perform it
+	ErrForbiddenActivity = errors.New("activity is not allowed")
+	// ErrSignatureMismatch is returned if an activity is not signed by its actor
+	ErrSignatureMismatch = errors.New("activity is not signed by its actor")
+	// ErrRemoteRequest is returned if a document of another instance can not be fetched
+	ErrRemoteRequest = errors.New("request to remote instance failed")
+	// ErrNotFederated is returned if a user or repository is not visible to other instances
+	ErrNotFederated = errors.New("not federated")
+)
+
+var (
+	httpClient    *http.Client
+	deliveryQueue queue.Queue
+)
+
+// Init creates the HTTP client and starts the queue which delivers activities to remote inboxes
+func Init() error {
+	allowedHostListValue := setting.Federation.AllowedHostList
+	if allowedHostListValue == "" {
+		allowedHostListValue = hostmatcher.MatchBuiltinExternal
+	}
+	allowedHostMatcher := hostmatcher.ParseHostMatchList("federation.ALLOWED_HOST_LIST", allowedHostListValue)
+
+	httpClient = &http.Client{
+		Timeout: time.Duration(setting.Federation.DeliverTimeout) * time.Second,
+		Transport: &http.Transport{
+			Proxy:       proxy.Proxy(),
+			DialContext: hostmatcher.NewDialContext("federation", allowedHostMatcher, nil),
+		},
+	}
+
+	deliveryQueue = queue.CreateQueue("federation_delivery", handleDelivery, &deliveryTask{})
+	if deliveryQueue == nil {
+		return fmt.Errorf("Unable to create federation_delivery Queue")
+	}
+
+	go graceful.GetManager().RunWithShutdownFns(deliveryQueue.Run)
+
+	return nil
+}
+
+func baseIRI() string {
+	return setting.AppURL + "api/v1/activitypub/"
+}
+
+// UserIRI returns the IRI of the actor of the user
+func UserIRI(u *models.User) string {
+	return baseIRI() + "user/" + url.PathEscape(u.Name)
+}
+
+// RepoIRI returns the IRI of the actor of the repository
+func RepoIRI(repo *models.Repository) string {
+	return baseIRI() + "repo/" + url.PathEscape(repo.OwnerName) + "/" + url.PathEscape(repo.Name)
+}
+
+// IssueIRI returns the IRI of the ticket of the issue
+func IssueIRI(repo *models.Repository, issue *models.Issue) string {
+	return RepoIRI(repo) + "/issues/" + strconv.FormatInt(issue.Index, 10)
+}
+
+func keyID(actorIRI string) string {
+	return actorIRI + "#main-key"
+}
+
+// IsUserFederated returns if the user is visible to other instances
+func IsUserFederated(u *models.User) bool {
+	return u.Type == models.UserTypeIndividual && u.IsActive && u.Visibility.IsPublic()
+}
+
+// IsRepoFederated returns if the repository is visible to other instances
+func IsRepoFederated(repo *models.Repository) bool {
+	if repo.IsPrivate {
+		return false
+	}
+	if err := repo.GetOwner(); err != nil {
+		return false
+	}
+	return repo.Owner.Visibility.IsPublic()
+}
+
+// localIRI is the user, repository or issue an IRI of this instance points to
+type localIRI struct {
+	OwnerName string
+	RepoName  string
+	Index     int64
+}
+
+func parseLocalIRI(iri string) (*localIRI, bool) {
+	if !strings.HasPrefix(iri, baseIRI()) {
+		return nil, false
+	}
+	parts := strings.Split(strings.TrimPrefix(iri, baseIRI()), "/")
+	for i := range parts {
+		p, err := url.PathUnescape(parts[i])
+		if err != nil {
+			return nil, false
+		}
+		parts[i] = p
+	}
+
+	switch {
+	case len(parts) == 2 && parts[0] == "user":
+		return &localIRI{OwnerName: parts[1]}, true
+	case len(parts) == 3 && parts[0] == "repo":
+		return &localIRI{OwnerName: parts[1], RepoName: parts[2]}, true
+	case len(parts) == 5 && parts[0] == "repo" && parts[3] == "issues":
+		index, err := strconv.ParseInt(parts[4], 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		return &localIRI{OwnerName: parts[1], RepoName: parts[2], Index: index}, true
+	}
+	return nil, false
+}
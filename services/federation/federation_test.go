@@ -0,0 +1,460 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/db"
+	federation_model "code.gitea.io/gitea/models/federation"
+	"code.gitea.io/gitea/models/unittest"
+	ap "code.gitea.io/gitea/modules/activitypub"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// received is an activity a remote instance received in one of its inboxes
+type received struct {
+	Inbox    string
+	Activity *ap.Activity
+}
+
+// remoteInstance is a minimal ActivityPub server standing in for another instance.
+// It serves the person alice and her repository with a single ticket
+// and only accepts activities whose signature matches the key of their actor.
+type remoteInstance struct {
+	t      *testing.T
+	server *httptest.Server
+	priv   string
+	pub    string
+
+	mu       sync.Mutex
+	received []*received
+}
+
+func newRemoteInstance(t *testing.T) *remoteInstance {
+	priv, pub, err := ap.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	r := &remoteInstance{t: t, priv: priv, pub: pub}
+	r.server = httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *remoteInstance) personIRI() string {
+	return r.server.URL + "/users/alice"
+}
+
+func (r *remoteInstance) repoIRI() string {
+	return r.server.URL + "/repos/alice/project"
+}
+
+func (r *remoteInstance) ticketIRI() string {
+	return r.repoIRI() + "/issues/1"
+}
+
+func (r *remoteInstance) actor(iri, typ string) *ap.Actor {
+	return &ap.Actor{
+		LDContext:         ap.DefaultLDContext(),
+		ID:                iri,
+		Type:              typ,
+		PreferredUsername: "alice",
+		Inbox:             iri + "/inbox",
+		Outbox:            iri + "/outbox",
+		PublicKey: &ap.PublicKey{
+			ID:           iri + "#main-key",
+			Owner:        iri,
+			PublicKeyPem: r.pub,
+		},
+	}
+}
+
+func (r *remoteInstance) serve(w http.ResponseWriter, req *http.Request) {
+	iri := r.server.URL + req.URL.Path
+
+	if req.Method == http.MethodPost && strings.HasSuffix(iri, "/inbox") {
+		r.receive(w, req)
+		return
+	}
+
+	var doc interface{}
+	switch iri {
+	case r.personIRI():
+		doc = r.actor(iri, ap.TypePerson)
+	case r.repoIRI():
+		doc = r.actor(iri, ap.TypeRepository)
+	case r.ticketIRI():
+		doc = &ap.Object{ID: iri, Type: ap.TypeTicket, Context: r.repoIRI(), Name: "Remote issue"}
+	default:
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", ap.ContentType)
+	assert.NoError(r.t, json.NewEncoder(w).Encode(doc))
+}
+
+// receive verifies the signature of the activity with the key of its actor like any other instance would
+func (r *remoteInstance) receive(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	assert.NoError(r.t, err)
+
+	activity := &ap.Activity{}
+	if err := json.Unmarshal(body, activity); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := http.Get(activity.Actor)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		http.Error(w, "unknown actor", http.StatusUnauthorized)
+		return
+	}
+	defer resp.Body.Close()
+	actor := &ap.Actor{}
+	assert.NoError(r.t, json.NewDecoder(resp.Body).Decode(actor))
+
+	keyID, err := ap.SignatureKeyID(req)
+	if err != nil || keyID != actor.PublicKey.ID {
+		http.Error(w, "invalid key", http.StatusUnauthorized)
+		return
+	}
+	if err := ap.VerifyRequest(req, body, actor.PublicKey.PublicKeyPem); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	r.mu.Lock()
+	r.received = append(r.received, &received{Inbox: r.server.URL + req.URL.Path, Activity: activity})
+	r.mu.Unlock()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// send delivers an activity of alice to the inbox, signed with the key of the keyActor
+func (r *remoteInstance) send(inbox string, activity *ap.Activity, priv string) int {
+	body, err := json.Marshal(activity)
+	assert.NoError(r.t, err)
+
+	req, err := http.NewRequest(http.MethodPost, inbox, bytes.NewReader(body))
+	assert.NoError(r.t, err)
+	key, err := ap.ParsePrivateKey(priv)
+	assert.NoError(r.t, err)
+	assert.NoError(r.t, ap.SignRequest(req, r.personIRI()+"#main-key", key, body))
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(r.t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func (r *remoteInstance) takeReceived() []*received {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := r.received
+	r.received = nil
+	return res
+}
+
+// newLocalInstance serves the actors and inboxes of this instance like the API routes do
+func newLocalInstance(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		iri := setting.AppURL + strings.TrimPrefix(req.URL.Path, "/")
+		isInbox := strings.HasSuffix(iri, "/inbox")
+		local, ok := parseLocalIRI(strings.TrimSuffix(iri, "/inbox"))
+		if !ok || local.Index != 0 {
+			http.NotFound(w, req)
+			return
+		}
+
+		target := &Target{}
+		var doc interface{}
+		var err error
+		if local.RepoName == "" {
+			if target.User, err = models.GetUserByName(local.OwnerName); err == nil && !isInbox {
+				doc, err = UserActor(req.Context(), target.User)
+			}
+		} else {
+			if target.Repo, err = models.GetRepositoryByOwnerAndName(local.OwnerName, local.RepoName); err == nil && !isInbox {
+				doc, err = RepoActor(req.Context(), target.Repo)
+			}
+		}
+		if err != nil {
+			http.NotFound(w, req)
+			return
+		}
+
+		if !isInbox {
+			w.Header().Set("Content-Type", ap.ContentType)
+			assert.NoError(t, json.NewEncoder(w).Encode(doc))
+			return
+		}
+
+		body, err := io.ReadAll(req.Body)
+		assert.NoError(t, err)
+		switch err := ReceiveActivity(req.Context(), target, req, body); {
+		case err == nil:
+			w.WriteHeader(http.StatusAccepted)
+		case errors.Is(err, ap.ErrInvalidSignature), errors.Is(err, ErrSignatureMismatch):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case errors.Is(err, ErrInvalidActivity), errors.Is(err, ErrForbiddenActivity):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	oldAppURL := setting.AppURL
+	setting.AppURL = server.URL + "/"
+	t.Cleanup(func() {
+		setting.AppURL = oldAppURL
+	})
+	return server
+}
+
+func prepareInstances(t *testing.T) *remoteInstance {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	oldClient, oldQueue := httpClient, deliveryQueue
+	httpClient = &http.Client{}
+	var err error
+	deliveryQueue, err = queue.NewImmediate(handleDelivery, nil, &deliveryTask{})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		httpClient, deliveryQueue = oldClient, oldQueue
+	})
+
+	newLocalInstance(t)
+	return newRemoteInstance(t)
+}
+
+func TestInboundDelivery(t *testing.T) {
+	remote := prepareInstances(t)
+
+	repo := unittest.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	issue := unittest.AssertExistsAndLoadBean(t, &models.Issue{ID: 1}).(*models.Issue)
+	repoInbox := RepoIRI(repo) + "/inbox"
+
+	t.Run("Follow", func(t *testing.T) {
+		follow, err := ap.NewActivity(remote.personIRI()+"/follow/1", ap.TypeFollow, remote.personIRI(), RepoIRI(repo))
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusAccepted, remote.send(repoInbox, follow, remote.priv))
+
+		count, err := federation_model.CountFollowers(db.DefaultContext, 0, repo.ID)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, count)
+
+		res := remote.takeReceived()
+		if assert.Len(t, res, 1) {
+			assert.Equal(t, remote.personIRI()+"/inbox", res[0].Inbox)
+			assert.Equal(t, ap.TypeAccept, res[0].Activity.Type)
+			assert.Equal(t, RepoIRI(repo), res[0].Activity.Actor)
+			assert.Equal(t, follow.ID, res[0].Activity.ObjectIRI())
+		}
+
+		// a repeated delivery is ignored
+		assert.Equal(t, http.StatusAccepted, remote.send(repoInbox, follow, remote.priv))
+		assert.Empty(t, remote.takeReceived())
+	})
+
+	t.Run("Star", func(t *testing.T) {
+		star, err := ap.NewActivity(remote.personIRI()+"/star/1", ap.TypeStar, remote.personIRI(), RepoIRI(repo))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, remote.send(repoInbox, star, remote.priv))
+
+		count, err := federation_model.CountStars(db.DefaultContext, repo.ID)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, count)
+
+		star.LDContext = nil
+		undo, err := ap.NewActivity(remote.personIRI()+"/undo/1", ap.TypeUndo, remote.personIRI(), star)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, remote.send(repoInbox, undo, remote.priv))
+
+		count, err = federation_model.CountStars(db.DefaultContext, repo.ID)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, count)
+	})
+
+	t.Run("Comment", func(t *testing.T) {
+		note := &ap.Object{
+			ID:           remote.personIRI() + "/notes/1",
+			Type:         ap.TypeNote,
+			AttributedTo: remote.personIRI(),
+			InReplyTo:    IssueIRI(repo, issue),
+			Content:      "Hello from another instance",
+		}
+		create, err := ap.NewActivity(remote.personIRI()+"/create/1", ap.TypeCreate, remote.personIRI(), note)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, remote.send(repoInbox, create, remote.priv))
+
+		comment := unittest.AssertExistsAndLoadBean(t, &models.Comment{IssueID: issue.ID, Content: note.Content}).(*models.Comment)
+		assert.Equal(t, models.CommentTypeComment, comment.Type)
+		assert.Equal(t, "alice@"+strings.TrimPrefix(remote.server.URL, "http://"), comment.OriginalAuthor)
+	})
+
+	t.Run("CommentOnArchivedRepository", func(t *testing.T) {
+		_, err := db.GetEngine(db.DefaultContext).ID(repo.ID).Cols("is_archived").Update(&models.Repository{IsArchived: true})
+		assert.NoError(t, err)
+		defer func() {
+			_, err := db.GetEngine(db.DefaultContext).ID(repo.ID).Cols("is_archived").Update(&models.Repository{IsArchived: false})
+			assert.NoError(t, err)
+		}()
+
+		note := &ap.Object{
+			ID:           remote.personIRI() + "/notes/3",
+			Type:         ap.TypeNote,
+			AttributedTo: remote.personIRI(),
+			InReplyTo:    IssueIRI(repo, issue),
+			Content:      "Comment on an archived repository",
+		}
+		create, err := ap.NewActivity(remote.personIRI()+"/create/3", ap.TypeCreate, remote.personIRI(), note)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, remote.send(repoInbox, create, remote.priv))
+
+		unittest.AssertNotExistsBean(t, &models.Comment{IssueID: issue.ID, Content: note.Content})
+	})
+
+	t.Run("ForgedSignature", func(t *testing.T) {
+		otherPriv, _, err := ap.GenerateKeyPair()
+		assert.NoError(t, err)
+
+		note := &ap.Object{
+			ID:        remote.personIRI() + "/notes/2",
+			Type:      ap.TypeNote,
+			InReplyTo: IssueIRI(repo, issue),
+			Content:   "Forged comment",
+		}
+		create, err := ap.NewActivity(remote.personIRI()+"/create/2", ap.TypeCreate, remote.personIRI(), note)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, remote.send(repoInbox, create, otherPriv))
+
+		unittest.AssertNotExistsBean(t, &models.Comment{IssueID: issue.ID, Content: note.Content})
+	})
+}
+
+func TestOutboundDelivery(t *testing.T) {
+	remote := prepareInstances(t)
+
+	user := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo := unittest.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	issue := unittest.AssertExistsAndLoadBean(t, &models.Issue{ID: 1}).(*models.Issue)
+
+	t.Run("Follow", func(t *testing.T) {
+		actor, err := FollowRemote(db.DefaultContext, user, remote.personIRI())
+		assert.NoError(t, err)
+		assert.Equal(t, remote.personIRI(), actor.IRI)
+
+		res := remote.takeReceived()
+		if !assert.Len(t, res, 1) {
+			return
+		}
+		follow := res[0].Activity
+		assert.Equal(t, remote.personIRI()+"/inbox", res[0].Inbox)
+		assert.Equal(t, ap.TypeFollow, follow.Type)
+		assert.Equal(t, UserIRI(user), follow.Actor)
+		assert.Equal(t, remote.personIRI(), follow.ObjectIRI())
+
+		follow.LDContext = nil
+		accept, err := ap.NewActivity(remote.personIRI()+"/accept/1", ap.TypeAccept, remote.personIRI(), follow)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, remote.send(UserIRI(user)+"/inbox", accept, remote.priv))
+
+		following, err := federation_model.GetFollowing(db.DefaultContext, user.ID, actor.ID)
+		assert.NoError(t, err)
+		assert.True(t, following.IsAccepted)
+	})
+
+	t.Run("Star", func(t *testing.T) {
+		_, err := StarRemote(db.DefaultContext, user, remote.repoIRI())
+		assert.NoError(t, err)
+
+		res := remote.takeReceived()
+		if assert.Len(t, res, 1) {
+			assert.Equal(t, remote.repoIRI()+"/inbox", res[0].Inbox)
+			assert.Equal(t, ap.TypeStar, res[0].Activity.Type)
+			assert.Equal(t, remote.repoIRI(), res[0].Activity.ObjectIRI())
+		}
+	})
+
+	t.Run("CommentOnRemoteIssue", func(t *testing.T) {
+		assert.NoError(t, CommentRemote(db.DefaultContext, user, remote.ticketIRI(), "Hello from Gitea"))
+
+		res := remote.takeReceived()
+		if assert.Len(t, res, 1) {
+			assert.Equal(t, remote.repoIRI()+"/inbox", res[0].Inbox)
+			assert.Equal(t, ap.TypeCreate, res[0].Activity.Type)
+			note, err := res[0].Activity.ObjectObject()
+			assert.NoError(t, err)
+			assert.Equal(t, remote.ticketIRI(), note.InReplyTo)
+			assert.Equal(t, "Hello from Gitea", note.Content)
+		}
+	})
+
+	t.Run("CommentOnFollowedRepository", func(t *testing.T) {
+		actor, err := federation_model.GetActorByIRI(db.DefaultContext, remote.personIRI())
+		assert.NoError(t, err)
+		assert.NoError(t, federation_model.AddFollower(db.DefaultContext, actor.ID, 0, repo.ID))
+
+		comment, err := models.CreateComment(&models.CreateCommentOptions{
+			Type:    models.CommentTypeComment,
+			Doer:    user,
+			Repo:    repo,
+			Issue:   issue,
+			Content: "Hello followers",
+		})
+		assert.NoError(t, err)
+		NotifyComment(user, repo, issue, comment)
+
+		res := remote.takeReceived()
+		if assert.Len(t, res, 1) {
+			assert.Equal(t, remote.personIRI()+"/inbox", res[0].Inbox)
+			assert.Equal(t, ap.TypeCreate, res[0].Activity.Type)
+			assert.Equal(t, UserIRI(user), res[0].Activity.Actor)
+			note, err := res[0].Activity.ObjectObject()
+			assert.NoError(t, err)
+			assert.Equal(t, IssueIRI(repo, issue), note.InReplyTo)
+			assert.Equal(t, "Hello followers", note.Content)
+		}
+
+		outbox, err := Outbox(db.DefaultContext, user.ID, 0, UserIRI(user)+"/outbox", db.ListOptions{})
+		assert.NoError(t, err)
+		assert.EqualValues(t, 4, outbox.TotalItems)
+	})
+}
+
+func TestWebFinger(t *testing.T) {
+	prepareInstances(t)
+
+	user := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	host := strings.TrimSuffix(strings.TrimPrefix(setting.AppURL, "http://"), "/")
+
+	jrd, err := WebFinger("acct:user2@" + host)
+	assert.NoError(t, err)
+	assert.Contains(t, jrd.Links, ap.WebFingerLink{Rel: "self", Type: ap.ContentType, Href: UserIRI(user)})
+
+	repo := unittest.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	jrd, err = WebFinger(setting.AppURL + "user2/repo1")
+	assert.NoError(t, err)
+	assert.Contains(t, jrd.Links, ap.WebFingerLink{Rel: "self", Type: ap.ContentType, Href: RepoIRI(repo)})
+
+	_, err = WebFinger("acct:user2@example.com")
+	assert.ErrorIs(t, err, ErrNotFederated)
+	// private repositories are not federated
+	_, err = WebFinger(setting.AppURL + "user2/repo2")
+	assert.ErrorIs(t, err, ErrNotFederated)
+}
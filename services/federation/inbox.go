@@ -0,0 +1,228 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	federation_model "code.gitea.io/gitea/models/federation"
+	"code.gitea.io/gitea/models/unit"
+	ap "code.gitea.io/gitea/modules/activitypub"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// Target is the local user or repository whose inbox received an activity
+type Target struct {
+	User *models.User
+	Repo *models.Repository
+}
+
+func (t *Target) signer() *signer {
+	if t.Repo != nil {
+		return &signer{RepoID: t.Repo.ID, IRI: RepoIRI(t.Repo)}
+	}
+	return &signer{UserID: t.User.ID, IRI: UserIRI(t.User)}
+}
+
+// ReceiveActivity verifies the signature of an activity posted to the inbox of the target and processes it.
+// Activities which were processed before and activities of unsupported types are ignored.
+func ReceiveActivity(ctx context.Context, target *Target, req *http.Request, body []byte) error {
+	activity := &ap.Activity{}
+	if err := json.Unmarshal(body, activity); err != nil {
+		return ErrInvalidActivity
+	}
+	if err := activity.Validate(); err != nil {
+		return ErrInvalidActivity
+	}
+
+	s := target.signer()
+	client, err := s.client(ctx)
+	if err != nil {
+		return err
+	}
+	actor, err := verifyActivity(ctx, client, req, body, activity)
+	if err != nil {
+		return err
+	}
+
+	has, err := federation_model.HasActivity(ctx, activity.ID)
+	if err != nil || has {
+		return err
+	}
+
+	if err := processActivity(ctx, target, s, actor, activity); err != nil {
+		return err
+	}
+
+	err = federation_model.InsertActivity(ctx, &federation_model.Activity{
+		IRI:    activity.ID,
+		Type:   activity.Type,
+		UserID: s.UserID,
+		RepoID: s.RepoID,
+	})
+	if err == federation_model.ErrActivityAlreadyExist {
+		return nil
+	}
+	return err
+}
+
+// verifyActivity checks that the request is signed with the key of the actor of the activity
+func verifyActivity(ctx context.Context, client *ap.Client, req *http.Request, body []byte, activity *ap.Activity) (*federation_model.Actor, error) {
+	keyID, err := ap.SignatureKeyID(req)
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := getRemoteActor(ctx, client, activity.Actor, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ap.ErrInvalidSignature, err)
+	}
+	if actor.PublicKeyID == keyID && ap.VerifyRequest(req, body, actor.PublicKeyPem) == nil {
+		return actor, nil
+	}
+
+	// the actor may have rotated its key since it was fetched
+	actor, err = getRemoteActor(ctx, client, activity.Actor, true)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ap.ErrInvalidSignature, err)
+	}
+	if actor.PublicKeyID != keyID {
+		return nil, ErrSignatureMismatch
+	}
+	if err := ap.VerifyRequest(req, body, actor.PublicKeyPem); err != nil {
+		return nil, err
+	}
+	return actor, nil
+}
+
+func processActivity(ctx context.Context, target *Target, s *signer, actor *federation_model.Actor, activity *ap.Activity) error {
+	switch activity.Type {
+	case ap.TypeFollow:
+		if activity.ObjectIRI() != s.IRI {
+			return ErrInvalidActivity
+		}
+		if err := federation_model.AddFollower(ctx, actor.ID, s.UserID, s.RepoID); err != nil {
+			return err
+		}
+		return acceptFollow(ctx, s, actor, activity)
+	case ap.TypeStar:
+		if target.Repo == nil || activity.ObjectIRI() != s.IRI {
+			return ErrInvalidActivity
+		}
+		return federation_model.AddStar(ctx, actor.ID, target.Repo.ID)
+	case ap.TypeUndo:
+		inner, err := activity.ObjectActivity()
+		if err != nil {
+			return ErrInvalidActivity
+		}
+		if inner.Actor != activity.Actor {
+			return ErrForbiddenActivity
+		}
+		if inner.ObjectIRI() != s.IRI {
+			return ErrInvalidActivity
+		}
+		switch inner.Type {
+		case ap.TypeFollow:
+			return federation_model.RemoveFollower(ctx, actor.ID, s.UserID, s.RepoID)
+		case ap.TypeStar:
+			if target.Repo == nil {
+				return ErrInvalidActivity
+			}
+			return federation_model.RemoveStar(ctx, actor.ID, target.Repo.ID)
+		}
+	case ap.TypeCreate:
+		obj, err := activity.ObjectObject()
+		if err != nil {
+			return ErrInvalidActivity
+		}
+		if obj.Type != ap.TypeNote {
+			break
+		}
+		if obj.AttributedTo != "" && obj.AttributedTo != actor.IRI {
+			return ErrForbiddenActivity
+		}
+		return createRemoteComment(ctx, target, actor, obj)
+	case ap.TypeAccept:
+		if target.User == nil {
+			return ErrInvalidActivity
+		}
+		accepted, err := federation_model.AcceptFollowing(ctx, actor.ID, activity.ObjectIRI())
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			return ErrInvalidActivity
+		}
+		return nil
+	}
+
+	log.Trace("Ignoring %s activity %s of %s", activity.Type, activity.ID, actor.IRI)
+	return nil
+}
+
+func acceptFollow(ctx context.Context, s *signer, actor *federation_model.Actor, follow *ap.Activity) error {
+	follow.LDContext = nil
+	accept, err := ap.NewActivity(newActivityIRI(s.IRI, "accept"), ap.TypeAccept, s.IRI, follow)
+	if err != nil {
+		return err
+	}
+	accept.To = []string{actor.IRI}
+	return publish(ctx, s, accept, []string{actor.Inbox})
+}
+
+// createRemoteComment adds the note as comment to the issue it replies to.
+// The comment is posted by the ghost user and attributed to the remote actor.
+func createRemoteComment(ctx context.Context, target *Target, actor *federation_model.Actor, note *ap.Object) error {
+	if target.Repo == nil {
+		return ErrInvalidActivity
+	}
+	local, ok := parseLocalIRI(note.InReplyTo)
+	if !ok || local.Index == 0 ||
+		!strings.EqualFold(local.OwnerName, target.Repo.OwnerName) ||
+		!strings.EqualFold(local.RepoName, target.Repo.Name) {
+		return ErrInvalidActivity
+	}
+
+	issue, err := models.GetIssueByIndex(target.Repo.ID, local.Index)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			return ErrInvalidActivity
+		}
+		return err
+	}
+	// remote comments follow the same restrictions as local ones
+	unitType := unit.TypeIssues
+	if issue.IsPull {
+		unitType = unit.TypePullRequests
+	}
+	if issue.IsLocked || target.Repo.IsArchived || !target.Repo.UnitEnabled(unitType) {
+		return ErrForbiddenActivity
+	}
+
+	content := note.Content
+	if note.Source != nil && note.Source.MediaType == "text/markdown" {
+		content = note.Source.Content
+	}
+	if strings.TrimSpace(content) == "" {
+		return ErrInvalidActivity
+	}
+
+	now := timeutil.TimeStampNow()
+	return models.InsertIssueComments([]*models.Comment{{
+		Type:           models.CommentTypeComment,
+		IssueID:        issue.ID,
+		PosterID:       models.NewGhostUser().ID,
+		OriginalAuthor: actor.Handle(),
+		Content:        content,
+		CreatedUnix:    now,
+		UpdatedUnix:    now,
+	}})
+}
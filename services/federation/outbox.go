@@ -0,0 +1,199 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/db"
+	federation_model "code.gitea.io/gitea/models/federation"
+	ap "code.gitea.io/gitea/modules/activitypub"
+	"code.gitea.io/gitea/modules/log"
+)
+
+func userSigner(ctx context.Context, doer *models.User) (*signer, *ap.Client, error) {
+	if !IsUserFederated(doer) {
+		return nil, nil, ErrNotFederated
+	}
+	s := &signer{UserID: doer.ID, IRI: UserIRI(doer)}
+	client, err := s.client(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, client, nil
+}
+
+// resolveActor returns the remote actor of an IRI or a handle like user@example.com
+func resolveActor(ctx context.Context, client *ap.Client, iriOrHandle string) (*federation_model.Actor, error) {
+	iri := iriOrHandle
+	if !strings.HasPrefix(iri, "https://") && !strings.HasPrefix(iri, "http://") {
+		var err error
+		if iri, err = client.WebFinger(ctx, iriOrHandle); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRemoteRequest, err)
+		}
+	}
+	return getRemoteActor(ctx, client, iri, true)
+}
+
+// FollowRemote sends a Follow activity of the user to the remote actor
+func FollowRemote(ctx context.Context, doer *models.User, iriOrHandle string) (*federation_model.Actor, error) {
+	s, client, err := userSigner(ctx, doer)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := resolveActor(ctx, client, iriOrHandle)
+	if err != nil {
+		return nil, err
+	}
+
+	follow, err := ap.NewActivity(newActivityIRI(s.IRI, "follow"), ap.TypeFollow, s.IRI, actor.IRI)
+	if err != nil {
+		return nil, err
+	}
+	follow.To = []string{actor.IRI}
+
+	if err := federation_model.AddFollowing(ctx, doer.ID, actor.ID, follow.ID); err != nil {
+		return nil, err
+	}
+	return actor, publish(ctx, s, follow, []string{actor.Inbox})
+}
+
+// UnfollowRemote undoes the Follow activity of the user
+func UnfollowRemote(ctx context.Context, doer *models.User, iri string) error {
+	s, _, err := userSigner(ctx, doer)
+	if err != nil {
+		return err
+	}
+	actor, err := federation_model.GetActorByIRI(ctx, iri)
+	if err != nil {
+		return err
+	}
+	following, err := federation_model.GetFollowing(ctx, doer.ID, actor.ID)
+	if err != nil {
+		return err
+	}
+
+	follow, err := ap.NewActivity(following.ActivityIRI, ap.TypeFollow, s.IRI, actor.IRI)
+	if err != nil {
+		return err
+	}
+	follow.LDContext = nil
+	undo, err := ap.NewActivity(newActivityIRI(s.IRI, "undo"), ap.TypeUndo, s.IRI, follow)
+	if err != nil {
+		return err
+	}
+	undo.To = []string{actor.IRI}
+
+	if _, err := db.DeleteByBean(ctx, following); err != nil {
+		return err
+	}
+	return publish(ctx, s, undo, []string{actor.Inbox})
+}
+
+// StarRemote sends a Star activity of the user to the remote repository
+func StarRemote(ctx context.Context, doer *models.User, iri string) (*federation_model.Actor, error) {
+	s, client, err := userSigner(ctx, doer)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := resolveActor(ctx, client, iri)
+	if err != nil {
+		return nil, err
+	}
+	if actor.Type != ap.TypeRepository {
+		return nil, ErrInvalidActivity
+	}
+
+	star, err := ap.NewActivity(newActivityIRI(s.IRI, "star"), ap.TypeStar, s.IRI, actor.IRI)
+	if err != nil {
+		return nil, err
+	}
+	star.To = []string{actor.IRI}
+	return actor, publish(ctx, s, star, []string{actor.Inbox})
+}
+
+// CommentRemote sends a comment of the user on the remote ticket to the repository of the ticket
+func CommentRemote(ctx context.Context, doer *models.User, ticketIRI, content string) error {
+	s, client, err := userSigner(ctx, doer)
+	if err != nil {
+		return err
+	}
+	ticket, err := client.FetchObject(ctx, ticketIRI)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRemoteRequest, err)
+	}
+	if ticket.Type != ap.TypeTicket || ticket.Context == "" {
+		return ErrInvalidActivity
+	}
+	repo, err := getRemoteActor(ctx, client, ticket.Context, false)
+	if err != nil {
+		return err
+	}
+
+	note := newNote(newActivityIRI(s.IRI, "note"), s.IRI, ticket.ID, repo.IRI, content, time.Now())
+	return publishNote(ctx, s, note, []string{repo.Inbox})
+}
+
+// NotifyComment sends a new comment on an issue to the remote followers of the repository
+func NotifyComment(doer *models.User, repo *models.Repository, issue *models.Issue, comment *models.Comment) {
+	if comment.Type != models.CommentTypeComment || comment.OriginalAuthor != "" {
+		return
+	}
+	if !IsUserFederated(doer) || !IsRepoFederated(repo) {
+		return
+	}
+
+	ctx := db.DefaultContext
+	followers, err := federation_model.GetFollowerActors(ctx, 0, repo.ID)
+	if err != nil {
+		log.Error("GetFollowerActors: %v", err)
+		return
+	}
+	if len(followers) == 0 {
+		return
+	}
+	inboxes := make([]string, 0, len(followers))
+	for _, follower := range followers {
+		inboxes = append(inboxes, follower.Inbox)
+	}
+
+	s := &signer{UserID: doer.ID, IRI: UserIRI(doer)}
+	note := newNote(comment.HTMLURL(), s.IRI, IssueIRI(repo, issue), RepoIRI(repo), comment.Content, comment.CreatedUnix.AsTime())
+	if err := publishNote(ctx, s, note, inboxes); err != nil {
+		log.Error("Unable to publish comment %d: %v", comment.ID, err)
+	}
+}
+
+func newNote(id, author, inReplyTo, repoIRI, content string, published time.Time) *ap.Object {
+	return &ap.Object{
+		ID:           id,
+		Type:         ap.TypeNote,
+		AttributedTo: author,
+		Context:      repoIRI,
+		InReplyTo:    inReplyTo,
+		Content:      content,
+		MediaType:    "text/markdown",
+		Source: &ap.Source{
+			Content:   content,
+			MediaType: "text/markdown",
+		},
+		To:        []string{ap.Public},
+		Published: published.UTC().Format(time.RFC3339),
+	}
+}
+
+func publishNote(ctx context.Context, s *signer, note *ap.Object, inboxes []string) error {
+	create, err := ap.NewActivity(newActivityIRI(s.IRI, "create"), ap.TypeCreate, s.IRI, note)
+	if err != nil {
+		return err
+	}
+	create.To = note.To
+	create.Published = note.Published
+	return publish(ctx, s, create, inboxes)
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	federation_model "code.gitea.io/gitea/models/federation"
+	ap "code.gitea.io/gitea/modules/activitypub"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// signer is the local user or repository requests and activities are signed with
+type signer struct {
+	UserID int64
+	RepoID int64
+	IRI    string
+}
+
+func (s *signer) client(ctx context.Context) (*ap.Client, error) {
+	key, err := federation_model.GetOrCreateKeyPair(ctx, s.UserID, s.RepoID)
+	if err != nil {
+		return nil, err
+	}
+	return ap.NewClient(httpClient, keyID(s.IRI), key.PrivateKey, setting.Federation.MaxSize)
+}
+
+// getRemoteActor returns the stored copy of the remote actor.
+// The actor is fetched if it is not known yet or if refresh is set.
+func getRemoteActor(ctx context.Context, client *ap.Client, iri string, refresh bool) (*federation_model.Actor, error) {
+	if _, ok := parseLocalIRI(iri); ok {
+		return nil, ErrInvalidActivity
+	}
+	if !refresh {
+		actor, err := federation_model.GetActorByIRI(ctx, iri)
+		if err != federation_model.ErrActorNotExist {
+			return actor, err
+		}
+	}
+
+	doc, err := client.FetchActor(ctx, iri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRemoteRequest, err)
+	}
+	u, err := url.Parse(doc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := &federation_model.Actor{
+		IRI:               doc.ID,
+		Type:              doc.Type,
+		PreferredUsername: doc.PreferredUsername,
+		Name:              doc.Name,
+		Host:              u.Host,
+		URL:               doc.URL,
+		Inbox:             doc.Inbox,
+	}
+	if doc.PublicKey != nil {
+		actor.PublicKeyID = doc.PublicKey.ID
+		actor.PublicKeyPem = doc.PublicKey.PublicKeyPem
+	}
+	if err := federation_model.SaveActor(ctx, actor); err != nil {
+		return nil, err
+	}
+	return actor, nil
+}
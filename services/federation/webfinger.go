@@ -0,0 +1,93 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"net/url"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	ap "code.gitea.io/gitea/modules/activitypub"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// WebFinger returns the descriptor of the resource which may be an acct: URI of a user,
+// the URL of a user or repository or the IRI of an actor
+func WebFinger(resource string) (*ap.WebFingerJRD, error) {
+	ownerName, repoName, err := parseWebFingerResource(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := models.GetUserByName(ownerName)
+	if err != nil {
+		return nil, err
+	}
+	if repoName == "" {
+		if !IsUserFederated(owner) {
+			return nil, ErrNotFederated
+		}
+		return newWebFingerJRD(resource, owner.HTMLURL(), UserIRI(owner)), nil
+	}
+
+	repo, err := models.GetRepositoryByName(owner.ID, repoName)
+	if err != nil {
+		return nil, err
+	}
+	repo.Owner = owner
+	if !IsRepoFederated(repo) {
+		return nil, ErrNotFederated
+	}
+	return newWebFingerJRD(resource, repo.HTMLURL(), RepoIRI(repo)), nil
+}
+
+func parseWebFingerResource(resource string) (ownerName, repoName string, err error) {
+	if strings.HasPrefix(resource, "acct:") {
+		parts := strings.SplitN(strings.TrimPrefix(resource, "acct:"), "@", 2)
+		appURL, err := url.Parse(setting.AppURL)
+		if err != nil {
+			return "", "", err
+		}
+		if len(parts) != 2 || !strings.EqualFold(parts[1], appURL.Host) {
+			return "", "", ErrNotFederated
+		}
+		return parts[0], "", nil
+	}
+
+	if local, ok := parseLocalIRI(resource); ok && local.Index == 0 {
+		return local.OwnerName, local.RepoName, nil
+	}
+
+	if !strings.HasPrefix(resource, setting.AppURL) {
+		return "", "", ErrNotFederated
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(resource, setting.AppURL), "/"), "/")
+	switch len(parts) {
+	case 1:
+		return parts[0], "", nil
+	case 2:
+		return parts[0], parts[1], nil
+	}
+	return "", "", ErrNotFederated
+}
+
+func newWebFingerJRD(subject, htmlURL, actorIRI string) *ap.WebFingerJRD {
+	return &ap.WebFingerJRD{
+		Subject: subject,
+		Aliases: []string{htmlURL, actorIRI},
+		Links: []ap.WebFingerLink{
+			{
+				Rel:  "http://webfinger.net/rel/profile-page",
+				Type: "text/html",
+				Href: htmlURL,
+			},
+			{
+				Rel:  "self",
+				Type: ap.ContentType,
+				Href: actorIRI,
+			},
+		},
+	}
+}
@@ -6,6 +6,7 @@
 package forms
 
 import (
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strings"
@@ -90,6 +91,21 @@ func (f *MigrateRepoForm) Validate(req *http.Request, errs binding.Errors) bindi
 	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// ImportRepoForm form for importing a repository from an exported archive
+type ImportRepoForm struct {
+	Archive     *multipart.FileHeader
+	UID         int64  `binding:"Required"`
+	RepoName    string `binding:"Required;AlphaDashDot;MaxSize(100)"`
+	Private     bool
+	Description string `binding:"MaxSize(255)"`
+}
+
+// Validate validates the fields
+func (f *ImportRepoForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // ParseRemoteAddr checks if given remote address is valid,
 // and returns composed URL with needed username and password.
 func ParseRemoteAddr(remoteAddr, authUsername, authPassword string) (string, error) {
@@ -113,23 +129,26 @@ func ParseRemoteAddr(remoteAddr, authUsername, authPassword string) (string, err
 
 // RepoSettingForm form for changing repository settings
 type RepoSettingForm struct {
-	RepoName           string `binding:"Required;AlphaDashDot;MaxSize(100)"`
-	Description        string `binding:"MaxSize(255)"`
-	Website            string `binding:"ValidUrl;MaxSize(255)"`
-	Interval           string
-	MirrorAddress      string
-	MirrorUsername     string
-	MirrorPassword     string
-	LFS                bool   `form:"mirror_lfs"`
-	LFSEndpoint        string `form:"mirror_lfs_endpoint"`
-	PushMirrorID       string
-	PushMirrorAddress  string
-	PushMirrorUsername string
-	PushMirrorPassword string
-	PushMirrorInterval string
-	Private            bool
-	Template           bool
-	EnablePrune        bool
+	RepoName              string `binding:"Required;AlphaDashDot;MaxSize(100)"`
+	Description           string `binding:"MaxSize(255)"`
+	Website               string `binding:"ValidUrl;MaxSize(255)"`
+	Interval              string
+	MirrorAddress         string
+	MirrorUsername        string
+	MirrorPassword        string
+	LFS                   bool   `form:"mirror_lfs"`
+	LFSEndpoint           string `form:"mirror_lfs_endpoint"`
+	PushMirrorID          string
+	PushMirrorAddress     string
+	PushMirrorUsername    string
+	PushMirrorPassword    string
+	PushMirrorInterval    string
+	PushMirrorSyncOnPush  bool
+	PushMirrorIncludeRefs string
+	PushMirrorExcludeRefs string
+	Private               bool
+	Template              bool
+	EnablePrune           bool
 
 	// Advanced settings
 	EnableWiki                            bool
@@ -195,11 +214,14 @@ type ProtectBranchForm struct {
 	ApprovalsWhitelistTeams       string
 	BlockOnRejectedReviews        bool
 	BlockOnOfficialReviewRequests bool
+	RequireCodeOwnerReviews       bool
 	BlockOnOutdatedBranch         bool
+	EnableMergeQueue              bool
 	DismissStaleApprovals         bool
 	RequireSignedCommits          bool
 	ProtectedFilePatterns         string
 	UnprotectedFilePatterns       string
+	Priority                      int64
 }
 
 // Validate validates the fields
@@ -238,6 +260,7 @@ type WebhookForm struct {
 	Repository           bool
 	Active               bool
 	BranchFilter         string `binding:"GlobPattern"`
+	MaxAttempts          int    `binding:"Range(0,100)"`
 }
 
 // PushOnly if the hook will be triggered when push
@@ -486,13 +509,10 @@ type CreateProjectForm struct {
 	BoardType models.ProjectBoardType
 }
 
-// UserCreateProjectForm is a from for creating an individual or organization
-// form.
-type UserCreateProjectForm struct {
-	Title     string `binding:"Required;MaxSize(100)"`
-	Content   string
-	BoardType models.ProjectBoardType
-	UID       int64 `binding:"Required"`
+// AddProjectIssueForm is a form for adding an issue or a pull request to a
+// project
+type AddProjectIssueForm struct {
+	Issue string `binding:"Required"` // reference to the issue as owner/repo#index
 }
 
 // EditProjectBoardForm is a form for editing a project board
@@ -502,6 +522,24 @@ type EditProjectBoardForm struct {
 	Color   string `binding:"MaxSize(7)"`
 }
 
+// ProjectAutomationRuleForm is a form for adding an automation rule to a project
+type ProjectAutomationRuleForm struct {
+	Event   models.ProjectAutomationEvent `binding:"Required"`
+	BoardID int64
+	Labels  string // comma-separated names of labels
+}
+
+// LabelNames returns the names of the labels of the rule
+func (f *ProjectAutomationRuleForm) LabelNames() []string {
+	names := make([]string, 0, 5)
+	for _, name := range strings.Split(f.Labels, ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 //    _____  .__.__                   __
 //   /     \ |__|  |   ____   _______/  |_  ____   ____   ____
 //  /  \ /  \|  |  | _/ __ \ /  ___/\   __\/  _ \ /    \_/ __ \
@@ -572,6 +610,7 @@ type MergePullRequestForm struct {
 	MergeCommitID          string // only used for manually-merged
 	ForceMerge             *bool  `json:"force_merge,omitempty"`
 	DeleteBranchAfterMerge bool   `json:"delete_branch_after_merge,omitempty"`
+	MergeWhenChecksSucceed bool   `json:"merge_when_checks_succeed,omitempty"`
 }
 
 // Validate validates the fields
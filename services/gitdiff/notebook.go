@@ -0,0 +1,212 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gitdiff
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"code.gitea.io/gitea/modules/markup/jupyter"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// NotebookDiffCellType represents the type of a NotebookDiffCell.
+type NotebookDiffCellType uint8
+
+// NotebookDiffCellType possible values.
+const (
+	NotebookDiffCellUnchanged NotebookDiffCellType = iota + 1
+	NotebookDiffCellChanged
+	NotebookDiffCellAdd
+	NotebookDiffCellDel
+)
+
+// NotebookDiffCell represents the difference of a cell of two notebooks
+type NotebookDiffCell struct {
+	Type           NotebookDiffCellType
+	CellType       string
+	LeftIdx        int
+	RightIdx       int
+	Lines          []*DiffLine
+	OutputsChanged bool
+}
+
+// CreateNotebookDiff creates a diff of the cells of two Jupyter notebooks. A missing notebook is
+// treated as a notebook without cells.
+func CreateNotebookDiff(baseReader, headReader io.Reader) ([]*NotebookDiffCell, error) {
+	readCells := func(reader io.Reader) ([]*jupyter.Cell, error) {
+		if reader == nil {
+			return nil, nil
+		}
+		nb, err := jupyter.ParseNotebook(reader)
+		if err != nil {
+			return nil, err
+		}
+		return nb.Cells, nil
+	}
+
+	baseCells, err := readCells(baseReader)
+	if err != nil {
+		return nil, err
+	}
+	headCells, err := readCells(headReader)
+	if err != nil {
+		return nil, err
+	}
+
+	// match the unchanged cells like lines of a text
+	keyOf := func(cells []*jupyter.Cell) []string {
+		keys := make([]string, len(cells))
+		for i, cell := range cells {
+			keys[i] = cell.CellType + "\x00" + string(cell.Source)
+		}
+		return keys
+	}
+	baseRunes, headRunes := stringsToRunes(keyOf(baseCells), keyOf(headCells))
+	diffs := diffMatchPatch.DiffMainRunes(baseRunes, headRunes, false)
+
+	var result []*NotebookDiffCell
+	var deleted, added []int
+	baseIdx, headIdx := 0, 0
+
+	// cells removed and added at the same position are paired as changed cells when their types match
+	flush := func() {
+		i := 0
+		for ; i < len(deleted) && i < len(added); i++ {
+			baseCell, headCell := baseCells[deleted[i]], headCells[added[i]]
+			if baseCell.CellType != headCell.CellType {
+				result = append(result, createNotebookDiffCell(NotebookDiffCellDel, deleted[i], baseCell, -1, nil))
+				result = append(result, createNotebookDiffCell(NotebookDiffCellAdd, -1, nil, added[i], headCell))
+				continue
+			}
+			result = append(result, createNotebookDiffCell(NotebookDiffCellChanged, deleted[i], baseCell, added[i], headCell))
+		}
+		for _, idx := range deleted[i:] {
+			result = append(result, createNotebookDiffCell(NotebookDiffCellDel, idx, baseCells[idx], -1, nil))
+		}
+		for _, idx := range added[i:] {
+			result = append(result, createNotebookDiffCell(NotebookDiffCellAdd, -1, nil, idx, headCells[idx]))
+		}
+		deleted, added = deleted[:0], added[:0]
+	}
+
+	for _, diff := range diffs {
+		count := len([]rune(diff.Text))
+		switch diff.Type {
+		case diffmatchpatch.DiffEqual:
+			flush()
+			for i := 0; i < count; i++ {
+				result = append(result, createNotebookDiffCell(NotebookDiffCellUnchanged, baseIdx, baseCells[baseIdx], headIdx, headCells[headIdx]))
+				baseIdx++
+				headIdx++
+			}
+		case diffmatchpatch.DiffDelete:
+			for i := 0; i < count; i++ {
+				deleted = append(deleted, baseIdx)
+				baseIdx++
+			}
+		case diffmatchpatch.DiffInsert:
+			for i := 0; i < count; i++ {
+				added = append(added, headIdx)
+				headIdx++
+			}
+		}
+	}
+	flush()
+
+	return result, nil
+}
+
+// createNotebookDiffCell creates the diff of a cell. The indexes of missing cells are -1.
+func createNotebookDiffCell(cellType NotebookDiffCellType, baseIdx int, baseCell *jupyter.Cell, headIdx int, headCell *jupyter.Cell) *NotebookDiffCell {
+	cell := &NotebookDiffCell{
+		Type:     cellType,
+		LeftIdx:  baseIdx + 1,
+		RightIdx: headIdx + 1,
+	}
+
+	var baseSource, headSource string
+	if baseCell != nil {
+		cell.CellType = baseCell.CellType
+		baseSource = string(baseCell.Source)
+	}
+	if headCell != nil {
+		cell.CellType = headCell.CellType
+		headSource = string(headCell.Source)
+	}
+	if baseCell != nil && headCell != nil {
+		cell.OutputsChanged = !equalOutputs(baseCell.Outputs, headCell.Outputs)
+	}
+	if cellType != NotebookDiffCellUnchanged {
+		cell.Lines = diffSourceLines(baseSource, headSource)
+	}
+	return cell
+}
+
+// diffSourceLines creates the line diff of the sources of a cell
+func diffSourceLines(baseSource, headSource string) []*DiffLine {
+	splitLines := func(text string) []string {
+		if text == "" {
+			return nil
+		}
+		return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	}
+	baseLines, headLines := splitLines(baseSource), splitLines(headSource)
+	baseRunes, headRunes := stringsToRunes(baseLines, headLines)
+
+	var lines []*DiffLine
+	leftIdx, rightIdx := 1, 1
+	for _, diff := range diffMatchPatch.DiffMainRunes(baseRunes, headRunes, false) {
+		for range []rune(diff.Text) {
+			switch diff.Type {
+			case diffmatchpatch.DiffEqual:
+				lines = append(lines, &DiffLine{LeftIdx: leftIdx, RightIdx: rightIdx, Type: DiffLinePlain, Content: " " + baseLines[leftIdx-1]})
+				leftIdx++
+				rightIdx++
+			case diffmatchpatch.DiffDelete:
+				lines = append(lines, &DiffLine{LeftIdx: leftIdx, Type: DiffLineDel, Content: "-" + baseLines[leftIdx-1]})
+				leftIdx++
+			case diffmatchpatch.DiffInsert:
+				lines = append(lines, &DiffLine{RightIdx: rightIdx, Type: DiffLineAdd, Content: "+" + headLines[rightIdx-1]})
+				rightIdx++
+			}
+		}
+	}
+	return lines
+}
+
+// stringsToRunes maps two lists of strings to runes, so they can be diffed like texts
+func stringsToRunes(base, head []string) ([]rune, []rune) {
+	keys := map[string]rune{}
+	toRunes := func(values []string) []rune {
+		runes := make([]rune, len(values))
+		for i, value := range values {
+			r, ok := keys[value]
+			if !ok {
+				r = rune(len(keys) + 1)
+				keys[value] = r
+			}
+			runes[i] = r
+		}
+		return runes
+	}
+	return toRunes(base), toRunes(head)
+}
+
+// equalOutputs compares the outputs of two cells ignoring their execution counts
+func equalOutputs(base, head []*jupyter.Output) bool {
+	normalize := func(outputs []*jupyter.Output) string {
+		normalized := make([]jupyter.Output, len(outputs))
+		for i, output := range outputs {
+			normalized[i] = *output
+			normalized[i].ExecutionCount = nil
+		}
+		data, _ := json.Marshal(normalized)
+		return string(data)
+	}
+	return normalize(base) == normalize(head)
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gitdiff
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotebookDiff(t *testing.T) {
+	notebook := func(cells string) io.Reader {
+		return strings.NewReader(`{"nbformat":4,"metadata":{},"cells":[` + cells + `]}`)
+	}
+
+	// initial commit of a notebook
+	cells, err := CreateNotebookDiff(nil, notebook(`{"cell_type":"markdown","source":"# Title"}`))
+	assert.NoError(t, err)
+	if assert.Len(t, cells, 1) {
+		assert.Equal(t, NotebookDiffCellAdd, cells[0].Type)
+		assert.Equal(t, 0, cells[0].LeftIdx)
+		assert.Equal(t, 1, cells[0].RightIdx)
+		if assert.Len(t, cells[0].Lines, 1) {
+			assert.Equal(t, DiffLineAdd, cells[0].Lines[0].Type)
+			assert.Equal(t, "+# Title", cells[0].Lines[0].Content)
+		}
+	}
+
+	base := notebook(`{"cell_type":"markdown","source":"# Title"},
+{"cell_type":"code","execution_count":1,"source":["a = 1\n","b = 2"],"outputs":[]},
+{"cell_type":"code","execution_count":2,"source":"print(a)","outputs":[{"output_type":"stream","name":"stdout","text":"1"}]},
+{"cell_type":"markdown","source":"Removed"}`)
+	head := notebook(`{"cell_type":"markdown","source":"# Title"},
+{"cell_type":"code","execution_count":3,"source":["a = 1\n","b = 3"],"outputs":[]},
+{"cell_type":"code","execution_count":4,"source":"print(a)","outputs":[{"output_type":"stream","name":"stdout","text":"2"}]},
+{"cell_type":"code","execution_count":5,"source":"print(b)","outputs":[]}`)
+
+	cells, err = CreateNotebookDiff(base, head)
+	assert.NoError(t, err)
+	if assert.Len(t, cells, 5) {
+		assert.Equal(t, NotebookDiffCellUnchanged, cells[0].Type)
+		assert.Empty(t, cells[0].Lines)
+
+		assert.Equal(t, NotebookDiffCellChanged, cells[1].Type)
+		assert.False(t, cells[1].OutputsChanged)
+		var types []DiffLineType
+		var contents []string
+		for _, line := range cells[1].Lines {
+			types = append(types, line.Type)
+			contents = append(contents, line.Content)
+		}
+		assert.Equal(t, []DiffLineType{DiffLinePlain, DiffLineDel, DiffLineAdd}, types)
+		assert.Equal(t, []string{" a = 1", "-b = 2", "+b = 3"}, contents)
+
+		assert.Equal(t, NotebookDiffCellUnchanged, cells[2].Type)
+		assert.True(t, cells[2].OutputsChanged)
+
+		// a markdown cell replaced by a code cell is not a changed cell
+		assert.Equal(t, NotebookDiffCellDel, cells[3].Type)
+		assert.Equal(t, 4, cells[3].LeftIdx)
+		assert.Equal(t, NotebookDiffCellAdd, cells[4].Type)
+		assert.Equal(t, 4, cells[4].RightIdx)
+	}
+
+	_, err = CreateNotebookDiff(strings.NewReader("not a notebook"), nil)
+	assert.Error(t, err)
+}
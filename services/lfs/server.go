@@ -81,6 +81,19 @@ func DownloadHandler(ctx *context.Context) {
 		return
 	}
 
+	if setting.LFS.ServeDirect {
+		//If we have a signed url (S3, object storage), redirect to this directly.
+		filename := ctx.Params("filename")
+		if len(filename) == 0 {
+			filename = meta.Oid
+		}
+		u, err := storage.LFS.URL(meta.RelativePath(), filename)
+		if u != nil && err == nil {
+			ctx.Redirect(u.String())
+			return
+		}
+	}
+
 	// Support resume download using Range header
 	var fromByte, toByte int64
 	toByte = meta.Size - 1
@@ -40,6 +40,8 @@ const (
 
 	mailRepoTransferNotify base.TplName = "notify/repo_transfer"
 
+	mailNotifyWebhookDisabled base.TplName = "notify/webhook_disabled"
+
 	// There's no actual limit for subject in RFC 5322
 	mailMaxSubjectRunes = 256
 )
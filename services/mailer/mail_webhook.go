@@ -0,0 +1,60 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	webhook_model "code.gitea.io/gitea/models/webhook"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/templates"
+	"code.gitea.io/gitea/modules/translation"
+)
+
+// SendWebhookDisabledMail notifies the owners of a webhook that it has been disabled after too
+// many failed deliveries
+func SendWebhookDisabledMail(w *webhook_model.Webhook, owners []*models.User, link string, failures int) {
+	if setting.MailService == nil {
+		// No mail service configured
+		return
+	}
+
+	langMap := make(map[string][]string)
+	for _, owner := range owners {
+		if owner.IsActive && owner.Email != "" {
+			langMap[owner.Language] = append(langMap[owner.Language], owner.Email)
+		}
+	}
+
+	for lang, tos := range langMap {
+		locale := translation.NewLocale(lang)
+		subject := locale.Tr("mail.webhook.disabled.subject", w.URL)
+		data := map[string]interface{}{
+			"Subject":  subject,
+			"URL":      w.URL,
+			"Failures": failures,
+			"Link":     link,
+			"Language": locale.Language(),
+			// helper
+			"i18n":     locale,
+			"Str2html": templates.Str2html,
+			"TrN":      templates.TrN,
+		}
+
+		var content bytes.Buffer
+		if err := bodyTemplates.ExecuteTemplate(&content, string(mailNotifyWebhookDisabled), data); err != nil {
+			log.Error("Template: %v", err)
+			return
+		}
+
+		msg := NewMessage(tos, subject, content.String())
+		msg.Info = fmt.Sprintf("Webhook: %d, disabled", w.ID)
+
+		SendAsync(msg)
+	}
+}
@@ -0,0 +1,821 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	base "code.gitea.io/gitea/modules/migration"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+var (
+	_ base.Downloader        = &AzureDevOpsDownloader{}
+	_ base.DownloaderFactory = &AzureDevOpsDownloaderFactory{}
+)
+
+func init() {
+	RegisterDownloaderFactory(&AzureDevOpsDownloaderFactory{})
+}
+
+// AzureDevOpsDownloaderFactory defines an Azure DevOps downloader factory
+type AzureDevOpsDownloaderFactory struct {
+}
+
+// New returns a Downloader related to this factory according MigrateOptions
+func (f *AzureDevOpsDownloaderFactory) New(ctx context.Context, opts base.MigrateOptions) (base.Downloader, error) {
+	u, err := url.Parse(opts.CloneAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, project, repoName, err := parseAzureDevOpsURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Trace("Create azure devops downloader. BaseURL: %s Project: %s Repo: %s", baseURL, project, repoName)
+
+	return NewAzureDevOpsDownloader(ctx, baseURL, opts.AuthUsername, opts.AuthPassword, opts.AuthToken, project, repoName), nil
+}
+
+// GitServiceType returns the type of git service
+func (f *AzureDevOpsDownloaderFactory) GitServiceType() structs.GitServiceType {
+	return structs.AzureDevOpsService
+}
+
+// parseAzureDevOpsURL splits urls like https://dev.azure.com/org/project/_git/repo,
+// https://org.visualstudio.com/project/_git/repo and https://server/collection/project/_git/repo
+// into the url of the organization or collection, the project and the repository.
+// The project may be omitted if the repository has the name of the project.
+func parseAzureDevOpsURL(u *url.URL) (baseURL, project, repoName string, err error) {
+	fields := strings.Split(strings.Trim(u.Path, "/"), "/")
+	gitIndex := -1
+	for i, field := range fields {
+		if field == "_git" && i+1 < len(fields) {
+			gitIndex = i
+			break
+		}
+	}
+	if gitIndex < 0 {
+		return "", "", "", fmt.Errorf("invalid path: %s", u.Path)
+	}
+	repoName = fields[gitIndex+1]
+
+	var collectionFields int
+	switch host := strings.ToLower(u.Hostname()); {
+	case host == "dev.azure.com":
+		collectionFields = 1
+	case strings.HasSuffix(host, ".visualstudio.com"):
+		if len(fields) > 0 && strings.EqualFold(fields[0], "DefaultCollection") {
+			collectionFields = 1
+		}
+	default:
+		// Azure DevOps Server always has a collection in front of the project
+		collectionFields = gitIndex - 1
+	}
+	if collectionFields < 0 || collectionFields > gitIndex {
+		return "", "", "", fmt.Errorf("invalid path: %s", u.Path)
+	}
+
+	project = repoName
+	if collectionFields < gitIndex {
+		project = strings.Join(fields[collectionFields:gitIndex], "/")
+	}
+	baseURL = u.Scheme + "://" + u.Host
+	if collectionFields > 0 {
+		baseURL += "/" + strings.Join(fields[:collectionFields], "/")
+	}
+	return baseURL, project, repoName, nil
+}
+
+type azureDevOpsIdentity struct {
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+}
+
+// email returns the unique name if it is an email, it may also be a windows account like DOMAIN\user
+func (i *azureDevOpsIdentity) email() string {
+	if i == nil || !strings.Contains(i.UniqueName, "@") {
+		return ""
+	}
+	return i.UniqueName
+}
+
+func (i *azureDevOpsIdentity) name() string {
+	if i == nil {
+		return ""
+	}
+	return i.DisplayName
+}
+
+type azureDevOpsThread struct {
+	ID            int64 `json:"id"`
+	IsDeleted     bool  `json:"isDeleted"`
+	ThreadContext *struct {
+		FilePath       string `json:"filePath"`
+		RightFileStart *struct {
+			Line int `json:"line"`
+		} `json:"rightFileStart"`
+		LeftFileStart *struct {
+			Line int `json:"line"`
+		} `json:"leftFileStart"`
+	} `json:"threadContext"`
+	Comments []struct {
+		ID              int64                `json:"id"`
+		ParentCommentID int64                `json:"parentCommentId"`
+		Author          *azureDevOpsIdentity `json:"author"`
+		Content         string               `json:"content"`
+		Published       time.Time            `json:"publishedDate"`
+		LastUpdated     time.Time            `json:"lastUpdatedDate"`
+		CommentType     string               `json:"commentType"`
+		IsDeleted       bool                 `json:"isDeleted"`
+	} `json:"comments"`
+}
+
+type azureDevOpsIssueContext struct {
+	foreignID     int64
+	localID       int64
+	IsPullRequest bool
+}
+
+func (c azureDevOpsIssueContext) LocalID() int64 {
+	return c.localID
+}
+
+func (c azureDevOpsIssueContext) ForeignID() int64 {
+	return c.foreignID
+}
+
+// AzureDevOpsDownloader implements a Downloader interface to get repository information
+// from Azure DevOps Services and Azure DevOps Server
+type AzureDevOpsDownloader struct {
+	base.NullDownloader
+	ctx           context.Context
+	client        *http.Client
+	baseURL       string
+	project       string
+	repoName      string
+	userName      string
+	password      string
+	workItemIDs   []int64
+	maxIssueIndex int64
+}
+
+// NewAzureDevOpsDownloader creates an Azure DevOps downloader, baseURL is the url of the organization or collection.
+// A personal access token is sent as password.
+func NewAzureDevOpsDownloader(ctx context.Context, baseURL, userName, password, token, project, repoName string) *AzureDevOpsDownloader {
+	if token != "" {
+		password = token
+	}
+	return &AzureDevOpsDownloader{
+		ctx:      ctx,
+		client:   NewMigrationHTTPClient(),
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		project:  project,
+		repoName: repoName,
+		userName: userName,
+		password: password,
+	}
+}
+
+// SetContext set context
+func (d *AzureDevOpsDownloader) SetContext(ctx context.Context) {
+	d.ctx = ctx
+}
+
+// callAPI requests an endpoint of the project api, a body is sent as JSON
+func (d *AzureDevOpsDownloader) callAPI(method, endpoint string, parameter url.Values, body, result interface{}) error {
+	if parameter == nil {
+		parameter = url.Values{}
+	}
+	if parameter.Get("api-version") == "" {
+		parameter.Set("api-version", "6.0")
+	}
+	u := fmt.Sprintf("%s/%s/_apis%s?%s", d.baseURL, url.PathEscape(d.project), endpoint, parameter.Encode())
+
+	var reqBody io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(bs)
+	}
+
+	req, err := http.NewRequestWithContext(d.ctx, method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if d.userName != "" || d.password != "" {
+		req.SetBasicAuth(d.userName, d.password)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status of %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (d *AzureDevOpsDownloader) repoEndpoint(endpoint string) string {
+	return "/git/repositories/" + url.PathEscape(d.repoName) + endpoint
+}
+
+// GetRepoInfo returns repository information
+func (d *AzureDevOpsDownloader) GetRepoInfo() (*base.Repository, error) {
+	var repo struct {
+		Name          string `json:"name"`
+		DefaultBranch string `json:"defaultBranch"`
+		RemoteURL     string `json:"remoteUrl"`
+		WebURL        string `json:"webUrl"`
+		Project       struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Visibility  string `json:"visibility"`
+		} `json:"project"`
+	}
+	if err := d.callAPI("GET", d.repoEndpoint(""), nil, nil, &repo); err != nil {
+		return nil, err
+	}
+
+	// the remote url contains the name of the organization as user
+	cloneURL, err := url.Parse(repo.RemoteURL)
+	if err != nil {
+		return nil, err
+	}
+	cloneURL.User = nil
+
+	return &base.Repository{
+		Name:          repo.Name,
+		Owner:         repo.Project.Name,
+		IsPrivate:     repo.Project.Visibility != "public",
+		Description:   repo.Project.Description,
+		CloneURL:      cloneURL.String(),
+		OriginalURL:   repo.WebURL,
+		DefaultBranch: strings.TrimPrefix(repo.DefaultBranch, "refs/heads/"),
+	}, nil
+}
+
+// GetTopics return repository topics, Azure DevOps has none
+func (d *AzureDevOpsDownloader) GetTopics() ([]string, error) {
+	return []string{}, nil
+}
+
+// GetReleases returns no releases, the releases of Azure Pipelines are deployments and not related to tags
+func (d *AzureDevOpsDownloader) GetReleases() ([]*base.Release, error) {
+	return []*base.Release{}, nil
+}
+
+type azureDevOpsIteration struct {
+	Name       string `json:"name"`
+	Attributes *struct {
+		StartDate  *time.Time `json:"startDate"`
+		FinishDate *time.Time `json:"finishDate"`
+	} `json:"attributes"`
+	Children []*azureDevOpsIteration `json:"children"`
+}
+
+// GetMilestones returns the iterations of the project as milestones, nested iterations are named parent/child
+func (d *AzureDevOpsDownloader) GetMilestones() ([]*base.Milestone, error) {
+	var root azureDevOpsIteration
+	if err := d.callAPI("GET", "/wit/classificationnodes/Iterations", url.Values{"$depth": []string{"10"}}, nil, &root); err != nil {
+		return nil, err
+	}
+
+	var milestones = make([]*base.Milestone, 0, 10)
+	var walk func(iterations []*azureDevOpsIteration, prefix string)
+	walk = func(iterations []*azureDevOpsIteration, prefix string) {
+		for _, iteration := range iterations {
+			milestone := &base.Milestone{
+				Title: prefix + iteration.Name,
+				State: "open",
+			}
+			if iteration.Attributes != nil {
+				if iteration.Attributes.StartDate != nil {
+					milestone.Created = *iteration.Attributes.StartDate
+				}
+				if finish := iteration.Attributes.FinishDate; finish != nil {
+					milestone.Deadline = finish
+					if finish.Before(time.Now()) {
+						milestone.State = "closed"
+						milestone.Closed = finish
+					}
+				}
+			}
+			milestones = append(milestones, milestone)
+			walk(iteration.Children, milestone.Title+"/")
+		}
+	}
+	walk(root.Children, "")
+
+	return milestones, nil
+}
+
+// iterationTitle converts the iteration path of a work item to the title of the milestone
+func (d *AzureDevOpsDownloader) iterationTitle(iterationPath string) string {
+	fields := strings.SplitN(iterationPath, `\`, 2)
+	if len(fields) < 2 {
+		// the root iteration is the project itself
+		return ""
+	}
+	return strings.ReplaceAll(fields[1], `\`, "/")
+}
+
+// GetLabels returns the visible work item types and the tags of the project as labels
+func (d *AzureDevOpsDownloader) GetLabels() ([]*base.Label, error) {
+	var categories struct {
+		Value []struct {
+			ReferenceName string `json:"referenceName"`
+			WorkItemTypes []struct {
+				Name string `json:"name"`
+			} `json:"workItemTypes"`
+		} `json:"value"`
+	}
+	if err := d.callAPI("GET", "/wit/workitemtypecategories", nil, nil, &categories); err != nil {
+		return nil, err
+	}
+	hidden := make(map[string]bool)
+	for _, category := range categories.Value {
+		if category.ReferenceName != "Microsoft.HiddenCategory" {
+			continue
+		}
+		for _, tp := range category.WorkItemTypes {
+			hidden[tp.Name] = true
+		}
+	}
+
+	var types struct {
+		Value []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Color       string `json:"color"`
+			IsDisabled  bool   `json:"isDisabled"`
+		} `json:"value"`
+	}
+	if err := d.callAPI("GET", "/wit/workitemtypes", nil, nil, &types); err != nil {
+		return nil, err
+	}
+
+	var labels = make([]*base.Label, 0, len(types.Value))
+	for _, tp := range types.Value {
+		if tp.IsDisabled || hidden[tp.Name] {
+			continue
+		}
+		labels = append(labels, &base.Label{
+			Name:        tp.Name,
+			Color:       strings.ToLower(tp.Color),
+			Description: tp.Description,
+		})
+	}
+
+	var tags struct {
+		Value []struct {
+			Name string `json:"name"`
+		} `json:"value"`
+	}
+	if err := d.callAPI("GET", "/wit/tags", url.Values{"api-version": []string{"6.0-preview.1"}}, nil, &tags); err != nil {
+		return nil, err
+	}
+	for _, tag := range tags.Value {
+		labels = append(labels, &base.Label{
+			Name:  tag.Name,
+			Color: "e4e6eb",
+		})
+	}
+
+	return labels, nil
+}
+
+// azureDevOpsClosedStates are the closed states of the default processes, other states are considered open
+var azureDevOpsClosedStates = map[string]bool{
+	"Closed":    true,
+	"Done":      true,
+	"Removed":   true,
+	"Completed": true,
+}
+
+// GetIssues returns the work items of the project as issues
+func (d *AzureDevOpsDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	if d.workItemIDs == nil {
+		// WIQL returns the ids of at most 20000 work items
+		var result struct {
+			WorkItems []struct {
+				ID int64 `json:"id"`
+			} `json:"workItems"`
+		}
+		query := map[string]string{
+			"query": "SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = @project ORDER BY [System.Id]",
+		}
+		if err := d.callAPI("POST", "/wit/wiql", nil, query, &result); err != nil {
+			return nil, false, err
+		}
+		d.workItemIDs = make([]int64, 0, len(result.WorkItems))
+		for _, item := range result.WorkItems {
+			d.workItemIDs = append(d.workItemIDs, item.ID)
+		}
+	}
+
+	start := (page - 1) * perPage
+	if start >= len(d.workItemIDs) {
+		return []*base.Issue{}, true, nil
+	}
+	end := start + perPage
+	if end > len(d.workItemIDs) {
+		end = len(d.workItemIDs)
+	}
+
+	issues := make([]*base.Issue, 0, end-start)
+	// the work items api returns at most 200 items per request
+	for ; start < end; start += 200 {
+		batchEnd := start + 200
+		if batchEnd > end {
+			batchEnd = end
+		}
+		ids := make([]string, 0, batchEnd-start)
+		for _, id := range d.workItemIDs[start:batchEnd] {
+			ids = append(ids, strconv.FormatInt(id, 10))
+		}
+
+		var result struct {
+			Value []struct {
+				ID     int64 `json:"id"`
+				Fields struct {
+					Title         string               `json:"System.Title"`
+					Description   string               `json:"System.Description"`
+					ReproSteps    string               `json:"Microsoft.VSTS.TCM.ReproSteps"`
+					State         string               `json:"System.State"`
+					WorkItemType  string               `json:"System.WorkItemType"`
+					CreatedBy     *azureDevOpsIdentity `json:"System.CreatedBy"`
+					AssignedTo    *azureDevOpsIdentity `json:"System.AssignedTo"`
+					Created       time.Time            `json:"System.CreatedDate"`
+					Changed       time.Time            `json:"System.ChangedDate"`
+					Closed        *time.Time           `json:"Microsoft.VSTS.Common.ClosedDate"`
+					Tags          string               `json:"System.Tags"`
+					IterationPath string               `json:"System.IterationPath"`
+				} `json:"fields"`
+			} `json:"value"`
+		}
+		if err := d.callAPI("GET", "/wit/workitems", url.Values{"ids": []string{strings.Join(ids, ",")}}, nil, &result); err != nil {
+			return nil, false, err
+		}
+
+		for _, item := range result.Value {
+			fields := item.Fields
+
+			labels := []*base.Label{{Name: fields.WorkItemType}}
+			for _, tag := range strings.Split(fields.Tags, ";") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					labels = append(labels, &base.Label{Name: tag})
+				}
+			}
+
+			// bugs describe the steps to reproduce instead of having a description
+			content := fields.Description
+			if content == "" {
+				content = fields.ReproSteps
+			}
+
+			state := "open"
+			var closed *time.Time
+			if azureDevOpsClosedStates[fields.State] {
+				state = "closed"
+				closed = fields.Closed
+				if closed == nil {
+					closed = &fields.Changed
+				}
+			}
+
+			var assignees []string
+			if fields.AssignedTo != nil {
+				assignees = []string{fields.AssignedTo.name()}
+			}
+
+			issues = append(issues, &base.Issue{
+				Number:      item.ID,
+				PosterName:  fields.CreatedBy.name(),
+				PosterEmail: fields.CreatedBy.email(),
+				Title:       fields.Title,
+				Content:     content,
+				Milestone:   d.iterationTitle(fields.IterationPath),
+				State:       state,
+				Created:     fields.Created,
+				Updated:     fields.Changed,
+				Closed:      closed,
+				Labels:      labels,
+				Assignees:   assignees,
+				Context: azureDevOpsIssueContext{
+					foreignID: item.ID,
+					localID:   item.ID,
+				},
+			})
+
+			if d.maxIssueIndex < item.ID {
+				d.maxIssueIndex = item.ID
+			}
+		}
+	}
+
+	return issues, end == len(d.workItemIDs), nil
+}
+
+// GetComments returns the discussion of a work item or the general comments of a pull request,
+// comments on files of pull requests are returned by GetReviews
+func (d *AzureDevOpsDownloader) GetComments(opts base.GetCommentOptions) ([]*base.Comment, bool, error) {
+	context, ok := opts.Context.(azureDevOpsIssueContext)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected comment context: %+v", opts.Context)
+	}
+
+	if context.IsPullRequest {
+		threads, err := d.getThreads(context.ForeignID())
+		if err != nil {
+			return nil, false, err
+		}
+		var comments []*base.Comment
+		for _, thread := range threads {
+			if thread.IsDeleted || thread.ThreadContext != nil {
+				continue
+			}
+			for _, comment := range thread.Comments {
+				// system comments describe changes like the update of the source branch
+				if comment.IsDeleted || comment.CommentType != "text" {
+					continue
+				}
+				comments = append(comments, &base.Comment{
+					IssueIndex:  context.LocalID(),
+					PosterName:  comment.Author.name(),
+					PosterEmail: comment.Author.email(),
+					Content:     comment.Content,
+					Created:     comment.Published,
+					Updated:     comment.LastUpdated,
+				})
+			}
+		}
+		return comments, true, nil
+	}
+
+	var comments []*base.Comment
+	var continuationToken string
+	for {
+		parameter := url.Values{
+			"api-version": []string{"6.0-preview.3"},
+			"$top":        []string{"200"},
+			"order":       []string{"asc"},
+		}
+		if continuationToken != "" {
+			parameter.Set("continuationToken", continuationToken)
+		}
+		var result struct {
+			Comments []struct {
+				Text      string               `json:"text"`
+				CreatedBy *azureDevOpsIdentity `json:"createdBy"`
+				Created   time.Time            `json:"createdDate"`
+				Modified  time.Time            `json:"modifiedDate"`
+				IsDeleted bool                 `json:"isDeleted"`
+			} `json:"comments"`
+			ContinuationToken string `json:"continuationToken"`
+		}
+		if err := d.callAPI("GET", fmt.Sprintf("/wit/workItems/%d/comments", context.ForeignID()), parameter, nil, &result); err != nil {
+			return nil, false, err
+		}
+		for _, comment := range result.Comments {
+			if comment.IsDeleted {
+				continue
+			}
+			comments = append(comments, &base.Comment{
+				IssueIndex:  context.LocalID(),
+				PosterName:  comment.CreatedBy.name(),
+				PosterEmail: comment.CreatedBy.email(),
+				Content:     comment.Text,
+				Created:     comment.Created,
+				Updated:     comment.Modified,
+			})
+		}
+		if result.ContinuationToken == "" {
+			return comments, true, nil
+		}
+		continuationToken = result.ContinuationToken
+	}
+}
+
+type azureDevOpsCommit struct {
+	CommitID string `json:"commitId"`
+}
+
+func (c *azureDevOpsCommit) id() string {
+	if c == nil {
+		return ""
+	}
+	return c.CommitID
+}
+
+// GetPullRequests returns pull requests according page and perPage
+func (d *AzureDevOpsDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, bool, error) {
+	var result struct {
+		Value []struct {
+			PullRequestID         int64                `json:"pullRequestId"`
+			Status                string               `json:"status"`
+			CreatedBy             *azureDevOpsIdentity `json:"createdBy"`
+			Created               time.Time            `json:"creationDate"`
+			Closed                *time.Time           `json:"closedDate"`
+			Title                 string               `json:"title"`
+			Description           string               `json:"description"`
+			SourceRefName         string               `json:"sourceRefName"`
+			TargetRefName         string               `json:"targetRefName"`
+			LastMergeSourceCommit *azureDevOpsCommit   `json:"lastMergeSourceCommit"`
+			LastMergeTargetCommit *azureDevOpsCommit   `json:"lastMergeTargetCommit"`
+			LastMergeCommit       *azureDevOpsCommit   `json:"lastMergeCommit"`
+			Labels                []struct {
+				Name string `json:"name"`
+			} `json:"labels"`
+			ForkSource *struct {
+				Repository struct {
+					Name      string `json:"name"`
+					RemoteURL string `json:"remoteUrl"`
+					Project   struct {
+						Name string `json:"name"`
+					} `json:"project"`
+				} `json:"repository"`
+			} `json:"forkSource"`
+		} `json:"value"`
+	}
+	parameter := url.Values{
+		"searchCriteria.status": []string{"all"},
+		"$skip":                 []string{strconv.Itoa((page - 1) * perPage)},
+		"$top":                  []string{strconv.Itoa(perPage)},
+	}
+	if err := d.callAPI("GET", d.repoEndpoint("/pullrequests"), parameter, nil, &result); err != nil {
+		return nil, false, err
+	}
+
+	pullRequests := make([]*base.PullRequest, 0, len(result.Value))
+	for _, pr := range result.Value {
+		state := "open"
+		merged := false
+		var closed, mergedTime *time.Time
+		var mergeCommitSHA string
+		if pr.Status != "active" {
+			state = "closed"
+			closed = pr.Closed
+		}
+		if pr.Status == "completed" {
+			merged = true
+			mergedTime = pr.Closed
+			mergeCommitSHA = pr.LastMergeCommit.id()
+		}
+
+		labels := make([]*base.Label, 0, len(pr.Labels))
+		for _, label := range pr.Labels {
+			labels = append(labels, &base.Label{Name: label.Name})
+		}
+
+		head := base.PullRequestBranch{
+			Ref:       strings.TrimPrefix(pr.SourceRefName, "refs/heads/"),
+			SHA:       pr.LastMergeSourceCommit.id(),
+			RepoName:  d.repoName,
+			OwnerName: d.project,
+		}
+		if pr.ForkSource != nil {
+			head.RepoName = pr.ForkSource.Repository.Name
+			head.OwnerName = pr.ForkSource.Repository.Project.Name
+			head.CloneURL = pr.ForkSource.Repository.RemoteURL
+		}
+
+		number := pr.PullRequestID + d.maxIssueIndex
+		pullRequests = append(pullRequests, &base.PullRequest{
+			Number:         number,
+			Title:          pr.Title,
+			PosterName:     pr.CreatedBy.name(),
+			PosterEmail:    pr.CreatedBy.email(),
+			Content:        pr.Description,
+			State:          state,
+			Created:        pr.Created,
+			Updated:        pr.Created,
+			Closed:         closed,
+			Labels:         labels,
+			Merged:         merged,
+			MergedTime:     mergedTime,
+			MergeCommitSHA: mergeCommitSHA,
+			Head:           head,
+			Base: base.PullRequestBranch{
+				Ref:       strings.TrimPrefix(pr.TargetRefName, "refs/heads/"),
+				SHA:       pr.LastMergeTargetCommit.id(),
+				RepoName:  d.repoName,
+				OwnerName: d.project,
+			},
+			Context: azureDevOpsIssueContext{
+				foreignID:     pr.PullRequestID,
+				localID:       number,
+				IsPullRequest: true,
+			},
+		})
+	}
+
+	return pullRequests, len(pullRequests) < perPage, nil
+}
+
+func (d *AzureDevOpsDownloader) getThreads(id int64) ([]*azureDevOpsThread, error) {
+	var result struct {
+		Value []*azureDevOpsThread `json:"value"`
+	}
+	if err := d.callAPI("GET", d.repoEndpoint(fmt.Sprintf("/pullRequests/%d/threads", id)), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Value, nil
+}
+
+// GetReviews returns the votes of the reviewers and the threads on files of a pull request
+func (d *AzureDevOpsDownloader) GetReviews(context base.IssueContext) ([]*base.Review, error) {
+	var reviewers struct {
+		Value []struct {
+			DisplayName string `json:"displayName"`
+			Vote        int    `json:"vote"`
+		} `json:"value"`
+	}
+	if err := d.callAPI("GET", d.repoEndpoint(fmt.Sprintf("/pullRequests/%d/reviewers", context.ForeignID())), nil, nil, &reviewers); err != nil {
+		return nil, err
+	}
+
+	var reviews []*base.Review
+	for _, reviewer := range reviewers.Value {
+		// 10 approved, 5 approved with suggestions, 0 no vote, -5 waiting for author, -10 rejected
+		var state string
+		switch {
+		case reviewer.Vote > 0:
+			state = base.ReviewStateApproved
+		case reviewer.Vote < 0:
+			state = base.ReviewStateChangesRequested
+		default:
+			continue
+		}
+		reviews = append(reviews, &base.Review{
+			IssueIndex:   context.LocalID(),
+			ReviewerName: reviewer.DisplayName,
+			State:        state,
+		})
+	}
+
+	threads, err := d.getThreads(context.ForeignID())
+	if err != nil {
+		return nil, err
+	}
+	for _, thread := range threads {
+		if thread.IsDeleted || thread.ThreadContext == nil || len(thread.Comments) == 0 {
+			continue
+		}
+
+		var line int
+		if start := thread.ThreadContext.RightFileStart; start != nil {
+			line = start.Line
+		} else if start := thread.ThreadContext.LeftFileStart; start != nil {
+			line = -start.Line
+		}
+
+		first := thread.Comments[0]
+		review := &base.Review{
+			ID:           thread.ID,
+			IssueIndex:   context.LocalID(),
+			ReviewerName: first.Author.name(),
+			CreatedAt:    first.Published,
+			State:        base.ReviewStateCommented,
+		}
+		for _, comment := range thread.Comments {
+			if comment.IsDeleted || comment.CommentType != "text" {
+				continue
+			}
+			review.Comments = append(review.Comments, &base.ReviewComment{
+				ID:        comment.ID,
+				InReplyTo: comment.ParentCommentID,
+				Content:   comment.Content,
+				TreePath:  strings.TrimPrefix(thread.ThreadContext.FilePath, "/"),
+				Line:      line,
+				CreatedAt: comment.Published,
+				UpdatedAt: comment.LastUpdated,
+			})
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, nil
+}
@@ -0,0 +1,278 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	base "code.gitea.io/gitea/modules/migration"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAzureDevOpsURL(t *testing.T) {
+	kases := []struct {
+		url      string
+		baseURL  string
+		project  string
+		repoName string
+	}{
+		{"https://dev.azure.com/gitea-test/test_project/_git/test_repo", "https://dev.azure.com/gitea-test", "test_project", "test_repo"},
+		{"https://gitea-test@dev.azure.com/gitea-test/test_project/_git/test_repo", "https://dev.azure.com/gitea-test", "test_project", "test_repo"},
+		{"https://dev.azure.com/gitea-test/_git/test_project", "https://dev.azure.com/gitea-test", "test_project", "test_project"},
+		{"https://gitea-test.visualstudio.com/test_project/_git/test_repo", "https://gitea-test.visualstudio.com", "test_project", "test_repo"},
+		{"https://gitea-test.visualstudio.com/DefaultCollection/test_project/_git/test_repo", "https://gitea-test.visualstudio.com/DefaultCollection", "test_project", "test_repo"},
+		{"https://tfs.example.com/tfs/DefaultCollection/test_project/_git/test_repo", "https://tfs.example.com/tfs/DefaultCollection", "test_project", "test_repo"},
+	}
+	for _, kase := range kases {
+		u, err := url.Parse(kase.url)
+		assert.NoError(t, err)
+		baseURL, project, repoName, err := parseAzureDevOpsURL(u)
+		assert.NoError(t, err, kase.url)
+		assert.Equal(t, kase.baseURL, baseURL, kase.url)
+		assert.Equal(t, kase.project, project, kase.url)
+		assert.Equal(t, kase.repoName, repoName, kase.url)
+	}
+
+	u, _ := url.Parse("https://dev.azure.com/gitea-test/test_project/_boards")
+	_, _, _, err := parseAzureDevOpsURL(u)
+	assert.Error(t, err)
+}
+
+func TestAzureDevOpsDownloadRepo(t *testing.T) {
+	server := newMockWebServer(t, "https://dev.azure.com", "testdata/azuredevops", os.Getenv("GITEA_MIGRATION_RECORD") != "")
+
+	downloader := NewAzureDevOpsDownloader(context.Background(), server.URL+"/gitea-test", "", "", os.Getenv("AZURE_DEVOPS_READ_TOKEN"), "test_project", "test_repo")
+
+	repo, err := downloader.GetRepoInfo()
+	assert.NoError(t, err)
+	assertRepositoryEqual(t, &base.Repository{
+		Name:          "test_repo",
+		Owner:         "test_project",
+		IsPrivate:     true,
+		Description:   "Project for testing migration from Azure DevOps to gitea",
+		CloneURL:      "https://dev.azure.com/gitea-test/test_project/_git/test_repo",
+		OriginalURL:   server.URL + "/gitea-test/test_project/_git/test_repo",
+		DefaultBranch: "main",
+	}, repo)
+
+	milestones, err := downloader.GetMilestones()
+	assert.NoError(t, err)
+	assertMilestonesEqual(t, []*base.Milestone{
+		{
+			Title:    "Sprint 1",
+			Created:  time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC),
+			Deadline: timePtr(time.Date(2021, 11, 12, 0, 0, 0, 0, time.UTC)),
+			Closed:   timePtr(time.Date(2021, 11, 12, 0, 0, 0, 0, time.UTC)),
+			State:    "closed",
+		},
+		{
+			Title: "Release 2",
+			State: "open",
+		},
+		{
+			Title: "Release 2/Sprint 3",
+			State: "open",
+		},
+	}, milestones)
+
+	labels, err := downloader.GetLabels()
+	assert.NoError(t, err)
+	assertLabelsEqual(t, []*base.Label{
+		{Name: "Bug", Color: "cc293d", Description: "Describes a divergence between required and actual behavior."},
+		{Name: "User Story", Color: "009ccc", Description: "Tracks an activity the user will be able to perform with the product"},
+		{Name: "config", Color: "e4e6eb"},
+		{Name: "crash", Color: "e4e6eb"},
+	}, labels)
+
+	issues, isEnd, err := downloader.GetIssues(1, 2)
+	assert.NoError(t, err)
+	assert.False(t, isEnd)
+	assertIssuesEqual(t, []*base.Issue{
+		{
+			Number:      1,
+			Title:       "Crash on empty config",
+			Content:     "<div>Start the server with an empty <b>app.ini</b>.</div>",
+			PosterName:  "Alice Liddell",
+			PosterEmail: "alice@example.com",
+			Milestone:   "Sprint 1",
+			State:       "closed",
+			Created:     time.Date(2021, 11, 2, 10, 0, 0, 123000000, time.UTC),
+			Updated:     time.Date(2021, 11, 5, 12, 35, 0, 0, time.UTC),
+			Closed:      timePtr(time.Date(2021, 11, 5, 12, 30, 0, 0, time.UTC)),
+			Labels: []*base.Label{
+				{Name: "Bug"},
+				{Name: "config"},
+				{Name: "crash"},
+			},
+			Assignees: []string{"Bob Builder"},
+		},
+		{
+			Number:     2,
+			Title:      "Support dark mode",
+			Content:    "<div>It would be nice to have a dark theme.</div>",
+			PosterName: "Carol",
+			State:      "open",
+			Created:    time.Date(2021, 11, 3, 8, 15, 0, 0, time.UTC),
+			Updated:    time.Date(2021, 11, 3, 8, 15, 0, 0, time.UTC),
+			Labels: []*base.Label{
+				{Name: "User Story"},
+			},
+		},
+	}, issues)
+
+	comments, _, err := downloader.GetComments(base.GetCommentOptions{Context: issues[0].Context})
+	assert.NoError(t, err)
+	assertCommentsEqual(t, []*base.Comment{
+		{
+			IssueIndex:  1,
+			PosterName:  "Bob Builder",
+			PosterEmail: "bob@example.com",
+			Content:     "I can reproduce this.",
+			Created:     time.Date(2021, 11, 3, 9, 0, 0, 0, time.UTC),
+			Updated:     time.Date(2021, 11, 3, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			IssueIndex:  1,
+			PosterName:  "Alice Liddell",
+			PosterEmail: "alice@example.com",
+			Content:     "<p>Fixed in main.</p>",
+			Created:     time.Date(2021, 11, 5, 12, 31, 0, 0, time.UTC),
+			Updated:     time.Date(2021, 11, 5, 12, 35, 0, 0, time.UTC),
+		},
+	}, comments)
+
+	prs, isEnd, err := downloader.GetPullRequests(1, 2)
+	assert.NoError(t, err)
+	assert.False(t, isEnd)
+	assertPullRequestsEqual(t, []*base.PullRequest{
+		{
+			Number:      8,
+			Title:       "Add dark theme",
+			PosterName:  "Bob Builder",
+			PosterEmail: "bob@example.com",
+			State:       "open",
+			Created:     time.Date(2021, 11, 6, 14, 0, 0, 0, time.UTC),
+			Updated:     time.Date(2021, 11, 6, 14, 0, 0, 0, time.UTC),
+			Labels: []*base.Label{
+				{Name: "ui"},
+			},
+			Head: base.PullRequestBranch{
+				Ref:       "dark-theme",
+				SHA:       "fedcba9876543210fedcba9876543210fedcba98",
+				RepoName:  "test_repo",
+				OwnerName: "test_project",
+			},
+			Base: base.PullRequestBranch{
+				Ref:       "main",
+				SHA:       "aabbccddeeff00112233445566778899aabbccdd",
+				RepoName:  "test_repo",
+				OwnerName: "test_project",
+			},
+		},
+		{
+			Number:         7,
+			Title:          "Fix crash on empty config",
+			Content:        "Fixes #1",
+			PosterName:     "Alice Liddell",
+			PosterEmail:    "alice@example.com",
+			State:          "closed",
+			Created:        time.Date(2021, 11, 4, 10, 0, 0, 0, time.UTC),
+			Updated:        time.Date(2021, 11, 4, 10, 0, 0, 0, time.UTC),
+			Closed:         timePtr(time.Date(2021, 11, 5, 12, 0, 0, 0, time.UTC)),
+			Merged:         true,
+			MergedTime:     timePtr(time.Date(2021, 11, 5, 12, 0, 0, 0, time.UTC)),
+			MergeCommitSHA: "0123456789abcdef0123456789abcdef01234567",
+			Labels:         []*base.Label{},
+			Head: base.PullRequestBranch{
+				Ref:       "fix-empty-config",
+				SHA:       "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+				RepoName:  "test_repo",
+				OwnerName: "test_project",
+			},
+			Base: base.PullRequestBranch{
+				Ref:       "main",
+				SHA:       "aabbccddeeff00112233445566778899aabbccdd",
+				RepoName:  "test_repo",
+				OwnerName: "test_project",
+			},
+		},
+	}, prs)
+
+	comments, _, err = downloader.GetComments(base.GetCommentOptions{Context: prs[1].Context})
+	assert.NoError(t, err)
+	assertCommentsEqual(t, []*base.Comment{
+		{
+			IssueIndex:  7,
+			PosterName:  "Bob Builder",
+			PosterEmail: "bob@example.com",
+			Content:     "Thanks, I will have a look.",
+			Created:     time.Date(2021, 11, 4, 10, 15, 0, 0, time.UTC),
+			Updated:     time.Date(2021, 11, 4, 10, 20, 0, 0, time.UTC),
+		},
+	}, comments)
+
+	reviews, err := downloader.GetReviews(prs[1].Context)
+	assert.NoError(t, err)
+	assertReviewsEqual(t, []*base.Review{
+		{
+			IssueIndex:   7,
+			ReviewerName: "Bob Builder",
+			State:        base.ReviewStateApproved,
+		},
+		{
+			IssueIndex:   7,
+			ReviewerName: "Carol",
+			State:        base.ReviewStateChangesRequested,
+		},
+		{
+			ID:           13,
+			IssueIndex:   7,
+			ReviewerName: "Bob Builder",
+			CreatedAt:    time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+			State:        base.ReviewStateCommented,
+			Comments: []*base.ReviewComment{
+				{
+					ID:        1,
+					Content:   "Please check for `nil` here.",
+					TreePath:  "modules/setting/setting.go",
+					Line:      12,
+					CreatedAt: time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+					UpdatedAt: time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+				},
+				{
+					ID:        2,
+					InReplyTo: 1,
+					Content:   "Done.",
+					TreePath:  "modules/setting/setting.go",
+					Line:      12,
+					CreatedAt: time.Date(2021, 11, 4, 11, 30, 0, 0, time.UTC),
+					UpdatedAt: time.Date(2021, 11, 4, 11, 31, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			ID:           15,
+			IssueIndex:   7,
+			ReviewerName: "Carol",
+			CreatedAt:    time.Date(2021, 11, 4, 11, 50, 0, 0, time.UTC),
+			State:        base.ReviewStateCommented,
+			Comments: []*base.ReviewComment{
+				{
+					ID:        1,
+					Content:   "Why was this removed?",
+					TreePath:  "README.md",
+					Line:      -7,
+					CreatedAt: time.Date(2021, 11, 4, 11, 50, 0, 0, time.UTC),
+					UpdatedAt: time.Date(2021, 11, 4, 11, 50, 0, 0, time.UTC),
+				},
+			},
+		},
+	}, reviews)
+}
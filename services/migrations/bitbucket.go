@@ -0,0 +1,721 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	base "code.gitea.io/gitea/modules/migration"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+var (
+	_ base.Downloader        = &BitbucketDownloader{}
+	_ base.DownloaderFactory = &BitbucketDownloaderFactory{}
+)
+
+func init() {
+	RegisterDownloaderFactory(&BitbucketDownloaderFactory{})
+}
+
+// BitbucketDownloaderFactory defines a bitbucket.org downloader factory
+type BitbucketDownloaderFactory struct {
+}
+
+// New returns a Downloader related to this factory according MigrateOptions
+func (f *BitbucketDownloaderFactory) New(ctx context.Context, opts base.MigrateOptions) (base.Downloader, error) {
+	u, err := url.Parse(opts.CloneAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(strings.TrimPrefix(u.Host, "www."), "bitbucket.org") {
+		return nil, fmt.Errorf("%s is not bitbucket.org, use the Bitbucket Server migration instead", u.Host)
+	}
+
+	fields := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid path: %s", u.Path)
+	}
+	owner := fields[0]
+	repoName := strings.TrimSuffix(fields[1], ".git")
+
+	log.Trace("Create bitbucket downloader: %s/%s", owner, repoName)
+
+	return NewBitbucketDownloader(ctx, "https://api.bitbucket.org", opts.AuthUsername, opts.AuthPassword, opts.AuthToken, owner, repoName), nil
+}
+
+// GitServiceType returns the type of git service
+func (f *BitbucketDownloaderFactory) GitServiceType() structs.GitServiceType {
+	return structs.BitbucketService
+}
+
+type bitbucketUser struct {
+	DisplayName string `json:"display_name"`
+	Nickname    string `json:"nickname"`
+	AccountID   string `json:"account_id"`
+}
+
+// name returns the name of the user, users which have been deleted are null in the api
+func (u *bitbucketUser) name() string {
+	if u == nil {
+		return ""
+	}
+	if u.Nickname != "" {
+		return u.Nickname
+	}
+	return u.DisplayName
+}
+
+type bitbucketContent struct {
+	Raw string `json:"raw"`
+}
+
+type bitbucketComment struct {
+	ID      int64            `json:"id"`
+	Content bitbucketContent `json:"content"`
+	User    *bitbucketUser   `json:"user"`
+	Created time.Time        `json:"created_on"`
+	Updated time.Time        `json:"updated_on"`
+	Deleted bool             `json:"deleted"`
+	Inline  *struct {
+		Path string `json:"path"`
+		From *int   `json:"from"`
+		To   *int   `json:"to"`
+	} `json:"inline"`
+	Parent *struct {
+		ID int64 `json:"id"`
+	} `json:"parent"`
+}
+
+type bitbucketIssueContext struct {
+	foreignID     int64
+	localID       int64
+	IsPullRequest bool
+}
+
+func (c bitbucketIssueContext) LocalID() int64 {
+	return c.localID
+}
+
+func (c bitbucketIssueContext) ForeignID() int64 {
+	return c.foreignID
+}
+
+// BitbucketDownloader implements a Downloader interface to get repository information
+// from bitbucket.org
+type BitbucketDownloader struct {
+	base.NullDownloader
+	ctx           context.Context
+	client        *http.Client
+	apiBaseURL    string
+	repoOwner     string
+	repoName      string
+	userName      string
+	password      string
+	token         string
+	hasIssues     bool
+	maxIssueIndex int64
+}
+
+// NewBitbucketDownloader creates a bitbucket.org downloader, apiBaseURL is the location of the 2.0 api
+func NewBitbucketDownloader(ctx context.Context, apiBaseURL, userName, password, token, repoOwner, repoName string) *BitbucketDownloader {
+	return &BitbucketDownloader{
+		ctx:        ctx,
+		client:     NewMigrationHTTPClient(),
+		apiBaseURL: strings.TrimSuffix(apiBaseURL, "/"),
+		repoOwner:  repoOwner,
+		repoName:   repoName,
+		userName:   userName,
+		password:   password,
+		token:      token,
+	}
+}
+
+// SetContext set context
+func (d *BitbucketDownloader) SetContext(ctx context.Context) {
+	d.ctx = ctx
+}
+
+// repoEndpoint returns the api endpoint of a repository
+func repoEndpoint(owner, repoName, endpoint string) string {
+	return fmt.Sprintf("/2.0/repositories/%s/%s%s", url.PathEscape(owner), url.PathEscape(repoName), endpoint)
+}
+
+// callAPI requests an endpoint of the api and decodes the response into result
+func (d *BitbucketDownloader) callAPI(endpoint string, parameter url.Values, result interface{}) error {
+	u := d.apiBaseURL + endpoint
+	if parameter != nil {
+		u += "?" + parameter.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(d.ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	if d.token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.token)
+	} else if d.userName != "" {
+		req.SetBasicAuth(d.userName, d.password)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status of %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func pageParameter(page, perPage int) url.Values {
+	return url.Values{
+		"page":    []string{strconv.Itoa(page)},
+		"pagelen": []string{strconv.Itoa(perPage)},
+	}
+}
+
+// fullCommitHash expands the abbreviated hashes used by the pull request api,
+// an empty string is returned if the commit does not exist anymore
+func (d *BitbucketDownloader) fullCommitHash(owner, repoName, hash string) string {
+	if hash == "" {
+		return ""
+	}
+	var commit struct {
+		Hash string `json:"hash"`
+	}
+	if err := d.callAPI(repoEndpoint(owner, repoName, "/commit/"+url.PathEscape(hash)), nil, &commit); err != nil {
+		log.Warn("Unable to resolve commit %s of %s/%s: %v", hash, owner, repoName, err)
+		return ""
+	}
+	return commit.Hash
+}
+
+// GetRepoInfo returns repository information
+func (d *BitbucketDownloader) GetRepoInfo() (*base.Repository, error) {
+	var repo struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		IsPrivate   bool   `json:"is_private"`
+		HasIssues   bool   `json:"has_issues"`
+		MainBranch  *struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+			Clone []struct {
+				Name string `json:"name"`
+				Href string `json:"href"`
+			} `json:"clone"`
+		} `json:"links"`
+	}
+	if err := d.callAPI(repoEndpoint(d.repoOwner, d.repoName, ""), nil, &repo); err != nil {
+		return nil, err
+	}
+	d.hasIssues = repo.HasIssues
+
+	var cloneURL string
+	for _, link := range repo.Links.Clone {
+		if link.Name != "https" {
+			continue
+		}
+		// the link contains the name of the authenticated user
+		u, err := url.Parse(link.Href)
+		if err != nil {
+			return nil, err
+		}
+		u.User = nil
+		cloneURL = u.String()
+	}
+
+	var defaultBranch string
+	if repo.MainBranch != nil {
+		defaultBranch = repo.MainBranch.Name
+	}
+
+	return &base.Repository{
+		Name:          repo.Name,
+		Owner:         d.repoOwner,
+		IsPrivate:     repo.IsPrivate,
+		Description:   repo.Description,
+		CloneURL:      cloneURL,
+		OriginalURL:   repo.Links.HTML.Href,
+		DefaultBranch: defaultBranch,
+	}, nil
+}
+
+// FormatCloneURL add authentification into remote URLs, access tokens are sent with the user x-token-auth
+func (d *BitbucketDownloader) FormatCloneURL(opts base.MigrateOptions, remoteAddr string) (string, error) {
+	if len(opts.AuthToken) == 0 {
+		return d.NullDownloader.FormatCloneURL(opts, remoteAddr)
+	}
+	u, err := url.Parse(remoteAddr)
+	if err != nil {
+		return "", err
+	}
+	u.User = url.UserPassword("x-token-auth", opts.AuthToken)
+	return u.String(), nil
+}
+
+// GetTopics return repository topics, bitbucket.org has none
+func (d *BitbucketDownloader) GetTopics() ([]string, error) {
+	return []string{}, nil
+}
+
+// GetReleases returns no releases, the downloads of bitbucket.org are not related to tags
+func (d *BitbucketDownloader) GetReleases() ([]*base.Release, error) {
+	return []*base.Release{}, nil
+}
+
+// GetMilestones returns the milestones of the issue tracker
+func (d *BitbucketDownloader) GetMilestones() ([]*base.Milestone, error) {
+	var milestones = make([]*base.Milestone, 0, 10)
+	if !d.hasIssues {
+		return milestones, nil
+	}
+
+	for page := 1; ; page++ {
+		var result struct {
+			Values []struct {
+				Name string `json:"name"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if err := d.callAPI(repoEndpoint(d.repoOwner, d.repoName, "/milestones"), pageParameter(page, 100), &result); err != nil {
+			return nil, err
+		}
+		for _, milestone := range result.Values {
+			milestones = append(milestones, &base.Milestone{
+				Title: milestone.Name,
+				State: "open",
+			})
+		}
+		if result.Next == "" {
+			return milestones, nil
+		}
+	}
+}
+
+var (
+	bitbucketKindColors = map[string]string{
+		"bug":         "ee0701",
+		"enhancement": "84b6eb",
+		"proposal":    "cc317c",
+		"task":        "fbca04",
+	}
+	bitbucketPriorityColors = map[string]string{
+		"trivial":  "c5def5",
+		"minor":    "bfdadc",
+		"major":    "fef2c0",
+		"critical": "f9d0c4",
+		"blocker":  "b60205",
+	}
+)
+
+// GetLabels returns the issue kinds, priorities and components as labels
+func (d *BitbucketDownloader) GetLabels() ([]*base.Label, error) {
+	var labels = make([]*base.Label, 0, 20)
+	if !d.hasIssues {
+		return labels, nil
+	}
+
+	for _, kind := range []string{"bug", "enhancement", "proposal", "task"} {
+		labels = append(labels, &base.Label{Name: kind, Color: bitbucketKindColors[kind]})
+	}
+	for _, priority := range []string{"trivial", "minor", "major", "critical", "blocker"} {
+		labels = append(labels, &base.Label{Name: priority, Color: bitbucketPriorityColors[priority]})
+	}
+
+	for page := 1; ; page++ {
+		var result struct {
+			Values []struct {
+				Name string `json:"name"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if err := d.callAPI(repoEndpoint(d.repoOwner, d.repoName, "/components"), pageParameter(page, 100), &result); err != nil {
+			return nil, err
+		}
+		for _, component := range result.Values {
+			if _, ok := bitbucketKindColors[component.Name]; ok {
+				continue
+			}
+			if _, ok := bitbucketPriorityColors[component.Name]; ok {
+				continue
+			}
+			labels = append(labels, &base.Label{Name: component.Name, Color: "0075ca"})
+		}
+		if result.Next == "" {
+			return labels, nil
+		}
+	}
+}
+
+// GetIssues returns issues according start and limit
+func (d *BitbucketDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	if !d.hasIssues {
+		return []*base.Issue{}, true, nil
+	}
+
+	var result struct {
+		Values []struct {
+			ID        int64            `json:"id"`
+			Title     string           `json:"title"`
+			Content   bitbucketContent `json:"content"`
+			Reporter  *bitbucketUser   `json:"reporter"`
+			Assignee  *bitbucketUser   `json:"assignee"`
+			Kind      string           `json:"kind"`
+			Priority  string           `json:"priority"`
+			State     string           `json:"state"`
+			Component *struct {
+				Name string `json:"name"`
+			} `json:"component"`
+			Milestone *struct {
+				Name string `json:"name"`
+			} `json:"milestone"`
+			Created time.Time `json:"created_on"`
+			Updated time.Time `json:"updated_on"`
+		} `json:"values"`
+		Next string `json:"next"`
+	}
+	parameter := pageParameter(page, perPage)
+	parameter.Set("sort", "id")
+	if err := d.callAPI(repoEndpoint(d.repoOwner, d.repoName, "/issues"), parameter, &result); err != nil {
+		return nil, false, err
+	}
+
+	issues := make([]*base.Issue, 0, len(result.Values))
+	for _, issue := range result.Values {
+		labels := make([]*base.Label, 0, 3)
+		if issue.Kind != "" {
+			labels = append(labels, &base.Label{Name: issue.Kind, Color: bitbucketKindColors[issue.Kind]})
+		}
+		if issue.Priority != "" {
+			labels = append(labels, &base.Label{Name: issue.Priority, Color: bitbucketPriorityColors[issue.Priority]})
+		}
+		if issue.Component != nil {
+			labels = append(labels, &base.Label{Name: issue.Component.Name, Color: "0075ca"})
+		}
+
+		var milestone string
+		if issue.Milestone != nil {
+			milestone = issue.Milestone.Name
+		}
+
+		var assignees []string
+		if issue.Assignee != nil {
+			assignees = []string{issue.Assignee.name()}
+		}
+
+		// new, open and on hold issues are open, resolved, invalid, duplicate, wontfix and closed ones are closed
+		state := "open"
+		var closed *time.Time
+		switch issue.State {
+		case "resolved", "invalid", "duplicate", "wontfix", "closed":
+			state = "closed"
+			closedTime := issue.Updated
+			closed = &closedTime
+		}
+
+		issues = append(issues, &base.Issue{
+			Number:     issue.ID,
+			PosterName: issue.Reporter.name(),
+			Title:      issue.Title,
+			Content:    issue.Content.Raw,
+			Milestone:  milestone,
+			State:      state,
+			Created:    issue.Created,
+			Updated:    issue.Updated,
+			Closed:     closed,
+			Labels:     labels,
+			Assignees:  assignees,
+			Context: bitbucketIssueContext{
+				foreignID: issue.ID,
+				localID:   issue.ID,
+			},
+		})
+
+		if d.maxIssueIndex < issue.ID {
+			d.maxIssueIndex = issue.ID
+		}
+	}
+
+	return issues, result.Next == "", nil
+}
+
+// getComments returns all comments of an issue or a pull request
+func (d *BitbucketDownloader) getComments(context bitbucketIssueContext) ([]*bitbucketComment, error) {
+	var endpoint string
+	if context.IsPullRequest {
+		endpoint = fmt.Sprintf("/pullrequests/%d/comments", context.ForeignID())
+	} else {
+		endpoint = fmt.Sprintf("/issues/%d/comments", context.ForeignID())
+	}
+
+	var comments []*bitbucketComment
+	for page := 1; ; page++ {
+		var result struct {
+			Values []*bitbucketComment `json:"values"`
+			Next   string              `json:"next"`
+		}
+		if err := d.callAPI(repoEndpoint(d.repoOwner, d.repoName, endpoint), pageParameter(page, 100), &result); err != nil {
+			return nil, err
+		}
+		comments = append(comments, result.Values...)
+		if result.Next == "" {
+			return comments, nil
+		}
+	}
+}
+
+// GetComments returns the comments of an issue or the general comments of a pull request,
+// inline comments of pull requests are returned by GetReviews
+func (d *BitbucketDownloader) GetComments(opts base.GetCommentOptions) ([]*base.Comment, bool, error) {
+	context, ok := opts.Context.(bitbucketIssueContext)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected comment context: %+v", opts.Context)
+	}
+
+	rawComments, err := d.getComments(context)
+	if err != nil {
+		return nil, false, err
+	}
+
+	comments := make([]*base.Comment, 0, len(rawComments))
+	for _, comment := range rawComments {
+		// changes of the issue state are comments without content
+		if comment.Deleted || comment.Inline != nil || comment.Content.Raw == "" {
+			continue
+		}
+		comments = append(comments, &base.Comment{
+			ID:         comment.ID,
+			IssueIndex: context.LocalID(),
+			PosterName: comment.User.name(),
+			Content:    comment.Content.Raw,
+			Created:    comment.Created,
+			Updated:    comment.Updated,
+		})
+	}
+	return comments, true, nil
+}
+
+type bitbucketBranch struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit *struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+	Repository *struct {
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+func (b *bitbucketBranch) hash() string {
+	if b.Commit == nil {
+		return ""
+	}
+	return b.Commit.Hash
+}
+
+// GetPullRequests returns pull requests according page and perPage
+func (d *BitbucketDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, bool, error) {
+	var result struct {
+		Values []struct {
+			ID          int64            `json:"id"`
+			Title       string           `json:"title"`
+			Summary     bitbucketContent `json:"summary"`
+			State       string           `json:"state"`
+			Author      *bitbucketUser   `json:"author"`
+			Source      bitbucketBranch  `json:"source"`
+			Destination bitbucketBranch  `json:"destination"`
+			MergeCommit *struct {
+				Hash string `json:"hash"`
+			} `json:"merge_commit"`
+			Created time.Time `json:"created_on"`
+			Updated time.Time `json:"updated_on"`
+		} `json:"values"`
+		Next string `json:"next"`
+	}
+	parameter := pageParameter(page, perPage)
+	parameter["state"] = []string{"OPEN", "MERGED", "DECLINED", "SUPERSEDED"}
+	if err := d.callAPI(repoEndpoint(d.repoOwner, d.repoName, "/pullrequests"), parameter, &result); err != nil {
+		return nil, false, err
+	}
+
+	pullRequests := make([]*base.PullRequest, 0, len(result.Values))
+	for _, pr := range result.Values {
+		state := "open"
+		merged := false
+		var closed, mergedTime *time.Time
+		var mergeCommitSHA string
+		if pr.State != "OPEN" {
+			state = "closed"
+			closedTime := pr.Updated
+			closed = &closedTime
+		}
+		if pr.State == "MERGED" {
+			merged = true
+			mergedTime = closed
+			if pr.MergeCommit != nil {
+				mergeCommitSHA = d.fullCommitHash(d.repoOwner, d.repoName, pr.MergeCommit.Hash)
+			}
+		}
+
+		head := base.PullRequestBranch{
+			Ref:       pr.Source.Branch.Name,
+			RepoName:  d.repoName,
+			OwnerName: d.repoOwner,
+		}
+		if pr.Source.Repository != nil {
+			if owner, name, ok := splitRepoFullName(pr.Source.Repository.FullName); ok && (owner != d.repoOwner || name != d.repoName) {
+				head.OwnerName = owner
+				head.RepoName = name
+				head.CloneURL = fmt.Sprintf("https://bitbucket.org/%s/%s.git", owner, name)
+			}
+		}
+		head.SHA = d.fullCommitHash(head.OwnerName, head.RepoName, pr.Source.hash())
+
+		number := pr.ID + d.maxIssueIndex
+		pullRequests = append(pullRequests, &base.PullRequest{
+			Number:         number,
+			Title:          pr.Title,
+			PosterName:     pr.Author.name(),
+			Content:        pr.Summary.Raw,
+			State:          state,
+			Created:        pr.Created,
+			Updated:        pr.Updated,
+			Closed:         closed,
+			Merged:         merged,
+			MergedTime:     mergedTime,
+			MergeCommitSHA: mergeCommitSHA,
+			Head:           head,
+			Base: base.PullRequestBranch{
+				Ref:       pr.Destination.Branch.Name,
+				SHA:       d.fullCommitHash(d.repoOwner, d.repoName, pr.Destination.hash()),
+				RepoName:  d.repoName,
+				OwnerName: d.repoOwner,
+			},
+			Context: bitbucketIssueContext{
+				foreignID:     pr.ID,
+				localID:       number,
+				IsPullRequest: true,
+			},
+		})
+	}
+
+	return pullRequests, result.Next == "", nil
+}
+
+func splitRepoFullName(fullName string) (owner, name string, ok bool) {
+	fields := strings.SplitN(fullName, "/", 2)
+	if len(fields) != 2 {
+		return "", "", false
+	}
+	return fields[0], fields[1], true
+}
+
+// GetReviews returns the approvals and change requests of the participants and their inline comments
+func (d *BitbucketDownloader) GetReviews(context base.IssueContext) ([]*base.Review, error) {
+	var pr struct {
+		Participants []struct {
+			User           *bitbucketUser `json:"user"`
+			Approved       bool           `json:"approved"`
+			State          *string        `json:"state"`
+			ParticipatedOn *time.Time     `json:"participated_on"`
+		} `json:"participants"`
+	}
+	if err := d.callAPI(repoEndpoint(d.repoOwner, d.repoName, fmt.Sprintf("/pullrequests/%d", context.ForeignID())), nil, &pr); err != nil {
+		return nil, err
+	}
+
+	var reviews = make([]*base.Review, 0, len(pr.Participants))
+	for _, participant := range pr.Participants {
+		var state string
+		switch {
+		case participant.Approved:
+			state = base.ReviewStateApproved
+		case participant.State != nil && *participant.State == "changes_requested":
+			state = base.ReviewStateChangesRequested
+		default:
+			continue
+		}
+		review := &base.Review{
+			IssueIndex:   context.LocalID(),
+			ReviewerName: participant.User.name(),
+			State:        state,
+		}
+		if participant.ParticipatedOn != nil {
+			review.CreatedAt = *participant.ParticipatedOn
+		}
+		reviews = append(reviews, review)
+	}
+
+	rawComments, err := d.getComments(bitbucketIssueContext{
+		foreignID:     context.ForeignID(),
+		localID:       context.LocalID(),
+		IsPullRequest: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// inline comments are collected in one review per author
+	commentReviews := make(map[string]*base.Review)
+	for _, comment := range rawComments {
+		if comment.Deleted || comment.Inline == nil {
+			continue
+		}
+
+		var line int
+		if comment.Inline.To != nil {
+			line = *comment.Inline.To
+		} else if comment.Inline.From != nil {
+			line = -*comment.Inline.From
+		}
+		var inReplyTo int64
+		if comment.Parent != nil {
+			inReplyTo = comment.Parent.ID
+		}
+
+		name := comment.User.name()
+		review, ok := commentReviews[name]
+		if !ok {
+			review = &base.Review{
+				IssueIndex:   context.LocalID(),
+				ReviewerName: name,
+				CreatedAt:    comment.Created,
+				State:        base.ReviewStateCommented,
+			}
+			commentReviews[name] = review
+			reviews = append(reviews, review)
+		}
+		review.Comments = append(review.Comments, &base.ReviewComment{
+			ID:        comment.ID,
+			InReplyTo: inReplyTo,
+			Content:   comment.Content.Raw,
+			TreePath:  comment.Inline.Path,
+			Line:      line,
+			CreatedAt: comment.Created,
+			UpdatedAt: comment.Updated,
+		})
+	}
+
+	return reviews, nil
+}
@@ -0,0 +1,517 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	base "code.gitea.io/gitea/modules/migration"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+var (
+	_ base.Downloader        = &BitbucketServerDownloader{}
+	_ base.DownloaderFactory = &BitbucketServerDownloaderFactory{}
+)
+
+func init() {
+	RegisterDownloaderFactory(&BitbucketServerDownloaderFactory{})
+}
+
+// BitbucketServerDownloaderFactory defines a Bitbucket Server downloader factory
+type BitbucketServerDownloaderFactory struct {
+}
+
+// New returns a Downloader related to this factory according MigrateOptions
+func (f *BitbucketServerDownloaderFactory) New(ctx context.Context, opts base.MigrateOptions) (base.Downloader, error) {
+	u, err := url.Parse(opts.CloneAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, projectKey, repoSlug, err := parseBitbucketServerURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Trace("Create bitbucket server downloader. BaseURL: %s Project: %s Repo: %s", baseURL, projectKey, repoSlug)
+
+	return NewBitbucketServerDownloader(ctx, baseURL, opts.AuthUsername, opts.AuthPassword, opts.AuthToken, projectKey, repoSlug), nil
+}
+
+// GitServiceType returns the type of git service
+func (f *BitbucketServerDownloaderFactory) GitServiceType() structs.GitServiceType {
+	return structs.BitbucketServerService
+}
+
+// parseBitbucketServerURL accepts the urls of the web interface like
+// https://host/projects/KEY/repos/slug/browse and https://host/users/name/repos/slug
+// and the clone urls like https://host/scm/key/slug.git. Bitbucket Server may be served below a context path.
+func parseBitbucketServerURL(u *url.URL) (baseURL, projectKey, repoSlug string, err error) {
+	fields := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, field := range fields {
+		switch {
+		case (field == "projects" || field == "users") && i+3 < len(fields) && fields[i+2] == "repos":
+			projectKey = fields[i+1]
+			if field == "users" {
+				projectKey = "~" + projectKey
+			}
+			repoSlug = fields[i+3]
+		case field == "scm" && i+2 < len(fields):
+			projectKey = fields[i+1]
+			repoSlug = strings.TrimSuffix(fields[i+2], ".git")
+		default:
+			continue
+		}
+		baseURL = u.Scheme + "://" + u.Host
+		if i > 0 {
+			baseURL += "/" + strings.Join(fields[:i], "/")
+		}
+		return baseURL, projectKey, repoSlug, nil
+	}
+	return "", "", "", fmt.Errorf("invalid path: %s", u.Path)
+}
+
+type bitbucketServerUser struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+type bitbucketServerComment struct {
+	ID          int64                     `json:"id"`
+	Text        string                    `json:"text"`
+	Author      bitbucketServerUser       `json:"author"`
+	CreatedDate int64                     `json:"createdDate"`
+	UpdatedDate int64                     `json:"updatedDate"`
+	Comments    []*bitbucketServerComment `json:"comments"`
+}
+
+type bitbucketServerActivity struct {
+	ID            int64                   `json:"id"`
+	CreatedDate   int64                   `json:"createdDate"`
+	User          bitbucketServerUser     `json:"user"`
+	Action        string                  `json:"action"`
+	CommentAction string                  `json:"commentAction"`
+	Comment       *bitbucketServerComment `json:"comment"`
+	CommentAnchor *struct {
+		Path     string `json:"path"`
+		Line     int    `json:"line"`
+		FileType string `json:"fileType"`
+		ToHash   string `json:"toHash"`
+	} `json:"commentAnchor"`
+}
+
+type bitbucketServerRef struct {
+	ID           string `json:"id"`
+	DisplayID    string `json:"displayId"`
+	LatestCommit string `json:"latestCommit"`
+	Repository   struct {
+		Slug    string `json:"slug"`
+		Project struct {
+			Key string `json:"key"`
+		} `json:"project"`
+		Links struct {
+			Clone []struct {
+				Name string `json:"name"`
+				Href string `json:"href"`
+			} `json:"clone"`
+		} `json:"links"`
+	} `json:"repository"`
+}
+
+// bitbucketServerTime converts the milliseconds since the epoch used by the api
+func bitbucketServerTime(ms int64) time.Time {
+	return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))
+}
+
+// BitbucketServerDownloader implements a Downloader interface to get repository information
+// from Bitbucket Server
+type BitbucketServerDownloader struct {
+	base.NullDownloader
+	ctx        context.Context
+	client     *http.Client
+	baseURL    string
+	projectKey string
+	repoSlug   string
+	userName   string
+	password   string
+	token      string
+}
+
+// NewBitbucketServerDownloader creates a Bitbucket Server downloader
+func NewBitbucketServerDownloader(ctx context.Context, baseURL, userName, password, token, projectKey, repoSlug string) *BitbucketServerDownloader {
+	return &BitbucketServerDownloader{
+		ctx:        ctx,
+		client:     NewMigrationHTTPClient(),
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		projectKey: projectKey,
+		repoSlug:   repoSlug,
+		userName:   userName,
+		password:   password,
+		token:      token,
+	}
+}
+
+// SetContext set context
+func (d *BitbucketServerDownloader) SetContext(ctx context.Context) {
+	d.ctx = ctx
+}
+
+// callAPI requests an endpoint of the repository and decodes the response into result
+func (d *BitbucketServerDownloader) callAPI(endpoint string, parameter url.Values, result interface{}) error {
+	u := fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s%s", d.baseURL, url.PathEscape(d.projectKey), url.PathEscape(d.repoSlug), endpoint)
+	if parameter != nil {
+		u += "?" + parameter.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(d.ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	if d.token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.token)
+	} else if d.userName != "" {
+		req.SetBasicAuth(d.userName, d.password)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status of %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func bitbucketServerPageParameter(start, limit int) url.Values {
+	return url.Values{
+		"start": []string{strconv.Itoa(start)},
+		"limit": []string{strconv.Itoa(limit)},
+	}
+}
+
+// httpCloneURL returns the http clone link without the name of the authenticated user
+func httpCloneURL(links []struct {
+	Name string `json:"name"`
+	Href string `json:"href"`
+}) string {
+	for _, link := range links {
+		if link.Name != "http" && link.Name != "https" {
+			continue
+		}
+		u, err := url.Parse(link.Href)
+		if err != nil {
+			continue
+		}
+		u.User = nil
+		return u.String()
+	}
+	return ""
+}
+
+// GetRepoInfo returns repository information
+func (d *BitbucketServerDownloader) GetRepoInfo() (*base.Repository, error) {
+	var repo struct {
+		Slug        string `json:"slug"`
+		Description string `json:"description"`
+		Public      bool   `json:"public"`
+		Project     struct {
+			Key string `json:"key"`
+		} `json:"project"`
+		Links struct {
+			Clone []struct {
+				Name string `json:"name"`
+				Href string `json:"href"`
+			} `json:"clone"`
+			Self []struct {
+				Href string `json:"href"`
+			} `json:"self"`
+		} `json:"links"`
+	}
+	if err := d.callAPI("", nil, &repo); err != nil {
+		return nil, err
+	}
+
+	var originalURL string
+	if len(repo.Links.Self) > 0 {
+		originalURL = repo.Links.Self[0].Href
+	}
+
+	// empty repositories have no default branch
+	var defaultBranch struct {
+		DisplayID string `json:"displayId"`
+	}
+	if err := d.callAPI("/branches/default", nil, &defaultBranch); err != nil {
+		log.Warn("Unable to get the default branch of %s/%s: %v", d.projectKey, d.repoSlug, err)
+	}
+
+	return &base.Repository{
+		Name:          repo.Slug,
+		Owner:         repo.Project.Key,
+		IsPrivate:     !repo.Public,
+		Description:   repo.Description,
+		CloneURL:      httpCloneURL(repo.Links.Clone),
+		OriginalURL:   originalURL,
+		DefaultBranch: defaultBranch.DisplayID,
+	}, nil
+}
+
+// FormatCloneURL add authentification into remote URLs, personal access tokens are sent as password of the user
+func (d *BitbucketServerDownloader) FormatCloneURL(opts base.MigrateOptions, remoteAddr string) (string, error) {
+	if len(opts.AuthToken) == 0 {
+		return d.NullDownloader.FormatCloneURL(opts, remoteAddr)
+	}
+	u, err := url.Parse(remoteAddr)
+	if err != nil {
+		return "", err
+	}
+	userName := opts.AuthUsername
+	if userName == "" {
+		// project and repository access tokens are not bound to a user
+		userName = "x-token-auth"
+	}
+	u.User = url.UserPassword(userName, opts.AuthToken)
+	return u.String(), nil
+}
+
+// GetTopics return repository topics, Bitbucket Server has none
+func (d *BitbucketServerDownloader) GetTopics() ([]string, error) {
+	return []string{}, nil
+}
+
+// GetReleases returns no releases, Bitbucket Server only has the tags of the repository
+func (d *BitbucketServerDownloader) GetReleases() ([]*base.Release, error) {
+	return []*base.Release{}, nil
+}
+
+// GetPullRequests returns pull requests according page and perPage
+func (d *BitbucketServerDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, bool, error) {
+	var result struct {
+		Values []struct {
+			ID          int64  `json:"id"`
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			State       string `json:"state"`
+			CreatedDate int64  `json:"createdDate"`
+			UpdatedDate int64  `json:"updatedDate"`
+			ClosedDate  int64  `json:"closedDate"`
+			Author      struct {
+				User bitbucketServerUser `json:"user"`
+			} `json:"author"`
+			FromRef    bitbucketServerRef `json:"fromRef"`
+			ToRef      bitbucketServerRef `json:"toRef"`
+			Properties struct {
+				MergeCommit *struct {
+					ID string `json:"id"`
+				} `json:"mergeCommit"`
+			} `json:"properties"`
+		} `json:"values"`
+		IsLastPage bool `json:"isLastPage"`
+	}
+	parameter := bitbucketServerPageParameter((page-1)*perPage, perPage)
+	parameter.Set("state", "ALL")
+	parameter.Set("order", "OLDEST")
+	if err := d.callAPI("/pull-requests", parameter, &result); err != nil {
+		return nil, false, err
+	}
+
+	pullRequests := make([]*base.PullRequest, 0, len(result.Values))
+	for _, pr := range result.Values {
+		created := bitbucketServerTime(pr.CreatedDate)
+		updated := bitbucketServerTime(pr.UpdatedDate)
+
+		state := "open"
+		merged := false
+		var closed, mergedTime *time.Time
+		var mergeCommitSHA string
+		if pr.State != "OPEN" {
+			state = "closed"
+			// closedDate is only provided by Bitbucket Server 7 and later
+			closedTime := updated
+			if pr.ClosedDate > 0 {
+				closedTime = bitbucketServerTime(pr.ClosedDate)
+			}
+			closed = &closedTime
+			if pr.State == "MERGED" {
+				merged = true
+				mergedTime = &closedTime
+			}
+		}
+		if pr.Properties.MergeCommit != nil {
+			mergeCommitSHA = pr.Properties.MergeCommit.ID
+		}
+
+		head := base.PullRequestBranch{
+			Ref:       pr.FromRef.DisplayID,
+			SHA:       pr.FromRef.LatestCommit,
+			RepoName:  d.repoSlug,
+			OwnerName: d.projectKey,
+		}
+		if pr.FromRef.Repository.Slug != d.repoSlug || pr.FromRef.Repository.Project.Key != d.projectKey {
+			head.RepoName = pr.FromRef.Repository.Slug
+			head.OwnerName = pr.FromRef.Repository.Project.Key
+			head.CloneURL = httpCloneURL(pr.FromRef.Repository.Links.Clone)
+		}
+
+		pullRequests = append(pullRequests, &base.PullRequest{
+			Number:         pr.ID,
+			Title:          pr.Title,
+			PosterID:       pr.Author.User.ID,
+			PosterName:     pr.Author.User.Name,
+			PosterEmail:    pr.Author.User.EmailAddress,
+			Content:        pr.Description,
+			State:          state,
+			Created:        created,
+			Updated:        updated,
+			Closed:         closed,
+			Merged:         merged,
+			MergedTime:     mergedTime,
+			MergeCommitSHA: mergeCommitSHA,
+			Head:           head,
+			Base: base.PullRequestBranch{
+				Ref:       pr.ToRef.DisplayID,
+				SHA:       pr.ToRef.LatestCommit,
+				RepoName:  d.repoSlug,
+				OwnerName: d.projectKey,
+			},
+			Context: base.BasicIssueContext(pr.ID),
+		})
+	}
+
+	return pullRequests, result.IsLastPage, nil
+}
+
+// getActivities returns the activities of a pull request, the oldest first
+func (d *BitbucketServerDownloader) getActivities(id int64) ([]*bitbucketServerActivity, error) {
+	var activities []*bitbucketServerActivity
+	for start := 0; ; {
+		var result struct {
+			Values        []*bitbucketServerActivity `json:"values"`
+			IsLastPage    bool                       `json:"isLastPage"`
+			NextPageStart int                        `json:"nextPageStart"`
+		}
+		if err := d.callAPI(fmt.Sprintf("/pull-requests/%d/activities", id), bitbucketServerPageParameter(start, 100), &result); err != nil {
+			return nil, err
+		}
+		activities = append(activities, result.Values...)
+		if result.IsLastPage {
+			break
+		}
+		start = result.NextPageStart
+	}
+
+	sort.SliceStable(activities, func(i, j int) bool {
+		return activities[i].CreatedDate < activities[j].CreatedDate
+	})
+	return activities, nil
+}
+
+// flattenComments returns a comment and all of its replies
+func flattenComments(comment *bitbucketServerComment, parentID int64, fn func(comment *bitbucketServerComment, parentID int64)) {
+	fn(comment, parentID)
+	for _, reply := range comment.Comments {
+		flattenComments(reply, comment.ID, fn)
+	}
+}
+
+// GetComments returns the general comments of a pull request with their replies,
+// comments on the diff are returned by GetReviews
+func (d *BitbucketServerDownloader) GetComments(opts base.GetCommentOptions) ([]*base.Comment, bool, error) {
+	activities, err := d.getActivities(opts.Context.ForeignID())
+	if err != nil {
+		return nil, false, err
+	}
+
+	var comments []*base.Comment
+	for _, activity := range activities {
+		if activity.Action != "COMMENTED" || activity.CommentAction != "ADDED" || activity.Comment == nil || activity.CommentAnchor != nil {
+			continue
+		}
+		flattenComments(activity.Comment, 0, func(comment *bitbucketServerComment, _ int64) {
+			comments = append(comments, &base.Comment{
+				ID:          comment.ID,
+				IssueIndex:  opts.Context.LocalID(),
+				PosterID:    comment.Author.ID,
+				PosterName:  comment.Author.Name,
+				PosterEmail: comment.Author.EmailAddress,
+				Content:     comment.Text,
+				Created:     bitbucketServerTime(comment.CreatedDate),
+				Updated:     bitbucketServerTime(comment.UpdatedDate),
+			})
+		})
+	}
+	return comments, true, nil
+}
+
+// GetReviews returns the approvals and the "needs work" reviews of a pull request,
+// every comment on the diff becomes a review with the comment and its replies
+func (d *BitbucketServerDownloader) GetReviews(context base.IssueContext) ([]*base.Review, error) {
+	activities, err := d.getActivities(context.ForeignID())
+	if err != nil {
+		return nil, err
+	}
+
+	var reviews []*base.Review
+	for _, activity := range activities {
+		switch {
+		case activity.Action == "APPROVED" || activity.Action == "REVIEWED":
+			state := base.ReviewStateApproved
+			if activity.Action == "REVIEWED" {
+				state = base.ReviewStateChangesRequested
+			}
+			reviews = append(reviews, &base.Review{
+				ID:           activity.ID,
+				IssueIndex:   context.LocalID(),
+				ReviewerID:   activity.User.ID,
+				ReviewerName: activity.User.Name,
+				CreatedAt:    bitbucketServerTime(activity.CreatedDate),
+				State:        state,
+			})
+		case activity.Action == "COMMENTED" && activity.CommentAction == "ADDED" && activity.Comment != nil && activity.CommentAnchor != nil:
+			anchor := activity.CommentAnchor
+			line := anchor.Line
+			if anchor.FileType == "FROM" {
+				line = -line
+			}
+			review := &base.Review{
+				ID:           activity.ID,
+				IssueIndex:   context.LocalID(),
+				ReviewerID:   activity.User.ID,
+				ReviewerName: activity.User.Name,
+				CommitID:     anchor.ToHash,
+				CreatedAt:    bitbucketServerTime(activity.CreatedDate),
+				State:        base.ReviewStateCommented,
+			}
+			flattenComments(activity.Comment, 0, func(comment *bitbucketServerComment, parentID int64) {
+				review.Comments = append(review.Comments, &base.ReviewComment{
+					ID:        comment.ID,
+					InReplyTo: parentID,
+					Content:   comment.Text,
+					TreePath:  anchor.Path,
+					Line:      line,
+					CommitID:  anchor.ToHash,
+					PosterID:  comment.Author.ID,
+					CreatedAt: bitbucketServerTime(comment.CreatedDate),
+					UpdatedAt: bitbucketServerTime(comment.UpdatedDate),
+				})
+			})
+			reviews = append(reviews, review)
+		}
+	}
+	return reviews, nil
+}
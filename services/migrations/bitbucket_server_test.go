@@ -0,0 +1,199 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	base "code.gitea.io/gitea/modules/migration"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBitbucketServerURL(t *testing.T) {
+	kases := []struct {
+		url        string
+		baseURL    string
+		projectKey string
+		repoSlug   string
+	}{
+		{"https://bitbucket.example.com/projects/GT/repos/test_repo/browse", "https://bitbucket.example.com", "GT", "test_repo"},
+		{"https://bitbucket.example.com/scm/gt/test_repo.git", "https://bitbucket.example.com", "gt", "test_repo"},
+		{"https://bitbucket.example.com/users/alice/repos/test_repo", "https://bitbucket.example.com", "~alice", "test_repo"},
+		{"https://example.com/bitbucket/projects/GT/repos/test_repo/pull-requests", "https://example.com/bitbucket", "GT", "test_repo"},
+		{"https://example.com/bitbucket/scm/~alice/test_repo.git", "https://example.com/bitbucket", "~alice", "test_repo"},
+	}
+	for _, kase := range kases {
+		u, err := url.Parse(kase.url)
+		assert.NoError(t, err)
+		baseURL, projectKey, repoSlug, err := parseBitbucketServerURL(u)
+		assert.NoError(t, err, kase.url)
+		assert.Equal(t, kase.baseURL, baseURL, kase.url)
+		assert.Equal(t, kase.projectKey, projectKey, kase.url)
+		assert.Equal(t, kase.repoSlug, repoSlug, kase.url)
+	}
+
+	u, _ := url.Parse("https://bitbucket.example.com/dashboard")
+	_, _, _, err := parseBitbucketServerURL(u)
+	assert.Error(t, err)
+}
+
+func TestBitbucketServerDownloadRepo(t *testing.T) {
+	server := newMockWebServer(t, "https://bitbucket.example.com", "testdata/bitbucketserver", os.Getenv("GITEA_MIGRATION_RECORD") != "")
+
+	downloader := NewBitbucketServerDownloader(context.Background(), server.URL, "", "", os.Getenv("BITBUCKET_SERVER_READ_TOKEN"), "GT", "test_repo")
+
+	repo, err := downloader.GetRepoInfo()
+	assert.NoError(t, err)
+	assertRepositoryEqual(t, &base.Repository{
+		Name:          "test_repo",
+		Owner:         "GT",
+		IsPrivate:     true,
+		Description:   "Repository for testing migration from Bitbucket Server to gitea",
+		CloneURL:      "https://bitbucket.example.com/scm/gt/test_repo.git",
+		OriginalURL:   server.URL + "/projects/GT/repos/test_repo/browse",
+		DefaultBranch: "master",
+	}, repo)
+
+	releases, err := downloader.GetReleases()
+	assert.NoError(t, err)
+	assert.Empty(t, releases)
+
+	prs, isEnd, err := downloader.GetPullRequests(1, 2)
+	assert.NoError(t, err)
+	assert.True(t, isEnd)
+	assertPullRequestsEqual(t, []*base.PullRequest{
+		{
+			Number:         1,
+			Title:          "Fix crash on empty config",
+			Content:        "Fixes the crash if `app.ini` is empty.",
+			PosterID:       101,
+			PosterName:     "alice",
+			PosterEmail:    "alice@example.com",
+			State:          "closed",
+			Created:        time.Date(2021, 11, 4, 10, 0, 0, 0, time.UTC),
+			Updated:        time.Date(2021, 11, 5, 12, 1, 0, 0, time.UTC),
+			Closed:         timePtr(time.Date(2021, 11, 5, 12, 0, 0, 0, time.UTC)),
+			Merged:         true,
+			MergedTime:     timePtr(time.Date(2021, 11, 5, 12, 0, 0, 0, time.UTC)),
+			MergeCommitSHA: "0123456789abcdef0123456789abcdef01234567",
+			Head: base.PullRequestBranch{
+				Ref:       "fix-empty-config",
+				SHA:       "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+				RepoName:  "test_repo",
+				OwnerName: "GT",
+			},
+			Base: base.PullRequestBranch{
+				Ref:       "master",
+				SHA:       "aabbccddeeff00112233445566778899aabbccdd",
+				RepoName:  "test_repo",
+				OwnerName: "GT",
+			},
+		},
+		{
+			Number:      2,
+			Title:       "Add dark theme",
+			PosterID:    102,
+			PosterName:  "bob",
+			PosterEmail: "bob@example.com",
+			State:       "open",
+			Created:     time.Date(2021, 11, 6, 14, 0, 0, 0, time.UTC),
+			Updated:     time.Date(2021, 11, 6, 14, 30, 0, 0, time.UTC),
+			Head: base.PullRequestBranch{
+				Ref:       "dark-theme",
+				SHA:       "fedcba9876543210fedcba9876543210fedcba98",
+				CloneURL:  server.URL + "/scm/~bob/test_repo.git",
+				RepoName:  "test_repo",
+				OwnerName: "~BOB",
+			},
+			Base: base.PullRequestBranch{
+				Ref:       "master",
+				SHA:       "aabbccddeeff00112233445566778899aabbccdd",
+				RepoName:  "test_repo",
+				OwnerName: "GT",
+			},
+		},
+	}, prs)
+
+	comments, _, err := downloader.GetComments(base.GetCommentOptions{Context: prs[0].Context})
+	assert.NoError(t, err)
+	assertCommentsEqual(t, []*base.Comment{
+		{
+			IssueIndex:  1,
+			PosterID:    102,
+			PosterName:  "bob",
+			PosterEmail: "bob@example.com",
+			Content:     "Thanks, I will have a look.",
+			Created:     time.Date(2021, 11, 4, 10, 15, 0, 0, time.UTC),
+			Updated:     time.Date(2021, 11, 4, 10, 20, 0, 0, time.UTC),
+		},
+		{
+			IssueIndex:  1,
+			PosterID:    101,
+			PosterName:  "alice",
+			PosterEmail: "alice@example.com",
+			Content:     "Great!",
+			Created:     time.Date(2021, 11, 4, 10, 25, 0, 0, time.UTC),
+			Updated:     time.Date(2021, 11, 4, 10, 25, 0, 0, time.UTC),
+		},
+	}, comments)
+
+	reviews, err := downloader.GetReviews(prs[0].Context)
+	assert.NoError(t, err)
+	assertReviewsEqual(t, []*base.Review{
+		{
+			ID:           103,
+			IssueIndex:   1,
+			ReviewerID:   103,
+			ReviewerName: "carol",
+			CreatedAt:    time.Date(2021, 11, 4, 10, 30, 0, 0, time.UTC),
+			State:        base.ReviewStateChangesRequested,
+		},
+		{
+			ID:           104,
+			IssueIndex:   1,
+			ReviewerID:   102,
+			ReviewerName: "bob",
+			CommitID:     "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+			CreatedAt:    time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+			State:        base.ReviewStateCommented,
+			Comments: []*base.ReviewComment{
+				{
+					ID:        12,
+					Content:   "Please check for `nil` here.",
+					TreePath:  "modules/setting/setting.go",
+					Line:      12,
+					CommitID:  "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+					PosterID:  102,
+					CreatedAt: time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+					UpdatedAt: time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+				},
+				{
+					ID:        13,
+					InReplyTo: 12,
+					Content:   "Done.",
+					TreePath:  "modules/setting/setting.go",
+					Line:      12,
+					CommitID:  "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+					PosterID:  101,
+					CreatedAt: time.Date(2021, 11, 4, 11, 30, 0, 0, time.UTC),
+					UpdatedAt: time.Date(2021, 11, 4, 11, 31, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			ID:           105,
+			IssueIndex:   1,
+			ReviewerID:   102,
+			ReviewerName: "bob",
+			CreatedAt:    time.Date(2021, 11, 5, 11, 0, 0, 0, time.UTC),
+			State:        base.ReviewStateApproved,
+		},
+	}, reviews)
+}
@@ -0,0 +1,239 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	base "code.gitea.io/gitea/modules/migration"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitbucketDownloaderFactory(t *testing.T) {
+	factory := &BitbucketDownloaderFactory{}
+
+	downloader, err := factory.New(context.Background(), base.MigrateOptions{CloneAddr: "https://bitbucket.org/gitea-test/test_repo.git"})
+	assert.NoError(t, err)
+	bitbucket := downloader.(*BitbucketDownloader)
+	assert.Equal(t, "https://api.bitbucket.org", bitbucket.apiBaseURL)
+	assert.Equal(t, "gitea-test", bitbucket.repoOwner)
+	assert.Equal(t, "test_repo", bitbucket.repoName)
+
+	downloader, err = factory.New(context.Background(), base.MigrateOptions{CloneAddr: "https://bitbucket.org/gitea-test/test_repo/src/master/"})
+	assert.NoError(t, err)
+	assert.Equal(t, "test_repo", downloader.(*BitbucketDownloader).repoName)
+
+	_, err = factory.New(context.Background(), base.MigrateOptions{CloneAddr: "https://bitbucket.example.com/scm/gt/test_repo.git"})
+	assert.Error(t, err)
+}
+
+func TestBitbucketDownloadRepo(t *testing.T) {
+	server := newMockWebServer(t, "https://api.bitbucket.org", "testdata/bitbucket", os.Getenv("GITEA_MIGRATION_RECORD") != "")
+
+	downloader := NewBitbucketDownloader(context.Background(), server.URL, "", "", os.Getenv("BITBUCKET_READ_TOKEN"), "gitea-test", "test_repo")
+
+	repo, err := downloader.GetRepoInfo()
+	assert.NoError(t, err)
+	assertRepositoryEqual(t, &base.Repository{
+		Name:          "test_repo",
+		Owner:         "gitea-test",
+		Description:   "Repository for testing migration from bitbucket.org to gitea",
+		CloneURL:      "https://bitbucket.org/gitea-test/test_repo.git",
+		OriginalURL:   "https://bitbucket.org/gitea-test/test_repo",
+		DefaultBranch: "master",
+	}, repo)
+
+	milestones, err := downloader.GetMilestones()
+	assert.NoError(t, err)
+	assertMilestonesEqual(t, []*base.Milestone{
+		{
+			Title: "1.0.0",
+			State: "open",
+		},
+		{
+			Title: "1.1.0",
+			State: "open",
+		},
+	}, milestones)
+
+	labels, err := downloader.GetLabels()
+	assert.NoError(t, err)
+	// the component "bug" is merged with the kind
+	assert.Len(t, labels, 10)
+	assertLabelEqual(t, &base.Label{Name: "api", Color: "0075ca"}, labels[9])
+
+	issues, isEnd, err := downloader.GetIssues(1, 2)
+	assert.NoError(t, err)
+	assert.False(t, isEnd)
+	assertIssuesEqual(t, []*base.Issue{
+		{
+			Number:     1,
+			Title:      "Crash on empty config",
+			Content:    "The server crashes if `app.ini` is empty.",
+			PosterName: "alice",
+			Milestone:  "1.0.0",
+			State:      "closed",
+			Created:    time.Date(2021, 11, 2, 10, 0, 0, 0, time.UTC),
+			Updated:    time.Date(2021, 11, 5, 12, 30, 0, 0, time.UTC),
+			Closed:     timePtr(time.Date(2021, 11, 5, 12, 30, 0, 0, time.UTC)),
+			Labels: []*base.Label{
+				{Name: "bug", Color: "ee0701"},
+				{Name: "major", Color: "fef2c0"},
+				{Name: "api", Color: "0075ca"},
+			},
+			Assignees: []string{"bob"},
+		},
+		{
+			Number:     2,
+			Title:      "Support dark mode",
+			Content:    "It would be nice to have a dark theme.",
+			PosterName: "bob",
+			State:      "open",
+			Created:    time.Date(2021, 11, 3, 8, 15, 0, 0, time.UTC),
+			Updated:    time.Date(2021, 11, 3, 8, 15, 0, 0, time.UTC),
+			Labels: []*base.Label{
+				{Name: "enhancement", Color: "84b6eb"},
+				{Name: "minor", Color: "bfdadc"},
+			},
+		},
+	}, issues)
+
+	comments, _, err := downloader.GetComments(base.GetCommentOptions{Context: issues[0].Context})
+	assert.NoError(t, err)
+	assertCommentsEqual(t, []*base.Comment{
+		{
+			IssueIndex: 1,
+			PosterName: "bob",
+			Content:    "I can reproduce this.",
+			Created:    time.Date(2021, 11, 3, 9, 0, 0, 0, time.UTC),
+			Updated:    time.Date(2021, 11, 3, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			IssueIndex: 1,
+			PosterName: "alice",
+			Content:    "Fixed in master.",
+			Created:    time.Date(2021, 11, 5, 12, 31, 0, 0, time.UTC),
+			Updated:    time.Date(2021, 11, 5, 12, 35, 0, 0, time.UTC),
+		},
+	}, comments)
+
+	prs, isEnd, err := downloader.GetPullRequests(1, 2)
+	assert.NoError(t, err)
+	assert.True(t, isEnd)
+	assertPullRequestsEqual(t, []*base.PullRequest{
+		{
+			Number:         3,
+			Title:          "Fix crash on empty config",
+			Content:        "Fixes #1",
+			PosterName:     "alice",
+			State:          "closed",
+			Created:        time.Date(2021, 11, 4, 10, 0, 0, 0, time.UTC),
+			Updated:        time.Date(2021, 11, 5, 12, 0, 0, 0, time.UTC),
+			Closed:         timePtr(time.Date(2021, 11, 5, 12, 0, 0, 0, time.UTC)),
+			Merged:         true,
+			MergedTime:     timePtr(time.Date(2021, 11, 5, 12, 0, 0, 0, time.UTC)),
+			MergeCommitSHA: "0123456789abcdef0123456789abcdef01234567",
+			Head: base.PullRequestBranch{
+				Ref:       "fix-empty-config",
+				SHA:       "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+				RepoName:  "test_repo",
+				OwnerName: "gitea-test",
+			},
+			Base: base.PullRequestBranch{
+				Ref:       "master",
+				SHA:       "aabbccddeeff00112233445566778899aabbccdd",
+				RepoName:  "test_repo",
+				OwnerName: "gitea-test",
+			},
+		},
+		{
+			Number:     4,
+			Title:      "Add dark theme",
+			PosterName: "bob",
+			State:      "open",
+			Created:    time.Date(2021, 11, 6, 14, 0, 0, 0, time.UTC),
+			Updated:    time.Date(2021, 11, 6, 14, 0, 0, 0, time.UTC),
+			Head: base.PullRequestBranch{
+				Ref:       "dark-theme",
+				SHA:       "fedcba9876543210fedcba9876543210fedcba98",
+				CloneURL:  "https://bitbucket.org/bob/test_repo.git",
+				RepoName:  "test_repo",
+				OwnerName: "bob",
+			},
+			Base: base.PullRequestBranch{
+				Ref:       "master",
+				SHA:       "aabbccddeeff00112233445566778899aabbccdd",
+				RepoName:  "test_repo",
+				OwnerName: "gitea-test",
+			},
+		},
+	}, prs)
+
+	comments, _, err = downloader.GetComments(base.GetCommentOptions{Context: prs[0].Context})
+	assert.NoError(t, err)
+	assertCommentsEqual(t, []*base.Comment{
+		{
+			IssueIndex: 3,
+			PosterName: "bob",
+			Content:    "Thanks, looks good to me.",
+			Created:    time.Date(2021, 11, 4, 11, 0, 0, 0, time.UTC),
+			Updated:    time.Date(2021, 11, 4, 11, 0, 0, 0, time.UTC),
+		},
+	}, comments)
+
+	reviews, err := downloader.GetReviews(prs[0].Context)
+	assert.NoError(t, err)
+	assertReviewsEqual(t, []*base.Review{
+		{
+			IssueIndex:   3,
+			ReviewerName: "bob",
+			CreatedAt:    time.Date(2021, 11, 4, 11, 45, 0, 0, time.UTC),
+			State:        base.ReviewStateApproved,
+		},
+		{
+			IssueIndex:   3,
+			ReviewerName: "carol",
+			CreatedAt:    time.Date(2021, 11, 4, 10, 30, 0, 0, time.UTC),
+			State:        base.ReviewStateChangesRequested,
+		},
+		{
+			IssueIndex:   3,
+			ReviewerName: "bob",
+			CreatedAt:    time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+			State:        base.ReviewStateCommented,
+			Comments: []*base.ReviewComment{
+				{
+					ID:        265305962,
+					Content:   "Please check for `nil` here.",
+					TreePath:  "modules/setting/setting.go",
+					Line:      12,
+					CreatedAt: time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+					UpdatedAt: time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			IssueIndex:   3,
+			ReviewerName: "alice",
+			CreatedAt:    time.Date(2021, 11, 4, 11, 30, 0, 0, time.UTC),
+			State:        base.ReviewStateCommented,
+			Comments: []*base.ReviewComment{
+				{
+					ID:        265305963,
+					InReplyTo: 265305962,
+					Content:   "Done.",
+					TreePath:  "modules/setting/setting.go",
+					Line:      12,
+					CreatedAt: time.Date(2021, 11, 4, 11, 30, 0, 0, time.UTC),
+					UpdatedAt: time.Date(2021, 11, 4, 11, 31, 0, 0, time.UTC),
+				},
+			},
+		},
+	}, reviews)
+}
@@ -22,7 +22,9 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	base "code.gitea.io/gitea/modules/migration"
 	"code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
 
 	"gopkg.in/yaml.v2"
 )
@@ -416,6 +418,9 @@ func (g *RepositoryDumper) CreatePullRequests(prs ...*base.PullRequest) error {
 	for _, pr := range prs {
 		// download patch file
 		err := func() error {
+			if pr.PatchURL == "" {
+				return nil
+			}
 			u, err := g.setURLToken(pr.PatchURL)
 			if err != nil {
 				return err
@@ -611,27 +616,96 @@ func RestoreRepository(ctx context.Context, baseDir string, ownerName, repoName
 	if err != nil {
 		return err
 	}
+
+	var migrateOpts base.MigrateOptions
+	updateOptionsUnits(&migrateOpts, units)
+
+	_, err = restoreRepository(ctx, doer, baseDir, ownerName, repoName, migrateOpts, nil)
+	return err
+}
+
+// restoreRepository restores a repository from the disk directory. Whether it contains LFS
+// objects is read from the directory, as is the service type of the dumped repository if the
+// doer is an administrator.
+func restoreRepository(ctx context.Context, doer *models.User, baseDir string, ownerName, repoName string, migrateOpts base.MigrateOptions, messenger base.Messenger) (*models.Repository, error) {
 	var uploader = NewGiteaLocalUploader(ctx, doer, ownerName, repoName)
 	downloader, err := NewRepositoryRestorer(ctx, baseDir, ownerName, repoName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	opts, err := downloader.getRepoOptions()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// the service type drives the remapping of the posters to the local users linked to their
+	// external accounts, so only the administrators are trusted with the dumped one
+	migrateOpts.GitServiceType = structs.PlainGitService
+	if doer.IsAdmin {
+		tp, _ := strconv.Atoi(opts["service_type"])
+		migrateOpts.GitServiceType = structs.GitServiceType(tp)
 	}
-	tp, _ := strconv.Atoi(opts["service_type"])
 
-	var migrateOpts = base.MigrateOptions{
-		GitServiceType: structs.GitServiceType(tp),
+	// LFS objects are dumped in the layout of a local repository next to the git data
+	if isDir, _ := util.IsDir(filepath.Join(downloader.baseDir, "git", "lfs", "objects")); isDir {
+		migrateOpts.LFS = true
 	}
-	updateOptionsUnits(&migrateOpts, units)
 
-	if err = migrateRepository(downloader, uploader, migrateOpts, nil); err != nil {
+	if err = migrateRepository(downloader, uploader, migrateOpts, messenger); err != nil {
 		if err1 := uploader.Rollback(); err1 != nil {
 			log.Error("rollback failed: %v", err1)
 		}
+		return nil, err
+	}
+
+	if err = removeAlternates(ctx, uploader.repo.RepoPath()); err != nil {
+		return nil, err
+	}
+	if migrateOpts.Wiki {
+		if err = restoreWiki(ctx, downloader.baseDir, uploader.repo); err != nil {
+			log.Warn("Restore wiki: %v", err)
+		}
+	}
+	return uploader.repo, updateMigrationPosterIDByGitService(ctx, migrateOpts.GitServiceType)
+}
+
+// restoreWiki restores the dumped wiki, which isn't found by the uploader
+// as it isn't stored next to the git data like the wiki of a remote repository
+func restoreWiki(ctx context.Context, baseDir string, repo *models.Repository) error {
+	wikiPath := filepath.Join(baseDir, "wiki")
+	if isDir, err := util.IsDir(wikiPath); err != nil || !isDir {
+		return err
+	}
+
+	if err := util.RemoveAll(repo.WikiPath()); err != nil {
+		return fmt.Errorf("Failed to remove %s: %v", repo.WikiPath(), err)
+	}
+	if err := git.CloneWithContext(ctx, localCloneURL(wikiPath), repo.WikiPath(), git.CloneRepoOptions{
+		Mirror:  true,
+		Quiet:   true,
+		Timeout: time.Duration(setting.Git.Timeout.Migrate) * time.Second,
+	}); err != nil {
 		return err
 	}
-	return updateMigrationPosterIDByGitService(ctx, structs.GitServiceType(tp))
+	return removeAlternates(ctx, repo.WikiPath())
+}
+
+// localCloneURL returns the URL of a dumped repository, which is cloned through the file
+// transport like a remote repository, so its objects are copied instead of being hardlinked
+// and the alternates it may list are not used
+func localCloneURL(repoPath string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(repoPath)}).String()
+}
+
+// removeAlternates repacks the objects of a restored repository into its own object store
+// and removes the alternates it may still list
+func removeAlternates(ctx context.Context, repoPath string) error {
+	if _, err := git.NewCommandContext(ctx, "repack", "-a", "-d").RunInDir(repoPath); err != nil {
+		return fmt.Errorf("repack %s: %v", repoPath, err)
+	}
+	for _, name := range []string{"alternates", "http-alternates"} {
+		if err := util.Remove(filepath.Join(repoPath, "objects", "info", name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
 }
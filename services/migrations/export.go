@@ -0,0 +1,310 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/log"
+	base "code.gitea.io/gitea/modules/migration"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+// ExportRepository writes a zip archive of a repository of this instance to w. The archive
+// contains the repository in the format of DumpRepository including its wiki, release
+// attachments and LFS objects, so it can be imported with ImportRepository or restored with
+// the restore-repo command after it has been extracted.
+func ExportRepository(ctx context.Context, repo *models.Repository, w io.Writer, messenger base.Messenger) error {
+	if messenger == nil {
+		messenger = base.NilMessenger
+	}
+
+	tmpDir, err := models.CreateTemporaryPath("repo-export")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := models.RemoveTemporaryPath(tmpDir); err != nil {
+			log.Error("Unable to remove temporary directory: %s: Error: %v", tmpDir, err)
+		}
+	}()
+
+	var opts = base.MigrateOptions{
+		CloneAddr:      repo.HTMLURL(),
+		RepoName:       repo.Name,
+		Description:    repo.Description,
+		Private:        repo.IsPrivate,
+		GitServiceType: structs.GiteaService,
+	}
+	updateOptionsUnits(&opts, nil)
+
+	downloader := NewGiteaLocalDownloader(ctx, repo)
+	uploader, err := NewRepositoryDumper(ctx, tmpDir, repo.OwnerName, repo.Name, opts)
+	if err != nil {
+		return err
+	}
+	if err := migrateRepository(downloader, uploader, opts, messenger); err != nil {
+		return err
+	}
+
+	messenger("repo.export.exporting_lfs")
+	if err := exportLFSObjects(repo, filepath.Join(uploader.gitPath(), "lfs", "objects")); err != nil {
+		return err
+	}
+
+	messenger("repo.export.creating_archive")
+	return writeArchive(uploader.baseDir, w)
+}
+
+// exportLFSObjects copies the LFS objects of the repository into lfsDir, using the
+// layout of a local repository which is read by the LFS filesystem client
+func exportLFSObjects(repo *models.Repository, lfsDir string) error {
+	metas, err := repo.GetLFSMetaObjects(-1, 0)
+	if err != nil {
+		return err
+	}
+
+	contentStore := lfs.NewContentStore()
+	for _, meta := range metas {
+		exist, err := contentStore.Exists(meta.Pointer)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			log.Warn("LFS object %s of repository %s is missing, skipped", meta.Oid, repo.FullName())
+			continue
+		}
+
+		if err := func() error {
+			p := filepath.Join(lfsDir, meta.RelativePath())
+			if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+				return err
+			}
+			rc, err := contentStore.Get(meta.Pointer)
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+
+			f, err := os.Create(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			_, err = io.Copy(f, rc)
+			return err
+		}(); err != nil {
+			return fmt.Errorf("export LFS object %s: %v", meta.Oid, err)
+		}
+	}
+	return nil
+}
+
+// writeArchive writes the content of dir as a zip archive to w
+func writeArchive(dir string, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			// keep empty directories, a git repository is invalid without them
+			header.Name += "/"
+			_, err = zw.CreateHeader(header)
+			return err
+		}
+		header.Method = zip.Deflate
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(fw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ErrImportArchiveTooLarge represents an error if an imported archive or its content is larger
+// than the limits of the settings
+type ErrImportArchiveTooLarge struct {
+	MaxSize  int64
+	MaxFiles int
+}
+
+// IsErrImportArchiveTooLarge checks if an error is an ErrImportArchiveTooLarge
+func IsErrImportArchiveTooLarge(err error) bool {
+	_, ok := err.(ErrImportArchiveTooLarge)
+	return ok
+}
+
+// Error return error message
+func (err ErrImportArchiveTooLarge) Error() string {
+	return fmt.Sprintf("archive is larger than %d bytes or has more than %d files", err.MaxSize, err.MaxFiles)
+}
+
+func newErrImportArchiveTooLarge() ErrImportArchiveTooLarge {
+	return ErrImportArchiveTooLarge{
+		MaxSize:  ImportMaxSize(),
+		MaxFiles: setting.Repository.Import.MaxFiles,
+	}
+}
+
+// ImportMaxSize returns the maximum size in bytes of an imported archive and of its extracted content
+func ImportMaxSize() int64 {
+	return setting.Repository.Import.MaxSize * 1024 * 1024
+}
+
+// extractArchive extracts a zip archive created by ExportRepository into dir. As the
+// archive is uploaded by users, only regular files and directories within dir are allowed,
+// and the extracted content must fit in the import limits of the settings.
+func extractArchive(archive io.ReaderAt, size int64, dir string) error {
+	zr, err := zip.NewReader(archive, size)
+	if err != nil {
+		return err
+	}
+	if len(zr.File) > setting.Repository.Import.MaxFiles {
+		return newErrImportArchiveTooLarge()
+	}
+
+	// the sizes in the headers of the archive can't be trusted, so the extracted bytes are counted
+	remaining := ImportMaxSize()
+	for _, f := range zr.File {
+		p := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if !strings.HasPrefix(p, dir+string(filepath.Separator)) {
+			return fmt.Errorf("file %s is outside of the archive", f.Name)
+		}
+		// alternates would let the repository borrow the objects of any repository on the server
+		if isAlternatesFile(f.Name) {
+			return fmt.Errorf("file %s is not allowed in the archive", f.Name)
+		}
+
+		mode := f.Mode()
+		if mode.IsDir() {
+			if err := os.MkdirAll(p, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+		if !mode.IsRegular() {
+			return fmt.Errorf("file %s is not a regular file", f.Name)
+		}
+
+		if f.UncompressedSize64 > uint64(remaining) {
+			return newErrImportArchiveTooLarge()
+		}
+
+		if err := func() error {
+			if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+				return err
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+
+			fw, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+			if err != nil {
+				return err
+			}
+			defer fw.Close()
+
+			n, err := io.Copy(fw, io.LimitReader(rc, remaining+1))
+			remaining -= n
+			return err
+		}(); err != nil {
+			return fmt.Errorf("extract %s: %v", f.Name, err)
+		}
+		if remaining < 0 {
+			return newErrImportArchiveTooLarge()
+		}
+	}
+	return nil
+}
+
+// isAlternatesFile returns whether the file of an archive lists the alternate object stores of a repository
+func isAlternatesFile(name string) bool {
+	name = strings.ToLower(path.Clean(strings.ReplaceAll(name, "\\", "/")))
+	return strings.HasSuffix(name, "objects/info/alternates") || strings.HasSuffix(name, "objects/info/http-alternates")
+}
+
+// ImportRepository restores a repository from a zip archive created by ExportRepository.
+// The repository is created according to opts, which must contain its name.
+func ImportRepository(ctx context.Context, doer, owner *models.User, archive io.Reader, opts base.MigrateOptions, messenger base.Messenger) (*models.Repository, error) {
+	if messenger == nil {
+		messenger = base.NilMessenger
+	}
+
+	tmpDir, err := models.CreateTemporaryPath("repo-import")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := models.RemoveTemporaryPath(tmpDir); err != nil {
+			log.Error("Unable to remove temporary directory: %s: Error: %v", tmpDir, err)
+		}
+	}()
+
+	// zip archives can't be read as a stream, so the archive is stored next to the extracted files
+	messenger("repo.import.extracting_archive")
+	archivePath := filepath.Join(tmpDir, "archive.zip")
+	size, err := func() (int64, error) {
+		f, err := os.Create(archivePath)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		return io.Copy(f, io.LimitReader(archive, ImportMaxSize()+1))
+	}()
+	if err != nil {
+		return nil, err
+	} else if size > ImportMaxSize() {
+		return nil, newErrImportArchiveTooLarge()
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	repoDir := filepath.Join(tmpDir, "repo")
+	if err := extractArchive(f, size, repoDir); err != nil {
+		return nil, err
+	}
+
+	updateOptionsUnits(&opts, nil)
+	return restoreRepository(ctx, doer, repoDir, owner.Name, opts.RepoName, opts, messenger)
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/unittest"
+	base "code.gitea.io/gitea/modules/migration"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/builder"
+)
+
+func TestExportImportRepository(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	user := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo := unittest.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+	var buf bytes.Buffer
+	assert.NoError(t, ExportRepository(context.Background(), repo, &buf, nil))
+
+	dir := filepath.Join(t.TempDir(), "repo")
+	archive := bytes.NewReader(buf.Bytes())
+	assert.NoError(t, extractArchive(archive, archive.Size(), dir))
+
+	// pull requests are not restored as the pull request checks can't be queued in unit tests
+	imported, err := restoreRepository(context.Background(), user, dir, user.Name, "repo1-imported", base.MigrateOptions{
+		Wiki:       true,
+		Issues:     true,
+		Milestones: true,
+		Labels:     true,
+		Releases:   true,
+		Comments:   true,
+	}, nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, models.RepositoryReady, imported.Status)
+	assert.EqualValues(t, repo.DefaultBranch, imported.DefaultBranch)
+
+	assert.EqualValues(t,
+		unittest.GetCountByCond(t, "issue", builder.Eq{"repo_id": repo.ID, "is_pull": false}),
+		unittest.GetCountByCond(t, "issue", builder.Eq{"repo_id": imported.ID}))
+	for _, table := range []string{"label", "milestone"} {
+		assert.EqualValues(t,
+			unittest.GetCountByCond(t, table, builder.Eq{"repo_id": repo.ID}),
+			unittest.GetCountByCond(t, table, builder.Eq{"repo_id": imported.ID}),
+			table)
+	}
+
+	issue := unittest.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID, Index: 1}).(*models.Issue)
+	importedIssue := unittest.AssertExistsAndLoadBean(t, &models.Issue{RepoID: imported.ID, Index: 1}).(*models.Issue)
+	assert.EqualValues(t, issue.Title, importedIssue.Title)
+	assert.EqualValues(t, issue.Content, importedIssue.Content)
+	assert.EqualValues(t, issue.IsClosed, importedIssue.IsClosed)
+
+	// the objects of other repositories on the server can't be borrowed through alternates
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	var withAlternates bytes.Buffer
+	zw := zip.NewWriter(&withAlternates)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		assert.NoError(t, err)
+		fw, err := zw.CreateHeader(&f.FileHeader)
+		assert.NoError(t, err)
+		_, err = io.Copy(fw, rc)
+		assert.NoError(t, err)
+		rc.Close()
+	}
+	fw, err := zw.Create("git/objects/info/alternates")
+	assert.NoError(t, err)
+	_, err = fw.Write([]byte(filepath.Join(repo.RepoPath(), "objects") + "\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+	_, err = ImportRepository(context.Background(), user, user, &withAlternates, base.MigrateOptions{RepoName: "repo1-alternates"}, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "objects/info/alternates")
+	}
+	unittest.AssertNotExistsBean(t, &models.Repository{OwnerID: user.ID, LowerName: "repo1-alternates"})
+
+	// only the administrators can restore the service type of the archive
+	assert.EqualValues(t, structs.PlainGitService, imported.OriginalServiceType)
+	admin := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+	imported, err = restoreRepository(context.Background(), admin, dir, user.Name, "repo1-imported-by-admin", base.MigrateOptions{}, nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, structs.GiteaService, imported.OriginalServiceType)
+}
+
+func TestExtractArchive(t *testing.T) {
+	createArchive := func(names ...string) *bytes.Reader {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, name := range names {
+			fw, err := zw.Create(name)
+			assert.NoError(t, err)
+			_, err = fw.Write(bytes.Repeat([]byte{'a'}, 1024*1024))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, zw.Close())
+		return bytes.NewReader(buf.Bytes())
+	}
+
+	dir := t.TempDir()
+
+	archive := createArchive("git/HEAD")
+	assert.NoError(t, extractArchive(archive, archive.Size(), dir))
+	_, err := os.Stat(filepath.Join(dir, "git", "HEAD"))
+	assert.NoError(t, err)
+
+	archive = createArchive("git/objects/info/alternates")
+	assert.Error(t, extractArchive(archive, archive.Size(), t.TempDir()))
+	archive = createArchive("wiki/objects/info/http-alternates")
+	assert.Error(t, extractArchive(archive, archive.Size(), t.TempDir()))
+
+	archive = createArchive("../outside")
+	assert.Error(t, extractArchive(archive, archive.Size(), dir))
+	_, err = os.Stat(filepath.Join(dir, "..", "outside"))
+	assert.True(t, os.IsNotExist(err))
+
+	defer func(maxSize int64, maxFiles int) {
+		setting.Repository.Import.MaxSize = maxSize
+		setting.Repository.Import.MaxFiles = maxFiles
+	}(setting.Repository.Import.MaxSize, setting.Repository.Import.MaxFiles)
+	setting.Repository.Import.MaxSize = 2
+	setting.Repository.Import.MaxFiles = 2
+
+	archive = createArchive("git/a", "git/b", "git/c")
+	err = extractArchive(archive, archive.Size(), t.TempDir())
+	assert.True(t, IsErrImportArchiveTooLarge(err))
+
+	setting.Repository.Import.MaxFiles = 3
+	err = extractArchive(archive, archive.Size(), t.TempDir())
+	assert.True(t, IsErrImportArchiveTooLarge(err))
+
+	setting.Repository.Import.MaxSize = 3
+	assert.NoError(t, extractArchive(archive, archive.Size(), t.TempDir()))
+}
@@ -0,0 +1,472 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	base "code.gitea.io/gitea/modules/migration"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+var (
+	_ base.Downloader        = &GerritDownloader{}
+	_ base.DownloaderFactory = &GerritDownloaderFactory{}
+)
+
+func init() {
+	RegisterDownloaderFactory(&GerritDownloaderFactory{})
+}
+
+// GerritDownloaderFactory defines a Gerrit downloader factory
+type GerritDownloaderFactory struct {
+}
+
+// New returns a Downloader related to this factory according MigrateOptions
+func (f *GerritDownloaderFactory) New(ctx context.Context, opts base.MigrateOptions) (base.Downloader, error) {
+	u, err := url.Parse(opts.CloneAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	// accept the clone urls https://host/project and https://host/a/project, the project page https://host/admin/repos/project
+	// and the change pages https://host/c/project/+/1
+	project := strings.Trim(u.Path, "/")
+	project = strings.TrimPrefix(project, "a/")
+	project = strings.TrimPrefix(project, "admin/repos/")
+	project = strings.TrimPrefix(project, "c/")
+	if i := strings.IndexAny(project, ",+"); i >= 0 {
+		project = strings.TrimSuffix(project[:i], "/")
+	}
+	project = strings.TrimSuffix(project, ".git")
+	if project == "" {
+		return nil, fmt.Errorf("invalid path: %s", u.Path)
+	}
+
+	baseURL := u.Scheme + "://" + u.Host
+
+	log.Trace("Create gerrit downloader. BaseURL: %s Project: %s", baseURL, project)
+
+	return NewGerritDownloader(ctx, baseURL, opts.AuthUsername, opts.AuthPassword, project), nil
+}
+
+// GitServiceType returns the type of git service
+func (f *GerritDownloaderFactory) GitServiceType() structs.GitServiceType {
+	return structs.GerritService
+}
+
+// gerritTime is a timestamp of the api like "2021-11-30 10:20:30.000000000" in UTC
+type gerritTime struct {
+	time.Time
+}
+
+func (t *gerritTime) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseInLocation("2006-01-02 15:04:05.999999999", s, time.UTC)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+type gerritAccount struct {
+	AccountID int64  `json:"_account_id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+}
+
+func (a *gerritAccount) id() int64 {
+	if a == nil {
+		return 0
+	}
+	return a.AccountID
+}
+
+func (a *gerritAccount) name() string {
+	if a == nil {
+		return ""
+	}
+	if a.Username != "" {
+		return a.Username
+	}
+	return a.Name
+}
+
+func (a *gerritAccount) email() string {
+	if a == nil {
+		return ""
+	}
+	return a.Email
+}
+
+// GerritDownloader implements a Downloader interface to get repository information
+// from Gerrit. Changes are migrated as pull requests, Gerrit has no issues.
+type GerritDownloader struct {
+	base.NullDownloader
+	ctx      context.Context
+	client   *http.Client
+	baseURL  string
+	project  string
+	userName string
+	password string
+}
+
+// NewGerritDownloader creates a Gerrit downloader, the password is the http password of the user
+func NewGerritDownloader(ctx context.Context, baseURL, userName, password, project string) *GerritDownloader {
+	return &GerritDownloader{
+		ctx:      ctx,
+		client:   NewMigrationHTTPClient(),
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		project:  project,
+		userName: userName,
+		password: password,
+	}
+}
+
+// SetContext set context
+func (d *GerritDownloader) SetContext(ctx context.Context) {
+	d.ctx = ctx
+}
+
+// gerritMagicPrefix is prepended to all JSON responses to prevent XSSI
+var gerritMagicPrefix = []byte(")]}'")
+
+func (d *GerritDownloader) callAPI(endpoint string, parameter url.Values, result interface{}) error {
+	u := d.baseURL
+	if d.userName != "" {
+		// authenticated requests are served below /a/
+		u += "/a"
+	}
+	u += endpoint
+	if parameter != nil {
+		u += "?" + parameter.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(d.ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	if d.userName != "" {
+		req.SetBasicAuth(d.userName, d.password)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status of %s: %s", u, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes.TrimPrefix(body, gerritMagicPrefix), result)
+}
+
+// GetRepoInfo returns repository information
+func (d *GerritDownloader) GetRepoInfo() (*base.Repository, error) {
+	escaped := url.PathEscape(d.project)
+
+	var project struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := d.callAPI("/projects/"+escaped, nil, &project); err != nil {
+		return nil, err
+	}
+
+	var head string
+	if err := d.callAPI("/projects/"+escaped+"/HEAD", nil, &head); err != nil {
+		return nil, err
+	}
+
+	name := project.Name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return &base.Repository{
+		Name:          name,
+		Description:   project.Description,
+		CloneURL:      d.baseURL + "/" + project.Name,
+		OriginalURL:   d.baseURL + "/admin/repos/" + project.Name,
+		DefaultBranch: strings.TrimPrefix(head, "refs/heads/"),
+	}, nil
+}
+
+// GetTopics return repository topics, Gerrit has none
+func (d *GerritDownloader) GetTopics() ([]string, error) {
+	return []string{}, nil
+}
+
+// GetReleases returns no releases, Gerrit only has the tags of the repository
+func (d *GerritDownloader) GetReleases() ([]*base.Release, error) {
+	return []*base.Release{}, nil
+}
+
+// GetIssues returns no issues, Gerrit has no issue tracker
+func (d *GerritDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	return []*base.Issue{}, true, nil
+}
+
+// GetPullRequests returns the changes of the project as pull requests, the change number is the number of the pull request
+func (d *GerritDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, bool, error) {
+	var changes []struct {
+		Number          int64          `json:"_number"`
+		Branch          string         `json:"branch"`
+		Subject         string         `json:"subject"`
+		Status          string         `json:"status"`
+		Created         gerritTime     `json:"created"`
+		Updated         gerritTime     `json:"updated"`
+		Submitted       *gerritTime    `json:"submitted"`
+		Owner           *gerritAccount `json:"owner"`
+		CurrentRevision string         `json:"current_revision"`
+		Revisions       map[string]struct {
+			Ref    string `json:"ref"`
+			Commit struct {
+				Parents []struct {
+					Commit string `json:"commit"`
+				} `json:"parents"`
+				Message string `json:"message"`
+			} `json:"commit"`
+		} `json:"revisions"`
+		MoreChanges bool `json:"_more_changes"`
+	}
+	parameter := url.Values{
+		"q": []string{"project:" + d.project},
+		"o": []string{"CURRENT_REVISION", "CURRENT_COMMIT", "DETAILED_ACCOUNTS"},
+		"n": []string{strconv.Itoa(perPage)},
+		"S": []string{strconv.Itoa((page - 1) * perPage)},
+	}
+	if err := d.callAPI("/changes/", parameter, &changes); err != nil {
+		return nil, false, err
+	}
+
+	pullRequests := make([]*base.PullRequest, 0, len(changes))
+	isEnd := true
+	for _, change := range changes {
+		isEnd = !change.MoreChanges
+
+		revision := change.Revisions[change.CurrentRevision]
+
+		// the subject is the title, the rest of the commit message the description
+		var content string
+		if i := strings.Index(revision.Commit.Message, "\n"); i >= 0 {
+			content = strings.TrimSpace(revision.Commit.Message[i:])
+		}
+
+		var baseSHA string
+		if len(revision.Commit.Parents) > 0 {
+			baseSHA = revision.Commit.Parents[0].Commit
+		}
+
+		state := "open"
+		merged := false
+		var closed, mergedTime *time.Time
+		switch change.Status {
+		case "MERGED":
+			state = "closed"
+			merged = true
+			closedTime := change.Updated.Time
+			if change.Submitted != nil {
+				closedTime = change.Submitted.Time
+			}
+			closed = &closedTime
+			mergedTime = &closedTime
+		case "ABANDONED":
+			state = "closed"
+			closedTime := change.Updated.Time
+			closed = &closedTime
+		}
+
+		pullRequests = append(pullRequests, &base.PullRequest{
+			Number:      change.Number,
+			Title:       change.Subject,
+			PosterID:    change.Owner.id(),
+			PosterName:  change.Owner.name(),
+			PosterEmail: change.Owner.email(),
+			Content:     content,
+			State:       state,
+			Created:     change.Created.Time,
+			Updated:     change.Updated.Time,
+			Closed:      closed,
+			Merged:      merged,
+			MergedTime:  mergedTime,
+			Head: base.PullRequestBranch{
+				Ref:      revision.Ref,
+				SHA:      change.CurrentRevision,
+				RepoName: d.project,
+			},
+			Base: base.PullRequestBranch{
+				Ref:      change.Branch,
+				SHA:      baseSHA,
+				RepoName: d.project,
+			},
+			Context: base.BasicIssueContext(change.Number),
+		})
+	}
+
+	return pullRequests, isEnd, nil
+}
+
+// GetComments returns the review messages of a change, messages generated by Gerrit or bots are skipped
+func (d *GerritDownloader) GetComments(opts base.GetCommentOptions) ([]*base.Comment, bool, error) {
+	var messages []struct {
+		Author  *gerritAccount `json:"author"`
+		Date    gerritTime     `json:"date"`
+		Message string         `json:"message"`
+		Tag     string         `json:"tag"`
+	}
+	if err := d.callAPI(fmt.Sprintf("/changes/%d/messages", opts.Context.ForeignID()), nil, &messages); err != nil {
+		return nil, false, err
+	}
+
+	comments := make([]*base.Comment, 0, len(messages))
+	for _, message := range messages {
+		if message.Author == nil || strings.HasPrefix(message.Tag, "autogenerated:") {
+			continue
+		}
+		comments = append(comments, &base.Comment{
+			IssueIndex:  opts.Context.LocalID(),
+			PosterID:    message.Author.id(),
+			PosterName:  message.Author.name(),
+			PosterEmail: message.Author.email(),
+			Content:     message.Message,
+			Created:     message.Date.Time,
+			Updated:     message.Date.Time,
+		})
+	}
+	return comments, true, nil
+}
+
+// GetReviews returns the Code-Review votes of a change and the inline comments of every reviewer and patch set
+func (d *GerritDownloader) GetReviews(context base.IssueContext) ([]*base.Review, error) {
+	var change struct {
+		Labels map[string]struct {
+			All []struct {
+				gerritAccount
+				Value int         `json:"value"`
+				Date  *gerritTime `json:"date"`
+			} `json:"all"`
+		} `json:"labels"`
+	}
+	parameter := url.Values{"o": []string{"DETAILED_LABELS", "DETAILED_ACCOUNTS"}}
+	if err := d.callAPI(fmt.Sprintf("/changes/%d", context.ForeignID()), parameter, &change); err != nil {
+		return nil, err
+	}
+
+	var reviews []*base.Review
+	for _, vote := range change.Labels["Code-Review"].All {
+		var state string
+		switch {
+		case vote.Value > 0:
+			state = base.ReviewStateApproved
+		case vote.Value < 0:
+			state = base.ReviewStateChangesRequested
+		default:
+			continue
+		}
+		review := &base.Review{
+			IssueIndex:   context.LocalID(),
+			ReviewerID:   vote.AccountID,
+			ReviewerName: vote.gerritAccount.name(),
+			Content:      fmt.Sprintf("Code-Review%+d", vote.Value),
+			State:        state,
+		}
+		if vote.Date != nil {
+			review.CreatedAt = vote.Date.Time
+		}
+		reviews = append(reviews, review)
+	}
+
+	var files map[string][]struct {
+		PatchSet int            `json:"patch_set"`
+		Line     int            `json:"line"`
+		Side     string         `json:"side"`
+		Message  string         `json:"message"`
+		Updated  gerritTime     `json:"updated"`
+		Author   *gerritAccount `json:"author"`
+		CommitID string         `json:"commit_id"`
+	}
+	if err := d.callAPI(fmt.Sprintf("/changes/%d/comments", context.ForeignID()), nil, &files); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	type reviewKey struct {
+		accountID int64
+		patchSet  int
+	}
+	commentReviews := make(map[reviewKey]*base.Review)
+	var inlineReviews []*base.Review
+	for _, path := range paths {
+		// comments on the commit message are not part of the diff
+		if strings.HasPrefix(path, "/") {
+			continue
+		}
+		for _, comment := range files[path] {
+			key := reviewKey{accountID: comment.Author.id(), patchSet: comment.PatchSet}
+			review, ok := commentReviews[key]
+			if !ok {
+				review = &base.Review{
+					IssueIndex:   context.LocalID(),
+					ReviewerID:   comment.Author.id(),
+					ReviewerName: comment.Author.name(),
+					CommitID:     comment.CommitID,
+					CreatedAt:    comment.Updated.Time,
+					State:        base.ReviewStateCommented,
+				}
+				commentReviews[key] = review
+				inlineReviews = append(inlineReviews, review)
+			}
+			if comment.Updated.Before(review.CreatedAt) {
+				review.CreatedAt = comment.Updated.Time
+			}
+
+			line := comment.Line
+			if comment.Side == "PARENT" {
+				line = -line
+			}
+			review.Comments = append(review.Comments, &base.ReviewComment{
+				Content:   comment.Message,
+				TreePath:  path,
+				Line:      line,
+				CommitID:  comment.CommitID,
+				PosterID:  comment.Author.id(),
+				CreatedAt: comment.Updated.Time,
+				UpdatedAt: comment.Updated.Time,
+			})
+		}
+	}
+	sort.SliceStable(inlineReviews, func(i, j int) bool {
+		return inlineReviews[i].CreatedAt.Before(inlineReviews[j].CreatedAt)
+	})
+
+	return append(reviews, inlineReviews...), nil
+}
@@ -0,0 +1,219 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	base "code.gitea.io/gitea/modules/migration"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGerritDownloaderFactory(t *testing.T) {
+	factory := &GerritDownloaderFactory{}
+
+	for _, cloneAddr := range []string{
+		"https://gerrit.example.com/gitea-test/test_repo",
+		"https://gerrit.example.com/gitea-test/test_repo.git",
+		"https://gerrit.example.com/a/gitea-test/test_repo",
+		"https://gerrit.example.com/admin/repos/gitea-test/test_repo",
+		"https://gerrit.example.com/admin/repos/gitea-test/test_repo,branches",
+		"https://gerrit.example.com/c/gitea-test/test_repo/+/1",
+	} {
+		downloader, err := factory.New(context.Background(), base.MigrateOptions{CloneAddr: cloneAddr})
+		assert.NoError(t, err, cloneAddr)
+		gerrit := downloader.(*GerritDownloader)
+		assert.Equal(t, "https://gerrit.example.com", gerrit.baseURL, cloneAddr)
+		assert.Equal(t, "gitea-test/test_repo", gerrit.project, cloneAddr)
+	}
+
+	_, err := factory.New(context.Background(), base.MigrateOptions{CloneAddr: "https://gerrit.example.com/"})
+	assert.Error(t, err)
+}
+
+func TestGerritDownloadRepo(t *testing.T) {
+	server := newMockWebServer(t, "https://gerrit.example.com", "testdata/gerrit", os.Getenv("GITEA_MIGRATION_RECORD") != "")
+
+	downloader := NewGerritDownloader(context.Background(), server.URL, "", "", "gitea-test/test_repo")
+
+	repo, err := downloader.GetRepoInfo()
+	assert.NoError(t, err)
+	assertRepositoryEqual(t, &base.Repository{
+		Name:          "test_repo",
+		Description:   "Repository for testing migration from Gerrit to gitea",
+		CloneURL:      server.URL + "/gitea-test/test_repo",
+		OriginalURL:   server.URL + "/admin/repos/gitea-test/test_repo",
+		DefaultBranch: "master",
+	}, repo)
+
+	issues, isEnd, err := downloader.GetIssues(1, 2)
+	assert.NoError(t, err)
+	assert.True(t, isEnd)
+	assert.Empty(t, issues)
+
+	prs, isEnd, err := downloader.GetPullRequests(1, 2)
+	assert.NoError(t, err)
+	assert.True(t, isEnd)
+	assertPullRequestsEqual(t, []*base.PullRequest{
+		{
+			Number:      1,
+			Title:       "Fix crash on empty config",
+			Content:     "The server crashed if app.ini is empty.\n\nChange-Id: I8473b95934b5732ac55d26311a706c9c2bde9940",
+			PosterID:    1000001,
+			PosterName:  "alice",
+			PosterEmail: "alice@example.com",
+			State:       "closed",
+			Created:     time.Date(2021, 11, 4, 10, 0, 0, 0, time.UTC),
+			Updated:     time.Date(2021, 11, 5, 12, 1, 0, 0, time.UTC),
+			Closed:      timePtr(time.Date(2021, 11, 5, 12, 0, 0, 0, time.UTC)),
+			Merged:      true,
+			MergedTime:  timePtr(time.Date(2021, 11, 5, 12, 0, 0, 0, time.UTC)),
+			Head: base.PullRequestBranch{
+				Ref:      "refs/changes/01/1/2",
+				SHA:      "0123456789abcdef0123456789abcdef01234567",
+				RepoName: "gitea-test/test_repo",
+			},
+			Base: base.PullRequestBranch{
+				Ref:      "master",
+				SHA:      "aabbccddeeff00112233445566778899aabbccdd",
+				RepoName: "gitea-test/test_repo",
+			},
+		},
+		{
+			Number:      2,
+			Title:       "Add dark theme",
+			PosterID:    1000002,
+			PosterName:  "bob",
+			PosterEmail: "bob@example.com",
+			State:       "open",
+			Created:     time.Date(2021, 11, 6, 14, 0, 0, 0, time.UTC),
+			Updated:     time.Date(2021, 11, 6, 14, 30, 0, 0, time.UTC),
+			Head: base.PullRequestBranch{
+				Ref:      "refs/changes/02/2/1",
+				SHA:      "fedcba9876543210fedcba9876543210fedcba98",
+				RepoName: "gitea-test/test_repo",
+			},
+			Base: base.PullRequestBranch{
+				Ref:      "master",
+				SHA:      "aabbccddeeff00112233445566778899aabbccdd",
+				RepoName: "gitea-test/test_repo",
+			},
+		},
+	}, prs)
+
+	comments, _, err := downloader.GetComments(base.GetCommentOptions{Context: prs[0].Context})
+	assert.NoError(t, err)
+	assertCommentsEqual(t, []*base.Comment{
+		{
+			IssueIndex:  1,
+			PosterID:    1000002,
+			PosterName:  "bob",
+			PosterEmail: "bob@example.com",
+			Content:     "Patch Set 1:\n\n(1 comment)\n\nThanks, I will have a look.",
+			Created:     time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+			Updated:     time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+		},
+		{
+			IssueIndex:  1,
+			PosterID:    1000003,
+			PosterName:  "Carol",
+			PosterEmail: "carol@example.com",
+			Content:     "Patch Set 1: Code-Review-1",
+			Created:     time.Date(2021, 11, 4, 11, 10, 0, 0, time.UTC),
+			Updated:     time.Date(2021, 11, 4, 11, 10, 0, 0, time.UTC),
+		},
+		{
+			IssueIndex:  1,
+			PosterID:    1000002,
+			PosterName:  "bob",
+			PosterEmail: "bob@example.com",
+			Content:     "Patch Set 2: Code-Review+2",
+			Created:     time.Date(2021, 11, 5, 11, 0, 0, 0, time.UTC),
+			Updated:     time.Date(2021, 11, 5, 11, 0, 0, 0, time.UTC),
+		},
+	}, comments)
+
+	reviews, err := downloader.GetReviews(prs[0].Context)
+	assert.NoError(t, err)
+	assertReviewsEqual(t, []*base.Review{
+		{
+			IssueIndex:   1,
+			ReviewerID:   1000002,
+			ReviewerName: "bob",
+			Content:      "Code-Review+2",
+			CreatedAt:    time.Date(2021, 11, 5, 11, 0, 0, 0, time.UTC),
+			State:        base.ReviewStateApproved,
+		},
+		{
+			IssueIndex:   1,
+			ReviewerID:   1000003,
+			ReviewerName: "Carol",
+			Content:      "Code-Review-1",
+			CreatedAt:    time.Date(2021, 11, 4, 11, 10, 0, 0, time.UTC),
+			State:        base.ReviewStateChangesRequested,
+		},
+		{
+			IssueIndex:   1,
+			ReviewerID:   1000002,
+			ReviewerName: "bob",
+			CommitID:     "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+			CreatedAt:    time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+			State:        base.ReviewStateCommented,
+			Comments: []*base.ReviewComment{
+				{
+					Content:   "Please check for `nil` here.",
+					TreePath:  "modules/setting/setting.go",
+					Line:      12,
+					CommitID:  "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+					PosterID:  1000002,
+					CreatedAt: time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+					UpdatedAt: time.Date(2021, 11, 4, 11, 5, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			IssueIndex:   1,
+			ReviewerID:   1000001,
+			ReviewerName: "alice",
+			CommitID:     "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+			CreatedAt:    time.Date(2021, 11, 4, 11, 30, 0, 0, time.UTC),
+			State:        base.ReviewStateCommented,
+			Comments: []*base.ReviewComment{
+				{
+					Content:   "Done.",
+					TreePath:  "modules/setting/setting.go",
+					Line:      12,
+					CommitID:  "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+					PosterID:  1000001,
+					CreatedAt: time.Date(2021, 11, 4, 11, 30, 0, 0, time.UTC),
+					UpdatedAt: time.Date(2021, 11, 4, 11, 30, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			IssueIndex:   1,
+			ReviewerID:   1000003,
+			ReviewerName: "Carol",
+			CommitID:     "0123456789abcdef0123456789abcdef01234567",
+			CreatedAt:    time.Date(2021, 11, 4, 11, 50, 0, 0, time.UTC),
+			State:        base.ReviewStateCommented,
+			Comments: []*base.ReviewComment{
+				{
+					Content:   "Why was this removed?",
+					TreePath:  "README.md",
+					Line:      -7,
+					CommitID:  "0123456789abcdef0123456789abcdef01234567",
+					PosterID:  1000003,
+					CreatedAt: time.Date(2021, 11, 4, 11, 50, 0, 0, time.UTC),
+					UpdatedAt: time.Date(2021, 11, 4, 11, 50, 0, 0, time.UTC),
+				},
+			},
+		},
+	}, reviews)
+}
@@ -0,0 +1,537 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	base "code.gitea.io/gitea/modules/migration"
+	"code.gitea.io/gitea/modules/storage"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+)
+
+var (
+	_ base.Downloader = &GiteaLocalDownloader{}
+)
+
+// GiteaLocalDownloader implements a Downloader which reads a repository of this
+// instance directly from the database, it is used to export repositories
+type GiteaLocalDownloader struct {
+	base.NullDownloader
+	ctx  context.Context
+	repo *models.Repository
+}
+
+// NewGiteaLocalDownloader creates a downloader for a repository of this instance
+func NewGiteaLocalDownloader(ctx context.Context, repo *models.Repository) *GiteaLocalDownloader {
+	return &GiteaLocalDownloader{
+		ctx:  ctx,
+		repo: repo,
+	}
+}
+
+// SetContext set context
+func (g *GiteaLocalDownloader) SetContext(ctx context.Context) {
+	g.ctx = ctx
+}
+
+// GetRepoInfo returns repository information
+func (g *GiteaLocalDownloader) GetRepoInfo() (*base.Repository, error) {
+	return &base.Repository{
+		Name:          g.repo.Name,
+		Owner:         g.repo.OwnerName,
+		IsPrivate:     g.repo.IsPrivate,
+		Description:   g.repo.Description,
+		CloneURL:      g.repo.RepoPath(),
+		OriginalURL:   g.repo.HTMLURL(),
+		DefaultBranch: g.repo.DefaultBranch,
+	}, nil
+}
+
+// GetTopics return repository topics
+func (g *GiteaLocalDownloader) GetTopics() ([]string, error) {
+	return g.repo.Topics, nil
+}
+
+// GetMilestones returns milestones
+func (g *GiteaLocalDownloader) GetMilestones() ([]*base.Milestone, error) {
+	milestones, _, err := models.GetMilestones(models.GetMilestonesOption{
+		RepoID: g.repo.ID,
+		State:  api.StateAll,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result = make([]*base.Milestone, 0, len(milestones))
+	for _, m := range milestones {
+		updated := m.UpdatedUnix.AsTime()
+		milestone := &base.Milestone{
+			Title:       m.Name,
+			Description: m.Content,
+			Created:     m.CreatedUnix.AsTime(),
+			Updated:     &updated,
+			State:       string(api.StateOpen),
+		}
+		// milestones without deadline are stored with a deadline in year 9999
+		if m.DeadlineUnix.Year() < 9999 {
+			deadline := m.DeadlineUnix.AsTime()
+			milestone.Deadline = &deadline
+		}
+		if m.IsClosed {
+			closed := m.ClosedDateUnix.AsTime()
+			milestone.State = string(api.StateClosed)
+			milestone.Closed = &closed
+		}
+		result = append(result, milestone)
+	}
+	return result, nil
+}
+
+func convertLocalLabel(label *models.Label) *base.Label {
+	return &base.Label{
+		Name:        label.Name,
+		Color:       strings.TrimPrefix(label.Color, "#"),
+		Description: label.Description,
+	}
+}
+
+// GetLabels returns labels
+func (g *GiteaLocalDownloader) GetLabels() ([]*base.Label, error) {
+	labels, err := models.GetLabelsByRepoID(g.repo.ID, "", db.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var result = make([]*base.Label, 0, len(labels))
+	for _, label := range labels {
+		result = append(result, convertLocalLabel(label))
+	}
+	return result, nil
+}
+
+// GetReleases returns releases
+func (g *GiteaLocalDownloader) GetReleases() ([]*base.Release, error) {
+	releases, err := models.GetReleasesByRepoID(g.repo.ID, models.FindReleasesOptions{
+		IncludeDrafts: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := models.GetReleaseAttachments(releases...); err != nil {
+		return nil, err
+	}
+
+	gitRepo, err := git.OpenRepository(g.repo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+	defer gitRepo.Close()
+
+	var result = make([]*base.Release, 0, len(releases))
+	for _, rel := range releases {
+		// the tags of published releases must be part of the exported git data
+		if !rel.IsDraft && !gitRepo.IsTagExist(rel.TagName) {
+			log.Warn("Tag %s of release in %s is missing, skipped", rel.TagName, g.repo.FullName())
+			continue
+		}
+		if err := rel.LoadAttributes(); err != nil {
+			return nil, err
+		}
+
+		publisherID, publisherName, publisherEmail := localPoster(rel.Publisher, rel.OriginalAuthorID, rel.OriginalAuthor)
+		r := &base.Release{
+			TagName:         rel.TagName,
+			TargetCommitish: rel.Target,
+			Name:            rel.Title,
+			Body:            rel.Note,
+			Draft:           rel.IsDraft,
+			Prerelease:      rel.IsPrerelease,
+			PublisherID:     publisherID,
+			PublisherName:   publisherName,
+			PublisherEmail:  publisherEmail,
+			Created:         rel.CreatedUnix.AsTime(),
+			Published:       rel.CreatedUnix.AsTime(),
+		}
+
+		for _, attach := range rel.Attachments {
+			attach := attach
+			if _, err := storage.Attachments.Stat(attach.RelativePath()); err != nil {
+				if !os.IsNotExist(err) {
+					return nil, err
+				}
+				log.Warn("Attachment %s of release %s in %s is missing, skipped", attach.UUID, rel.TagName, g.repo.FullName())
+				continue
+			}
+			size := int(attach.Size)
+			dlCount := int(attach.DownloadCount)
+			r.Assets = append(r.Assets, &base.ReleaseAsset{
+				ID:            attach.ID,
+				Name:          attach.Name,
+				Size:          &size,
+				DownloadCount: &dlCount,
+				Created:       attach.CreatedUnix.AsTime(),
+				DownloadFunc: func() (io.ReadCloser, error) {
+					return storage.Attachments.Open(attach.RelativePath())
+				},
+			})
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// localPoster returns the id, name and email of the poster of an item. Items which have been
+// migrated from another service and never been claimed keep their original author.
+func localPoster(poster *models.User, originalAuthorID int64, originalAuthor string) (int64, string, string) {
+	if originalAuthor != "" {
+		return originalAuthorID, originalAuthor, ""
+	}
+	if poster == nil {
+		poster = models.NewGhostUser()
+	}
+	return poster.ID, poster.Name, poster.Email
+}
+
+func convertLocalReactions(reactions models.ReactionList) []*base.Reaction {
+	var result = make([]*base.Reaction, 0, len(reactions))
+	for _, reaction := range reactions {
+		userID, userName, _ := localPoster(reaction.User, reaction.OriginalAuthorID, reaction.OriginalAuthor)
+		result = append(result, &base.Reaction{
+			UserID:   userID,
+			UserName: userName,
+			Content:  reaction.Type,
+		})
+	}
+	return result
+}
+
+func (g *GiteaLocalDownloader) getIssueReactions(issue *models.Issue) ([]*base.Reaction, error) {
+	reactions, err := models.FindIssueReactions(issue, db.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := reactions.LoadUsers(g.repo); err != nil {
+		return nil, err
+	}
+	return convertLocalReactions(reactions), nil
+}
+
+func (g *GiteaLocalDownloader) listIssues(page, perPage int, isPull bool) (models.IssueList, bool, error) {
+	issues, err := models.Issues(&models.IssuesOptions{
+		ListOptions: db.ListOptions{
+			Page:     page,
+			PageSize: perPage,
+		},
+		RepoIDs:  []int64{g.repo.ID},
+		IsPull:   util.OptionalBoolOf(isPull),
+		SortType: "oldest",
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	list := models.IssueList(issues)
+	if err := list.LoadAttributes(); err != nil {
+		return nil, false, err
+	}
+	return list, len(issues) < perPage, nil
+}
+
+// issueCommon holds the fields which issues and pull requests have in common
+type issueCommon struct {
+	posterID    int64
+	posterName  string
+	posterEmail string
+	milestone   string
+	state       string
+	closed      *time.Time
+	labels      []*base.Label
+	reactions   []*base.Reaction
+	assignees   []string
+}
+
+func (g *GiteaLocalDownloader) convertIssueCommon(issue *models.Issue) (*issueCommon, error) {
+	reactions, err := g.getIssueReactions(issue)
+	if err != nil {
+		return nil, err
+	}
+
+	common := &issueCommon{
+		state:     string(issue.State()),
+		reactions: reactions,
+		labels:    make([]*base.Label, 0, len(issue.Labels)),
+	}
+	common.posterID, common.posterName, common.posterEmail = localPoster(issue.Poster, issue.OriginalAuthorID, issue.OriginalAuthor)
+	if issue.Milestone != nil {
+		common.milestone = issue.Milestone.Name
+	}
+	if issue.IsClosed && issue.ClosedUnix > 0 {
+		closed := issue.ClosedUnix.AsTime()
+		common.closed = &closed
+	}
+	for _, label := range issue.Labels {
+		common.labels = append(common.labels, convertLocalLabel(label))
+	}
+	for _, assignee := range issue.Assignees {
+		common.assignees = append(common.assignees, assignee.Name)
+	}
+	return common, nil
+}
+
+// GetIssues returns issues according start and limit
+func (g *GiteaLocalDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	issues, isEnd, err := g.listIssues(page, perPage, false)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var result = make([]*base.Issue, 0, len(issues))
+	for _, issue := range issues {
+		common, err := g.convertIssueCommon(issue)
+		if err != nil {
+			return nil, false, err
+		}
+
+		result = append(result, &base.Issue{
+			Number:      issue.Index,
+			PosterID:    common.posterID,
+			PosterName:  common.posterName,
+			PosterEmail: common.posterEmail,
+			Title:       issue.Title,
+			Content:     issue.Content,
+			Ref:         issue.Ref,
+			Milestone:   common.milestone,
+			State:       common.state,
+			IsLocked:    issue.IsLocked,
+			Created:     issue.CreatedUnix.AsTime(),
+			Updated:     issue.UpdatedUnix.AsTime(),
+			Closed:      common.closed,
+			Labels:      common.labels,
+			Reactions:   common.reactions,
+			Assignees:   common.assignees,
+			Context:     base.BasicIssueContext(issue.Index),
+		})
+	}
+	return result, isEnd, nil
+}
+
+// GetComments returns comments according issueNumber
+func (g *GiteaLocalDownloader) GetComments(opts base.GetCommentOptions) ([]*base.Comment, bool, error) {
+	issue, err := models.GetIssueByIndex(g.repo.ID, opts.Context.ForeignID())
+	if err != nil {
+		return nil, false, err
+	}
+
+	comments, err := models.FindComments(&models.FindCommentsOptions{
+		IssueID: issue.ID,
+		Type:    models.CommentTypeComment,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	var result = make([]*base.Comment, 0, len(comments))
+	for _, comment := range comments {
+		if err := comment.LoadPoster(); err != nil {
+			return nil, false, err
+		}
+		if err := comment.LoadReactions(g.repo); err != nil {
+			return nil, false, err
+		}
+
+		posterID, posterName, posterEmail := localPoster(comment.Poster, comment.OriginalAuthorID, comment.OriginalAuthor)
+		result = append(result, &base.Comment{
+			ID:          comment.ID,
+			IssueIndex:  opts.Context.LocalID(),
+			PosterID:    posterID,
+			PosterName:  posterName,
+			PosterEmail: posterEmail,
+			Created:     comment.CreatedUnix.AsTime(),
+			Updated:     comment.UpdatedUnix.AsTime(),
+			Content:     comment.Content,
+			Reactions:   convertLocalReactions(comment.Reactions),
+		})
+	}
+	return result, true, nil
+}
+
+// GetPullRequests returns pull requests according page and perPage
+func (g *GiteaLocalDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, bool, error) {
+	issues, isEnd, err := g.listIssues(page, perPage, true)
+	if err != nil {
+		return nil, false, err
+	}
+
+	gitRepo, err := git.OpenRepository(g.repo.RepoPath())
+	if err != nil {
+		return nil, false, err
+	}
+	defer gitRepo.Close()
+
+	var result = make([]*base.PullRequest, 0, len(issues))
+	for _, issue := range issues {
+		common, err := g.convertIssueCommon(issue)
+		if err != nil {
+			return nil, false, err
+		}
+
+		pr := issue.PullRequest
+		if err := pr.LoadHeadRepo(); err != nil {
+			return nil, false, err
+		}
+
+		headSHA, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+		if err != nil && !git.IsErrNotExist(err) {
+			return nil, false, err
+		}
+
+		head := base.PullRequestBranch{
+			Ref:       pr.HeadBranch,
+			SHA:       headSHA,
+			RepoName:  g.repo.Name,
+			OwnerName: g.repo.OwnerName,
+		}
+		if pr.HeadRepo != nil && pr.HeadRepo.ID != g.repo.ID {
+			head.RepoName = pr.HeadRepo.Name
+			head.OwnerName = pr.HeadRepo.OwnerName
+			head.CloneURL = pr.HeadRepo.RepoPath()
+		}
+
+		var mergedTime *time.Time
+		if pr.HasMerged {
+			merged := pr.MergedUnix.AsTime()
+			mergedTime = &merged
+			if common.closed == nil {
+				common.closed = mergedTime
+			}
+		}
+
+		result = append(result, &base.PullRequest{
+			Number:         issue.Index,
+			Title:          issue.Title,
+			PosterID:       common.posterID,
+			PosterName:     common.posterName,
+			PosterEmail:    common.posterEmail,
+			Content:        issue.Content,
+			Milestone:      common.milestone,
+			State:          common.state,
+			Created:        issue.CreatedUnix.AsTime(),
+			Updated:        issue.UpdatedUnix.AsTime(),
+			Closed:         common.closed,
+			Labels:         common.labels,
+			Merged:         pr.HasMerged,
+			MergedTime:     mergedTime,
+			MergeCommitSHA: pr.MergedCommitID,
+			Head:           head,
+			Base: base.PullRequestBranch{
+				Ref:       pr.BaseBranch,
+				SHA:       pr.MergeBase,
+				RepoName:  g.repo.Name,
+				OwnerName: g.repo.OwnerName,
+			},
+			Assignees: common.assignees,
+			IsLocked:  issue.IsLocked,
+			Reactions: common.reactions,
+			Context:   base.BasicIssueContext(issue.Index),
+		})
+	}
+	return result, isEnd, nil
+}
+
+func convertLocalReviewState(tp models.ReviewType) string {
+	switch tp {
+	case models.ReviewTypePending:
+		return base.ReviewStatePending
+	case models.ReviewTypeApprove:
+		return base.ReviewStateApproved
+	case models.ReviewTypeReject:
+		return base.ReviewStateChangesRequested
+	default:
+		return base.ReviewStateCommented
+	}
+}
+
+// GetReviews returns pull requests review
+func (g *GiteaLocalDownloader) GetReviews(context base.IssueContext) ([]*base.Review, error) {
+	issue, err := models.GetIssueByIndex(g.repo.ID, context.ForeignID())
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, err := models.FindReviews(models.FindReviewOptions{
+		IssueID: issue.ID,
+		Type:    models.ReviewTypeUnknown,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result = make([]*base.Review, 0, len(reviews))
+	for _, review := range reviews {
+		// review requests and pending reviews are no reviews which have been submitted
+		if review.Type == models.ReviewTypeRequest || review.Type == models.ReviewTypePending {
+			continue
+		}
+		if err := review.LoadReviewer(); err != nil && !models.IsErrUserNotExist(err) {
+			return nil, err
+		}
+
+		comments, err := models.FindComments(&models.FindCommentsOptions{
+			IssueID:  issue.ID,
+			ReviewID: review.ID,
+			Type:     models.CommentTypeCode,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var reviewComments = make([]*base.ReviewComment, 0, len(comments))
+		for _, comment := range comments {
+			if err := comment.LoadReactions(g.repo); err != nil {
+				return nil, err
+			}
+			posterID := comment.PosterID
+			if comment.OriginalAuthor != "" {
+				posterID = comment.OriginalAuthorID
+			}
+			reviewComments = append(reviewComments, &base.ReviewComment{
+				ID:        comment.ID,
+				Content:   comment.Content,
+				TreePath:  comment.TreePath,
+				DiffHunk:  comment.Patch,
+				Line:      int(comment.Line),
+				CommitID:  comment.CommitSHA,
+				PosterID:  posterID,
+				Reactions: convertLocalReactions(comment.Reactions),
+				CreatedAt: comment.CreatedUnix.AsTime(),
+				UpdatedAt: comment.UpdatedUnix.AsTime(),
+			})
+		}
+
+		reviewerID, reviewerName, _ := localPoster(review.Reviewer, review.OriginalAuthorID, review.OriginalAuthor)
+		result = append(result, &base.Review{
+			ID:           review.ID,
+			IssueIndex:   context.LocalID(),
+			ReviewerID:   reviewerID,
+			ReviewerName: reviewerName,
+			Official:     review.Official,
+			CommitID:     review.CommitID,
+			Content:      review.Content,
+			CreatedAt:    review.CreatedUnix.AsTime(),
+			State:        convertLocalReviewState(review.Type),
+			Comments:     reviewComments,
+		})
+	}
+	return result, nil
+}
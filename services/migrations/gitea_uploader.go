@@ -11,12 +11,14 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/foreignreference"
 	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
@@ -158,6 +160,19 @@ func (g *GiteaLocalUploader) CreateTopics(topics ...string) error {
 
 // CreateMilestones creates milestones
 func (g *GiteaLocalUploader) CreateMilestones(milestones ...*base.Milestone) error {
+	mss := g.newMilestones(milestones...)
+	err := models.InsertMilestones(mss...)
+	if err != nil {
+		return err
+	}
+
+	for _, ms := range mss {
+		g.milestones.Store(ms.Name, ms.ID)
+	}
+	return nil
+}
+
+func (g *GiteaLocalUploader) newMilestones(milestones ...*base.Milestone) []*models.Milestone {
 	var mss = make([]*models.Milestone, 0, len(milestones))
 	for _, milestone := range milestones {
 		var deadline timeutil.TimeStamp
@@ -195,16 +210,7 @@ func (g *GiteaLocalUploader) CreateMilestones(milestones ...*base.Milestone) err
 		}
 		mss = append(mss, &ms)
 	}
-
-	err := models.InsertMilestones(mss...)
-	if err != nil {
-		return err
-	}
-
-	for _, ms := range mss {
-		g.milestones.Store(ms.Name, ms.ID)
-	}
-	return nil
+	return mss
 }
 
 // CreateLabels creates labels
@@ -347,6 +353,26 @@ func (g *GiteaLocalUploader) SyncTags() error {
 
 // CreateIssues creates issues
 func (g *GiteaLocalUploader) CreateIssues(issues ...*base.Issue) error {
+	iss := g.newIssues(issues...)
+	if len(iss) > 0 {
+		if err := models.InsertIssues(iss...); err != nil {
+			return err
+		}
+
+		refs := make([]*foreignreference.ForeignReference, 0, len(iss))
+		for i, is := range iss {
+			g.issues.Store(is.Index, is)
+			refs = append(refs, foreignreference.New(g.repo.ID, is.Index, foreignIssueIndex(issues[i].Number, issues[i].Context), foreignreference.TypeIssue))
+		}
+		if err := foreignreference.InsertForeignReferences(refs...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *GiteaLocalUploader) newIssues(issues ...*base.Issue) []*models.Issue {
 	var iss = make([]*models.Issue, 0, len(issues))
 	for _, issue := range issues {
 		var labels []*models.Label
@@ -447,18 +473,7 @@ func (g *GiteaLocalUploader) CreateIssues(issues ...*base.Issue) error {
 		}
 		iss = append(iss, &is)
 	}
-
-	if len(iss) > 0 {
-		if err := models.InsertIssues(iss...); err != nil {
-			return err
-		}
-
-		for _, is := range iss {
-			g.issues.Store(is.Index, is)
-		}
-	}
-
-	return nil
+	return iss
 }
 
 // CreateComments creates comments of issues
@@ -547,7 +562,17 @@ func (g *GiteaLocalUploader) CreateComments(comments ...*base.Comment) error {
 	if len(cms) == 0 {
 		return nil
 	}
-	return models.InsertIssueComments(cms)
+	if err := models.InsertIssueComments(cms); err != nil {
+		return err
+	}
+
+	refs := make([]*foreignreference.ForeignReference, 0, len(cms))
+	for i, cm := range cms {
+		if comments[i].ID != 0 {
+			refs = append(refs, foreignreference.New(g.repo.ID, cm.ID, foreignCommentIndex(comments[i].IssueIndex, comments[i].ID), foreignreference.TypeComment))
+		}
+	}
+	return foreignreference.InsertForeignReferences(refs...)
 }
 
 // CreatePullRequests creates pull requests
@@ -585,11 +610,13 @@ func (g *GiteaLocalUploader) CreatePullRequests(prs ...*base.PullRequest) error
 	if err := models.InsertPullRequests(gprs...); err != nil {
 		return err
 	}
-	for _, pr := range gprs {
+	refs := make([]*foreignreference.ForeignReference, 0, len(gprs))
+	for i, pr := range gprs {
 		g.issues.Store(pr.Issue.Index, pr.Issue)
 		pull.AddToTaskQueue(pr)
+		refs = append(refs, foreignreference.New(g.repo.ID, pr.Index, foreignIssueIndex(prs[i].Number, prs[i].Context), foreignreference.TypePullRequest))
 	}
-	return nil
+	return foreignreference.InsertForeignReferences(refs...)
 }
 
 func (g *GiteaLocalUploader) newPullRequest(pr *base.PullRequest) (*models.PullRequest, error) {
@@ -813,6 +840,20 @@ func (g *GiteaLocalUploader) newPullRequest(pr *base.PullRequest) (*models.PullR
 	return &pullRequest, nil
 }
 
+// foreignIssueIndex returns the index of an issue or pull request on the original service
+func foreignIssueIndex(number int64, context base.IssueContext) string {
+	if context != nil {
+		return strconv.FormatInt(context.ForeignID(), 10)
+	}
+	return strconv.FormatInt(number, 10)
+}
+
+// foreignCommentIndex returns the index of a comment or review on the original service.
+// Some services only number them per issue, so it is prefixed with the issue index.
+func foreignCommentIndex(issueIndex, id int64) string {
+	return fmt.Sprintf("%d/%d", issueIndex, id)
+}
+
 func convertReviewState(state string) models.ReviewType {
 	switch state {
 	case base.ReviewStatePending:
@@ -953,7 +994,17 @@ func (g *GiteaLocalUploader) CreateReviews(reviews ...*base.Review) error {
 		cms = append(cms, &cm)
 	}
 
-	return models.InsertReviews(cms)
+	if err := models.InsertReviews(cms); err != nil {
+		return err
+	}
+
+	refs := make([]*foreignreference.ForeignReference, 0, len(cms))
+	for i, cm := range cms {
+		if reviews[i].ID != 0 {
+			refs = append(refs, foreignreference.New(g.repo.ID, cm.ID, foreignCommentIndex(reviews[i].IssueIndex, reviews[i].ID), foreignreference.TypeReview))
+		}
+	}
+	return foreignreference.InsertForeignReferences(refs...)
 }
 
 // Rollback when migrating failed, this will rollback all the changes.
@@ -981,3 +1032,284 @@ func (g *GiteaLocalUploader) Finish() error {
 	g.repo.Status = models.RepositoryReady
 	return models.UpdateRepositoryCols(g.repo, "status")
 }
+
+// loadRepository prepares the uploader to sync the issues, pull requests etc. of an existing repository
+func (g *GiteaLocalUploader) loadRepository(repo *models.Repository) error {
+	var err error
+	g.repo = repo
+	g.gitRepo, err = git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return err
+	}
+
+	labels, err := models.GetLabelsByRepoID(repo.ID, "", db.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, label := range labels {
+		g.labels.Store(label.Name, label)
+	}
+
+	milestones, _, err := models.GetMilestones(models.GetMilestonesOption{
+		RepoID: repo.ID,
+		State:  structs.StateAll,
+	})
+	if err != nil {
+		return err
+	}
+	for _, milestone := range milestones {
+		g.milestones.Store(milestone.Name, milestone.ID)
+	}
+	return nil
+}
+
+// UpdateMilestones updates the milestones of a synced repository and creates the missing ones
+func (g *GiteaLocalUploader) UpdateMilestones(milestones ...*base.Milestone) error {
+	var newMilestones = make([]*base.Milestone, 0, len(milestones))
+	for _, milestone := range milestones {
+		ms, err := models.GetMilestoneByRepoIDANDName(g.repo.ID, milestone.Title)
+		if err != nil {
+			if !models.IsErrMilestoneNotExist(err) {
+				return err
+			}
+			newMilestones = append(newMilestones, milestone)
+			continue
+		}
+
+		synced := g.newMilestones(milestone)[0]
+		oldIsClosed := ms.IsClosed
+		ms.Content = synced.Content
+		ms.IsClosed = synced.IsClosed
+		ms.DeadlineUnix = synced.DeadlineUnix
+		if err := models.UpdateMilestone(ms, oldIsClosed); err != nil {
+			return err
+		}
+		g.milestones.Store(ms.Name, ms.ID)
+	}
+
+	return g.CreateMilestones(newMilestones...)
+}
+
+// UpdateLabels updates the labels of a synced repository and creates the missing ones
+func (g *GiteaLocalUploader) UpdateLabels(labels ...*base.Label) error {
+	var newLabels = make([]*base.Label, 0, len(labels))
+	for _, label := range labels {
+		lb, ok := g.labels.Load(label.Name)
+		if !ok {
+			newLabels = append(newLabels, label)
+			continue
+		}
+
+		l := lb.(*models.Label)
+		l.Description = label.Description
+		l.Color = fmt.Sprintf("#%s", label.Color)
+		if err := models.UpdateLabel(l); err != nil {
+			return err
+		}
+	}
+
+	return g.CreateLabels(newLabels...)
+}
+
+// UpdateReleases updates the releases of a synced repository and creates the missing ones.
+// The assets of existing releases are not downloaded again.
+func (g *GiteaLocalUploader) UpdateReleases(releases ...*base.Release) error {
+	var newReleases = make([]*base.Release, 0, len(releases))
+	for _, release := range releases {
+		rel, err := models.GetRelease(g.repo.ID, release.TagName)
+		if err != nil {
+			if !models.IsErrReleaseNotExist(err) {
+				return err
+			}
+			newReleases = append(newReleases, release)
+			continue
+		}
+
+		rel.Title = release.Name
+		rel.Note = release.Body
+		rel.IsDraft = release.Draft
+		rel.IsPrerelease = release.Prerelease
+		rel.IsTag = false
+		if release.TargetCommitish != "" {
+			rel.Target = release.TargetCommitish
+		}
+		if err := models.UpdateRelease(db.DefaultContext, rel); err != nil {
+			return err
+		}
+	}
+
+	return g.CreateReleases(newReleases...)
+}
+
+// localIssueIndex returns the index of a new issue or pull request of a synced repository.
+// The index on the original service is kept unless it is already used by another issue.
+func (g *GiteaLocalUploader) localIssueIndex(index int64) (int64, error) {
+	_, err := models.GetIssueByIndex(g.repo.ID, index)
+	if models.IsErrIssueNotExist(err) {
+		return index, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	if err := models.RecalculateIssueIndexForRepo(g.repo.ID); err != nil {
+		return 0, err
+	}
+	return db.GetNextResourceIndex("issue_index", g.repo.ID)
+}
+
+// UpdateIssues updates the issues of a synced repository and creates the new ones
+func (g *GiteaLocalUploader) UpdateIssues(issues ...*base.Issue) error {
+	var updatedIssues = make([]*models.Issue, 0, len(issues))
+	for _, issue := range issues {
+		foreignIndex := foreignIssueIndex(issue.Number, issue.Context)
+		is := g.newIssues(issue)[0]
+
+		index, err := foreignreference.GetLocalIndex(g.repo.ID, foreignIndex, foreignreference.TypeIssue)
+		if err == nil {
+			existing, err := models.GetIssueByIndex(g.repo.ID, index)
+			if err != nil {
+				return err
+			}
+			is.ID = existing.ID
+			is.Index = existing.Index
+			updatedIssues = append(updatedIssues, is)
+		} else if foreignreference.IsErrForeignReferenceNotExist(err) {
+			if is.Index, err = g.localIssueIndex(issue.Number); err != nil {
+				return err
+			}
+			if err := models.InsertIssues(is); err != nil {
+				return err
+			}
+			if err := foreignreference.InsertForeignReferences(foreignreference.New(g.repo.ID, is.Index, foreignIndex, foreignreference.TypeIssue)); err != nil {
+				return err
+			}
+		} else {
+			return err
+		}
+
+		// comments refer to the issue by its index on the original service
+		g.issues.Store(issue.Number, is)
+	}
+
+	if len(updatedIssues) == 0 {
+		return nil
+	}
+	return models.UpdateMigratedIssues(updatedIssues...)
+}
+
+// UpdatePullRequests updates the pull requests of a synced repository and creates the new ones
+func (g *GiteaLocalUploader) UpdatePullRequests(prs ...*base.PullRequest) error {
+	for _, pr := range prs {
+		foreignIndex := foreignIssueIndex(pr.Number, pr.Context)
+
+		var existing *models.PullRequest
+		index, err := foreignreference.GetLocalIndex(g.repo.ID, foreignIndex, foreignreference.TypePullRequest)
+		if err == nil {
+			if existing, err = models.GetPullRequestByIndex(g.repo.ID, index); err != nil {
+				return err
+			}
+		} else if foreignreference.IsErrForeignReferenceNotExist(err) {
+			if index, err = g.localIssueIndex(pr.Number); err != nil {
+				return err
+			}
+		} else {
+			return err
+		}
+
+		// the head ref and patch of the pull request have to be stored under the local index
+		localPR := *pr
+		localPR.Number = index
+		gpr, err := g.newPullRequest(&localPR)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			gpr.ID = existing.ID
+			gpr.Issue.ID = existing.IssueID
+			if err := models.UpdateMigratedPullRequests(gpr); err != nil {
+				return err
+			}
+		} else {
+			if err := models.InsertPullRequests(gpr); err != nil {
+				return err
+			}
+			if err := foreignreference.InsertForeignReferences(foreignreference.New(g.repo.ID, gpr.Index, foreignIndex, foreignreference.TypePullRequest)); err != nil {
+				return err
+			}
+		}
+
+		// comments and reviews refer to the pull request by its index on the original service
+		g.issues.Store(pr.Number, gpr.Issue)
+		pull.AddToTaskQueue(gpr)
+	}
+	return nil
+}
+
+// UpdateComments updates the comments of a synced repository changed since the last sync and creates the new ones
+func (g *GiteaLocalUploader) UpdateComments(since time.Time, comments ...*base.Comment) error {
+	var (
+		newComments     = make([]*base.Comment, 0, len(comments))
+		updatedComments = make([]*models.Comment, 0, len(comments))
+	)
+	for _, comment := range comments {
+		if comment.ID == 0 {
+			// without an id comments can only be told apart by their creation time
+			if comment.Created.After(since) {
+				newComments = append(newComments, comment)
+			}
+			continue
+		}
+
+		id, err := foreignreference.GetLocalIndex(g.repo.ID, foreignCommentIndex(comment.IssueIndex, comment.ID), foreignreference.TypeComment)
+		if err != nil {
+			if !foreignreference.IsErrForeignReferenceNotExist(err) {
+				return err
+			}
+			newComments = append(newComments, comment)
+			continue
+		}
+
+		if comment.Updated.After(since) {
+			updatedComments = append(updatedComments, &models.Comment{
+				ID:          id,
+				Content:     comment.Content,
+				UpdatedUnix: timeutil.TimeStamp(comment.Updated.Unix()),
+			})
+		}
+	}
+
+	if len(updatedComments) > 0 {
+		if err := models.UpdateMigratedComments(updatedComments...); err != nil {
+			return err
+		}
+	}
+	return g.CreateComments(newComments...)
+}
+
+// UpdateReviews creates the reviews of a synced repository which have been added since the last sync
+func (g *GiteaLocalUploader) UpdateReviews(since time.Time, reviews ...*base.Review) error {
+	var newReviews = make([]*base.Review, 0, len(reviews))
+	for _, review := range reviews {
+		if review.ID == 0 {
+			// without an id reviews can only be told apart by their creation time
+			if review.CreatedAt.After(since) {
+				newReviews = append(newReviews, review)
+			}
+			continue
+		}
+
+		_, err := foreignreference.GetLocalIndex(g.repo.ID, foreignCommentIndex(review.IssueIndex, review.ID), foreignreference.TypeReview)
+		if err != nil {
+			if !foreignreference.IsErrForeignReferenceNotExist(err) {
+				return err
+			}
+			newReviews = append(newReviews, review)
+		}
+	}
+
+	if len(newReviews) == 0 {
+		return nil
+	}
+	return g.CreateReviews(newReviews...)
+}
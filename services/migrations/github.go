@@ -383,6 +383,15 @@ func (g *GithubDownloaderV3) GetReleases() ([]*base.Release, error) {
 
 // GetIssues returns issues according start and limit
 func (g *GithubDownloaderV3) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	return g.getIssues(page, perPage, time.Time{})
+}
+
+// GetNewIssues returns issues updated after the given time according page and perPage
+func (g *GithubDownloaderV3) GetNewIssues(page, perPage int, updatedAfter time.Time) ([]*base.Issue, bool, error) {
+	return g.getIssues(page, perPage, updatedAfter)
+}
+
+func (g *GithubDownloaderV3) getIssues(page, perPage int, updatedAfter time.Time) ([]*base.Issue, bool, error) {
 	if perPage > g.maxPerPage {
 		perPage = g.maxPerPage
 	}
@@ -390,6 +399,7 @@ func (g *GithubDownloaderV3) GetIssues(page, perPage int) ([]*base.Issue, bool,
 		Sort:      "created",
 		Direction: "asc",
 		State:     "all",
+		Since:     updatedAfter,
 		ListOptions: github.ListOptions{
 			PerPage: perPage,
 			Page:    page,
@@ -531,6 +541,7 @@ func (g *GithubDownloaderV3) getComments(issueContext base.IssueContext) ([]*bas
 			}
 
 			allComments = append(allComments, &base.Comment{
+				ID:          comment.GetID(),
 				IssueIndex:  issueContext.LocalID(),
 				PosterID:    comment.GetUser().GetID(),
 				PosterName:  comment.GetUser().GetLogin(),
@@ -606,6 +617,7 @@ func (g *GithubDownloaderV3) GetAllComments(page, perPage int) ([]*base.Comment,
 		idx := strings.LastIndex(*comment.IssueURL, "/")
 		issueIndex, _ := strconv.ParseInt((*comment.IssueURL)[idx+1:], 10, 64)
 		allComments = append(allComments, &base.Comment{
+			ID:          comment.GetID(),
 			IssueIndex:  issueIndex,
 			PosterID:    comment.GetUser().GetID(),
 			PosterName:  comment.GetUser().GetLogin(),
@@ -622,6 +634,15 @@ func (g *GithubDownloaderV3) GetAllComments(page, perPage int) ([]*base.Comment,
 
 // GetPullRequests returns pull requests according page and perPage
 func (g *GithubDownloaderV3) GetPullRequests(page, perPage int) ([]*base.PullRequest, bool, error) {
+	return g.getPullRequests(page, perPage, time.Time{})
+}
+
+// GetNewPullRequests returns pull requests updated after the given time according page and perPage
+func (g *GithubDownloaderV3) GetNewPullRequests(page, perPage int, updatedAfter time.Time) ([]*base.PullRequest, bool, error) {
+	return g.getPullRequests(page, perPage, updatedAfter)
+}
+
+func (g *GithubDownloaderV3) getPullRequests(page, perPage int, updatedAfter time.Time) ([]*base.PullRequest, bool, error) {
 	if perPage > g.maxPerPage {
 		perPage = g.maxPerPage
 	}
@@ -634,6 +655,11 @@ func (g *GithubDownloaderV3) GetPullRequests(page, perPage int) ([]*base.PullReq
 			Page:    page,
 		},
 	}
+	// the pull request API has no since parameter, so list the most recently updated first
+	if !updatedAfter.IsZero() {
+		opt.Sort = "updated"
+		opt.Direction = "desc"
+	}
 	var allPRs = make([]*base.PullRequest, 0, perPage)
 	g.waitAndPickClient()
 	prs, resp, err := g.getClient().PullRequests.List(g.ctx, g.repoOwner, g.repoName, opt)
@@ -642,7 +668,13 @@ func (g *GithubDownloaderV3) GetPullRequests(page, perPage int) ([]*base.PullReq
 	}
 	log.Trace("Request get pull requests %d/%d, but in fact get %d", perPage, page, len(prs))
 	g.setRate(&resp.Rate)
+	isEnd := len(prs) < perPage
 	for _, pr := range prs {
+		if !updatedAfter.IsZero() && !pr.GetUpdatedAt().After(updatedAfter) {
+			isEnd = true
+			break
+		}
+
 		var labels = make([]*base.Label, 0, len(pr.Labels))
 		for _, l := range pr.Labels {
 			labels = append(labels, convertGithubLabel(l))
@@ -713,7 +745,7 @@ func (g *GithubDownloaderV3) GetPullRequests(page, perPage int) ([]*base.PullReq
 		})
 	}
 
-	return allPRs, len(prs) < perPage, nil
+	return allPRs, isEnd, nil
 }
 
 func convertGithubReview(r *github.PullRequestReview) *base.Review {
@@ -365,6 +365,15 @@ func (c gitlabIssueContext) ForeignID() int64 {
 // GetIssues returns issues according start and limit
 //   Note: issue label description and colors are not supported by the go-gitlab library at this time
 func (g *GitlabDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	return g.getIssues(page, perPage, time.Time{})
+}
+
+// GetNewIssues returns issues updated after the given time according page and perPage
+func (g *GitlabDownloader) GetNewIssues(page, perPage int, updatedAfter time.Time) ([]*base.Issue, bool, error) {
+	return g.getIssues(page, perPage, updatedAfter)
+}
+
+func (g *GitlabDownloader) getIssues(page, perPage int, updatedAfter time.Time) ([]*base.Issue, bool, error) {
 	state := "all"
 	sort := "asc"
 
@@ -380,6 +389,9 @@ func (g *GitlabDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, er
 			Page:    page,
 		},
 	}
+	if !updatedAfter.IsZero() {
+		opt.UpdatedAfter = &updatedAfter
+	}
 
 	var allIssues = make([]*base.Issue, 0, perPage)
 
@@ -484,6 +496,7 @@ func (g *GitlabDownloader) GetComments(opts base.GetCommentOptions) ([]*base.Com
 			if !comment.IndividualNote {
 				for _, note := range comment.Notes {
 					allComments = append(allComments, &base.Comment{
+						ID:          int64(note.ID),
 						IssueIndex:  context.LocalID(),
 						PosterID:    int64(note.Author.ID),
 						PosterName:  note.Author.Username,
@@ -495,6 +508,7 @@ func (g *GitlabDownloader) GetComments(opts base.GetCommentOptions) ([]*base.Com
 			} else {
 				c := comment.Notes[0]
 				allComments = append(allComments, &base.Comment{
+					ID:          int64(c.ID),
 					IssueIndex:  context.LocalID(),
 					PosterID:    int64(c.Author.ID),
 					PosterName:  c.Author.Username,
@@ -515,6 +529,15 @@ func (g *GitlabDownloader) GetComments(opts base.GetCommentOptions) ([]*base.Com
 
 // GetPullRequests returns pull requests according page and perPage
 func (g *GitlabDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, bool, error) {
+	return g.getPullRequests(page, perPage, time.Time{})
+}
+
+// GetNewPullRequests returns pull requests updated after the given time according page and perPage
+func (g *GitlabDownloader) GetNewPullRequests(page, perPage int, updatedAfter time.Time) ([]*base.PullRequest, bool, error) {
+	return g.getPullRequests(page, perPage, updatedAfter)
+}
+
+func (g *GitlabDownloader) getPullRequests(page, perPage int, updatedAfter time.Time) ([]*base.PullRequest, bool, error) {
 	if perPage > g.maxPerPage {
 		perPage = g.maxPerPage
 	}
@@ -525,6 +548,9 @@ func (g *GitlabDownloader) GetPullRequests(page, perPage int) ([]*base.PullReque
 			Page:    page,
 		},
 	}
+	if !updatedAfter.IsZero() {
+		opt.UpdatedAfter = &updatedAfter
+	}
 
 	var allPRs = make([]*base.PullRequest, 0, perPage)
 
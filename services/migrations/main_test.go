@@ -6,12 +6,20 @@
 package migrations
 
 import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"code.gitea.io/gitea/models/unittest"
 	base "code.gitea.io/gitea/modules/migration"
+	"code.gitea.io/gitea/modules/setting"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -20,6 +28,73 @@ func TestMain(m *testing.M) {
 	unittest.MainTest(m, filepath.Join("..", ".."))
 }
 
+var fixtureNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// fixtureName returns the file name of the recorded response of a request
+func fixtureName(r *http.Request) string {
+	name := r.URL.Path
+	if r.URL.RawQuery != "" {
+		name += "?" + r.URL.RawQuery
+	}
+	return strings.Trim(fixtureNameReplacer.ReplaceAllString(name, "_"), "_")
+}
+
+// newMockWebServer replays the responses of a remote api which are recorded in testDataDir.
+// In live mode the requests are forwarded to liveServerBaseURL and the responses are recorded.
+// Links to liveServerBaseURL in the responses are rewritten to the mock server.
+func newMockWebServer(t *testing.T, liveServerBaseURL, testDataDir string, liveMode bool) *httptest.Server {
+	// the migration http client refuses to connect to the loopback interface by default
+	allowLocalNetworks, allowedDomains, blockedDomains := setting.Migrations.AllowLocalNetworks, setting.Migrations.AllowedDomains, setting.Migrations.BlockedDomains
+	setting.Migrations.AllowLocalNetworks, setting.Migrations.AllowedDomains, setting.Migrations.BlockedDomains = true, "", ""
+	assert.NoError(t, Init())
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fixturePath := filepath.Join(testDataDir, fixtureName(r))
+
+		if liveMode {
+			req, err := http.NewRequest(r.Method, liveServerBaseURL+r.URL.RequestURI(), r.Body)
+			if !assert.NoError(t, err) {
+				return
+			}
+			req.Header = r.Header.Clone()
+			resp, err := http.DefaultClient.Do(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if !assert.NoError(t, err) {
+				return
+			}
+			if resp.StatusCode == http.StatusOK {
+				assert.NoError(t, os.MkdirAll(testDataDir, os.ModePerm))
+				assert.NoError(t, os.WriteFile(fixturePath, body, 0644))
+			}
+			w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+			w.WriteHeader(resp.StatusCode)
+			_, _ = w.Write(bytes.ReplaceAll(body, []byte(liveServerBaseURL), []byte(server.URL)))
+			return
+		}
+
+		body, err := os.ReadFile(fixturePath)
+		if err != nil {
+			t.Logf("No recorded response for %s %s: %v", r.Method, r.URL.RequestURI(), err)
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bytes.ReplaceAll(body, []byte(liveServerBaseURL), []byte(server.URL)))
+	}))
+
+	t.Cleanup(func() {
+		server.Close()
+		setting.Migrations.AllowLocalNetworks, setting.Migrations.AllowedDomains, setting.Migrations.BlockedDomains = allowLocalNetworks, allowedDomains, blockedDomains
+		assert.NoError(t, Init())
+	})
+	return server
+}
+
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
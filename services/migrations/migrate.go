@@ -19,6 +19,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	base "code.gitea.io/gitea/modules/migration"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
 )
 
@@ -124,6 +125,7 @@ func MigrateRepository(ctx context.Context, doer *models.User, ownerName string,
 	var uploader = NewGiteaLocalUploader(ctx, doer, ownerName, opts.RepoName)
 	uploader.gitServiceType = opts.GitServiceType
 
+	startTime := timeutil.TimeStampNow()
 	if err := migrateRepository(downloader, uploader, opts, messenger); err != nil {
 		if err1 := uploader.Rollback(); err1 != nil {
 			log.Error("rollback failed: %v", err1)
@@ -133,6 +135,21 @@ func MigrateRepository(ctx context.Context, doer *models.User, ownerName string,
 		}
 		return nil, err
 	}
+
+	if opts.Mirror && (opts.Milestones || opts.Labels || opts.Releases || opts.Issues || opts.PullRequests) {
+		// keep the migrate options to sync the metadata with the mirror later
+		m, err := models.GetMirrorByRepoID(uploader.repo.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.SetMigrateConfig(opts); err != nil {
+			return nil, err
+		}
+		m.MetadataSyncedUnix = startTime
+		if err := models.UpdateMirror(m); err != nil {
+			return nil, err
+		}
+	}
 	return uploader.repo, nil
 }
 
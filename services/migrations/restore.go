@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	base "code.gitea.io/gitea/modules/migration"
 
@@ -47,6 +48,15 @@ func (r *RepositoryRestorer) reviewDir() string {
 	return filepath.Join(r.baseDir, "reviews")
 }
 
+// fileURL returns the URL of a file of the dump, files outside of the dump are refused
+func (r *RepositoryRestorer) fileURL(relPath string) (string, error) {
+	p := filepath.Join(r.baseDir, relPath)
+	if !strings.HasPrefix(p, r.baseDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("file %s is outside of the dump", relPath)
+	}
+	return "file://" + p, nil
+}
+
 // SetContext set context
 func (r *RepositoryRestorer) SetContext(ctx context.Context) {
 	r.ctx = ctx
@@ -82,7 +92,7 @@ func (r *RepositoryRestorer) GetRepoInfo() (*base.Repository, error) {
 		IsPrivate:     isPrivate,
 		Description:   opts["description"],
 		OriginalURL:   opts["original_url"],
-		CloneURL:      filepath.Join(r.baseDir, "git"),
+		CloneURL:      localCloneURL(filepath.Join(r.baseDir, "git")),
 		DefaultBranch: opts["default_branch"],
 	}, nil
 }
@@ -97,6 +107,9 @@ func (r *RepositoryRestorer) GetTopics() ([]string, error) {
 
 	bs, err := os.ReadFile(p)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
 		return nil, err
 	}
 
@@ -155,7 +168,9 @@ func (r *RepositoryRestorer) GetReleases() ([]*base.Release, error) {
 	for _, rel := range releases {
 		for _, asset := range rel.Assets {
 			if asset.DownloadURL != nil {
-				*asset.DownloadURL = "file://" + filepath.Join(r.baseDir, *asset.DownloadURL)
+				if *asset.DownloadURL, err = r.fileURL(*asset.DownloadURL); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
@@ -259,7 +274,16 @@ func (r *RepositoryRestorer) GetPullRequests(page, perPage int) ([]*base.PullReq
 		return nil, false, err
 	}
 	for _, pr := range pulls {
-		pr.PatchURL = "file://" + filepath.Join(r.baseDir, pr.PatchURL)
+		if pr.PatchURL != "" {
+			if pr.PatchURL, err = r.fileURL(pr.PatchURL); err != nil {
+				return nil, false, err
+			}
+		}
+		// the head branches of all pull requests are part of the dumped git data,
+		// so nothing must be fetched from elsewhere
+		pr.Head.CloneURL = ""
+		pr.Head.OwnerName = pr.Base.OwnerName
+		pr.Head.RepoName = pr.Base.RepoName
 		pr.Context = base.BasicIssueContext(pr.Number)
 	}
 	return pulls, true, nil
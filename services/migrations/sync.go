@@ -0,0 +1,239 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	admin_model "code.gitea.io/gitea/models/admin"
+	"code.gitea.io/gitea/modules/log"
+	base "code.gitea.io/gitea/modules/migration"
+)
+
+// SyncRepository syncs the topics, milestones, labels, releases, issues and pull requests
+// of a mirror which have changed on the original service since the given time
+func SyncRepository(ctx context.Context, doer *models.User, repo *models.Repository, opts base.MigrateOptions, since time.Time) error {
+	downloader, err := newDownloader(ctx, repo.OwnerName, opts)
+	if err != nil {
+		return err
+	}
+
+	var uploader = NewGiteaLocalUploader(ctx, doer, repo.OwnerName, repo.Name)
+	uploader.gitServiceType = opts.GitServiceType
+	if err := uploader.loadRepository(repo); err != nil {
+		return err
+	}
+	defer uploader.Close()
+
+	if err := syncRepository(downloader, uploader, opts, since); err != nil {
+		if err2 := admin_model.CreateRepositoryNotice(fmt.Sprintf("Sync repository %s from %s failed: %v", repo.FullName(), repo.OriginalURL, err)); err2 != nil {
+			log.Error("create repository notice failed: ", err2)
+		}
+		return err
+	}
+	return nil
+}
+
+func syncRepository(downloader base.Downloader, uploader *GiteaLocalUploader, opts base.MigrateOptions, since time.Time) error {
+	log.Trace("syncing topics")
+	topics, err := downloader.GetTopics()
+	if err != nil {
+		if !base.IsErrNotSupported(err) {
+			return err
+		}
+		log.Warn("syncing topics is not supported, ignored")
+	}
+	if len(topics) != 0 {
+		if err = uploader.CreateTopics(topics...); err != nil {
+			return err
+		}
+	}
+
+	if opts.Milestones {
+		log.Trace("syncing milestones")
+		milestones, err := downloader.GetMilestones()
+		if err != nil {
+			if !base.IsErrNotSupported(err) {
+				return err
+			}
+			log.Warn("syncing milestones is not supported, ignored")
+		}
+		if err := uploader.UpdateMilestones(milestones...); err != nil {
+			return err
+		}
+	}
+
+	if opts.Labels {
+		log.Trace("syncing labels")
+		labels, err := downloader.GetLabels()
+		if err != nil {
+			if !base.IsErrNotSupported(err) {
+				return err
+			}
+			log.Warn("syncing labels is not supported, ignored")
+		}
+		if err := uploader.UpdateLabels(labels...); err != nil {
+			return err
+		}
+	}
+
+	if opts.Releases {
+		log.Trace("syncing releases")
+		releases, err := downloader.GetReleases()
+		if err != nil {
+			if !base.IsErrNotSupported(err) {
+				return err
+			}
+			log.Warn("syncing releases is not supported, ignored")
+		}
+		if err := uploader.UpdateReleases(releases...); err != nil {
+			return err
+		}
+
+		// Once all releases (if any) are updated, sync any remaining non-release tags
+		if err = uploader.SyncTags(); err != nil {
+			return err
+		}
+	}
+
+	if opts.Issues {
+		log.Trace("syncing issues and comments")
+		var issueBatchSize = uploader.MaxBatchInsertSize("issue")
+
+		for i := 1; ; i++ {
+			issues, isEnd, err := getNewIssues(downloader, i, issueBatchSize, since)
+			if err != nil {
+				if !base.IsErrNotSupported(err) {
+					return err
+				}
+				log.Warn("syncing issues is not supported, ignored")
+				break
+			}
+
+			if err := uploader.UpdateIssues(issues...); err != nil {
+				return err
+			}
+
+			if opts.Comments {
+				for _, issue := range issues {
+					if err := syncComments(downloader, uploader, issue.Context, since); err != nil {
+						return err
+					}
+				}
+			}
+
+			if isEnd {
+				break
+			}
+		}
+	}
+
+	if opts.PullRequests {
+		log.Trace("syncing pull requests and comments")
+		var prBatchSize = uploader.MaxBatchInsertSize("pullrequest")
+
+		for i := 1; ; i++ {
+			prs, isEnd, err := getNewPullRequests(downloader, i, prBatchSize, since)
+			if err != nil {
+				if !base.IsErrNotSupported(err) {
+					return err
+				}
+				log.Warn("syncing pull requests is not supported, ignored")
+				break
+			}
+
+			if err := uploader.UpdatePullRequests(prs...); err != nil {
+				return err
+			}
+
+			if opts.Comments {
+				for _, pr := range prs {
+					if err := syncComments(downloader, uploader, pr.Context, since); err != nil {
+						return err
+					}
+
+					reviews, err := downloader.GetReviews(pr.Context)
+					if err != nil {
+						if !base.IsErrNotSupported(err) {
+							return err
+						}
+						log.Warn("syncing reviews is not supported, ignored")
+						continue
+					}
+					if err := uploader.UpdateReviews(since, reviews...); err != nil {
+						return err
+					}
+				}
+			}
+
+			if isEnd {
+				break
+			}
+		}
+	}
+
+	return models.RecalculateIssueIndexForRepo(uploader.repo.ID)
+}
+
+// syncComments syncs the comments of a single issue or pull request. Comments are always
+// fetched per issue, so only those of the issues changed since the last sync are requested.
+func syncComments(downloader base.Downloader, uploader *GiteaLocalUploader, issueContext base.IssueContext, since time.Time) error {
+	comments, _, err := downloader.GetComments(base.GetCommentOptions{
+		Context: issueContext,
+	})
+	if err != nil {
+		if !base.IsErrNotSupported(err) {
+			return err
+		}
+		log.Warn("syncing comments is not supported, ignored")
+		return nil
+	}
+	return uploader.UpdateComments(since, comments...)
+}
+
+// getNewIssues returns the issues updated after the given time. If the downloader
+// can't filter them, all issues are requested and filtered locally.
+func getNewIssues(downloader base.Downloader, page, perPage int, updatedAfter time.Time) ([]*base.Issue, bool, error) {
+	issues, isEnd, err := downloader.GetNewIssues(page, perPage, updatedAfter)
+	if err == nil || !base.IsErrNotSupported(err) {
+		return issues, isEnd, err
+	}
+
+	issues, isEnd, err = downloader.GetIssues(page, perPage)
+	if err != nil {
+		return nil, false, err
+	}
+	newIssues := make([]*base.Issue, 0, len(issues))
+	for _, issue := range issues {
+		if issue.Updated.After(updatedAfter) {
+			newIssues = append(newIssues, issue)
+		}
+	}
+	return newIssues, isEnd, nil
+}
+
+// getNewPullRequests returns the pull requests updated after the given time. If the downloader
+// can't filter them, all pull requests are requested and filtered locally.
+func getNewPullRequests(downloader base.Downloader, page, perPage int, updatedAfter time.Time) ([]*base.PullRequest, bool, error) {
+	prs, isEnd, err := downloader.GetNewPullRequests(page, perPage, updatedAfter)
+	if err == nil || !base.IsErrNotSupported(err) {
+		return prs, isEnd, err
+	}
+
+	prs, isEnd, err = downloader.GetPullRequests(page, perPage)
+	if err != nil {
+		return nil, false, err
+	}
+	newPRs := make([]*base.PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		if pr.Updated.After(updatedAfter) {
+			newPRs = append(newPRs, pr)
+		}
+	}
+	return newPRs, isEnd, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/foreignreference"
+	"code.gitea.io/gitea/models/unittest"
+	base "code.gitea.io/gitea/modules/migration"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type syncTestDownloader struct {
+	base.NullDownloader
+	issues   []*base.Issue
+	comments []*base.Comment
+}
+
+func (d *syncTestDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	return d.issues, true, nil
+}
+
+func (d *syncTestDownloader) GetComments(opts base.GetCommentOptions) ([]*base.Comment, bool, error) {
+	var comments []*base.Comment
+	for _, comment := range d.comments {
+		if comment.IssueIndex == opts.Context.LocalID() {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, true, nil
+}
+
+func TestSyncRepository(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	user := unittest.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo := unittest.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+	since := time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+	downloader := &syncTestDownloader{
+		issues: []*base.Issue{
+			{
+				Number:  1,
+				Title:   "Synced issue",
+				Content: "Issue of the original service",
+				State:   "open",
+				Created: since.Add(time.Hour),
+				Updated: since.Add(time.Hour),
+				Context: base.BasicIssueContext(1),
+			},
+		},
+		comments: []*base.Comment{
+			{
+				ID:         10,
+				IssueIndex: 1,
+				Content:    "Comment of the original service",
+				Created:    since.Add(2 * time.Hour),
+				Updated:    since.Add(2 * time.Hour),
+			},
+		},
+	}
+
+	sync := func() {
+		uploader := NewGiteaLocalUploader(context.Background(), user, repo.OwnerName, repo.Name)
+		assert.NoError(t, uploader.loadRepository(repo))
+		defer uploader.Close()
+
+		assert.NoError(t, syncRepository(downloader, uploader, base.MigrateOptions{
+			Issues:   true,
+			Comments: true,
+		}, since))
+	}
+
+	sync()
+
+	// index 1 is already used by an issue of the repository
+	index, err := foreignreference.GetLocalIndex(repo.ID, "1", foreignreference.TypeIssue)
+	assert.NoError(t, err)
+	assert.NotEqualValues(t, 1, index)
+
+	issue, err := models.GetIssueByIndex(repo.ID, index)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Synced issue", issue.Title)
+	unittest.AssertExistsAndLoadBean(t, &models.Comment{IssueID: issue.ID, Content: "Comment of the original service"})
+
+	downloader.issues[0].Title = "Renamed synced issue"
+	downloader.issues[0].State = "closed"
+	downloader.issues[0].Updated = since.Add(3 * time.Hour)
+	downloader.comments[0].Content = "Edited comment of the original service"
+	downloader.comments[0].Updated = since.Add(3 * time.Hour)
+
+	sync()
+
+	// the synced issue and comment are updated instead of created again
+	issue, err = models.GetIssueByID(issue.ID)
+	assert.NoError(t, err)
+	assert.EqualValues(t, index, issue.Index)
+	assert.EqualValues(t, "Renamed synced issue", issue.Title)
+	assert.True(t, issue.IsClosed)
+	unittest.AssertCount(t, &models.Issue{RepoID: repo.ID, Title: "Synced issue"}, 0)
+	unittest.AssertCount(t, &models.Comment{IssueID: issue.ID, Type: models.CommentTypeComment}, 1)
+	unittest.AssertExistsAndLoadBean(t, &models.Comment{IssueID: issue.ID, Content: "Edited comment of the original service"})
+}
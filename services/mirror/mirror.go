@@ -11,6 +11,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/graceful"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/queue"
 	"code.gitea.io/gitea/modules/setting"
 )
@@ -145,6 +146,8 @@ func InitSyncMirrors() {
 	mirrorQueue = queue.CreateUniqueQueue("mirror", queueHandle, new(SyncRequest))
 
 	go graceful.GetManager().RunWithShutdownFns(mirrorQueue.Run)
+
+	notification.RegisterNotifier(&mirrorNotifier{})
 }
 
 // StartToMirror adds repoID to mirror queue
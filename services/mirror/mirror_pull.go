@@ -22,6 +22,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/migrations"
 )
 
 // gitShortEmptySha Git short empty SHA
@@ -349,6 +350,36 @@ func runSync(ctx context.Context, m *models.Mirror) ([]*mirrorSyncResult, bool)
 	return parseRemoteUpdateOutput(output), true
 }
 
+// syncMetadata syncs the issues, pull requests, releases etc. of a mirror changed on the original service since the last sync.
+// Failures are only logged, as the git data of the mirror has been synced already.
+func syncMetadata(ctx context.Context, m *models.Mirror) {
+	opts, err := m.MigrateConfig()
+	if err != nil {
+		log.Error("MigrateConfig [%d]: %v", m.RepoID, err)
+		return
+	}
+
+	// items of users unknown to this instance are attributed to the owner, or the ghost user for organizations
+	doer := models.NewGhostUser()
+	if err := m.Repo.GetOwner(); err != nil {
+		log.Error("GetOwner [%d]: %v", m.RepoID, err)
+		return
+	} else if !m.Repo.Owner.IsOrganization() {
+		doer = m.Repo.Owner
+	}
+
+	startTime := timeutil.TimeStampNow()
+	if err := migrations.SyncRepository(ctx, doer, m.Repo, *opts, m.MetadataSyncedUnix.AsTime()); err != nil {
+		log.Error("SyncRepository [%d]: %v", m.RepoID, err)
+		return
+	}
+
+	m.MetadataSyncedUnix = startTime
+	if err := models.UpdateMirror(m); err != nil {
+		log.Error("UpdateMirror [%d]: %v", m.RepoID, err)
+	}
+}
+
 // SyncPullMirror starts the sync of the pull mirror and schedules the next run.
 func SyncPullMirror(ctx context.Context, repoID int64) bool {
 	log.Trace("SyncMirrors [repo_id: %v]", repoID)
@@ -380,6 +411,11 @@ func SyncPullMirror(ctx context.Context, repoID int64) bool {
 		return false
 	}
 
+	if m.SyncsMetadata() {
+		log.Trace("SyncMirrors [repo: %-v]: Syncing issues, pull requests and releases", m.Repo)
+		syncMetadata(ctx, m)
+	}
+
 	var gitRepo *git.Repository
 	if len(results) == 0 {
 		log.Trace("SyncMirrors [repo: %-v]: no branches updated", m.Repo)
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"io"
 	"regexp"
+	"strings"
 	"time"
 
 	"code.gitea.io/gitea/models"
@@ -93,6 +94,7 @@ func SyncPushMirror(ctx context.Context, mirrorID int64) bool {
 	m.LastError = ""
 
 	log.Trace("SyncPushMirror [mirror: %d][repo: %-v]: Running Sync", m.ID, m.Repo)
+	started := timeutil.TimeStampNow()
 	err = runPushSync(ctx, m)
 	if err != nil {
 		log.Error("SyncPushMirror [mirror: %d][repo: %-v]: %v", m.ID, m.Repo, err)
@@ -107,6 +109,16 @@ func SyncPushMirror(ctx context.Context, mirrorID int64) bool {
 		return false
 	}
 
+	if err := models.InsertPushMirrorAttempt(&models.PushMirrorAttempt{
+		PushMirrorID: m.ID,
+		RepoID:       m.RepoID,
+		Error:        m.LastError,
+		StartedUnix:  started,
+		EndedUnix:    m.LastUpdateUnix,
+	}, setting.Mirror.PushHistoryLength); err != nil {
+		log.Error("InsertPushMirrorAttempt [%d]: %v", m.ID, err)
+	}
+
 	log.Trace("SyncPushMirror [mirror: %d][repo: %-v]: Finished", m.ID, m.Repo)
 
 	return err == nil
@@ -115,7 +127,7 @@ func SyncPushMirror(ctx context.Context, mirrorID int64) bool {
 func runPushSync(ctx context.Context, m *models.PushMirror) error {
 	timeout := time.Duration(setting.Git.Timeout.Mirror) * time.Second
 
-	performPush := func(path string) error {
+	performPush := func(path string, filterRefs bool) error {
 		remoteAddr, err := git.GetRemoteAddress(path, m.RemoteName)
 		if err != nil {
 			log.Error("GetRemoteAddress(%s) Error %v", path, err)
@@ -139,14 +151,34 @@ func runPushSync(ctx context.Context, m *models.PushMirror) error {
 			}
 		}
 
-		log.Trace("Pushing %s mirror[%d] remote %s", path, m.ID, m.RemoteName)
-
-		if err := git.Push(path, git.PushOptions{
+		pushOpts := git.PushOptions{
 			Remote:  m.RemoteName,
 			Force:   true,
 			Mirror:  true,
 			Timeout: timeout,
-		}); err != nil {
+		}
+		if filterRefs {
+			refspecs, err := pushMirrorRefspecs(path, m, timeout)
+			if err != nil {
+				log.Error("Error listing refs of %s mirror[%d] remote %s: %v", path, m.ID, m.RemoteName, err)
+				return util.NewURLSanitizedError(err, remoteAddr, true)
+			}
+			if len(refspecs) == 0 {
+				log.Trace("No refs to push to %s mirror[%d] remote %s", path, m.ID, m.RemoteName)
+				return nil
+			}
+			// the remote is added with --mirror=push, which can't be combined with refspecs
+			if _, err := git.NewCommand("config", "remote."+m.RemoteName+".mirror", "false").RunInDirTimeout(timeout, path); err != nil {
+				log.Error("Error disabling mirroring of %s mirror[%d] remote %s: %v", path, m.ID, m.RemoteName, err)
+				return util.NewURLSanitizedError(err, remoteAddr, true)
+			}
+			pushOpts.Mirror = false
+			pushOpts.Refspecs = refspecs
+		}
+
+		log.Trace("Pushing %s mirror[%d] remote %s", path, m.ID, m.RemoteName)
+
+		if err := git.Push(path, pushOpts); err != nil {
 			log.Error("Error pushing %s mirror[%d] remote %s: %v", path, m.ID, m.RemoteName, err)
 
 			return util.NewURLSanitizedError(err, remoteAddr, true)
@@ -155,7 +187,7 @@ func runPushSync(ctx context.Context, m *models.PushMirror) error {
 		return nil
 	}
 
-	err := performPush(m.Repo.RepoPath())
+	err := performPush(m.Repo.RepoPath(), m.HasRefFilter())
 	if err != nil {
 		return err
 	}
@@ -164,7 +196,7 @@ func runPushSync(ctx context.Context, m *models.PushMirror) error {
 		wikiPath := m.Repo.WikiPath()
 		_, err := git.GetRemoteAddress(wikiPath, m.RemoteName)
 		if err == nil {
-			err := performPush(wikiPath)
+			err := performPush(wikiPath, false)
 			if err != nil {
 				return err
 			}
@@ -176,6 +208,42 @@ func runPushSync(ctx context.Context, m *models.PushMirror) error {
 	return nil
 }
 
+// pushMirrorRefspecs returns the refspecs updating the refs of the remote which are matched by the
+// ref filter of the push mirror, including the deletion of matched refs which don't exist anymore.
+func pushMirrorRefspecs(path string, m *models.PushMirror, timeout time.Duration) ([]string, error) {
+	match := m.RefMatcher()
+
+	stdout, err := git.NewCommand("for-each-ref", "--format=%(refname)", git.BranchPrefix, git.TagPrefix).RunInDirTimeout(timeout, path)
+	if err != nil {
+		return nil, err
+	}
+	refspecs := make([]string, 0, 10)
+	localRefs := make(map[string]bool)
+	for _, refName := range strings.Fields(string(stdout)) {
+		if match(refName) {
+			localRefs[refName] = true
+			refspecs = append(refspecs, "+"+refName+":"+refName)
+		}
+	}
+
+	stdout, err = git.NewCommand("ls-remote", "--heads", "--tags", m.RemoteName).RunInDirTimeout(timeout, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(stdout), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		refName := fields[1]
+		if strings.HasSuffix(refName, "^{}") || localRefs[refName] || !match(refName) {
+			continue
+		}
+		refspecs = append(refspecs, ":"+refName)
+	}
+	return refspecs, nil
+}
+
 func pushAllLFSObjects(ctx context.Context, gitRepo *git.Repository, lfsClient lfs.Client) error {
 	contentStore := lfs.NewContentStore()
 
@@ -0,0 +1,38 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mirror
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunPushSyncWithRefFilter(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	repo := unittest.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	remotePath := t.TempDir()
+	assert.NoError(t, git.InitRepository(remotePath, true))
+
+	m := &models.PushMirror{
+		RepoID:      repo.ID,
+		Repo:        repo,
+		RemoteName:  "filtered_mirror",
+		IncludeRefs: "master feature/* v1.1",
+	}
+	assert.NoError(t, AddPushMirrorRemote(m, remotePath))
+	assert.NoError(t, runPushSync(context.Background(), m))
+
+	stdout, err := git.NewCommand("for-each-ref", "--format=%(refname)").RunInDir(remotePath)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"refs/heads/feature/1", "refs/heads/master", "refs/tags/v1.1"}, strings.Fields(stdout))
+}
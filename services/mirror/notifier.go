@@ -0,0 +1,96 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mirror
+
+import (
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification/base"
+	"code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+var (
+	pushMirrorTimersLock sync.Mutex
+	pushMirrorTimers     = make(map[int64]*time.Timer)
+)
+
+// debouncePushMirrorSync adds the push mirror to the queue once it hasn't been pushed to
+// for the debounce delay, so a series of pushes results in a single sync.
+func debouncePushMirrorSync(mirrorID int64) {
+	if setting.Mirror.PushDebounce <= 0 {
+		AddPushMirrorToQueue(mirrorID)
+		return
+	}
+
+	pushMirrorTimersLock.Lock()
+	defer pushMirrorTimersLock.Unlock()
+
+	if timer, ok := pushMirrorTimers[mirrorID]; ok && timer.Stop() {
+		timer.Reset(setting.Mirror.PushDebounce)
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(setting.Mirror.PushDebounce, func() {
+		pushMirrorTimersLock.Lock()
+		if pushMirrorTimers[mirrorID] == timer {
+			delete(pushMirrorTimers, mirrorID)
+		}
+		pushMirrorTimersLock.Unlock()
+
+		AddPushMirrorToQueue(mirrorID)
+	})
+	pushMirrorTimers[mirrorID] = timer
+}
+
+// syncPushMirrorsOnPush schedules the sync of the push mirrors of the repository which are synced
+// on push and whose ref filter matches the pushed ref
+func syncPushMirrorsOnPush(repo *models.Repository, refFullName string) {
+	mirrors, err := models.GetPushMirrorsSyncedOnPush(repo.ID)
+	if err != nil {
+		log.Error("GetPushMirrorsSyncedOnPush [repo: %-v]: %v", repo, err)
+		return
+	}
+	for _, m := range mirrors {
+		if m.RefMatcher()(refFullName) {
+			debouncePushMirrorSync(m.ID)
+		}
+	}
+}
+
+// mirrorNotifier syncs the push mirrors which are synced on push
+type mirrorNotifier struct {
+	base.NullNotifier
+}
+
+var _ base.Notifier = &mirrorNotifier{}
+
+func (*mirrorNotifier) NotifyPushCommits(pusher *models.User, repo *models.Repository, opts *repository.PushUpdateOptions, commits *repository.PushCommits) {
+	syncPushMirrorsOnPush(repo, opts.RefFullName)
+}
+
+func (*mirrorNotifier) NotifyCreateRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
+	syncPushMirrorsOnPush(repo, refFullName)
+}
+
+func (*mirrorNotifier) NotifyDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
+	syncPushMirrorsOnPush(repo, refFullName)
+}
+
+func (*mirrorNotifier) NotifySyncPushCommits(pusher *models.User, repo *models.Repository, opts *repository.PushUpdateOptions, commits *repository.PushCommits) {
+	syncPushMirrorsOnPush(repo, opts.RefFullName)
+}
+
+func (*mirrorNotifier) NotifySyncCreateRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
+	syncPushMirrorsOnPush(repo, refFullName)
+}
+
+func (*mirrorNotifier) NotifySyncDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
+	syncPushMirrorsOnPush(repo, refFullName)
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ErrInvalidUploadOffset indicates a chunk which does not continue the upload
+var ErrInvalidUploadOffset = errors.New("The upload offset does not match the received bytes")
+
+func blobUploadPath(id string) string {
+	return filepath.Join(setting.Packages.ChunkedUploadPath, id)
+}
+
+// CreateBlobUpload starts a new chunked upload
+func CreateBlobUpload(ctx context.Context) (*packages_model.PackageBlobUpload, error) {
+	if err := os.MkdirAll(setting.Packages.ChunkedUploadPath, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	pbu, err := packages_model.CreateBlobUpload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(blobUploadPath(pbu.ID))
+	if err != nil {
+		_ = packages_model.DeleteBlobUploadByID(ctx, pbu.ID)
+		return nil, err
+	}
+	return pbu, f.Close()
+}
+
+// AppendToBlobUpload appends a chunk at the given offset to the upload
+func AppendToBlobUpload(ctx context.Context, pbu *packages_model.PackageBlobUpload, offset int64, r io.Reader) error {
+	if offset != pbu.BytesReceived {
+		return ErrInvalidUploadOffset
+	}
+
+	f, err := os.OpenFile(blobUploadPath(pbu.ID), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := io.Copy(f, r)
+	pbu.BytesReceived += n
+	if err != nil {
+		return err
+	}
+	return packages_model.UpdateBlobUpload(ctx, pbu)
+}
+
+// OpenBlobUpload opens the content received so far
+func OpenBlobUpload(pbu *packages_model.PackageBlobUpload) (*os.File, error) {
+	return os.Open(blobUploadPath(pbu.ID))
+}
+
+// RemoveBlobUploadByID deletes the upload and its content
+func RemoveBlobUploadByID(ctx context.Context, id string) error {
+	if err := packages_model.DeleteBlobUploadByID(ctx, id); err != nil {
+		return err
+	}
+	if err := util.Remove(blobUploadPath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,363 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// PackageInfo describes a package
+type PackageInfo struct {
+	Owner       *models.User
+	PackageType packages_model.Type
+	Name        string
+	Version     string
+}
+
+// PackageCreationInfo describes a package to create
+type PackageCreationInfo struct {
+	PackageInfo
+	Creator    *models.User
+	Metadata   interface{}
+	IsInternal bool
+}
+
+// PackageFileInfo describes a package file
+type PackageFileInfo struct {
+	Filename string
+	IsLead   bool
+}
+
+// CreatePackageAndAddFile creates a package with a file. If the same package exists already, ErrDuplicatePackageVersion is returned
+func CreatePackageAndAddFile(pvci *PackageCreationInfo, pfi *PackageFileInfo, data packages_module.HashedSizeReader) (*packages_model.PackageVersion, *packages_model.PackageFile, error) {
+	return createPackageAndAddFile(pvci, pfi, data, false, nil)
+}
+
+// CreatePackageAndAddFileWithReferences creates a package with a file and adds the referenced blobs as additional files.
+// The references map file names to the ids of existing blobs.
+func CreatePackageAndAddFileWithReferences(pvci *PackageCreationInfo, pfi *PackageFileInfo, data packages_module.HashedSizeReader, references map[string]int64) (*packages_model.PackageVersion, *packages_model.PackageFile, error) {
+	return createPackageAndAddFile(pvci, pfi, data, false, references)
+}
+
+// CreatePackageOrAddFileToExisting creates a package with a file or adds the file if the package exists already
+func CreatePackageOrAddFileToExisting(pvci *PackageCreationInfo, pfi *PackageFileInfo, data packages_module.HashedSizeReader) (*packages_model.PackageVersion, *packages_model.PackageFile, error) {
+	return createPackageAndAddFile(pvci, pfi, data, true, nil)
+}
+
+func createPackageAndAddFile(pvci *PackageCreationInfo, pfi *PackageFileInfo, data packages_module.HashedSizeReader, allowDuplicate bool, references map[string]int64) (*packages_model.PackageVersion, *packages_model.PackageFile, error) {
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer committer.Close()
+
+	pv, err := createPackageAndVersion(ctx, pvci, allowDuplicate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pf, pb, blobCreated, err := addFileToPackageVersion(ctx, pv, pfi, data)
+	removeBlob := false
+	defer func() {
+		if blobCreated && removeBlob {
+			contentStore := packages_module.NewContentStore()
+			if err := contentStore.Delete(packages_module.BlobHash256Key(pb.HashSHA256)); err != nil {
+				log.Error("Error deleting package blob from content store: %v", err)
+			}
+		}
+	}()
+	if err != nil {
+		removeBlob = true
+		return nil, nil, err
+	}
+
+	for name, blobID := range references {
+		if name == pf.Name {
+			continue
+		}
+		if _, err := packages_model.TryInsertFile(ctx, &packages_model.PackageFile{
+			VersionID: pv.ID,
+			BlobID:    blobID,
+			Name:      name,
+		}); err != nil && err != packages_model.ErrDuplicatePackageFile {
+			removeBlob = true
+			return nil, nil, err
+		}
+	}
+
+	if err := committer.Commit(); err != nil {
+		removeBlob = true
+		return nil, nil, err
+	}
+
+	return pv, pf, nil
+}
+
+func createPackageAndVersion(ctx context.Context, pvci *PackageCreationInfo, allowDuplicate bool) (*packages_model.PackageVersion, error) {
+	p, err := packages_model.TryInsertPackage(ctx, &packages_model.Package{
+		OwnerID: pvci.Owner.ID,
+		Type:    pvci.PackageType,
+		Name:    pvci.Name,
+	})
+	if err != nil {
+		log.Error("Error inserting package: %v", err)
+		return nil, err
+	}
+
+	metadataJSON, err := json.Marshal(pvci.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	pv, err := packages_model.GetOrInsertVersion(ctx, &packages_model.PackageVersion{
+		PackageID:    p.ID,
+		CreatorID:    pvci.Creator.ID,
+		Version:      pvci.Version,
+		IsInternal:   pvci.IsInternal,
+		MetadataJSON: string(metadataJSON),
+	})
+	if err != nil {
+		if err == packages_model.ErrDuplicatePackageVersion && allowDuplicate {
+			return pv, nil
+		}
+		if err != packages_model.ErrDuplicatePackageVersion {
+			log.Error("Error inserting package: %v", err)
+		}
+		return nil, err
+	}
+	return pv, nil
+}
+
+// AddFileToExistingPackage adds a file to an existing package. If the package does not exist, ErrPackageVersionNotExist is returned
+func AddFileToExistingPackage(pvi *PackageInfo, pfi *PackageFileInfo, data packages_module.HashedSizeReader) (*packages_model.PackageVersion, *packages_model.PackageFile, error) {
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer committer.Close()
+
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, pvi.Owner.ID, pvi.PackageType, pvi.Name, pvi.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pf, pb, blobCreated, err := addFileToPackageVersion(ctx, pv, pfi, data)
+	removeBlob := false
+	defer func() {
+		if blobCreated && removeBlob {
+			contentStore := packages_module.NewContentStore()
+			if err := contentStore.Delete(packages_module.BlobHash256Key(pb.HashSHA256)); err != nil {
+				log.Error("Error deleting package blob from content store: %v", err)
+			}
+		}
+	}()
+	if err != nil {
+		removeBlob = true
+		return nil, nil, err
+	}
+
+	if err := committer.Commit(); err != nil {
+		removeBlob = true
+		return nil, nil, err
+	}
+
+	return pv, pf, nil
+}
+
+func addFileToPackageVersion(ctx context.Context, pv *packages_model.PackageVersion, pfi *PackageFileInfo, data packages_module.HashedSizeReader) (*packages_model.PackageFile, *packages_model.PackageBlob, bool, error) {
+	log.Trace("Adding package file: %v, %s", pv.ID, pfi.Filename)
+
+	pb, exists, err := GetOrCreateBlob(ctx, data)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if !exists {
+		contentStore := packages_module.NewContentStore()
+		if err := contentStore.Save(packages_module.BlobHash256Key(pb.HashSHA256), data, data.Size()); err != nil {
+			log.Error("Error saving package blob in content store: %v", err)
+			return nil, nil, false, err
+		}
+	}
+
+	pf, err := packages_model.TryInsertFile(ctx, &packages_model.PackageFile{
+		VersionID: pv.ID,
+		BlobID:    pb.ID,
+		Name:      pfi.Filename,
+		IsLead:    pfi.IsLead,
+	})
+	if err != nil {
+		if err != packages_model.ErrDuplicatePackageFile {
+			log.Error("Error inserting package file: %v", err)
+		}
+		return nil, pb, !exists, err
+	}
+
+	return pf, pb, !exists, nil
+}
+
+// GetOrCreateBlob gets the blob with the hashes of the data or creates a new one.
+// The content is not written to the content store.
+func GetOrCreateBlob(ctx context.Context, data packages_module.HashedSizeReader) (*packages_model.PackageBlob, bool, error) {
+	hashMD5, hashSHA1, hashSHA256, hashSHA512 := data.Sums()
+
+	pb, exists, err := packages_model.GetOrInsertBlob(ctx, &packages_model.PackageBlob{
+		Size:       data.Size(),
+		HashMD5:    hashMD5,
+		HashSHA1:   hashSHA1,
+		HashSHA256: hashSHA256,
+		HashSHA512: hashSHA512,
+	})
+	if err != nil {
+		log.Error("Error inserting package blob: %v", err)
+		return nil, false, err
+	}
+	return pb, exists, nil
+}
+
+// RemovePackageVersionByNameAndVersion deletes a package version and all associated files
+func RemovePackageVersionByNameAndVersion(doer *models.User, pvi *PackageInfo) error {
+	pv, err := packages_model.GetVersionByNameAndVersion(db.DefaultContext, pvi.Owner.ID, pvi.PackageType, pvi.Name, pvi.Version)
+	if err != nil {
+		return err
+	}
+
+	return RemovePackageVersion(doer, pv)
+}
+
+// RemovePackageVersion deletes the package version and all associated files
+func RemovePackageVersion(doer *models.User, pv *packages_model.PackageVersion) error {
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return err
+	}
+	defer committer.Close()
+
+	log.Trace("Deleting package version: %v", pv.ID)
+
+	if err := DeletePackageVersionAndReferences(ctx, pv); err != nil {
+		return err
+	}
+
+	return committer.Commit()
+}
+
+// DeletePackageVersionAndReferences deletes the package version and its files.
+// The package gets deleted too if it has no versions left. Unreferenced blobs are removed by the cleanup task.
+func DeletePackageVersionAndReferences(ctx context.Context, pv *packages_model.PackageVersion) error {
+	if err := packages_model.DeleteFilesByVersionID(ctx, pv.ID); err != nil {
+		return err
+	}
+	if err := packages_model.DeleteVersionByID(ctx, pv.ID); err != nil {
+		return err
+	}
+	return packages_model.DeletePackageByIDIfUnreferenced(ctx, pv.PackageID)
+}
+
+// Cleanup removes expired blob uploads and unreferenced blobs
+func Cleanup(taskCtx context.Context, olderThan time.Duration) error {
+	ctx, committer, err := db.TxContext()
+	if err != nil {
+		return err
+	}
+	defer committer.Close()
+
+	before := timeutil.TimeStampNow().AddDuration(-olderThan)
+
+	pbus, err := packages_model.FindExpiredBlobUploads(ctx, before)
+	if err != nil {
+		return err
+	}
+	for _, pbu := range pbus {
+		select {
+		case <-taskCtx.Done():
+			return db.ErrCancelledf("While cleanup expired blob uploads")
+		default:
+		}
+		if err := RemoveBlobUploadByID(ctx, pbu.ID); err != nil {
+			return err
+		}
+	}
+
+	pbs, err := packages_model.FindUnreferencedBlobs(ctx, before)
+	if err != nil {
+		return err
+	}
+	for _, pb := range pbs {
+		if err := packages_model.DeleteBlobByID(ctx, pb.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := committer.Commit(); err != nil {
+		return err
+	}
+
+	contentStore := packages_module.NewContentStore()
+	for _, pb := range pbs {
+		if err := contentStore.Delete(packages_module.BlobHash256Key(pb.HashSHA256)); err != nil {
+			log.Error("Error deleting package blob [%v]: %v", pb.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// GetFileStreamByPackageNameAndVersion returns the content of the specific package file
+func GetFileStreamByPackageNameAndVersion(pvi *PackageInfo, filename string) (io.ReadSeekCloser, *packages_model.PackageFile, error) {
+	log.Trace("Getting package file stream: %v, %v, %s, %s, %s", pvi.Owner.ID, pvi.PackageType, pvi.Name, pvi.Version, filename)
+
+	pv, err := packages_model.GetVersionByNameAndVersion(db.DefaultContext, pvi.Owner.ID, pvi.PackageType, pvi.Name, pvi.Version)
+	if err != nil {
+		if packages_model.IsErrPackageVersionNotExist(err) {
+			return nil, nil, err
+		}
+		log.Error("Error getting package: %v", err)
+		return nil, nil, err
+	}
+
+	return GetFileStreamByPackageVersion(db.DefaultContext, pv, filename)
+}
+
+// GetFileStreamByPackageVersion returns the content of the specific package file
+func GetFileStreamByPackageVersion(ctx context.Context, pv *packages_model.PackageVersion, filename string) (io.ReadSeekCloser, *packages_model.PackageFile, error) {
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return GetPackageFileStream(ctx, pv, pf)
+}
+
+// GetPackageFileStream returns the content of the specific package file.
+// The download counter of the version is incremented if the file is the lead file and pv is not nil.
+func GetPackageFileStream(ctx context.Context, pv *packages_model.PackageVersion, pf *packages_model.PackageFile) (io.ReadSeekCloser, *packages_model.PackageFile, error) {
+	pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s, err := packages_module.NewContentStore().Get(packages_module.BlobHash256Key(pb.HashSHA256))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if pv != nil && pf.IsLead {
+		if err := packages_model.IncrementDownloadCounter(ctx, pv.ID); err != nil {
+			log.Error("Error incrementing download counter: %v", err)
+		}
+	}
+
+	return s, pf, nil
+}
@@ -0,0 +1,319 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"fmt"
+	"strconv"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/notification/base"
+	"code.gitea.io/gitea/modules/queue"
+)
+
+// autoMergeQueue is the queue of the pull requests whose scheduled automatic merge has to be re-evaluated
+var autoMergeQueue queue.UniqueQueue
+
+func initAutoMerge() error {
+	autoMergeQueue = queue.CreateUniqueQueue("pr_auto_merge", handleAutoMerge, "")
+	if autoMergeQueue == nil {
+		return fmt.Errorf("Unable to create pr_auto_merge Queue")
+	}
+
+	go graceful.GetManager().RunWithShutdownFns(autoMergeQueue.Run)
+
+	notification.RegisterNotifier(&autoMergeNotifier{})
+	return nil
+}
+
+// triggerAutoMerge schedules the re-evaluation of the automatic merge of the pull request
+func triggerAutoMerge(pullID int64) {
+	if err := autoMergeQueue.Push(strconv.FormatInt(pullID, 10)); err != nil && err != queue.ErrAlreadyInQueue {
+		log.Error("Unable to push pull request %d to the auto merge queue: %v", pullID, err)
+	}
+}
+
+func handleAutoMerge(data ...queue.Data) {
+	for _, datum := range data {
+		pullID, err := strconv.ParseInt(datum.(string), 10, 64)
+		if err != nil {
+			continue
+		}
+		if err := processAutoMerge(pullID); err != nil {
+			log.Error("Processing the automatic merge of pull request %d failed: %v", pullID, err)
+		}
+	}
+}
+
+// ScheduleAutoMerge schedules the pull request to be merged as soon as all checks succeed.
+// It returns false if the pull request is ready to be merged right away, nothing is scheduled in that case.
+func ScheduleAutoMerge(doer *models.User, pr *models.PullRequest, mergeStyle models.MergeStyle, message string) (bool, error) {
+	if err := pr.LoadBaseRepo(); err != nil {
+		return false, fmt.Errorf("LoadBaseRepo: %v", err)
+	}
+	if err := pr.LoadIssue(); err != nil {
+		return false, fmt.Errorf("LoadIssue: %v", err)
+	}
+
+	prUnit, err := pr.BaseRepo.GetUnit(unit.TypePullRequests)
+	if err != nil {
+		return false, err
+	}
+	if mergeStyle == models.MergeStyleManuallyMerged || mergeStyle == models.MergeStyleRebaseUpdate || !prUnit.PullRequestsConfig().IsMergeStyleAllowed(mergeStyle) {
+		return false, models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: mergeStyle}
+	}
+
+	if ready, err := isPullReadyToAutoMerge(pr); err != nil {
+		return false, err
+	} else if ready {
+		return false, nil
+	}
+
+	if err := models.InsertPullAutoMerge(&models.PullAutoMerge{
+		RepoID:     pr.BaseRepoID,
+		PullID:     pr.ID,
+		DoerID:     doer.ID,
+		MergeStyle: mergeStyle,
+		Message:    message,
+	}); err != nil {
+		return false, err
+	}
+
+	if _, err := models.CreateComment(&models.CreateCommentOptions{
+		Type:  models.CommentTypePRScheduledToAutoMerge,
+		Doer:  doer,
+		Repo:  pr.BaseRepo,
+		Issue: pr.Issue,
+	}); err != nil {
+		log.Error("CreateComment: %v", err)
+	}
+	return true, nil
+}
+
+// CancelAutoMerge cancels the scheduled automatic merge of the pull request
+func CancelAutoMerge(pr *models.PullRequest, doer *models.User) error {
+	m, err := models.GetPullAutoMergeByPullID(pr.ID)
+	if err != nil {
+		return err
+	}
+	return cancelAutoMerge(pr, m, doer, "")
+}
+
+// cancelAutoMerge deletes the scheduled automatic merge and explains the reason in a comment
+func cancelAutoMerge(pr *models.PullRequest, m *models.PullAutoMerge, doer *models.User, reason string) error {
+	if err := models.DeletePullAutoMerge(m); err != nil {
+		return err
+	}
+	if err := pr.LoadIssue(); err != nil {
+		return err
+	}
+	if err := pr.Issue.LoadRepo(); err != nil {
+		return err
+	}
+	_, err := models.CreateComment(&models.CreateCommentOptions{
+		Type:    models.CommentTypePRUnScheduledToAutoMerge,
+		Doer:    doer,
+		Repo:    pr.Issue.Repo,
+		Issue:   pr.Issue,
+		Content: reason,
+	})
+	return err
+}
+
+// isPullReadyToAutoMerge checks whether the pull request has no conflicts and passed all reviews and status checks
+func isPullReadyToAutoMerge(pr *models.PullRequest) (bool, error) {
+	if !pr.CanAutoMerge() || pr.IsWorkInProgress() {
+		return false, nil
+	}
+
+	if err := CheckPRReadyToMerge(pr, false); err != nil {
+		if models.IsErrNotAllowedToMerge(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// without required status checks all status checks of the head commit have to succeed
+	state, err := GetPullRequestCommitStatusState(pr)
+	if err != nil {
+		return false, err
+	}
+	if !state.IsSuccess() {
+		return false, nil
+	}
+
+	if err := pr.LoadIssue(); err != nil {
+		return false, err
+	}
+	return models.IssueNoDependenciesLeft(pr.Issue)
+}
+
+// processAutoMerge merges the pull request if it is scheduled to be merged and ready
+func processAutoMerge(pullID int64) error {
+	m, err := models.GetPullAutoMergeByPullID(pullID)
+	if err != nil {
+		if models.IsErrPullAutoMergeNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	pr, err := models.GetPullRequestByID(pullID)
+	if err != nil {
+		return err
+	}
+	if err := pr.LoadIssue(); err != nil {
+		return err
+	}
+	if err := pr.LoadBaseRepo(); err != nil {
+		return err
+	}
+	if pr.HasMerged || pr.Issue.IsClosed {
+		return models.DeletePullAutoMerge(m)
+	}
+
+	if err := m.LoadDoer(); err != nil {
+		if models.IsErrUserNotExist(err) {
+			return models.DeletePullAutoMerge(m)
+		}
+		return err
+	}
+
+	perm, err := models.GetUserRepoPermission(pr.BaseRepo, m.Doer)
+	if err != nil {
+		return err
+	}
+	if allowed, err := IsUserAllowedToMerge(pr, perm, m.Doer); err != nil {
+		return err
+	} else if !allowed {
+		return cancelAutoMerge(pr, m, models.NewGhostUser(), "The user who scheduled the merge is not allowed to merge anymore.")
+	}
+
+	if ready, err := isPullReadyToAutoMerge(pr); err != nil || !ready {
+		return err
+	}
+
+	if pr.ProtectedBranch != nil && pr.ProtectedBranch.EnableMergeQueue {
+		if err := AddToMergeQueue(pr, m.Doer, m.MergeStyle, m.Message); err != nil && !models.IsErrMergeQueueEntryAlreadyExist(err) {
+			if models.IsErrInvalidMergeStyle(err) {
+				return cancelAutoMerge(pr, m, m.Doer, "The merge style is not allowed anymore.")
+			}
+			return err
+		}
+		return models.DeletePullAutoMerge(m)
+	}
+
+	baseGitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer baseGitRepo.Close()
+
+	log.Trace("Merging pull request %d automatically", pr.ID)
+	if err := Merge(pr, m.Doer, baseGitRepo, m.MergeStyle, m.Message); err != nil {
+		if git.IsErrPushOutOfDate(err) {
+			// the base branch has been updated in the meantime, try again on top of it
+			triggerAutoMerge(pr.ID)
+			return nil
+		}
+		log.Error("Automatic merge of pull request %d failed: %v", pr.ID, err)
+		return cancelAutoMerge(pr, m, m.Doer, "The pull request could not be merged.")
+	}
+	return models.DeletePullAutoMerge(m)
+}
+
+// autoMergeNotifier re-evaluates the scheduled automatic merges affected by new commit statuses, reviews and pushes
+type autoMergeNotifier struct {
+	base.NullNotifier
+}
+
+var _ base.Notifier = &autoMergeNotifier{}
+
+// getAutoMergesByHeadSHA returns the scheduled automatic merges of the pull requests of the repository
+// whose head commit is sha
+func getAutoMergesByHeadSHA(repo *models.Repository, sha string) ([]*models.PullAutoMerge, error) {
+	merges, err := models.GetPullAutoMergesByRepoID(repo.ID)
+	if err != nil || len(merges) == 0 {
+		return nil, err
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+	defer gitRepo.Close()
+
+	result := make([]*models.PullAutoMerge, 0, len(merges))
+	for _, m := range merges {
+		pr, err := models.GetPullRequestByID(m.PullID)
+		if err != nil {
+			if models.IsErrPullRequestNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		headCommitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+		if err != nil {
+			if git.IsErrNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if headCommitID == sha {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+func (*autoMergeNotifier) NotifyCreateCommitStatus(repo *models.Repository, sha string, creator *models.User, status *models.CommitStatus) {
+	merges, err := getAutoMergesByHeadSHA(repo, sha)
+	if err != nil {
+		log.Error("getAutoMergesByHeadSHA: %v", err)
+		return
+	}
+	for _, m := range merges {
+		triggerAutoMerge(m.PullID)
+	}
+}
+
+func (*autoMergeNotifier) NotifyPullRequestReview(pr *models.PullRequest, review *models.Review, comment *models.Comment, mentions []*models.User) {
+	triggerAutoMerge(pr.ID)
+}
+
+func (*autoMergeNotifier) NotifyPullRequestSynchronized(doer *models.User, pr *models.PullRequest) {
+	m, err := models.GetPullAutoMergeByPullID(pr.ID)
+	if err != nil {
+		if !models.IsErrPullAutoMergeNotExist(err) {
+			log.Error("GetPullAutoMergeByPullID: %v", err)
+		}
+		return
+	}
+
+	// commits of other users have not been seen by the user who scheduled the merge
+	if doer.ID != m.DoerID {
+		if err := cancelAutoMerge(pr, m, doer, "New commits were pushed by another user."); err != nil {
+			log.Error("cancelAutoMerge: %v", err)
+		}
+		return
+	}
+	triggerAutoMerge(pr.ID)
+}
+
+func (*autoMergeNotifier) NotifyIssueChangeStatus(doer *models.User, issue *models.Issue, actionComment *models.Comment, isClosed bool) {
+	if !issue.IsPull || !isClosed {
+		return
+	}
+	if err := issue.LoadPullRequest(); err != nil {
+		log.Error("LoadPullRequest: %v", err)
+		return
+	}
+	triggerAutoMerge(issue.PullRequest.ID)
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAutoMergesByHeadSHA(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+
+	repo := unittest.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	for _, pullID := range []int64{1, 2} {
+		assert.NoError(t, models.InsertPullAutoMerge(&models.PullAutoMerge{RepoID: repo.ID, PullID: pullID, DoerID: 2, MergeStyle: models.MergeStyleMerge}))
+	}
+
+	// the head of pull request 2 is refs/pull/3/head
+	merges, err := getAutoMergesByHeadSHA(repo, "5f22f7d0d95d614d25a5b68592adb345a4b5c7fd")
+	assert.NoError(t, err)
+	if assert.Len(t, merges, 1) {
+		assert.EqualValues(t, 2, merges[0].PullID)
+	}
+
+	merges, err = getAutoMergesByHeadSHA(repo, "0000000000000000000000000000000000000000")
+	assert.NoError(t, err)
+	assert.Empty(t, merges)
+}
@@ -252,6 +252,27 @@ func CheckPrsForBaseBranch(baseRepo *models.Repository, baseBranchName string) e
 	return nil
 }
 
+// CheckPrsForProtectedBranch check all pulls whose base branch is protected by the rule
+func CheckPrsForProtectedBranch(baseRepo *models.Repository, protectBranch *models.ProtectedBranch) error {
+	if !protectBranch.IsRuleNamePattern() {
+		return CheckPrsForBaseBranch(baseRepo, protectBranch.BranchName)
+	}
+
+	branches, err := models.GetUnmergedPullRequestBaseBranches(baseRepo.ID)
+	if err != nil {
+		return err
+	}
+	for _, branch := range branches {
+		if !protectBranch.Match(branch) {
+			continue
+		}
+		if err := CheckPrsForBaseBranch(baseRepo, branch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Init runs the task queue to test all the checking status pull requests
 func Init() error {
 	prQueue = queue.CreateUniqueQueue("pr_patch_checker", handle, "")
@@ -262,5 +283,9 @@ func Init() error {
 
 	go graceful.GetManager().RunWithShutdownFns(prQueue.Run)
 	go graceful.GetManager().RunWithShutdownContext(InitializePullRequests)
-	return nil
+
+	if err := initMergeQueue(); err != nil {
+		return err
+	}
+	return initAutoMerge()
 }
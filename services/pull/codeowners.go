@@ -0,0 +1,395 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// codeOwnersFiles are the paths the CODEOWNERS file is looked up at, the first existing one is used
+var codeOwnersFiles = []string{"CODEOWNERS", "docs/CODEOWNERS", ".gitea/CODEOWNERS"}
+
+// codeOwnersMaxSize is the maximum size of a CODEOWNERS file which is read
+const codeOwnersMaxSize = 3 * 1024 * 1024
+
+// CodeOwnerRule assigns owners to the files matching a pattern of a CODEOWNERS file
+type CodeOwnerRule struct {
+	Pattern string
+	Owners  []string
+	regexp  *regexp.Regexp
+}
+
+// Match returns true if the path of a file matches the pattern of the rule
+func (rule *CodeOwnerRule) Match(path string) bool {
+	return rule.regexp.MatchString(path)
+}
+
+// ParseCodeOwners parses the content of a CODEOWNERS file.
+// Every line consists of a gitignore style pattern followed by the owners of the matching files,
+// invalid lines are skipped.
+func ParseCodeOwners(content string) []*CodeOwnerRule {
+	rules := make([]*CodeOwnerRule, 0, 10)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		owners := make([]string, 0, len(fields)-1)
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			owners = append(owners, owner)
+		}
+
+		re, err := codeOwnerPatternToRegexp(fields[0])
+		if err != nil {
+			log.Debug("Invalid CODEOWNERS pattern %q (skipped): %v", fields[0], err)
+			continue
+		}
+		rules = append(rules, &CodeOwnerRule{
+			Pattern: fields[0],
+			Owners:  owners,
+			regexp:  re,
+		})
+	}
+	return rules
+}
+
+// codeOwnerPatternToRegexp converts a gitignore style pattern to a regular expression matching file paths.
+// Patterns without a slash match at any depth, patterns matching a directory match all files below it.
+func codeOwnerPatternToRegexp(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var sb strings.Builder
+	if anchored {
+		sb.WriteString("^")
+	} else {
+		sb.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				if i+2 < len(pattern) && pattern[i+2] == '/' {
+					// "**/" matches zero or more directories
+					sb.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					sb.WriteString(".*")
+					i++
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	switch {
+	case dirOnly:
+		sb.WriteString("/.*$")
+	case strings.HasSuffix(pattern, "/*"):
+		// "docs/*" only matches the files directly in docs
+		sb.WriteString("$")
+	default:
+		sb.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(sb.String())
+}
+
+// MatchCodeOwnerRule returns the last rule matching the path, nil if no rule matches
+func MatchCodeOwnerRule(rules []*CodeOwnerRule, path string) *CodeOwnerRule {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].Match(path) {
+			return rules[i]
+		}
+	}
+	return nil
+}
+
+// CodeOwners are the users and teams owning a file
+type CodeOwners struct {
+	Users []*models.User
+	Teams []*models.Team
+}
+
+// IsEmpty returns true if nobody owns the file
+func (owners *CodeOwners) IsEmpty() bool {
+	return len(owners.Users) == 0 && len(owners.Teams) == 0
+}
+
+// GetCodeOwnerRules reads the rules of the CODEOWNERS file of the commit, it returns nil if there is none
+func GetCodeOwnerRules(commit *git.Commit) ([]*CodeOwnerRule, error) {
+	for _, path := range codeOwnersFiles {
+		blob, err := commit.GetBlobByPath(path)
+		if err != nil {
+			if git.IsErrNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		rd, err := blob.DataAsync()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(io.LimitReader(rd, codeOwnersMaxSize))
+		rd.Close()
+		if err != nil {
+			return nil, err
+		}
+		return ParseCodeOwners(string(content)), nil
+	}
+	return nil, nil
+}
+
+// resolveCodeOwners looks up the users and teams of the owners of a rule.
+// Teams have to belong to the owner of the repository, unknown owners are skipped.
+func resolveCodeOwners(repo *models.Repository, rule *CodeOwnerRule) (*CodeOwners, error) {
+	owners := &CodeOwners{}
+	for _, owner := range rule.Owners {
+		if !strings.HasPrefix(owner, "@") {
+			if !strings.Contains(owner, "@") {
+				continue
+			}
+			user, err := models.GetUserByEmail(owner)
+			if err != nil {
+				if models.IsErrUserNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			owners.Users = append(owners.Users, user)
+			continue
+		}
+
+		name := owner[1:]
+		if idx := strings.IndexByte(name, '/'); idx >= 0 {
+			if !strings.EqualFold(name[:idx], repo.OwnerName) {
+				continue
+			}
+			team, err := models.GetTeam(repo.OwnerID, name[idx+1:])
+			if err != nil {
+				if models.IsErrTeamNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			owners.Teams = append(owners.Teams, team)
+			continue
+		}
+
+		user, err := models.GetUserByName(name)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if user.IsOrganization() {
+			continue
+		}
+		owners.Users = append(owners.Users, user)
+	}
+	return owners, nil
+}
+
+// GetPullRequestCodeOwners returns the owners of every file changed by the pull request which has owners.
+// The CODEOWNERS file of the base branch is used.
+func GetPullRequestCodeOwners(pr *models.PullRequest) (map[string]*CodeOwners, error) {
+	if err := pr.LoadBaseRepo(); err != nil {
+		return nil, err
+	}
+
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetBranchCommit(pr.BaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := GetCodeOwnerRules(commit)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	files, err := gitRepo.GetFilesChangedBetween(pr.BaseBranch, pr.GetGitRefName())
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[*CodeOwnerRule]*CodeOwners)
+	fileOwners := make(map[string]*CodeOwners)
+	for _, file := range files {
+		rule := MatchCodeOwnerRule(rules, file)
+		if rule == nil {
+			continue
+		}
+		owners, ok := resolved[rule]
+		if !ok {
+			if owners, err = resolveCodeOwners(pr.BaseRepo, rule); err != nil {
+				return nil, err
+			}
+			resolved[rule] = owners
+		}
+		if !owners.IsEmpty() {
+			fileOwners[file] = owners
+		}
+	}
+	return fileOwners, nil
+}
+
+// requestCodeOwnerReviews requests reviews from the owners of the files changed by the pull request
+// who have not been requested or reviewed the pull request yet
+func requestCodeOwnerReviews(pr *models.PullRequest, doer *models.User) error {
+	fileOwners, err := GetPullRequestCodeOwners(pr)
+	if err != nil || len(fileOwners) == 0 {
+		return err
+	}
+	if err := pr.LoadIssue(); err != nil {
+		return err
+	}
+	if err := pr.Issue.LoadRepo(); err != nil {
+		return err
+	}
+
+	users := make(map[int64]*models.User)
+	teams := make(map[int64]*models.Team)
+	for _, owners := range fileOwners {
+		for _, user := range owners.Users {
+			users[user.ID] = user
+		}
+		for _, team := range owners.Teams {
+			teams[team.ID] = team
+		}
+	}
+
+	for _, user := range users {
+		if user.ID == pr.Issue.PosterID || user.ID == doer.ID {
+			continue
+		}
+		if _, err := models.GetReviewByIssueIDAndUserID(pr.IssueID, user.ID); err == nil {
+			continue
+		} else if !models.IsErrReviewNotExist(err) {
+			return err
+		}
+
+		perm, err := models.GetUserRepoPermission(pr.Issue.Repo, user)
+		if err != nil {
+			return err
+		}
+		if !perm.CanAccessAny(models.AccessModeRead, unit.TypePullRequests) {
+			continue
+		}
+		if _, err := issue_service.ReviewRequest(pr.Issue, doer, user, true); err != nil {
+			return err
+		}
+	}
+
+	for _, team := range teams {
+		if _, err := models.GetTeamReviewerByIssueIDAndTeamID(pr.IssueID, team.ID); err == nil {
+			continue
+		} else if !models.IsErrReviewNotExist(err) {
+			return err
+		}
+		if pr.Issue.Repo.IsPrivate && !models.HasTeamRepo(team.OrgID, team.ID, pr.Issue.RepoID) {
+			continue
+		}
+		if _, err := issue_service.TeamReviewRequest(pr.Issue, doer, team, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetUnapprovedCodeOwnerFiles returns the files changed by the pull request which are not approved by one of their owners
+func GetUnapprovedCodeOwnerFiles(pr *models.PullRequest) ([]string, error) {
+	fileOwners, err := GetPullRequestCodeOwners(pr)
+	if err != nil || len(fileOwners) == 0 {
+		return nil, err
+	}
+	if err := pr.LoadProtectedBranch(); err != nil {
+		return nil, err
+	}
+
+	reviews, err := models.GetReviewersByIssueID(pr.IssueID)
+	if err != nil {
+		return nil, err
+	}
+	approvers := make(map[int64]bool)
+	for _, review := range reviews {
+		if review.Type != models.ReviewTypeApprove || review.ReviewerID == 0 {
+			continue
+		}
+		if review.Stale && pr.ProtectedBranch != nil && pr.ProtectedBranch.DismissStaleApprovals {
+			continue
+		}
+		approvers[review.ReviewerID] = true
+	}
+
+	unapproved := make([]string, 0, len(fileOwners))
+	for file, owners := range fileOwners {
+		approved, err := isApprovedByCodeOwners(owners, approvers)
+		if err != nil {
+			return nil, err
+		}
+		if !approved {
+			unapproved = append(unapproved, file)
+		}
+	}
+	sort.Strings(unapproved)
+	return unapproved, nil
+}
+
+func isApprovedByCodeOwners(owners *CodeOwners, approvers map[int64]bool) (bool, error) {
+	for _, user := range owners.Users {
+		if approvers[user.ID] {
+			return true, nil
+		}
+	}
+	for _, team := range owners.Teams {
+		for approverID := range approvers {
+			isMember, err := models.IsTeamMember(team.OrgID, team.ID, approverID)
+			if err != nil {
+				return false, err
+			}
+			if isMember {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
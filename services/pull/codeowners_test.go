@@ -0,0 +1,72 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCodeOwners(t *testing.T) {
+	rules := ParseCodeOwners(`# comment
+*       @user1
+
+*.js    @user2 @org3/team1 # trailing comment
+/docs/  user4@example.com
+build/logs/ @user5
+apps/   @user6
+docs/*  @user7
+**/vendor @user8
+/src/**/test.go @user9
+/generated
+`)
+	if !assert.Len(t, rules, 9) {
+		return
+	}
+	assert.Equal(t, "*", rules[0].Pattern)
+	assert.Equal(t, []string{"@user1"}, rules[0].Owners)
+	assert.Equal(t, []string{"@user2", "@org3/team1"}, rules[1].Owners)
+	assert.Empty(t, rules[8].Owners)
+
+	owner := func(path string) string {
+		rule := MatchCodeOwnerRule(rules, path)
+		if rule == nil || len(rule.Owners) == 0 {
+			return ""
+		}
+		return rule.Owners[0]
+	}
+
+	assert.Equal(t, "@user1", owner("README.md"))
+	assert.Equal(t, "@user2", owner("web_src/index.js"))
+	assert.Equal(t, "@user7", owner("docs/README.md"))
+	assert.Equal(t, "user4@example.com", owner("docs/content/index.md"))
+	assert.Equal(t, "@user1", owner("sub/docs/README.md"))
+	assert.Equal(t, "@user5", owner("build/logs/1.txt"))
+	assert.Equal(t, "@user6", owner("apps/main.go"))
+	assert.Equal(t, "@user6", owner("services/apps/main.go"))
+	assert.Equal(t, "@user7", owner("docs/index.md"))
+	assert.Equal(t, "@user8", owner("vendor/modules.txt"))
+	assert.Equal(t, "@user8", owner("modules/vendor/lib/lib.go"))
+	assert.Equal(t, "@user9", owner("src/test.go"))
+	assert.Equal(t, "@user9", owner("src/a/b/test.go"))
+	assert.Equal(t, "@user1", owner("src/a/b/main.go"))
+	assert.Equal(t, "", owner("generated/file.go"))
+}
+
+func TestCodeOwnerPatternToRegexp(t *testing.T) {
+	re, err := codeOwnerPatternToRegexp("docs/*")
+	assert.NoError(t, err)
+	assert.True(t, re.MatchString("docs/index.md"))
+	assert.False(t, re.MatchString("docs/sub/index.md"))
+
+	re, err = codeOwnerPatternToRegexp("file?.txt")
+	assert.NoError(t, err)
+	assert.True(t, re.MatchString("dir/file1.txt"))
+	assert.False(t, re.MatchString("file10.txt"))
+
+	_, err = codeOwnerPatternToRegexp("/")
+	assert.Error(t, err)
+}
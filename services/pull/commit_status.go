@@ -135,5 +135,9 @@ func GetPullRequestCommitStatusState(pr *models.PullRequest) (structs.CommitStat
 		return "", errors.Wrap(err, "GetLatestCommitStatus")
 	}
 
-	return MergeRequiredContextsCommitStatus(commitStatuses, pr.ProtectedBranch.StatusCheckContexts), nil
+	var requiredContexts []string
+	if pr.ProtectedBranch != nil && pr.ProtectedBranch.EnableStatusCheck {
+		requiredContexts = pr.ProtectedBranch.StatusCheckContexts
+	}
+	return MergeRequiredContextsCommitStatus(commitStatuses, requiredContexts), nil
 }
@@ -60,6 +60,11 @@ func Merge(pr *models.PullRequest, doer *models.User, baseGitRepo *git.Repositor
 		return err
 	}
 
+	return finishMerge(pr, doer)
+}
+
+// finishMerge marks the pull request as merged as pr.MergedCommitID after it was pushed to the base branch
+func finishMerge(pr *models.PullRequest, doer *models.User) (err error) {
 	pr.MergedUnix = timeutil.TimeStampNow()
 	pr.Merger = doer
 	pr.MergerID = doer.ID
@@ -111,6 +116,12 @@ func Merge(pr *models.PullRequest, doer *models.User, baseGitRepo *git.Repositor
 
 // rawMerge perform the merge operation without changing any pull information in database
 func rawMerge(pr *models.PullRequest, doer *models.User, mergeStyle models.MergeStyle, message string) (string, error) {
+	return rawMergeOnto(pr, doer, mergeStyle, message, "", pr.BaseBranch)
+}
+
+// rawMergeOnto performs the merge on top of the commit onto instead of the base branch if onto is not empty
+// and pushes the result to the branch target of the base repository
+func rawMergeOnto(pr *models.PullRequest, doer *models.User, mergeStyle models.MergeStyle, message, onto, target string) (string, error) {
 	err := git.LoadGitVersion()
 	if err != nil {
 		log.Error("git.LoadGitVersion: %v", err)
@@ -135,6 +146,15 @@ func rawMerge(pr *models.PullRequest, doer *models.User, mergeStyle models.Merge
 
 	var outbuf, errbuf strings.Builder
 
+	if onto != "" {
+		if err := git.NewCommand("update-ref", git.BranchPrefix+baseBranch, onto).RunInDirPipeline(tmpBasePath, &outbuf, &errbuf); err != nil {
+			log.Error("git update-ref [%s -> %s]: %v\n%s\n%s", baseBranch, onto, err, outbuf.String(), errbuf.String())
+			return "", fmt.Errorf("git update-ref [%s -> %s]: %v\n%s\n%s", baseBranch, onto, err, outbuf.String(), errbuf.String())
+		}
+		outbuf.Reset()
+		errbuf.Reset()
+	}
+
 	// Enable sparse-checkout
 	sparseCheckoutList, err := getDiffTree(tmpBasePath, baseBranch, trackingBranch)
 	if err != nil {
@@ -421,6 +441,9 @@ func rawMerge(pr *models.PullRequest, doer *models.User, mergeStyle models.Merge
 	if mergeStyle == models.MergeStyleRebaseUpdate {
 		// force push the rebase result to head brach
 		pushCmd = git.NewCommand("push", "-f", "head_repo", stagingBranch+":refs/heads/"+pr.HeadBranch)
+	} else if target != pr.BaseBranch {
+		// the speculative merge commits of the merge queue are rebuilt whenever the queue changes
+		pushCmd = git.NewCommand("push", "-f", "origin", baseBranch+":refs/heads/"+target)
 	} else {
 		pushCmd = git.NewCommand("push", "origin", baseBranch+":refs/heads/"+pr.BaseBranch)
 	}
@@ -611,6 +634,17 @@ func CheckPRReadyToMerge(pr *models.PullRequest, skipProtectedFilesCheck bool) (
 			Reason: "There are official review requests",
 		}
 	}
+	if pr.ProtectedBranch.RequireCodeOwnerReviews {
+		unapproved, err := GetUnapprovedCodeOwnerFiles(pr)
+		if err != nil {
+			return err
+		}
+		if len(unapproved) > 0 {
+			return models.ErrNotAllowedToMerge{
+				Reason: "Not all changed files are approved by their code owners",
+			}
+		}
+	}
 
 	if pr.ProtectedBranch.MergeBlockedByOutdatedBranch(pr) {
 		return models.ErrNotAllowedToMerge{
@@ -0,0 +1,493 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/notification/base"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/sync"
+)
+
+// mergeQueue is the queue of the branches whose merge queue has to be processed
+var mergeQueue queue.UniqueQueue
+
+// mergeQueueWorkingPool makes sure the merge queue of a branch is only processed once at the same time
+var mergeQueueWorkingPool = sync.NewExclusivePool()
+
+func initMergeQueue() error {
+	mergeQueue = queue.CreateUniqueQueue("pr_merge_queue", handleMergeQueue, "")
+	if mergeQueue == nil {
+		return fmt.Errorf("Unable to create pr_merge_queue Queue")
+	}
+
+	go graceful.GetManager().RunWithShutdownFns(mergeQueue.Run)
+
+	notification.RegisterNotifier(&mergeQueueNotifier{})
+	return nil
+}
+
+func mergeQueueKey(repoID int64, branch string) string {
+	return strconv.FormatInt(repoID, 10) + ":" + branch
+}
+
+// triggerMergeQueue schedules the processing of the merge queue of the branch
+func triggerMergeQueue(repoID int64, branch string) {
+	if err := mergeQueue.Push(mergeQueueKey(repoID, branch)); err != nil && err != queue.ErrAlreadyInQueue {
+		log.Error("Unable to push merge queue of branch %s in repo %d to the queue: %v", branch, repoID, err)
+	}
+}
+
+func handleMergeQueue(data ...queue.Data) {
+	for _, datum := range data {
+		parts := strings.SplitN(datum.(string), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		repoID, _ := strconv.ParseInt(parts[0], 10, 64)
+		if err := processMergeQueue(repoID, parts[1]); err != nil {
+			log.Error("Processing the merge queue of branch %s in repo %d failed: %v", parts[1], repoID, err)
+		}
+	}
+}
+
+// AddToMergeQueue adds the pull request to the end of the merge queue of its base branch.
+// Caller should check PR is ready to be merged (review and status checks)
+func AddToMergeQueue(pr *models.PullRequest, doer *models.User, mergeStyle models.MergeStyle, message string) error {
+	if err := pr.LoadBaseRepo(); err != nil {
+		return fmt.Errorf("LoadBaseRepo: %v", err)
+	}
+	if err := pr.LoadIssue(); err != nil {
+		return fmt.Errorf("LoadIssue: %v", err)
+	}
+
+	prUnit, err := pr.BaseRepo.GetUnit(unit.TypePullRequests)
+	if err != nil {
+		return err
+	}
+	if mergeStyle == models.MergeStyleRebaseUpdate || !prUnit.PullRequestsConfig().IsMergeStyleAllowed(mergeStyle) {
+		return models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: mergeStyle}
+	}
+
+	headCommitID, err := getMergeQueueHeadCommitID(pr)
+	if err != nil {
+		return err
+	}
+
+	if err := models.InsertMergeQueueEntry(&models.MergeQueueEntry{
+		RepoID:       pr.BaseRepoID,
+		BaseBranch:   pr.BaseBranch,
+		PullID:       pr.ID,
+		DoerID:       doer.ID,
+		MergeStyle:   mergeStyle,
+		Message:      message,
+		HeadCommitID: headCommitID,
+	}); err != nil {
+		return err
+	}
+
+	if _, err := models.CreateComment(&models.CreateCommentOptions{
+		Type:  models.CommentTypeMergeQueueAdd,
+		Doer:  doer,
+		Repo:  pr.BaseRepo,
+		Issue: pr.Issue,
+	}); err != nil {
+		log.Error("CreateComment: %v", err)
+	}
+
+	triggerMergeQueue(pr.BaseRepoID, pr.BaseBranch)
+	return nil
+}
+
+// RemoveFromMergeQueue removes the pull request from the merge queue of its base branch
+func RemoveFromMergeQueue(pr *models.PullRequest, doer *models.User) error {
+	entry, err := models.GetMergeQueueEntryByPullID(pr.ID)
+	if err != nil {
+		return err
+	}
+	entry.Pull = pr
+
+	if err := removeMergeQueueEntry(entry, doer, ""); err != nil {
+		return err
+	}
+
+	// the speculative merge commits of the following entries have to be rebuilt
+	triggerMergeQueue(entry.RepoID, entry.BaseBranch)
+	return nil
+}
+
+// removeMergeQueueEntry deletes the entry and its branch and explains the reason in a comment
+func removeMergeQueueEntry(entry *models.MergeQueueEntry, doer *models.User, reason string) error {
+	if err := models.DeleteMergeQueueEntry(entry); err != nil {
+		return err
+	}
+	if err := deleteMergeQueueBranch(entry); err != nil {
+		log.Error("Unable to delete merge queue branch %s: %v", entry.BranchName(), err)
+	}
+
+	if err := entry.LoadPull(); err != nil {
+		return err
+	}
+	if entry.Pull.HasMerged {
+		return nil
+	}
+	if err := entry.Pull.LoadIssue(); err != nil {
+		return err
+	}
+	if err := entry.Pull.Issue.LoadRepo(); err != nil {
+		return err
+	}
+	_, err := models.CreateComment(&models.CreateCommentOptions{
+		Type:    models.CommentTypeMergeQueueRemove,
+		Doer:    doer,
+		Repo:    entry.Pull.Issue.Repo,
+		Issue:   entry.Pull.Issue,
+		Content: reason,
+	})
+	return err
+}
+
+// ejectMergeQueueEntry removes an entry which can not be merged from the merge queue
+func ejectMergeQueueEntry(entry *models.MergeQueueEntry, reason string) error {
+	log.Trace("Ejecting pull request %d from the merge queue of %s: %s", entry.PullID, entry.BaseBranch, reason)
+	doer := models.NewGhostUser()
+	if err := entry.LoadDoer(); err == nil {
+		doer = entry.Doer
+	} else if !models.IsErrUserNotExist(err) {
+		return err
+	}
+	return removeMergeQueueEntry(entry, doer, reason)
+}
+
+func deleteMergeQueueBranch(entry *models.MergeQueueEntry) error {
+	if entry.CommitID == "" {
+		return nil
+	}
+	repo, err := models.GetRepositoryByID(entry.RepoID)
+	if err != nil {
+		return err
+	}
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+
+	if !gitRepo.IsBranchExist(entry.BranchName()) {
+		return nil
+	}
+	return gitRepo.DeleteBranch(entry.BranchName(), git.DeleteBranchOptions{Force: true})
+}
+
+func getMergeQueueHeadCommitID(pr *models.PullRequest) (string, error) {
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return "", err
+	}
+	defer gitRepo.Close()
+	return gitRepo.GetRefCommitID(pr.GetGitRefName())
+}
+
+// checkMergeQueueEntry returns the reason why the entry can not be merged anymore
+func checkMergeQueueEntry(entry *models.MergeQueueEntry) (string, error) {
+	if err := entry.LoadPull(); err != nil {
+		return "", err
+	}
+	pr := entry.Pull
+	if err := pr.LoadIssue(); err != nil {
+		return "", err
+	}
+	if err := pr.LoadBaseRepo(); err != nil {
+		return "", err
+	}
+
+	switch {
+	case pr.HasMerged:
+		return "The pull request has been merged.", nil
+	case pr.Issue.IsClosed:
+		return "The pull request has been closed.", nil
+	case pr.BaseBranch != entry.BaseBranch:
+		return "The target branch of the pull request has been changed.", nil
+	}
+
+	headCommitID, err := getMergeQueueHeadCommitID(pr)
+	if err != nil {
+		return "", err
+	}
+	if headCommitID != entry.HeadCommitID {
+		return "The head branch of the pull request has been updated.", nil
+	}
+	return "", nil
+}
+
+// processMergeQueue merges the leading entries of the merge queue whose speculative merge commits
+// passed the required status checks and (re)builds the speculative merge commits of the other entries.
+// Every entry is merged on top of the entry ahead of it, so a change of an entry invalidates all following ones.
+func processMergeQueue(repoID int64, branch string) error {
+	key := mergeQueueKey(repoID, branch)
+	mergeQueueWorkingPool.CheckIn(key)
+	defer mergeQueueWorkingPool.CheckOut(key)
+
+	entries, err := models.GetMergeQueueEntries(repoID, branch)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	protectBranch, err := models.GetFirstMatchProtectedBranchRule(repoID, branch)
+	if err != nil {
+		return err
+	}
+	if protectBranch == nil || !protectBranch.EnableMergeQueue {
+		for _, entry := range entries {
+			if err := ejectMergeQueueEntry(entry, "The merge queue of the target branch has been disabled."); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	repo, err := models.GetRepositoryByID(repoID)
+	if err != nil {
+		return err
+	}
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return err
+	}
+	baseCommitID, err := gitRepo.GetBranchCommitID(branch)
+	gitRepo.Close()
+	if err != nil {
+		return err
+	}
+
+	// Merge the leading entries which passed their checks
+	for len(entries) > 0 {
+		entry := entries[0]
+		if reason, err := checkMergeQueueEntry(entry); err != nil {
+			return err
+		} else if reason != "" {
+			if err := ejectMergeQueueEntry(entry, reason); err != nil {
+				return err
+			}
+			entries = entries[1:]
+			continue
+		}
+
+		if entry.CommitID == "" || entry.BaseCommitID != baseCommitID {
+			break
+		}
+
+		state, err := getMergeQueueEntryStatusState(protectBranch, entry)
+		if err != nil {
+			return err
+		}
+		if state.IsPending() {
+			break
+		}
+		if !state.IsSuccess() {
+			if err := ejectMergeQueueEntry(entry, "The required status checks of the merge commit failed."); err != nil {
+				return err
+			}
+			entries = entries[1:]
+			continue
+		}
+
+		if err := mergeQueueEntry(entry); err != nil {
+			if git.IsErrPushOutOfDate(err) {
+				// the branch has been updated in the meantime, the queue is rebuilt on top of it
+				triggerMergeQueue(repoID, branch)
+				return nil
+			} else if git.IsErrPushRejected(err) {
+				if err := ejectMergeQueueEntry(entry, "The push of the merge commit was rejected."); err != nil {
+					return err
+				}
+				entries = entries[1:]
+				continue
+			}
+			return err
+		}
+		baseCommitID = entry.CommitID
+		entries = entries[1:]
+	}
+
+	// Build the speculative merge commits of the remaining entries
+	head := baseCommitID
+	for _, entry := range entries {
+		if entry.CommitID != "" && entry.BaseCommitID == head {
+			head = entry.CommitID
+			continue
+		}
+
+		if reason, err := checkMergeQueueEntry(entry); err != nil {
+			return err
+		} else if reason != "" {
+			if err := ejectMergeQueueEntry(entry, reason); err != nil {
+				return err
+			}
+			continue
+		}
+
+		commitID, err := buildMergeQueueEntry(entry, head)
+		if err != nil {
+			if models.IsErrMergeConflicts(err) || models.IsErrRebaseConflicts(err) || models.IsErrMergeUnrelatedHistories(err) {
+				if err := ejectMergeQueueEntry(entry, "The pull request conflicts with the pull requests ahead of it in the merge queue."); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+
+		entry.BaseCommitID = head
+		entry.CommitID = commitID
+		if err := models.UpdateMergeQueueEntryCols(entry, "base_commit_id", "commit_id"); err != nil {
+			return err
+		}
+		head = commitID
+	}
+	return nil
+}
+
+// buildMergeQueueEntry creates the speculative merge commit of the entry on top of the commit onto
+func buildMergeQueueEntry(entry *models.MergeQueueEntry, onto string) (string, error) {
+	if err := entry.LoadDoer(); err != nil {
+		return "", err
+	}
+	log.Trace("Building merge queue commit of pull request %d on top of %s", entry.PullID, onto)
+	return rawMergeOnto(entry.Pull, entry.Doer, entry.MergeStyle, entry.Message, onto, entry.BranchName())
+}
+
+// getMergeQueueEntryStatusState returns the combined state of the required status checks of the speculative merge commit
+func getMergeQueueEntryStatusState(protectBranch *models.ProtectedBranch, entry *models.MergeQueueEntry) (structs.CommitStatusState, error) {
+	if !protectBranch.EnableStatusCheck {
+		return structs.CommitStatusSuccess, nil
+	}
+	commitStatuses, err := models.GetLatestCommitStatus(entry.RepoID, entry.CommitID, db.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	return MergeRequiredContextsCommitStatus(commitStatuses, protectBranch.StatusCheckContexts), nil
+}
+
+// mergeQueueEntry fast-forwards the base branch to the speculative merge commit of the entry
+func mergeQueueEntry(entry *models.MergeQueueEntry) error {
+	if err := entry.LoadDoer(); err != nil {
+		return err
+	}
+	pr := entry.Pull
+	if err := pr.LoadHeadRepo(); err != nil {
+		return err
+	}
+
+	headUser := entry.Doer
+	if pr.HeadRepo != nil {
+		if err := pr.HeadRepo.GetOwner(); err != nil {
+			log.Error("Can't find user: %d for head repository - defaulting to doer: %s - %v", pr.HeadRepo.OwnerID, entry.Doer.Name, err)
+		} else {
+			headUser = pr.HeadRepo.Owner
+		}
+	}
+
+	env := models.FullPushingEnvironment(
+		headUser,
+		entry.Doer,
+		pr.BaseRepo,
+		pr.BaseRepo.Name,
+		pr.ID,
+	)
+
+	var outbuf, errbuf strings.Builder
+	if err := git.NewCommand("push", ".", entry.CommitID+":"+git.BranchPrefix+pr.BaseBranch).RunInDirTimeoutEnvPipeline(env, -1, pr.BaseRepo.RepoPath(), &outbuf, &errbuf); err != nil {
+		if strings.Contains(errbuf.String(), "non-fast-forward") {
+			return &git.ErrPushOutOfDate{
+				StdOut: outbuf.String(),
+				StdErr: errbuf.String(),
+				Err:    err,
+			}
+		} else if strings.Contains(errbuf.String(), "! [remote rejected]") {
+			err := &git.ErrPushRejected{
+				StdOut: outbuf.String(),
+				StdErr: errbuf.String(),
+				Err:    err,
+			}
+			err.GenerateMessage()
+			return err
+		}
+		return fmt.Errorf("git push: %s", errbuf.String())
+	}
+
+	if err := models.DeleteMergeQueueEntry(entry); err != nil {
+		return err
+	}
+	if err := deleteMergeQueueBranch(entry); err != nil {
+		log.Error("Unable to delete merge queue branch %s: %v", entry.BranchName(), err)
+	}
+
+	defer func() {
+		go AddTestPullRequestTask(entry.Doer, pr.BaseRepo.ID, pr.BaseBranch, false, "", "")
+	}()
+
+	pr.MergedCommitID = entry.CommitID
+	return finishMerge(pr, entry.Doer)
+}
+
+// mergeQueueNotifier processes the merge queues affected by new commit statuses and pushes
+type mergeQueueNotifier struct {
+	base.NullNotifier
+}
+
+var _ base.Notifier = &mergeQueueNotifier{}
+
+func (*mergeQueueNotifier) NotifyCreateCommitStatus(repo *models.Repository, sha string, creator *models.User, status *models.CommitStatus) {
+	entries, err := models.GetMergeQueueEntriesByCommitID(repo.ID, sha)
+	if err != nil {
+		log.Error("GetMergeQueueEntriesByCommitID: %v", err)
+		return
+	}
+	for _, entry := range entries {
+		triggerMergeQueue(entry.RepoID, entry.BaseBranch)
+	}
+}
+
+func (*mergeQueueNotifier) NotifyPushCommits(pusher *models.User, repo *models.Repository, opts *repository.PushUpdateOptions, commits *repository.PushCommits) {
+	if !opts.IsBranch() || strings.HasPrefix(opts.BranchName(), models.MergeQueueBranchPrefix) {
+		return
+	}
+	triggerMergeQueue(repo.ID, opts.BranchName())
+}
+
+func (*mergeQueueNotifier) NotifyPullRequestSynchronized(doer *models.User, pr *models.PullRequest) {
+	triggerMergeQueue(pr.BaseRepoID, pr.BaseBranch)
+}
+
+func (*mergeQueueNotifier) NotifyPullRequestChangeTargetBranch(doer *models.User, pr *models.PullRequest, oldBranch string) {
+	triggerMergeQueue(pr.BaseRepoID, oldBranch)
+}
+
+func (*mergeQueueNotifier) NotifyIssueChangeStatus(doer *models.User, issue *models.Issue, actionComment *models.Comment, isClosed bool) {
+	if !issue.IsPull || !isClosed {
+		return
+	}
+	if err := issue.LoadPullRequest(); err != nil {
+		log.Error("LoadPullRequest: %v", err)
+		return
+	}
+	triggerMergeQueue(issue.PullRequest.BaseRepoID, issue.PullRequest.BaseBranch)
+}
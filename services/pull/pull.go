@@ -65,6 +65,9 @@ func NewPullRequest(repo *models.Repository, pull *models.Issue, labelIDs []int6
 	}
 
 	notification.NotifyNewPullRequest(pr, mentions)
+	if err := requestCodeOwnerReviews(pr, pull.Poster); err != nil {
+		log.Error("requestCodeOwnerReviews: %v", err)
+	}
 	if len(pull.Labels) > 0 {
 		notification.NotifyIssueChangeLabels(pull.Poster, pull, pull.Labels, nil)
 	}
@@ -301,6 +304,11 @@ func AddTestPullRequestTask(doer *models.User, repoID int64, branch string, isSy
 			if err == nil && comment != nil {
 				notification.NotifyPullRequestPushCommits(doer, pr, comment)
 			}
+			if isSync {
+				if err := requestCodeOwnerReviews(pr, doer); err != nil {
+					log.Error("requestCodeOwnerReviews: %v", err)
+				}
+			}
 		}
 
 		log.Trace("AddTestPullRequestTask [base_repo_id: %d, base_branch: %s]: finding pull requests", repoID, branch)
@@ -34,7 +34,7 @@ func CacheRef(ctx context.Context, repo *models.Repository, gitRepo *git.Reposit
 		return err
 	}
 
-	commitsCount, err := cache.GetInt64(repo.GetCommitsCountCacheKey(getRefName(fullRefName), true), commit.CommitsCount)
+	commitsCount, err := cache.GetInt64(ctx, repo.GetCommitsCountCacheKey(getRefName(fullRefName), true), commit.CommitsCount)
 	if err != nil {
 		return err
 	}
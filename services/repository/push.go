@@ -51,11 +51,11 @@ func initPushQueue() error {
 
 // PushUpdate is an alias of PushUpdates for single push update options
 func PushUpdate(opts *repo_module.PushUpdateOptions) error {
-	return PushUpdates([]*repo_module.PushUpdateOptions{opts})
+	return PushUpdates(db.DefaultContext, []*repo_module.PushUpdateOptions{opts})
 }
 
-// PushUpdates adds a push update to push queue
-func PushUpdates(opts []*repo_module.PushUpdateOptions) error {
+// PushUpdates adds a push update to push queue, the update is handled in the trace of the context
+func PushUpdates(ctx context.Context, opts []*repo_module.PushUpdateOptions) error {
 	if len(opts) == 0 {
 		return nil
 	}
@@ -66,7 +66,7 @@ func PushUpdates(opts []*repo_module.PushUpdateOptions) error {
 		}
 	}
 
-	return pushQueue.Push(opts)
+	return queue.PushContext(ctx, pushQueue, opts)
 }
 
 // pushUpdates generates push action history feeds for push updating multiple refs
@@ -0,0 +1,125 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/process"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/services/migrations"
+)
+
+// ExportRepository adds a task exporting the repository to an archive. Only the archive of
+// the latest export is kept, so a running export is returned instead of starting a new one.
+func ExportRepository(doer *models.User, repo *models.Repository) (*models.Task, error) {
+	tasks, err := models.FindTasks(models.FindTaskOptions{
+		Status: -1,
+		Type:   int(structs.TaskTypeExportRepo),
+		RepoID: repo.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		if task.Status == structs.TaskStatusQueue || task.Status == structs.TaskStatusRunning {
+			return task, nil
+		}
+	}
+
+	for _, task := range tasks {
+		if err := storage.RepoExports.Delete(task.ArchiveRelativePath()); err != nil {
+			log.Warn("Unable to delete export archive %s: %v", task.ArchiveRelativePath(), err)
+		}
+		if err := models.DeleteTask(task); err != nil {
+			return nil, err
+		}
+	}
+
+	var task = &models.Task{
+		DoerID:  doer.ID,
+		OwnerID: repo.OwnerID,
+		RepoID:  repo.ID,
+		Type:    structs.TaskTypeExportRepo,
+		Status:  structs.TaskStatusQueue,
+	}
+	if err := models.CreateTask(task); err != nil {
+		return nil, err
+	}
+
+	return task, taskQueue.Push(task)
+}
+
+// updateTaskMessage returns a messenger storing the progress messages in the task
+func updateTaskMessage(t *models.Task) func(format string, args ...interface{}) {
+	return func(format string, args ...interface{}) {
+		message := models.TranslatableMessage{
+			Format: format,
+			Args:   args,
+		}
+		bs, _ := json.Marshal(message)
+		t.Message = string(bs)
+		_ = t.UpdateCols("message")
+	}
+}
+
+func runExportTask(t *models.Task) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("PANIC whilst trying to do export task: %v", e)
+			log.Critical("PANIC during runExportTask[%d] by DoerID[%d] of RepoID[%d]: %v\nStacktrace: %v", t.ID, t.DoerID, t.RepoID, e, log.Stack(2))
+		}
+
+		t.EndTime = timeutil.TimeStampNow()
+		if err == nil {
+			t.Status = structs.TaskStatusFinished
+			if err := t.UpdateCols("status", "end_time"); err != nil {
+				log.Error("Task UpdateCols failed: %v", err)
+			}
+			return
+		}
+
+		t.Status = structs.TaskStatusFailed
+		t.Message = err.Error()
+		if err := t.UpdateCols("status", "message", "end_time"); err != nil {
+			log.Error("Task UpdateCols failed: %v", err)
+		}
+		if err := storage.RepoExports.Delete(t.ArchiveRelativePath()); err != nil {
+			log.Warn("Unable to delete export archive %s: %v", t.ArchiveRelativePath(), err)
+		}
+	}()
+
+	if err = t.LoadRepo(); err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(graceful.GetManager().ShutdownContext())
+	defer cancel()
+	pm := process.GetManager()
+	pid := pm.Add(fmt.Sprintf("ExportTask: %s", t.Repo.FullName()), cancel)
+	defer pm.Remove(pid)
+
+	t.StartTime = timeutil.TimeStampNow()
+	t.Status = structs.TaskStatusRunning
+	if err = t.UpdateCols("start_time", "status"); err != nil {
+		return
+	}
+
+	err = storage.SaveFrom(storage.RepoExports, t.ArchiveRelativePath(), func(w io.Writer) error {
+		return migrations.ExportRepository(ctx, t.Repo, w, updateTaskMessage(t))
+	})
+	if err == nil {
+		log.Trace("Repository exported [%d]: %s", t.Repo.ID, t.Repo.FullName())
+	}
+	return
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package task
+
+import (
+	"context"
+	"io"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	base "code.gitea.io/gitea/modules/migration"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/services/migrations"
+)
+
+// ImportRepository adds a task importing a repository from an archive created by an export.
+// The archive is kept in storage until the task has been run.
+func ImportRepository(doer, u *models.User, opts base.MigrateOptions, archive io.Reader, size int64) (*models.Task, error) {
+	if size > migrations.ImportMaxSize() {
+		return nil, migrations.ErrImportArchiveTooLarge{
+			MaxSize:  migrations.ImportMaxSize(),
+			MaxFiles: setting.Repository.Import.MaxFiles,
+		}
+	}
+
+	// the import is displayed like a migration, so the clone address names the archive
+	opts.OriginalURL = ""
+	opts.Mirror = false
+	opts.LFS = false
+	opts.AuthUsername = ""
+	opts.AuthPassword = ""
+	opts.AuthToken = ""
+	bs, err := json.Marshal(&opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var task = &models.Task{
+		DoerID:         doer.ID,
+		OwnerID:        u.ID,
+		Type:           structs.TaskTypeImportRepo,
+		Status:         structs.TaskStatusQueue,
+		PayloadContent: string(bs),
+	}
+	if err := models.CreateTask(task); err != nil {
+		return nil, err
+	}
+
+	if _, err := storage.RepoExports.Save(task.ArchiveRelativePath(), archive, size); err != nil {
+		task.EndTime = timeutil.TimeStampNow()
+		task.Status = structs.TaskStatusFailed
+		if err2 := task.UpdateCols("end_time", "status"); err2 != nil {
+			log.Error("UpdateCols Failed: %v", err2.Error())
+		}
+		return nil, err
+	}
+
+	if err := createMigratingRepository(doer, u, task, opts); err != nil {
+		if err2 := storage.RepoExports.Delete(task.ArchiveRelativePath()); err2 != nil {
+			log.Warn("Unable to delete import archive %s: %v", task.ArchiveRelativePath(), err2)
+		}
+		return nil, err
+	}
+
+	return task, taskQueue.Push(task)
+}
+
+// importRepository restores the repository of an import task from its archive
+func importRepository(ctx context.Context, t *models.Task, opts base.MigrateOptions, messenger base.Messenger) (*models.Repository, error) {
+	defer func() {
+		if err := storage.RepoExports.Delete(t.ArchiveRelativePath()); err != nil {
+			log.Warn("Unable to delete import archive %s: %v", t.ArchiveRelativePath(), err)
+		}
+	}()
+
+	archive, err := storage.RepoExports.Open(t.ArchiveRelativePath())
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	return migrations.ImportRepository(ctx, t.Doer, t.Owner, archive, opts, messenger)
+}
@@ -12,7 +12,6 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/graceful"
-	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/migration"
 	"code.gitea.io/gitea/modules/notification"
@@ -109,15 +108,11 @@ func runMigrateTask(t *models.Task) (err error) {
 		return
 	}
 
-	t.Repo, err = migrations.MigrateRepository(ctx, t.Doer, t.Owner.Name, *opts, func(format string, args ...interface{}) {
-		message := models.TranslatableMessage{
-			Format: format,
-			Args:   args,
-		}
-		bs, _ := json.Marshal(message)
-		t.Message = string(bs)
-		_ = t.UpdateCols("message")
-	})
+	if t.Type == structs.TaskTypeImportRepo {
+		t.Repo, err = importRepository(ctx, t, *opts, updateTaskMessage(t))
+	} else {
+		t.Repo, err = migrations.MigrateRepository(ctx, t.Doer, t.Owner.Name, *opts, updateTaskMessage(t))
+	}
 	if err == nil {
 		log.Trace("Repository migrated [%d]: %s/%s", t.Repo.ID, t.Owner.Name, t.Repo.Name)
 		return
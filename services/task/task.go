@@ -27,8 +27,10 @@ var taskQueue queue.Queue
 // Run a task
 func Run(t *models.Task) error {
 	switch t.Type {
-	case structs.TaskTypeMigrateRepo:
+	case structs.TaskTypeMigrateRepo, structs.TaskTypeImportRepo:
 		return runMigrateTask(t)
+	case structs.TaskTypeExportRepo:
+		return runExportTask(t)
 	default:
 		return fmt.Errorf("Unknown task type: %d", t.Type)
 	}
@@ -102,6 +104,14 @@ func CreateMigrateTask(doer, u *models.User, opts base.MigrateOptions) (*models.
 		return nil, err
 	}
 
+	if err := createMigratingRepository(doer, u, task, opts); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// createMigratingRepository creates the repository a migrate or import task is run into
+func createMigratingRepository(doer, u *models.User, task *models.Task, opts base.MigrateOptions) error {
 	repo, err := repo_module.CreateRepository(doer, u, models.CreateRepoOptions{
 		Name:           opts.RepoName,
 		Description:    opts.Description,
@@ -118,13 +128,9 @@ func CreateMigrateTask(doer, u *models.User, opts base.MigrateOptions) (*models.
 		if err2 != nil {
 			log.Error("UpdateCols Failed: %v", err2.Error())
 		}
-		return nil, err
+		return err
 	}
 
 	task.RepoID = repo.ID
-	if err = task.UpdateCols("repo_id"); err != nil {
-		return nil, err
-	}
-
-	return task, nil
+	return task.UpdateCols("repo_id")
 }
@@ -26,6 +26,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/proxy"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
 
 	"github.com/gobwas/glob"
 )
@@ -46,8 +47,6 @@ func Deliver(t *webhook_model.HookTask) error {
 		log.Error("PANIC whilst trying to deliver webhook[%d] for repo[%d] to %s Panic: %v\nStacktrace: %s", t.ID, t.RepoID, w.URL, err, log.Stack(2))
 	}()
 
-	t.IsDelivered = true
-
 	var req *http.Request
 
 	switch w.HTTPMethod {
@@ -144,11 +143,36 @@ func Deliver(t *webhook_model.HookTask) error {
 		Headers: map[string]string{},
 	}
 
+	// Connection errors are retried, as well as some response statuses.
+	retryable := true
+	skipped := false
+	retryAfter := ""
+	start := time.Now()
 	defer func() {
-		t.Delivered = time.Now().UnixNano()
-		if t.IsSucceed {
+		now := time.Now()
+		t.Delivered = now.UnixNano()
+		t.IsDelivered = true
+		if !skipped {
+			observeDelivery(w.Type, start, t.IsSucceed)
+			attempt := &webhook_model.HookAttempt{
+				Delivered: t.Delivered,
+				Status:    t.ResponseInfo.Status,
+			}
+			if attempt.Status == 0 {
+				attempt.Error = t.ResponseInfo.Body
+			}
+			t.AddAttempt(attempt)
+		}
+
+		switch {
+		case t.IsSucceed:
 			log.Trace("Hook delivered: %s", t.UUID)
-		} else {
+		case !skipped && retryable && w.IsActive && t.Attempts < maxAttempts(w):
+			delay := retryDelay(t.Attempts, retryAfter, now)
+			t.IsDelivered = false
+			t.NextAttemptUnix = timeutil.TimeStamp(now.Add(delay).Unix())
+			log.Trace("Hook delivery failed: %s, retrying in %v", t.UUID, delay)
+		default:
 			log.Trace("Hook delivery failed: %s", t.UUID)
 		}
 
@@ -156,19 +180,29 @@ func Deliver(t *webhook_model.HookTask) error {
 			log.Error("UpdateHookTask [%d]: %v", t.ID, err)
 		}
 
-		// Update webhook last delivery status.
+		// Update webhook last delivery status, a hook task which won't be attempted again counts
+		// as a failure of the webhook.
+		w.LastStatus = webhook_model.HookStatusFail
 		if t.IsSucceed {
 			w.LastStatus = webhook_model.HookStatusSucceed
-		} else {
-			w.LastStatus = webhook_model.HookStatusFail
 		}
-		if err = webhook_model.UpdateWebhookLastStatus(w); err != nil {
+		failed := !t.IsSucceed && t.IsDelivered && !skipped
+		if err = webhook_model.UpdateWebhookLastStatus(w.ID, w.LastStatus, failed); err != nil {
 			log.Error("UpdateWebhookLastStatus: %v", err)
 			return
 		}
+		if !failed {
+			return
+		}
+		if disabled, err := disableAfterFailures(w); err != nil {
+			log.Error("DisableWebhookAfterFailures [%d]: %v", w.ID, err)
+		} else if disabled {
+			notifyWebhookDisabled(w)
+		}
 	}()
 
 	if setting.DisableWebhooks {
+		skipped = true
 		return fmt.Errorf("webhook task skipped (webhooks disabled): [%d]", t.ID)
 	}
 
@@ -182,6 +216,8 @@ func Deliver(t *webhook_model.HookTask) error {
 	// Status code is 20x can be seen as succeed.
 	t.IsSucceed = resp.StatusCode/100 == 2
 	t.ResponseInfo.Status = resp.StatusCode
+	retryable = isRetryableStatus(resp.StatusCode)
+	retryAfter = resp.Header.Get("Retry-After")
 	for k, vals := range resp.Header {
 		t.ResponseInfo.Headers[k] = strings.Join(vals, ",")
 	}
@@ -195,7 +231,8 @@ func Deliver(t *webhook_model.HookTask) error {
 	return nil
 }
 
-// DeliverHooks checks and delivers undelivered hooks.
+// DeliverHooks checks and delivers undelivered hooks, then delivers the new hooks and retries
+// the failed deliveries when they are due.
 // FIXME: graceful: This would likely benefit from either a worker pool with dummy queue
 // or a full queue. Then more hooks could be sent at same time.
 func DeliverHooks(ctx context.Context) {
@@ -211,23 +248,28 @@ func DeliverHooks(ctx context.Context) {
 	}
 
 	// Update hook task status.
-	for _, t := range tasks {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-		}
-		if err = Deliver(t); err != nil {
-			log.Error("deliver: %v", err)
-		}
+	if !deliverTasks(ctx, tasks) {
+		return
 	}
 
+	ticker := time.NewTicker(retryCheckInterval)
+	defer ticker.Stop()
+
 	// Start listening on new hook requests.
 	for {
 		select {
 		case <-ctx.Done():
 			hookQueue.Close()
 			return
+		case <-ticker.C:
+			tasks, err := webhook_model.FindUndeliveredHookTasks()
+			if err != nil {
+				log.Error("Get undelivered hook tasks: %v", err)
+				continue
+			}
+			if !deliverTasks(ctx, tasks) {
+				return
+			}
 		case repoIDStr := <-hookQueue.Queue():
 			log.Trace("DeliverHooks [repo_id: %v]", repoIDStr)
 			hookQueue.Remove(repoIDStr)
@@ -243,21 +285,29 @@ func DeliverHooks(ctx context.Context) {
 				log.Error("Get repository [%d] hook tasks: %v", repoID, err)
 				continue
 			}
-			for _, t := range tasks {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-				}
-				if err = Deliver(t); err != nil {
-					log.Error("deliver: %v", err)
-				}
+			if !deliverTasks(ctx, tasks) {
+				return
 			}
 		}
 	}
 
 }
 
+// deliverTasks delivers hook tasks, it returns false if it has been cancelled
+func deliverTasks(ctx context.Context, tasks []*webhook_model.HookTask) bool {
+	for _, t := range tasks {
+		select {
+		case <-ctx.Done():
+			return false
+		default:
+		}
+		if err := Deliver(t); err != nil {
+			log.Error("deliver: %v", err)
+		}
+	}
+	return true
+}
+
 var (
 	webhookHTTPClient *http.Client
 	once              sync.Once
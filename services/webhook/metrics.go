@@ -0,0 +1,44 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"time"
+
+	webhook_model "code.gitea.io/gitea/models/webhook"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	deliveryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "gitea",
+		Subsystem: "webhook",
+		Name:      "delivery_duration_seconds",
+		Help:      "Duration of the webhook deliveries by hook type",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"type"})
+
+	deliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gitea",
+		Subsystem: "webhook",
+		Name:      "deliveries_total",
+		Help:      "Number of webhook delivery attempts by hook type and result, success or failure",
+	}, []string{"type", "result"})
+)
+
+func init() {
+	prometheus.MustRegister(deliveryDuration, deliveries)
+}
+
+// observeDelivery records the duration and the result of a delivery attempt
+func observeDelivery(hookType webhook_model.HookType, start time.Time, succeed bool) {
+	deliveryDuration.WithLabelValues(hookType).Observe(time.Since(start).Seconds())
+	result := "failure"
+	if succeed {
+		result = "success"
+	}
+	deliveries.WithLabelValues(hookType, result).Inc()
+}
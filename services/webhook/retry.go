@@ -0,0 +1,141 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	webhook_model "code.gitea.io/gitea/models/webhook"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/mailer"
+)
+
+// retryCheckInterval is the interval between the checks for the failed deliveries to retry
+const retryCheckInterval = 10 * time.Second
+
+// maxAttempts returns the maximum number of attempts to deliver a hook task of a webhook
+func maxAttempts(w *webhook_model.Webhook) int {
+	if w.MaxAttempts > 0 {
+		return w.MaxAttempts
+	}
+	return setting.Webhook.MaxAttempts
+}
+
+// isRetryableStatus returns true if a delivery which got a response with this status should be
+// retried
+func isRetryableStatus(status int) bool {
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status/100 == 5
+}
+
+// retryDelay returns the delay before retrying a delivery after its failed attempts: the base
+// delay doubled after each attempt, or the delay asked by the Retry-After header of the last
+// response if it is longer, up to the maximum delay.
+func retryDelay(attempts int, retryAfter string, now time.Time) time.Duration {
+	delay := setting.Webhook.RetryBaseDelay
+	for i := 1; i < attempts && delay < setting.Webhook.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+
+	var asked time.Duration
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		asked = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(retryAfter); err == nil {
+		asked = date.Sub(now)
+	}
+	if asked > delay {
+		delay = asked
+	}
+
+	if delay > setting.Webhook.RetryMaxDelay {
+		delay = setting.Webhook.RetryMaxDelay
+	}
+	return delay
+}
+
+// disableAfterFailures disables the webhook if too many hook tasks failed in a row after all their
+// attempts, it returns true if the webhook has been disabled.
+func disableAfterFailures(w *webhook_model.Webhook) (bool, error) {
+	if setting.Webhook.DisableAfterFailures <= 0 {
+		return false, nil
+	}
+	disabled, err := webhook_model.DisableWebhookAfterFailures(w.ID, setting.Webhook.DisableAfterFailures)
+	if err != nil || !disabled {
+		return false, err
+	}
+	log.Warn("Webhook [%d] to %s disabled after %d failed deliveries in a row", w.ID, w.URL, setting.Webhook.DisableAfterFailures)
+	w.IsActive = false
+	w.ConsecutiveFailures = 0
+	return true, nil
+}
+
+// notifyWebhookDisabled notifies the owners of a webhook that it has been disabled
+func notifyWebhookDisabled(w *webhook_model.Webhook) {
+	owners, link, err := webhookOwners(w)
+	if err != nil {
+		log.Error("webhookOwners [%d]: %v", w.ID, err)
+		return
+	}
+	mailer.SendWebhookDisabledMail(w, owners, link, setting.Webhook.DisableAfterFailures)
+}
+
+// webhookOwners returns the users who can manage a webhook and the link to its settings
+func webhookOwners(w *webhook_model.Webhook) ([]*models.User, string, error) {
+	switch {
+	case w.RepoID > 0:
+		repo, err := models.GetRepositoryByID(w.RepoID)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := repo.GetOwner(); err != nil {
+			return nil, "", err
+		}
+		link := fmt.Sprintf("%s/settings/hooks/%d", repo.HTMLURL(), w.ID)
+		if !repo.Owner.IsOrganization() {
+			return []*models.User{repo.Owner}, link, nil
+		}
+		owners, err := organizationOwners(repo.Owner)
+		return owners, link, err
+	case w.OrgID > 0:
+		org, err := models.GetUserByID(w.OrgID)
+		if err != nil {
+			return nil, "", err
+		}
+		owners, err := organizationOwners(org)
+		return owners, fmt.Sprintf("%s/settings/hooks/%d", org.HTMLURL(), w.ID), err
+	default:
+		admins, err := models.GetActiveAdminUsers()
+		return admins, fmt.Sprintf("%sadmin/hooks/%d", setting.AppURL, w.ID), err
+	}
+}
+
+func organizationOwners(org *models.User) ([]*models.User, error) {
+	team, err := models.OrgFromUser(org).GetOwnerTeam()
+	if err != nil {
+		return nil, err
+	}
+	return models.GetTeamMembers(team.ID)
+}
+
+// Redeliver delivers again the failed hook tasks of a webhook, only those with the given IDs if
+// any. It returns the hook tasks to deliver.
+func Redeliver(w *webhook_model.Webhook, ids []int64) ([]*webhook_model.HookTask, error) {
+	tasks, err := webhook_model.RedeliverHookTasks(w.ID, ids)
+	if err != nil {
+		return nil, err
+	}
+	repoIDs := make(map[int64]bool, len(tasks))
+	for _, t := range tasks {
+		if !repoIDs[t.RepoID] {
+			repoIDs[t.RepoID] = true
+			go hookQueue.Add(t.RepoID)
+		}
+	}
+	return tasks, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models/unittest"
+	webhook_model "code.gitea.io/gitea/models/webhook"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryableStatus(t *testing.T) {
+	for _, status := range []int{http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		assert.True(t, isRetryableStatus(status), status)
+	}
+	for _, status := range []int{http.StatusOK, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound} {
+		assert.False(t, isRetryableStatus(status), status)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	oldBase, oldMax := setting.Webhook.RetryBaseDelay, setting.Webhook.RetryMaxDelay
+	setting.Webhook.RetryBaseDelay = 30 * time.Second
+	setting.Webhook.RetryMaxDelay = time.Hour
+	defer func() {
+		setting.Webhook.RetryBaseDelay, setting.Webhook.RetryMaxDelay = oldBase, oldMax
+	}()
+
+	now := time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC)
+	kases := []struct {
+		attempts   int
+		retryAfter string
+		expected   time.Duration
+	}{
+		{1, "", 30 * time.Second},
+		{2, "", time.Minute},
+		{4, "", 4 * time.Minute},
+		{20, "", time.Hour},
+		{1, "120", 2 * time.Minute},
+		{4, "120", 4 * time.Minute},
+		{1, "Wed, 01 Dec 2021 10:05:00 GMT", 5 * time.Minute},
+		{1, "Wed, 01 Dec 2021 12:00:00 GMT", time.Hour},
+		{1, "invalid", 30 * time.Second},
+	}
+	for _, kase := range kases {
+		assert.Equal(t, kase.expected, retryDelay(kase.attempts, kase.retryAfter, now), "%d attempts, Retry-After: %q", kase.attempts, kase.retryAfter)
+	}
+}
+
+func TestDisableAfterFailures(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	oldFailures := setting.Webhook.DisableAfterFailures
+	setting.Webhook.DisableAfterFailures = 2
+	defer func() {
+		setting.Webhook.DisableAfterFailures = oldFailures
+	}()
+
+	w := unittest.AssertExistsAndLoadBean(t, &webhook_model.Webhook{ID: 1}).(*webhook_model.Webhook)
+	assert.NoError(t, webhook_model.UpdateWebhookLastStatus(w.ID, webhook_model.HookStatusFail, true))
+	disabled, err := disableAfterFailures(w)
+	assert.NoError(t, err)
+	assert.False(t, disabled)
+
+	assert.NoError(t, webhook_model.UpdateWebhookLastStatus(w.ID, webhook_model.HookStatusFail, true))
+	disabled, err = disableAfterFailures(w)
+	assert.NoError(t, err)
+	assert.True(t, disabled)
+	assert.False(t, w.IsActive)
+	unittest.AssertExistsAndLoadBean(t, &webhook_model.Webhook{ID: 1, IsActive: false})
+
+	setting.Webhook.DisableAfterFailures = 0
+	w = unittest.AssertExistsAndLoadBean(t, &webhook_model.Webhook{ID: 3}).(*webhook_model.Webhook)
+	assert.NoError(t, webhook_model.UpdateWebhookLastStatus(w.ID, webhook_model.HookStatusFail, true))
+	disabled, err = disableAfterFailures(w)
+	assert.NoError(t, err)
+	assert.False(t, disabled)
+	assert.True(t, w.IsActive)
+}
+
+func TestMaxAttempts(t *testing.T) {
+	oldMaxAttempts := setting.Webhook.MaxAttempts
+	setting.Webhook.MaxAttempts = 5
+	defer func() {
+		setting.Webhook.MaxAttempts = oldMaxAttempts
+	}()
+
+	assert.Equal(t, 5, maxAttempts(&webhook_model.Webhook{}))
+	assert.Equal(t, 2, maxAttempts(&webhook_model.Webhook{MaxAttempts: 2}))
+}
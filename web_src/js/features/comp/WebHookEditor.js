@@ -25,6 +25,18 @@ export function initCompWebHookEditor() {
     updateContentType();
   });
 
+  // Redeliver failed deliveries
+  $('.redeliver-button').on('click', function () {
+    const $this = $(this);
+    $this.addClass('loading disabled');
+    $.post($this.data('link'), {
+      _csrf: csrfToken,
+      id: $this.data('id'),
+    }).done((data) => {
+      window.location.href = data.redirect;
+    });
+  });
+
   // Test delivery
   $('#test-delivery').on('click', function () {
     const $this = $(this);
@@ -2,7 +2,6 @@ const $service = $('#service_type');
 const $user = $('#auth_username');
 const $pass = $('#auth_password');
 const $token = $('#auth_token');
-const $mirror = $('#mirror');
 const $lfs = $('#lfs');
 const $lfsSettings = $('#lfs_settings');
 const $lfsEndpoint = $('#lfs_endpoint');
@@ -15,7 +14,6 @@ export default function initRepoMigration() {
   $user.on('keyup', () => {checkItems(false)});
   $pass.on('keyup', () => {checkItems(false)});
   $token.on('keyup', () => {checkItems(true)});
-  $mirror.on('change', () => {checkItems(true)});
   $('#lfs_settings_show').on('click', () => { $lfsEndpoint.show(); return false });
   $lfs.on('change', setLFSSettingsVisibility);
 
@@ -42,11 +40,6 @@ function checkItems(tokenAuth) {
     enableItems = $user.val() !== '' || $pass.val() !== '';
   }
   if (enableItems && $service.val() > 1) {
-    if ($mirror.is(':checked')) {
-      $items.not('[name="wiki"]').attr('disabled', true);
-      $items.filter('[name="wiki"]').attr('disabled', false);
-      return;
-    }
     $items.attr('disabled', false);
   } else {
     $items.attr('disabled', true);
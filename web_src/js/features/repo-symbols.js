@@ -0,0 +1,71 @@
+import {htmlEscape} from 'escape-goat';
+
+const identifierRegex = /^[A-Za-z_$][\w$]*$/;
+
+function renderLocations($list, locations, showContent) {
+  if (!locations.length) {
+    $list.html(`<div class="item text grey">${htmlEscape($list.data('empty'))}</div>`);
+    return;
+  }
+  $list.html(locations.map((location) => {
+    let html = `<a class="item" href="${htmlEscape(location.url)}">`;
+    html += `<span class="mono">${htmlEscape(location.repo)}/${htmlEscape(location.path)}:${location.line}</span>`;
+    if (location.kind) {
+      html += ` <span class="text grey">${htmlEscape(location.kind)}</span>`;
+    }
+    if (showContent && location.content) {
+      html += `<code class="symbol-content">${htmlEscape(location.content)}</code>`;
+    }
+    return `${html}</a>`;
+  }).join(''));
+}
+
+export function initRepoSymbols() {
+  const $popup = $('.symbol-popup');
+  if (!$popup.length) return;
+
+  const url = $popup.data('url');
+  let name = '';
+  let scope = 'repo';
+  let $target = null;
+
+  const load = async () => {
+    $popup.find('.symbol-loading').removeClass('hide');
+    $popup.find('.symbol-results').addClass('hide');
+    const data = await $.get(url, {name, scope});
+    renderLocations($popup.find('.symbol-definitions'), data.definitions, false);
+    renderLocations($popup.find('.symbol-references'), data.references, true);
+    $popup.find('.symbol-loading').addClass('hide');
+    $popup.find('.symbol-results').removeClass('hide');
+    if ($target) $target.popup('reposition');
+  };
+
+  $popup.on('click', '[data-scope]', async function () {
+    scope = $(this).data('scope');
+    $popup.find('[data-scope]').removeClass('active');
+    $(this).addClass('active');
+    await load();
+  });
+
+  $(document).on('click', '.code-view.symbol-navigation .lines-code .code-inner span[class^="n"]', async function () {
+    // keep selecting text possible
+    if (window.getSelection().toString()) return;
+    const text = this.textContent.trim();
+    if (!identifierRegex.test(text)) return;
+
+    if ($target) $target.popup('destroy');
+    name = text;
+    $target = $(this);
+    $popup.find('.symbol-name').text(name);
+    $target.popup({
+      popup: $popup,
+      on: 'click',
+      position: 'bottom left',
+      lastResort: 'bottom left',
+      onHidden: () => {
+        $target = null;
+      },
+    }).popup('show');
+    await load();
+  });
+}
@@ -53,6 +53,7 @@ import {initAdminEmails} from './features/admin-emails.js';
 import {initAdminCommon} from './features/admin-common.js';
 import {initRepoTemplateSearch} from './features/repo-template.js';
 import {initRepoCodeView} from './features/repo-code.js';
+import {initRepoSymbols} from './features/repo-symbols.js';
 import {initSshKeyFormParser} from './features/sshkey-helper.js';
 import {initUserSettings} from './features/user-settings.js';
 import {initRepoArchiveLinks} from './features/repo-common.js';
@@ -131,6 +132,7 @@ $(document).ready(() => {
   initRepoArchiveLinks();
   initRepoBranchButton();
   initRepoCodeView();
+  initRepoSymbols();
   initRepoCommentForm();
   initRepoEllipsisButton();
   initRepoCommitLastCommitLoader();